@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// defaultAdminIdentity 未命名令牌（含沿用的 WS_AUTH_TOKEN）对应的审计身份
+const defaultAdminIdentity = "admin"
+
+// adminCredential 管理令牌及其审计身份
+type adminCredential struct {
+	identity string
+	token    []byte
+}
+
+// adminAuth 管理接口写操作的令牌校验（ADMIN_TOKEN，未配置时沿用 WS_AUTH_TOKEN）。
+// 未配置任何令牌时写操作一律拒绝；仅在显式 ADMIN_ALLOW_ANONYMOUS=true 时放行，审计身份记为 anonymous@<来源地址>
+type adminAuth struct {
+	credentials    []adminCredential
+	allowAnonymous bool
+}
+
+// newAdminAuth 解析 ADMIN_TOKEN 条目（"name:token" 或不带名字的 token）；entries 为空时以 fallback 作为唯一令牌，
+// allowAnonymous 仅在没有任何令牌时生效
+func newAdminAuth(entries []string, fallback string, allowAnonymous bool) *adminAuth {
+	a := &adminAuth{allowAnonymous: allowAnonymous}
+	for _, entry := range entries {
+		identity, token, named := strings.Cut(entry, ":")
+		if !named {
+			identity, token = defaultAdminIdentity, entry
+		}
+		if identity, token = strings.TrimSpace(identity), strings.TrimSpace(token); identity != "" && token != "" {
+			a.credentials = append(a.credentials, adminCredential{identity: identity, token: []byte(token)})
+		}
+	}
+	if len(a.credentials) == 0 && fallback != "" {
+		a.credentials = append(a.credentials, adminCredential{identity: defaultAdminIdentity, token: []byte(fallback)})
+	}
+	return a
+}
+
+// enabled 是否要求令牌
+func (a *adminAuth) enabled() bool {
+	return a != nil && len(a.credentials) > 0
+}
+
+// anonymous 未配置令牌且显式允许匿名写操作
+func (a *adminAuth) anonymous() bool {
+	return a != nil && !a.enabled() && a.allowAnonymous
+}
+
+// identify 校验 Authorization: Bearer 令牌并返回对应身份；逐条常量时间比较，不因匹配位置泄露耗时差异
+func (a *adminAuth) identify(r *http.Request) (string, bool) {
+	if a.anonymous() {
+		return "anonymous@" + r.RemoteAddr, true
+	}
+	if !a.enabled() {
+		return "", false
+	}
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		return "", false
+	}
+	identity := ""
+	for _, c := range a.credentials {
+		if subtle.ConstantTimeCompare([]byte(token), c.token) == 1 {
+			identity = c.identity
+		}
+	}
+	return identity, identity != ""
+}
+
+// authorizeAdmin 管理接口准入：只读请求直接放行（actor 为空），写操作要求管理令牌；
+// 未配置令牌（且未允许匿名）时写回 403，令牌缺失或错误时写回 401，通过时返回写入审计的身份
+func (s *Server) authorizeAdmin(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return "", true
+	}
+	s.mu.RLock()
+	auth := s.adminAuth
+	s.mu.RUnlock()
+	if !auth.enabled() && !auth.anonymous() {
+		slog.Warn("🚫 [Security] Admin write disabled: no ADMIN_TOKEN configured", "method", r.Method, "path", r.URL.Path, "remote", r.RemoteAddr)
+		http.Error(w, "admin writes disabled: set ADMIN_TOKEN (or ADMIN_ALLOW_ANONYMOUS=true)", http.StatusForbidden)
+		return "", false
+	}
+	actor, ok := auth.identify(r)
+	if !ok {
+		slog.Warn("🚫 [Security] Admin request rejected", "method", r.Method, "path", r.URL.Path, "remote", r.RemoteAddr)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return "", false
+	}
+	return actor, true
+}
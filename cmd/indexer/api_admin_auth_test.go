@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditStore 只记录审计身份的监控地址存储
+type auditStore struct {
+	actors []string
+}
+
+func (s *auditStore) SeedWatchedAddresses(context.Context, []string, string) (int64, error) {
+	return 0, nil
+}
+
+func (s *auditStore) ListWatchedAddresses(context.Context) ([]storage.WatchedAddressRow, error) {
+	return nil, nil
+}
+
+func (s *auditStore) AddWatchedAddress(_ context.Context, _ storage.WatchedAddressRow, actor string) (bool, error) {
+	s.actors = append(s.actors, actor)
+	return true, nil
+}
+
+func (s *auditStore) RemoveWatchedAddress(_ context.Context, _, actor string) (bool, error) {
+	s.actors = append(s.actors, actor)
+	return true, nil
+}
+
+func (s *auditStore) ListWatchlistAudit(context.Context, int) ([]storage.WatchlistAuditRow, error) {
+	return nil, nil
+}
+
+func TestNewAdminAuth(t *testing.T) {
+	request := func(token string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/api/admin/watchlist", nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		return r
+	}
+
+	a := newAdminAuth([]string{"alice:tok-a", "bob:tok-b", "bare", "nameless:", ":tokenless"}, "ws-token", true)
+	require.True(t, a.enabled())
+	for token, identity := range map[string]string{"tok-a": "alice", "tok-b": "bob", "bare": defaultAdminIdentity} {
+		got, ok := a.identify(request(token))
+		assert.True(t, ok, token)
+		assert.Equal(t, identity, got)
+	}
+	for _, token := range []string{"", "ws-token", "tok-", "tok-a ", "tokenless"} {
+		_, ok := a.identify(request(token))
+		assert.False(t, ok, "token %q", token)
+	}
+
+	// 未配置 ADMIN_TOKEN 时沿用 WS_AUTH_TOKEN
+	a = newAdminAuth(nil, "ws-token", false)
+	got, ok := a.identify(request("ws-token"))
+	assert.True(t, ok)
+	assert.Equal(t, defaultAdminIdentity, got)
+
+	// 均未配置：默认拒绝
+	a = newAdminAuth(nil, "", false)
+	assert.False(t, a.enabled())
+	assert.False(t, a.anonymous())
+	_, ok = a.identify(request(""))
+	assert.False(t, ok)
+
+	// 显式允许匿名：放行，身份记为来源地址
+	a = newAdminAuth(nil, "", true)
+	assert.True(t, a.anonymous())
+	got, ok = a.identify(request(""))
+	assert.True(t, ok)
+	assert.Equal(t, "anonymous@192.0.2.1:1234", got)
+}
+
+func TestAuthorizeAdmin_WatchlistAudit(t *testing.T) {
+	s := NewServer(nil, nil, "0", "test")
+	s.SetAdminAuth(newAdminAuth([]string{"alice:tok-a"}, "", false))
+	store := &auditStore{}
+	watchlist := engine.NewWatchlist(store, 0)
+	serve := func(method, target, token, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		if actor, ok := s.authorizeAdmin(w, r); ok {
+			handleWatchlist(w, r, watchlist, actor)
+		}
+		return w
+	}
+	const add = `{"address": "0x5FC8d32690cc91D4c39d9d3abcBD16989F875707", "label": "usdc"}`
+
+	w := serve(http.MethodPost, "/api/admin/watchlist", "", add)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+	w = serve(http.MethodPost, "/api/admin/watchlist", "wrong", add)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, store.actors, "rejected requests never reach the store")
+
+	w = serve(http.MethodPost, "/api/admin/watchlist", "tok-a", add)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, []string{"alice"}, store.actors, "audit records the token identity, not the remote address")
+
+	// 只读请求无需令牌
+	w = serve(http.MethodGet, "/api/admin/watchlist", "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAuthorizeAdmin_NoTokenFailsClosed(t *testing.T) {
+	store := &auditStore{}
+	watchlist := engine.NewWatchlist(store, 0)
+	serve := func(s *Server, method string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/api/admin/watchlist",
+			strings.NewReader(`{"address": "0x5FC8d32690cc91D4c39d9d3abcBD16989F875707"}`))
+		r.Header.Set("Authorization", "Bearer anything")
+		w := httptest.NewRecorder()
+		if actor, ok := s.authorizeAdmin(w, r); ok {
+			handleWatchlist(w, r, watchlist, actor)
+		}
+		return w
+	}
+
+	// 未调用 SetAdminAuth 与未配置任何令牌一样：写操作 403，只读照常
+	for _, s := range []*Server{NewServer(nil, nil, "0", "test"), withAdminAuth(newAdminAuth(nil, "", false))} {
+		assert.Equal(t, http.StatusForbidden, serve(s, http.MethodPost).Code)
+		assert.Equal(t, http.StatusForbidden, serve(s, http.MethodDelete).Code)
+		assert.Equal(t, http.StatusOK, serve(s, http.MethodGet).Code)
+	}
+	assert.Empty(t, store.actors, "no write reaches the store without a configured token")
+
+	// ADMIN_ALLOW_ANONYMOUS=true 显式放行
+	w := serve(withAdminAuth(newAdminAuth(nil, "", true)), http.MethodPost)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	require.Len(t, store.actors, 1)
+	assert.Equal(t, "anonymous@192.0.2.1:1234", store.actors[0])
+}
+
+func withAdminAuth(a *adminAuth) *Server {
+	s := NewServer(nil, nil, "0", "test")
+	s.SetAdminAuth(a)
+	return s
+}
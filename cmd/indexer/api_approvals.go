@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	defaultApprovals = 100
+	maxApprovals     = 1000
+)
+
+// approvalColumns approvals 的 API 投影（NULL 数值转为空串）
+const approvalColumns = `block_number::TEXT AS block_number, log_index, tx_hash, token_address, kind, owner, spender,
+	COALESCE(amount::TEXT, '') AS amount, COALESCE(token_id::TEXT, '') AS token_id, approved`
+
+// approvalKeyExpr 授权的覆盖范围：同一 key 的后一次事件覆盖前一次。
+// ERC-20 / ApprovalForAll 按 (代币, 被授权方)；ERC-721 单 token 授权按 (合集, tokenId)，新授权替换旧的被授权方
+const approvalKeyExpr = `token_address, kind, CASE WHEN kind = 'erc721' THEN token_id::TEXT ELSE spender END`
+
+// AddressApprovalsResponse /api/address/{address}/approvals 响应
+type AddressApprovalsResponse struct {
+	Owner     string                `json:"owner"`
+	History   bool                  `json:"history"`
+	Approvals []storage.ApprovalRow `json:"approvals"`
+}
+
+// handleGetAddressApprovals 返回地址作为 owner 的授权。默认只列出仍生效的授权（每个授权范围取最新一次事件，
+// 已撤销的不返回）；?history=true 返回原始事件流水（按区块倒序）。可按 ?token= / ?kind= 过滤。
+// 注意：ERC-721 单 token 授权在 token 转出时被隐式清除，该情况不产生 Approval 事件，这里无法反映
+func handleGetAddressApprovals(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
+	addr := r.PathValue("address")
+	if !common.IsHexAddress(addr) {
+		http.Error(w, "invalid address", http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	resp := AddressApprovalsResponse{
+		Owner:     strings.ToLower(common.HexToAddress(addr).Hex()),
+		History:   q.Get("history") == "true",
+		Approvals: []storage.ApprovalRow{},
+	}
+
+	args := []interface{}{resp.Owner}
+	conds := []string{"owner = $1"}
+	if v := q.Get("token"); v != "" {
+		if !common.IsHexAddress(v) {
+			http.Error(w, "invalid token address", http.StatusBadRequest)
+			return
+		}
+		args = append(args, strings.ToLower(common.HexToAddress(v).Hex()))
+		conds = append(conds, fmt.Sprintf("token_address = $%d", len(args)))
+	}
+	if v := q.Get("kind"); v != "" {
+		switch v {
+		case storage.ApprovalKindERC20, storage.ApprovalKindERC721, storage.ApprovalKindForAll:
+		default:
+			http.Error(w, "invalid kind (want erc20, erc721 or for_all)", http.StatusBadRequest)
+			return
+		}
+		args = append(args, v)
+		conds = append(conds, fmt.Sprintf("kind = $%d", len(args)))
+	}
+	where := strings.Join(conds, " AND ")
+	args = append(args, parseLimit(r, defaultApprovals, maxApprovals))
+	limit := fmt.Sprintf("$%d", len(args))
+
+	var query string
+	if resp.History {
+		query = `SELECT ` + approvalColumns + ` FROM approvals WHERE ` + where +
+			` ORDER BY block_number DESC, log_index DESC LIMIT ` + limit
+	} else {
+		query = activeApprovalsQuery(where, "", limit)
+	}
+
+	if err := engine.TimedSelect(r.Context(), db, "api_address_approvals", &resp.Approvals, query, args...); err != nil {
+		http.Error(w, "Failed to load approvals", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("failed_to_encode_approvals", "err", err)
+	}
+}
+
+// activeApprovalsQuery 仍生效的授权：每个授权范围（approvalKeyExpr）取满足 where 的最新一次事件，去掉已撤销的；
+// having 为对最新事件的附加过滤（作用于 approvalColumns 投影，可为空）。按区块倒序返回最多 limit 条
+func activeApprovalsQuery(where, having, limit string) string {
+	if having != "" {
+		having = " AND " + having
+	}
+	return `SELECT * FROM (
+			SELECT DISTINCT ON (` + approvalKeyExpr + `) ` + approvalColumns + `
+			FROM approvals WHERE ` + where + `
+			ORDER BY ` + approvalKeyExpr + `, approvals.block_number DESC, approvals.log_index DESC
+		) latest
+		WHERE approved` + having + `
+		ORDER BY block_number::NUMERIC DESC, log_index DESC LIMIT ` + limit
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+
+	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/jmoiron/sqlx"
+)
+
+// maxApprovalsReport 单次报告最多评估的无限授权数（超出时 truncated=true）
+const maxApprovalsReport = 2000
+
+const (
+	approvalRiskFlagged   = "flagged"
+	approvalRiskUnlabeled = "unlabeled"
+)
+
+// ApprovalRisk 一条有风险的无限授权
+type ApprovalRisk struct {
+	storage.ApprovalRow
+	Symbol       string   `db:"symbol" json:"symbol,omitempty"`
+	SpenderLabel string   `db:"-" json:"spender_label,omitempty"`
+	Risk         string   `db:"-" json:"risk"`    // flagged | unlabeled
+	Reasons      []string `db:"-" json:"reasons"` // flagged_spender / unlabeled_spender / eoa_spender
+}
+
+// ApprovalsReportSummary 仍生效的无限授权按被授权方分类的计数
+type ApprovalsReportSummary struct {
+	Unlimited   int `json:"unlimited"`
+	Labeled     int `json:"labeled"`
+	Unlabeled   int `json:"unlabeled"`
+	Flagged     int `json:"flagged"`
+	EOASpenders int `json:"eoa_spenders"`
+}
+
+// AddressApprovalsReport /api/address/{address}/approvals-report 响应
+type AddressApprovalsReport struct {
+	Owner     string                 `json:"owner"`
+	Summary   ApprovalsReportSummary `json:"summary"`
+	AtRisk    []ApprovalRisk         `json:"at_risk"`
+	Truncated bool                   `json:"truncated"`
+}
+
+// handleGetApprovalsReport 钱包授权体检：列出地址仍生效的无限授权（ERC-20 额度 ≥ 2^255 与 ApprovalForAll）中
+// 被授权方未打标签或在 APPROVAL_FLAGGED_SPENDERS 中的那些，标记为 flagged 的排在前面。
+// 被授权方是 EOA（无字节码）时附加 eoa_spender 原因：正常协议通过合约使用授权，授权给个人地址常见于钓鱼
+func handleGetApprovalsReport(w http.ResponseWriter, r *http.Request, db *sqlx.DB, flaggedSpenders []string) {
+	addr := r.PathValue("address")
+	if !common.IsHexAddress(addr) {
+		http.Error(w, "invalid address", http.StatusBadRequest)
+		return
+	}
+	report := AddressApprovalsReport{
+		Owner:  strings.ToLower(common.HexToAddress(addr).Hex()),
+		AtRisk: []ApprovalRisk{},
+	}
+
+	query := `SELECT a.*, COALESCE(m.symbol, '') AS symbol FROM (` +
+		activeApprovalsQuery(`owner = $1 AND kind IN ('erc20', 'for_all')`,
+			`(kind = 'for_all' OR NULLIF(amount, '')::NUMERIC >= $2::NUMERIC)`, "$3") + `
+		) a LEFT JOIN token_metadata m ON m.address = a.token_address
+		ORDER BY a.block_number::NUMERIC DESC, a.log_index DESC`
+	var rows []ApprovalRisk
+	if err := engine.TimedSelect(r.Context(), db, "api_approvals_report", &rows, query,
+		report.Owner, engine.UnlimitedAllowance.String(), maxApprovalsReport+1); err != nil {
+		http.Error(w, "Failed to load approvals", 500)
+		return
+	}
+	if len(rows) > maxApprovalsReport {
+		rows, report.Truncated = rows[:maxApprovalsReport], true
+	}
+
+	flagged := make(map[string]bool, len(flaggedSpenders))
+	for _, a := range flaggedSpenders {
+		flagged[strings.ToLower(a)] = true
+	}
+	// 字节码查询失败（无 RPC / 超时）时不判定 EOA，其他分类不受影响
+	spenders := make([]common.Address, len(rows))
+	for i, row := range rows {
+		spenders[i] = common.HexToAddress(row.Spender)
+	}
+	isContract, err := engine.GetCodeCache().Lookup(r.Context(), spenders)
+	if err != nil {
+		slog.Warn("approvals_report_code_lookup_failed", "owner", report.Owner, "err", err)
+		isContract = nil
+	}
+
+	for i, row := range rows {
+		if !engine.IsUnlimitedApproval(row.Kind, row.Amount) {
+			continue
+		}
+		report.Summary.Unlimited++
+		row.SpenderLabel = engine.GetSpenderLabel(row.Spender)
+		row.Reasons = []string{}
+		if flagged[row.Spender] {
+			row.Risk = approvalRiskFlagged
+			row.Reasons = append(row.Reasons, "flagged_spender")
+			report.Summary.Flagged++
+		}
+		if row.SpenderLabel == "" {
+			if row.Risk == "" {
+				row.Risk = approvalRiskUnlabeled
+			}
+			row.Reasons = append(row.Reasons, "unlabeled_spender")
+			report.Summary.Unlabeled++
+		} else {
+			report.Summary.Labeled++
+		}
+		if contract, known := isContract[spenders[i]]; known && !contract {
+			row.Reasons = append(row.Reasons, "eoa_spender")
+			report.Summary.EOASpenders++
+		}
+		if row.Risk != "" {
+			report.AtRisk = append(report.AtRisk, row)
+		}
+	}
+	// 查询结果已按区块倒序，稳定排序只把 flagged 提前
+	sort.SliceStable(report.AtRisk, func(i, j int) bool {
+		return report.AtRisk[i].Risk == approvalRiskFlagged && report.AtRisk[j].Risk != approvalRiskFlagged
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.Error("failed_to_encode_approvals_report", "err", err)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// maxBlockRangeSpan /api/blocks/range 单次最多返回的区块数
+const maxBlockRangeSpan = 200
+
+// BlockSummary 区块及其交易/转账摘要（浏览器视图一次拉取，无需逐块请求）
+type BlockSummary struct {
+	Number           models.Height   `db:"number" json:"number"`
+	Hash             string          `db:"hash" json:"hash"`
+	ParentHash       string          `db:"parent_hash" json:"parent_hash"`
+	Timestamp        int64           `db:"timestamp" json:"timestamp"`
+	TransactionCount int             `db:"transaction_count" json:"transaction_count"`
+	TransferCount    int             `db:"transfer_count" json:"transfer_count"`
+	TokenCount       int             `db:"token_count" json:"token_count"`
+	GasUsed          int64           `db:"gas_used" json:"gas_used"`
+	GasLimit         int64           `db:"gas_limit" json:"gas_limit"`
+	GasUtilization   float64         `db:"-" json:"gas_utilization"`
+	BaseFeePerGas    *models.Uint256 `db:"base_fee_per_gas" json:"base_fee_per_gas,omitempty"`
+	// 以下字段来自 receipts 表，仅在 FETCH_RECEIPTS 开启后索引的区块上存在
+	FeesPaid  *models.Uint256 `db:"fees_paid" json:"fees_paid,omitempty"` // 区块内交易实际手续费合计（wei）
+	FailedTxs *int            `db:"failed_txs" json:"failed_txs,omitempty"`
+	// EIP-4844：Dencun 之前的区块不返回
+	BlobGasUsed   *int64 `db:"blob_gas_used" json:"blob_gas_used,omitempty"`
+	ExcessBlobGas *int64 `db:"excess_blob_gas" json:"excess_blob_gas,omitempty"`
+	BlobTxs       int    `db:"blob_txs" json:"blob_txs"`
+	Blobs         int    `db:"blobs" json:"blobs"`
+}
+
+// parseBlockRange 解析 from/to；to 缺省时取 from 起最大跨度，超出上限时截断
+func parseBlockRange(r *http.Request) (from, to uint64, truncated bool, err error) {
+	q := r.URL.Query()
+	from, err = strconv.ParseUint(q.Get("from"), 10, 64)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("invalid or missing 'from' block number")
+	}
+	to = from + maxBlockRangeSpan - 1
+	if v := q.Get("to"); v != "" {
+		if to, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return 0, 0, false, fmt.Errorf("invalid 'to' block number")
+		}
+	}
+	if to < from {
+		return 0, 0, false, fmt.Errorf("'to' must not be less than 'from'")
+	}
+	if to-from >= maxBlockRangeSpan {
+		to = from + maxBlockRangeSpan - 1
+		truncated = true
+	}
+	return from, to, truncated, nil
+}
+
+// handleGetBlocksRange 返回 [from, to] 区块及每块转账数、代币数与 gas 统计（单条查询）
+func handleGetBlocksRange(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
+	from, to, truncated, err := parseBlockRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	blocks, err := queryBlockSummaries(r.Context(), db, from, to)
+	if err != nil {
+		slog.Error("failed_to_query_block_range", "err", err, "from", from, "to", to)
+		http.Error(w, "Failed to retrieve blocks", 500)
+		return
+	}
+
+	var totalGas int64
+	var totalTransfers int
+	for _, b := range blocks {
+		totalGas += b.GasUsed
+		totalTransfers += b.TransferCount
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":            from,
+		"to":              to,
+		"truncated":       truncated,
+		"max_span":        maxBlockRangeSpan,
+		"blocks":          blocks,
+		"total_gas_used":  totalGas,
+		"total_transfers": totalTransfers,
+	}); err != nil {
+		slog.Error("failed_to_encode_block_range", "err", err)
+	}
+}
+
+// queryBlockSummaries 读取 [from, to] 区块摘要（新区块在前），并填充 gas 利用率
+func queryBlockSummaries(ctx context.Context, db *sqlx.DB, from, to uint64) ([]BlockSummary, error) {
+	blocks := []BlockSummary{}
+	err := engine.TimedSelect(ctx, db, "api_blocks_range", &blocks, `
+		SELECT b.number::TEXT AS number, b.hash, b.parent_hash, b.timestamp,
+			COALESCE(b.transaction_count, 0) AS transaction_count,
+			COALESCE(b.gas_used, 0) AS gas_used, COALESCE(b.gas_limit, 0) AS gas_limit,
+			b.base_fee_per_gas::TEXT AS base_fee_per_gas,
+			COALESCE(t.transfer_count, 0) AS transfer_count,
+			COALESCE(t.token_count, 0) AS token_count,
+			rc.fees_paid::TEXT AS fees_paid, rc.failed_txs,
+			b.blob_gas_used, b.excess_blob_gas,
+			COALESCE(bt.blob_txs, 0) AS blob_txs, COALESCE(bt.blobs, 0) AS blobs
+		FROM blocks b
+		LEFT JOIN (
+			SELECT block_number, COUNT(*) AS transfer_count, COUNT(DISTINCT token_address) AS token_count
+			FROM transfers
+			WHERE block_number >= $1::NUMERIC AND block_number <= $2::NUMERIC
+			GROUP BY block_number
+		) t ON t.block_number = b.number
+		LEFT JOIN (
+			SELECT block_number, SUM(fee) AS fees_paid, COUNT(*) FILTER (WHERE status = 0)::INT AS failed_txs
+			FROM receipts
+			WHERE block_number >= $1::NUMERIC AND block_number <= $2::NUMERIC
+			GROUP BY block_number
+		) rc ON rc.block_number = b.number
+		LEFT JOIN (
+			SELECT block_number, COUNT(*)::INT AS blob_txs, SUM(blob_count)::INT AS blobs
+			FROM blob_transactions
+			WHERE block_number >= $1::NUMERIC AND block_number <= $2::NUMERIC
+			GROUP BY block_number
+		) bt ON bt.block_number = b.number
+		WHERE b.number >= $1::NUMERIC AND b.number <= $2::NUMERIC
+		ORDER BY b.number DESC`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for i := range blocks {
+		b := &blocks[i]
+		if b.GasLimit > 0 {
+			b.GasUtilization = float64(b.GasUsed) / float64(b.GasLimit)
+		}
+	}
+	return blocks, nil
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"web3-indexer-go/internal/engine"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	defaultFeeHistoryBlocks = 20
+	maxFeeHistoryBlocks     = 1024 // 与 geth eth_feeHistory 的单次上限一致
+	maxFeeHistoryPercentile = 100
+)
+
+// FeeHistory /api/fees/history 响应：字段与 eth_feeHistory 一致（数值为十六进制），
+// baseFeePerGas 比区块数多一项（按 EIP-1559 规则推算的下一块 baseFee）。
+// burntFees / priorityFees 为扩展字段：每块销毁的 baseFee × gasUsed 与小费总额
+type FeeHistory struct {
+	OldestBlock   string     `json:"oldestBlock"`
+	BaseFeePerGas []string   `json:"baseFeePerGas"`
+	GasUsedRatio  []float64  `json:"gasUsedRatio"`
+	Reward        [][]string `json:"reward,omitempty"`
+	BurntFees     []string   `json:"burntFees"`
+	PriorityFees  []string   `json:"priorityFees"`
+	Estimated     bool       `json:"estimated"` // 有区块在未抓取回执时索引，小费按 Gas Limit 加权估算
+}
+
+type feeHistoryRow struct {
+	Number       uint64         `db:"number"`
+	GasUsed      uint64         `db:"gas_used"`
+	GasLimit     uint64         `db:"gas_limit"`
+	BaseFee      sql.NullString `db:"base_fee_per_gas"`
+	PriorityFees sql.NullString `db:"priority_fees"`
+	Rewards      sql.NullString `db:"reward_percentiles"`
+	Estimated    bool           `db:"estimated"`
+}
+
+// parseFeeHistoryParams 解析 blockCount、newestBlock（latest / 十进制 / 0x 十六进制）与 rewardPercentiles（逗号分隔，单调不减，0–100）
+func parseFeeHistoryParams(r *http.Request) (count uint64, newest string, percentiles []float64, err error) {
+	q := r.URL.Query()
+	count = defaultFeeHistoryBlocks
+	if v := q.Get("blockCount"); v != "" {
+		if count, err = parseQuantity(v); err != nil || count == 0 {
+			return 0, "", nil, fmt.Errorf("invalid 'blockCount'")
+		}
+		count = min(count, maxFeeHistoryBlocks)
+	}
+	newest = strings.ToLower(q.Get("newestBlock"))
+	if newest == "" || newest == "pending" {
+		newest = "latest"
+	}
+	if newest != "latest" {
+		n, perr := parseQuantity(newest)
+		if perr != nil {
+			return 0, "", nil, fmt.Errorf("invalid 'newestBlock'")
+		}
+		newest = strconv.FormatUint(n, 10)
+	}
+	if v := q.Get("rewardPercentiles"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			p, perr := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if perr != nil || p < 0 || p > maxFeeHistoryPercentile {
+				return 0, "", nil, fmt.Errorf("invalid reward percentile %q", part)
+			}
+			if len(percentiles) > 0 && p < percentiles[len(percentiles)-1] {
+				return 0, "", nil, fmt.Errorf("reward percentiles must be in non-decreasing order")
+			}
+			percentiles = append(percentiles, p)
+		}
+	}
+	return count, newest, percentiles, nil
+}
+
+// parseQuantity 十进制或 0x 十六进制数
+func parseQuantity(v string) (uint64, error) {
+	if strings.HasPrefix(v, "0x") {
+		return hexutil.DecodeUint64(v)
+	}
+	return strconv.ParseUint(v, 10, 64)
+}
+
+// handleGetFeeHistory eth_feeHistory 风格的费用历史，数据来自 blocks 与 block_fees。
+// 小费分位由落库的 0, 5, …, 100 分位插值得到；未写入费用统计的区块（该功能上线前索引）小费记为 0。
+// 区间内有未索引的区块时只返回以 newestBlock 结尾的连续部分
+func handleGetFeeHistory(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
+	count, newest, percentiles, err := parseFeeHistoryParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rows, err := queryFeeHistory(r.Context(), db, count, newest)
+	if err != nil {
+		slog.Error("failed_to_query_fee_history", "err", err)
+		http.Error(w, "Failed to retrieve fee history", 500)
+		return
+	}
+	history, err := buildFeeHistory(rows, percentiles)
+	if err != nil {
+		slog.Error("failed_to_build_fee_history", "err", err)
+		http.Error(w, "Failed to retrieve fee history", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		slog.Error("failed_to_encode_fee_history", "err", err)
+	}
+}
+
+func queryFeeHistory(ctx context.Context, db *sqlx.DB, count uint64, newest string) ([]feeHistoryRow, error) {
+	if newest == "latest" {
+		var head sql.NullString
+		if err := engine.TimedGet(ctx, db, "api_fee_history_head", &head, "SELECT MAX(number)::TEXT FROM blocks"); err != nil {
+			return nil, err
+		}
+		if !head.Valid {
+			return nil, nil
+		}
+		newest = head.String
+	}
+	n, err := strconv.ParseUint(newest, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	from := uint64(0)
+	if n+1 > count {
+		from = n + 1 - count
+	}
+
+	rows := []feeHistoryRow{}
+	err = engine.TimedSelect(ctx, db, "api_fee_history", &rows, `
+		SELECT b.number::BIGINT AS number,
+			COALESCE(b.gas_used, 0) AS gas_used, COALESCE(b.gas_limit, 0) AS gas_limit,
+			b.base_fee_per_gas::TEXT AS base_fee_per_gas,
+			f.priority_fees::TEXT AS priority_fees, f.reward_percentiles::TEXT AS reward_percentiles,
+			COALESCE(f.estimated, FALSE) AS estimated
+		FROM blocks b LEFT JOIN block_fees f ON f.block_number = b.number
+		WHERE b.number >= $1::NUMERIC AND b.number <= $2::NUMERIC
+		ORDER BY b.number`, from, n)
+	return rows, err
+}
+
+// buildFeeHistory 按升序区块行组装响应；区间内有缺块时只保留结尾的连续部分
+func buildFeeHistory(rows []feeHistoryRow, percentiles []float64) (FeeHistory, error) {
+	for i := len(rows) - 1; i > 0; i-- {
+		if rows[i-1].Number+1 != rows[i].Number {
+			rows = rows[i:]
+			break
+		}
+	}
+	history := FeeHistory{
+		BaseFeePerGas: []string{},
+		GasUsedRatio:  []float64{},
+		BurntFees:     []string{},
+		PriorityFees:  []string{},
+	}
+	if len(rows) == 0 {
+		history.OldestBlock = hexutil.EncodeUint64(0)
+		return history, nil
+	}
+	history.OldestBlock = hexutil.EncodeUint64(rows[0].Number)
+	if len(percentiles) > 0 {
+		history.Reward = make([][]string, 0, len(rows))
+	}
+
+	var baseFee *big.Int
+	for _, row := range rows {
+		baseFee = parseBig(row.BaseFee)
+		ratio := 0.0
+		if row.GasLimit > 0 {
+			ratio = float64(row.GasUsed) / float64(row.GasLimit)
+		}
+		history.BaseFeePerGas = append(history.BaseFeePerGas, hexutil.EncodeBig(baseFee))
+		history.GasUsedRatio = append(history.GasUsedRatio, ratio)
+		burnt := new(big.Int).Mul(baseFee, new(big.Int).SetUint64(row.GasUsed))
+		history.BurntFees = append(history.BurntFees, hexutil.EncodeBig(burnt))
+		history.PriorityFees = append(history.PriorityFees, hexutil.EncodeBig(parseBig(row.PriorityFees)))
+		history.Estimated = history.Estimated || row.Estimated
+
+		if len(percentiles) == 0 {
+			continue
+		}
+		var points []*big.Int
+		if row.Rewards.Valid {
+			var values []string
+			if err := json.Unmarshal([]byte(row.Rewards.String), &values); err != nil {
+				return FeeHistory{}, fmt.Errorf("block %d reward percentiles: %w", row.Number, err)
+			}
+			for _, v := range values {
+				points = append(points, parseBig(sql.NullString{String: v, Valid: true}))
+			}
+		}
+		rewards := make([]string, len(percentiles))
+		for i, p := range percentiles {
+			rewards[i] = hexutil.EncodeBig(engine.InterpolateReward(points, p))
+		}
+		history.Reward = append(history.Reward, rewards)
+	}
+	last := rows[len(rows)-1]
+	history.BaseFeePerGas = append(history.BaseFeePerGas,
+		hexutil.EncodeBig(engine.NextBaseFee(baseFee, last.GasUsed, last.GasLimit)))
+	return history, nil
+}
+
+// parseBig 十进制 NUMERIC 文本转 *big.Int（NULL 或非法值为 0）
+func parseBig(v sql.NullString) *big.Int {
+	n := new(big.Int)
+	if v.Valid {
+		if _, ok := n.SetString(v.String, 10); !ok {
+			n.SetInt64(0)
+		}
+	}
+	return n
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// timeRange 基于区块时间戳的查询窗口（Unix 秒，闭区间）
+type timeRange struct {
+	From int64
+	To   int64
+	Set  bool // 请求中是否显式携带 from_ts/to_ts
+}
+
+// blockSpan 时间窗口对应的区块号区间；Empty 表示窗口内没有已索引区块（From / To 为 nil）
+type blockSpan struct {
+	From   *models.Height
+	To     *models.Height
+	Blocks int64
+	Empty  bool
+}
+
+// parseTimestamp 接受 Unix 秒或 RFC3339
+func parseTimestamp(v string) (int64, error) {
+	if ts, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return ts, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q (want unix seconds or RFC3339)", v)
+	}
+	return t.Unix(), nil
+}
+
+// parseTimeRange 解析 from_ts / to_ts；未提供的一端分别取 0 与当前时间
+func parseTimeRange(r *http.Request) (timeRange, error) {
+	tr := timeRange{From: 0, To: time.Now().Unix()}
+	q := r.URL.Query()
+	if v := q.Get("from_ts"); v != "" {
+		ts, err := parseTimestamp(v)
+		if err != nil {
+			return tr, err
+		}
+		tr.From, tr.Set = ts, true
+	}
+	if v := q.Get("to_ts"); v != "" {
+		ts, err := parseTimestamp(v)
+		if err != nil {
+			return tr, err
+		}
+		tr.To, tr.Set = ts, true
+	}
+	if tr.From > tr.To {
+		return tr, fmt.Errorf("from_ts must not be after to_ts")
+	}
+	return tr, nil
+}
+
+// resolveBlockSpan 先经 blocks(timestamp) 索引把时间窗口换算为区块号区间，
+// 后续 transfers 查询即可复用 block_number 索引，避免按时间 JOIN 全表
+func resolveBlockSpan(ctx context.Context, db *sqlx.DB, tr timeRange) (blockSpan, error) {
+	var row struct {
+		From   *models.Height `db:"from_block"`
+		To     *models.Height `db:"to_block"`
+		Blocks int64          `db:"blocks"`
+	}
+	err := engine.TimedGet(ctx, db, "api_block_span", &row, `
+		SELECT MIN(number)::TEXT AS from_block, MAX(number)::TEXT AS to_block, COUNT(*) AS blocks
+		FROM blocks WHERE timestamp >= $1 AND timestamp <= $2`, tr.From, tr.To)
+	if err != nil {
+		return blockSpan{}, err
+	}
+	if row.From == nil || row.To == nil {
+		return blockSpan{Empty: true}, nil
+	}
+	return blockSpan{From: row.From, To: row.To, Blocks: row.Blocks}, nil
+}
+
+// parseLimit 解析 limit 参数并限制在 [1, maxLimit]
+func parseLimit(r *http.Request, def, maxLimit int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || v <= 0 {
+		return def
+	}
+	return min(v, maxLimit)
+}
@@ -7,33 +7,67 @@ import (
 	"log/slog"
 	"math/big"
 	"net/http"
+	"strconv"
 	"time"
 
+	"web3-indexer-go/internal/database"
 	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/jmoiron/sqlx"
 )
 
 // REST Models
 type Block struct {
-	ProcessedAt string `db:"processed_at" json:"processed_at"`
-	Number      string `db:"number" json:"number"`
-	Hash        string `db:"hash" json:"hash"`
-	ParentHash  string `db:"parent_hash" json:"parent_hash"`
-	Timestamp   string `db:"timestamp" json:"timestamp"`
+	ProcessedAt string        `db:"processed_at" json:"processed_at"`
+	Number      models.Height `db:"number" json:"number"`
+	Hash        string        `db:"hash" json:"hash"`
+	ParentHash  string        `db:"parent_hash" json:"parent_hash"`
+	Timestamp   string        `db:"timestamp" json:"timestamp"`
 }
 
 type Transfer struct {
-	ID           int    `db:"id" json:"id"`
-	BlockNumber  string `db:"block_number" json:"block_number"`
-	TxHash       string `db:"tx_hash" json:"tx_hash"`
-	LogIndex     int    `db:"log_index" json:"log_index"`
-	FromAddress  string `db:"from_address" json:"from_address"`
-	ToAddress    string `db:"to_address" json:"to_address"`
-	Amount       string `db:"amount" json:"amount"`
-	TokenAddress string `db:"token_address" json:"token_address"`
-	Symbol       string `db:"symbol" json:"symbol"`
-	Type         string `db:"activity_type" json:"type"`
+	storage.TransferRow
+
+	// 按 token_metadata.decimals 归一化后的金额（服务端计算，前端无需处理 uint256）
+	AmountNormalized string   `db:"-" json:"amount_normalized"`
+	AmountUSD        *float64 `db:"-" json:"amount_usd,omitempty"`
+}
+
+// toAPIBlocks 转换为 REST 区块（processed_at 仅保留时分秒毫秒）
+func toAPIBlocks(rows []storage.BlockRow) []Block {
+	blocks := make([]Block, len(rows))
+	for i, b := range rows {
+		blocks[i] = Block{
+			Number:      b.Number,
+			Hash:        b.Hash,
+			ParentHash:  b.ParentHash,
+			Timestamp:   b.Timestamp,
+			ProcessedAt: b.ProcessedAt.Format("15:04:05.000"),
+		}
+	}
+	return blocks
+}
+
+// toAPITransfers 转换为 REST 转账并填充归一化金额
+func toAPITransfers(rows []storage.TransferRow) []Transfer {
+	transfers := make([]Transfer, len(rows))
+	for i, row := range rows {
+		transfers[i] = Transfer{TransferRow: row}
+	}
+	normalizeAmounts(transfers)
+	return transfers
+}
+
+// normalizeAmounts 填充归一化金额与可选的美元估值
+func normalizeAmounts(transfers []Transfer) {
+	for i := range transfers {
+		t := &transfers[i]
+		t.AmountNormalized = engine.FormatTokenAmount(t.Amount.String(), t.Decimals)
+		t.AmountUSD = engine.TokenAmountUSD(t.TokenAddress, t.AmountNormalized)
+	}
 }
 
 type DebugSnapshot struct {
@@ -43,30 +77,12 @@ type DebugSnapshot struct {
 }
 
 func handleGetBlocks(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
-	type dbBlock struct {
-		ProcessedAt time.Time `db:"processed_at"`
-		Number      string    `db:"number"`
-		Hash        string    `db:"hash"`
-		ParentHash  string    `db:"parent_hash"`
-		Timestamp   string    `db:"timestamp"`
-	}
-	var rawBlocks []dbBlock
-	err := db.SelectContext(r.Context(), &rawBlocks, `SELECT number, hash, parent_hash, timestamp, processed_at FROM blocks ORDER BY number DESC LIMIT 10`)
+	rows, err := engine.NewStore(db).GetLatestBlocks(r.Context(), 10)
 	if err != nil {
 		http.Error(w, "Failed to retrieve blocks", 500)
 		return
 	}
-
-	blocks := make([]Block, len(rawBlocks))
-	for i, b := range rawBlocks {
-		blocks[i] = Block{
-			Number:      b.Number,
-			Hash:        b.Hash,
-			ParentHash:  b.ParentHash,
-			Timestamp:   b.Timestamp,
-			ProcessedAt: b.ProcessedAt.Format("15:04:05.000"),
-		}
-	}
+	blocks := toAPIBlocks(rows)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]interface{}{"blocks": blocks}); err != nil {
@@ -74,36 +90,80 @@ func handleGetBlocks(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
 	}
 }
 
+// handleGetTransfers 最近转账：?type=SWAP,DEPLOY 按活动类型过滤，?from_ts/to_ts 限定区块时间窗口
 func handleGetTransfers(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
-	var transfers []Transfer
-	err := db.SelectContext(r.Context(), &transfers, "SELECT id, block_number, tx_hash, log_index, from_address, to_address, amount, token_address, symbol, activity_type FROM transfers ORDER BY block_number DESC, log_index DESC LIMIT 10")
+	tr, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if tr.Set {
+		handleGetTransfersInRange(w, r, db, tr)
+		return
+	}
+
+	rows, err := engine.NewStore(db).GetLatestTransfers(r.Context(), parseLimit(r, 10, 500), engine.ParseActivityTypes(r.URL.Query().Get("type"))...)
 	if err != nil {
 		http.Error(w, "Failed to retrieve transfers", 500)
 		return
 	}
+	transfers := toAPITransfers(rows)
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]interface{}{"transfers": transfers}); err != nil {
 		slog.Error("failed_to_encode_transfers", "err", err)
 	}
 }
 
+// handleGetTransfersInRange 按区块时间窗口（from_ts/to_ts）查询转账
+func handleGetTransfersInRange(w http.ResponseWriter, r *http.Request, db *sqlx.DB, tr timeRange) {
+	span, err := resolveBlockSpan(r.Context(), db, tr)
+	if err != nil {
+		http.Error(w, "Failed to resolve time range", 500)
+		return
+	}
+
+	transfers := []Transfer{}
+	if !span.Empty {
+		rows, err := engine.NewStore(db).GetTransfersInRange(r.Context(), span.From.String(), span.To.String(), parseLimit(r, 10, 500), engine.ParseActivityTypes(r.URL.Query().Get("type"))...)
+		if err != nil {
+			http.Error(w, "Failed to retrieve transfers", 500)
+			return
+		}
+		transfers = toAPITransfers(rows)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"transfers":  transfers,
+		"from_ts":    tr.From,
+		"to_ts":      tr.To,
+		"from_block": span.From,
+		"to_block":   span.To,
+	}); err != nil {
+		slog.Error("failed_to_encode_transfers", "err", err)
+	}
+}
+
 func handleGetTransfersFromHotBuffer(w http.ResponseWriter, processor *engine.Processor) {
 	hotTransfers := processor.GetHotBuffer().GetLatest(10)
 	apiTransfers := make([]Transfer, len(hotTransfers))
 	for i, t := range hotTransfers {
 		// #nosec G115 - LogIndex is within safe range for int
-		apiTransfers[i] = Transfer{
-			BlockNumber:  t.BlockNumber.String(),
+		apiTransfers[i] = Transfer{TransferRow: storage.TransferRow{
+			BlockNumber:  models.NewHeight(t.BlockNumber.Int),
 			TxHash:       t.TxHash,
 			LogIndex:     int(t.LogIndex),
 			FromAddress:  t.From,
 			ToAddress:    t.To,
-			Amount:       t.Amount.String(),
+			Amount:       t.Amount,
 			TokenAddress: t.TokenAddress,
 			Symbol:       t.Symbol,
 			Type:         t.Type,
-		}
+			Origin:       t.Origin,
+			Decimals:     processor.GetDecimals(common.HexToAddress(t.TokenAddress)),
+		}}
 	}
+	normalizeAmounts(apiTransfers)
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]interface{}{
 		"transfers": apiTransfers,
@@ -136,13 +196,12 @@ func handleInitialStatus(w http.ResponseWriter, title string) {
 	}
 }
 
-func handleGetStatus(w http.ResponseWriter, r *http.Request, db *sqlx.DB, _ engine.RPCClient, lazyManager *engine.LazyManager, chainID int64, signer *engine.SignerMachine) {
+func handleGetStatus(w http.ResponseWriter, r *http.Request, lazyManager *engine.LazyManager, signer *engine.SignerMachine) {
 	if lazyManager != nil {
 		lazyManager.Trigger()
 	}
 
-	orchestrator := engine.GetOrchestrator()
-	status := orchestrator.GetUIStatus(r.Context(), db, Version)
+	status := engine.GetStatusService().Status(r.Context())
 
 	if signer != nil {
 		if signed, err := signer.Sign("status", status); err == nil {
@@ -182,7 +241,7 @@ func handleGetDebugSnapshot(w http.ResponseWriter, r *http.Request, db *sqlx.DB,
 
 	// 2. Data Integrity
 	latestRPCNum := uint64(0)
-	if latestRPC, err := rpcPool.GetLatestBlockNumber(r.Context()); err == nil && latestRPC != nil {
+	if latestRPC, err := engine.LatestChainHead(r.Context(), rpcPool); err == nil && latestRPC != nil {
 		latestRPCNum = latestRPC.Uint64()
 	}
 
@@ -194,31 +253,18 @@ func handleGetDebugSnapshot(w http.ResponseWriter, r *http.Request, db *sqlx.DB,
 	}
 
 	// 3. Recent Data Samples
-	var rawBlocks []struct {
-		Number      string    `db:"number"`
-		Hash        string    `db:"hash"`
-		ParentHash  string    `db:"parent_hash"`
-		Timestamp   string    `db:"timestamp"`
-		ProcessedAt time.Time `db:"processed_at"`
-	}
-	if err := db.SelectContext(r.Context(), &rawBlocks, "SELECT number, hash, parent_hash, timestamp, processed_at FROM blocks ORDER BY number DESC LIMIT 5"); err != nil {
+	store := engine.NewStore(db)
+	rawBlocks, err := store.GetLatestBlocks(r.Context(), 5)
+	if err != nil {
 		slog.Warn("failed_to_select_recent_blocks", "err", err)
 	}
-	recentBlocks := make([]Block, 0, len(rawBlocks))
-	for _, b := range rawBlocks {
-		recentBlocks = append(recentBlocks, Block{
-			Number:      b.Number,
-			Hash:        b.Hash,
-			ParentHash:  b.ParentHash,
-			Timestamp:   b.Timestamp,
-			ProcessedAt: b.ProcessedAt.Format("15:04:05.000"),
-		})
-	}
+	recentBlocks := toAPIBlocks(rawBlocks)
 
-	var recentTransfers []Transfer
-	if err := db.SelectContext(r.Context(), &recentTransfers, "SELECT block_number, tx_hash, from_address, to_address, amount, token_address, symbol, activity_type FROM transfers ORDER BY block_number DESC, log_index DESC LIMIT 5"); err != nil {
+	rawTransfers, err := store.GetLatestTransfers(r.Context(), 5)
+	if err != nil {
 		slog.Warn("failed_to_select_recent_transfers", "err", err)
 	}
+	recentTransfers := toAPITransfers(rawTransfers)
 
 	recentDataSamples := map[string]interface{}{
 		"blocks_count":    len(recentBlocks),
@@ -240,16 +286,16 @@ func handleGetDebugSnapshot(w http.ResponseWriter, r *http.Request, db *sqlx.DB,
 }
 
 func getLatestIndexedBlock(ctx context.Context, db *sqlx.DB) string {
-	var latest string
-	if err := db.GetContext(ctx, &latest, "SELECT COALESCE(MAX(number), '0') FROM blocks"); err != nil {
+	latest, err := engine.NewStore(db).GetMaxStoredBlock(ctx)
+	if err != nil {
 		return "0"
 	}
-	return latest
+	return strconv.FormatInt(latest, 10)
 }
 
 func getCount(ctx context.Context, db *sqlx.DB, query string) int64 {
 	var count int64
-	if err := db.GetContext(ctx, &count, query); err != nil {
+	if err := engine.TimedGet(ctx, db, "api_count", &count, query); err != nil {
 		return 0
 	}
 	return count
@@ -270,19 +316,291 @@ func calculateLatency(ctx context.Context, db *sqlx.DB, latestChain, latestIndex
 		return "0s", 0
 	}
 	syncLag := latestChain - latestIndexed
+	profile := engine.GetChainProfile(cfg.ChainID)
 	if syncLag > 100 {
-		estLatency := float64(syncLag) * 12
+		estLatency := profile.EstimateLatency(syncLag).Seconds()
 		return fmt.Sprintf("Catching up... (%d blocks behind)", syncLag), estLatency
 	}
-	var processedAt time.Time
-	err := db.GetContext(ctx, &processedAt, "SELECT processed_at FROM blocks WHERE number = $1", latestIndexedStr)
+	processedAt, err := engine.NewStore(db).GetBlockProcessedAt(ctx, latestIndexedStr)
 	if err == nil && !processedAt.IsZero() {
 		latency := time.Since(processedAt).Seconds()
 		return fmt.Sprintf("%.2fs", latency), latency
 	}
-	return fmt.Sprintf("%.2fs", float64(syncLag)*12), float64(syncLag) * 12
+	estLatency := profile.EstimateLatency(syncLag).Seconds()
+	return fmt.Sprintf("%.2fs", estLatency), estLatency
 }
 
 func calculateTPS(_ context.Context, _ *sqlx.DB) float64 {
 	return engine.GetMetrics().GetWindowTPS()
 }
+
+func handleGetProviderIncidents(w http.ResponseWriter, rpcPool engine.RPCClient) {
+	incidents := []engine.ProviderIncident{}
+	if reporter, ok := rpcPool.(interface {
+		ProviderIncidents() []engine.ProviderIncident
+	}); ok {
+		incidents = reporter.ProviderIncidents()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"incidents": incidents}); err != nil {
+		slog.Error("failed_to_encode_provider_incidents", "err", err)
+	}
+}
+
+// handleGetScheduleJobs 列出调度/补洞区间进度（from, to, fetched, committed, errors）
+func handleGetScheduleJobs(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(engine.GetOrchestrator().ScheduleJobs()); err != nil {
+		slog.Error("failed_to_encode_schedule_jobs", "err", err)
+	}
+}
+
+// handleGetBlockTrace 重建区块在 Fetcher→Sequencer→Processor→AsyncWriter 中的流转链路
+func handleGetBlockTrace(w http.ResponseWriter, r *http.Request) {
+	block, err := strconv.ParseUint(r.PathValue("block"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid block number", http.StatusBadRequest)
+		return
+	}
+
+	journeys := engine.BlockTrace(block)
+	if len(journeys) == 0 {
+		http.Error(w, "no trace for block (not seen yet or evicted from ring buffer)", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"block":    block,
+		"attempts": len(journeys),
+		"journeys": journeys,
+	}); err != nil {
+		slog.Error("failed_to_encode_block_trace", "err", err)
+	}
+}
+
+// handleGetIndexReport 返回各索引大小、扫描次数与未使用索引汇总，用于发现冗余索引
+func handleGetIndexReport(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
+	report, err := database.QueryIndexReport(r.Context(), db)
+	if err != nil {
+		slog.Error("failed_to_query_index_report", "err", err)
+		http.Error(w, "Failed to retrieve index report", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.Error("failed_to_encode_index_report", "err", err)
+	}
+}
+
+// handleGetSanityReport 一次返回数据质量不变量检查结果；?window=N 只检查最近 N 个块的转账与父哈希
+func handleGetSanityReport(w http.ResponseWriter, r *http.Request, db *sqlx.DB, chainID int64) {
+	var window int64
+	if raw := r.URL.Query().Get("window"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, "window must be a non-negative block count", http.StatusBadRequest)
+			return
+		}
+		window = n
+	}
+	report, err := database.QuerySanityReport(r.Context(), db, chainID, window)
+	if err != nil {
+		slog.Error("failed_to_query_sanity_report", "err", err)
+		http.Error(w, "Failed to run sanity checks", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.Error("failed_to_encode_sanity_report", "err", err)
+	}
+}
+
+// MigrationReport /api/admin/db/migrations 响应
+type MigrationReport struct {
+	Mode  string                         `json:"mode"`
+	Steps []database.MigrationStepStatus `json:"steps"`
+}
+
+// handleGetMigrations 返回在线表结构迁移各步骤的状态（blocking 模式下步骤已在启动时完成，列表为空）
+func handleGetMigrations(w http.ResponseWriter, migrator *database.OnlineMigrator) {
+	report := MigrationReport{Mode: cfg.SchemaMigrationMode, Steps: []database.MigrationStepStatus{}}
+	if migrator != nil {
+		report.Steps = migrator.Status()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.Error("failed_to_encode_migration_report", "err", err)
+	}
+}
+
+// handleGetDecimalBackfill normalized_amount 回填进度；未启用时 enabled=false
+func handleGetDecimalBackfill(w http.ResponseWriter, backfiller *engine.DecimalBackfiller) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(backfiller.Status()); err != nil {
+		slog.Error("failed_to_encode_decimal_backfill_status", "err", err)
+	}
+}
+
+// spamGuardOf 处理器尚未就绪或未启用垃圾代币检测时返回 nil
+func spamGuardOf(processor *engine.Processor) *engine.SpamGuard {
+	if processor == nil {
+		return nil
+	}
+	return processor.SpamGuard()
+}
+
+// handleListSpamTokens 列出被启发式判定为垃圾或带管理员覆盖的代币
+func handleListSpamTokens(w http.ResponseWriter, processor *engine.Processor) {
+	guard := spamGuardOf(processor)
+	if guard == nil {
+		http.Error(w, "spam detection not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"tokens": guard.List()}); err != nil {
+		slog.Error("failed_to_encode_spam_tokens", "err", err)
+	}
+}
+
+// handleTokenSpamOverride 查看 (GET) / 设置 (PUT {"spam": bool}) / 清除 (DELETE) 单个代币的垃圾判定覆盖；actor 为操作者身份
+func handleTokenSpamOverride(w http.ResponseWriter, r *http.Request, processor *engine.Processor, actor string) {
+	guard := spamGuardOf(processor)
+	if guard == nil {
+		http.Error(w, "spam detection not initialized", http.StatusServiceUnavailable)
+		return
+	}
+	addr := r.PathValue("address")
+	if !common.IsHexAddress(addr) {
+		http.Error(w, "invalid token address", http.StatusBadRequest)
+		return
+	}
+	token := common.HexToAddress(addr)
+
+	var status engine.SpamToken
+	switch r.Method {
+	case http.MethodGet:
+		status = guard.Status(token)
+	case http.MethodPut, http.MethodDelete:
+		var override *bool
+		if r.Method == http.MethodPut {
+			var body struct {
+				Spam *bool `json:"spam"`
+			}
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&body); err != nil || body.Spam == nil {
+				http.Error(w, `body must be {"spam": true|false}`, http.StatusBadRequest)
+				return
+			}
+			override = body.Spam
+		}
+		var err error
+		if status, err = guard.SetOverride(r.Context(), token, override); err != nil {
+			slog.Error("failed_to_set_spam_override", "token", addr, "err", err)
+			http.Error(w, "failed to persist override", http.StatusInternalServerError)
+			return
+		}
+		slog.Info("🗑️ spam_override_updated", "token", status.Address, "cleared", override == nil, "muted", status.Muted, "actor", actor, "remote_addr", r.RemoteAddr)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		slog.Error("failed_to_encode_spam_status", "err", err)
+	}
+}
+
+// watchlistAuditLimit GET /api/admin/watchlist 附带的最近变更条数
+const watchlistAuditLimit = 50
+
+// WatchlistReport /api/admin/watchlist 响应
+type WatchlistReport struct {
+	Addresses []storage.WatchedAddressRow `json:"addresses"`
+	Audit     []storage.WatchlistAuditRow `json:"audit"`
+}
+
+// handleWatchlist 查看 (GET) 当前监控地址与最近变更 / 加入 (POST {"address", "label"}) 监控地址，立即对 Fetcher 与 Processor 生效；
+// actor 为操作者身份，记入审计
+func handleWatchlist(w http.ResponseWriter, r *http.Request, watchlist *engine.Watchlist, actor string) {
+	if watchlist == nil {
+		http.Error(w, "watchlist not initialized", http.StatusServiceUnavailable)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		audit, err := watchlist.Audit(r.Context(), watchlistAuditLimit)
+		if err != nil {
+			slog.Error("failed_to_list_watchlist_audit", "err", err)
+			http.Error(w, "Failed to retrieve watchlist audit", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(WatchlistReport{Addresses: watchlist.List(), Audit: audit}); err != nil {
+			slog.Error("failed_to_encode_watchlist", "err", err)
+		}
+	case http.MethodPost:
+		var body struct {
+			Address string `json:"address"`
+			Label   string `json:"label"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&body); err != nil {
+			http.Error(w, `body must be {"address": "0x…", "label": "…"}`, http.StatusBadRequest)
+			return
+		}
+		addr, err := engine.ParseWatchAddress(body.Address)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid address: %v", err), http.StatusBadRequest)
+			return
+		}
+		if len(body.Label) > 128 {
+			http.Error(w, "label must be at most 128 bytes", http.StatusBadRequest)
+			return
+		}
+		added, err := watchlist.Add(r.Context(), addr, body.Label, actor)
+		if err != nil {
+			slog.Error("failed_to_add_watched_address", "address", body.Address, "err", err)
+			http.Error(w, "failed to persist watchlist change", http.StatusInternalServerError)
+			return
+		}
+		if !added {
+			http.Error(w, "address already watched", http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(map[string]string{"address": addr.Hex(), "label": body.Label}); err != nil {
+			slog.Error("failed_to_encode_watchlist", "err", err)
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleRemoveWatchedAddress 移除 (DELETE) 监控地址，立即对 Fetcher 与 Processor 生效；actor 为操作者身份，记入审计
+func handleRemoveWatchedAddress(w http.ResponseWriter, r *http.Request, watchlist *engine.Watchlist, actor string) {
+	if watchlist == nil {
+		http.Error(w, "watchlist not initialized", http.StatusServiceUnavailable)
+		return
+	}
+	addr, err := engine.ParseWatchAddress(r.PathValue("address"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid address: %v", err), http.StatusBadRequest)
+		return
+	}
+	removed, err := watchlist.Remove(r.Context(), addr, actor)
+	if err != nil {
+		slog.Error("failed_to_remove_watched_address", "address", addr.Hex(), "err", err)
+		http.Error(w, "failed to persist watchlist change", http.StatusInternalServerError)
+		return
+	}
+	if !removed {
+		http.Error(w, "address not watched", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
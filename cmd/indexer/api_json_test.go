@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// maxUint256 2^256-1：超出 JS Number 精度，必须以字符串下发
+const maxUint256 = "115792089237316195423570786752725631957808125097254307843769590327386046463935"
+
+// roundTrip 编码后按通用 JSON 解码（检查字段类型），再解码回原类型
+func roundTrip[T any](t *testing.T, v T) (map[string]interface{}, T) {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	require.NoError(t, err)
+	var generic map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &generic))
+	var back T
+	require.NoError(t, json.Unmarshal(raw, &back))
+	return generic, back
+}
+
+func uint256Of(t *testing.T, s string) models.Uint256 {
+	t.Helper()
+	u, ok := models.NewUint256FromString(s)
+	require.True(t, ok, s)
+	return u
+}
+
+func TestRESTJSON_Block(t *testing.T) {
+	block := Block{Number: 21_000_000, Hash: "0xabc", ParentHash: "0xdef", Timestamp: "1700000000", ProcessedAt: "12:00:00.000"}
+	generic, back := roundTrip(t, block)
+	assert.Equal(t, float64(21_000_000), generic["number"], "height is a JSON number")
+	assert.Equal(t, block, back)
+
+	var legacy Block
+	require.NoError(t, json.Unmarshal([]byte(`{"number": "21000000"}`), &legacy))
+	assert.Equal(t, models.Height(21_000_000), legacy.Number, "string heights from older payloads still decode")
+}
+
+func TestRESTJSON_BlockSummary(t *testing.T) {
+	fee := uint256Of(t, maxUint256)
+	summary := BlockSummary{Number: 19_000_000, Hash: "0xabc", BaseFeePerGas: &fee, GasUsed: 15, GasLimit: 30}
+	generic, back := roundTrip(t, summary)
+	assert.Equal(t, float64(19_000_000), generic["number"])
+	assert.Equal(t, maxUint256, generic["base_fee_per_gas"], "amounts are decimal strings")
+	assert.NotContains(t, generic, "fees_paid", "absent receipts are omitted")
+	assert.Equal(t, summary.Number, back.Number)
+	require.NotNil(t, back.BaseFeePerGas)
+	assert.Equal(t, maxUint256, back.BaseFeePerGas.String())
+	assert.Nil(t, back.FeesPaid)
+}
+
+func TestRESTJSON_TokenSupply(t *testing.T) {
+	history := TokenSupplyHistory{
+		Token:       "0x5fc8d32690cc91d4c39d9d3abcbd16989f875707",
+		TotalMinted: uint256Of(t, maxUint256),
+		TotalBurned: models.NewUint256(7),
+		Supply:      models.BigInt{Int: big.NewInt(-7)},
+		Points: []SupplyPoint{{
+			BlockNumber: 42, Timestamp: 1700000000, Minted: uint256Of(t, maxUint256), Burned: models.NewUint256(0),
+			Mints: 1, Supply: models.BigInt{Int: big.NewInt(-7)},
+		}},
+	}
+	generic, back := roundTrip(t, history)
+	assert.Equal(t, maxUint256, generic["total_minted"])
+	assert.Equal(t, "7", generic["total_burned"])
+	assert.Equal(t, "-7", generic["supply"], "net supply may be negative")
+	point := generic["points"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, float64(42), point["block_number"])
+	assert.Equal(t, maxUint256, point["minted"])
+	assert.Equal(t, "0", point["burned"])
+
+	require.Len(t, back.Points, 1)
+	assert.Equal(t, models.Height(42), back.Points[0].BlockNumber)
+	assert.Equal(t, maxUint256, back.Points[0].Minted.String())
+	assert.Equal(t, "-7", back.Supply.String())
+	assert.Equal(t, history.TotalBurned.String(), back.TotalBurned.String())
+}
+
+func TestRESTJSON_Transfer(t *testing.T) {
+	transfers := toAPITransfers([]storage.TransferRow{{
+		BlockNumber:  123,
+		TxHash:       "0xfeed",
+		Amount:       uint256Of(t, "1500000000000000000"),
+		TokenAddress: "0x5fc8d32690cc91d4c39d9d3abcbd16989f875707",
+		Type:         "transfer",
+		Decimals:     18,
+	}})
+	generic, back := roundTrip(t, transfers[0])
+	assert.Equal(t, float64(123), generic["block_number"])
+	assert.Equal(t, "1500000000000000000", generic["amount"])
+	assert.Equal(t, "1.5", generic["amount_normalized"])
+	assert.Equal(t, models.Height(123), back.BlockNumber)
+	assert.Equal(t, "1500000000000000000", back.Amount.String())
+}
+
+func TestRESTJSON_AddressActivityOmitsUnknownBlocks(t *testing.T) {
+	generic, _ := roundTrip(t, AddressActivity{Address: "0xabc"})
+	assert.NotContains(t, generic, "first_block")
+	assert.NotContains(t, generic, "last_block")
+
+	first := models.Height(10)
+	generic, back := roundTrip(t, AddressActivity{Address: "0xabc", FirstBlock: &first, LastBlock: &first})
+	assert.Equal(t, float64(10), generic["first_block"])
+	require.NotNil(t, back.FirstBlock)
+	assert.Equal(t, first, *back.FirstBlock)
+}
+
+func TestRESTJSON_NetworkStatus(t *testing.T) {
+	status := NetworkStatus{ChainID: 1, Name: "mainnet", LatestBlock: statusHeight("21000005"), LatestIndexed: statusHeight("21000000"), SyncLag: 5}
+	generic, back := roundTrip(t, status)
+	assert.Equal(t, float64(21_000_005), generic["latest_block"], "heights are JSON numbers")
+	assert.Equal(t, float64(21_000_000), generic["latest_indexed"])
+	assert.Equal(t, status, back)
+	assert.Zero(t, statusHeight(""), "unknown heights encode as 0")
+}
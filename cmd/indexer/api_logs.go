@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"web3-indexer-go/internal/engine"
+)
+
+const (
+	defaultRecentLogs = 100
+	logStreamPing     = 15 * time.Second
+)
+
+// handleGetRecentLogs 返回环形缓冲中的最近日志：?limit=（默认 100）&level=（最低级别）&since=（只要序号更大的）
+func handleGetRecentLogs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := defaultRecentLogs
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	since, err := parseLogSeq(q.Get("since"))
+	if err != nil {
+		http.Error(w, "invalid since", http.StatusBadRequest)
+		return
+	}
+
+	logs := engine.GetLogStream().Recent(limit, q.Get("level"), since)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"logs": logs, "count": len(logs)}); err != nil {
+		slog.Error("failed_to_encode_recent_logs", "err", err)
+	}
+}
+
+// handleLogStream 以 SSE 推送实时日志（?level= 过滤最低级别）。
+// 重连时浏览器自动带上 Last-Event-ID（或显式 ?since=），先补发缓冲中更新的事件再转入实时推送。
+func handleLogStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	minLevel := r.URL.Query().Get("level")
+	resume := r.Header.Get("Last-Event-ID")
+	if resume == "" {
+		resume = r.URL.Query().Get("since")
+	}
+	lastSeq, err := parseLogSeq(resume)
+	if err != nil {
+		http.Error(w, "invalid since", http.StatusBadRequest)
+		return
+	}
+
+	// 长连接不受 http.Server WriteTimeout 限制
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		slog.Debug("log_stream_write_deadline_unsupported", "err", err)
+	}
+
+	stream := engine.GetLogStream()
+	events, cancel := stream.Subscribe(0)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// 先订阅再取缓冲，按序号去重，避免两者之间的事件丢失或重复
+	for _, ev := range stream.Recent(0, minLevel, lastSeq) {
+		if err := writeLogEvent(w, ev); err != nil {
+			return
+		}
+		lastSeq = ev.Seq
+	}
+	flusher.Flush()
+
+	ping := time.NewTicker(logStreamPing)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev := <-events:
+			if ev.Seq <= lastSeq || !engine.LogLevelAtLeast(ev.Level, minLevel) {
+				continue
+			}
+			if err := writeLogEvent(w, ev); err != nil {
+				return
+			}
+			lastSeq = ev.Seq
+			flusher.Flush()
+		case <-ping.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeLogEvent(w http.ResponseWriter, ev engine.LogEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		slog.Debug("failed_to_encode_log_event", "seq", ev.Seq, "err", err)
+		return nil
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: log\ndata: %s\n\n", ev.Seq, data)
+	return err
+}
+
+func parseLogSeq(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
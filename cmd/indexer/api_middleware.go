@@ -1,15 +1,20 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
 	"regexp"
+	"runtime/debug"
 	"strings"
 	"sync"
 	"time"
 
+	"web3-indexer-go/internal/engine"
+
 	"github.com/jmoiron/sqlx"
 )
 
@@ -131,3 +136,132 @@ func logVisitor(db *sqlx.DB, ip, ua, path string) {
 		time.Sleep(time.Millisecond * 100 * time.Duration(attempt+1))
 	}
 }
+
+// statusRecorder 记录响应状态码；透传 Hijacker/Flusher 以兼容 WebSocket 升级与流式响应
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	hijacked    bool
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	if !sr.wroteHeader {
+		sr.status = code
+		sr.wroteHeader = true
+	}
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if !sr.wroteHeader {
+		sr.status = http.StatusOK
+		sr.wroteHeader = true
+	}
+	return sr.ResponseWriter.Write(b)
+}
+
+func (sr *statusRecorder) Flush() {
+	if f, ok := sr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap 供 http.ResponseController 访问底层连接（如 SSE 解除写超时）
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+func (sr *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := sr.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	sr.hijacked = true
+	return h.Hijack()
+}
+
+// routeOf 返回请求命中的 mux 路由模式，用作指标标签以避免路径基数爆炸
+func routeOf(mux *http.ServeMux, r *http.Request) string {
+	if _, pattern := mux.Handler(r); pattern != "" {
+		return pattern
+	}
+	return "unmatched"
+}
+
+// RecoveryMiddleware 捕获 handler 中的 panic，记录堆栈并返回 500，避免单个请求拖垮连接
+func RecoveryMiddleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				route := routeOf(mux, r)
+				slog.Error("🔥 http_handler_panic",
+					"route", route,
+					"method", r.Method,
+					"path", r.URL.Path,
+					"panic", rec,
+					"stack", string(debug.Stack()))
+				engine.GetMetrics().HTTPPanics.WithLabelValues(route).Inc()
+
+				if sr, ok := w.(*statusRecorder); ok && (sr.wroteHeader || sr.hijacked) {
+					return
+				}
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequestLoggingMiddleware 记录每个请求的状态码与耗时，并按路由写入请求计数、延迟与 DB 耗时分布
+func RequestLoggingMiddleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sr := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		ctx, dbTime := engine.WithDBTimer(r.Context())
+		next.ServeHTTP(sr, r.WithContext(ctx))
+
+		route := routeOf(mux, r)
+		status := sr.status
+		if sr.hijacked {
+			status = http.StatusSwitchingProtocols
+		}
+		metrics := engine.GetMetrics()
+		metrics.RecordHTTPStatus(route, r.Method, status)
+
+		// WebSocket 等被劫持的长连接与 SSE 流不计入延迟分布
+		if sr.hijacked || sr.Header().Get("Content-Type") == "text/event-stream" {
+			return
+		}
+
+		duration := time.Since(start)
+		dbDuration := time.Duration(dbTime.Load())
+		metrics.RecordHTTPRequest(route, r.Method, sr.status, duration)
+		metrics.RecordHTTPRequestDB(route, dbDuration)
+
+		attrs := []any{
+			"route", route,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", sr.status,
+			"duration_ms", duration.Milliseconds(),
+			"db_ms", dbDuration.Milliseconds(),
+		}
+		if sr.status >= http.StatusInternalServerError {
+			slog.Warn("http_request", attrs...)
+		} else {
+			slog.Debug("http_request", attrs...)
+		}
+	})
+}
+
+// newMiddlewareStack 组装所有路由共用的中间件链：日志/指标 → panic 恢复 → 访客统计 → 链范围 → mux
+func newMiddlewareStack(mux *http.ServeMux, dbGetter func() *sqlx.DB, chains func() []int64) http.Handler {
+	return RequestLoggingMiddleware(mux,
+		RecoveryMiddleware(mux,
+			VisitorStatsMiddleware(dbGetter,
+				ChainScopeMiddleware(chains, mux))))
+}
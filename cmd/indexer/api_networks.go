@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/models"
+)
+
+// NetworkStatus /api/networks 中单条链的进度摘要
+type NetworkStatus struct {
+	ChainID          int64         `json:"chain_id"`
+	Name             string        `json:"name"`
+	NativeSymbol     string        `json:"native_symbol"`
+	Primary          bool          `json:"primary"` // 不带 ?chain= 的请求默认作用于主链
+	State            string        `json:"state"`
+	LatestBlock      models.Height `json:"latest_block"`
+	LatestIndexed    models.Height `json:"latest_indexed"`
+	SyncLag          int64         `json:"sync_lag"`
+	TPS              float64       `json:"tps"`
+	BPS              float64       `json:"bps"`
+	BlockTimeSeconds float64       `json:"block_time_seconds"`
+}
+
+// NetworksResponse /api/networks 响应
+type NetworksResponse struct {
+	Primary  int64           `json:"primary"`
+	Networks []NetworkStatus `json:"networks"`
+}
+
+// networks 本实例索引的链（首个为主链）。每个进程只索引一条链；多链模式下由 runMultiChain 汇总各链进程的结果
+func (s *Server) networks() []int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.chainID == 0 {
+		return nil
+	}
+	return []int64{s.chainID}
+}
+
+// handleGetNetworks 列出本实例索引的每条链及其链头、滞后与吞吐
+func handleGetNetworks(w http.ResponseWriter, r *http.Request, chainIDs []int64) {
+	resp := NetworksResponse{Networks: make([]NetworkStatus, 0, len(chainIDs))}
+	for i, id := range chainIDs {
+		// 单链进程的 StatusService 即该链的状态；多链模式下需按链取各自的状态源
+		status := engine.GetStatusService().Status(r.Context())
+		profile := engine.GetChainProfile(id)
+		if i == 0 {
+			resp.Primary = id
+		}
+		resp.Networks = append(resp.Networks, NetworkStatus{
+			ChainID:          id,
+			Name:             profile.Name,
+			NativeSymbol:     profile.NativeSymbol,
+			Primary:          i == 0,
+			State:            status.State,
+			LatestBlock:      statusHeight(status.LatestBlock),
+			LatestIndexed:    statusHeight(status.LatestIndexed),
+			SyncLag:          status.SyncLag,
+			TPS:              status.TPS,
+			BPS:              status.BPS,
+			BlockTimeSeconds: profile.BlockTime.Seconds(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("failed_to_encode_networks", "err", err)
+	}
+}
+
+// statusHeight 把 UIStatusDTO 的十进制字符串高度转为 models.Height（空串或无法解析时为 0）
+func statusHeight(s string) models.Height {
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return models.Height(n)
+}
+
+// parseChainParam 解析 ?chain=：十进制 chain id 或已注册的链名（大小写不敏感，如 "sepolia"）
+func parseChainParam(v string) (int64, bool) {
+	if id, err := strconv.ParseInt(v, 10, 64); err == nil && id > 0 {
+		return id, true
+	}
+	if p, ok := engine.ChainProfileByName(v); ok {
+		return p.ChainID, true
+	}
+	return 0, false
+}
+
+// ChainScopeMiddleware 按可选的 ?chain= 把 /api/ 请求限定到某条链：不带参数时作用于主链（向后兼容）；
+// 参数无法解析返回 400，指向本实例未索引的链返回 404，避免把主链数据当成其他链的数据返回
+func ChainScopeMiddleware(chains func() []int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := r.URL.Query().Get("chain")
+		if v == "" || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		id, ok := parseChainParam(v)
+		if !ok {
+			http.Error(w, "invalid chain (want a chain id or a known network name)", http.StatusBadRequest)
+			return
+		}
+		served := chains()
+		// 初始化完成前链 ID 尚未注入，交给各路由返回 503
+		if len(served) > 0 && !slices.Contains(served, id) {
+			http.Error(w, "chain "+strconv.FormatInt(id, 10)+" is not indexed by this instance (see /api/networks)", http.StatusNotFound)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	defaultNFTTransfers = 50
+	maxNFTTransfers     = 1000
+)
+
+// NFTTransfersResponse /api/nft/transfers 响应
+type NFTTransfersResponse struct {
+	Transfers []storage.NFTTransferRow `json:"transfers"`
+}
+
+// handleGetNFTTransfers 返回最近的 ERC-721 转账（按区块倒序），可按 ?collection= / ?token_id= / ?address=（发送方或接收方）过滤
+func handleGetNFTTransfers(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
+	q := r.URL.Query()
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if v := q.Get("collection"); v != "" {
+		if !common.IsHexAddress(v) {
+			http.Error(w, "invalid collection address", http.StatusBadRequest)
+			return
+		}
+		args = append(args, strings.ToLower(common.HexToAddress(v).Hex()))
+		conds = append(conds, fmt.Sprintf("collection = $%d", len(args)))
+	}
+	if v := q.Get("token_id"); v != "" {
+		id, ok := new(big.Int).SetString(v, 10)
+		if !ok || id.Sign() < 0 {
+			http.Error(w, "invalid token_id (want a decimal integer)", http.StatusBadRequest)
+			return
+		}
+		args = append(args, id.String())
+		conds = append(conds, fmt.Sprintf("token_id = $%d::NUMERIC", len(args)))
+	}
+	if v := q.Get("address"); v != "" {
+		if !common.IsHexAddress(v) {
+			http.Error(w, "invalid address", http.StatusBadRequest)
+			return
+		}
+		args = append(args, strings.ToLower(common.HexToAddress(v).Hex()))
+		conds = append(conds, fmt.Sprintf("(from_address = $%d OR to_address = $%d)", len(args), len(args)))
+	}
+
+	query := `SELECT block_number::TEXT AS block_number, log_index, tx_hash, collection, from_address, to_address, token_id::TEXT AS token_id
+		FROM nft_transfers`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	args = append(args, parseLimit(r, defaultNFTTransfers, maxNFTTransfers))
+	query += fmt.Sprintf(" ORDER BY block_number DESC, log_index DESC LIMIT $%d", len(args))
+
+	resp := NFTTransfersResponse{Transfers: []storage.NFTTransferRow{}}
+	if err := engine.TimedSelect(r.Context(), db, "api_nft_transfers", &resp.Transfers, query, args...); err != nil {
+		http.Error(w, "Failed to load NFT transfers", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("failed_to_encode_nft_transfers", "err", err)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"web3-indexer-go/internal/engine"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	defaultPending = 100
+	maxPending     = 1000
+)
+
+// PendingResponse /api/pending 响应
+type PendingResponse struct {
+	Enabled   bool                     `json:"enabled"` // 未启用 MEMPOOL_ENABLED 时为 false，transfers 恒为空
+	Total     int                      `json:"total"`   // 视图中的待打包转账总数（过滤前）
+	Transfers []engine.PendingTransfer `json:"transfers"`
+}
+
+// handleGetPending 返回尚未上链的 ERC-20 转账（最新的在前）。可按 ?token= 与 ?address=（from / to / sender 任一匹配）过滤；
+// 交易上链、被替换或超过 TTL 后即从视图移除，历史以 /api/transfers 为准
+func handleGetPending(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var token, addr string
+	if v := q.Get("token"); v != "" {
+		if !common.IsHexAddress(v) {
+			http.Error(w, "invalid token address", http.StatusBadRequest)
+			return
+		}
+		token = strings.ToLower(common.HexToAddress(v).Hex())
+	}
+	if v := q.Get("address"); v != "" {
+		if !common.IsHexAddress(v) {
+			http.Error(w, "invalid address", http.StatusBadRequest)
+			return
+		}
+		addr = strings.ToLower(common.HexToAddress(v).Hex())
+	}
+
+	mp := engine.GetMempool()
+	resp := PendingResponse{Enabled: mp.Enabled(), Transfers: []engine.PendingTransfer{}}
+	if resp.Enabled {
+		match := func(t engine.PendingTransfer) bool {
+			if token != "" && t.TokenAddress != token {
+				return false
+			}
+			return addr == "" || t.From == addr || t.To == addr || t.Sender == addr
+		}
+		resp.Transfers, resp.Total = mp.Pending(match, parseLimit(r, defaultPending, maxPending))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("failed_to_encode_pending", "err", err)
+	}
+}
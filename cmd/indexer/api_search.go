@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 搜索结果类型
+const (
+	searchTypeBlock       = "block"
+	searchTypeTransaction = "transaction"
+	searchTypeAddress     = "address"
+	searchTypeToken       = "token"
+)
+
+// searchRecentTransfers 地址/代币结果附带的最近转账条数
+const searchRecentTransfers = 10
+
+var (
+	addressPattern  = regexp.MustCompile(`^0x[0-9a-f]{40}$`)
+	hash32Pattern   = regexp.MustCompile(`^0x[0-9a-f]{64}$`)
+	hexBlockPattern = regexp.MustCompile(`^0x[0-9a-f]{1,16}$`)
+)
+
+// errNotFound 查询格式正确但没有匹配的实体
+var errNotFound = errors.New("not found")
+
+// AddressActivity 地址（或代币合约）的转账概况
+type AddressActivity struct {
+	Address    string         `db:"address" json:"address"`
+	Sent       int64          `db:"sent" json:"sent"`
+	Received   int64          `db:"received" json:"received"`
+	FirstBlock *models.Height `db:"first_block" json:"first_block,omitempty"`
+	LastBlock  *models.Height `db:"last_block" json:"last_block,omitempty"`
+	TxCount    int64          `db:"tx_count" json:"tx_count"`
+	IsContract *bool          `db:"is_contract" json:"is_contract,omitempty"`
+
+	// 仅代币合约
+	Symbol    string `db:"symbol" json:"symbol,omitempty"`
+	Decimals  *int   `db:"decimals" json:"decimals,omitempty"`
+	Transfers int64  `db:"token_transfers" json:"token_transfers,omitempty"`
+
+	Recent []Transfer `db:"-" json:"recent_transfers"`
+}
+
+// handleSearch /api/search?q= 自动识别区块号、区块哈希、交易哈希与地址，返回最匹配的实体
+func handleSearch(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
+	q := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	if q == "" {
+		http.Error(w, "missing 'q'", http.StatusBadRequest)
+		return
+	}
+
+	kind, result, err := search(r.Context(), db, q)
+	switch {
+	case errors.Is(err, errNotFound):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"query": q, "type": kind, "result": nil})
+		return
+	case err != nil && kind == "":
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		slog.Error("failed_to_search", "err", err, "q", q)
+		http.Error(w, "Search failed", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"query": q, "type": kind, "result": result}); err != nil {
+		slog.Error("failed_to_encode_search", "err", err)
+	}
+}
+
+// search 按输入形态分派查询；kind 为空表示输入无法识别
+func search(ctx context.Context, db *sqlx.DB, q string) (string, interface{}, error) {
+	switch {
+	case addressPattern.MatchString(q):
+		return searchAddress(ctx, db, q)
+	case hash32Pattern.MatchString(q):
+		// 32 字节哈希：交易优先，其次区块
+		if tx, err := searchTransaction(ctx, db, q); !errors.Is(err, errNotFound) {
+			return searchTypeTransaction, tx, err
+		}
+		var number uint64
+		err := engine.TimedGet(ctx, db, "api_search_block_hash", &number, "SELECT number FROM blocks WHERE hash = $1", q)
+		if errors.Is(err, sql.ErrNoRows) {
+			return searchTypeTransaction, nil, errNotFound
+		}
+		if err != nil {
+			return searchTypeBlock, nil, err
+		}
+		return searchBlock(ctx, db, number)
+	case hexBlockPattern.MatchString(q):
+		number, err := strconv.ParseUint(q[2:], 16, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid block number %q", q)
+		}
+		return searchBlock(ctx, db, number)
+	default:
+		number, err := strconv.ParseUint(q, 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("unrecognized query %q (want address, tx/block hash or block number)", q)
+		}
+		return searchBlock(ctx, db, number)
+	}
+}
+
+func searchBlock(ctx context.Context, db *sqlx.DB, number uint64) (string, interface{}, error) {
+	blocks, err := queryBlockSummaries(ctx, db, number, number)
+	if err != nil {
+		return searchTypeBlock, nil, err
+	}
+	if len(blocks) == 0 {
+		return searchTypeBlock, nil, errNotFound
+	}
+	return searchTypeBlock, blocks[0], nil
+}
+
+func searchTransaction(ctx context.Context, db *sqlx.DB, hash string) (interface{}, error) {
+	rows, err := engine.NewStore(db).GetTransfersByTx(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errNotFound
+	}
+	transfers := toAPITransfers(rows)
+	return map[string]interface{}{
+		"tx_hash":      hash,
+		"block_number": transfers[0].BlockNumber,
+		"transfers":    transfers,
+	}, nil
+}
+
+// searchAddress 代币合约返回 token 概况，普通地址返回收发概况；两者都附带最近转账。
+// 首次/最近出现区块与交易数取自 addresses 注册表，避免按地址扫描 transfers 求 MIN/MAX
+func searchAddress(ctx context.Context, db *sqlx.DB, addr string) (string, interface{}, error) {
+	activity := AddressActivity{Address: addr}
+	if err := engine.TimedGet(ctx, db, "api_search_address", &activity, `
+		SELECT $1 AS address,
+			(SELECT COUNT(*) FROM transfers WHERE from_address = $1) AS sent,
+			(SELECT COUNT(*) FROM transfers WHERE to_address = $1) AS received,
+			r.first_seen_block::TEXT AS first_block,
+			r.last_seen_block::TEXT AS last_block,
+			COALESCE(r.tx_count, 0) AS tx_count,
+			r.is_contract,
+			COALESCE((SELECT symbol FROM token_metadata WHERE address = $1), '') AS symbol,
+			(SELECT decimals FROM token_metadata WHERE address = $1) AS decimals,
+			(SELECT COUNT(*) FROM transfers WHERE token_address = $1) AS token_transfers
+		FROM (SELECT 1) one LEFT JOIN addresses r ON r.address = $1`, addr); err != nil {
+		return searchTypeAddress, nil, err
+	}
+
+	kind := searchTypeAddress
+	filter := "t.from_address = $1 OR t.to_address = $1"
+	if activity.Transfers > 0 || activity.Decimals != nil {
+		kind, filter = searchTypeToken, "t.token_address = $1"
+	} else if activity.Sent == 0 && activity.Received == 0 {
+		return kind, nil, errNotFound
+	}
+
+	activity.Recent = []Transfer{}
+	if err := engine.TimedSelect(ctx, db, "api_search_address_recent", &activity.Recent,
+		"SELECT "+storage.TransferColumns+" "+storage.TransferFrom+" WHERE "+filter+" ORDER BY t.block_number DESC, t.log_index DESC LIMIT $2",
+		addr, searchRecentTransfers); err != nil {
+		return kind, nil, err
+	}
+	normalizeAmounts(activity.Recent)
+	return kind, activity, nil
+}
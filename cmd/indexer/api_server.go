@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 	"sync"
 	"time"
 
+	"web3-indexer-go/internal/database"
 	"web3-indexer-go/internal/engine"
 	"web3-indexer-go/internal/web"
 
@@ -23,6 +25,11 @@ type Server struct {
 	lazyManager *engine.LazyManager
 	processor   *engine.Processor // 🚀 新增：用于访问 HotBuffer
 	signer      *engine.SignerMachine
+	health      *engine.HealthServer
+	migrator    *database.OnlineMigrator
+	decimals    *engine.DecimalBackfiller
+	watchlist   *engine.Watchlist
+	adminAuth   *adminAuth
 	chainID     int64
 	mu          sync.RWMutex
 	srv         *http.Server
@@ -47,9 +54,64 @@ func (s *Server) SetDependencies(db *sqlx.DB, rpcPool engine.RPCClient, lazyMana
 	s.lazyManager = lazyManager
 	s.processor = processor
 	s.chainID = chainID
+	engine.GetStatusService().Configure(db, rpcPool, chainID, Version)
 	slog.Info("💉 API Server dependencies injected")
 }
 
+// SetHealthServer 注入健康检查服务（/healthz 系列路由）
+func (s *Server) SetHealthServer(h *engine.HealthServer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health = h
+}
+
+// SetMigrator 注入在线表结构迁移器（/api/admin/db/migrations 展示其进度）
+func (s *Server) SetMigrator(m *database.OnlineMigrator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.migrator = m
+}
+
+// SetDecimalBackfiller 注入金额精度回填任务（/api/admin/backfill/decimals 展示其进度）
+func (s *Server) SetDecimalBackfiller(b *engine.DecimalBackfiller) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.decimals = b
+}
+
+// SetWatchlist 注入监控地址列表（/api/admin/watchlist 增删查）
+func (s *Server) SetWatchlist(w *engine.Watchlist) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watchlist = w
+}
+
+// SetAdminAuth 配置管理接口写操作的令牌校验（需在对外服务前调用）
+func (s *Server) SetAdminAuth(a *adminAuth) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.adminAuth = a
+}
+
+// SetEmulatorStatus 注入内置仿真器状态（/api/status 附带其运行状态）
+func (s *Server) SetEmulatorStatus(fn func() interface{}) {
+	engine.GetStatusService().SetEmulatorStatus(fn)
+}
+
+// withHealth 健康检查服务就绪前返回 503，便于负载均衡在初始化期间不导流
+func (s *Server) withHealth(handler func(*engine.HealthServer, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		h := s.health
+		s.mu.RUnlock()
+		if h == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handler(h, w, r)
+	}
+}
+
 func (s *Server) Start() error {
 	slog.Info("🚀 STARTING SERVER V2 - CONFIGURING ROUTES")
 	mux := http.NewServeMux()
@@ -69,6 +131,17 @@ func (s *Server) Start() error {
 		handleGetBlocks(w, r, db)
 	})
 
+	mux.HandleFunc("/api/blocks/range", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		db := s.db
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetBlocksRange(w, r, db)
+	})
+
 	mux.HandleFunc("/api/transfers", func(w http.ResponseWriter, r *http.Request) {
 		s.mu.RLock()
 		db := s.db
@@ -80,7 +153,10 @@ func (s *Server) Start() error {
 			return
 		}
 
-		if processor != nil && processor.GetHotBuffer() != nil && processor.GetHotBuffer().GetCount() > 0 {
+		// 带时间窗口或类型过滤的查询必须走 DB，HotBuffer 只保存最新数据
+		hasTimeFilter := r.URL.Query().Get("from_ts") != "" || r.URL.Query().Get("to_ts") != ""
+		hasTypeFilter := r.URL.Query().Get("type") != ""
+		if !hasTimeFilter && !hasTypeFilter && processor != nil && processor.GetHotBuffer() != nil && processor.GetHotBuffer().GetCount() > 0 {
 			handleGetTransfersFromHotBuffer(w, processor)
 			return
 		}
@@ -88,19 +164,189 @@ func (s *Server) Start() error {
 		handleGetTransfers(w, r, db)
 	})
 
-	mux.HandleFunc("/api/status", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/search", func(w http.ResponseWriter, r *http.Request) {
 		s.mu.RLock()
 		db := s.db
-		rpcPool := s.rpcPool
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleSearch(w, r, db)
+	})
+
+	mux.HandleFunc("/api/stats", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		db := s.db
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetStats(w, r, db)
+	})
+
+	mux.HandleFunc("/api/stats/daily", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		db := s.db
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetDailyStats(w, r, db)
+	})
+
+	mux.HandleFunc("/api/stats/types", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		db := s.db
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetTypeStats(w, r, db)
+	})
+
+	mux.HandleFunc("/api/stats/stablecoins", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		db, chainID := s.db, s.chainID
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetStablecoinFlows(w, r, db, chainID)
+	})
+
+	mux.HandleFunc("/api/tokens/{address}/supply", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		db := s.db
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetTokenSupply(w, r, db)
+	})
+
+	mux.HandleFunc("/api/nft/transfers", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		db := s.db
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetNFTTransfers(w, r, db)
+	})
+
+	mux.HandleFunc("/api/address/{address}/approvals", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		db := s.db
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetAddressApprovals(w, r, db)
+	})
+
+	mux.HandleFunc("/api/address/{address}/approvals-report", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		db := s.db
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetApprovalsReport(w, r, db, cfg.FlaggedSpenders)
+	})
+
+	mux.HandleFunc("/api/pending", handleGetPending)
+
+	mux.HandleFunc("/api/skipped-ranges", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		db := s.db
+		s.mu.RUnlock()
+		handleGetSkippedRanges(w, r, db)
+	})
+
+	mux.HandleFunc("/api/stats/new-addresses", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		db := s.db
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetNewAddressStats(w, r, db)
+	})
+
+	mux.HandleFunc("/api/stats/throughput", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		db := s.db
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetThroughput(w, r, db)
+	})
+
+	mux.HandleFunc("/api/stats/congestion", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		db := s.db
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetCongestion(w, r, db)
+	})
+
+	mux.HandleFunc("/api/fees/history", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		db := s.db
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetFeeHistory(w, r, db)
+	})
+
+	mux.HandleFunc("/api/stats/slo", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		db := s.db
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetSLO(w, r, db)
+	})
+
+	mux.HandleFunc("/api/status", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
 		lazyManager := s.lazyManager
-		chainID := s.chainID
 		s.mu.RUnlock()
 
-		if db == nil || rpcPool == nil {
+		if !engine.GetStatusService().Ready() {
 			handleInitialStatus(w, s.title)
 			return
 		}
-		handleGetStatus(w, r, db, rpcPool, lazyManager, chainID, s.signer)
+		handleGetStatus(w, r, lazyManager, s.signer)
+	})
+
+	mux.HandleFunc("/api/version", handleGetVersion)
+
+	mux.HandleFunc("/api/networks", func(w http.ResponseWriter, r *http.Request) {
+		if !engine.GetStatusService().Ready() {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetNetworks(w, r, s.networks())
 	})
 
 	mux.HandleFunc("/api/debug/snapshot", func(w http.ResponseWriter, r *http.Request) {
@@ -116,6 +362,169 @@ func (s *Server) Start() error {
 		handleGetDebugSnapshot(w, r, db, rpcPool)
 	})
 
+	mux.HandleFunc("/api/admin/providers/incidents", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		rpcPool := s.rpcPool
+		s.mu.RUnlock()
+
+		handleGetProviderIncidents(w, rpcPool)
+	})
+
+	mux.HandleFunc("/api/admin/jobs", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handleGetScheduleJobs(w)
+	})
+
+	mux.HandleFunc("/api/admin/trace/{block}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handleGetBlockTrace(w, r)
+	})
+
+	mux.HandleFunc("/api/admin/db/indexes", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.mu.RLock()
+		db := s.db
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetIndexReport(w, r, db)
+	})
+
+	mux.HandleFunc("/api/admin/sanity", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.mu.RLock()
+		db, chainID := s.db, s.chainID
+		s.mu.RUnlock()
+		if db == nil {
+			http.Error(w, "System Initializing...", 503)
+			return
+		}
+		handleGetSanityReport(w, r, db, chainID)
+	})
+
+	mux.HandleFunc("/api/admin/db/migrations", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.mu.RLock()
+		migrator := s.migrator
+		s.mu.RUnlock()
+		handleGetMigrations(w, migrator)
+	})
+
+	mux.HandleFunc("/api/admin/backfill/decimals", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.mu.RLock()
+		backfiller := s.decimals
+		s.mu.RUnlock()
+		handleGetDecimalBackfill(w, backfiller)
+	})
+
+	mux.HandleFunc("/api/logs/recent", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handleGetRecentLogs(w, r)
+	})
+
+	mux.HandleFunc("/api/logs/stream", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handleLogStream(w, r)
+	})
+
+	mux.HandleFunc("/api/admin/tokens/spam", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.mu.RLock()
+		processor := s.processor
+		s.mu.RUnlock()
+		handleListSpamTokens(w, processor)
+	})
+
+	// 🔐 以下管理接口的写操作需管理令牌，审计记录令牌对应的身份
+	mux.HandleFunc("/api/admin/tokens/{address}/spam", func(w http.ResponseWriter, r *http.Request) {
+		actor, ok := s.authorizeAdmin(w, r)
+		if !ok {
+			return
+		}
+		s.mu.RLock()
+		processor := s.processor
+		s.mu.RUnlock()
+		handleTokenSpamOverride(w, r, processor, actor)
+	})
+
+	mux.HandleFunc("/api/admin/watchlist", func(w http.ResponseWriter, r *http.Request) {
+		actor, ok := s.authorizeAdmin(w, r)
+		if !ok {
+			return
+		}
+		s.mu.RLock()
+		watchlist := s.watchlist
+		s.mu.RUnlock()
+		handleWatchlist(w, r, watchlist, actor)
+	})
+
+	mux.HandleFunc("/api/admin/watchlist/{address}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		actor, ok := s.authorizeAdmin(w, r)
+		if !ok {
+			return
+		}
+		s.mu.RLock()
+		watchlist := s.watchlist
+		s.mu.RUnlock()
+		handleRemoveWatchedAddress(w, r, watchlist, actor)
+	})
+
+	mux.HandleFunc("/api/admin/enrich", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		actor, ok := s.authorizeAdmin(w, r)
+		if !ok {
+			return
+		}
+		s.mu.RLock()
+		processor := s.processor
+		s.mu.RUnlock()
+		handleEnrichTokens(w, r, processor, actor)
+	})
+
+	mux.HandleFunc("/healthz", s.withHealth((*engine.HealthServer).Healthz))
+	mux.HandleFunc("/healthz/ready", s.withHealth((*engine.HealthServer).Ready))
+	mux.HandleFunc("/healthz/live", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"alive"}`))
+	})
+
 	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
 		s.wsHub.HandleWS(w, r)
 	})
@@ -137,11 +546,11 @@ func (s *Server) Start() error {
 	s.mu.Lock()
 	s.srv = &http.Server{
 		Addr: ":" + s.port,
-		Handler: VisitorStatsMiddleware(func() *sqlx.DB {
+		Handler: newMiddlewareStack(mux, func() *sqlx.DB {
 			s.mu.RLock()
 			defer s.mu.RUnlock()
 			return s.db
-		}, mux),
+		}, s.networks),
 		ReadHeaderTimeout: 5 * time.Second,
 		ReadTimeout:       10 * time.Second,
 		WriteTimeout:      10 * time.Second,
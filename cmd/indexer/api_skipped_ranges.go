@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	defaultSkippedRanges = 100
+	maxSkippedRanges     = 1000
+)
+
+// SkippedRangesResponse /api/skipped-ranges 响应
+type SkippedRangesResponse struct {
+	Policy engine.GapSkipPolicy      `json:"policy"`
+	Source string                    `json:"source"` // db：skipped_ranges 表；memory：本进程最近记录（未落库或数据库未就绪）
+	Ranges []storage.SkippedRangeRow `json:"ranges"`
+}
+
+// handleGetSkippedRanges 返回未经索引即被跳过的区块区间（新的在前）与当前跳过策略。
+// GAP_RECORD_SKIPPED 开启且数据库可用时读 skipped_ranges，否则返回本进程内存中的最近记录
+func handleGetSkippedRanges(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
+	skipper := engine.GetGapSkipper()
+	resp := SkippedRangesResponse{Policy: skipper.Policy(), Source: "memory"}
+	limit := parseLimit(r, defaultSkippedRanges, maxSkippedRanges)
+
+	if db != nil && resp.Policy.RecordSkipped {
+		rows, err := engine.NewStore(db).ListSkippedRanges(r.Context(), limit)
+		if err != nil {
+			http.Error(w, "Failed to load skipped ranges", 500)
+			return
+		}
+		resp.Source, resp.Ranges = "db", rows
+	} else {
+		resp.Ranges = skipper.Recent(limit)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("failed_to_encode_skipped_ranges", "err", err)
+	}
+}
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// defaultStatsWindow 未指定 from_ts 时的默认统计窗口，避免对全表做 DISTINCT 聚合
+const defaultStatsWindow = 24 * time.Hour
+
+const (
+	defaultDailyStatsDays = 30
+	maxDailyStatsDays     = 365
+	dailyStatsCacheTTL    = time.Minute // 与 DailyAggregator 刷新周期一致
+)
+
+type dailyStatsEntry struct {
+	at   time.Time
+	body []byte
+}
+
+// dailyStatsCache 按 days 参数缓存已编码的响应
+var dailyStatsCache = struct {
+	sync.Mutex
+	entries map[int]dailyStatsEntry
+}{entries: make(map[int]dailyStatsEntry)}
+
+// WindowStats 时间窗口内的聚合统计
+type WindowStats struct {
+	FromTs          int64          `json:"from_ts"`
+	ToTs            int64          `json:"to_ts"`
+	FromBlock       *models.Height `json:"from_block,omitempty"`
+	ToBlock         *models.Height `json:"to_block,omitempty"`
+	Blocks          int64          `json:"blocks"`
+	Transfers       int64          `json:"transfers"`
+	UniqueAddresses int64          `json:"unique_addresses"`
+	UniqueTokens    int64          `json:"unique_tokens"`
+}
+
+// handleGetStats 返回 from_ts/to_ts 窗口内的区块、转账、地址与代币统计
+func handleGetStats(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
+	tr, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("from_ts") == "" {
+		tr.From = tr.To - int64(defaultStatsWindow.Seconds())
+	}
+
+	span, err := resolveBlockSpan(r.Context(), db, tr)
+	if err != nil {
+		http.Error(w, "Failed to resolve time range", 500)
+		return
+	}
+
+	stats := WindowStats{FromTs: tr.From, ToTs: tr.To, FromBlock: span.From, ToBlock: span.To, Blocks: span.Blocks}
+	if !span.Empty {
+		var row struct {
+			Transfers int64 `db:"transfers"`
+			Tokens    int64 `db:"tokens"`
+			Addresses int64 `db:"addresses"`
+		}
+		err = engine.TimedGet(r.Context(), db, "api_stats", &row, `
+			WITH t AS (
+				SELECT from_address, to_address, token_address FROM transfers
+				WHERE block_number >= $1::NUMERIC AND block_number <= $2::NUMERIC
+			)
+			SELECT
+				(SELECT COUNT(*) FROM t) AS transfers,
+				(SELECT COUNT(DISTINCT token_address) FROM t) AS tokens,
+				(SELECT COUNT(*) FROM (SELECT from_address FROM t UNION SELECT to_address FROM t) a) AS addresses`,
+			span.From, span.To)
+		if err != nil {
+			http.Error(w, "Failed to compute stats", 500)
+			return
+		}
+		stats.Transfers, stats.UniqueTokens, stats.UniqueAddresses = row.Transfers, row.Tokens, row.Addresses
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		slog.Error("failed_to_encode_stats", "err", err)
+	}
+}
+
+// TypeCount 单个活动类型的转账数
+type TypeCount struct {
+	Type  string `json:"type"`
+	Count int64  `json:"count"`
+}
+
+type typeCountRow struct {
+	Type  string `db:"activity_type"`
+	Count int64  `db:"count"`
+}
+
+// TypeStats 时间窗口内按活动类型的转账分布
+type TypeStats struct {
+	FromTs    int64          `json:"from_ts"`
+	ToTs      int64          `json:"to_ts"`
+	FromBlock *models.Height `json:"from_block,omitempty"`
+	ToBlock   *models.Height `json:"to_block,omitempty"`
+	Total     int64          `json:"total"`
+	Types     []TypeCount    `json:"types"`
+}
+
+// handleGetTypeStats 返回 from_ts/to_ts 窗口内各 activity_type 的转账数（按数量降序）。
+// 与 indexer_transaction_types_total 同源：二者都只统计已提交的 transfers 行。
+func handleGetTypeStats(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
+	tr, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("from_ts") == "" {
+		tr.From = tr.To - int64(defaultStatsWindow.Seconds())
+	}
+
+	span, err := resolveBlockSpan(r.Context(), db, tr)
+	if err != nil {
+		http.Error(w, "Failed to resolve time range", 500)
+		return
+	}
+
+	stats := TypeStats{FromTs: tr.From, ToTs: tr.To, FromBlock: span.From, ToBlock: span.To, Types: []TypeCount{}}
+	if !span.Empty {
+		var rows []typeCountRow
+		err = engine.TimedSelect(r.Context(), db, "api_stats_types", &rows, `
+			SELECT COALESCE(activity_type, '') AS activity_type, COUNT(*) AS count FROM transfers
+			WHERE block_number >= $1::NUMERIC AND block_number <= $2::NUMERIC
+			GROUP BY 1`,
+			span.From, span.To)
+		if err != nil {
+			http.Error(w, "Failed to compute type stats", 500)
+			return
+		}
+		stats.Types = mergeTypeCounts(rows)
+		for _, c := range stats.Types {
+			stats.Total += c.Count
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		slog.Error("failed_to_encode_type_stats", "err", err)
+	}
+}
+
+// mergeTypeCounts 按规范化后的类型合并（兼容规范化之前写入的小写 / 空值行），按数量降序
+func mergeTypeCounts(rows []typeCountRow) []TypeCount {
+	merged := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		merged[engine.NormalizeActivityType(row.Type)] += row.Count
+	}
+	out := make([]TypeCount, 0, len(merged))
+	for t, n := range merged {
+		out = append(out, TypeCount{Type: t, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Type < out[j].Type
+	})
+	return out
+}
+
+const (
+	defaultNewAddressLimit = 20
+	maxNewAddressLimit     = 500
+)
+
+// RegistryAddress addresses 注册表的一行
+type RegistryAddress struct {
+	Address        string        `db:"address" json:"address"`
+	FirstSeenBlock models.Height `db:"first_seen_block" json:"first_seen_block"`
+	LastSeenBlock  models.Height `db:"last_seen_block" json:"last_seen_block"`
+	TxCount        int64         `db:"tx_count" json:"tx_count"`
+	IsContract     *bool         `db:"is_contract" json:"is_contract"`
+}
+
+// NewAddressStats 时间窗口内首次出现的地址
+type NewAddressStats struct {
+	FromTs       int64             `json:"from_ts"`
+	ToTs         int64             `json:"to_ts"`
+	FromBlock    *models.Height    `json:"from_block,omitempty"`
+	ToBlock      *models.Height    `json:"to_block,omitempty"`
+	NewAddresses int64             `json:"new_addresses"`
+	NewContracts int64             `json:"new_contracts"`
+	Recent       []RegistryAddress `json:"recent"`
+}
+
+// handleGetNewAddressStats 返回 from_ts/to_ts 窗口内首次出现的地址数与最新的 ?limit= 个地址（来自 addresses 注册表）
+func handleGetNewAddressStats(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
+	tr, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("from_ts") == "" {
+		tr.From = tr.To - int64(defaultStatsWindow.Seconds())
+	}
+	limit := parseLimit(r, defaultNewAddressLimit, maxNewAddressLimit)
+
+	span, err := resolveBlockSpan(r.Context(), db, tr)
+	if err != nil {
+		http.Error(w, "Failed to resolve time range", 500)
+		return
+	}
+
+	stats := NewAddressStats{FromTs: tr.From, ToTs: tr.To, FromBlock: span.From, ToBlock: span.To, Recent: []RegistryAddress{}}
+	if !span.Empty {
+		var counts struct {
+			Addresses int64 `db:"addresses"`
+			Contracts int64 `db:"contracts"`
+		}
+		err = engine.TimedGet(r.Context(), db, "api_stats_new_addresses", &counts, `
+			SELECT COUNT(*) AS addresses, COUNT(*) FILTER (WHERE is_contract) AS contracts FROM addresses
+			WHERE first_seen_block >= $1::NUMERIC AND first_seen_block <= $2::NUMERIC`,
+			span.From, span.To)
+		if err != nil {
+			http.Error(w, "Failed to compute new address stats", 500)
+			return
+		}
+		stats.NewAddresses, stats.NewContracts = counts.Addresses, counts.Contracts
+
+		err = engine.TimedSelect(r.Context(), db, "api_stats_new_addresses_recent", &stats.Recent, `
+			SELECT address, first_seen_block::TEXT AS first_seen_block, last_seen_block::TEXT AS last_seen_block, tx_count, is_contract
+			FROM addresses
+			WHERE first_seen_block >= $1::NUMERIC AND first_seen_block <= $2::NUMERIC
+			ORDER BY first_seen_block DESC, address
+			LIMIT $3`,
+			span.From, span.To, limit)
+		if err != nil {
+			http.Error(w, "Failed to list new addresses", 500)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		slog.Error("failed_to_encode_new_address_stats", "err", err)
+	}
+}
+
+// handleGetDailyStats 返回最近 N 天的日统计（来自 daily_stats 聚合表，带 1 分钟缓存）
+func handleGetDailyStats(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
+	days := defaultDailyStatsDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid days", http.StatusBadRequest)
+			return
+		}
+		days = min(n, maxDailyStatsDays)
+	}
+
+	dailyStatsCache.Lock()
+	entry, ok := dailyStatsCache.entries[days]
+	dailyStatsCache.Unlock()
+
+	if !ok || time.Since(entry.at) > dailyStatsCacheTTL {
+		stats, err := engine.QueryDailyStats(r.Context(), db, days)
+		if err != nil {
+			slog.Error("failed_to_query_daily_stats", "err", err)
+			http.Error(w, "Failed to retrieve daily stats", 500)
+			return
+		}
+		body, err := json.Marshal(map[string]interface{}{"days": days, "stats": stats})
+		if err != nil {
+			slog.Error("failed_to_encode_daily_stats", "err", err)
+			http.Error(w, "Failed to encode daily stats", 500)
+			return
+		}
+		entry = dailyStatsEntry{at: time.Now(), body: body}
+		dailyStatsCache.Lock()
+		dailyStatsCache.entries[days] = entry
+		dailyStatsCache.Unlock()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	if _, err := w.Write(entry.body); err != nil {
+		slog.Debug("failed_to_write_daily_stats", "err", err)
+	}
+}
+
+const defaultThroughputWindow = 24 * time.Hour
+
+// parseWindow 解析 window 参数：支持 Go duration（90m、6h）与天数（7d）
+func parseWindow(v string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid window %q", v)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid window %q", v)
+	}
+	return d, nil
+}
+
+// handleGetThroughput 返回 metrics_history 中最近 window（默认 24h，最长 7d）的 TPS/BPS/延迟曲线
+func handleGetThroughput(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
+	window := defaultThroughputWindow
+	if v := r.URL.Query().Get("window"); v != "" {
+		d, err := parseWindow(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		window = min(d, engine.MetricsHistoryRetention)
+	}
+
+	samples, bucket, err := engine.QueryThroughput(r.Context(), db, window)
+	if err != nil {
+		slog.Error("failed_to_query_throughput", "err", err)
+		http.Error(w, "Failed to retrieve throughput history", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"window":         window.String(),
+		"bucket_seconds": int64(bucket.Seconds()),
+		"samples":        samples,
+	}); err != nil {
+		slog.Error("failed_to_encode_throughput", "err", err)
+	}
+}
+
+// handleGetCongestion 返回区块填充率（gas_used / gas_limit）的滑动平均与最近 window（默认 24h，最长 7d）的分桶曲线，
+// 每个桶附带区块的平均索引延迟，供看板对照链拥堵与索引延迟
+func handleGetCongestion(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
+	window := defaultThroughputWindow
+	if v := r.URL.Query().Get("window"); v != "" {
+		d, err := parseWindow(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		window = min(d, engine.MetricsHistoryRetention)
+	}
+
+	rolling, err := engine.QueryCongestionRolling(r.Context(), db)
+	if err != nil {
+		slog.Error("failed_to_query_congestion", "err", err)
+		http.Error(w, "Failed to retrieve congestion stats", 500)
+		return
+	}
+	samples, bucket, err := engine.QueryCongestion(r.Context(), db, window)
+	if err != nil {
+		slog.Error("failed_to_query_congestion", "err", err)
+		http.Error(w, "Failed to retrieve congestion stats", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"window":         window.String(),
+		"bucket_seconds": int64(bucket.Seconds()),
+		"rolling":        rolling,
+		"samples":        samples,
+	}); err != nil {
+		slog.Error("failed_to_encode_congestion", "err", err)
+	}
+}
+
+const (
+	defaultStablecoinHours = 24
+	maxStablecoinHours     = 24 * 30
+)
+
+// handleGetStablecoinFlows 返回最近 hours 小时（默认 24，最长 30 天）稳定币流入 / 流出已标记交易所的小时明细与按交易所汇总的净流入
+func handleGetStablecoinFlows(w http.ResponseWriter, r *http.Request, db *sqlx.DB, chainID int64) {
+	hours := defaultStablecoinHours
+	if v := r.URL.Query().Get("hours"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid hours", http.StatusBadRequest)
+			return
+		}
+		hours = min(n, maxStablecoinHours)
+	}
+
+	report, err := engine.QueryStablecoinFlows(r.Context(), db, chainID, hours)
+	if err != nil {
+		slog.Error("failed_to_query_stablecoin_flows", "err", err)
+		http.Error(w, "Failed to retrieve stablecoin flows", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.Error("failed_to_encode_stablecoin_flows", "err", err)
+	}
+}
+
+const (
+	defaultSLODays = 7
+	maxSLODays     = 90
+)
+
+// handleGetSLO 返回最近 days 个 UTC 日（默认 7，最长 90）的在线率、各状态分钟数与同步滞后 SLO 达标率
+func handleGetSLO(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
+	days := defaultSLODays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid days", http.StatusBadRequest)
+			return
+		}
+		days = min(n, maxSLODays)
+	}
+
+	report, err := engine.QuerySLO(r.Context(), db, days, time.Now())
+	if err != nil {
+		slog.Error("failed_to_query_slo", "err", err)
+		http.Error(w, "Failed to retrieve SLO stats", 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.Error("failed_to_encode_slo", "err", err)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	defaultSupplyPoints = 200
+	maxSupplyPoints     = 5000
+)
+
+// SupplyPoint 某区块内的铸造 / 销毁量与截至该区块的累计净供应量
+type SupplyPoint struct {
+	BlockNumber models.Height  `db:"block_number" json:"block_number"`
+	Timestamp   int64          `db:"timestamp" json:"timestamp"`
+	Minted      models.Uint256 `db:"minted" json:"minted"`
+	Burned      models.Uint256 `db:"burned" json:"burned"`
+	Mints       int            `db:"mints" json:"mints"`
+	Burns       int            `db:"burns" json:"burns"`
+	Supply      models.BigInt  `db:"supply" json:"supply"` // 净铸造量，索引起点晚于代币部署时可能为负
+}
+
+// TokenSupplyHistory /api/tokens/{address}/supply 响应。
+// Supply 为已索引区间内的净铸造量（从索引起点累计），从创世块开始索引时即为链上总供应量。
+type TokenSupplyHistory struct {
+	Token       string         `json:"token"`
+	TotalMinted models.Uint256 `json:"total_minted"`
+	TotalBurned models.Uint256 `json:"total_burned"`
+	Supply      models.BigInt  `json:"supply"`
+	Points      []SupplyPoint  `json:"points"`
+}
+
+// handleGetTokenSupply 返回代币最近 ?limit= 个有铸造 / 销毁的区块及累计供应量（按区块升序）
+func handleGetTokenSupply(w http.ResponseWriter, r *http.Request, db *sqlx.DB) {
+	addr := r.PathValue("address")
+	if !common.IsHexAddress(addr) {
+		http.Error(w, "invalid token address", http.StatusBadRequest)
+		return
+	}
+	token := strings.ToLower(common.HexToAddress(addr).Hex())
+	limit := parseLimit(r, defaultSupplyPoints, maxSupplyPoints)
+
+	history := TokenSupplyHistory{Token: token, Points: []SupplyPoint{}}
+	var totals struct {
+		Minted models.Uint256 `db:"minted"`
+		Burned models.Uint256 `db:"burned"`
+	}
+	err := engine.TimedGet(r.Context(), db, "api_token_supply_totals", &totals, `
+		SELECT COALESCE(SUM(minted), 0)::TEXT AS minted, COALESCE(SUM(burned), 0)::TEXT AS burned
+		FROM token_supply_deltas WHERE token_address = $1`, token)
+	if err != nil {
+		http.Error(w, "Failed to compute token supply", 500)
+		return
+	}
+	history.TotalMinted, history.TotalBurned = totals.Minted, totals.Burned
+
+	err = engine.TimedSelect(r.Context(), db, "api_token_supply_history", &history.Points, `
+		SELECT block_number::TEXT AS block_number, timestamp, minted::TEXT AS minted, burned::TEXT AS burned,
+			mints, burns, supply::TEXT AS supply
+		FROM (
+			SELECT d.block_number, b.timestamp, d.minted, d.burned, d.mints, d.burns,
+				SUM(d.minted - d.burned) OVER (ORDER BY d.block_number) AS supply
+			FROM token_supply_deltas d JOIN blocks b ON b.number = d.block_number
+			WHERE d.token_address = $1
+		) s
+		ORDER BY block_number DESC
+		LIMIT $2`, token, limit)
+	if err != nil {
+		http.Error(w, "Failed to load token supply history", 500)
+		return
+	}
+	for i, j := 0, len(history.Points)-1; i < j; i, j = i+1, j-1 {
+		history.Points[i], history.Points[j] = history.Points[j], history.Points[i]
+	}
+	history.Supply = models.NewBigInt(0)
+	if n := len(history.Points); n > 0 {
+		history.Supply = history.Points[n-1].Supply
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		slog.Error("failed_to_encode_token_supply", "err", err)
+	}
+}
+
+// EnrichResponse /api/admin/enrich 响应
+type EnrichResponse struct {
+	Mode      string                         `json:"mode"` // token | stale
+	Requested int                            `json:"requested"`
+	Refreshed int                            `json:"refreshed"`
+	Results   []engine.MetadataRefreshResult `json:"results"`
+}
+
+// handleEnrichTokens 强制刷新代币元数据并同步返回结果：
+// {"token": "0x..."} 刷新单个代币；{"stale": true, "ttl_hours": 168, "limit": 200} 刷新缺失或过期的代币；actor 为操作者身份
+func handleEnrichTokens(w http.ResponseWriter, r *http.Request, processor *engine.Processor, actor string) {
+	var enricher *engine.MetadataEnricher
+	if processor != nil {
+		enricher = processor.MetadataEnricher()
+	}
+	if enricher == nil {
+		http.Error(w, "metadata enrichment not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	var body struct {
+		Token    string `json:"token"`
+		Stale    bool   `json:"stale"`
+		TTLHours int    `json:"ttl_hours"`
+		Limit    int    `json:"limit"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&body); err != nil {
+		http.Error(w, `body must be {"token": "0x..."} or {"stale": true}`, http.StatusBadRequest)
+		return
+	}
+
+	resp := EnrichResponse{}
+	switch {
+	case body.Token != "" && body.Stale:
+		http.Error(w, `"token" and "stale" are mutually exclusive`, http.StatusBadRequest)
+		return
+	case body.Token != "":
+		if !common.IsHexAddress(body.Token) {
+			http.Error(w, "invalid token address", http.StatusBadRequest)
+			return
+		}
+		resp.Mode = "token"
+		resp.Results = enricher.Refresh(r.Context(), []common.Address{common.HexToAddress(body.Token)})
+	case body.Stale:
+		resp.Mode = "stale"
+		results, err := enricher.RefreshStale(r.Context(), time.Duration(body.TTLHours)*time.Hour, body.Limit)
+		if err != nil {
+			slog.Error("failed_to_refresh_stale_metadata", "err", err)
+			http.Error(w, "failed to list stale tokens", http.StatusInternalServerError)
+			return
+		}
+		resp.Results = results
+	default:
+		http.Error(w, `body must be {"token": "0x..."} or {"stale": true}`, http.StatusBadRequest)
+		return
+	}
+
+	resp.Requested = len(resp.Results)
+	for _, res := range resp.Results {
+		if res.Status == engine.MetadataRefreshed {
+			resp.Refreshed++
+		}
+	}
+	slog.Info("🔄 metadata_refresh_requested", "mode", resp.Mode, "requested", resp.Requested, "refreshed", resp.Refreshed, "actor", actor, "remote_addr", r.RemoteAddr)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("failed_to_encode_enrich_results", "err", err)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"web3-indexer-go/internal/database"
+	"web3-indexer-go/internal/emulator"
+)
+
+// 构建信息：可由 -ldflags "-X main.GitCommit=... -X main.BuildTime=..." 注入，
+// 未注入时回退到 Go 工具链写入的 vcs 信息
+var (
+	GitCommit string
+	BuildTime string
+)
+
+// VersionInfo /api/version 响应
+type VersionInfo struct {
+	Version       string       `json:"version"`
+	GitCommit     string       `json:"git_commit"`
+	GitDirty      bool         `json:"git_dirty"`
+	BuildTime     string       `json:"build_time"`
+	GoVersion     string       `json:"go_version"`
+	Mode          string       `json:"mode"`
+	ChainID       int64        `json:"chain_id"`
+	SchemaVersion int          `json:"schema_version"`
+	Features      FeatureFlags `json:"features"`
+}
+
+// FeatureFlags 当前进程启用的功能开关
+type FeatureFlags struct {
+	Simulator bool `json:"simulator"`
+	Replay    bool `json:"replay"`
+	Emulator  bool `json:"emulator"`
+	LabMode   bool `json:"lab_mode"`
+	DemoMode  bool `json:"demo_mode"`
+	Ephemeral bool `json:"ephemeral"`
+}
+
+type buildStamp struct {
+	commit string
+	dirty  bool
+	time   string
+}
+
+var readBuildStamp = sync.OnceValue(func() buildStamp {
+	stamp := buildStamp{commit: GitCommit, time: BuildTime}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return stamp
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if stamp.commit == "" {
+				stamp.commit = s.Value
+			}
+		case "vcs.time":
+			if stamp.time == "" {
+				stamp.time = s.Value
+			}
+		case "vcs.modified":
+			stamp.dirty = s.Value == "true"
+		}
+	}
+	return stamp
+})
+
+// currentVersionInfo 汇总版本、构建与功能开关
+func currentVersionInfo() VersionInfo {
+	stamp := readBuildStamp()
+	return VersionInfo{
+		Version:       Version,
+		GitCommit:     stamp.commit,
+		GitDirty:      stamp.dirty,
+		BuildTime:     stamp.time,
+		GoVersion:     runtime.Version(),
+		Mode:          runMode,
+		ChainID:       cfg.ChainID,
+		SchemaVersion: database.SchemaVersion,
+		Features: FeatureFlags{
+			Simulator: cfg.EnableSimulator,
+			Replay:    runMode == "replay" || runMode == "soak",
+			Emulator:  emulator.LoadConfig().Enabled,
+			LabMode:   cfg.ChainID == 31337 || cfg.ForceAlwaysActive,
+			DemoMode:  cfg.DemoMode,
+			Ephemeral: cfg.EphemeralMode,
+		},
+	}
+}
+
+// handleGetVersion 返回构建信息、功能开关与 schema 版本（不依赖数据库，启动阶段即可访问）
+func handleGetVersion(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(currentVersionInfo()); err != nil {
+		slog.Error("failed_to_encode_version", "err", err)
+	}
+}
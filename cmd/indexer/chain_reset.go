@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+
+	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/web"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// chainResetHandler 本地开发链重启后把索引器拉回创世：走与 EPHEMERAL_MODE / soak 相同的归零路径
+// （清空抓取队列与 Sequencer 缓冲、暂停落盘并丢弃旧链待写任务、清库、Orchestrator.ResetToZero），
+// 然后由 TailFollow 从 0 重新调度
+type chainResetHandler struct {
+	detector  *engine.ChainResetDetector
+	db        *sqlx.DB
+	fetcher   *engine.Fetcher
+	sequencer *engine.Sequencer
+	persist   bool
+	wsHub     *web.Hub
+}
+
+// newChainResetHandler 只对本地开发链（Anvil 等按需出块的链）启用
+func newChainResetHandler(sm *ServiceManager, sequencer *engine.Sequencer, persist bool, wsHub *web.Hub) *chainResetHandler {
+	if !engine.GetChainProfile(cfg.ChainID).OnDemandMining && !engine.IsLocalAnvil(cfg.RPCURLs[0]) {
+		return nil
+	}
+	var store engine.ChainResetStore
+	if persist {
+		store = engine.NewStore(sm.db)
+	}
+	return &chainResetHandler{
+		detector:  engine.NewChainResetDetector(sm.rpcPool, store),
+		db:        sm.db,
+		fetcher:   sm.fetcher,
+		sequencer: sequencer,
+		persist:   persist,
+		wsHub:     wsHub,
+	}
+}
+
+// check 检测链头；发生重置并完成归零时返回 true，调用方须从区块 0 重新调度
+func (h *chainResetHandler) check(ctx context.Context, head uint64) bool {
+	if h == nil {
+		return false
+	}
+	cursor := engine.GetOrchestrator().GetSnapshot().SyncedCursor
+	ev, err := h.detector.Check(ctx, head, cursor)
+	if err != nil {
+		slog.Debug("chain_reset_check_failed", "err", err)
+		return false
+	}
+	if ev == nil {
+		return false
+	}
+
+	slog.Warn("🔄 [ChainReset] Local chain restarted, resetting index to genesis",
+		"reason", ev.Reason,
+		"chain_head", ev.ChainHead,
+		"synced_cursor", ev.SyncedCursor,
+		"previous_genesis", ev.PreviousGenesis,
+		"genesis", ev.Genesis)
+
+	zero := big.NewInt(0)
+	h.fetcher.Reset(zero)
+	h.sequencer.ClearBuffer()
+	h.sequencer.ResetExpectedBlock(zero)
+	if err := h.resetStorage(ctx); err != nil {
+		slog.Error("❌ [ChainReset] Storage reset failed", "err", err)
+	}
+	engine.GetOrchestrator().ResetToZero()
+	engine.GetChainHeadCache().Invalidate()
+	engine.GetUpstreamWatch().Reset()
+	engine.GetMetrics().ChainResets.Inc()
+
+	h.wsHub.Broadcast(web.WSEvent{Type: "chain_reset", Data: ev})
+	slog.Info("✅ [ChainReset] Index reset complete, re-syncing from block 0")
+	return true
+}
+
+// resetStorage 暂停落盘后丢弃旧链的待写任务（队列、进行中的批次与尚未入队的分发），再清库与字节码缓存。
+// 不先暂停的话，清库后提交的旧链批次会占住新链同高度的区块（ON CONFLICT DO NOTHING）并把 checkpoint 拉回旧链高度
+func (h *chainResetHandler) resetStorage(ctx context.Context) error {
+	writer := engine.GetOrchestrator().GetAsyncWriter()
+	if writer != nil {
+		if err := writer.PauseWrites(ctx); err != nil {
+			return fmt.Errorf("pause writes: %w", err)
+		}
+		defer writer.ResumeWrites()
+		if discarded := writer.DiscardPending(); discarded > 0 {
+			slog.Info("🗑️ [ChainReset] Discarded queued persist tasks", "tasks", discarded)
+		}
+	}
+	engine.GetCodeCache().Purge()
+	if !h.persist {
+		return nil
+	}
+	return resetIndexedData(ctx, h.db)
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"web3-indexer-go/internal/config"
+	"web3-indexer-go/internal/emulator"
 	"web3-indexer-go/internal/engine"
 	"web3-indexer-go/internal/recovery"
 	"web3-indexer-go/internal/web"
@@ -24,26 +25,63 @@ func main() {
 }
 
 func run() error {
+	// 子命令：indexer replay fetch ...（不启动索引引擎）
+	if len(os.Args) > 2 && os.Args[1] == "replay" && os.Args[2] == "fetch" {
+		engine.InitLogger(os.Getenv("LOG_LEVEL"))
+		if err := runReplayFetch(os.Args[3:]); err != nil {
+			slog.Error("❌ replay fetch failed", "err", err)
+			return err
+		}
+		return nil
+	}
+
 	resetDB := flag.Bool("reset", false, "Reset database")
 	startFrom := flag.String("start-from", "", "Force start from: 'latest' or specific block number")
-	mode := flag.String("mode", "index", "Operation mode: 'index' or 'replay'")
-	replayFile := flag.String("file", "", "Trajectory file for replay (.jsonl or .lz4)")
+	mode := flag.String("mode", "index", "Operation mode: 'index', 'replay' or 'soak'")
+	replayFile := flag.String("file", "", "Replay trajectory: .lz4 file, directory of .lz4 chunks, or s3://bucket/prefix")
 	replaySpeed := flag.Float64("speed", 1.0, "Replay speed factor (e.g. 2.0 for 2x speed, 0 for max speed)")
+	soakPasses := flag.Int("passes", 0, "Soak mode: number of replay passes (0 = until interrupted)")
+	soakReport := flag.String("soak-report", "", "Soak mode: per-pass JSONL report (default logs/soak_<time>.jsonl)")
+	var chaos engine.ReplayChaos
+	flag.DurationVar(&chaos.Latency, "latency", 0, "Replay/soak: artificial delivery latency per block (e.g. 20ms)")
+	flag.DurationVar(&chaos.Jitter, "jitter", 0, "Replay/soak: extra random delivery delay in [0, jitter)")
+	flag.IntVar(&chaos.ReorderWindow, "reorder", 0, "Replay/soak: deliver blocks out of order within a window of N blocks")
+	flag.Int64Var(&chaos.Seed, "seed", 0, "Replay/soak: random seed for jitter/reorder (0 = time-based)")
 	flag.Parse()
 	cfg = config.Load()
 	engine.InitLogger(cfg.LogLevel)
+	// 🔗 多链模式：本进程只做监督与路由，每条链在独立的 goroutine 组 / 子进程中运行
+	if len(cfg.Chains) > 0 && *mode == "index" {
+		return runMultiChain(cfg.Chains)
+	}
+	engine.UseChainRegistry(cfg.ChainID)
+	emulator.RegisterMetrics()
+	engine.GetLogStream().SetCapacity(cfg.LogStreamBuffer)
 	forceFrom = *startFrom
+	runMode = *mode
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	startMetricsPush(ctx)
 	wsHub := setupWebSocketHub(ctx)
 
 	if *mode == "replay" {
-		return startReplayMode(ctx, *replayFile, *replaySpeed)
+		return startReplayMode(ctx, *replayFile, *replaySpeed, chaos)
+	}
+	if *mode == "soak" {
+		return startSoakMode(ctx, *replayFile, *replaySpeed, *soakPasses, *soakReport, chaos)
 	}
 
 	apiServer := NewServer(nil, wsHub, cfg.Port, cfg.AppTitle)
+	adminAuth := newAdminAuth(cfg.AdminTokens, cfg.WSAuthToken, cfg.AdminAllowAnonymous)
+	switch {
+	case adminAuth.anonymous():
+		slog.Warn("🔓 ADMIN_ALLOW_ANONYMOUS=true without ADMIN_TOKEN / WS_AUTH_TOKEN: admin write endpoints are unauthenticated")
+	case !adminAuth.enabled():
+		slog.Warn("🔒 ADMIN_TOKEN / WS_AUTH_TOKEN not set: admin write endpoints are disabled")
+	}
+	apiServer.SetAdminAuth(adminAuth)
 	recovery.WithRecovery(func() {
 		slog.Info("🚀 Indexer API Server starting (Early Bird Mode)", "port", cfg.Port)
 		if err := apiServer.Start(); err != nil && err != http.ErrServerClosed {
@@ -51,15 +89,25 @@ func run() error {
 		}
 	}, "api_server")
 
+	// 🔁 监督者放弃重启时上报致命错误，主循环据此退出（交由外部进程管理器重启）
+	fatalErrCh := make(chan error, 8)
 	recovery.WithRecoveryNamed("async_init", func() {
-		initEngine(ctx, apiServer, wsHub, *resetDB)
+		initEngine(ctx, apiServer, wsHub, *resetDB, fatalErrCh)
 	})
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	slog.Info("🏁 System Operational. Press Ctrl+C to stop.")
-	sig := <-sigCh
-	slog.Info("🛑 Signal received, initiating graceful shutdown...", "signal", sig)
+	var exitErr error
+	select {
+	case sig := <-sigCh:
+		slog.Info("🛑 Signal received, initiating graceful shutdown...", "signal", sig)
+	case err := <-fatalErrCh:
+		slog.Error("💀 Fatal component failure, initiating graceful shutdown...", "err", err)
+		exitErr = err
+	}
+
+	shutdownStart := time.Now()
 
 	// 1. 创建 15 秒超时 context 用于关闭流程
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 15*time.Second)
@@ -75,7 +123,7 @@ func run() error {
 	// 3. 取消全局 Context，通知 Sequencer, Fetcher 等组件停止
 	cancel()
 
-	// 4. 关闭 Orchestrator（会触发 AsyncWriter 的 Flush 和 Shutdown）
+	// 4. 关闭 Orchestrator（AsyncWriter 排空队列并强制写入最终 checkpoint）
 	orchestrator := engine.GetOrchestrator()
 	if orchestrator != nil {
 		slog.Info("🎼 Shutting down Orchestrator and Flushing DB...")
@@ -83,26 +131,34 @@ func run() error {
 		slog.Info("✅ Orchestrator and AsyncWriter shut down")
 	}
 
-	slog.Info("🏁 Graceful shutdown complete. Goodbye!")
-	return nil
+	shutdownDuration := time.Since(shutdownStart)
+	engine.GetMetrics().ShutdownDuration.Set(shutdownDuration.Seconds())
+	slog.Info("🏁 Graceful shutdown complete. Goodbye!", "duration", shutdownDuration)
+	return exitErr
 }
 
 func setupWebSocketHub(ctx context.Context) *web.Hub {
 	var wsHub *web.Hub
 	if cfg.ChainID == 31337 {
 		throttledHub := web.NewThrottledHub(500 * time.Millisecond)
+		throttledHub.SetBackpressure(cfg.WSClientBuffer, cfg.WSMaxConsecutiveDrops)
+		throttledHub.SetReplayBuffer(cfg.WSReplayBuffer)
+		throttledHub.SetAccessControl(cfg.WSAuthToken, cfg.WSAllowedOrigins, cfg.WSMaxClients)
 		go throttledHub.RunWithThrottling(ctx)
 		wsHub = throttledHub.Hub
 		slog.Info("🔥 Throttled WebSocket Hub activated for Anvil", "throttle", "500ms")
 	} else {
 		wsHub = web.NewHub()
+		wsHub.SetBackpressure(cfg.WSClientBuffer, cfg.WSMaxConsecutiveDrops)
+		wsHub.SetReplayBuffer(cfg.WSReplayBuffer)
+		wsHub.SetAccessControl(cfg.WSAuthToken, cfg.WSAllowedOrigins, cfg.WSMaxClients)
 		go wsHub.Run(ctx)
 		slog.Info("📡 Standard WebSocket Hub activated for production")
 	}
 	return wsHub
 }
 
-func startReplayMode(ctx context.Context, replayFile string, replaySpeed float64) error {
+func startReplayMode(ctx context.Context, replayFile string, replaySpeed float64, chaos engine.ReplayChaos) error {
 	if replayFile == "" {
 		slog.Error("❌ Replay mode requires -file parameter")
 		return fmt.Errorf("replay mode requires -file parameter")
@@ -114,9 +170,34 @@ func startReplayMode(ctx context.Context, replayFile string, replaySpeed float64
 	processor := engine.NewProcessor(db, nil, 100, cfg.ChainID, false, "replay")
 	orchestrator := engine.GetOrchestrator()
 	asyncWriter := engine.NewAsyncWriter(db, orchestrator, cfg.EphemeralMode, cfg.ChainID)
+	asyncWriter.SetCommitHook(processor.EmitCommitted)
 	orchestrator.SetAsyncWriter(asyncWriter)
 	asyncWriter.Start()
 
 	slog.Info("🏁 System starting in REPLAY mode.")
-	return RunReplayMode(ctx, replayFile, replaySpeed, processor)
+	return RunReplayMode(ctx, replayFile, replaySpeed, chaos, processor)
+}
+
+func startSoakMode(ctx context.Context, replayFile string, replaySpeed float64, passes int, reportPath string, chaos engine.ReplayChaos) error {
+	if replayFile == "" {
+		slog.Error("❌ Soak mode requires -file parameter")
+		return fmt.Errorf("soak mode requires -file parameter")
+	}
+	db, err := connectDB(ctx, cfg.ChainID == 31337)
+	if err != nil {
+		return err
+	}
+	processor := engine.NewProcessor(db, nil, 100, cfg.ChainID, false, "replay")
+	orchestrator := engine.GetOrchestrator()
+	asyncWriter := engine.NewAsyncWriter(db, orchestrator, cfg.EphemeralMode, cfg.ChainID)
+	asyncWriter.SetCommitHook(processor.EmitCommitted)
+	orchestrator.SetAsyncWriter(asyncWriter)
+	asyncWriter.Start()
+
+	// 隔夜运行需要响应 Ctrl+C / SIGTERM，写完当前轮报告后退出
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	slog.Info("🏁 System starting in SOAK mode.")
+	return RunSoakMode(ctx, db, replayFile, replaySpeed, chaos, passes, reportPath, processor)
 }
@@ -6,9 +6,13 @@ import (
 	"fmt"
 	"log/slog"
 	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"web3-indexer-go/internal/database"
+	"web3-indexer-go/internal/emulator"
 	"web3-indexer-go/internal/engine"
 	"web3-indexer-go/internal/recovery"
 	"web3-indexer-go/internal/web"
@@ -19,7 +23,7 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-func initEngine(ctx context.Context, apiServer *Server, wsHub *web.Hub, resetDB bool) {
+func initEngine(ctx context.Context, apiServer *Server, wsHub *web.Hub, resetDB bool, fatalErrCh chan<- error) {
 	slog.Info("⏳ Async engine initialization started...")
 	recovery.OnPanic = func(name string, err interface{}, _ string) {
 		wsHub.Broadcast(web.WSEvent{
@@ -27,12 +31,25 @@ func initEngine(ctx context.Context, apiServer *Server, wsHub *web.Hub, resetDB
 			Data: map[string]interface{}{"worker": name, "error": fmt.Sprintf("%v", err), "ts": time.Now().Unix()},
 		})
 	}
+	recovery.OnRestart = func(name string, _ int, _ time.Duration) {
+		engine.GetMetrics().SupervisorRestarts.WithLabelValues(name).Inc()
+	}
+	recovery.OnGiveUp = func(name string, err error) {
+		engine.GetMetrics().SupervisorGiveUps.WithLabelValues(name).Inc()
+		wsHub.Broadcast(web.WSEvent{
+			Type: "engine_fatal",
+			Data: map[string]interface{}{"worker": name, "error": err.Error(), "ts": time.Now().Unix()},
+		})
+	}
+	configureRestartPolicies()
+	startMemProfiler(ctx)
 
 	db, err := connectDB(ctx, cfg.ChainID == 31337)
 	if err != nil {
 		return
 	}
-	if err := database.InitSchema(ctx, db); err != nil {
+	deferredSteps, err := initSchema(ctx, db)
+	if err != nil {
 		slog.Error("❌ Database schema initialization failed", "err", err)
 		return
 	}
@@ -44,6 +61,22 @@ func initEngine(ctx context.Context, apiServer *Server, wsHub *web.Hub, resetDB
 	if err := verifyNetworkWithRetry(); err != nil {
 		return
 	}
+	if enhanced, ok := rpcPool.(*engine.EnhancedRPCClientPool); ok {
+		enhanced.OnProviderQuarantined = func(incident engine.ProviderIncident) {
+			wsHub.Broadcast(web.WSEvent{Type: "provider_quarantined", Data: incident})
+		}
+		enhanced.StartQuorumCheck(ctx, 30*time.Second)
+		enhanced.SetStaleHeadThreshold(5 * engine.GetChainProfile(cfg.ChainID).BlockTime)
+	}
+	engine.GetUpstreamWatch().Configure(
+		engine.UpstreamStallThreshold(engine.GetChainProfile(cfg.ChainID), cfg.UpstreamStallAfter),
+		func(state engine.UpstreamStallState) {
+			eventType := "upstream_resumed"
+			if state.Stalled {
+				eventType = "upstream_stalled"
+			}
+			wsHub.Broadcast(web.WSEvent{Type: eventType, Data: state})
+		})
 
 	perfProfile := engine.GetPerformanceProfile(cfg.RPCURLs, cfg.ChainID)
 	perfProfile.ApplyToConfig(cfg)
@@ -55,13 +88,25 @@ func initEngine(ctx context.Context, apiServer *Server, wsHub *web.Hub, resetDB
 
 	sm := NewServiceManager(db, rpcPool, cfg.ChainID, cfg.RetryQueueSize, tpsLimit, tpsLimit*2, cfg.FetchConcurrency, cfg.EnableSimulator, cfg.NetworkMode, cfg.EnableRecording, cfg.RecordingPath)
 	sm.fetcher.SetThroughputLimit(float64(tpsLimit))
+	sm.fetcher.SetReceiptFetchMode(cfg.FetchReceipts)
+	sm.fetcher.SetInternalTraceMode(cfg.TraceInternalTransfers)
+	sm.Processor.SetSyntheticFallback(cfg.SyntheticFallback)
+	sm.Processor.SetTokenFilter(engine.NewTokenFilter(cfg.TokenAllowList, cfg.TokenDenyList))
+	attachSpamGuard(ctx, db, sm.Processor)
+	attachTransferTopics(sm)
+	attachEventSignatures(sm)
+	apiServer.SetWatchlist(attachWatchlist(ctx, db, sm))
+	attachCodeCache(ctx, db, rpcPool)
+	attachFileSink(ctx, sm.Processor)
+	attachObjectSink(ctx, sm.Processor)
+	attachMempool(ctx, sm.Processor, wsHub)
+	attachGapSkipPolicy(db)
 
 	if err := sm.Processor.AlignInfrastructure(ctx, rpcPool); err != nil {
 		slog.Error("❌ [FATAL] Infrastructure alignment failed", "err", err)
 	}
 
-	guard := engine.NewConsistencyGuard(sm.Processor.GetRepoAdapter(), rpcPool)
-	guard.SetDemoMode(cfg.DemoMode)
+	guard := engine.NewConsistencyGuard(sm.Processor.GetRepoAdapter(), rpcPool, cfg.ChainID)
 	guard.OnStatus = func(status string, detail string, progress int) {
 		wsHub.Broadcast(web.WSEvent{Type: "linearity_status", Data: map[string]interface{}{"status": status, "detail": detail, "progress": progress}})
 	}
@@ -89,17 +134,324 @@ func initEngine(ctx context.Context, apiServer *Server, wsHub *web.Hub, resetDB
 	wsHub.OnActivity = func() { lazyManager.Trigger() }
 	wsHub.OnNeedMeta = func(addr string) { sm.Processor.GetSymbol(common.HexToAddress(addr)) }
 
-	sm.Processor.EventHook = func(eventType string, data interface{}) {
-		wsHub.Broadcast(web.WSEvent{Type: eventType, Data: data})
-	}
+	wsEvents := sm.Processor.Events().Subscribe("ws_hub", 10000)
+	go func() {
+		for ev := range wsEvents {
+			wsHub.Broadcast(web.WSEvent{Type: string(ev.Topic), Data: ev.Data})
+		}
+	}()
 
+	engine.GetOrchestrator().SetCheckpointAuditMode(cfg.CheckpointAuditMode)
 	startBlock, err := sm.GetStartBlock(ctx, forceFrom, resetDB)
 	if err != nil {
 		slog.Error("❌ Failed to determine start block", "err", err)
 		startBlock = big.NewInt(cfg.StartBlock)
 	}
 	setupParentAnchor(ctx, db, rpcPool, startBlock)
-	initServices(ctx, sm, startBlock, lazyManager, rpcPool, wsHub)
+	sequencer := initServices(ctx, sm, startBlock, lazyManager, rpcPool, wsHub, fatalErrCh)
+	startOnlineMigration(ctx, db, deferredSteps, apiServer)
+	engine.GetStatusService().SetSequencer(sequencer)
+
+	startGapBackfill(ctx, db, sm, rpcPool)
+	startDecimalBackfill(ctx, db, apiServer)
+
+	healthServer := engine.NewHealthServer(db, rpcPool, sequencer, sm.fetcher)
+	healthServer.SetThresholds(engine.HealthThresholds{
+		MaxSyncLag:         cfg.HealthMaxSyncLag,
+		MaxE2ELatency:      cfg.HealthMaxE2ELatency,
+		MinHealthyNodes:    cfg.HealthMinHealthyNodes,
+		MaxSequencerBuffer: cfg.HealthMaxSequencerBuffer,
+	})
+	engine.GetStatusService().SetMinHealthyNodes(cfg.HealthMinHealthyNodes)
+	apiServer.SetHealthServer(healthServer)
+
+	if emu := startEmulator(ctx); emu != nil {
+		apiServer.SetEmulatorStatus(func() interface{} { return emu.Status() })
+	}
+}
+
+// startEmulator 在 EMULATOR_ENABLED=true 时启动内置流量仿真器（RPC 缺省复用首个 RPC_URLS）
+func startEmulator(ctx context.Context) *emulator.Emulator {
+	emuCfg := emulator.LoadConfig()
+	if !emuCfg.Enabled {
+		return nil
+	}
+	if emuCfg.RPCURL == "" && len(cfg.RPCURLs) > 0 {
+		emuCfg.RPCURL = cfg.RPCURLs[0]
+	}
+	if !emuCfg.IsValid() {
+		slog.Warn("🎭 Emulator enabled but RPC URL or private key missing, skipping")
+		return nil
+	}
+
+	emu, err := emulator.NewEmulator(emuCfg.RPCURL, emuCfg.PrivateKey, emulator.WithTxInterval(emuCfg.TxInterval))
+	if err != nil {
+		slog.Error("🎭 Emulator init failed", "err", err)
+		return nil
+	}
+	if amount, ok := new(big.Int).SetString(emuCfg.TxAmount, 10); ok {
+		emu.SetTxAmount(amount)
+	}
+	emu.SetBlockInterval(emuCfg.BlockInterval)
+
+	go recovery.WithRecoveryNamed("emulator", func() {
+		if err := emu.Start(ctx, nil); err != nil && ctx.Err() == nil {
+			slog.Error("🎭 Emulator stopped", "err", err)
+		}
+	})
+	return emu
+}
+
+// startMemProfiler 按配置启动内存压力自动 profile（阈值均为 0 时不启动）
+func startMemProfiler(ctx context.Context) {
+	engine.NewMemProfiler(engine.MemProfilerConfig{
+		HeapInuseThreshold: uint64(max(cfg.MemProfileHeapMB, 0)) << 20,
+		RSSThreshold:       uint64(max(cfg.MemProfileRSSMB, 0)) << 20,
+		Cooldown:           cfg.MemProfileCooldown,
+		Dir:                cfg.MemProfileDir,
+	}).Start(ctx)
+}
+
+// startMetricsPush 按 ConfigManager（METRICS_PUSH_* 环境变量）启动指标推送循环，未启用时循环空转等待热更新
+func startMetricsPush(ctx context.Context) {
+	cm := engine.NewConfigManagerFromEnv()
+	go recovery.WithRecoveryNamed("metrics_push", func() {
+		engine.NewMetricsPusher(cm).Run(ctx)
+	})
+}
+
+// initSchema 按 SCHEMA_MIGRATION_MODE 初始化表结构；online 模式返回留待同步开始后执行的耗时步骤
+func initSchema(ctx context.Context, db *sqlx.DB) ([]database.MigrationStep, error) {
+	if cfg.SchemaMigrationMode == database.MigrationModeOnline {
+		return database.InitSchemaOnline(ctx, db)
+	}
+	return nil, database.InitSchema(ctx, db)
+}
+
+// startOnlineMigration 在后台执行延后的表结构步骤：索引并发构建，回填期间暂停 AsyncWriter 并自动恢复
+func startOnlineMigration(ctx context.Context, db *sqlx.DB, steps []database.MigrationStep, apiServer *Server) {
+	if len(steps) == 0 {
+		return
+	}
+	var pauser database.WritePauser
+	if writer := engine.GetOrchestrator().GetAsyncWriter(); writer != nil {
+		pauser = writer
+	}
+	migrator := database.NewOnlineMigrator(db, steps, pauser)
+	apiServer.SetMigrator(migrator)
+	go recovery.WithRecoveryNamed("online_migration", func() {
+		migrator.Run(ctx)
+	})
+}
+
+// attachSpamGuard 启用垃圾代币启发式，并从 token_metadata 恢复已判定代币与管理员覆盖
+func attachSpamGuard(ctx context.Context, db *sqlx.DB, processor *engine.Processor) {
+	guard := engine.NewSpamGuard(engine.SpamConfig{
+		ZeroTransfersPerBlock: cfg.SpamZeroTransfersPerBlock,
+		AirdropRecipients:     cfg.SpamAirdropRecipients,
+		FlagUnverifiable:      cfg.SpamFlagUnverifiable,
+	}, engine.NewStore(db))
+	if err := guard.Load(ctx); err != nil {
+		slog.Warn("failed_to_load_spam_tokens", "err", err)
+	}
+	processor.SetSpamGuard(guard)
+}
+
+// attachTransferTopics 启用配置的 Transfer 等价事件（EXTRA_TRANSFER_TOPICS）；配置非法时整体忽略
+func attachTransferTopics(sm *ServiceManager) {
+	if len(cfg.ExtraTransferTopics) == 0 {
+		return
+	}
+	topics, err := engine.ParseTransferTopics(cfg.ExtraTransferTopics)
+	if err != nil {
+		slog.Error("invalid_extra_transfer_topics", "err", err)
+		return
+	}
+	sm.Processor.SetExtraTransferTopics(topics)
+	sm.fetcher.SetExtraTransferTopics(topics)
+}
+
+// attachWatchlist 从 watched_addresses 表加载监控地址（表为空时以 WATCHED_TOKEN_ADDRESSES 初始化）并下发给 Fetcher / Processor，
+// 之后定期重读，表的修改无需重启即可生效；返回的列表供管理接口增删
+func attachWatchlist(ctx context.Context, db *sqlx.DB, sm *ServiceManager) *engine.Watchlist {
+	watchlist := engine.NewWatchlist(engine.NewStore(db), cfg.WatchlistReload, sm.fetcher, sm.Processor)
+	if err := watchlist.Load(ctx, cfg.WatchedTokenAddresses); err != nil {
+		slog.Warn("failed_to_load_watchlist", "err", err)
+	}
+	go recovery.WithRecoveryNamed("watchlist_reload", func() {
+		watchlist.Run(ctx)
+	})
+	return watchlist
+}
+
+// attachEventSignatures 启用配置的通用事件解码（EVENT_SIGNATURES）；配置非法时整体忽略
+func attachEventSignatures(sm *ServiceManager) {
+	if len(cfg.EventSignatures) == 0 {
+		return
+	}
+	sigs, err := engine.ParseEventSignatures(cfg.EventSignatures)
+	if err != nil {
+		slog.Error("invalid_event_signatures", "err", err)
+		return
+	}
+	sm.Processor.SetEventSignatures(sigs)
+	sm.fetcher.SetEventSignatures(sigs)
+}
+
+// attachCodeCache 绑定 eth_getCode 缓存（RPC 池不支持批量 eth_getCode 时保持关闭）并启动后台预热
+func attachCodeCache(ctx context.Context, db *sqlx.DB, rpcPool engine.RPCClient) {
+	client, ok := rpcPool.(engine.CodeClient)
+	if !ok {
+		return
+	}
+	cache := engine.GetCodeCache()
+	cache.SetLimits(cfg.CodeCacheSize, cfg.CodeCacheEOATTL)
+	cache.Configure(client, engine.NewStore(db))
+	go cache.Run(ctx)
+}
+
+// attachFileSink 配置了 FILE_SINK_DIR 时追加 JSONL 导出 sink，退出时关闭以写出 LZ4 帧尾
+func attachFileSink(ctx context.Context, processor *engine.Processor) {
+	if cfg.FileSinkDir == "" {
+		return
+	}
+	sink, err := engine.NewFileSink(engine.FileSinkConfig{
+		Dir:      cfg.FileSinkDir,
+		MaxBytes: cfg.FileSinkMaxMB << 20,
+		Compress: cfg.FileSinkLZ4,
+		ChainID:  cfg.ChainID,
+	})
+	if err != nil {
+		slog.Error("failed_to_init_file_sink", "err", err)
+		return
+	}
+	processor.AddSink(sink)
+	go func() {
+		<-ctx.Done()
+		if err := sink.Close(); err != nil {
+			slog.Warn("file_sink_close_failed", "err", err)
+		}
+	}()
+	slog.Info("🗂️ [FileSink] JSONL export ACTIVE", "dir", cfg.FileSinkDir, "max_mb", cfg.FileSinkMaxMB, "lz4", cfg.FileSinkLZ4)
+}
+
+// attachMempool 配置 MEMPOOL_ENABLED 且有 WSS_URL 时启动待打包转账视图，生命周期事件推送到 WS
+func attachMempool(ctx context.Context, processor *engine.Processor, wsHub *web.Hub) {
+	if !cfg.MempoolEnabled {
+		return
+	}
+	if cfg.WSSURL == "" {
+		slog.Warn("🫧 [Mempool] MEMPOOL_ENABLED requires WSS_URL, pending view disabled")
+		return
+	}
+	mp := engine.GetMempool()
+	mp.Configure(engine.MempoolConfig{
+		ChainID: cfg.ChainID,
+		TTL:     cfg.MempoolTTL,
+		MaxSize: cfg.MempoolMaxPending,
+		Filter:  engine.NewTokenFilter(cfg.TokenAllowList, cfg.TokenDenyList),
+		Symbol:  processor.GetSymbol,
+		OnEvent: func(kind string, t engine.PendingTransfer) {
+			wsHub.Broadcast(web.WSEvent{Type: kind, Data: t})
+		},
+	})
+	mp.Start(ctx, cfg.WSSURL)
+}
+
+// attachGapSkipPolicy 按 DEMO_MODE 与 GAP_* 配置设置区块跳过策略，跳过区间记录到 skipped_ranges
+func attachGapSkipPolicy(db *sqlx.DB) {
+	policy := engine.GapSkipPolicy{
+		LeapSync:        cfg.DemoMode,
+		LeapThreshold:   uint64(max(cfg.GapLeapThreshold, 0)), // #nosec G115 - 已截断为非负
+		MaxSkippableGap: uint64(max(cfg.GapMaxSkip, 0)),       // #nosec G115 - 已截断为非负
+		RecordSkipped:   cfg.GapRecordSkipped,
+	}
+	engine.GetGapSkipper().Configure(policy, engine.NewStore(db))
+	slog.Info("⏭️ [GapSkip] policy", "leap_sync", policy.LeapSync, "leap_threshold", policy.LeapThreshold,
+		"max_skippable_gap", policy.MaxSkippableGap, "record_skipped", policy.RecordSkipped)
+}
+
+// startGapBackfill 启动 skipped_ranges 的低优先级后台回填（GAP_BACKFILL_ENABLED）
+func startGapBackfill(ctx context.Context, db *sqlx.DB, sm *ServiceManager, rpcPool engine.RPCClient) {
+	if !cfg.GapBackfill || !cfg.GapRecordSkipped {
+		return
+	}
+	backfiller := engine.NewGapBackfiller(engine.NewStore(db), sm.fetcher, sm.Processor, rpcPool, engine.GapBackfillConfig{
+		Interval:         cfg.GapBackfillEvery,
+		ChunkBlocks:      uint64(max(cfg.GapBackfillChunk, 0)), // #nosec G115 - 已截断为非负
+		MinQuotaHeadroom: cfg.GapBackfillQuota,
+	})
+	go recovery.WithRecoveryNamed("gap_backfill", func() {
+		backfiller.Run(ctx)
+	})
+}
+
+// startDecimalBackfill 启动 normalized_amount 后台回填，进度经 /api/admin/backfill/decimals 展示
+func startDecimalBackfill(ctx context.Context, db *sqlx.DB, apiServer *Server) {
+	if !cfg.DecimalFill {
+		return
+	}
+	backfiller := engine.NewDecimalBackfiller(engine.NewStore(db), engine.DecimalBackfillConfig{
+		Interval:  cfg.DecimalFillEvery,
+		BatchSize: int(cfg.DecimalFillBatch),
+	})
+	apiServer.SetDecimalBackfiller(backfiller)
+	go recovery.WithRecoveryNamed("decimal_backfill", func() {
+		backfiller.Run(ctx)
+	})
+}
+
+// attachObjectSink 配置了 OBJECT_SINK_BUCKET 时追加对象存储上传 sink
+func attachObjectSink(ctx context.Context, processor *engine.Processor) {
+	if cfg.ObjectSinkBucket == "" {
+		return
+	}
+	uploader, err := engine.NewS3Uploader(engine.S3UploaderConfig{
+		Endpoint:  cfg.ObjectSinkEndpoint,
+		Region:    cfg.ObjectSinkRegion,
+		Bucket:    cfg.ObjectSinkBucket,
+		AccessKey: cfg.ObjectSinkAccessKey,
+		SecretKey: cfg.ObjectSinkSecretKey,
+	})
+	if err != nil {
+		slog.Error("failed_to_init_object_uploader", "err", err)
+		return
+	}
+	sink, err := engine.NewObjectSink(engine.ObjectSinkConfig{
+		SpoolDir:      cfg.ObjectSinkSpoolDir,
+		KeyPrefix:     cfg.ObjectSinkPrefix,
+		MaxBytes:      cfg.ObjectSinkMaxMB << 20,
+		FlushInterval: cfg.ObjectSinkFlush,
+		ChainID:       cfg.ChainID,
+	}, uploader)
+	if err != nil {
+		slog.Error("failed_to_init_object_sink", "err", err)
+		return
+	}
+	processor.AddSink(sink)
+	go func() {
+		<-ctx.Done()
+		if err := sink.Close(); err != nil {
+			slog.Warn("object_sink_close_failed", "err", err)
+		}
+	}()
+	slog.Info("🪣 [ObjectSink] Upload ACTIVE", "endpoint", cfg.ObjectSinkEndpoint, "bucket", cfg.ObjectSinkBucket,
+		"prefix", cfg.ObjectSinkPrefix, "max_mb", cfg.ObjectSinkMaxMB, "flush", cfg.ObjectSinkFlush)
+}
+
+// configureRestartPolicies 按组件配置监督者重启策略
+func configureRestartPolicies() {
+	sequencerPolicy := recovery.DefaultRestartPolicy()
+	sequencerPolicy.MaxRestarts = cfg.SequencerMaxRestarts
+	sequencerPolicy.Window = cfg.SequencerRestartWindow
+	sequencerPolicy.MaxBackoff = cfg.SequencerMaxBackoff
+	recovery.SetRestartPolicy("sequencer_run", sequencerPolicy)
+
+	tailPolicy := recovery.DefaultRestartPolicy()
+	tailPolicy.MaxRestarts = cfg.TailFollowMaxRestarts
+	tailPolicy.Window = cfg.TailFollowRestartWindow
+	tailPolicy.InitialBackoff = time.Second
+	recovery.SetRestartPolicy("tail_follow", tailPolicy)
 }
 
 func setupSubscriptions(wsHub *web.Hub) {
@@ -127,10 +479,20 @@ func setupSubscriptions(wsHub *web.Hub) {
 func connectDB(ctx context.Context, isLocalAnvil bool) (*sqlx.DB, error) {
 	dbCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
-	db, err := sqlx.ConnectContext(dbCtx, "pgx", cfg.DatabaseURL)
+	db, err := sqlx.ConnectContext(dbCtx, "pgx", withStatementTimeout(cfg.DatabaseURL, cfg.DBStatementTimeout))
 	if err != nil {
 		return nil, err
 	}
+	engine.SetDBQueryPolicy(cfg.DBQueryTimeout, cfg.DBSlowQuery)
+	engine.SetStatementCache(cfg.DBStmtCache)
+	if level, err := engine.ParseIsolationLevel(cfg.DBWriteIsolation); err != nil {
+		slog.Warn("⚠️ Invalid DB_WRITE_ISOLATION, using read_committed", "err", err)
+	} else {
+		engine.SetWriteIsolation(level)
+	}
+	if cfg.DBWriteLock && !cfg.EphemeralMode {
+		engine.SetWriteLock(engine.NewWriteLock(db, cfg.ChainID))
+	}
 
 	maxConns := 25
 	if isLocalAnvil {
@@ -163,6 +525,22 @@ func connectDB(ctx context.Context, isLocalAnvil bool) (*sqlx.DB, error) {
 	return db, nil
 }
 
+// withStatementTimeout 为每个连接设置服务端 statement_timeout（兜底所有未经 Timed* 包装的查询）；
+// DSN 已显式指定或 d <= 0 时原样返回。支持 URL 与 key=value 两种 DSN 格式。
+func withStatementTimeout(dsn string, d time.Duration) string {
+	if d <= 0 || strings.Contains(dsn, "statement_timeout") {
+		return dsn
+	}
+	ms := strconv.FormatInt(d.Milliseconds(), 10)
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		q := u.Query()
+		q.Set("statement_timeout", ms)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	return dsn + " statement_timeout=" + ms
+}
+
 func setupRPC() (engine.RPCClient, error) {
 	var rpcPool engine.RPCClient
 	var err error
@@ -173,6 +551,7 @@ func setupRPC() (engine.RPCClient, error) {
 	}
 	if err == nil {
 		rpcPool.SetRateLimit(float64(cfg.RPCRateLimit), cfg.RPCRateLimit*2)
+		engine.GetChainHeadCache().Configure(rpcPool, cfg.ChainHeadCacheTTL)
 	}
 	return rpcPool, err
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/big"
@@ -9,13 +10,14 @@ import (
 	"time"
 
 	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/models"
 	"web3-indexer-go/internal/recovery"
 	"web3-indexer-go/internal/web"
 
 	"github.com/jmoiron/sqlx"
 )
 
-func initServices(ctx context.Context, sm *ServiceManager, startBlock *big.Int, lazyManager *engine.LazyManager, rpcPool engine.RPCClient, wsHub *web.Hub) {
+func initServices(ctx context.Context, sm *ServiceManager, startBlock *big.Int, lazyManager *engine.LazyManager, rpcPool engine.RPCClient, wsHub *web.Hub, fatalErrCh chan<- error) *engine.Sequencer {
 	if cfg.ChainID == 31337 {
 		AlignAnvilData(ctx, sm.db, rpcPool)
 	}
@@ -33,11 +35,24 @@ func initServices(ctx context.Context, sm *ServiceManager, startBlock *big.Int,
 
 	// AsyncWriter 必须在 Fetcher 启动前就绑定到 Orchestrator
 	asyncWriter := engine.NewAsyncWriter(sm.Processor.GetDB(), orchestrator, !strategy.ShouldPersist(), cfg.ChainID)
+	asyncWriter.SetCommitHook(sm.Processor.EmitCommitted) // 下游 sink 只接收已提交的区块
 	orchestrator.SetAsyncWriter(asyncWriter)
 	asyncWriter.Start()
 
-	sequencer := engine.NewSequencerWithFetcher(sm.Processor, sm.fetcher, startBlock, cfg.ChainID, sm.fetcher.Results, make(chan error, 100), nil, engine.GetMetrics())
+	reorgCh := make(chan engine.ReorgEvent, 16)
+	sequencer := engine.NewSequencerWithFetcher(sm.Processor, sm.fetcher, startBlock, cfg.ChainID, sm.fetcher.Results, make(chan error, 100), reorgCh, engine.GetMetrics())
 	sm.fetcher.SetSequencer(sequencer)
+	sm.chainReset = newChainResetHandler(sm, sequencer, strategy.ShouldPersist(), wsHub)
+	go recovery.WithRecoveryNamed("reorg_refetch", func() {
+		handleReorgEvents(ctx, sm.fetcher, reorgCh)
+	})
+
+	if strategy.ShouldPersist() {
+		engine.NewDailyAggregator(sm.db, time.Minute).Start(ctx)
+		engine.NewStablecoinFlowAggregator(sm.db, cfg.ChainID, time.Minute).Start(ctx)
+		engine.NewThroughputRecorder(sm.db, time.Minute).Start(ctx)
+		engine.NewSLORecorder(sm.db, cfg.SLOLagBlocks).Start(ctx)
+	}
 
 	healer := engine.NewSelfHealer(orchestrator)
 	go healer.Start(ctx)
@@ -55,14 +70,14 @@ func initServices(ctx context.Context, sm *ServiceManager, startBlock *big.Int,
 	wg.Add(1)
 	go func() {
 		close(sequencerReady) // 通知 TailFollow 可以开始调度
-		runSequencerWithSelfHealing(ctx, sequencer, &wg)
+		runSequencerWithSelfHealing(ctx, sequencer, &wg, fatalErrCh)
 	}()
 
 	// Phase 3: 启动生产端（等待 Sequencer 就绪后再调度）
 	sm.fetcher.Start(ctx, &wg)
-	go recovery.WithRecoveryNamed("tail_follow", func() {
+	go recovery.WithRecoveryNamed("tail_follow_init", func() {
 		<-sequencerReady // 等待 Sequencer 就绪
-		sm.StartTailFollow(ctx, startBlock)
+		sm.StartTailFollow(ctx, startBlock, fatalErrCh)
 	})
 
 	if cfg.EnableSimulator {
@@ -72,13 +87,25 @@ func initServices(ctx context.Context, sm *ServiceManager, startBlock *big.Int,
 			engine.NewProSimulator(cfg.RPCURLs[0], true, 10).Start()
 		}()
 	}
+	if cfg.SimulatorPersist {
+		startDeFiSimulator(ctx, sm.Processor)
+	}
+	return sequencer
+}
+
+// resetIndexedData 清空所有索引数据与进度（-reset 与 soak 模式每轮开始前使用）
+func resetIndexedData(ctx context.Context, db *sqlx.DB) error {
+	if err := engine.NewStore(db).Reset(ctx); err != nil {
+		return fmt.Errorf("reset database failed: %w", err)
+	}
+	return nil
 }
 
 func getStartBlockFromCheckpoint(ctx context.Context, db *sqlx.DB, rpcPool engine.RPCClient, chainID int64, forceFrom string, resetDB bool) (*big.Int, error) {
 	latestChainBlock, rpcErr := rpcPool.GetLatestBlockNumber(ctx)
 	if resetDB {
-		if _, err := db.ExecContext(ctx, "TRUNCATE TABLE blocks, transfers CASCADE; DELETE FROM sync_checkpoints;"); err != nil {
-			return nil, fmt.Errorf("reset database failed: %w", err)
+		if err := resetIndexedData(ctx, db); err != nil {
+			return nil, err
 		}
 		return getDefaultStartBlockForChain(chainID), nil
 	}
@@ -107,18 +134,15 @@ func getStartBlockFromCheckpoint(ctx context.Context, db *sqlx.DB, rpcPool engin
 	if cfg.StartBlock > 0 {
 		return new(big.Int).SetInt64(cfg.StartBlock), nil
 	}
-	var lastSyncedBlock string
-	if err := db.GetContext(ctx, &lastSyncedBlock, "SELECT last_synced_block FROM sync_checkpoints WHERE chain_id = $1", chainID); err != nil {
-		slog.Debug("📊 No checkpoint found, starting from scratch", "chain_id", chainID, "err", err)
+	// 🧾 checkpoint / MAX(blocks) / 协调器游标统一由启动对账决定（策略见 engine.CheckpointAudit）
+	audit, err := engine.GetOrchestrator().AuditCheckpoints(ctx, db, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint audit: %w", err)
 	}
-	if lastSyncedBlock == "" {
+	if !audit.HasState() {
 		return getDefaultStartBlockForChain(chainID), nil
 	}
-	blockNum, ok := new(big.Int).SetString(lastSyncedBlock, 10)
-	if !ok {
-		return nil, fmt.Errorf("invalid checkpoint block number: %q", lastSyncedBlock)
-	}
-	return new(big.Int).Add(blockNum, big.NewInt(1)), nil
+	return big.NewInt(audit.Resolved + 1), nil
 }
 
 func getDefaultStartBlockForChain(chainID int64) *big.Int {
@@ -130,18 +154,28 @@ func getDefaultStartBlockForChain(chainID int64) *big.Int {
 	}
 }
 
-func runSequencerWithSelfHealing(ctx context.Context, sequencer *engine.Sequencer, wg *sync.WaitGroup) {
+// runSequencerWithSelfHealing 在监督者下运行 Sequencer：按 "sequencer_run" 策略指数退避重启，
+// 超出窗口内重启上限后上报 fatalErrCh
+func runSequencerWithSelfHealing(ctx context.Context, sequencer *engine.Sequencer, wg *sync.WaitGroup, fatalErrCh chan<- error) {
 	defer wg.Done()
+	recovery.Supervise(ctx, "sequencer_run", fatalErrCh, func() { sequencer.Run(ctx) })
+}
+
+// handleReorgEvents 消费 Sequencer 的 reorg 事件：原地重置 Fetcher（不重建实例，Results 通道保持不变），
+// 再从分叉点重新调度到已调度高度
+func handleReorgEvents(ctx context.Context, fetcher *engine.Fetcher, events <-chan engine.ReorgEvent) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		default:
-			recovery.WithRecoveryNamed("sequencer_run", func() { sequencer.Run(ctx) })
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(3 * time.Second):
+		case ev := <-events:
+			fetcher.Reset(ev.At)
+			end := new(big.Int).SetUint64(engine.GetOrchestrator().GetSnapshot().ScheduledHeight)
+			if end.Cmp(ev.At) < 0 {
+				end.Set(ev.At)
+			}
+			if err := fetcher.Reschedule(ctx, ev.At, end); err != nil && !errors.Is(err, engine.ErrBlockNotYetAvailable) {
+				slog.Warn("⚠️ [Reorg] Reschedule after fetcher reset failed", "from", ev.At.String(), "to", end.String(), "err", err)
 			}
 		}
 	}
@@ -153,58 +187,92 @@ func setupParentAnchor(ctx context.Context, db *sqlx.DB, rpcPool engine.RPCClien
 	}
 	parentNum := new(big.Int).Sub(startBlock, big.NewInt(1))
 	if parent, err := rpcPool.BlockByNumber(ctx, parentNum); err == nil && parent != nil {
-		if _, err := db.Exec("INSERT INTO blocks (number, hash, parent_hash, timestamp) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING",
-			parentNum.String(), parent.Hash().Hex(), parent.ParentHash().Hex(), parent.Time()); err != nil {
+		anchor := models.Block{
+			Number:           models.BigInt{Int: parentNum},
+			Hash:             parent.Hash().Hex(),
+			ParentHash:       parent.ParentHash().Hex(),
+			Timestamp:        parent.Time(),
+			GasLimit:         parent.GasLimit(),
+			GasUsed:          parent.GasUsed(),
+			TransactionCount: len(parent.Transactions()),
+		}
+		if baseFee := parent.BaseFee(); baseFee != nil {
+			anchor.BaseFeePerGas = &models.BigInt{Int: baseFee}
+		}
+		if err := engine.NewStore(db).InsertBlocks(ctx, []models.Block{anchor}); err != nil {
 			slog.Warn("❌ Failed to insert parent anchor", "err", err, "block", parentNum.String())
 		}
 	}
 }
 
-func continuousTailFollow(ctx context.Context, fetcher *engine.Fetcher, rpcPool engine.RPCClient, startBlock *big.Int) {
+func continuousTailFollow(ctx context.Context, fetcher *engine.Fetcher, rpcPool engine.RPCClient, startBlock *big.Int, chainReset *chainResetHandler) {
 	lastScheduled := new(big.Int).Sub(startBlock, big.NewInt(1))
-	tickerInterval := 500 * time.Millisecond
-	schedulingWindow := big.NewInt(10)
-	if cfg.ChainID == 31337 {
-		tickerInterval = 100 * time.Millisecond
-		schedulingWindow = big.NewInt(100)
+	// 💤 休眠模式下轮询间隔逐级衰减，用户活动唤醒时立即恢复
+	poller := engine.NewEcoPoller("tail_follow", engine.GetChainProfile(cfg.ChainID).TailPollInterval(), cfg.EcoPollSteps, engine.GetOrchestrator())
+	// 📡 配置了 WSS 时由 newHeads 推送驱动，断线期间自动回落到轮询
+	listener := startHeadListener(ctx)
+	if listener != nil {
+		defer listener.Stop() // 监督者重启时旧订阅随本轮退出
 	}
-	ticker := time.NewTicker(tickerInterval)
+	follower := engine.NewHeadFollower(poller, rpcPool, listener, cfg.HeadSafetyPoll)
 
 	for {
-		select {
-		case <-ctx.Done():
+		tip, err := follower.Next(ctx)
+		if ctx.Err() != nil {
 			return
-		case <-ticker.C:
-			if tip, err := rpcPool.GetLatestBlockNumber(ctx); err == nil {
-				orch := engine.GetOrchestrator()
-				orch.UpdateChainHead(tip.Uint64())
-				snap := orch.GetSnapshot()
-				targetHeight := big.NewInt(int64(snap.TargetHeight))
-
-				if targetHeight.Cmp(lastScheduled) > 0 {
-					nextBlock := new(big.Int).Add(lastScheduled, big.NewInt(1))
-					aggressiveTarget := new(big.Int).Add(targetHeight, schedulingWindow)
-					if aggressiveTarget.Cmp(tip) > 0 {
-						aggressiveTarget = new(big.Int).Set(tip)
-					}
-					if nextBlock.Cmp(aggressiveTarget) <= 0 {
-						// 🔴 Critical Fix: 仅在调度成功时推进 lastScheduled
-						// 防止 Schedule 失败时跳过范围，造成数据缺口
-						if err := fetcher.Schedule(ctx, nextBlock, aggressiveTarget); err == nil {
-							lastScheduled.Set(aggressiveTarget)
-						} else {
-							slog.Warn("⚠️ [TailFollow] Schedule failed, keeping cursor",
-								"nextBlock", nextBlock,
-								"target", aggressiveTarget,
-								"err", err)
-						}
-					}
+		}
+		if err != nil {
+			continue
+		}
+		// 🔄 本地链重启：索引已归零，从区块 0 重新调度
+		if chainReset.check(ctx, tip.Uint64()) {
+			lastScheduled.SetInt64(-1)
+		}
+		orch := engine.GetOrchestrator()
+		orch.UpdateChainHead(tip.Uint64())
+		snap := orch.GetSnapshot()
+		targetHeight := big.NewInt(int64(snap.TargetHeight))
+
+		// 只调度到 TargetHeight（链高 - SafetyBuffer）；Fetcher 会按最新快照再次截断，
+		// 因此以实际调度到的高度推进游标，被截断的部分下个 tick 继续
+		if targetHeight.Cmp(lastScheduled) > 0 {
+			nextBlock := new(big.Int).Add(lastScheduled, big.NewInt(1))
+			// 🔴 Critical Fix: 仅在调度成功时推进 lastScheduled
+			// 防止 Schedule 失败时跳过范围，造成数据缺口
+			scheduledEnd, err := fetcher.ScheduleCapped(engine.WithScheduleSource(ctx, engine.ScheduleSourceTailFollow), nextBlock, targetHeight)
+			switch {
+			case err == nil:
+				if scheduledEnd.Cmp(lastScheduled) > 0 {
+					lastScheduled.Set(scheduledEnd)
+					orch.NotifyScheduled(scheduledEnd.Uint64())
 				}
+			case errors.Is(err, engine.ErrBlockNotYetAvailable):
+				// 安全垫刚被调大，目标高度回落到游标之下，等待链继续前进
+			default:
+				slog.Warn("⚠️ [TailFollow] Schedule failed, keeping cursor",
+					"nextBlock", nextBlock,
+					"target", targetHeight,
+					"err", err)
 			}
 		}
 	}
 }
 
+// startHeadListener 按配置启动 newHeads 订阅；未配置 WSS_URL 或关闭 HEAD_SUBSCRIBE 时返回 nil（纯轮询）
+func startHeadListener(ctx context.Context) *engine.WSSListener {
+	if !cfg.HeadSubscribe || cfg.WSSURL == "" {
+		return nil
+	}
+	listener, err := engine.NewWSSListener(cfg.WSSURL)
+	if err != nil {
+		slog.Warn("📡 [TailFollow] WSS head subscription disabled", "err", err)
+		return nil
+	}
+	listener.Start(ctx)
+	slog.Info("📡 [TailFollow] Head tracking via WSS newHeads", "safety_poll", cfg.HeadSafetyPoll)
+	return listener
+}
+
 // AlignAnvilData 强制对齐 Anvil 数据：如果 DB 高度 > RPC 高度，则削峰
 func AlignAnvilData(ctx context.Context, db *sqlx.DB, rpcPool engine.RPCClient) {
 	tip, err := rpcPool.GetLatestBlockNumber(ctx)
@@ -214,12 +282,14 @@ func AlignAnvilData(ctx context.Context, db *sqlx.DB, rpcPool engine.RPCClient)
 	}
 	rpcHeight := tip.Uint64()
 
-	var dbHeight uint64
-	err = db.GetContext(ctx, &dbHeight, "SELECT COALESCE(MAX(number), 0) FROM blocks")
+	store := engine.NewStore(db)
+	maxStored, err := store.GetMaxStoredBlock(ctx)
 	if err != nil {
 		slog.Error("⚠️ [AlignAnvil] Failed to get DB height", "err", err)
 		return
 	}
+	// #nosec G115 - MAX(number) 非负
+	dbHeight := uint64(maxStored)
 
 	if dbHeight > rpcHeight {
 		slog.Warn("🚨 [AlignAnvil] DATA INVERSION DETECTED",
@@ -229,22 +299,31 @@ func AlignAnvilData(ctx context.Context, db *sqlx.DB, rpcPool engine.RPCClient)
 
 		// 削峰填谷：删除所有高于 RPC 的数据
 		slog.Warn("🔪 [AlignAnvil] Executing CUTOFF...")
-		_, err = db.ExecContext(ctx, "DELETE FROM blocks WHERE number > $1", rpcHeight)
-		if err != nil {
+		// 同一事务内删除区块/转账、撤销日统计并重置 Checkpoint
+		if err := store.RollbackAbove(ctx, cfg.ChainID, tip.Int64()); err != nil {
 			slog.Error("❌ [AlignAnvil] Cutoff failed", "err", err)
 			return
 		}
-		// Transfers 会级联删除 (ON DELETE CASCADE)
-
-		// 重置 Checkpoint
-		_, err = db.ExecContext(ctx, "UPDATE sync_checkpoints SET last_synced_block = $1 WHERE chain_id = 31337", rpcHeight)
-		if err != nil {
-			slog.Error("❌ [AlignAnvil] Checkpoint reset failed", "err", err)
-			return
-		}
 
 		slog.Info("✅ [AlignAnvil] Data successfully aligned to RPC height", "new_height", rpcHeight)
 	} else {
 		slog.Info("✅ [AlignAnvil] Data integrity check passed", "db", dbHeight, "rpc", rpcHeight)
 	}
 }
+
+// startDeFiSimulator 启动 DeFi 模拟器，其合成转账经 Processor 挂接到区块后随正常流水线落库
+func startDeFiSimulator(ctx context.Context, processor *engine.Processor) {
+	sim, err := engine.NewDeFiSimulator(cfg.RPCURLs[0], big.NewInt(cfg.ChainID), true)
+	if err != nil {
+		slog.Warn("🏭 DeFi simulator unavailable", "err", err)
+		return
+	}
+	injectChan := make(chan *engine.SynthesizedTransfer, 1024)
+	processor.ConsumeSynthesized(ctx, injectChan)
+	sim.Start(injectChan)
+	go func() {
+		<-ctx.Done()
+		sim.Stop()
+	}()
+	slog.Info("🏭 DeFi simulator started", "persist", true)
+}
@@ -23,5 +23,6 @@ var (
 	cfg               *config.Config
 	selfHealingEvents atomic.Uint64
 	forceFrom         string
+	runMode           = "index"                      // -mode 参数：index / replay / soak
 	Version           = "v2.2.0-intelligence-engine" // 🚀 工业级版本号
 )
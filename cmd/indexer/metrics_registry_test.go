@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"web3-indexer-go/internal/emulator"
+	"web3-indexer-go/internal/engine"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUseChainRegistry_EveryFamilyLabelled 按 main 的启动顺序安装注册表后注册全部指标，
+// 采集结果（即 /metrics 与指标推送看到的）每个指标族的每条样本都带 chain_id
+func TestUseChainRegistry_EveryFamilyLabelled(t *testing.T) {
+	registerer, gatherer := prometheus.DefaultRegisterer, prometheus.DefaultGatherer
+	t.Cleanup(func() {
+		prometheus.DefaultRegisterer, prometheus.DefaultGatherer = registerer, gatherer
+	})
+
+	engine.UseChainRegistry(11155111)
+	emulator.RegisterMetrics()
+	engine.NewMetrics().RecordBlockProcessed(0)
+	promhttp.Handler() // 注册 promhttp_metric_handler_* 指标
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	seen := make(map[string]bool, len(families))
+	for _, family := range families {
+		seen[family.GetName()] = true
+		for _, metric := range family.GetMetric() {
+			chainID := ""
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "chain_id" {
+					chainID = label.GetValue()
+				}
+			}
+			assert.Equal(t, "11155111", chainID, family.GetName())
+		}
+	}
+	for _, name := range []string{
+		"indexer_blocks_processed_total",
+		"indexer_emulator_tx_sent_total",
+		"go_goroutines",
+		"process_cpu_seconds_total",
+		"promhttp_metric_handler_requests_total",
+	} {
+		assert.True(t, seen[name], "%s is gathered", name)
+	}
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/recovery"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	// chainScrapeTimeout 聚合 /metrics、/api/networks 时单条链的请求超时：停滞的链只会缺席本次结果
+	chainScrapeTimeout = 2 * time.Second
+	// chainStopGrace 收到退出信号后等待子进程优雅关闭（其自身关闭流程上限 15s）的时间
+	chainStopGrace = 20 * time.Second
+)
+
+// chainGroup 多链模式下一条链的独立 goroutine 组：以子进程运行该链完整的索引流水线
+// （Fetcher / Sequencer / Processor / AsyncWriter、RPC 池、DB 连接与全部单例都不与其他链共享），
+// 退出后由监督者按退避重启。某条链的 RPC 故障或崩溃只影响它自己的子进程
+type chainGroup struct {
+	chainID  int64
+	port     string
+	env      []string
+	client   *http.Client
+	proxy    *httputil.ReverseProxy
+	up       prometheus.Gauge
+	restarts prometheus.Counter
+}
+
+// chainGroupEnv 生成子进程环境：去掉 CHAINS 与所有 CHAIN_<id>_ 覆盖项，再把本链的 CHAIN_<id>_KEY 展开为 KEY，
+// 并设置 CHAIN_ID / PORT（CHAIN_<id>_PORT 未配置时使用 defaultPort）。
+// blocks 等表不带 chain_id，因此每条链必须配置自己的 RPC_URLS 与 DATABASE_URL
+func chainGroupEnv(chainID int64, defaultPort string, environ []string) (env []string, port string, err error) {
+	id := strconv.FormatInt(chainID, 10)
+	overrides := make(map[string]string)
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		if key == "CHAINS" {
+			continue
+		}
+		if owner, name, ok := chainOverride(key); ok {
+			if owner == id {
+				overrides[name] = value
+			}
+			continue
+		}
+		env = append(env, kv)
+	}
+	for _, required := range []string{"RPC_URLS", "DATABASE_URL"} {
+		if overrides[required] == "" {
+			return nil, "", fmt.Errorf("chain %d: CHAIN_%d_%s is required in multi-chain mode", chainID, chainID, required)
+		}
+	}
+	port = defaultPort
+	if p := overrides["PORT"]; p != "" {
+		port = p
+	}
+	overrides["PORT"] = port
+	overrides["CHAIN_ID"] = id
+	for name, value := range overrides {
+		env = append(env, name+"="+value) // exec 对重复键取最后一个
+	}
+	return env, port, nil
+}
+
+// chainOverride 解析 CHAIN_<id>_<KEY> 形式的环境变量名（CHAIN_ID 等不是覆盖项）
+func chainOverride(key string) (chainID, name string, ok bool) {
+	rest, found := strings.CutPrefix(key, "CHAIN_")
+	if !found {
+		return "", "", false
+	}
+	chainID, name, found = strings.Cut(rest, "_")
+	if !found || name == "" {
+		return "", "", false
+	}
+	if _, err := strconv.ParseUint(chainID, 10, 64); err != nil {
+		return "", "", false
+	}
+	return chainID, name, true
+}
+
+// newChainGroups 为每条链分配端口（PORT+1 起递增）与环境，并在 reg 上注册带 chain_id 的组级指标
+func newChainGroups(chains []int64, basePort string, environ []string, reg prometheus.Registerer) ([]*chainGroup, error) {
+	base, err := strconv.Atoi(basePort)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PORT %q: %w", basePort, err)
+	}
+	up := promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "indexer_chain_group_up",
+		Help: "Whether the chain's pipeline process is running (1) or restarting (0)",
+	}, []string{"chain_id"})
+	restarts := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "indexer_chain_group_restarts_total",
+		Help: "Number of times the chain's pipeline process exited and was restarted",
+	}, []string{"chain_id"})
+
+	groups := make([]*chainGroup, 0, len(chains))
+	seen := make(map[int64]bool, len(chains))
+	for i, chainID := range chains {
+		if seen[chainID] {
+			return nil, fmt.Errorf("chain %d is listed twice in CHAINS", chainID)
+		}
+		seen[chainID] = true
+		env, port, err := chainGroupEnv(chainID, strconv.Itoa(base+1+i), environ)
+		if err != nil {
+			return nil, err
+		}
+		target := &url.URL{Scheme: "http", Host: "127.0.0.1:" + port}
+		g := &chainGroup{
+			chainID:  chainID,
+			port:     port,
+			env:      env,
+			client:   &http.Client{Timeout: chainScrapeTimeout},
+			proxy:    httputil.NewSingleHostReverseProxy(target),
+			up:       up.WithLabelValues(strconv.FormatInt(chainID, 10)),
+			restarts: restarts.WithLabelValues(strconv.FormatInt(chainID, 10)),
+		}
+		g.proxy.ErrorHandler = func(w http.ResponseWriter, _ *http.Request, err error) {
+			slog.Warn("chain_proxy_failed", "chain_id", chainID, "err", err)
+			http.Error(w, "chain "+strconv.FormatInt(chainID, 10)+" is unavailable (restarting)", http.StatusBadGateway)
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// baseURL 子进程 API 地址
+func (g *chainGroup) baseURL() string {
+	return "http://127.0.0.1:" + g.port
+}
+
+// run 在监督者下运行本链的子进程直至 ctx 取消（不限重启次数：单链反复崩溃不应拖垮其他链）
+func (g *chainGroup) run(ctx context.Context, exe string, args []string) {
+	name := "chain_" + strconv.FormatInt(g.chainID, 10)
+	recovery.SetRestartPolicy(name, recovery.RestartPolicy{
+		Window:         5 * time.Minute,
+		InitialBackoff: 3 * time.Second,
+		MaxBackoff:     60 * time.Second,
+	})
+	recovery.Supervise(ctx, name, nil, func() { g.runOnce(ctx, exe, args) })
+}
+
+// runOnce 启动一次子进程并等待其退出；ctx 取消时先发 SIGTERM，超过 chainStopGrace 再强杀
+func (g *chainGroup) runOnce(ctx context.Context, exe string, args []string) {
+	cmd := exec.CommandContext(ctx, exe, args...)
+	cmd.Env = g.env
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+	cmd.WaitDelay = chainStopGrace
+
+	if err := cmd.Start(); err != nil {
+		g.restarts.Inc()
+		slog.Error("chain_group_start_failed", "chain_id", g.chainID, "err", err)
+		return
+	}
+	g.up.Set(1)
+	slog.Info("🔗 Chain pipeline started", "chain_id", g.chainID, "pid", cmd.Process.Pid, "port", g.port)
+	err := cmd.Wait()
+	g.up.Set(0)
+	if ctx.Err() != nil {
+		slog.Info("🔗 Chain pipeline stopped", "chain_id", g.chainID)
+		return
+	}
+	g.restarts.Inc()
+	slog.Warn("chain_group_exited", "chain_id", g.chainID, "err", err)
+}
+
+// Gather 抓取子进程 /metrics（样本已由子进程的 UseChainRegistry 打上 chain_id）。
+// 子进程重启中或无响应时返回空：该链暂缺样本，其他链照常输出
+func (g *chainGroup) Gather() ([]*dto.MetricFamily, error) {
+	resp, err := g.client.Get(g.baseURL() + "/metrics")
+	if err != nil {
+		slog.Debug("chain_metrics_unavailable", "chain_id", g.chainID, "err", err)
+		return nil, nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		slog.Debug("chain_metrics_unavailable", "chain_id", g.chainID, "status", resp.StatusCode)
+		return nil, nil
+	}
+	var parser expfmt.TextParser
+	parsed, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("chain %d metrics: %w", g.chainID, err)
+	}
+	families := make([]*dto.MetricFamily, 0, len(parsed))
+	for _, family := range parsed {
+		families = append(families, family)
+	}
+	return families, nil
+}
+
+// gatherChains 并发抓取各链指标并与监督者自身的组级指标合并（同名指标族按 chain_id 区分）
+func gatherChains(groups []*chainGroup, own prometheus.Gatherer) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		results := make(prometheus.Gatherers, len(groups)+1)
+		results[0] = own
+		var wg sync.WaitGroup
+		for i, g := range groups {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				families, err := g.Gather()
+				results[i+1] = prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) { return families, err })
+			}()
+		}
+		wg.Wait()
+		return results.Gather()
+	})
+}
+
+// networks 取本链子进程 /api/networks 中的本链条目；子进程无响应时以 state=unavailable 返回
+func (g *chainGroup) networks(ctx context.Context) NetworkStatus {
+	profile := engine.GetChainProfile(g.chainID)
+	fallback := NetworkStatus{
+		ChainID:          g.chainID,
+		Name:             profile.Name,
+		NativeSymbol:     profile.NativeSymbol,
+		State:            "unavailable",
+		BlockTimeSeconds: profile.BlockTime.Seconds(),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.baseURL()+"/api/networks", nil)
+	if err != nil {
+		return fallback
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fallback
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var body NetworksResponse
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&body) != nil {
+		return fallback
+	}
+	for _, n := range body.Networks {
+		if n.ChainID == g.chainID {
+			return n
+		}
+	}
+	return fallback
+}
+
+// handleMultiChainNetworks 多链模式下的 /api/networks：并发汇总各链进度，首条链为主链
+func handleMultiChainNetworks(w http.ResponseWriter, r *http.Request, groups []*chainGroup) {
+	resp := NetworksResponse{Networks: make([]NetworkStatus, len(groups))}
+	var wg sync.WaitGroup
+	for i, g := range groups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp.Networks[i] = g.networks(r.Context())
+		}()
+	}
+	wg.Wait()
+	for i := range resp.Networks {
+		resp.Networks[i].Primary = i == 0
+	}
+	if len(groups) > 0 {
+		resp.Primary = groups[0].chainID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("failed_to_encode_networks", "err", err)
+	}
+}
+
+// newMultiChainHandler 多链入口：/metrics 汇总各链指标，/api/networks 汇总各链进度，
+// 其余请求按 ?chain= 转发给对应链的子进程（不带参数时转发给主链，语义同 ChainScopeMiddleware）
+func newMultiChainHandler(groups []*chainGroup, own prometheus.Gatherer) http.Handler {
+	byID := make(map[int64]*chainGroup, len(groups))
+	for _, g := range groups {
+		byID[g.chainID] = g
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(gatherChains(groups, own), promhttp.HandlerOpts{
+		ErrorHandling: promhttp.ContinueOnError,
+	}))
+	mux.HandleFunc("/api/networks", func(w http.ResponseWriter, r *http.Request) {
+		handleMultiChainNetworks(w, r, groups)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		target := groups[0]
+		if v := r.URL.Query().Get("chain"); v != "" {
+			id, ok := parseChainParam(v)
+			if !ok {
+				http.Error(w, "invalid chain (want a chain id or a known network name)", http.StatusBadRequest)
+				return
+			}
+			if target, ok = byID[id]; !ok {
+				http.Error(w, "chain "+strconv.FormatInt(id, 10)+" is not indexed by this instance (see /api/networks)", http.StatusNotFound)
+				return
+			}
+		}
+		target.proxy.ServeHTTP(w, r)
+	})
+	return mux
+}
+
+// runMultiChain 多链模式（CHAINS 配置多条链）：每条链一个独立 goroutine 组与子进程，
+// 本进程只负责监督、指标汇总与请求路由，不运行任何流水线组件
+func runMultiChain(chains []int64) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	reg := prometheus.NewRegistry()
+	groups, err := newChainGroups(chains, cfg.Port, os.Environ(), reg)
+	if err != nil {
+		slog.Error("❌ Invalid multi-chain configuration", "err", err)
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("resolve executable: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, g := range groups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.run(ctx, exe, os.Args[1:])
+		}()
+	}
+
+	srv := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           newMultiChainHandler(groups, reg),
+		ReadHeaderTimeout: 5 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+	recovery.WithRecovery(func() {
+		slog.Info("🌐 Multi-chain router starting", "port", cfg.Port, "chains", chains)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("multichain_router_fail", "err", err)
+		}
+	}, "multichain_router")
+
+	<-ctx.Done()
+	slog.Info("🛑 Signal received, stopping chain pipelines...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("🛑 Multi-chain router shutdown error", "err", err)
+	}
+	wg.Wait()
+	slog.Info("🏁 All chain pipelines stopped. Goodbye!")
+	return nil
+}
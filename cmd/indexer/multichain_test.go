@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"web3-indexer-go/internal/engine"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainGroupEnv_ExpandsOwnOverrides(t *testing.T) {
+	environ := []string{
+		"CHAINS=1,11155111",
+		"CHAIN_ID=31337",
+		"LOG_LEVEL=info",
+		"CHAIN_1_RPC_URLS=https://mainnet.example",
+		"CHAIN_1_DATABASE_URL=postgres://db/mainnet",
+		"CHAIN_1_LOG_LEVEL=debug",
+		"CHAIN_11155111_RPC_URLS=https://sepolia.example",
+	}
+
+	env, port, err := chainGroupEnv(1, "8081", environ)
+	require.NoError(t, err)
+	assert.Equal(t, "8081", port)
+	vars := envMap(env)
+	assert.Equal(t, "1", vars["CHAIN_ID"])
+	assert.Equal(t, "8081", vars["PORT"])
+	assert.Equal(t, "https://mainnet.example", vars["RPC_URLS"])
+	assert.Equal(t, "postgres://db/mainnet", vars["DATABASE_URL"])
+	assert.Equal(t, "debug", vars["LOG_LEVEL"], "per-chain override wins over the shared value")
+	assert.NotContains(t, vars, "CHAINS", "child must run a single-chain pipeline")
+	assert.NotContains(t, vars, "CHAIN_11155111_RPC_URLS")
+
+	_, _, err = chainGroupEnv(11155111, "8082", environ)
+	require.Error(t, err, "a chain without its own DATABASE_URL would share the blocks table")
+	assert.Contains(t, err.Error(), "CHAIN_11155111_DATABASE_URL")
+}
+
+// envMap 按 exec 的语义（重复键取最后一个）展开环境
+func envMap(env []string) map[string]string {
+	out := make(map[string]string, len(env))
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		out[key] = value
+	}
+	return out
+}
+
+// TestMultiChainHandler_ChainOutageIsIsolated 一条链的子进程不可用时，
+// 另一条链的指标、进度与 API 仍正常经监督者对外提供
+func TestMultiChainHandler_ChainOutageIsIsolated(t *testing.T) {
+	labelled, childGatherer := engine.NewChainRegistry(1)
+	promauto.With(labelled).NewCounter(prometheus.CounterOpts{
+		Name: "indexer_blocks_processed_total",
+		Help: "Total number of blocks successfully processed",
+	}).Add(7)
+	child := http.NewServeMux()
+	child.Handle("/metrics", promhttp.HandlerFor(childGatherer, promhttp.HandlerOpts{}))
+	child.HandleFunc("/api/networks", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(NetworksResponse{Primary: 1, Networks: []NetworkStatus{
+			{ChainID: 1, Primary: true, State: "live", LatestBlock: 100, LatestIndexed: 99},
+		}})
+	})
+	child.HandleFunc("/api/status", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "chain-1-status")
+	})
+	healthy := httptest.NewServer(child)
+	defer healthy.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close() // 模拟 RPC 故障后反复崩溃、端口无人监听的链
+
+	environ := []string{
+		"CHAIN_1_RPC_URLS=https://mainnet.example",
+		"CHAIN_1_DATABASE_URL=postgres://db/mainnet",
+		"CHAIN_1_PORT=" + serverPort(t, healthy.URL),
+		"CHAIN_11155111_RPC_URLS=https://sepolia.example",
+		"CHAIN_11155111_DATABASE_URL=postgres://db/sepolia",
+		"CHAIN_11155111_PORT=" + serverPort(t, down.URL),
+	}
+	reg := prometheus.NewRegistry()
+	groups, err := newChainGroups([]int64{1, 11155111}, "8080", environ, reg)
+	require.NoError(t, err)
+	groups[0].up.Set(1)
+	groups[1].restarts.Inc()
+	handler := newMultiChainHandler(groups, reg)
+
+	t.Run("metrics", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+		assert.Contains(t, body, `indexer_blocks_processed_total{chain_id="1"} 7`)
+		assert.Contains(t, body, `indexer_chain_group_up{chain_id="1"} 1`)
+		assert.Contains(t, body, `indexer_chain_group_restarts_total{chain_id="11155111"} 1`)
+	})
+
+	t.Run("networks", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/networks", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp NetworksResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, int64(1), resp.Primary)
+		require.Len(t, resp.Networks, 2)
+		assert.Equal(t, "live", resp.Networks[0].State)
+		assert.Equal(t, uint64(99), uint64(resp.Networks[0].LatestIndexed))
+		assert.True(t, resp.Networks[0].Primary)
+		assert.Equal(t, int64(11155111), resp.Networks[1].ChainID)
+		assert.Equal(t, "unavailable", resp.Networks[1].State)
+		assert.False(t, resp.Networks[1].Primary)
+	})
+
+	t.Run("routing", func(t *testing.T) {
+		for _, tc := range []struct {
+			query string
+			code  int
+		}{
+			{"", http.StatusOK},
+			{"?chain=1", http.StatusOK},
+			{"?chain=sepolia", http.StatusBadGateway},
+			{"?chain=11155111", http.StatusBadGateway},
+			{"?chain=137", http.StatusNotFound},
+			{"?chain=nope", http.StatusBadRequest},
+		} {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/status"+tc.query, nil))
+			assert.Equal(t, tc.code, rec.Code, tc.query)
+			if tc.code == http.StatusOK {
+				assert.Equal(t, "chain-1-status", rec.Body.String(), tc.query)
+			}
+		}
+	})
+}
+
+func serverPort(t *testing.T, rawURL string) string {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return u.Port()
+}
+
+func TestNewChainGroups_RejectsDuplicateChains(t *testing.T) {
+	environ := []string{
+		"CHAIN_1_RPC_URLS=https://mainnet.example",
+		"CHAIN_1_DATABASE_URL=postgres://db/mainnet",
+	}
+	_, err := newChainGroups([]int64{1, 1}, "8080", environ, prometheus.NewRegistry())
+	require.Error(t, err)
+
+	groups, err := newChainGroups([]int64{1}, "8080", environ, prometheus.NewRegistry())
+	require.NoError(t, err)
+	assert.Equal(t, "8081", groups[0].port, "children listen on PORT+1 onwards")
+}
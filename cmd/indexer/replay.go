@@ -11,16 +11,81 @@ import (
 )
 
 // RunReplayMode 启动高保真回放模式
-func RunReplayMode(ctx context.Context, path string, speed float64, processor *engine.Processor) error {
+func RunReplayMode(ctx context.Context, path string, speed float64, chaos engine.ReplayChaos, processor *engine.Processor) error {
 	slog.Info("🎬 [REPLAY] Initializing replay machine", "file", path, "speed", speed)
 
 	// 1. 构造回放源
-	source, err := engine.NewLz4ReplaySource(path, speed)
+	source, err := engine.OpenReplaySource(ctx, path, speed, replayS3Config())
 	if err != nil {
 		return fmt.Errorf("failed to open replay file: %w", err)
 	}
 	defer source.Close()
+	source.SetChaos(chaos)
 
+	_, err = playReplay(ctx, source, processor, chaos)
+	return err
+}
+
+// replayS3Config s3:// 回放源的端点与凭证（Bucket 由回放 URL 指定）
+func replayS3Config() engine.S3UploaderConfig {
+	return engine.S3UploaderConfig{
+		Endpoint:  cfg.ReplayS3Endpoint,
+		Region:    cfg.ReplayS3Region,
+		AccessKey: cfg.ReplayS3AccessKey,
+		SecretKey: cfg.ReplayS3SecretKey,
+	}
+}
+
+// playReplay 配置了延迟/抖动/乱序时以推送模式经 Sequencer 回放，否则按区块范围拉取直接灌入处理器
+func playReplay(ctx context.Context, source *engine.Lz4ReplaySource, processor *engine.Processor, chaos engine.ReplayChaos) (int, error) {
+	if chaos.Enabled() {
+		slog.Info("🌪️ [REPLAY] Chaos delivery enabled",
+			"latency", chaos.Latency, "jitter", chaos.Jitter, "reorder_window", chaos.ReorderWindow, "seed", chaos.Seed)
+		return playReplayStream(ctx, source, processor)
+	}
+	return playReplaySource(ctx, source, processor)
+}
+
+// playReplayStream 推送模式：回放源按扰动配置投递到 Sequencer，由其缓冲乱序块、按序交给处理器；
+// 返回按序处理到的区块数（文件中的缺口由 Sequencer 的 gap 逻辑等待后跳过）
+func playReplayStream(ctx context.Context, source *engine.Lz4ReplaySource, processor *engine.Processor) (int, error) {
+	first, err := source.PeekFirstBlock()
+	if err != nil {
+		return 0, err
+	}
+
+	blocks := make(chan engine.BlockData, 256)
+	fatalErrCh := make(chan error, 1)
+	sequencer := engine.NewSequencer(processor, first, 0, blocks, fatalErrCh, engine.GetMetrics())
+
+	streamErr := make(chan error, 1)
+	go func() {
+		defer close(blocks)
+		streamErr <- source.StreamBlocks(ctx, blocks)
+	}()
+
+	slog.Info("🚀 [REPLAY] Streaming playback started", "first_block", first.String())
+	sequencer.Run(ctx)
+
+	played := int(new(big.Int).Sub(sequencer.GetExpectedBlock(), first).Int64())
+	if n := sequencer.GetBufferSize(); n > 0 {
+		slog.Warn("⚠️ [REPLAY] Blocks left behind an unfilled gap at end of file", "buffered", n, "expected", sequencer.GetExpectedBlock().String())
+	}
+	engine.GetMetrics().UpdateReplayProgress(source.GetProgress())
+
+	select {
+	case err := <-fatalErrCh:
+		return played, err
+	default:
+	}
+	if err := <-streamErr; err != nil && ctx.Err() == nil {
+		return played, err
+	}
+	return played, nil
+}
+
+// playReplaySource 从头到尾播放一遍回放源，返回灌入处理器的区块数（ctx 取消时正常返回）
+func playReplaySource(ctx context.Context, source *engine.Lz4ReplaySource, processor *engine.Processor) (int, error) {
 	// 2. 获取进度报告器
 	metrics := engine.GetMetrics()
 
@@ -30,6 +95,7 @@ func RunReplayMode(ctx context.Context, path string, speed float64, processor *e
 	// 每次回放 10 块
 	batchSize := big.NewInt(10)
 	currentBlock := big.NewInt(0) // 从头开始扫描文件
+	played := 0
 
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
@@ -38,7 +104,7 @@ func RunReplayMode(ctx context.Context, path string, speed float64, processor *e
 		select {
 		case <-ctx.Done():
 			slog.Info("🛑 [REPLAY] Playback interrupted by user")
-			return nil
+			return played, nil
 		case <-ticker.C:
 			// 从文件中提取下一批区块
 			end := new(big.Int).Add(currentBlock, batchSize)
@@ -46,7 +112,7 @@ func RunReplayMode(ctx context.Context, path string, speed float64, processor *e
 
 			if err != nil {
 				slog.Error("❌ [REPLAY] Read error", "err", err)
-				return err
+				return played, err
 			}
 
 			if len(blocks) == 0 {
@@ -54,7 +120,7 @@ func RunReplayMode(ctx context.Context, path string, speed float64, processor *e
 				// 我们暂时通过进度判断是否结束
 				if source.GetProgress() >= 99.9 {
 					slog.Info("🏁 [REPLAY] End of trajectory reached. Mission accomplished.")
-					return nil
+					return played, nil
 				}
 				// 没数据但没结束，继续往后探
 				currentBlock.Add(end, big.NewInt(1))
@@ -65,6 +131,7 @@ func RunReplayMode(ctx context.Context, path string, speed float64, processor *e
 			if err := processor.ProcessBatch(ctx, blocks, 0); err != nil {
 				slog.Error("❌ [REPLAY] Processing failed", "err", err)
 			}
+			played += len(blocks)
 
 			// 5. 更新进度指标
 			progress := source.GetProgress()
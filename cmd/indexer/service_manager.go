@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/recovery"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -20,16 +21,20 @@ type ServiceManager struct {
 	reconciler  *engine.Reconciler
 	chainID     int64
 	lazyManager *engine.LazyManager // 🔥 新增：用于通知区块活动
+	chainReset  *chainResetHandler  // 本地开发链重启检测（非本地链为 nil）
 }
 
 func NewServiceManager(db *sqlx.DB, rpcPool engine.RPCClient, chainID int64, retryQueueSize int, rps, burst, concurrency int, enableSimulator bool, networkMode string, enableRecording bool, recordingPath string) *ServiceManager {
 	// ✨ 使用工业级限流器创建 Fetcher
 	fetcher := engine.NewFetcherWithLimiter(rpcPool, concurrency, rps, burst)
+	if err := fetcher.EnableCapture(""); err != nil {
+		engine.Logger.Warn("failed_to_initialize_recorder", "err", err)
+	}
 	processor := engine.NewProcessor(db, rpcPool, retryQueueSize, chainID, enableSimulator, networkMode)
 
 	// 🚀 初始化物理分发 Sink
 	if enableRecording && recordingPath != "" {
-		if lz4Sink, err := engine.NewLz4Sink(recordingPath); err == nil {
+		if lz4Sink, err := engine.NewLz4Sink(recordingPath, chainID); err == nil {
 			processor.SetSink(lz4Sink)
 			engine.Logger.Info("🎙️ [Recorder] LZ4 Recording ACTIVE", "path", recordingPath)
 		} else {
@@ -58,13 +63,12 @@ func (sm *ServiceManager) GetStartBlock(ctx context.Context, forceFrom string, r
 }
 
 // StartTailFollow 启动持续追踪
-func (sm *ServiceManager) StartTailFollow(ctx context.Context, startBlock *big.Int) {
+func (sm *ServiceManager) StartTailFollow(ctx context.Context, startBlock *big.Int, fatalErrCh chan<- error) {
 	slog.Info("🎬 [StartTailFollow] Function called", "start_block", startBlock.String())
 
 	// 🚀 工业级优化：Gap Check (自动补洞)
 	// 检查数据库中已有的最大区块号，看是否与本次 startBlock 存在断层
-	var maxInDB int64
-	err := sm.db.GetContext(ctx, &maxInDB, "SELECT COALESCE(MAX(number), 0) FROM blocks")
+	maxInDB, err := sm.Processor.GetRepoAdapter().GetMaxStoredBlock(ctx)
 	if err == nil && maxInDB > 0 {
 		startNum := startBlock.Int64()
 		if startNum > maxInDB+1 {
@@ -74,19 +78,32 @@ func (sm *ServiceManager) StartTailFollow(ctx context.Context, startBlock *big.I
 				"start_at", startNum,
 				"gap_blocks", gapSize)
 
-			// 启动后台协程回填 Gap，不阻塞主 Tail 流程
+			// 启动后台协程回填 Gap，不阻塞主 Tail 流程（区间任务：worker 按子区间认领，不占用 jobs 通道）
 			go func() {
-				catchupCtx := context.Background()
-				if err := sm.fetcher.Schedule(catchupCtx, big.NewInt(maxInDB+1), big.NewInt(startNum-1)); err != nil {
+				catchupCtx := engine.WithScheduleSource(context.Background(), engine.ScheduleSourceCatchUp)
+				job, err := sm.fetcher.ScheduleRangeJob(catchupCtx, big.NewInt(maxInDB+1), big.NewInt(startNum-1))
+				if err != nil {
 					engine.Logger.Error("failed_to_schedule_catchup", "err", err)
+					return
+				}
+				if err := job.Wait(ctx); err != nil {
+					engine.Logger.Warn("catchup_range_job_incomplete", "job_id", job.ID, "err", err)
+					return
 				}
+				p := job.Progress()
+				engine.Logger.Info("🧩 Catch-up range job completed", "job_id", job.ID, "fetched", p.Fetched, "deduplicated", p.Skipped)
 			}()
 		}
 	}
 
 	// 启动后台指标上报
 	go sm.startMetricsReporter(ctx)
-	continuousTailFollow(ctx, sm.fetcher, sm.rpcPool, startBlock)
+
+	// 🔁 监督者重启时从 Orchestrator 调度游标续跑，避免重复调度已提交的范围
+	recovery.Supervise(ctx, "tail_follow", fatalErrCh, func() {
+		resumeAt := new(big.Int).SetUint64(engine.GetOrchestrator().ScheduleResumePoint(startBlock.Uint64()))
+		continuousTailFollow(ctx, sm.fetcher, sm.rpcPool, resumeAt, sm.chainReset)
+	})
 }
 
 // startMetricsReporter 定期上报系统指标到 Prometheus
@@ -121,8 +138,7 @@ func (sm *ServiceManager) startMetricsReporter(ctx context.Context) {
 			}
 
 			// 🔥 区块链活动检测（活动双重校验）
-			var currentMaxBlock int64
-			err := sm.db.GetContext(ctx, &currentMaxBlock, "SELECT COALESCE(MAX(number), 0) FROM blocks")
+			currentMaxBlock, err := sm.Processor.GetRepoAdapter().GetMaxStoredBlock(ctx)
 			if err == nil && currentMaxBlock > lastProcessedBlock {
 				// 有新区块被处理！通知 LazyManager
 				if sm.lazyManager != nil {
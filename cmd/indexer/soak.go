@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"web3-indexer-go/internal/engine"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// soakHeapGrowthWarn 相对第一轮的堆增长超过该比例时告警（疑似泄漏）
+const soakHeapGrowthWarn = 0.5
+
+// SoakPassReport 单轮回放的吞吐与内存快照（每轮一行写入 JSONL 报告）
+type SoakPassReport struct {
+	Pass        int       `json:"pass"`
+	StartedAt   time.Time `json:"started_at"`
+	DurationSec float64   `json:"duration_sec"`
+	Blocks      int       `json:"blocks"`
+	Transfers   uint64    `json:"transfers"`
+	BPS         float64   `json:"bps"`
+	TPS         float64   `json:"tps"`
+	HeapAllocMB float64   `json:"heap_alloc_mb"`
+	HeapSysMB   float64   `json:"heap_sys_mb"`
+	Goroutines  int       `json:"goroutines"`
+	NumGC       uint32    `json:"num_gc"`
+	HeapGrowth  float64   `json:"heap_growth"` // 相对第一轮 HeapAlloc 的增长比例
+	Error       string    `json:"error,omitempty"`
+	Interrupted bool      `json:"interrupted,omitempty"`
+}
+
+// RunSoakMode 循环回放同一文件直到 passes 轮（0 表示直到中断），每轮开始前清空数据库与协调器状态，
+// 轮末强制 GC 后记录堆与协程数，用于隔夜稳定性观察吞吐与内存趋势
+func RunSoakMode(ctx context.Context, db *sqlx.DB, path string, speed float64, chaos engine.ReplayChaos, passes int, reportPath string, processor *engine.Processor) error {
+	if reportPath == "" {
+		reportPath = filepath.Join("logs", fmt.Sprintf("soak_%s.jsonl", time.Now().Format("20060102_150405")))
+	}
+	if err := os.MkdirAll(filepath.Dir(reportPath), 0o750); err != nil {
+		return err
+	}
+	// #nosec G304 - 报告路径由操作者通过命令行指定
+	report, err := os.OpenFile(reportPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = report.Close() }()
+	enc := json.NewEncoder(report)
+
+	source, err := engine.OpenReplaySource(ctx, path, speed, replayS3Config())
+	if err != nil {
+		return fmt.Errorf("failed to open replay file: %w", err)
+	}
+	defer source.Close()
+	source.SetChaos(chaos)
+
+	slog.Info("🔁 [SOAK] Starting soak run", "file", path, "speed", speed, "passes", passes, "report", reportPath)
+
+	metrics := engine.GetMetrics()
+	var baselineHeap uint64
+	for pass := 1; passes == 0 || pass <= passes; pass++ {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := resetIndexedData(ctx, db); err != nil {
+			return err
+		}
+		engine.GetOrchestrator().ResetToZero()
+		if err := source.Reset(); err != nil {
+			return fmt.Errorf("rewind replay file: %w", err)
+		}
+
+		started := time.Now()
+		transfersBefore := metrics.GetTotalTransfersProcessed()
+		blocks, playErr := playReplay(ctx, source, processor, chaos)
+		elapsed := time.Since(started)
+
+		runtime.GC()
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		if pass == 1 {
+			baselineHeap = mem.HeapAlloc
+		}
+
+		r := SoakPassReport{
+			Pass:        pass,
+			StartedAt:   started,
+			DurationSec: elapsed.Seconds(),
+			Blocks:      blocks,
+			Transfers:   metrics.GetTotalTransfersProcessed() - transfersBefore,
+			HeapAllocMB: float64(mem.HeapAlloc) / (1 << 20),
+			HeapSysMB:   float64(mem.HeapSys) / (1 << 20),
+			Goroutines:  runtime.NumGoroutine(),
+			NumGC:       mem.NumGC,
+			Interrupted: ctx.Err() != nil,
+		}
+		if secs := elapsed.Seconds(); secs > 0 {
+			r.BPS = float64(r.Blocks) / secs
+			r.TPS = float64(r.Transfers) / secs
+		}
+		if baselineHeap > 0 {
+			r.HeapGrowth = float64(mem.HeapAlloc)/float64(baselineHeap) - 1
+		}
+		if playErr != nil {
+			r.Error = playErr.Error()
+		}
+		if err := enc.Encode(r); err != nil {
+			slog.Warn("⚠️ [SOAK] Failed to write pass report", "err", err)
+		}
+
+		attrs := []any{"pass", pass, "blocks", r.Blocks, "bps", r.BPS, "tps", r.TPS,
+			"heap_mb", r.HeapAllocMB, "goroutines", r.Goroutines, "heap_growth", r.HeapGrowth}
+		if r.HeapGrowth > soakHeapGrowthWarn {
+			slog.Warn("📈 [SOAK] Heap keeps growing across passes", attrs...)
+		} else {
+			slog.Info("🔁 [SOAK] Pass complete", attrs...)
+		}
+		if playErr != nil {
+			return playErr
+		}
+	}
+
+	slog.Info("🏁 [SOAK] Soak run finished", "report", reportPath)
+	return nil
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// IndexUsage 单个索引的大小与扫描次数（来自 pg_stat_user_indexes，计数自上次统计重置起累计）
+type IndexUsage struct {
+	Table      string `db:"table_name" json:"table"`
+	Name       string `db:"index_name" json:"index"`
+	Definition string `db:"definition" json:"definition"`
+	SizeBytes  int64  `db:"size_bytes" json:"size_bytes"`
+	Scans      int64  `db:"scans" json:"scans"`
+	Unique     bool   `db:"is_unique" json:"unique"`
+	Unused     bool   `db:"unused" json:"unused"` // 非唯一约束且从未被扫描，可考虑删除
+}
+
+// IndexReport 索引体检报告（/api/admin/db/indexes）
+type IndexReport struct {
+	StatsSince     *time.Time   `json:"stats_since,omitempty"` // pg_stat_database.stats_reset；nil 表示自建库起未重置
+	TotalSizeBytes int64        `json:"total_size_bytes"`
+	UnusedCount    int          `json:"unused_count"`
+	UnusedBytes    int64        `json:"unused_bytes"`
+	Indexes        []IndexUsage `json:"indexes"`
+}
+
+// QueryIndexReport 列出当前 schema 下全部索引（按大小降序）并标记未使用的索引。
+// 唯一索引承担约束语义，即使扫描次数为 0 也不计为未使用
+func QueryIndexReport(ctx context.Context, db *sqlx.DB) (IndexReport, error) {
+	var report IndexReport
+	err := sqlx.SelectContext(ctx, db, &report.Indexes, `
+		SELECT s.relname AS table_name, s.indexrelname AS index_name,
+			pg_get_indexdef(s.indexrelid) AS definition,
+			pg_relation_size(s.indexrelid) AS size_bytes,
+			s.idx_scan AS scans,
+			i.indisunique AS is_unique,
+			(s.idx_scan = 0 AND NOT i.indisunique) AS unused
+		FROM pg_stat_user_indexes s
+		JOIN pg_index i ON i.indexrelid = s.indexrelid
+		WHERE s.schemaname = current_schema()
+		ORDER BY pg_relation_size(s.indexrelid) DESC, s.indexrelname`)
+	if err != nil {
+		return report, err
+	}
+	if err := sqlx.GetContext(ctx, db, &report.StatsSince,
+		"SELECT stats_reset FROM pg_stat_database WHERE datname = current_database()"); err != nil {
+		return report, err
+	}
+	for _, idx := range report.Indexes {
+		report.TotalSizeBytes += idx.SizeBytes
+		if idx.Unused {
+			report.UnusedCount++
+			report.UnusedBytes += idx.SizeBytes
+		}
+	}
+	return report, nil
+}
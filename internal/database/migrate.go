@@ -0,0 +1,244 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+)
+
+// 表结构迁移模式（SCHEMA_MIGRATION_MODE）
+const (
+	MigrationModeBlocking = "blocking" // 全部步骤在同步开始前执行（默认）
+	MigrationModeOnline   = "online"   // 启动只建表补列，索引与回填在同步进行中执行
+)
+
+const (
+	// MaxWritePause 非并发安全步骤暂停落盘的上限；超时即取消该步骤并恢复写入（下次启动重试）
+	MaxWritePause = 2 * time.Minute
+	// migrationLockTimeout 在线补列的锁等待上限：排在长查询之后时放弃并重试，而不是阻塞后续读写
+	migrationLockTimeout = 2 * time.Second
+	// migrationLockRetries 在线补列因锁超时失败后的重试次数
+	migrationLockRetries = 5
+)
+
+// MigrationStep 一个表结构变更步骤
+type MigrationStep struct {
+	Name string
+	SQL  string
+	// Online 可与落盘并发执行（索引改为 CREATE INDEX CONCURRENTLY 构建）；否则执行期间暂停 AsyncWriter
+	Online bool
+}
+
+// WritePauser 在线迁移期间可暂停落盘的写入方（engine.AsyncWriter 实现）
+type WritePauser interface {
+	// PauseWrites 等待进行中的落盘事务结束后阻止新的事务开始
+	PauseWrites(ctx context.Context) error
+	ResumeWrites()
+}
+
+// 迁移步骤状态
+const (
+	StepPending = "pending"
+	StepRunning = "running"
+	StepDone    = "done"
+	StepFailed  = "failed"
+)
+
+// MigrationStepStatus 单个步骤的执行状态（/api/admin/db/migrations）
+type MigrationStepStatus struct {
+	Name          string `json:"name"`
+	Online        bool   `json:"online"`
+	State         string `json:"state"`
+	Error         string `json:"error,omitempty"`
+	DurationMs    int64  `json:"duration_ms,omitempty"`
+	WritesPauseMs int64  `json:"writes_paused_ms,omitempty"` // 非并发安全步骤暂停落盘的时长
+}
+
+// OnlineMigrator 在同步进行中执行耗时的表结构步骤：索引并发构建，非并发安全步骤前后暂停 / 恢复落盘
+type OnlineMigrator struct {
+	db     *sqlx.DB
+	steps  []MigrationStep
+	pauser WritePauser
+
+	mu     sync.Mutex
+	status []MigrationStepStatus
+}
+
+// NewOnlineMigrator 创建在线迁移器；pauser 为 nil 时非并发安全步骤直接执行（仅适用于没有写入方的场景）
+func NewOnlineMigrator(db *sqlx.DB, steps []MigrationStep, pauser WritePauser) *OnlineMigrator {
+	status := make([]MigrationStepStatus, len(steps))
+	for i, step := range steps {
+		status[i] = MigrationStepStatus{Name: step.Name, Online: step.Online, State: StepPending}
+	}
+	return &OnlineMigrator{db: db, steps: steps, pauser: pauser, status: status}
+}
+
+// Status 返回各步骤的执行状态快照
+func (m *OnlineMigrator) Status() []MigrationStepStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MigrationStepStatus(nil), m.status...)
+}
+
+// Run 按顺序执行全部步骤；单步失败只记录，不影响后续步骤。返回失败的步骤数
+func (m *OnlineMigrator) Run(ctx context.Context) int {
+	start := time.Now()
+	failed := 0
+	for i, step := range m.steps {
+		if ctx.Err() != nil {
+			break
+		}
+		m.update(i, func(s *MigrationStepStatus) { s.State = StepRunning })
+		stepStart := time.Now()
+		paused, err := m.runStep(ctx, step)
+		m.update(i, func(s *MigrationStepStatus) {
+			s.DurationMs = time.Since(stepStart).Milliseconds()
+			s.WritesPauseMs = paused.Milliseconds()
+			s.State = StepDone
+			if err != nil {
+				s.State, s.Error = StepFailed, err.Error()
+			}
+		})
+		if err != nil {
+			failed++
+			slog.Warn("⚠️ [Migration] Step failed", "step", step.Name, "online", step.Online, "err", err)
+		}
+	}
+	slog.Info("✅ [Migration] Online schema migration finished",
+		"steps", len(m.steps), "failed", failed, "duration", time.Since(start))
+	return failed
+}
+
+func (m *OnlineMigrator) update(i int, fn func(*MigrationStepStatus)) {
+	m.mu.Lock()
+	fn(&m.status[i])
+	m.mu.Unlock()
+}
+
+// runStep 在专用连接上关闭语句超时后执行步骤；返回暂停落盘的时长
+func (m *OnlineMigrator) runStep(ctx context.Context, step MigrationStep) (time.Duration, error) {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("acquire connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.ExecContext(ctx, "SET statement_timeout = 0"); err != nil {
+		return 0, fmt.Errorf("disable statement timeout: %w", err)
+	}
+	defer func() { _, _ = conn.ExecContext(context.Background(), "RESET statement_timeout") }()
+
+	if step.Online {
+		if name := indexName(step.SQL); name != "" {
+			if err := dropInvalidIndex(ctx, conn, name); err != nil {
+				return 0, err
+			}
+			_, err = conn.ExecContext(ctx, concurrentIndexSQL(step.SQL))
+			return 0, err
+		}
+		_, err = conn.ExecContext(ctx, step.SQL)
+		return 0, err
+	}
+
+	if m.pauser == nil {
+		_, err = conn.ExecContext(ctx, step.SQL)
+		return 0, err
+	}
+	pauseCtx, cancel := context.WithTimeout(ctx, MaxWritePause)
+	defer cancel()
+	if err := m.pauser.PauseWrites(pauseCtx); err != nil {
+		return 0, fmt.Errorf("pause writes: %w", err)
+	}
+	pausedAt := time.Now()
+	slog.Info("⏸️ [Migration] Writes paused", "step", step.Name)
+	_, err = conn.ExecContext(pauseCtx, step.SQL)
+	m.pauser.ResumeWrites()
+	paused := time.Since(pausedAt)
+	slog.Info("▶️ [Migration] Writes resumed", "step", step.Name, "paused", paused)
+	return paused, err
+}
+
+var (
+	indexStmtPattern = regexp.MustCompile(`(?i)^\s*CREATE\s+(UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?`)
+	indexNamePattern = regexp.MustCompile(`(?i)INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(\w+)`)
+)
+
+// isIndexStatement 是否为 CREATE [UNIQUE] INDEX 语句
+func isIndexStatement(stmt string) bool {
+	return indexStmtPattern.MatchString(stmt)
+}
+
+// indexName 提取 CREATE INDEX 语句中的索引名（非索引语句返回空串）
+func indexName(stmt string) string {
+	if !isIndexStatement(stmt) {
+		return ""
+	}
+	if m := indexNamePattern.FindStringSubmatch(stmt); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// concurrentIndexSQL 把 CREATE INDEX 改写为 CREATE INDEX CONCURRENTLY（构建期间不阻塞写入）
+func concurrentIndexSQL(stmt string) string {
+	return indexStmtPattern.ReplaceAllString(stmt, "CREATE ${1}INDEX CONCURRENTLY ")
+}
+
+// dropInvalidIndex 删除上次并发构建中断留下的无效索引（否则 IF NOT EXISTS 会跳过重建）
+func dropInvalidIndex(ctx context.Context, conn *sqlx.Conn, name string) error {
+	var invalid bool
+	err := conn.GetContext(ctx, &invalid, `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_index i
+			JOIN pg_class c ON c.oid = i.indexrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE c.relname = $1 AND n.nspname = current_schema() AND NOT i.indisvalid
+		)`, name)
+	if err != nil || !invalid {
+		return err
+	}
+	slog.Warn("🧹 [Migration] Dropping invalid index left by an interrupted build", "index", name)
+	// #nosec G201 - 索引名来自固定的 schemaIndices 列表
+	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s", name))
+	return err
+}
+
+// execWithLockRetry 以短锁超时执行 DDL，锁等待超时（SQLSTATE 55P03）时退避重试
+func execWithLockRetry(ctx context.Context, db *sqlx.DB, stmt string) error {
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET lock_timeout = %d", migrationLockTimeout.Milliseconds())); err != nil {
+		return err
+	}
+	defer func() { _, _ = conn.ExecContext(context.Background(), "RESET lock_timeout") }()
+
+	backoff := 500 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		_, err = conn.ExecContext(ctx, stmt)
+		if err == nil || !isLockTimeout(err) || attempt >= migrationLockRetries {
+			return err
+		}
+		slog.Warn("🔒 [Migration] Lock timeout, retrying", "stmt", stmt, "attempt", attempt+1)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// isLockTimeout 是否为 lock_timeout 触发的锁等待失败
+func isLockTimeout(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "55P03"
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentIndexSQL(t *testing.T) {
+	assert.Equal(t,
+		"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_blocks_hash ON blocks(hash)",
+		concurrentIndexSQL("CREATE INDEX IF NOT EXISTS idx_blocks_hash ON blocks(hash)"))
+	assert.Equal(t,
+		"CREATE UNIQUE INDEX CONCURRENTLY idx_u ON t(a)",
+		concurrentIndexSQL("CREATE UNIQUE INDEX idx_u ON t(a)"))
+	assert.Equal(t,
+		"CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_x ON t(a)",
+		concurrentIndexSQL("CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_x ON t(a)"), "already concurrent statements are unchanged")
+}
+
+func TestDeferredSteps(t *testing.T) {
+	steps := DeferredSteps()
+	names := make(map[string]bool, len(steps))
+	for _, step := range steps {
+		assert.NotEmpty(t, step.Name)
+		assert.False(t, names[step.Name], "duplicate step %s", step.Name)
+		names[step.Name] = true
+		assert.Equal(t, indexName(step.SQL) != "", step.Online, "only index builds run alongside writes: %s", step.Name)
+	}
+	assert.True(t, names["idx_transfers_from_block"])
+	assert.True(t, names["backfill_supply_deltas"])
+	assert.Empty(t, indexName("UPDATE transfers SET activity_type = 'MINT'"))
+}
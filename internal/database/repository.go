@@ -5,17 +5,19 @@ import (
 	"fmt"
 	"log"
 	"math/big"
-	"strings"
 	"time"
 
 	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
 
 	"github.com/jmoiron/sqlx"
 
 	_ "github.com/jackc/pgx/v5/stdlib" // Required for sqlx Open to recognize "pgx" driver
 )
 
+// Repository 按 chain_id 维护同步状态；通用读写（回滚、游标、元数据）由内嵌的 storage.Postgres 提供
 type Repository struct {
+	*storage.Postgres
 	db *sqlx.DB
 }
 
@@ -31,12 +33,12 @@ func NewRepository(databaseURL string) (*Repository, error) {
 	db.SetConnMaxLifetime(5 * time.Minute) // 连接最大生命周期
 	db.SetConnMaxIdleTime(1 * time.Minute) // 空闲连接最大存活时间
 
-	return &Repository{db: db}, nil
+	return NewRepositoryFromDB(db), nil
 }
 
 // NewRepositoryFromDB 从现有 DB 连接创建 Repository
 func NewRepositoryFromDB(db *sqlx.DB) *Repository {
-	return &Repository{db: db}
+	return &Repository{Postgres: storage.NewPostgres(db, storage.Options{}), db: db}
 }
 
 func (r *Repository) Close() error {
@@ -58,9 +60,9 @@ func (r *Repository) SaveBlock(ctx context.Context, block *models.Block) error {
 func (r *Repository) SaveTransfer(ctx context.Context, transfer *models.Transfer) error {
 	query := `
 		INSERT INTO transfers 
-		(block_number, tx_hash, log_index, from_address, to_address, amount, token_address, symbol, activity_type)
+		(block_number, tx_hash, log_index, from_address, to_address, amount, token_address, symbol, activity_type, synthesized, origin)
 		VALUES 
-		(:block_number, :tx_hash, :log_index, :from_address, :to_address, :amount, :token_address, :symbol, :activity_type)
+		(:block_number, :tx_hash, :log_index, :from_address, :to_address, :amount, :token_address, :symbol, :activity_type, :synthesized, :origin)
 		ON CONFLICT (block_number, log_index) DO NOTHING
 	`
 	_, err := r.db.NamedExecContext(ctx, query, transfer)
@@ -140,114 +142,3 @@ func (r *Repository) RecordSyncError(ctx context.Context, chainID int64, errorMs
 	_, err := r.db.ExecContext(ctx, query, chainID, errorMsg)
 	return err
 }
-
-// PruneFutureData 删除所有高于指定高度的数据（用于处理 Anvil 重启导致的穿越问题）
-func (r *Repository) PruneFutureData(ctx context.Context, chainHead int64) error {
-	tx, err := r.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback() // nolint:errcheck // Rollback is standard for safe transaction handling // Rollback is standard practice, error is usually non-critical during cleanup
-
-	headStr := fmt.Sprintf("%d", chainHead)
-
-	// 1. 删除过时的转账记录
-	if _, err := tx.ExecContext(ctx, "DELETE FROM transfers WHERE block_number > $1", headStr); err != nil {
-		return err
-	}
-
-	// 2. 删除过时的区块记录
-	if _, err := tx.ExecContext(ctx, "DELETE FROM blocks WHERE number > $1", headStr); err != nil {
-		return err
-	}
-
-	// 3. 更新同步检查点
-	if _, err := tx.ExecContext(ctx, "UPDATE sync_checkpoints SET last_synced_block = $1, updated_at = NOW()", headStr); err != nil {
-		return err
-	}
-
-	// 🚀 工业级对齐：更新或重置 sync_status，防止 API 抓取到脏高度
-	if _, err := tx.ExecContext(ctx, "UPDATE sync_status SET last_processed_block = $1, last_processed_timestamp = NOW()", headStr); err != nil {
-		return err
-	}
-
-	return tx.Commit()
-}
-
-// UpdateSyncCursor 强制更新同步游标（用于演示模式下的状态坍缩）
-func (r *Repository) UpdateSyncCursor(ctx context.Context, height int64) error {
-	tx, err := r.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback() // nolint:errcheck // Rollback is standard for safe transaction handling
-
-	headStr := fmt.Sprintf("%d", height)
-
-	if _, err := tx.ExecContext(ctx, "UPDATE sync_checkpoints SET last_synced_block = $1, updated_at = NOW()", headStr); err != nil {
-		return err
-	}
-	if _, err := tx.ExecContext(ctx, "UPDATE sync_status SET last_processed_block = $1, last_processed_timestamp = NOW()", headStr); err != nil {
-		return err
-	}
-
-	return tx.Commit()
-}
-
-// UpdateTokenSymbol 更新代币符号（用于 Metadata Enricher 回填）
-func (r *Repository) UpdateTokenSymbol(tokenAddress, symbol string) error {
-	query := `
-		UPDATE transfers
-		SET symbol = $1
-		WHERE token_address = $2 AND (symbol IS NULL OR symbol = '')
-	`
-	_, err := r.db.Exec(query, symbol, tokenAddress)
-	return err
-}
-
-// UpdateTokenDecimals 更新代币精度（用于未来扩展）
-func (r *Repository) UpdateTokenDecimals(_ string, _ uint8) error {
-	// 预留方法，当前 schema 没有 decimals 字段
-	// 未来可以添加 token_metadata 表来存储这些信息
-	return nil
-}
-
-// SaveTokenMetadata 持久化代币元数据到 L2 缓存
-func (r *Repository) SaveTokenMetadata(meta models.TokenMetadata, address string) error {
-	query := `
-		INSERT INTO token_metadata (address, symbol, decimals, name, updated_at)
-		VALUES ($1, $2, $3, $4, NOW())
-		ON CONFLICT (address) DO UPDATE SET
-			symbol = EXCLUDED.symbol,
-			decimals = EXCLUDED.decimals,
-			name = EXCLUDED.name,
-			updated_at = NOW()
-	`
-	_, err := r.db.Exec(query, strings.ToLower(address), meta.Symbol, meta.Decimals, meta.Name)
-	return err
-}
-
-// LoadAllMetadata 从数据库加载所有已缓存的元数据
-func (r *Repository) LoadAllMetadata() (map[string]models.TokenMetadata, error) {
-	var rows []struct {
-		Address  string `db:"address"`
-		Symbol   string `db:"symbol"`
-		Decimals uint8  `db:"decimals"`
-		Name     string `db:"name"`
-	}
-
-	err := r.db.Select(&rows, "SELECT address, symbol, decimals, name FROM token_metadata")
-	if err != nil {
-		return nil, err
-	}
-
-	result := make(map[string]models.TokenMetadata)
-	for _, row := range rows {
-		result[strings.ToLower(row.Address)] = models.TokenMetadata{
-			Symbol:   row.Symbol,
-			Decimals: row.Decimals,
-			Name:     row.Name,
-		}
-	}
-	return result, nil
-}
@@ -0,0 +1,22 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointCheck(t *testing.T) {
+	at := func(n int64) *int64 { return &n }
+
+	check := checkpointCheck(at(120), 100)
+	assert.False(t, check.OK, "checkpoint ahead of stored blocks would skip them on restart")
+	assert.Equal(t, int64(20), check.Violations)
+
+	check = checkpointCheck(at(90), 100)
+	assert.True(t, check.OK, "checkpoints advance in batches and may trail")
+	assert.Contains(t, check.Detail, "10")
+
+	assert.True(t, checkpointCheck(at(100), 100).OK)
+	assert.True(t, checkpointCheck(nil, 0).OK)
+}
@@ -2,16 +2,67 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 
 	"github.com/jmoiron/sqlx"
 )
 
-// InitSchema 确保数据库核心表结构已就绪
+// SchemaVersion 当前 InitSchema 定义的表结构版本（结构变更时递增，由 /api/version 对外暴露）
+const SchemaVersion = 2
+
+// InitSchema 确保数据库核心表结构已就绪（阻塞模式：全部步骤在同步开始前顺序执行）
 func InitSchema(ctx context.Context, db *sqlx.DB) error {
+	if err := initTables(ctx, db, false); err != nil {
+		return err
+	}
+
+	// 大表建索引可能超过 DB_STATEMENT_TIMEOUT_MS，在专用连接上关闭语句超时
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection for indices: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.ExecContext(ctx, "SET statement_timeout = 0"); err != nil {
+		slog.Warn("failed_to_disable_statement_timeout", "err", err)
+	}
+	for _, step := range DeferredSteps() {
+		if _, err := conn.ExecContext(ctx, step.SQL); err != nil {
+			slog.Warn("failed_to_apply_schema_step", "step", step.Name, "err", err)
+		}
+	}
+	if _, err := conn.ExecContext(ctx, "RESET statement_timeout"); err != nil {
+		slog.Warn("failed_to_reset_statement_timeout", "err", err)
+	}
+
+	slog.Info("✅ [Database] Schema is ready.")
+	return nil
+}
+
+// InitSchemaOnline 在线迁移模式的启动阶段：只建表、补列（短锁超时 + 重试）并迁移旧区块号列，
+// 返回索引构建与历史回填等耗时步骤，交给 OnlineMigrator 在同步开始后执行
+func InitSchemaOnline(ctx context.Context, db *sqlx.DB) ([]MigrationStep, error) {
+	if err := initTables(ctx, db, true); err != nil {
+		return nil, err
+	}
+	steps := DeferredSteps()
+	slog.Info("✅ [Database] Tables are ready, long-running steps deferred to online migration", "steps", len(steps))
+	return steps, nil
+}
+
+// initTables 建表并补齐旧表的列；online 时补列使用短锁超时并重试，避免排在长查询之后阻塞读写
+func initTables(ctx context.Context, db *sqlx.DB, online bool) error {
 	slog.Info("🛡️ [Database] Initializing Schema...")
 
+	// 区块号列必须为数值类型：旧库中的 TEXT/VARCHAR 列会按字典序排序与比较（"9" > "10"）。
+	// 须在建表之前迁移：下面新建的表以 NUMERIC 列引用 blocks(number)，对 TEXT 主键建外键会直接失败
+	if err := ensureNumericBlockColumns(ctx, db); err != nil {
+		return fmt.Errorf("migrate block number columns to NUMERIC: %w", err)
+	}
+
 	schema := `
 	CREATE TABLE IF NOT EXISTS blocks (
 		number NUMERIC PRIMARY KEY,
@@ -22,6 +73,8 @@ func InitSchema(ctx context.Context, db *sqlx.DB) error {
 		gas_used BIGINT DEFAULT 0,
 		base_fee_per_gas NUMERIC,
 		transaction_count INTEGER DEFAULT 0,
+		blob_gas_used BIGINT, -- EIP-4844，Dencun 之前的区块为 NULL
+		excess_blob_gas BIGINT,
 		processed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 	);
 
@@ -36,6 +89,9 @@ func InitSchema(ctx context.Context, db *sqlx.DB) error {
 		token_address VARCHAR(42) NOT NULL,
 		symbol VARCHAR(20),
 		activity_type VARCHAR(20) DEFAULT 'TRANSFER',
+		synthesized BOOLEAN NOT NULL DEFAULT FALSE,
+		origin VARCHAR(64) NOT NULL DEFAULT '', -- Transfer 等价事件的来源标签（EXTRA_TRANSFER_TOPICS），标准事件为空
+		normalized_amount NUMERIC, -- amount / 10^decimals，代币精度读取后由后台任务回填；NULL 表示精度未知或尚未回填
 		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 	);
 
@@ -45,6 +101,10 @@ func InitSchema(ctx context.Context, db *sqlx.DB) error {
 		decimals SMALLINT NOT NULL DEFAULT 18,
 		name TEXT, -- Already TEXT type, sufficient for long names
 		is_verified BOOLEAN DEFAULT FALSE,
+		is_spam BOOLEAN NOT NULL DEFAULT FALSE, -- 启发式判定的垃圾代币
+		spam_reason TEXT,
+		spam_override BOOLEAN, -- 管理员覆盖：TRUE 强制静音，FALSE 永不静音，NULL 跟随启发式
+		spam_marked_at TIMESTAMP WITH TIME ZONE,
 		updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 	);
 
@@ -63,6 +123,213 @@ func InitSchema(ctx context.Context, db *sqlx.DB) error {
 		error_message TEXT
 	);
 
+	CREATE TABLE IF NOT EXISTS daily_stats (
+		day DATE PRIMARY KEY,
+		blocks BIGINT NOT NULL DEFAULT 0,
+		transfers BIGINT NOT NULL DEFAULT 0,
+		unique_addresses BIGINT NOT NULL DEFAULT 0,
+		updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+	);
+
+	CREATE TABLE IF NOT EXISTS daily_token_stats (
+		day DATE NOT NULL,
+		token_address VARCHAR(42) NOT NULL,
+		symbol TEXT,
+		transfers BIGINT NOT NULL DEFAULT 0,
+		volume NUMERIC NOT NULL DEFAULT 0,
+		PRIMARY KEY (day, token_address)
+	);
+
+	-- 增量聚合水位（记录已聚合到的区块号）
+	CREATE TABLE IF NOT EXISTS aggregate_watermarks (
+		name TEXT PRIMARY KEY,
+		last_block NUMERIC NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+	);
+
+	-- 逐块聚合增量（reorg 回滚时据此撤销已聚合区块对日统计的贡献；token_address 为空串的行是区块级汇总）
+	CREATE TABLE IF NOT EXISTS aggregate_block_deltas (
+		block_number NUMERIC NOT NULL,
+		day DATE NOT NULL,
+		token_address VARCHAR(42) NOT NULL DEFAULT '',
+		transfers BIGINT NOT NULL DEFAULT 0,
+		volume NUMERIC NOT NULL DEFAULT 0,
+		PRIMARY KEY (block_number, token_address)
+	);
+
+	-- 稳定币与已标记交易所之间的小时资金流（StablecoinFlowAggregator 按小时整体重算；数量为代币原始单位）
+	CREATE TABLE IF NOT EXISTS stablecoin_hourly_flows (
+		hour TIMESTAMP WITH TIME ZONE NOT NULL,
+		token_address VARCHAR(42) NOT NULL,
+		exchange TEXT NOT NULL,
+		inflow NUMERIC NOT NULL DEFAULT 0,
+		outflow NUMERIC NOT NULL DEFAULT 0,
+		transfers BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (hour, token_address, exchange)
+	);
+
+	-- 吞吐历史：每分钟一条 TPS/BPS/延迟采样（/api/stats/throughput 无需 Prometheus 即可画趋势）
+	CREATE TABLE IF NOT EXISTS metrics_history (
+		ts TIMESTAMP WITH TIME ZONE PRIMARY KEY,
+		tps DOUBLE PRECISION NOT NULL DEFAULT 0,
+		bps DOUBLE PRECISION NOT NULL DEFAULT 0,
+		latency_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+		blocks BIGINT NOT NULL DEFAULT 0,
+		transfers BIGINT NOT NULL DEFAULT 0
+	);
+
+	-- SLO 分钟计数：按 UTC 日 + 状态累计在线分钟与同步滞后达标分钟（/api/stats/slo）
+	CREATE TABLE IF NOT EXISTS slo_minutes (
+		day DATE NOT NULL,
+		state VARCHAR(20) NOT NULL,
+		minutes INTEGER NOT NULL DEFAULT 0,
+		lag_ok_minutes INTEGER NOT NULL DEFAULT 0,
+		lag_sum NUMERIC NOT NULL DEFAULT 0,
+		max_lag BIGINT NOT NULL DEFAULT 0,
+		lag_threshold BIGINT NOT NULL DEFAULT 10,
+		PRIMARY KEY (day, state)
+	);
+
+	-- 地址字节码缓存：eth_getCode 结果（code_size = 0 为外部账户），供 is-contract 判断跨重启复用
+	CREATE TABLE IF NOT EXISTS address_code (
+		address VARCHAR(42) PRIMARY KEY,
+		code_size INTEGER NOT NULL,
+		checked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+
+	-- 地址注册表：首次/最近出现区块与参与交易数（AsyncWriter 随批次增量维护，reorg 时同事务撤销）
+	CREATE TABLE IF NOT EXISTS addresses (
+		address VARCHAR(42) PRIMARY KEY,
+		first_seen_block NUMERIC NOT NULL,
+		last_seen_block NUMERIC NOT NULL,
+		tx_count BIGINT NOT NULL DEFAULT 0,
+		is_contract BOOLEAN,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+
+	-- 代币供应量变动：每个 (代币, 区块) 的铸造 / 销毁量（随区块级联删除，reorg 时自动撤销）
+	CREATE TABLE IF NOT EXISTS token_supply_deltas (
+		token_address VARCHAR(42) NOT NULL,
+		block_number NUMERIC NOT NULL REFERENCES blocks(number) ON DELETE CASCADE,
+		minted NUMERIC NOT NULL DEFAULT 0,
+		burned NUMERIC NOT NULL DEFAULT 0,
+		mints INTEGER NOT NULL DEFAULT 0,
+		burns INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (token_address, block_number)
+	);
+
+	-- 同块环形套利候选：同一 sender 跨多个池子的 Swap 且净代币变动全部非负（随区块级联删除）
+	CREATE TABLE IF NOT EXISTS arbitrage_events (
+		block_number NUMERIC NOT NULL REFERENCES blocks(number) ON DELETE CASCADE,
+		sender VARCHAR(42) NOT NULL,
+		tx_hashes JSONB NOT NULL DEFAULT '[]',
+		pools JSONB NOT NULL DEFAULT '[]',
+		swaps INTEGER NOT NULL DEFAULT 0,
+		profit_token VARCHAR(42) NOT NULL,
+		profit_amount NUMERIC NOT NULL DEFAULT 0,
+		detected_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		PRIMARY KEY (block_number, sender)
+	);
+
+	-- 交易回执摘要（FETCH_RECEIPTS 开启时写入）：实际 gasUsed / 手续费与成功状态（随区块级联删除）
+	CREATE TABLE IF NOT EXISTS receipts (
+		tx_hash VARCHAR(66) PRIMARY KEY,
+		block_number NUMERIC NOT NULL REFERENCES blocks(number) ON DELETE CASCADE,
+		tx_index INTEGER NOT NULL,
+		status SMALLINT NOT NULL,
+		gas_used NUMERIC NOT NULL,
+		effective_gas_price NUMERIC NOT NULL DEFAULT 0,
+		fee NUMERIC NOT NULL DEFAULT 0,
+		contract_address VARCHAR(42)
+	);
+
+	-- ERC-721 转账（Transfer 事件 tokenId 为 indexed 参数，共 4 个 topic）；随区块级联删除
+	CREATE TABLE IF NOT EXISTS nft_transfers (
+		block_number NUMERIC NOT NULL REFERENCES blocks(number) ON DELETE CASCADE,
+		log_index INTEGER NOT NULL,
+		tx_hash VARCHAR(66) NOT NULL,
+		collection VARCHAR(42) NOT NULL,
+		from_address VARCHAR(42) NOT NULL,
+		to_address VARCHAR(42) NOT NULL,
+		token_id NUMERIC NOT NULL,
+		PRIMARY KEY (block_number, log_index)
+	);
+
+	-- EIP-4844 blob 交易（type 3）：versioned hash 列表与 blob gas（随区块级联删除）
+	CREATE TABLE IF NOT EXISTS blob_transactions (
+		tx_hash VARCHAR(66) PRIMARY KEY,
+		block_number NUMERIC NOT NULL REFERENCES blocks(number) ON DELETE CASCADE,
+		tx_index INTEGER NOT NULL,
+		blob_hashes JSONB NOT NULL DEFAULT '[]',
+		blob_count SMALLINT NOT NULL,
+		blob_gas BIGINT NOT NULL,
+		blob_gas_fee_cap NUMERIC NOT NULL DEFAULT 0
+	);
+
+	-- EIP-1559 费用统计（每块一行，随区块级联删除）：小费分位为 0, 5, …, 100 分位的 gas 加权值（wei，十进制字符串）
+	CREATE TABLE IF NOT EXISTS block_fees (
+		block_number NUMERIC PRIMARY KEY REFERENCES blocks(number) ON DELETE CASCADE,
+		burnt_fees NUMERIC NOT NULL DEFAULT 0,
+		priority_fees NUMERIC NOT NULL DEFAULT 0,
+		reward_percentiles JSONB NOT NULL DEFAULT '[]',
+		estimated BOOLEAN NOT NULL DEFAULT FALSE -- 无回执时按 Gas Limit 加权估算
+	);
+
+	CREATE TABLE IF NOT EXISTS approvals (
+		block_number NUMERIC NOT NULL REFERENCES blocks(number) ON DELETE CASCADE,
+		log_index INTEGER NOT NULL,
+		tx_hash VARCHAR(66) NOT NULL,
+		token_address VARCHAR(42) NOT NULL,
+		kind VARCHAR(16) NOT NULL,
+		owner VARCHAR(42) NOT NULL,
+		spender VARCHAR(42) NOT NULL,
+		amount NUMERIC,
+		token_id NUMERIC,
+		approved BOOLEAN NOT NULL,
+		PRIMARY KEY (block_number, log_index)
+	);
+
+	-- 按 EVENT_SIGNATURES 配置的 ABI 片段通用解码的合约事件（随区块级联删除）
+	CREATE TABLE IF NOT EXISTS contract_events (
+		block_number NUMERIC NOT NULL REFERENCES blocks(number) ON DELETE CASCADE,
+		log_index INTEGER NOT NULL,
+		tx_hash VARCHAR(66) NOT NULL,
+		contract_address VARCHAR(42) NOT NULL,
+		event_name VARCHAR(128) NOT NULL,
+		topic0 VARCHAR(66) NOT NULL,
+		args JSONB NOT NULL DEFAULT '{}',
+		PRIMARY KEY (block_number, log_index)
+	);
+
+	-- 未索引即被跳过的区块区间（演示模式 Leap-Sync、gap-fill 重试耗尽等），供后续回填；不随区块级联删除
+	CREATE TABLE IF NOT EXISTS skipped_ranges (
+		id BIGSERIAL PRIMARY KEY,
+		from_block NUMERIC NOT NULL,
+		to_block NUMERIC NOT NULL,
+		reason VARCHAR(32) NOT NULL,
+		skipped_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		backfilled_to NUMERIC, -- 回填进度（已补齐到的区块），NULL 表示尚未开始
+		backfilled_at TIMESTAMP WITH TIME ZONE -- 整段补齐的时间
+	);
+
+	-- 监控地址列表：WATCHED_TOKEN_ADDRESSES 仅在表为空时作为初始值写入，之后以本表为准（运行时修改无需重启）；不随 Reset 清空
+	CREATE TABLE IF NOT EXISTS watched_addresses (
+		address VARCHAR(42) PRIMARY KEY, -- 小写
+		label VARCHAR(128) NOT NULL DEFAULT '',
+		source VARCHAR(16) NOT NULL DEFAULT 'api', -- env / api
+		added_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+
+	-- 监控地址变更审计（与变更在同一语句中写入）
+	CREATE TABLE IF NOT EXISTS watchlist_audit (
+		id BIGSERIAL PRIMARY KEY,
+		action VARCHAR(8) NOT NULL, -- add / remove
+		address VARCHAR(42) NOT NULL,
+		label VARCHAR(128) NOT NULL DEFAULT '',
+		actor VARCHAR(128) NOT NULL DEFAULT '', -- 发起方（管理令牌对应的身份；未启用令牌时为 anonymous@来源地址）
+		changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+
 	CREATE TABLE IF NOT EXISTS visitor_stats (
 		id SERIAL PRIMARY KEY,
 		ip_address VARCHAR(45) NOT NULL,
@@ -78,30 +345,208 @@ func InitSchema(ctx context.Context, db *sqlx.DB) error {
 	}
 
 	// 🚀 工业级补丁：确保旧表结构也能对齐最新逻辑
-	patches := []string{
-		"ALTER TABLE sync_status ADD COLUMN IF NOT EXISTS last_processed_block NUMERIC",
-		"ALTER TABLE sync_status ADD COLUMN IF NOT EXISTS last_processed_timestamp TIMESTAMP WITH TIME ZONE DEFAULT NOW()",
-		"ALTER TABLE transfers ADD COLUMN IF NOT EXISTS activity_type VARCHAR(20) DEFAULT 'TRANSFER'",
-	}
-	for _, patch := range patches {
-		if _, err := db.ExecContext(ctx, patch); err != nil {
+	for _, patch := range schemaPatches {
+		if online {
+			err = execWithLockRetry(ctx, db, patch)
+		} else {
+			_, err = db.ExecContext(ctx, patch)
+		}
+		if err != nil {
 			slog.Warn("failed_to_apply_patch", "err", err, "patch", patch)
 		}
 	}
+	return nil
+}
 
-	// 补充索引
-	indices := []string{
-		"CREATE INDEX IF NOT EXISTS idx_transfers_block_number ON transfers(block_number)",
-		"CREATE INDEX IF NOT EXISTS idx_transfers_tx_hash ON transfers(tx_hash)",
-		"CREATE INDEX IF NOT EXISTS idx_token_metadata_symbol ON token_metadata(symbol)",
+// schemaPatches 旧表结构补列（ADD COLUMN IF NOT EXISTS，仅改元数据，不重写表）
+var schemaPatches = []string{
+	"ALTER TABLE sync_status ADD COLUMN IF NOT EXISTS last_processed_block NUMERIC",
+	"ALTER TABLE sync_status ADD COLUMN IF NOT EXISTS last_processed_timestamp TIMESTAMP WITH TIME ZONE DEFAULT NOW()",
+	"ALTER TABLE transfers ADD COLUMN IF NOT EXISTS activity_type VARCHAR(20) DEFAULT 'TRANSFER'",
+	"ALTER TABLE transfers ADD COLUMN IF NOT EXISTS synthesized BOOLEAN NOT NULL DEFAULT FALSE",
+	"ALTER TABLE transfers ADD COLUMN IF NOT EXISTS origin VARCHAR(64) NOT NULL DEFAULT ''",
+	"ALTER TABLE transfers ADD COLUMN IF NOT EXISTS normalized_amount NUMERIC",
+	"ALTER TABLE token_metadata ADD COLUMN IF NOT EXISTS is_spam BOOLEAN NOT NULL DEFAULT FALSE",
+	"ALTER TABLE token_metadata ADD COLUMN IF NOT EXISTS spam_reason TEXT",
+	"ALTER TABLE token_metadata ADD COLUMN IF NOT EXISTS spam_override BOOLEAN",
+	"ALTER TABLE token_metadata ADD COLUMN IF NOT EXISTS spam_marked_at TIMESTAMP WITH TIME ZONE",
+	"ALTER TABLE blocks ADD COLUMN IF NOT EXISTS blob_gas_used BIGINT",
+	"ALTER TABLE blocks ADD COLUMN IF NOT EXISTS excess_blob_gas BIGINT",
+	"ALTER TABLE skipped_ranges ADD COLUMN IF NOT EXISTS backfilled_to NUMERIC",
+	"ALTER TABLE skipped_ranges ADD COLUMN IF NOT EXISTS backfilled_at TIMESTAMP WITH TIME ZONE",
+}
+
+// schemaIndices 补充索引（在线迁移模式下以 CREATE INDEX CONCURRENTLY 构建）
+var schemaIndices = []string{
+	"CREATE INDEX IF NOT EXISTS idx_transfers_block_number ON transfers(block_number)",
+	"CREATE INDEX IF NOT EXISTS idx_transfers_tx_hash ON transfers(tx_hash)",
+	"CREATE INDEX IF NOT EXISTS idx_blocks_timestamp ON blocks(timestamp)", // from_ts/to_ts 时间窗口查询
+	// 模拟器合成数据清理/隔离
+	"CREATE INDEX IF NOT EXISTS idx_transfers_synthesized ON transfers(block_number) WHERE synthesized",
+	"CREATE INDEX IF NOT EXISTS idx_transfers_unnormalized ON transfers(id) WHERE normalized_amount IS NULL",
+	"CREATE INDEX IF NOT EXISTS idx_token_metadata_symbol ON token_metadata(symbol)",
+	// /api/search 地址与哈希查找
+	"CREATE INDEX IF NOT EXISTS idx_transfers_from_address ON transfers(from_address)",
+	"CREATE INDEX IF NOT EXISTS idx_transfers_to_address ON transfers(to_address)",
+	"CREATE INDEX IF NOT EXISTS idx_transfers_token_address ON transfers(token_address)",
+	"CREATE INDEX IF NOT EXISTS idx_blocks_hash ON blocks(hash)",
+	// 地址 / 代币 / 类型过滤均按 block_number DESC 分页（与 migrations/005_transfer_indexes.sql 保持一致）
+	"CREATE INDEX IF NOT EXISTS idx_transfers_from_block ON transfers(from_address, block_number DESC)",
+	"CREATE INDEX IF NOT EXISTS idx_transfers_to_block ON transfers(to_address, block_number DESC)",
+	"CREATE INDEX IF NOT EXISTS idx_transfers_token_block ON transfers(token_address, block_number DESC)",
+	"CREATE INDEX IF NOT EXISTS idx_transfers_activity_block ON transfers(activity_type, block_number DESC)",
+	// 新地址分析与 reorg 撤销
+	"CREATE INDEX IF NOT EXISTS idx_addresses_first_seen ON addresses(first_seen_block)",
+	"CREATE INDEX IF NOT EXISTS idx_addresses_last_seen ON addresses(last_seen_block)",
+	"CREATE INDEX IF NOT EXISTS idx_token_supply_deltas_block ON token_supply_deltas(block_number)",
+	"CREATE INDEX IF NOT EXISTS idx_arbitrage_events_sender ON arbitrage_events(sender)",
+	"CREATE INDEX IF NOT EXISTS idx_receipts_block ON receipts(block_number)",
+	"CREATE INDEX IF NOT EXISTS idx_blob_transactions_block ON blob_transactions(block_number)",
+	"CREATE INDEX IF NOT EXISTS idx_nft_transfers_collection ON nft_transfers(collection, token_id, block_number DESC)",
+	"CREATE INDEX IF NOT EXISTS idx_approvals_owner ON approvals(owner, block_number DESC, log_index DESC)",
+	"CREATE INDEX IF NOT EXISTS idx_contract_events_name ON contract_events(event_name, block_number DESC, log_index DESC)",
+	"CREATE INDEX IF NOT EXISTS idx_contract_events_contract ON contract_events(contract_address, block_number DESC, log_index DESC)",
+}
+
+// DeferredSteps 建表之后的耗时步骤：补充索引（可并发构建）与首次启用时的历史回填。
+// 回填读取 transfers 并写入由 AsyncWriter 增量维护的表，与落盘并发会重复计数，在线模式下须暂停写入
+func DeferredSteps() []MigrationStep {
+	steps := make([]MigrationStep, 0, len(schemaIndices)+3)
+	for _, idx := range schemaIndices {
+		steps = append(steps, MigrationStep{Name: indexName(idx), SQL: idx, Online: true})
 	}
+	return append(steps,
+		// 地址注册表首次启用时从已有 transfers 一次性回填（之后由 AsyncWriter 增量维护）
+		MigrationStep{Name: "backfill_addresses", SQL: `
+			INSERT INTO addresses (address, first_seen_block, last_seen_block, tx_count)
+			SELECT address, MIN(block_number), MAX(block_number), COUNT(DISTINCT tx_hash)
+			FROM (
+				SELECT from_address AS address, block_number, tx_hash FROM transfers
+				UNION ALL
+				SELECT to_address, block_number, tx_hash FROM transfers
+			) t
+			WHERE address ~ '^0x[0-9a-f]{40}$' AND address <> '0x0000000000000000000000000000000000000000'
+				AND NOT EXISTS (SELECT 1 FROM addresses)
+			GROUP BY address
+			ON CONFLICT (address) DO NOTHING`},
+		// 供应量表首次启用时：把零地址转出的历史转账标记为 MINT，并从 MINT / BURN 转账回填逐块增量
+		MigrationStep{Name: "backfill_mint_types", SQL: `
+			UPDATE transfers SET activity_type = 'MINT'
+			WHERE from_address = '0x0000000000000000000000000000000000000000' AND activity_type = 'TRANSFER'
+				AND NOT EXISTS (SELECT 1 FROM token_supply_deltas)`},
+		MigrationStep{Name: "backfill_supply_deltas", SQL: `
+			INSERT INTO token_supply_deltas (token_address, block_number, minted, burned, mints, burns)
+			SELECT token_address, block_number,
+				COALESCE(SUM(amount) FILTER (WHERE activity_type = 'MINT'), 0),
+				COALESCE(SUM(amount) FILTER (WHERE activity_type = 'BURN'), 0),
+				COUNT(*) FILTER (WHERE activity_type = 'MINT'),
+				COUNT(*) FILTER (WHERE activity_type = 'BURN')
+			FROM transfers
+			WHERE activity_type IN ('MINT', 'BURN') AND NOT EXISTS (SELECT 1 FROM token_supply_deltas)
+			GROUP BY token_address, block_number
+			ON CONFLICT (token_address, block_number) DO NOTHING`},
+	)
+}
+
+// blockColumn 存放区块号的列
+type blockColumn struct{ table, column string }
+
+// numericBlockColumns 所有存放区块号的列
+var numericBlockColumns = []blockColumn{
+	{"blocks", "number"},
+	{"transfers", "block_number"},
+	{"sync_checkpoints", "last_synced_block"},
+	{"sync_status", "last_processed_block"},
+	{"aggregate_watermarks", "last_block"},
+	{"aggregate_block_deltas", "block_number"},
+}
+
+// blockForeignKey 引用 blocks(number) 的外键及其引用列
+type blockForeignKey struct {
+	Table      string `db:"table_name"`
+	Name       string `db:"constraint_name"`
+	Definition string `db:"definition"`
+	Column     string `db:"column_name"`
+	DataType   string `db:"data_type"`
+}
 
-	for _, idx := range indices {
-		if _, err := db.ExecContext(ctx, idx); err != nil {
-			slog.Warn("failed_to_create_index", "err", err)
+// isTextType 是否为按字典序比较的字符串类型
+func isTextType(dataType string) bool {
+	return dataType == "text" || dataType == "character varying"
+}
+
+// ensureNumericBlockColumns 把仍为字符串类型的区块号列迁移为 NUMERIC。
+// 引用 blocks(number) 的外键（在 pg_constraint 中查找，不限于 transfers）先删除、迁移后按原定义恢复，
+// 外键引用列若同为字符串类型一并迁移；整个过程在单个事务中完成，失败时回滚并返回错误
+func ensureNumericBlockColumns(ctx context.Context, db *sqlx.DB) error {
+	var legacy []blockColumn
+	for _, c := range numericBlockColumns {
+		var dataType string
+		err := db.GetContext(ctx, &dataType, `
+			SELECT data_type FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2`, c.table, c.column)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue // 表或列尚不存在，稍后按 NUMERIC 新建
+		}
+		if err != nil {
+			return fmt.Errorf("inspect %s.%s: %w", c.table, c.column, err)
 		}
+		if isTextType(dataType) {
+			legacy = append(legacy, c)
+		}
+	}
+	if len(legacy) == 0 {
+		return nil
 	}
 
-	slog.Info("✅ [Database] Schema is ready.")
-	return nil
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 整表重写耗时与数据量成正比，不受 DB_STATEMENT_TIMEOUT_MS 限制
+	if _, err := tx.ExecContext(ctx, "SET LOCAL statement_timeout = 0"); err != nil {
+		return fmt.Errorf("disable statement timeout: %w", err)
+	}
+
+	var fks []blockForeignKey
+	if err := tx.SelectContext(ctx, &fks, `
+		SELECT c.conrelid::regclass::TEXT AS table_name, quote_ident(c.conname) AS constraint_name,
+			pg_get_constraintdef(c.oid) AS definition, a.attname AS column_name,
+			COALESCE(col.data_type, '') AS data_type
+		FROM pg_constraint c
+		JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = c.conkey[1]
+		LEFT JOIN information_schema.columns col
+			ON col.table_schema = current_schema() AND col.table_name = c.conrelid::regclass::TEXT AND col.column_name = a.attname
+		WHERE c.contype = 'f' AND c.confrelid = to_regclass('blocks')
+		ORDER BY 1, 2`); err != nil {
+		return fmt.Errorf("list foreign keys on blocks: %w", err)
+	}
+	for _, fk := range fks {
+		// #nosec G201 - 表名与约束名取自系统目录并已转义
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", fk.Table, fk.Name)); err != nil {
+			return fmt.Errorf("drop %s.%s: %w", fk.Table, fk.Name, err)
+		}
+		if isTextType(fk.DataType) && !slices.Contains(legacy, blockColumn{fk.Table, fk.Column}) {
+			legacy = append(legacy, blockColumn{fk.Table, fk.Column})
+		}
+	}
+
+	for _, c := range legacy {
+		// #nosec G201 - 表名与列名来自固定白名单或系统目录
+		stmt := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE NUMERIC USING %s::NUMERIC", c.table, c.column, c.column)
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migrate %s.%s: %w", c.table, c.column, err)
+		}
+		slog.Info("🔢 [Database] Migrated block number column to NUMERIC", "table", c.table, "column", c.column)
+	}
+
+	for _, fk := range fks {
+		// #nosec G201 - 定义由 pg_get_constraintdef 生成
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s", fk.Table, fk.Name, fk.Definition)); err != nil {
+			return fmt.Errorf("restore %s.%s: %w", fk.Table, fk.Name, err)
+		}
+	}
+	return tx.Commit()
 }
@@ -24,6 +24,8 @@ func (e *Emulator) SetTxAmount(amount *big.Int) {
 
 // GetContractAddress 返回部署的合约地址.
 func (e *Emulator) GetContractAddress() common.Address {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
 	return e.contract
 }
 
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"math/big"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -53,9 +54,30 @@ type Emulator struct {
 	txInterval      time.Duration
 
 	logger *slog.Logger
+
+	// 合约地址与最近一次错误（供 /api/status 并发读取）
+	stateMu     sync.Mutex
+	lastErr     string
+	lastErrTime time.Time
+}
+
+// Status 仿真器运行状态快照
+type Status struct {
+	Enabled         bool      `json:"enabled"`
+	ContractAddress string    `json:"contract_address,omitempty"`
+	FromAddress     string    `json:"from_address"`
+	ChainID         string    `json:"chain_id"`
+	TxInterval      string    `json:"tx_interval"`
+	Sent            uint64    `json:"sent"`
+	Confirmed       uint64    `json:"confirmed"`
+	Failed          uint64    `json:"failed"`
+	SelfHealed      uint64    `json:"self_healed"`
+	LastError       string    `json:"last_error,omitempty"`
+	LastErrorAt     time.Time `json:"last_error_at,omitempty"`
 }
 
 func NewEmulator(rpcURL, privKeyHex string, opts ...func(*Emulator)) (*Emulator, error) {
+	RegisterMetrics()
 	client, err := ethclient.Dial(rpcURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to RPC: %w", err)
@@ -106,6 +128,40 @@ func WithTxInterval(d time.Duration) func(*Emulator) {
 	}
 }
 
+// Status 返回仿真器状态快照
+func (e *Emulator) Status() Status {
+	e.stateMu.Lock()
+	lastErr, lastErrTime, contract := e.lastErr, e.lastErrTime, e.contract
+	e.stateMu.Unlock()
+
+	st := Status{
+		Enabled:     true,
+		FromAddress: e.fromAddr.Hex(),
+		ChainID:     e.chainID.String(),
+		TxInterval:  e.txInterval.String(),
+		Sent:        e.Metrics.Sent.Load(),
+		Confirmed:   e.Metrics.Confirmed.Load(),
+		Failed:      e.Metrics.Failed.Load(),
+		SelfHealed:  e.Metrics.SelfHealed.Load(),
+		LastError:   lastErr,
+		LastErrorAt: lastErrTime,
+	}
+	if contract != (common.Address{}) {
+		st.ContractAddress = contract.Hex()
+	}
+	return st
+}
+
+func (e *Emulator) setLastError(err error) {
+	if err == nil {
+		return
+	}
+	e.stateMu.Lock()
+	e.lastErr = err.Error()
+	e.lastErrTime = time.Now()
+	e.stateMu.Unlock()
+}
+
 // ensureBalance 演示级余额补给逻辑
 func (e *Emulator) ensureBalance(ctx context.Context) error {
 	balance, err := e.client.BalanceAt(ctx, e.fromAddr, nil)
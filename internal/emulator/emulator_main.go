@@ -2,6 +2,7 @@ package emulator
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"math/big"
 	"time"
@@ -27,9 +28,12 @@ func (e *Emulator) Start(ctx context.Context, addressChan chan<- common.Address)
 	contractAddr, err := e.deployContract(deployCtx)
 	cancel()
 	if err != nil {
+		e.setLastError(fmt.Errorf("deploy contract: %w", err))
 		return err
 	}
+	e.stateMu.Lock()
 	e.contract = contractAddr
+	e.stateMu.Unlock()
 	e.logger.Info("contract_deployed", slog.String("address", contractAddr.Hex()))
 
 	if addressChan != nil {
@@ -22,11 +22,13 @@ func (e *Emulator) sendTransfer(ctx context.Context) {
 
 	nonce, err := e.nm.GetNextNonce(ctx)
 	if err != nil {
+		e.setLastError(fmt.Errorf("get nonce: %w", err))
 		return
 	}
 
 	gasPrice, err := e.client.SuggestGasPrice(ctx)
 	if err != nil {
+		e.setLastError(fmt.Errorf("suggest gas price: %w", err))
 		return
 	}
 
@@ -72,17 +74,17 @@ func (e *Emulator) sendTransfer(ctx context.Context) {
 	tx := types.NewTransaction(nonce, e.contract, big.NewInt(0), estimatedGas, gasPrice, data)
 	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(e.chainID), e.privateKey)
 	if err != nil {
-		e.Metrics.Failed.Add(1)
+		e.recordFailed(fmt.Errorf("sign tx: %w", err))
 		return
 	}
 
 	if err := e.client.SendTransaction(ctx, signedTx); err != nil {
-		e.Metrics.Failed.Add(1)
+		e.recordFailed(err)
 		e.logger.Error("send_failed", slog.String("error", err.Error()), slog.Uint64("nonce", nonce))
 		// ---------------- 自修复逻辑 ----------------
 		if strings.Contains(err.Error(), "nonce too low") || strings.Contains(err.Error(), "already known") {
 			e.logger.Warn("🚨 NONCE_OUT_OF_SYNC", slog.Uint64("failed_nonce", nonce))
-			e.Metrics.SelfHealed.Add(1)
+			e.recordSelfHealed()
 			if e.OnSelfHealing != nil {
 				e.OnSelfHealing("nonce_mismatch")
 			}
@@ -97,7 +99,7 @@ func (e *Emulator) sendTransfer(ctx context.Context) {
 		return
 	}
 
-	e.Metrics.Sent.Add(1)
+	e.recordSent()
 	if e.OnMetrics != nil {
 		// Get individual values to avoid copying atomic values
 		sent := e.Metrics.Sent.Load()
@@ -130,10 +132,14 @@ func (e *Emulator) sendTransfer(ctx context.Context) {
 			}
 		}()
 		receipt, err := e.waitForReceipt(ctx, signedTx.Hash())
-		if err == nil {
-			e.Metrics.Confirmed.Add(1)
-			e.logger.Info("✅ [Emulator] Confirmed", slog.String("hash", signedTx.Hash().Hex()[:10]), slog.Uint64("block", receipt.BlockNumber.Uint64()))
+		if err != nil {
+			if ctx.Err() == nil {
+				e.setLastError(fmt.Errorf("wait receipt %s: %w", signedTx.Hash().Hex()[:10], err))
+			}
+			return
 		}
+		e.recordConfirmed()
+		e.logger.Info("✅ [Emulator] Confirmed", slog.String("hash", signedTx.Hash().Hex()[:10]), slog.Uint64("block", receipt.BlockNumber.Uint64()))
 	}()
 }
 
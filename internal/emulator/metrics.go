@@ -0,0 +1,73 @@
+package emulator
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// emulatorCounters 仿真器 Prometheus 指标（与 Metrics 原子计数同步累加）
+type emulatorCounters struct {
+	txSent      prometheus.Counter
+	txConfirmed prometheus.Counter
+	txFailed    prometheus.Counter
+	selfHealed  prometheus.Counter
+}
+
+var (
+	promOnce     sync.Once
+	promCounters *emulatorCounters
+)
+
+// RegisterMetrics 注册仿真器指标（幂等，NewEmulator 也会调用）。
+// 不在包初始化时注册：须落在 main 替换后的带 chain_id 注册表上
+func RegisterMetrics() {
+	promOnce.Do(func() {
+		promCounters = &emulatorCounters{
+			txSent: promauto.NewCounter(prometheus.CounterOpts{
+				Name: "indexer_emulator_tx_sent_total",
+				Help: "Total number of transactions sent by the built-in emulator",
+			}),
+			txConfirmed: promauto.NewCounter(prometheus.CounterOpts{
+				Name: "indexer_emulator_tx_confirmed_total",
+				Help: "Total number of emulator transactions confirmed on chain",
+			}),
+			txFailed: promauto.NewCounter(prometheus.CounterOpts{
+				Name: "indexer_emulator_tx_failed_total",
+				Help: "Total number of emulator transactions that failed to sign or send",
+			}),
+			selfHealed: promauto.NewCounter(prometheus.CounterOpts{
+				Name: "indexer_emulator_self_healed_total",
+				Help: "Total number of emulator nonce self-healing events",
+			}),
+		}
+	})
+}
+
+// prom 返回已注册的仿真器指标
+func prom() *emulatorCounters {
+	RegisterMetrics()
+	return promCounters
+}
+
+func (e *Emulator) recordSent() {
+	e.Metrics.Sent.Add(1)
+	prom().txSent.Inc()
+}
+
+func (e *Emulator) recordConfirmed() {
+	e.Metrics.Confirmed.Add(1)
+	prom().txConfirmed.Inc()
+}
+
+func (e *Emulator) recordFailed(err error) {
+	e.Metrics.Failed.Add(1)
+	prom().txFailed.Inc()
+	e.setLastError(err)
+}
+
+func (e *Emulator) recordSelfHealed() {
+	e.Metrics.SelfHealed.Add(1)
+	prom().selfHealed.Inc()
+}
@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"strings"
+
+	"web3-indexer-go/internal/models"
+)
+
+// NormalizeActivityType 活动类型规范化（大写、去空白，空值视为 TRANSFER）。
+// 落盘前与 API 过滤参数都经过这里，保证 activity_type 列、
+// indexer_transaction_types_total 标签与 /api/stats/types 使用同一套取值。
+func NormalizeActivityType(t string) string {
+	t = strings.ToUpper(strings.TrimSpace(t))
+	if t == "" {
+		return models.ActivityTransfer
+	}
+	return t
+}
+
+// ParseActivityTypes 解析逗号分隔的活动类型列表（规范化并去重，空串返回 nil）
+func ParseActivityTypes(s string) []string {
+	var out []string
+	seen := make(map[string]struct{})
+	for _, item := range strings.Split(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		t := NormalizeActivityType(item)
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		out = append(out, t)
+	}
+	return out
+}
+
+// countActivityTypes 统计已提交转账的活动类型分布（TransactionTypesTotal 的唯一数据来源）
+func countActivityTypes(batch []PersistTask) map[string]int {
+	counts := make(map[string]int)
+	for _, task := range batch {
+		for _, t := range task.Transfers {
+			counts[NormalizeActivityType(t.Type)]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,33 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"web3-indexer-go/internal/models"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseActivityTypes(t *testing.T) {
+	assert.Nil(t, ParseActivityTypes(""))
+	assert.Equal(t, []string{"SWAP", "DEPLOY"}, ParseActivityTypes(" swap, DEPLOY ,,Swap"))
+	assert.Equal(t, models.ActivityTransfer, NormalizeActivityType("  "))
+	assert.Equal(t, models.ActivityETH, NormalizeActivityType("eth_transfer"))
+}
+
+func TestEphemeralFlush_CountsCommittedActivityTypes(t *testing.T) {
+	m := GetMetrics()
+	before := testutil.ToFloat64(m.TransactionTypesTotal.WithLabelValues(models.ActivityFaucet))
+	beforeTransfer := testutil.ToFloat64(m.TransactionTypesTotal.WithLabelValues(models.ActivityTransfer))
+
+	w := &AsyncWriter{orchestrator: &Orchestrator{ctx: context.Background(), cmdChan: make(chan Message, 4)}}
+	w.handleEphemeralFlush([]PersistTask{
+		{Height: 1, Transfers: []models.Transfer{{Type: "faucet_claim"}, {Type: models.ActivityFaucet}}},
+		{Height: 2, Transfers: []models.Transfer{{Type: ""}}},
+	})
+
+	assert.Equal(t, before+2, testutil.ToFloat64(m.TransactionTypesTotal.WithLabelValues(models.ActivityFaucet)))
+	assert.Equal(t, beforeTransfer+1, testutil.ToFloat64(m.TransactionTypesTotal.WithLabelValues(models.ActivityTransfer)))
+}
@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/jmoiron/sqlx"
+)
+
+// collectAddressActivity 把批次内转账按地址汇总为注册表增量：
+// from/to 中的有效地址（跳过零地址与 0xcontract_creation 等占位值），
+// tx_count 为该地址参与的不同交易数（同一交易内多条日志只计一次）。
+// 结果按地址排序，使并发事务以相同顺序加行锁。
+func collectAddressActivity(transfers []models.Transfer) []storage.AddressSeenRow {
+	type seenTx struct{ addr, tx string }
+	rows := make(map[string]*storage.AddressSeenRow)
+	counted := make(map[seenTx]struct{}, len(transfers)*2)
+
+	for _, t := range transfers {
+		if t.BlockNumber.Int == nil {
+			continue
+		}
+		block := t.BlockNumber.Uint64()
+		for _, a := range [...]string{t.From, t.To} {
+			if !common.IsHexAddress(a) || common.HexToAddress(a) == (common.Address{}) {
+				continue
+			}
+			addr := strings.ToLower(a)
+			row, ok := rows[addr]
+			if !ok {
+				row = &storage.AddressSeenRow{Address: addr, FirstSeen: block, LastSeen: block}
+				rows[addr] = row
+			}
+			row.FirstSeen = min(row.FirstSeen, block)
+			row.LastSeen = max(row.LastSeen, block)
+			key := seenTx{addr, strings.ToLower(t.TxHash)}
+			if _, dup := counted[key]; !dup {
+				counted[key] = struct{}{}
+				row.TxCount++
+			}
+		}
+	}
+
+	out := make([]storage.AddressSeenRow, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, *row)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	return out
+}
+
+// upsertAddressRegistryTx 在 AsyncWriter 事务内把批次地址合并进 addresses 表；
+// is_contract 只取字节码缓存内存中已有的结果，其余由 SaveAddressCodes 在预热完成后回填
+func upsertAddressRegistryTx(ctx context.Context, tx execer, transfers []models.Transfer) error {
+	rows := collectAddressActivity(transfers)
+	if len(rows) == 0 {
+		return nil
+	}
+	addrs := make([]common.Address, len(rows))
+	for i, row := range rows {
+		addrs[i] = common.HexToAddress(row.Address)
+	}
+	known := GetCodeCache().Peek(addrs)
+	for i := range rows {
+		if isContract, ok := known[addrs[i]]; ok {
+			rows[i].IsContract = &isContract
+		}
+	}
+	return storage.UpsertAddressesTx(ctx, tx, rows)
+}
+
+// RevertAddressRegistry 在回滚事务内撤销 number >= fromBlock 的转账对 addresses 的贡献：
+// 扣减 tx_count，删除首次出现于被回滚区块的地址，其余地址的 last_seen_block 从保留的转账中重算。
+// 必须在删除 transfers 之前、与删除处于同一事务中调用；返回被删除的地址数。
+func RevertAddressRegistry(ctx context.Context, tx *sqlx.Tx, fromBlock string) (int64, error) {
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE addresses a SET tx_count = GREATEST(a.tx_count - x.txs, 0), updated_at = NOW()
+		FROM (
+			SELECT address, COUNT(DISTINCT tx_hash) AS txs FROM (
+				SELECT from_address AS address, tx_hash FROM transfers WHERE block_number >= $1::NUMERIC
+				UNION ALL
+				SELECT to_address, tx_hash FROM transfers WHERE block_number >= $1::NUMERIC
+			) t
+			GROUP BY address
+		) x
+		WHERE a.address = x.address`, fromBlock); err != nil {
+		return 0, fmt.Errorf("revert address tx counts: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM addresses WHERE first_seen_block >= $1::NUMERIC", fromBlock)
+	if err != nil {
+		return 0, fmt.Errorf("drop reorged addresses: %w", err)
+	}
+	removed, _ := res.RowsAffected()
+
+	// 保留区块内的转账已被裁剪时退回 first_seen_block
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE addresses a SET updated_at = NOW(), last_seen_block = COALESCE((
+			SELECT MAX(t.block_number) FROM transfers t
+			WHERE t.block_number < $1::NUMERIC AND (t.from_address = a.address OR t.to_address = a.address)
+		), a.first_seen_block)
+		WHERE a.last_seen_block >= $1::NUMERIC`, fromBlock); err != nil {
+		return 0, fmt.Errorf("rewind address last_seen: %w", err)
+	}
+
+	if removed > 0 {
+		Logger.Info("📇 [Addresses] Reverted reorged addresses",
+			slog.String("from_block", fromBlock),
+			slog.Int64("removed", removed))
+	}
+	return removed, nil
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectAddressActivity(t *testing.T) {
+	alice := "0x00000000000000000000000000000000000000a1"
+	bob := "0x00000000000000000000000000000000000000B2"
+	zero := "0x0000000000000000000000000000000000000000"
+
+	rows := collectAddressActivity([]models.Transfer{
+		{BlockNumber: models.NewBigInt(12), TxHash: "0x01", From: alice, To: bob},
+		{BlockNumber: models.NewBigInt(12), TxHash: "0x01", LogIndex: 1, From: bob, To: alice}, // 同一交易只计一次
+		{BlockNumber: models.NewBigInt(10), TxHash: "0x02", From: zero, To: alice},             // mint：跳过零地址
+		{BlockNumber: models.NewBigInt(15), TxHash: "0x03", From: bob, To: "0xcontract_creation"},
+		{TxHash: "0x04", From: alice, To: bob}, // 缺少区块号
+	})
+
+	require.Len(t, rows, 2)
+	assert.Equal(t, storage.AddressSeenRow{Address: alice, FirstSeen: 10, LastSeen: 12, TxCount: 2}, rows[0])
+	assert.Equal(t, storage.AddressSeenRow{Address: "0x00000000000000000000000000000000000000b2", FirstSeen: 12, LastSeen: 15, TxCount: 2}, rows[1])
+}
+
+func TestCodeCache_PeekSkipsStaleAndUnknown(t *testing.T) {
+	contract := common.HexToAddress("0xc0")
+	eoa := common.HexToAddress("0xe0")
+	unknown := common.HexToAddress("0xf0")
+
+	c := NewCodeCache(16, 0)
+	c.Configure(&fakeCodeClient{sizes: map[common.Address]int{contract: 10}}, nil)
+	_, err := c.Lookup(context.Background(), []common.Address{contract, eoa})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[common.Address]bool{contract: true, eoa: false}, c.Peek([]common.Address{contract, eoa, unknown}))
+
+	// 外部账户结果过期后不再作为 is_contract 写入依据
+	c.now = func() time.Time { return time.Now().Add(2 * defaultCodeEOATTL) }
+	assert.Equal(t, map[common.Address]bool{contract: true}, c.Peek([]common.Address{contract, eoa}))
+}
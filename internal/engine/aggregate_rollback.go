@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// aggregateDeltaRetention 逐块增量保留深度（与 FindCommonAncestor 的最大回退 1000 块一致，更深的重组无法回滚）
+const aggregateDeltaRetention = 1000
+
+// recordAggregateDeltas 在聚合事务内记录 (from, to] 区块对日统计的逐块贡献，并清理超出回滚深度的旧增量。
+// 重复聚合同一区块时覆盖旧值，保证增量与当前 blocks/transfers 一致。
+func recordAggregateDeltas(ctx context.Context, tx *sqlx.Tx, from, to string) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO aggregate_block_deltas (block_number, day, token_address, transfers, volume)
+		SELECT b.number, (to_timestamp(b.timestamp) AT TIME ZONE 'UTC')::DATE, '', COUNT(t.block_number), 0
+		FROM blocks b LEFT JOIN transfers t ON t.block_number = b.number
+		WHERE b.number > $1::NUMERIC AND b.number <= $2::NUMERIC
+		GROUP BY b.number, b.timestamp
+		ON CONFLICT (block_number, token_address) DO UPDATE SET
+			day = EXCLUDED.day, transfers = EXCLUDED.transfers, volume = EXCLUDED.volume`,
+		from, to); err != nil {
+		return fmt.Errorf("record block deltas: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO aggregate_block_deltas (block_number, day, token_address, transfers, volume)
+		SELECT t.block_number, (to_timestamp(b.timestamp) AT TIME ZONE 'UTC')::DATE, t.token_address, COUNT(*), SUM(t.amount)
+		FROM transfers t JOIN blocks b ON b.number = t.block_number
+		WHERE t.block_number > $1::NUMERIC AND t.block_number <= $2::NUMERIC
+		GROUP BY t.block_number, b.timestamp, t.token_address
+		ON CONFLICT (block_number, token_address) DO UPDATE SET
+			day = EXCLUDED.day, transfers = EXCLUDED.transfers, volume = EXCLUDED.volume`,
+		from, to); err != nil {
+		return fmt.Errorf("record token deltas: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM aggregate_block_deltas WHERE block_number <= $1::NUMERIC - $2",
+		to, aggregateDeltaRetention); err != nil {
+		return fmt.Errorf("prune deltas: %w", err)
+	}
+	return nil
+}
+
+// RevertAggregates 在回滚事务内撤销 number >= fromBlock 的已聚合区块对 daily_stats / daily_token_stats 的贡献，
+// 并把日统计、稳定币资金流与 sink 水位回退到 fromBlock-1，使替换后的新区块触发受影响日期 / 小时的整体重算并重新转发给下游。
+// 必须在删除 blocks 之前、与删除处于同一事务中调用；返回被撤销的区块数。
+//
+// unique_addresses 无法按块相减，在下一轮 DailyAggregator.Refresh 重算该日之前保持旧值。
+func RevertAggregates(ctx context.Context, tx *sqlx.Tx, fromBlock string) (int64, error) {
+	var reverted int64
+	if err := tx.GetContext(ctx, &reverted,
+		"SELECT COUNT(*) FROM aggregate_block_deltas WHERE token_address = '' AND block_number >= $1::NUMERIC",
+		fromBlock); err != nil {
+		return 0, fmt.Errorf("count deltas: %w", err)
+	}
+
+	if reverted > 0 {
+		stmts := []string{
+			`UPDATE daily_stats d SET
+				blocks = GREATEST(d.blocks - x.blocks, 0),
+				transfers = GREATEST(d.transfers - x.transfers, 0),
+				updated_at = NOW()
+			FROM (
+				SELECT day, COUNT(*) AS blocks, SUM(transfers) AS transfers
+				FROM aggregate_block_deltas WHERE token_address = '' AND block_number >= $1::NUMERIC
+				GROUP BY day
+			) x
+			WHERE d.day = x.day`,
+			`UPDATE daily_token_stats d SET
+				transfers = GREATEST(d.transfers - x.transfers, 0),
+				volume = GREATEST(d.volume - x.volume, 0)
+			FROM (
+				SELECT day, token_address, SUM(transfers) AS transfers, SUM(volume) AS volume
+				FROM aggregate_block_deltas WHERE token_address <> '' AND block_number >= $1::NUMERIC
+				GROUP BY day, token_address
+			) x
+			WHERE d.day = x.day AND d.token_address = x.token_address`,
+			"DELETE FROM aggregate_block_deltas WHERE block_number >= $1::NUMERIC",
+		}
+		for _, stmt := range stmts {
+			if _, err := tx.ExecContext(ctx, stmt, fromBlock); err != nil {
+				return 0, fmt.Errorf("revert aggregates: %w", err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM daily_token_stats WHERE transfers = 0"); err != nil {
+			return 0, fmt.Errorf("drop empty token stats: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM daily_stats WHERE blocks = 0"); err != nil {
+			return 0, fmt.Errorf("drop empty daily stats: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE aggregate_watermarks SET last_block = $1::NUMERIC - 1, updated_at = NOW()
+		WHERE name IN ($2, $3, $4) AND last_block >= $1::NUMERIC`,
+		fromBlock, dailyAggregateWatermark, stablecoinFlowWatermark, sinkWatermark); err != nil {
+		return 0, fmt.Errorf("rewind aggregate watermark: %w", err)
+	}
+
+	if reverted > 0 {
+		Logger.Info("📅 [Aggregates] Reverted reorged blocks",
+			slog.String("from_block", fromBlock),
+			slog.Int64("blocks", reverted))
+	}
+	return reverted, nil
+}
@@ -0,0 +1,164 @@
+//go:build integration
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// aggregateDayRow daily_stats 的可比较部分
+type aggregateDayRow struct {
+	Day             string `db:"day"`
+	Blocks          int64  `db:"blocks"`
+	Transfers       int64  `db:"transfers"`
+	UniqueAddresses int64  `db:"unique_addresses"`
+}
+
+// aggregateTokenRow daily_token_stats 的可比较部分
+type aggregateTokenRow struct {
+	Day          string `db:"day"`
+	TokenAddress string `db:"token_address"`
+	Transfers    int64  `db:"transfers"`
+	Volume       string `db:"volume"`
+}
+
+// aggregateBase 区块 1..8 落在第一天（20:00 起每 30 分钟一块），9 起落在第二天
+var aggregateBase = time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC).Unix()
+
+// insertAggregateBlocks 写入 [from, to] 区块；每块 n%3+extra 条转账，代币交替，variant 区分重组前后的数据
+func insertAggregateBlocks(t *testing.T, db *sqlx.DB, from, to uint64, variant string, extra uint64) {
+	t.Helper()
+	tokens := []string{
+		strings.ToLower(testkit.Address("token", 1).Hex()),
+		strings.ToLower(testkit.Address("token", 2).Hex()),
+	}
+	for n := from; n <= to; n++ {
+		_, err := db.Exec(`INSERT INTO blocks (number, hash, parent_hash, timestamp) VALUES ($1, $2, $3, $4)`,
+			n, fmt.Sprintf("0x%s%062d", variant, n), fmt.Sprintf("0x%s%062d", variant, n-1), aggregateBase+int64(n-1)*1800)
+		require.NoError(t, err)
+		for i := uint64(0); i < n%3+extra; i++ {
+			_, err := db.Exec(`INSERT INTO transfers (block_number, tx_hash, log_index, from_address, to_address, amount, token_address)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+				n, testkit.TxHash(n, i).Hex(), i,
+				strings.ToLower(testkit.Address("from"+variant, n).Hex()),
+				strings.ToLower(testkit.Address("to", i).Hex()),
+				fmt.Sprintf("%d000000000000000000", n*10+i), tokens[(n+i)%2])
+			require.NoError(t, err)
+		}
+	}
+}
+
+// aggregateState 当前日统计、代币日统计与水位
+func aggregateState(t *testing.T, db *sqlx.DB) ([]aggregateDayRow, []aggregateTokenRow, string) {
+	t.Helper()
+	var days []aggregateDayRow
+	require.NoError(t, db.Select(&days, `
+		SELECT day::TEXT AS day, blocks, transfers, unique_addresses FROM daily_stats ORDER BY day`))
+	var tokens []aggregateTokenRow
+	require.NoError(t, db.Select(&tokens, `
+		SELECT day::TEXT AS day, token_address, transfers, volume::TEXT AS volume
+		FROM daily_token_stats ORDER BY day, token_address`))
+	var watermark string
+	require.NoError(t, db.Get(&watermark,
+		"SELECT last_block::TEXT FROM aggregate_watermarks WHERE name = $1", dailyAggregateWatermark))
+	return days, tokens, watermark
+}
+
+// recomputedAggregates 从当前 blocks / transfers 全量重算的日统计（回滚结果必须与之一致）
+func recomputedAggregates(t *testing.T, db *sqlx.DB) ([]aggregateDayRow, []aggregateTokenRow) {
+	t.Helper()
+	var days []aggregateDayRow
+	require.NoError(t, db.Select(&days, `
+		WITH bd AS (
+			SELECT number, (to_timestamp(timestamp) AT TIME ZONE 'UTC')::DATE AS day FROM blocks
+		), td AS (
+			SELECT bd.day, t.from_address, t.to_address FROM transfers t JOIN bd ON bd.number = t.block_number
+		)
+		SELECT bd.day::TEXT AS day, COUNT(*) AS blocks,
+			(SELECT COUNT(*) FROM td WHERE td.day = bd.day) AS transfers,
+			(SELECT COUNT(*) FROM (
+				SELECT from_address FROM td WHERE td.day = bd.day
+				UNION
+				SELECT to_address FROM td WHERE td.day = bd.day
+			) u) AS unique_addresses
+		FROM bd
+		GROUP BY bd.day
+		ORDER BY bd.day`))
+	var tokens []aggregateTokenRow
+	require.NoError(t, db.Select(&tokens, `
+		SELECT (to_timestamp(b.timestamp) AT TIME ZONE 'UTC')::DATE::TEXT AS day, t.token_address,
+			COUNT(*) AS transfers, SUM(t.amount)::TEXT AS volume
+		FROM transfers t JOIN blocks b ON b.number = t.block_number
+		GROUP BY 1, t.token_address
+		ORDER BY 1, 2`))
+	return days, tokens
+}
+
+// withoutUniqueAddresses 回滚后 unique_addresses 保持旧值直到下一轮重算，比较时忽略
+func withoutUniqueAddresses(rows []aggregateDayRow) []aggregateDayRow {
+	out := make([]aggregateDayRow, len(rows))
+	for i, r := range rows {
+		r.UniqueAddresses = 0
+		out[i] = r
+	}
+	return out
+}
+
+// TestAggregateRollback_MatchesRecompute 聚合 N 块后从 N-k 回滚，日统计与代币日统计须等于对剩余数据的全量重算，水位回退到 N-k-1
+func TestAggregateRollback_MatchesRecompute(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+	store := NewStore(db)
+	require.NoError(t, store.Reset(ctx))
+	aggregator := NewDailyAggregator(db, time.Minute)
+
+	const n, k = 20, 6
+	insertAggregateBlocks(t, db, 1, n, "aa", 0)
+	require.NoError(t, aggregator.Refresh(ctx))
+	days, tokens, watermark := aggregateState(t, db)
+	wantDays, wantTokens := recomputedAggregates(t, db)
+	require.Len(t, days, 2, "fixture spans two UTC days")
+	assert.Equal(t, wantDays, days)
+	assert.Equal(t, wantTokens, tokens)
+	assert.Equal(t, fmt.Sprint(n), watermark)
+
+	// 1. 从 N-k 回滚：第二天只撤销部分区块
+	require.NoError(t, store.RollbackAbove(ctx, 1, n-k-1))
+	days, tokens, watermark = aggregateState(t, db)
+	wantDays, wantTokens = recomputedAggregates(t, db)
+	assert.Equal(t, withoutUniqueAddresses(wantDays), withoutUniqueAddresses(days))
+	assert.Equal(t, wantTokens, tokens)
+	assert.Equal(t, fmt.Sprint(n-k-1), watermark, "watermark rewinds below the reverted range")
+	var deltas int64
+	require.NoError(t, db.Get(&deltas, "SELECT COUNT(*) FROM aggregate_block_deltas WHERE block_number >= $1", n-k))
+	assert.Zero(t, deltas, "reverted deltas are dropped")
+
+	// 2. 替换链接入后重新聚合：包括 unique_addresses 在内与全量重算一致
+	insertAggregateBlocks(t, db, n-k, n+2, "bb", 1)
+	require.NoError(t, aggregator.Refresh(ctx))
+	days, tokens, watermark = aggregateState(t, db)
+	wantDays, wantTokens = recomputedAggregates(t, db)
+	assert.Equal(t, wantDays, days)
+	assert.Equal(t, wantTokens, tokens)
+	assert.Equal(t, fmt.Sprint(n+2), watermark)
+
+	// 3. 回滚整个第二天：该日的统计行被删除
+	require.NoError(t, store.RollbackAbove(ctx, 1, 8))
+	days, tokens, watermark = aggregateState(t, db)
+	wantDays, wantTokens = recomputedAggregates(t, db)
+	require.Len(t, days, 1)
+	assert.Equal(t, withoutUniqueAddresses(wantDays), withoutUniqueAddresses(days))
+	assert.Equal(t, wantTokens, tokens)
+	assert.Equal(t, "8", watermark)
+}
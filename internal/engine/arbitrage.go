@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"strings"
+
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// arbitrageMinPools 构成环形路径所需的最少池子数
+const arbitrageMinPools = 2
+
+// swapLeg 从 Uniswap V2/V3 Swap 日志解出的一次池子交换（topics[1] 为调用池子的 sender，topics[2] 为接收方）
+type swapLeg struct {
+	TxHash    common.Hash
+	Pool      common.Address
+	Sender    common.Address
+	Recipient common.Address
+}
+
+// decodeSwapLeg 识别 V2/V3 Swap 日志；其他日志返回 false
+func decodeSwapLeg(l types.Log) (swapLeg, bool) {
+	if len(l.Topics) < 3 || (l.Topics[0] != SwapEventHash && l.Topics[0] != SwapV2EventHash) {
+		return swapLeg{}, false
+	}
+	return swapLeg{
+		TxHash:    l.TxHash,
+		Pool:      l.Address,
+		Sender:    common.BytesToAddress(l.Topics[1].Bytes()),
+		Recipient: common.BytesToAddress(l.Topics[2].Bytes()),
+	}, true
+}
+
+// tokenFlow 某地址在一组交易内对单个代币的收支
+type tokenFlow struct {
+	in, out *big.Int
+}
+
+// detectArbitrage 在单个区块的日志中查找环形套利候选：
+// 同一 sender 在本块内跨至少两个不同池子发起 Swap，且其在这些交易中的 ERC20 净变动全部非负、
+// 至少一个代币既有支出又有净收入（起点代币绕一圈后变多）。利润取该代币的净收入，未扣除 Gas。
+func detectArbitrage(blockNum uint64, logs []types.Log) []storage.ArbitrageEventRow {
+	type group struct {
+		pools  map[common.Address]struct{}
+		txs    map[common.Hash]struct{}
+		poolOr []string
+		txOr   []string
+		swaps  int
+	}
+	groups := make(map[common.Address]*group)
+	for _, l := range logs {
+		leg, ok := decodeSwapLeg(l)
+		if !ok || leg.Sender == (common.Address{}) {
+			continue
+		}
+		g, ok := groups[leg.Sender]
+		if !ok {
+			g = &group{pools: make(map[common.Address]struct{}), txs: make(map[common.Hash]struct{})}
+			groups[leg.Sender] = g
+		}
+		g.swaps++
+		if _, seen := g.pools[leg.Pool]; !seen {
+			g.pools[leg.Pool] = struct{}{}
+			g.poolOr = append(g.poolOr, strings.ToLower(leg.Pool.Hex()))
+		}
+		if _, seen := g.txs[leg.TxHash]; !seen {
+			g.txs[leg.TxHash] = struct{}{}
+			g.txOr = append(g.txOr, leg.TxHash.Hex())
+		}
+	}
+
+	var out []storage.ArbitrageEventRow
+	for sender, g := range groups {
+		if len(g.pools) < arbitrageMinPools {
+			continue
+		}
+		flows := senderTokenFlows(sender, g.txs, logs)
+		token, profit, ok := circularProfit(flows)
+		if !ok {
+			continue
+		}
+		out = append(out, storage.ArbitrageEventRow{
+			Block:        blockNum,
+			Sender:       strings.ToLower(sender.Hex()),
+			TxHashes:     g.txOr,
+			Pools:        g.poolOr,
+			Swaps:        g.swaps,
+			ProfitToken:  strings.ToLower(token.Hex()),
+			ProfitAmount: profit,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Sender < out[j].Sender })
+	return out
+}
+
+// senderTokenFlows 汇总 sender 在指定交易内的 ERC20 Transfer 收支（按代币）
+func senderTokenFlows(sender common.Address, txs map[common.Hash]struct{}, logs []types.Log) map[common.Address]*tokenFlow {
+	flows := make(map[common.Address]*tokenFlow)
+	for _, l := range logs {
+		if len(l.Topics) != 3 || l.Topics[0] != TransferEventHash || len(l.Data) != 32 {
+			continue // 只统计 ERC20（ERC721 的 tokenId 在 topics[3]）
+		}
+		if _, ok := txs[l.TxHash]; !ok {
+			continue
+		}
+		from := common.BytesToAddress(l.Topics[1].Bytes())
+		to := common.BytesToAddress(l.Topics[2].Bytes())
+		if from != sender && to != sender {
+			continue
+		}
+		f, ok := flows[l.Address]
+		if !ok {
+			f = &tokenFlow{in: new(big.Int), out: new(big.Int)}
+			flows[l.Address] = f
+		}
+		amount := new(big.Int).SetBytes(l.Data)
+		if to == sender {
+			f.in.Add(f.in, amount)
+		}
+		if from == sender {
+			f.out.Add(f.out, amount)
+		}
+	}
+	return flows
+}
+
+// circularProfit 判断收支是否构成环形套利：所有代币净变动非负，返回既有支出又有净收入的代币及其净收入
+// （多个代币同时满足时取地址最小者，保证结果确定）
+func circularProfit(flows map[common.Address]*tokenFlow) (common.Address, *big.Int, bool) {
+	var (
+		best   common.Address
+		profit *big.Int
+	)
+	for token, f := range flows {
+		net := new(big.Int).Sub(f.in, f.out)
+		if net.Sign() < 0 {
+			return common.Address{}, nil, false
+		}
+		if net.Sign() == 0 || f.out.Sign() == 0 {
+			continue
+		}
+		if profit == nil || strings.ToLower(token.Hex()) < strings.ToLower(best.Hex()) {
+			best, profit = token, net
+		}
+	}
+	return best, profit, profit != nil
+}
+
+// insertArbitrageEventsTx 在 AsyncWriter 事务内写入批次的套利候选（随 blocks 级联删除，reorg 无需额外撤销）
+func insertArbitrageEventsTx(ctx context.Context, tx execer, rows []storage.ArbitrageEventRow) error {
+	return storage.InsertArbitrageEventsTx(ctx, tx, rows)
+}
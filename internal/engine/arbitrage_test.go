@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectArbitrage(t *testing.T) {
+	var (
+		weth   = common.HexToAddress("0x00000000000000000000000000000000000000e1")
+		usdc   = common.HexToAddress("0x00000000000000000000000000000000000000e2")
+		pool1  = common.HexToAddress("0x00000000000000000000000000000000000000f1")
+		pool2  = common.HexToAddress("0x00000000000000000000000000000000000000f2")
+		arber  = common.HexToAddress("0x00000000000000000000000000000000000000a1")
+		loser  = common.HexToAddress("0x00000000000000000000000000000000000000a2")
+		router = common.HexToAddress("0x00000000000000000000000000000000000000a3")
+		user   = common.HexToAddress("0x00000000000000000000000000000000000000a4")
+		tx1    = common.HexToHash("0x01")
+		tx2    = common.HexToHash("0x02")
+		tx3    = common.HexToHash("0x03")
+	)
+	transfer := func(tx common.Hash, token, from, to common.Address, amount int64) types.Log {
+		return types.Log{
+			TxHash: tx, Address: token,
+			Topics: []common.Hash{TransferEventHash, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+			Data:   common.LeftPadBytes(big.NewInt(amount).Bytes(), 32),
+		}
+	}
+	swap := func(tx common.Hash, sig common.Hash, pool, sender, recipient common.Address) types.Log {
+		return types.Log{
+			TxHash: tx, Address: pool,
+			Topics: []common.Hash{sig, common.BytesToHash(sender.Bytes()), common.BytesToHash(recipient.Bytes())},
+		}
+	}
+
+	logs := []types.Log{
+		// 套利：WETH -> USDC (V3 池) -> WETH (V2 池)，多换回 10 WETH
+		transfer(tx1, weth, arber, pool1, 100),
+		transfer(tx1, usdc, pool1, arber, 200),
+		swap(tx1, SwapEventHash, pool1, arber, arber),
+		transfer(tx1, usdc, arber, pool2, 200),
+		transfer(tx1, weth, pool2, arber, 110),
+		swap(tx1, SwapV2EventHash, pool2, arber, arber),
+
+		// 同样的环形路径但亏损
+		transfer(tx2, weth, loser, pool1, 100),
+		transfer(tx2, usdc, pool1, loser, 200),
+		swap(tx2, SwapEventHash, pool1, loser, loser),
+		transfer(tx2, usdc, loser, pool2, 200),
+		transfer(tx2, weth, pool2, loser, 90),
+		swap(tx2, SwapEventHash, pool2, loser, loser),
+
+		// 路由器代用户多跳：路由器自身无净收入
+		transfer(tx3, weth, user, pool1, 100),
+		swap(tx3, SwapEventHash, pool1, router, pool2),
+		transfer(tx3, usdc, pool2, user, 50),
+		swap(tx3, SwapEventHash, pool2, router, user),
+	}
+
+	got := detectArbitrage(42, logs)
+	require.Len(t, got, 1)
+	assert.EqualValues(t, 42, got[0].Block)
+	assert.Equal(t, "0x00000000000000000000000000000000000000a1", got[0].Sender)
+	assert.Equal(t, []string{"0x00000000000000000000000000000000000000f1", "0x00000000000000000000000000000000000000f2"}, got[0].Pools)
+	assert.Equal(t, []string{tx1.Hex()}, got[0].TxHashes)
+	assert.Equal(t, 2, got[0].Swaps)
+	assert.Equal(t, "0x00000000000000000000000000000000000000e1", got[0].ProfitToken)
+	assert.EqualValues(t, 10, got[0].ProfitAmount.Int64())
+
+	// 单池往返不构成跨池环形交易
+	assert.Empty(t, detectArbitrage(42, logs[:3]))
+}
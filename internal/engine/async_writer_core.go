@@ -3,11 +3,29 @@ package engine
 import (
 	"context"
 	"log/slog"
+	"sync/atomic"
 	"time"
 
+	"web3-indexer-go/internal/storage"
+
 	"github.com/jmoiron/sqlx"
 )
 
+// finalFlushTimeout 关闭时最终落盘事务的超时时间
+const finalFlushTimeout = 20 * time.Second
+
+// statementCacheEnabled 新建的 AsyncWriter 是否启用预编译语句缓存（默认开启，压测对比时可关闭）
+var statementCacheEnabled atomic.Bool
+
+func init() {
+	statementCacheEnabled.Store(true)
+}
+
+// SetStatementCache 设置之后创建的 AsyncWriter 是否对热路径 INSERT 使用预编译语句缓存
+func SetStatementCache(enabled bool) {
+	statementCacheEnabled.Store(enabled)
+}
+
 // NewAsyncWriter 初始化
 func NewAsyncWriter(db *sqlx.DB, o *Orchestrator, ephemeral bool, chainID int64) *AsyncWriter {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -22,10 +40,18 @@ func NewAsyncWriter(db *sqlx.DB, o *Orchestrator, ephemeral bool, chainID int64)
 		ctx:           ctx,
 		cancel:        cancel,
 	}
+	if db != nil && !ephemeral && statementCacheEnabled.Load() {
+		w.stmts = storage.NewStmtCache(db)
+	}
 	w.emergencyDrainCooldown.Store(false) // 🚀 初始化冷却标志
 	return w
 }
 
+// SetCommitHook 设置批次提交成功后的回调（需在 Start 之前调用）
+func (w *AsyncWriter) SetCommitHook(hook func(ctx context.Context, batch []PersistTask, durable bool)) {
+	w.onCommitted = hook
+}
+
 // Start 启动写入主循环
 func (w *AsyncWriter) Start() {
 	slog.Info("📝 AsyncWriter: Engine Started",
@@ -44,9 +70,7 @@ func (w *AsyncWriter) run() {
 	for {
 		select {
 		case <-w.ctx.Done():
-			if len(batch) > 0 {
-				w.flush(batch)
-			}
+			w.finalFlush(batch)
 			return
 		case task := <-w.taskChan:
 			// 🚀 紧急排水检查：如果队列深度超过 75%，先保存当前 task 再排水
@@ -54,27 +78,62 @@ func (w *AsyncWriter) run() {
 			if len(w.taskChan) > drainThreshold {
 				// 🔥 FINDING-5 修复：不丢弃当前 task，先 flush 已积累的 batch + 当前 task
 				batch = append(batch, task)
-				if len(batch) > 0 {
-					w.flush(batch)
+				if w.flush(batch) {
+					batch = batch[:0]
 				}
-				batch = batch[:0]
 				w.emergencyDrain()
 				continue
 			}
 			batch = append(batch, task)
-			if len(batch) >= w.batchSize {
-				w.flush(batch)
+			// flush 返回 false 时保留批次（ctx 已结束，由 finalFlush 接手）
+			if len(batch) >= w.batchSize && w.flush(batch) {
 				batch = batch[:0]
 			}
 		case <-ticker.C:
-			if len(batch) > 0 {
-				w.flush(batch)
+			if len(batch) > 0 && w.flush(batch) {
 				batch = batch[:0]
 			}
 		}
 	}
 }
 
+// finalFlush 关闭时排空队列中剩余任务并强制写入最后一次 checkpoint，
+// 避免重启后重复处理最多一个批次的区块
+func (w *AsyncWriter) finalFlush(batch []PersistTask) {
+	start := time.Now()
+drain:
+	for {
+		select {
+		case task := <-w.taskChan:
+			batch = append(batch, task)
+		default:
+			break drain
+		}
+	}
+
+	if len(batch) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), finalFlushTimeout)
+		defer cancel()
+		// 按 batchSize 分块写入，避免单个事务过大
+		for i := 0; i < len(batch); i += w.batchSize {
+			end := i + w.batchSize
+			if end > len(batch) {
+				end = len(batch)
+			}
+			if !w.flushWithContext(ctx, batch[i:end]) {
+				slog.Error("📝 AsyncWriter: Final flush gave up, tasks not persisted", "tasks", len(batch)-i)
+				break
+			}
+		}
+	}
+
+	GetMetrics().ShutdownFlushDuration.Observe(time.Since(start).Seconds())
+	slog.Info("📝 AsyncWriter: Final flush complete",
+		"tasks", len(batch),
+		"disk_watermark", w.diskWatermark.Load(),
+		"duration", time.Since(start))
+}
+
 // Enqueue 提交持久化任务
 func (w *AsyncWriter) Enqueue(task PersistTask) error {
 	select {
@@ -95,17 +154,75 @@ func (w *AsyncWriter) Shutdown(timeout time.Duration) error {
 	}()
 	select {
 	case <-done:
+		if w.stmts != nil {
+			return w.stmts.Close()
+		}
 		return nil
 	case <-time.After(timeout):
 		return context.DeadlineExceeded
 	}
 }
 
+// PauseWrites 等待进行中的落盘事务结束后暂停落盘（实现 database.WritePauser）。
+// 暂停期间任务继续在队列中积累，ResumeWrites 后按原顺序写入；ctx 结束前未能暂停则放弃
+func (w *AsyncWriter) PauseWrites(ctx context.Context) error {
+	locked := make(chan struct{})
+	go func() {
+		w.writeGate.Lock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		w.paused.Store(true)
+		return nil
+	case <-ctx.Done():
+		go func() {
+			<-locked
+			w.writeGate.Unlock()
+		}()
+		return ctx.Err()
+	}
+}
+
+// ResumeWrites 恢复落盘（未暂停时为空操作）
+func (w *AsyncWriter) ResumeWrites() {
+	if w.paused.CompareAndSwap(true, false) {
+		w.writeGate.Unlock()
+	}
+}
+
+// DiscardPending 丢弃链重置前分发的全部待写任务并把落盘水位归零，返回从队列中移除的任务数。
+// 须在 PauseWrites 之后、清库之前调用：递增落盘纪元后，run 循环手中的批次与仍在 Orchestrator 通道中的
+// 旧任务在下一次 flush 时同样被丢弃，不会在清库后以 ON CONFLICT DO NOTHING 挤掉新链同高度的区块
+func (w *AsyncWriter) DiscardPending() int {
+	w.orchestrator.persistEpoch.Add(1)
+	discarded := 0
+	for {
+		select {
+		case <-w.taskChan:
+			discarded++
+		default:
+			w.diskWatermark.Store(0)
+			return discarded
+		}
+	}
+}
+
 // GetMetrics 获取性能指标
 func (w *AsyncWriter) GetMetrics() map[string]interface{} {
 	return map[string]interface{}{
 		"disk_watermark":    w.diskWatermark.Load(),
 		"write_duration_ms": time.Duration(w.writeDuration.Load()).Milliseconds(),
 		"queue_depth":       len(w.taskChan),
+		"writes_paused":     w.paused.Load(),
+		"stmt_cache":        w.StatementCacheStats(),
+	}
+}
+
+// StatementCacheStats 返回预编译语句缓存统计（未启用时为零值）
+func (w *AsyncWriter) StatementCacheStats() storage.StmtCacheStats {
+	if w.stmts == nil {
+		return storage.StmtCacheStats{}
 	}
+	return w.stmts.Stats()
 }
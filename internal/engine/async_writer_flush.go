@@ -1,33 +1,76 @@
 package engine
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
+	"slices"
 	"time"
 
 	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 开启写事务 / 校验写锁遇到瞬时错误（连接中断、取连接失败）时的重试退避
+const (
+	writeLockRetryBase = 200 * time.Millisecond
+	writeLockRetryMax  = 5 * time.Second
 )
 
-func (w *AsyncWriter) flush(batch []PersistTask) {
+func (w *AsyncWriter) flush(batch []PersistTask) bool {
+	return w.flushWithContext(w.ctx, batch)
+}
+
+// flushWithContext 以指定 ctx 写入批次；关闭路径使用独立 ctx，避免已取消的生命周期 ctx 导致最终事务失败。
+// 返回 false 表示批次未被处理（开启事务 / 校验写锁期间 ctx 结束），调用方应保留批次稍后重试
+func (w *AsyncWriter) flushWithContext(ctx context.Context, batch []PersistTask) bool {
 	if len(batch) == 0 {
-		return
+		return true
+	}
+	w.writeGate.Lock()
+	defer w.writeGate.Unlock()
+	if batch = w.dropStale(batch); len(batch) == 0 {
+		return true
 	}
 	start := time.Now()
 	if w.ephemeralMode {
 		w.handleEphemeralFlush(batch)
-		return
+		return true
 	}
 
 	// 🔥 FINDING-9 修复：在事务开始前捕获快照，保证 latestHeight 与批次数据一致
 	snap := w.orchestrator.GetSnapshot()
 	latestHeight := snap.LatestHeight
 
-	tx, err := w.db.BeginTxx(w.ctx, nil)
-	if err != nil {
-		slog.Error("📝 AsyncWriter: BeginTx failed", "err", err)
-		return
+	// 🔒 单写者保护：写锁由其他实例持有时整批放弃，不推进落盘游标；
+	// 瞬时错误（数据库短暂不可用）重试直到成功或 ctx 结束，批次不丢弃
+	var tx *sqlx.Tx
+	err := retryWriteLock(ctx, func() error {
+		var err error
+		if tx, err = w.db.BeginTxx(ctx, writeTxOptions()); err != nil {
+			return fmt.Errorf("begin tx: %w", err)
+		}
+		if lock := ActiveWriteLock(); lock != nil {
+			if err := lock.Guard(ctx, tx); err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, ErrWriteLockHeld):
+		slog.Error("📝 AsyncWriter: Write lock held by another instance, batch discarded", "blocks", len(batch))
+		traceBatch(batch, TraceStageFailed, "write_lock: "+err.Error())
+		return true
+	case err != nil:
+		slog.Error("📝 AsyncWriter: Write transaction unavailable, batch retained", "err", err, "blocks", len(batch))
+		return false
 	}
 	defer func() {
 		if rbErr := tx.Rollback(); rbErr != nil && rbErr != sql.ErrTxDone {
@@ -37,75 +80,197 @@ func (w *AsyncWriter) flush(batch []PersistTask) {
 
 	var (
 		maxHeight         uint64
+		advances          bool // 批次内有实时区块（全是回填时不动 checkpoint）
 		transfersToInsert []models.Transfer
 		blocksToInsert    []models.Block
+		arbitrageToInsert []storage.ArbitrageEventRow
+		receiptsToInsert  []storage.ReceiptRow
+		nftsToInsert      []storage.NFTTransferRow
+		approvalsToInsert []storage.ApprovalRow
+		eventsToInsert    []storage.ContractEventRow
+		blobsToInsert     []storage.BlobTxRow
+		feesToInsert      []storage.BlockFeeRow
 	)
 
 	for _, task := range batch {
-		if task.Height > maxHeight {
-			maxHeight = task.Height
+		if !task.Backfill {
+			advances = true
+			maxHeight = max(maxHeight, task.Height)
 		}
 		GetMetrics().RecordBlockActivity(1)
 		blocksToInsert = append(blocksToInsert, task.Block)
 		transfersToInsert = append(transfersToInsert, task.Transfers...)
+		arbitrageToInsert = append(arbitrageToInsert, task.Arbitrage...)
+		receiptsToInsert = append(receiptsToInsert, task.Receipts...)
+		nftsToInsert = append(nftsToInsert, task.NFTs...)
+		approvalsToInsert = append(approvalsToInsert, task.Approvals...)
+		eventsToInsert = append(eventsToInsert, task.Events...)
+		blobsToInsert = append(blobsToInsert, task.Blobs...)
+		if task.Fees != nil {
+			feesToInsert = append(feesToInsert, *task.Fees)
+		}
+	}
+	for i := range transfersToInsert {
+		transfersToInsert[i].Type = NormalizeActivityType(transfersToInsert[i].Type)
 	}
 
+	exec := w.txExecer(tx)
 	inserter := NewBulkInserter(w.db)
-	if err := inserter.InsertBlocksBatchTx(w.ctx, tx, blocksToInsert); err != nil {
+	if err := inserter.InsertBlocksBatchTx(ctx, exec, blocksToInsert); err != nil {
 		slog.Error("📝 AsyncWriter: Block insert failed", "err", err, "count", len(blocksToInsert))
 		// 注意: 不 return，继续尝试插入 transfers，让 tx.Commit() 处理整体失败
 	}
 	if len(transfersToInsert) > 0 {
-		if err := inserter.InsertTransfersBatchTx(w.ctx, tx, transfersToInsert); err != nil {
+		if err := inserter.InsertTransfersBatchTx(ctx, exec, transfersToInsert); err != nil {
 			slog.Error("📝 AsyncWriter: Transfer insert failed", "err", err, "count", len(transfersToInsert))
 			// 注意: 不 return，让 tx.Commit() 处理整体失败
 		}
+		if err := upsertAddressRegistryTx(ctx, exec, transfersToInsert); err != nil {
+			slog.Error("📝 AsyncWriter: Address registry update failed", "err", err, "count", len(transfersToInsert))
+		}
+		if err := upsertSupplyDeltasTx(ctx, exec, transfersToInsert); err != nil {
+			slog.Error("📝 AsyncWriter: Supply delta update failed", "err", err, "count", len(transfersToInsert))
+		}
+	}
+	if err := insertArbitrageEventsTx(ctx, exec, arbitrageToInsert); err != nil {
+		slog.Error("📝 AsyncWriter: Arbitrage event insert failed", "err", err, "count", len(arbitrageToInsert))
+	}
+	if err := storage.InsertReceiptsTx(ctx, exec, receiptsToInsert); err != nil {
+		slog.Error("📝 AsyncWriter: Receipt insert failed", "err", err, "count", len(receiptsToInsert))
+	}
+	if err := storage.InsertNFTTransfersTx(ctx, exec, nftsToInsert); err != nil {
+		slog.Error("📝 AsyncWriter: NFT transfer insert failed", "err", err, "count", len(nftsToInsert))
+	}
+	if err := storage.InsertApprovalsTx(ctx, exec, approvalsToInsert); err != nil {
+		slog.Error("📝 AsyncWriter: Approval insert failed", "err", err, "count", len(approvalsToInsert))
+	}
+	if err := storage.InsertContractEventsTx(ctx, exec, eventsToInsert); err != nil {
+		slog.Error("📝 AsyncWriter: Contract event insert failed", "err", err, "count", len(eventsToInsert))
+	}
+	if err := storage.InsertBlobTxsTx(ctx, exec, blobsToInsert); err != nil {
+		slog.Error("📝 AsyncWriter: Blob transaction insert failed", "err", err, "count", len(blobsToInsert))
+	}
+	if err := storage.InsertBlockFeesTx(ctx, exec, feesToInsert); err != nil {
+		slog.Error("📝 AsyncWriter: Block fee insert failed", "err", err, "count", len(feesToInsert))
 	}
 
-	w.updateCheckpointsTx(tx, maxHeight, latestHeight)
+	if advances {
+		w.updateCheckpointsTx(ctx, exec, maxHeight, latestHeight)
+	}
 
-	if err := tx.Commit(); err != nil {
-		slog.Error("📝 AsyncWriter: Commit failed", "err", err)
-		return
+	err = tx.Commit()
+	// 提交路径只记录耗时与慢查询，不设超时（中断提交比慢提交代价更高）
+	observeQuery(ctx, "async_writer_commit", "INSERT blocks/transfers/addresses/supply/arbitrage/receipts/nft/approvals/events/blobs/fees + checkpoints; COMMIT", time.Since(start), err)
+	if err != nil {
+		slog.Error("📝 AsyncWriter: Commit failed", "err", err, "from_trace", batch[0].TraceID, "to_trace", batch[len(batch)-1].TraceID)
+		traceBatch(batch, TraceStageFailed, "commit: "+err.Error())
+		return true
 	}
+	traceBatch(batch, TraceStageCommitted, fmt.Sprintf("batch=%d", len(batch)))
+	// 类型分布只统计已提交的行，与 /api/stats/types 的数据库计数同源
+	GetMetrics().RecordActivityTypes(countActivityTypes(batch))
+	w.emitCommitted(ctx, batch, true)
 
-	w.diskWatermark.Store(maxHeight)
 	w.writeDuration.Store(int64(time.Since(start)))
-	w.orchestrator.Dispatch(CmdCommitDisk, maxHeight)
+	if advances {
+		w.diskWatermark.Store(maxHeight)
+		w.orchestrator.Dispatch(CmdCommitDisk, maxHeight)
+	}
+	return true
+}
+
+// dropStale 过滤早于当前落盘纪元的任务（持有 writeGate 时调用，纪元只在暂停期间递增）
+func (w *AsyncWriter) dropStale(batch []PersistTask) []PersistTask {
+	epoch := w.orchestrator.persistEpoch.Load()
+	if !slices.ContainsFunc(batch, func(task PersistTask) bool { return task.Epoch != epoch }) {
+		return batch
+	}
+	var fresh, stale []PersistTask
+	for _, task := range batch {
+		if task.Epoch == epoch {
+			fresh = append(fresh, task)
+		} else {
+			stale = append(stale, task)
+		}
+	}
+	if len(stale) > 0 {
+		slog.Warn("📝 AsyncWriter: Dropped tasks dispatched before chain reset", "tasks", len(stale))
+		traceBatch(stale, TraceStageFailed, "chain_reset: stale epoch")
+	}
+	return fresh
+}
+
+// retryWriteLock 执行 attempt 直到成功；ErrWriteLockHeld 立即返回（锁被他人持有，重试无意义），
+// 其余错误视为瞬时错误，按指数退避重试，ctx 结束时返回最后一次错误
+func retryWriteLock(ctx context.Context, attempt func() error) error {
+	delay := writeLockRetryBase
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil || errors.Is(err, ErrWriteLockHeld) {
+			return err
+		}
+		slog.Warn("📝 AsyncWriter: Write transaction setup failed, retrying", "err", err, "attempt", n, "retry_in", delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (last error: %w)", ctx.Err(), err)
+		case <-timer.C:
+		}
+		delay = min(delay*2, writeLockRetryMax)
+	}
+}
+
+// txExecer 返回批次事务使用的执行器：启用语句缓存时各 INSERT 复用连接上已 prepare 的语句
+func (w *AsyncWriter) txExecer(tx *sqlx.Tx) execer {
+	if w.stmts == nil {
+		return tx
+	}
+	return w.stmts.Tx(tx)
 }
 
 func (w *AsyncWriter) handleEphemeralFlush(batch []PersistTask) {
-	maxHeight := uint64(0)
+	maxHeight, advances := uint64(0), false
 	for _, task := range batch {
-		if task.Height > maxHeight {
-			maxHeight = task.Height
+		if !task.Backfill {
+			advances = true
+			maxHeight = max(maxHeight, task.Height)
 		}
 		GetMetrics().RecordBlockActivity(1)
 	}
-	w.diskWatermark.Store(maxHeight)
-	w.orchestrator.AdvanceDBCursor(maxHeight)
+	if advances {
+		w.diskWatermark.Store(maxHeight)
+		w.orchestrator.AdvanceDBCursor(maxHeight)
+	}
+	traceBatch(batch, TraceStageCommitted, "ephemeral")
+	GetMetrics().RecordActivityTypes(countActivityTypes(batch))
+	w.emitCommitted(w.ctx, batch, false)
 }
 
-func (w *AsyncWriter) updateCheckpointsTx(tx execer, maxHeight uint64, latestHeight uint64) {
-	maxHeightStr := fmt.Sprintf("%d", maxHeight)
-	_, err := tx.ExecContext(w.ctx, `
-		INSERT INTO sync_checkpoints (chain_id, last_synced_block)
-		VALUES ($1, $2) ON CONFLICT (chain_id) DO UPDATE SET last_synced_block = EXCLUDED.last_synced_block, updated_at = NOW()`,
-		w.chainID, maxHeightStr)
-	if err != nil {
-		slog.Error("📝 AsyncWriter: Checkpoint update failed", "err", err, "maxHeight", maxHeight)
+// emitCommitted 将已提交的批次交给提交后回调（未设置时跳过）
+func (w *AsyncWriter) emitCommitted(ctx context.Context, batch []PersistTask, durable bool) {
+	if w.onCommitted != nil {
+		w.onCommitted(ctx, batch, durable)
 	}
+}
 
+// traceBatch 为批次内每个任务记录追踪阶段（带 Orchestrator 序列号）
+func traceBatch(batch []PersistTask, stage, detail string) {
+	for _, task := range batch {
+		pipelineTrace.record(task.TraceID, task.Height, stage, task.Sequence, detail)
+	}
+}
+
+func (w *AsyncWriter) updateCheckpointsTx(ctx context.Context, tx execer, maxHeight uint64, latestHeight uint64) {
 	// 🛡️ 防御性位掩码：确保 uint64 → int64 转换时不会溢出
 	syncedBlock := SafeUint64ToInt64(maxHeight & uint64(math.MaxInt64))
+	if err := storage.UpsertCheckpointTx(ctx, tx, w.chainID, syncedBlock); err != nil {
+		slog.Error("📝 AsyncWriter: Checkpoint update failed", "err", err, "maxHeight", maxHeight)
+	}
+
 	// 🔥 FINDING-9 修复：latestBlock 从 flush 入口处的快照获取，保证与事务原子性一致
 	latestBlock := SafeUint64ToInt64(latestHeight & uint64(math.MaxInt64))
-	_, err = tx.ExecContext(w.ctx, `
-		INSERT INTO sync_status (chain_id, last_synced_block, latest_block, sync_lag, status, last_processed_block, last_processed_timestamp)
-		VALUES ($1, $2, $3, $4, 'syncing', $5, NOW())
-		ON CONFLICT (chain_id) DO UPDATE SET last_synced_block = EXCLUDED.last_synced_block, latest_block = EXCLUDED.latest_block, sync_lag = EXCLUDED.sync_lag, last_processed_block = EXCLUDED.last_processed_block`,
-		w.chainID, syncedBlock, latestBlock, latestBlock-syncedBlock, syncedBlock)
-	if err != nil {
+	if err := storage.UpsertSyncStatusTx(ctx, tx, w.chainID, syncedBlock, latestBlock); err != nil {
 		slog.Error("📝 AsyncWriter: Sync status update failed", "err", err, "syncedBlock", syncedBlock, "latestBlock", latestBlock)
 	}
 }
@@ -138,7 +303,14 @@ flushAll:
 	if len(megaBatch) > 0 {
 		slog.Warn("📝 AsyncWriter: Emergency drain → flushing to DB",
 			"drained_tasks", len(megaBatch))
-		w.flush(megaBatch)
+		if !w.flush(megaBatch) {
+			// 生命周期 ctx 已结束：放回队列交给 finalFlush
+			for _, task := range megaBatch {
+				if w.Enqueue(task) != nil {
+					slog.Error("📝 AsyncWriter: Emergency drain could not requeue task", "height", task.Height)
+				}
+			}
+		}
 	}
 	w.orchestrator.SetSystemState(SystemStateRunning)
 }
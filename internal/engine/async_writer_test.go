@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"web3-indexer-go/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAsyncWriter_ShutdownDrainsQueue 关闭时队列中尚未落盘的任务必须全部写入，水位推进到最高块
+func TestAsyncWriter_ShutdownDrainsQueue(t *testing.T) {
+	w := NewAsyncWriter(nil, GetOrchestrator(), true, 1)
+	w.flushInterval = time.Hour // 禁用定时 flush，确保数据只能由关闭路径写入
+	w.batchSize = 1000
+
+	for i := uint64(1); i <= 250; i++ {
+		assert.NoError(t, w.Enqueue(PersistTask{Height: i}))
+	}
+
+	w.Start()
+	assert.NoError(t, w.Shutdown(5*time.Second))
+
+	assert.Equal(t, uint64(250), w.diskWatermark.Load())
+	assert.Equal(t, 0, len(w.taskChan))
+}
+
+// TestAsyncWriter_PauseWritesHoldsFlush 暂停期间 flush 等待闸门，恢复后按原批次写入
+func TestAsyncWriter_PauseWritesHoldsFlush(t *testing.T) {
+	w := NewAsyncWriter(nil, GetOrchestrator(), true, 1)
+	require.NoError(t, w.PauseWrites(context.Background()))
+	assert.True(t, w.GetMetrics()["writes_paused"].(bool))
+
+	done := make(chan struct{})
+	go func() {
+		w.flush([]PersistTask{{Height: 7}})
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("flush completed while writes were paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	w.ResumeWrites()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("flush did not resume")
+	}
+	assert.Equal(t, uint64(7), w.diskWatermark.Load())
+	w.ResumeWrites() // 未暂停时为空操作
+}
+
+// TestAsyncWriter_DiscardPending 链重置时队列、调用方手中的批次与仍在 Orchestrator 通道中的旧纪元任务都不再落盘
+func TestAsyncWriter_DiscardPending(t *testing.T) {
+	o := &Orchestrator{ctx: context.Background(), cmdChan: make(chan Message, 16)}
+	dispatched := func(task PersistTask) PersistTask {
+		o.Dispatch(CmdCommitBatch, task)
+		for msg := range o.cmdChan {
+			if msg.Type == CmdCommitBatch {
+				return msg.Data.(PersistTask)
+			}
+		}
+		return PersistTask{}
+	}
+	w := NewAsyncWriter(nil, o, true, 1)
+	w.flush([]PersistTask{{Height: 5}})
+	require.Equal(t, uint64(5), w.diskWatermark.Load())
+
+	// 重置前分发、尚在 Orchestrator 通道中的任务带旧纪元
+	inFlight := dispatched(PersistTask{Height: 6})
+
+	require.NoError(t, w.PauseWrites(context.Background()))
+	require.NoError(t, w.Enqueue(PersistTask{Height: 7}))
+	require.NoError(t, w.Enqueue(PersistTask{Height: 8}))
+	assert.Equal(t, 2, w.DiscardPending())
+	assert.Zero(t, w.diskWatermark.Load())
+	assert.Zero(t, len(w.taskChan))
+	w.ResumeWrites()
+
+	assert.True(t, w.flush([]PersistTask{inFlight, {Height: 9}}))
+	assert.Zero(t, w.diskWatermark.Load(), "stale tasks never reach the store")
+
+	fresh := dispatched(PersistTask{Height: 1})
+	assert.Equal(t, uint64(1), fresh.Epoch)
+	w.flush([]PersistTask{fresh})
+	assert.Equal(t, uint64(1), w.diskWatermark.Load())
+}
+
+// TestAsyncWriter_CommitHookSeesOnlyCommittedTasks 下游 sink 只接收已确认的批次，链重置前分发的旧任务不会转发
+func TestAsyncWriter_CommitHookSeesOnlyCommittedTasks(t *testing.T) {
+	o := &Orchestrator{ctx: context.Background(), cmdChan: make(chan Message, 16)}
+	sink := &recordingSink{}
+	p := &Processor{chainID: 1}
+	p.SetSink(sink)
+	w := NewAsyncWriter(nil, o, true, 1)
+	w.SetCommitHook(p.EmitCommitted)
+
+	task := func(height int64, epoch uint64) PersistTask {
+		return PersistTask{
+			Height:    uint64(height),
+			Block:     models.Block{Number: models.NewBigInt(height), Hash: fmt.Sprintf("0x%d", height)},
+			Transfers: testTransfers(height, 1),
+			Epoch:     epoch,
+		}
+	}
+	w.flush([]PersistTask{task(1, 0), task(2, 0)})
+	require.NoError(t, w.PauseWrites(context.Background()))
+	w.DiscardPending()
+	w.ResumeWrites()
+	w.flush([]PersistTask{task(3, 0), task(4, 1)})
+
+	heights := make([]string, 0, len(sink.blocks))
+	for _, b := range sink.blocks {
+		heights = append(heights, b.Number.String())
+	}
+	assert.Equal(t, []string{"1", "2", "4"}, heights)
+	assert.Len(t, sink.transfers, 3)
+}
+
+// TestAsyncWriter_PauseWritesGivesUpOnContext 进行中的 flush 未结束时，ctx 到期即放弃暂停且不遗留锁
+func TestAsyncWriter_PauseWritesGivesUpOnContext(t *testing.T) {
+	w := NewAsyncWriter(nil, GetOrchestrator(), true, 1)
+	w.writeGate.Lock() // 模拟进行中的落盘事务
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.PauseWrites(ctx), context.DeadlineExceeded)
+	assert.False(t, w.paused.Load())
+
+	w.writeGate.Unlock()
+	require.Eventually(t, func() bool {
+		if !w.writeGate.TryLock() {
+			return false
+		}
+		w.writeGate.Unlock()
+		return true
+	}, time.Second, 5*time.Millisecond, "abandoned pause releases the gate")
+}
+
+// TestRetryWriteLock 瞬时错误重试直到成功；锁被他人持有立即放弃；ctx 结束时返回并保留最后一次错误
+func TestRetryWriteLock(t *testing.T) {
+	transient := errors.New("driver: bad connection")
+
+	calls := 0
+	err := retryWriteLock(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return transient
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls, "transient errors are retried")
+
+	calls = 0
+	err = retryWriteLock(context.Background(), func() error {
+		calls++
+		return fmt.Errorf("verify: %w", ErrWriteLockHeld)
+	})
+	assert.ErrorIs(t, err, ErrWriteLockHeld)
+	assert.Equal(t, 1, calls, "lock held elsewhere is not retried")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = retryWriteLock(ctx, func() error { return transient })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, err, transient)
+	assert.NotErrorIs(t, err, ErrWriteLockHeld)
+}
@@ -7,16 +7,27 @@ import (
 	"time"
 
 	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
 
 	"github.com/jmoiron/sqlx"
 )
 
 // PersistTask 携带需要落盘的原始交易数据
 type PersistTask struct {
-	Height    uint64            // 区块高度
-	Block     models.Block      // 区块元数据
-	Transfers []models.Transfer // 提取出的转账记录
-	Sequence  uint64            // 消息序列号 (用于追踪)
+	Height    uint64                      // 区块高度
+	Block     models.Block                // 区块元数据
+	Transfers []models.Transfer           // 提取出的转账记录
+	Arbitrage []storage.ArbitrageEventRow // 同块环形套利候选
+	Receipts  []storage.ReceiptRow        // 交易回执摘要（回执抓取模式）
+	NFTs      []storage.NFTTransferRow    // ERC-721 转账
+	Approvals []storage.ApprovalRow       // Approval / ApprovalForAll 授权事件
+	Events    []storage.ContractEventRow  // 按配置签名通用解码的合约事件
+	Blobs     []storage.BlobTxRow         // EIP-4844 blob 交易
+	Fees      *storage.BlockFeeRow        // EIP-1559 费用统计（London 之前的区块为 nil）
+	Sequence  uint64                      // Orchestrator 消息序列号（分发时写入）
+	TraceID   string                      // 流水线追踪 ID
+	Backfill  bool                        // 回填已跳过的历史区块：照常入库，但不推进 checkpoint 与落盘游标
+	Epoch     uint64                      // 分发时的落盘纪元：早于当前纪元的任务（链重置前的旧链数据）在 flush 时丢弃
 }
 
 // AsyncWriter 负责异步持久化逻辑
@@ -28,6 +39,7 @@ type AsyncWriter struct {
 	orchestrator  *Orchestrator
 	chainID       int64
 	ephemeralMode bool
+	stmts         *storage.StmtCache // 热路径 INSERT 预编译语句缓存（nil 表示直接执行）
 
 	// 2. 批处理配置
 	batchSize     int
@@ -42,4 +54,11 @@ type AsyncWriter struct {
 	diskWatermark          atomic.Uint64
 	writeDuration          atomic.Int64 // 纳秒
 	emergencyDrainCooldown atomic.Bool  // 🚀 紧急排水冷却标志，防止频繁触发
+
+	// 📤 提交后回调：批次提交成功（临时模式下确认）后转发给下游 sink，durable 表示数据已入库
+	onCommitted func(ctx context.Context, batch []PersistTask, durable bool)
+
+	// ⏸️ 落盘闸门：每次 flush 持有，在线迁移的非并发安全步骤期间由 PauseWrites 持有
+	writeGate sync.Mutex
+	paused    atomic.Bool
 }
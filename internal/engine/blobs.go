@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"strings"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// blobChainConfigs 已知 L1 的分叉时间表，决定各时间点每块的 blob 上限（Cancun 6 / Prague 9 / 之后按 BPO 调整）
+var blobChainConfigs = map[int64]*params.ChainConfig{
+	1:        params.MainnetChainConfig,
+	11155111: params.SepoliaChainConfig,
+	17000:    params.HoleskyChainConfig,
+	560048:   params.HoodiChainConfig,
+}
+
+// maxBlobGasPerBlock 区块时间戳处每块 blob gas 上限；未登记的链（Anvil 等开发链）按 Prague 默认上限
+func maxBlobGasPerBlock(chainID int64, timestamp uint64) uint64 {
+	if cfg, ok := blobChainConfigs[chainID]; ok {
+		return eip4844.MaxBlobGasPerBlock(cfg, timestamp)
+	}
+	return uint64(params.DefaultPragueBlobConfig.Max) * params.BlobTxBlobGasPerBlob // #nosec G115 - 协议常量
+}
+
+// newBlockModel 区块头 → 落库模型（ProcessBlock 与 ProcessBatch 共用）
+func newBlockModel(block *types.Block) models.Block {
+	var baseFee *models.BigInt
+	if block.BaseFee() != nil {
+		baseFee = &models.BigInt{Int: block.BaseFee()}
+	}
+	return models.Block{
+		Number:           models.BigInt{Int: block.Number()},
+		Hash:             block.Hash().Hex(),
+		ParentHash:       block.ParentHash().Hex(),
+		Timestamp:        block.Time(),
+		GasLimit:         block.GasLimit(),
+		GasUsed:          block.GasUsed(),
+		BaseFeePerGas:    baseFee,
+		TransactionCount: len(block.Transactions()),
+		BlobGasUsed:      block.BlobGasUsed(),
+		ExcessBlobGas:    block.ExcessBlobGas(),
+	}
+}
+
+// buildBlobTxRows 提取区块内的 blob 交易（type 3）及其 versioned hash；区块不含 blob 交易时返回 nil。
+// blob 本体（sidecar）不随区块下发，只记录链上承诺
+func buildBlobTxRows(block *types.Block) []storage.BlobTxRow {
+	var rows []storage.BlobTxRow
+	for i, tx := range block.Transactions() {
+		if tx.Type() != types.BlobTxType {
+			continue
+		}
+		hashes := make([]string, len(tx.BlobHashes()))
+		for j, h := range tx.BlobHashes() {
+			hashes[j] = strings.ToLower(h.Hex())
+		}
+		rows = append(rows, storage.BlobTxRow{
+			TxHash:        tx.Hash().Hex(),
+			Block:         block.NumberU64(),
+			TxIndex:       uint(i),
+			BlobHashes:    hashes,
+			BlobGas:       tx.BlobGas(),
+			BlobGasFeeCap: tx.BlobGasFeeCap(),
+		})
+	}
+	return rows
+}
+
+// recordBlobUsage 更新 blob 利用率指标；Dencun 之前的区块（无 blobGasUsed 字段）不计
+func (p *Processor) recordBlobUsage(block *types.Block, rows []storage.BlobTxRow) {
+	if p.metrics == nil || block.BlobGasUsed() == nil {
+		return
+	}
+	blobs := 0
+	for _, row := range rows {
+		blobs += len(row.BlobHashes)
+	}
+	var excess uint64
+	if block.ExcessBlobGas() != nil {
+		excess = *block.ExcessBlobGas()
+	}
+	p.metrics.RecordBlobUsage(*block.BlobGasUsed(), excess, maxBlobGasPerBlock(p.chainID, block.Time()), len(rows), blobs)
+}
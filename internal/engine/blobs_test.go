@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildBlobTxRows(t *testing.T) {
+	to := testkit.Address("rollup", 1)
+	blobHashes := []common.Hash{{0x01, 0xaa}, {0x01, 0xbb}}
+	blobTx := types.NewTx(&types.BlobTx{
+		ChainID: uint256.NewInt(1), Nonce: 1, To: to, Gas: 21_000,
+		GasTipCap: uint256.NewInt(1), GasFeeCap: uint256.NewInt(2), BlobFeeCap: uint256.NewInt(7),
+		BlobHashes: blobHashes,
+	})
+	plain := types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(1), To: &to, Gas: 21_000, GasFeeCap: big.NewInt(2), GasTipCap: big.NewInt(1)})
+
+	h := testkit.Header(20, common.Hash{})
+	blobGas, excess := uint64(2*params.BlobTxBlobGasPerBlob), uint64(393216)
+	h.BlobGasUsed, h.ExcessBlobGas = &blobGas, &excess
+	block := types.NewBlockWithHeader(h).WithBody(types.Body{Transactions: []*types.Transaction{plain, blobTx}})
+
+	rows := buildBlobTxRows(block)
+	require.Len(t, rows, 1)
+	assert.Equal(t, blobTx.Hash().Hex(), rows[0].TxHash)
+	assert.Equal(t, uint64(20), rows[0].Block)
+	assert.Equal(t, uint(1), rows[0].TxIndex)
+	assert.Equal(t, []string{strings.ToLower(blobHashes[0].Hex()), strings.ToLower(blobHashes[1].Hex())}, rows[0].BlobHashes)
+	assert.Equal(t, blobGas, rows[0].BlobGas)
+	assert.Equal(t, int64(7), rows[0].BlobGasFeeCap.Int64())
+
+	mBlock := newBlockModel(block)
+	require.NotNil(t, mBlock.BlobGasUsed)
+	assert.Equal(t, blobGas, *mBlock.BlobGasUsed)
+	assert.Equal(t, excess, *mBlock.ExcessBlobGas)
+	assert.Equal(t, 2, mBlock.TransactionCount)
+
+	legacy := newBlockModel(testkit.Block(5, common.Hash{}))
+	assert.Nil(t, legacy.BlobGasUsed, "pre-Dencun headers carry no blob fields")
+	assert.Nil(t, buildBlobTxRows(testkit.Block(5, common.Hash{})))
+}
+
+func TestMaxBlobGasPerBlock(t *testing.T) {
+	const cancun, prague = 1710338135, 1746612311 // 主网激活时间
+	assert.Equal(t, uint64(6*params.BlobTxBlobGasPerBlob), maxBlobGasPerBlock(1, cancun))
+	assert.Equal(t, uint64(9*params.BlobTxBlobGasPerBlob), maxBlobGasPerBlock(1, prague))
+	assert.Zero(t, maxBlobGasPerBlock(1, cancun-1), "no blobs before Dencun")
+	assert.Equal(t, uint64(9*params.BlobTxBlobGasPerBlob), maxBlobGasPerBlock(31337, 0), "dev chains use the Prague default")
+}
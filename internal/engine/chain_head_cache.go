@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// defaultChainHeadTTL 链头缓存默认 TTL（远小于出块间隔，仪表盘轮询几乎总是命中）
+const defaultChainHeadTTL = 300 * time.Millisecond
+
+var (
+	chainHeadCache     *ChainHeadCache
+	chainHeadCacheOnce sync.Once
+
+	errChainHeadCacheUnbound = errors.New("chain head cache not configured")
+)
+
+// ChainHeadCache 链头高度的 TTL 缓存。状态接口、遥测脉冲与调度器共享同一份，
+// 避免每个客户端的每次轮询都消耗一次 RPC 额度。并发未命中在锁内合并为一次查询；错误不缓存。
+type ChainHeadCache struct {
+	mu        sync.Mutex
+	client    RPCClient
+	ttl       time.Duration
+	head      *big.Int
+	fetchedAt time.Time
+	now       func() time.Time
+}
+
+// GetChainHeadCache 返回链头缓存单例（启动流程通过 Configure 绑定 RPC 池）
+func GetChainHeadCache() *ChainHeadCache {
+	chainHeadCacheOnce.Do(func() {
+		chainHeadCache = NewChainHeadCache(nil, defaultChainHeadTTL)
+	})
+	return chainHeadCache
+}
+
+// NewChainHeadCache 创建独立的链头缓存（ttl <= 0 使用默认值）
+func NewChainHeadCache(client RPCClient, ttl time.Duration) *ChainHeadCache {
+	c := &ChainHeadCache{now: time.Now}
+	c.Configure(client, ttl)
+	return c
+}
+
+// Configure 绑定 RPC 客户端与 TTL，并丢弃已缓存的高度
+func (c *ChainHeadCache) Configure(client RPCClient, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultChainHeadTTL
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.client = client
+	c.ttl = ttl
+	c.head = nil
+}
+
+// Latest 返回链头高度：TTL 内直接返回缓存，否则查询 RPC 并刷新
+func (c *ChainHeadCache) Latest(ctx context.Context) (*big.Int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.client == nil {
+		return nil, errChainHeadCacheUnbound
+	}
+	if c.head != nil && c.now().Sub(c.fetchedAt) < c.ttl {
+		GetMetrics().RecordChainHeadLookup(true)
+		return new(big.Int).Set(c.head), nil
+	}
+
+	GetMetrics().RecordChainHeadLookup(false)
+	head, err := c.client.GetLatestBlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return nil, errors.New("rpc returned nil chain head")
+	}
+	c.head = new(big.Int).Set(head)
+	c.fetchedAt = c.now()
+	return new(big.Int).Set(head), nil
+}
+
+// Observe 记录外部推送的链头（WSS newHeads）；只前进不后退，TTL 内的查询直接命中
+func (c *ChainHeadCache) Observe(head *big.Int) {
+	if head == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client == nil || (c.head != nil && head.Cmp(c.head) < 0) {
+		return
+	}
+	c.head = new(big.Int).Set(head)
+	c.fetchedAt = c.now()
+}
+
+// Invalidate 丢弃缓存，下一次 Latest 必定查询 RPC
+func (c *ChainHeadCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.head = nil
+}
+
+// boundTo 缓存是否绑定在 client 上
+func (c *ChainHeadCache) boundTo(client RPCClient) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client != nil && c.client == client
+}
+
+// LatestChainHead 经共享缓存查询 client 的链头；缓存未配置或绑定的是其他客户端时直接查询 RPC
+func LatestChainHead(ctx context.Context, client RPCClient) (*big.Int, error) {
+	if c := GetChainHeadCache(); c.boundTo(client) {
+		return c.Latest(ctx)
+	}
+	return client.GetLatestBlockNumber(ctx)
+}
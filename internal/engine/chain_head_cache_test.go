@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// headCountingClient 统计 GetLatestBlockNumber 调用次数
+type headCountingClient struct {
+	lossyLogClient
+	calls atomic.Int32
+	head  atomic.Int64
+	err   error
+	delay time.Duration
+}
+
+func (c *headCountingClient) GetLatestBlockNumber(context.Context) (*big.Int, error) {
+	c.calls.Add(1)
+	time.Sleep(c.delay)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return big.NewInt(c.head.Load()), nil
+}
+
+func TestChainHeadCache_ServesWithinTTL(t *testing.T) {
+	client := &headCountingClient{}
+	client.head.Store(100)
+	cache := NewChainHeadCache(client, 500*time.Millisecond)
+	now := time.Unix(1_700_000_000, 0)
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 5; i++ {
+		head, err := cache.Latest(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), head.Int64())
+	}
+	assert.EqualValues(t, 1, client.calls.Load())
+
+	// 返回副本：调用方修改不影响缓存
+	head, _ := cache.Latest(context.Background())
+	head.SetInt64(0)
+
+	client.head.Store(101)
+	now = now.Add(499 * time.Millisecond)
+	head, _ = cache.Latest(context.Background())
+	assert.Equal(t, int64(100), head.Int64())
+
+	now = now.Add(time.Millisecond)
+	head, _ = cache.Latest(context.Background())
+	assert.Equal(t, int64(101), head.Int64())
+	assert.EqualValues(t, 2, client.calls.Load())
+
+	cache.Invalidate()
+	_, _ = cache.Latest(context.Background())
+	assert.EqualValues(t, 3, client.calls.Load())
+}
+
+func TestChainHeadCache_CoalescesConcurrentMissesAndSkipsErrors(t *testing.T) {
+	client := &headCountingClient{delay: 20 * time.Millisecond}
+	client.head.Store(7)
+	cache := NewChainHeadCache(client, time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			head, err := cache.Latest(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, int64(7), head.Int64())
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, client.calls.Load())
+
+	failing := &headCountingClient{err: errors.New("429 too many requests")}
+	cache = NewChainHeadCache(failing, time.Second)
+	_, err := cache.Latest(context.Background())
+	require.Error(t, err)
+	_, err = cache.Latest(context.Background())
+	require.Error(t, err)
+	assert.EqualValues(t, 2, failing.calls.Load(), "errors must not be cached")
+}
+
+func TestLatestChainHead_OnlyUsesCacheForBoundClient(t *testing.T) {
+	bound := &headCountingClient{}
+	bound.head.Store(10)
+	other := &headCountingClient{}
+	other.head.Store(20)
+
+	GetChainHeadCache().Configure(bound, time.Minute)
+	defer GetChainHeadCache().Configure(nil, 0)
+
+	for i := 0; i < 3; i++ {
+		head, err := LatestChainHead(context.Background(), bound)
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), head.Int64())
+		head, err = LatestChainHead(context.Background(), other)
+		require.NoError(t, err)
+		assert.Equal(t, int64(20), head.Int64())
+	}
+	assert.EqualValues(t, 1, bound.calls.Load())
+	assert.EqualValues(t, 3, other.calls.Load())
+}
@@ -0,0 +1,257 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ChainProfile 链级别的静态特征（出块时间、原生币、最终性深度、浏览器链接、销毁地址约定）
+// 调度器、延迟估算与 API 响应统一从这里读取，避免到处硬编码 "12 秒一块"
+type ChainProfile struct {
+	ChainID       int64
+	Name          string
+	NativeSymbol  string
+	BlockTime     time.Duration
+	FinalityDepth uint64
+	// OnDemandMining 按需出块（无交易时链头不动，如本地 Anvil），链头长时间不变不视为上游停滞
+	OnDemandMining bool
+	// ExplorerURL 浏览器根地址，空表示无公开浏览器（如本地 Anvil）
+	ExplorerURL   string
+	BurnAddresses []common.Address
+	// Stablecoins 该链上标记的稳定币（资金流统计只关注这些代币）
+	Stablecoins []Stablecoin
+	// WrappedNative 原生币包装合约（WETH9 及其克隆），其 Deposit / Withdrawal 按 WRAP / UNWRAP 入库；
+	// 按需出块的本地链未配置时接受任意合约的同布局事件（本地部署地址不固定）
+	WrappedNative []common.Address
+}
+
+// Stablecoin 稳定币标记；Decimals 用于把原始数量换算为美元
+type Stablecoin struct {
+	Symbol   string
+	Address  common.Address
+	Decimals uint8
+}
+
+// ChainInfo API 响应中的链信息投影
+type ChainInfo struct {
+	ChainID          int64   `json:"chain_id"`
+	Name             string  `json:"name"`
+	NativeSymbol     string  `json:"native_symbol"`
+	BlockTimeSeconds float64 `json:"block_time_seconds"`
+	FinalityDepth    uint64  `json:"finality_depth"`
+	ExplorerURL      string  `json:"explorer_url,omitempty"`
+}
+
+// 通用销毁地址约定：零地址与 0x...dEaD
+var defaultBurnAddresses = []common.Address{
+	common.HexToAddress("0x0000000000000000000000000000000000000000"),
+	common.HexToAddress("0x000000000000000000000000000000000000dEaD"),
+}
+
+var (
+	chainProfilesMu sync.RWMutex
+	chainProfiles   = map[int64]ChainProfile{
+		1: {
+			ChainID: 1, Name: "Ethereum Mainnet", NativeSymbol: "ETH",
+			BlockTime: 12 * time.Second, FinalityDepth: 64,
+			ExplorerURL: "https://etherscan.io", BurnAddresses: defaultBurnAddresses,
+			WrappedNative: []common.Address{common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")},
+			Stablecoins: []Stablecoin{
+				{Symbol: "USDC", Address: common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"), Decimals: 6},
+				{Symbol: "USDT", Address: common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"), Decimals: 6},
+				{Symbol: "DAI", Address: common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F"), Decimals: 18},
+			},
+		},
+		11155111: {
+			ChainID: 11155111, Name: "Sepolia", NativeSymbol: "ETH",
+			BlockTime: 12 * time.Second, FinalityDepth: 6,
+			ExplorerURL: "https://sepolia.etherscan.io", BurnAddresses: defaultBurnAddresses,
+			WrappedNative: []common.Address{
+				common.HexToAddress("0x7b79995e5f793A07Bc00c21412e50Ecae098E7f9"),
+				common.HexToAddress("0xfFf9976782d46CC05630D1f6eBAb18b2324d6B14"),
+			},
+			Stablecoins: []Stablecoin{
+				{Symbol: "USDC", Address: common.HexToAddress("0x1c7D4B196Cb0C7B01d743Fbc6116a902379C7238"), Decimals: 6},
+			},
+		},
+		17000: {
+			ChainID: 17000, Name: "Holesky", NativeSymbol: "ETH",
+			BlockTime: 12 * time.Second, FinalityDepth: 6,
+			ExplorerURL: "https://holesky.etherscan.io", BurnAddresses: defaultBurnAddresses,
+		},
+		10: {
+			ChainID: 10, Name: "OP Mainnet", NativeSymbol: "ETH",
+			BlockTime: 2 * time.Second, FinalityDepth: 30,
+			ExplorerURL: "https://optimistic.etherscan.io", BurnAddresses: defaultBurnAddresses,
+			WrappedNative: []common.Address{common.HexToAddress("0x4200000000000000000000000000000000000006")},
+			Stablecoins: []Stablecoin{
+				{Symbol: "USDC", Address: common.HexToAddress("0x0b2C639c533813f4Aa9D7837CAf62653d097Ff85"), Decimals: 6},
+				{Symbol: "USDT", Address: common.HexToAddress("0x94b008aA00579c1307B0EF2c499aD98a8ce58e58"), Decimals: 6},
+				{Symbol: "DAI", Address: common.HexToAddress("0xDA10009cBd5D07dd0CeCc66161FC93D7c9000da1"), Decimals: 18},
+			},
+		},
+		8453: {
+			ChainID: 8453, Name: "Base", NativeSymbol: "ETH",
+			BlockTime: 2 * time.Second, FinalityDepth: 30,
+			ExplorerURL: "https://basescan.org", BurnAddresses: defaultBurnAddresses,
+			WrappedNative: []common.Address{common.HexToAddress("0x4200000000000000000000000000000000000006")},
+			Stablecoins: []Stablecoin{
+				{Symbol: "USDC", Address: common.HexToAddress("0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"), Decimals: 6},
+				{Symbol: "DAI", Address: common.HexToAddress("0x50c5725949A6F0c72E6C4a641F24049A917DB0Cb"), Decimals: 18},
+			},
+		},
+		42161: {
+			ChainID: 42161, Name: "Arbitrum One", NativeSymbol: "ETH",
+			BlockTime: 250 * time.Millisecond, FinalityDepth: 240,
+			ExplorerURL: "https://arbiscan.io", BurnAddresses: defaultBurnAddresses,
+			WrappedNative: []common.Address{common.HexToAddress("0x82aF49447D8a07e3bd95BD0d56f35241523fBab1")},
+			Stablecoins: []Stablecoin{
+				{Symbol: "USDC", Address: common.HexToAddress("0xaf88d065e77c8cC2239327C5EDb3A432268e5831"), Decimals: 6},
+				{Symbol: "USDT", Address: common.HexToAddress("0xFd086bC7CD5C481DCC9C85ebE478A1C0b69FCbb9"), Decimals: 6},
+				{Symbol: "DAI", Address: common.HexToAddress("0xDA10009cBd5D07dd0CeCc66161FC93D7c9000da1"), Decimals: 18},
+			},
+		},
+		137: {
+			ChainID: 137, Name: "Polygon PoS", NativeSymbol: "POL",
+			BlockTime: 2 * time.Second, FinalityDepth: 128,
+			ExplorerURL: "https://polygonscan.com", BurnAddresses: defaultBurnAddresses,
+			WrappedNative: []common.Address{common.HexToAddress("0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270")},
+			Stablecoins: []Stablecoin{
+				{Symbol: "USDC", Address: common.HexToAddress("0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359"), Decimals: 6},
+				{Symbol: "USDT", Address: common.HexToAddress("0xc2132D05D31c914a87C6611C10748AEb04B58e8F"), Decimals: 6},
+				{Symbol: "DAI", Address: common.HexToAddress("0x8f3Cf7ad23Cd3CaDbD9735AFf958023239c6A063"), Decimals: 18},
+			},
+		},
+		31337: {
+			ChainID: 31337, Name: "Anvil", NativeSymbol: "ETH",
+			BlockTime: 1 * time.Second, FinalityDepth: 0, OnDemandMining: true,
+			BurnAddresses: defaultBurnAddresses,
+		},
+	}
+)
+
+// RegisterChainProfile 注册或覆盖链配置（用于私有链 / 测试）
+func RegisterChainProfile(p ChainProfile) {
+	chainProfilesMu.Lock()
+	defer chainProfilesMu.Unlock()
+	chainProfiles[p.ChainID] = p
+}
+
+// ChainProfileByName 按名称（大小写不敏感，如 "sepolia"、"base"）查找已注册的链配置
+func ChainProfileByName(name string) (ChainProfile, bool) {
+	chainProfilesMu.RLock()
+	defer chainProfilesMu.RUnlock()
+	for _, p := range chainProfiles {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return ChainProfile{}, false
+}
+
+// GetChainProfile 按 chain id 获取链配置；未知链回退到以太坊主网风格的默认值
+func GetChainProfile(chainID int64) ChainProfile {
+	chainProfilesMu.RLock()
+	p, ok := chainProfiles[chainID]
+	chainProfilesMu.RUnlock()
+	if ok {
+		return p
+	}
+	return ChainProfile{
+		ChainID:       chainID,
+		Name:          fmt.Sprintf("Chain %d", chainID),
+		NativeSymbol:  "ETH",
+		BlockTime:     12 * time.Second,
+		FinalityDepth: 12,
+		BurnAddresses: defaultBurnAddresses,
+	}
+}
+
+// EstimateLatency 按出块时间估算落后 lag 个块对应的时间
+func (p ChainProfile) EstimateLatency(lag int64) time.Duration {
+	if lag <= 0 {
+		return 0
+	}
+	return time.Duration(lag) * p.BlockTime
+}
+
+// TailPollInterval 追块轮询间隔：出块时间的 1/10，限制在 [100ms, 500ms]
+func (p ChainProfile) TailPollInterval() time.Duration {
+	interval := p.BlockTime / 10
+	if interval < 100*time.Millisecond {
+		return 100 * time.Millisecond
+	}
+	if interval > 500*time.Millisecond {
+		return 500 * time.Millisecond
+	}
+	return interval
+}
+
+// TxURL 交易浏览器链接；无浏览器时返回空
+func (p ChainProfile) TxURL(txHash string) string {
+	if p.ExplorerURL == "" {
+		return ""
+	}
+	return p.ExplorerURL + "/tx/" + txHash
+}
+
+// AddressURL 地址浏览器链接；无浏览器时返回空
+func (p ChainProfile) AddressURL(addr string) string {
+	if p.ExplorerURL == "" {
+		return ""
+	}
+	return p.ExplorerURL + "/address/" + addr
+}
+
+// IsBurnAddress 是否为该链约定的销毁地址
+func (p ChainProfile) IsBurnAddress(addr string) bool {
+	if !common.IsHexAddress(addr) {
+		return false
+	}
+	target := common.HexToAddress(strings.TrimSpace(addr))
+	for _, burn := range p.BurnAddresses {
+		if burn == target {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWrappedNative 是否为该链的原生币包装合约；按需出块的本地链未配置时接受任意合约
+func (p ChainProfile) IsWrappedNative(addr common.Address) bool {
+	if len(p.WrappedNative) == 0 {
+		return p.OnDemandMining
+	}
+	return slices.Contains(p.WrappedNative, addr)
+}
+
+// StablecoinByAddress 按代币地址查找该链标记的稳定币
+func (p ChainProfile) StablecoinByAddress(addr string) (Stablecoin, bool) {
+	if !common.IsHexAddress(strings.TrimSpace(addr)) {
+		return Stablecoin{}, false
+	}
+	target := common.HexToAddress(strings.TrimSpace(addr))
+	for _, coin := range p.Stablecoins {
+		if coin.Address == target {
+			return coin, true
+		}
+	}
+	return Stablecoin{}, false
+}
+
+// Info 投影为 API 响应结构
+func (p ChainProfile) Info() ChainInfo {
+	return ChainInfo{
+		ChainID:          p.ChainID,
+		Name:             p.Name,
+		NativeSymbol:     p.NativeSymbol,
+		BlockTimeSeconds: p.BlockTime.Seconds(),
+		FinalityDepth:    p.FinalityDepth,
+		ExplorerURL:      p.ExplorerURL,
+	}
+}
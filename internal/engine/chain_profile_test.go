@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetChainProfileKnownAndFallback(t *testing.T) {
+	sepolia := GetChainProfile(11155111)
+	assert.Equal(t, "Sepolia", sepolia.Name)
+	assert.Equal(t, uint64(6), sepolia.FinalityDepth)
+	assert.Equal(t, "https://sepolia.etherscan.io/tx/0xabc", sepolia.TxURL("0xabc"))
+
+	unknown := GetChainProfile(999999)
+	assert.Equal(t, int64(999999), unknown.ChainID)
+	assert.Equal(t, 12*time.Second, unknown.BlockTime)
+	assert.Empty(t, unknown.TxURL("0xabc"))
+}
+
+func TestChainProfileHeuristics(t *testing.T) {
+	mainnet := GetChainProfile(1)
+	assert.Equal(t, 120*time.Second, mainnet.EstimateLatency(10))
+	assert.Equal(t, time.Duration(0), mainnet.EstimateLatency(-5))
+	assert.Equal(t, 500*time.Millisecond, mainnet.TailPollInterval())
+	assert.Equal(t, 100*time.Millisecond, GetChainProfile(31337).TailPollInterval())
+
+	assert.True(t, mainnet.IsBurnAddress("0x000000000000000000000000000000000000dead"))
+	assert.True(t, mainnet.IsBurnAddress("0x0000000000000000000000000000000000000000"))
+	assert.False(t, mainnet.IsBurnAddress("0x1c7d4b196cb0c7b01d743fbc6116a902379c7238"))
+	assert.False(t, mainnet.IsBurnAddress("Multiple"))
+}
+
+func TestStrategyConfirmationsFromProfile(t *testing.T) {
+	assert.Equal(t, uint64(0), GetStrategy(31337).GetConfirmations())
+	assert.Equal(t, uint64(6), GetStrategy(11155111).GetConfirmations())
+	assert.Equal(t, uint64(64), GetStrategy(1).GetConfirmations())
+}
@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// ChainReset* 重置原因
+	ChainResetGenesisChanged = "genesis_changed" // 创世块哈希变化：节点以新链重启
+	ChainResetHeadRegressed  = "head_regressed"  // 链头低于已同步游标，且该高度的哈希与已落盘的不同
+
+	// defaultGenesisCheckInterval 链头正常前进时复查创世哈希的间隔（Anvil 重启后可能很快越过旧游标）
+	defaultGenesisCheckInterval = 30 * time.Second
+)
+
+// ChainResetEvent 检测到的链重置（日志、指标与 WS "chain_reset" 事件）
+type ChainResetEvent struct {
+	Reason          string    `json:"reason"`
+	PreviousGenesis string    `json:"previous_genesis"`
+	Genesis         string    `json:"genesis"`
+	ChainHead       uint64    `json:"chain_head"`
+	SyncedCursor    uint64    `json:"synced_cursor"`
+	DetectedAt      time.Time `json:"detected_at"`
+}
+
+type headerSource interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// ChainResetStore 读取已落盘区块哈希（*storage.Postgres 实现）
+type ChainResetStore interface {
+	GetBlockHash(ctx context.Context, number string) (string, error)
+}
+
+// ChainResetDetector 识别本地开发链（Anvil / Hardhat）重启：重启后链从创世重新出块，
+// 链头低于 SyncedCursor，不处理的话“时空穿越”告警会一直存在。
+// 判定依据：创世哈希变化；或链头低于游标且该高度的链上哈希与已落盘的不同（固定 --timestamp 时创世哈希可能不变）。
+// 单纯的链头回退（节点落后、负载均衡切到滞后节点）哈希一致，不会触发重置
+type ChainResetDetector struct {
+	mu          sync.Mutex
+	client      headerSource
+	store       ChainResetStore
+	genesis     common.Hash
+	checkedAt   time.Time
+	interval    time.Duration
+	now         func() time.Time
+	initialized bool
+}
+
+// NewChainResetDetector 创建检测器；store 为 nil 时只比较创世哈希
+func NewChainResetDetector(client headerSource, store ChainResetStore) *ChainResetDetector {
+	return &ChainResetDetector{client: client, store: store, interval: defaultGenesisCheckInterval, now: time.Now}
+}
+
+// Check 对一次观测到的链头做检测，返回非 nil 表示链已重置（基线随即更新为新链的创世哈希）。
+// 链头正常前进时每 interval 复查一次创世哈希，其余情况不发起 RPC
+func (d *ChainResetDetector) Check(ctx context.Context, head, cursor uint64) (*ChainResetEvent, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.initialized {
+		if err := d.initBaseline(ctx); err != nil {
+			return nil, err
+		}
+	}
+	regressed := head < cursor
+	if !regressed && d.now().Sub(d.checkedAt) < d.interval {
+		return nil, nil
+	}
+
+	genesis, err := d.headerHash(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	d.checkedAt = d.now()
+	ev := &ChainResetEvent{
+		PreviousGenesis: d.genesis.Hex(),
+		Genesis:         genesis.Hex(),
+		ChainHead:       head,
+		SyncedCursor:    cursor,
+		DetectedAt:      d.checkedAt,
+	}
+	if genesis != d.genesis {
+		ev.Reason = ChainResetGenesisChanged
+		d.genesis = genesis
+		return ev, nil
+	}
+	if !regressed || d.store == nil {
+		return nil, nil
+	}
+
+	local, err := d.store.GetBlockHash(ctx, strconv.FormatUint(head, 10))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil // 该高度未索引（从中途开始同步），无法比对
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load local hash at %d: %w", head, err)
+	}
+	remote, err := d.headerHash(ctx, head)
+	if err != nil {
+		return nil, err
+	}
+	if common.HexToHash(local) == remote {
+		return nil, nil
+	}
+	ev.Reason = ChainResetHeadRegressed
+	return ev, nil
+}
+
+// initBaseline 基线优先取已落盘的创世块哈希：进程停机期间节点重启也能在首次检测时发现
+func (d *ChainResetDetector) initBaseline(ctx context.Context) error {
+	if d.store != nil {
+		if local, err := d.store.GetBlockHash(ctx, "0"); err == nil {
+			d.genesis = common.HexToHash(local)
+			d.initialized = true
+			return nil
+		}
+	}
+	genesis, err := d.headerHash(ctx, 0)
+	if err != nil {
+		return err
+	}
+	d.genesis = genesis
+	d.checkedAt = d.now()
+	d.initialized = true
+	return nil
+}
+
+func (d *ChainResetDetector) headerHash(ctx context.Context, n uint64) (common.Hash, error) {
+	header, err := d.client.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("fetch header %d: %w", n, err)
+	}
+	if header == nil {
+		return common.Hash{}, fmt.Errorf("header %d not found", n)
+	}
+	return header.Hash(), nil
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHeaderChain struct {
+	blocks []*types.Block
+	calls  int
+}
+
+func (c *fakeHeaderChain) HeaderByNumber(_ context.Context, n *big.Int) (*types.Header, error) {
+	c.calls++
+	if n.Uint64() >= uint64(len(c.blocks)) {
+		return nil, nil
+	}
+	return c.blocks[n.Uint64()].Header(), nil
+}
+
+type fakeHashStore map[uint64]string
+
+func (s fakeHashStore) GetBlockHash(_ context.Context, number string) (string, error) {
+	n, _ := strconv.ParseUint(number, 10, 64)
+	if h, ok := s[n]; ok {
+		return h, nil
+	}
+	return "", sql.ErrNoRows
+}
+
+func storedHashes(blocks []*types.Block) fakeHashStore {
+	s := fakeHashStore{}
+	for _, b := range blocks {
+		s[b.NumberU64()] = b.Hash().Hex()
+	}
+	return s
+}
+
+func TestChainResetDetector_GenesisChange(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+	chain := &fakeHeaderChain{blocks: testkit.Chain(0, 20)}
+	d := NewChainResetDetector(chain, nil)
+	d.now = func() time.Time { return now }
+
+	ev, err := d.Check(ctx, 19, 19)
+	require.NoError(t, err)
+	assert.Nil(t, ev, "the first check only records the baseline")
+
+	calls := chain.calls
+	ev, err = d.Check(ctx, 19, 19)
+	require.NoError(t, err)
+	assert.Nil(t, ev)
+	assert.Equal(t, calls, chain.calls, "no RPC while the head advances normally within the interval")
+
+	// 节点以新链重启（不同的创世块），且在复查前已越过旧游标
+	_, genesis := testkit.ReorgPair(0, common.Hash{})
+	chain.blocks = append([]*types.Block{genesis}, testkit.Fork(genesis, 25)...)
+	now = now.Add(time.Minute)
+	ev, err = d.Check(ctx, 25, 19)
+	require.NoError(t, err)
+	require.NotNil(t, ev)
+	assert.Equal(t, ChainResetGenesisChanged, ev.Reason)
+	assert.Equal(t, chain.blocks[0].Hash().Hex(), ev.Genesis)
+
+	ev, err = d.Check(ctx, 25, 0)
+	require.NoError(t, err)
+	assert.Nil(t, ev, "the new genesis becomes the baseline")
+}
+
+func TestChainResetDetector_HeadRegressed(t *testing.T) {
+	ctx := context.Background()
+	old := testkit.Chain(0, 50)
+	store := storedHashes(old)
+
+	// 同一条链只是节点落后：哈希一致，不重置
+	lagging := &fakeHeaderChain{blocks: old[:30]}
+	ev, err := NewChainResetDetector(lagging, store).Check(ctx, 29, 49)
+	require.NoError(t, err)
+	assert.Nil(t, ev)
+
+	// 固定时间戳重启：创世相同，但 10 号块之后是另一条链
+	restarted := &fakeHeaderChain{blocks: append(append([]*types.Block{}, old[:10]...), testkit.Fork(old[9], 5)...)}
+	ev, err = NewChainResetDetector(restarted, store).Check(ctx, 14, 49)
+	require.NoError(t, err)
+	require.NotNil(t, ev)
+	assert.Equal(t, ChainResetHeadRegressed, ev.Reason)
+	assert.Equal(t, uint64(49), ev.SyncedCursor)
+
+	// 链头高度未落盘时无法比对
+	delete(store, 14)
+	ev, err = NewChainResetDetector(restarted, store).Check(ctx, 14, 49)
+	require.NoError(t, err)
+	assert.Nil(t, ev)
+}
+
+func TestChainResetDetector_BaselineFromStore(t *testing.T) {
+	stored := storedHashes(testkit.Chain(0, 5))
+	stored[0] = common.HexToHash("0x01").Hex() // 停机期间节点已重启
+	chain := &fakeHeaderChain{blocks: testkit.Chain(0, 5)}
+
+	ev, err := NewChainResetDetector(chain, stored).Check(context.Background(), 4, 4)
+	require.NoError(t, err)
+	require.NotNil(t, ev)
+	assert.Equal(t, ChainResetGenesisChanged, ev.Reason)
+}
@@ -0,0 +1,261 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"web3-indexer-go/internal/storage"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 启动对账模式
+const (
+	// CheckpointAuditReport 只读：按策略计算起点并上报，不改写 sync_checkpoints
+	CheckpointAuditReport = "report"
+	// CheckpointAuditEnforce 在 report 基础上把对账结果写回 sync_checkpoints
+	CheckpointAuditEnforce = "enforce"
+)
+
+// 对账结论（即 indexer_checkpoint_audits_total 的 verdict 标签）
+const (
+	AuditVerdictConsistent        = "consistent"         // checkpoint == MAX(blocks)
+	AuditVerdictCheckpointBehind  = "checkpoint_behind"  // checkpoint < MAX(blocks)
+	AuditVerdictCheckpointAhead   = "checkpoint_ahead"   // checkpoint > MAX(blocks)
+	AuditVerdictCheckpointMissing = "checkpoint_missing" // 有区块但无 checkpoint
+	AuditVerdictEmpty             = "empty"              // 两者皆无：全新数据库
+)
+
+// CheckpointAudit 启动时对 sync_checkpoints、MAX(blocks.number) 与协调器游标的对账结果。
+//
+// 对账策略（exactly-once 的前提是 checkpoint 与区块数据在同一事务中提交）：
+//   - consistent：三者一致，直接使用 checkpoint。
+//   - checkpoint_behind：blocks 中存在高于 checkpoint 的行（父块锚点、对齐写入或中断的批次）。
+//     从 checkpoint 区块起逐块校验高度连续且 parent_hash 衔接，连续的前缀直接采用（InFlightAdopted），
+//     断点之后的部分重新抓取；写入均为 ON CONFLICT 幂等，不会重复计数。
+//   - checkpoint_ahead：checkpoint 声称已同步的区块在 blocks 中不存在（自愈跳洞、回滚删除了区块等）。
+//     回退到 MAX(blocks.number)，缺失区间重新抓取，保证 checkpoint 以下的数据完整。
+//   - checkpoint_missing：旧版本数据没有 checkpoint，采用 MAX(blocks.number)。
+//   - empty：全新数据库，由调用方决定起始高度（链默认值或配置）。
+//
+// 协调器游标（热启动时可能已由 RestoreState 恢复）始终对齐到 Resolved。
+type CheckpointAudit struct {
+	ChainID            int64     `json:"chain_id"`
+	Checkpoint         int64     `json:"checkpoint"` // -1 表示不存在
+	MaxBlock           int64     `json:"max_block"`  // -1 表示 blocks 为空
+	OrchestratorCursor uint64    `json:"orchestrator_cursor"`
+	Resolved           int64     `json:"resolved"` // 对账后的最后已同步区块，-1 表示从头开始
+	Verdict            string    `json:"verdict"`
+	Mode               string    `json:"mode"`
+	Repaired           bool      `json:"repaired"`           // enforce 模式下是否改写了 checkpoint
+	InFlightAdopted    int64     `json:"inflight_adopted"`   // checkpoint 之上经哈希连续性校验后采用的区块数
+	InFlightRefetched  int64     `json:"inflight_refetched"` // checkpoint 之上未通过校验、需重新抓取的区块数
+	AuditedAt          time.Time `json:"audited_at"`
+}
+
+// Drift checkpoint 与 MAX(blocks.number) 的差值（任一缺失时为 0）
+func (a CheckpointAudit) Drift() int64 {
+	if a.Checkpoint < 0 || a.MaxBlock < 0 {
+		return 0
+	}
+	return a.Checkpoint - a.MaxBlock
+}
+
+// HasState 数据库中是否存在可恢复的同步进度
+func (a CheckpointAudit) HasState() bool {
+	return a.Resolved >= 0
+}
+
+// reconcileCheckpoint 纯策略函数：给定 checkpoint 与 MAX(blocks)（-1 表示缺失），返回对账起点与结论
+func reconcileCheckpoint(checkpoint, maxBlock int64) (int64, string) {
+	switch {
+	case checkpoint < 0 && maxBlock < 0:
+		return -1, AuditVerdictEmpty
+	case checkpoint < 0:
+		return maxBlock, AuditVerdictCheckpointMissing
+	case maxBlock < 0:
+		// checkpoint 存在但 blocks 为空：数据被清空，从头开始
+		return -1, AuditVerdictCheckpointAhead
+	case checkpoint == maxBlock:
+		return checkpoint, AuditVerdictConsistent
+	case checkpoint < maxBlock:
+		return checkpoint, AuditVerdictCheckpointBehind
+	default:
+		return maxBlock, AuditVerdictCheckpointAhead
+	}
+}
+
+// SetCheckpointAuditMode 设置启动对账模式（report / enforce），未设置时按 report 处理
+func (o *Orchestrator) SetCheckpointAuditMode(mode string) {
+	if mode != CheckpointAuditEnforce {
+		mode = CheckpointAuditReport
+	}
+	o.mu.Lock()
+	o.auditMode = mode
+	o.mu.Unlock()
+}
+
+// CheckpointAudit 返回最近一次对账结果（尚未对账时为 nil）
+func (o *Orchestrator) CheckpointAudit() *CheckpointAudit {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if o.lastAudit == nil {
+		return nil
+	}
+	a := *o.lastAudit
+	return &a
+}
+
+// AuditCheckpoints 读取三方游标，按策略对账并记录指标；
+// enforce 模式下将结果写回 sync_checkpoints，并把协调器游标对齐到对账结果
+func (o *Orchestrator) AuditCheckpoints(ctx context.Context, db *sqlx.DB, chainID int64) (CheckpointAudit, error) {
+	o.mu.RLock()
+	mode := o.auditMode
+	cursor := o.state.SyncedCursor
+	o.mu.RUnlock()
+	if mode == "" {
+		mode = CheckpointAuditReport
+	}
+
+	audit := CheckpointAudit{
+		ChainID:            chainID,
+		Checkpoint:         -1,
+		MaxBlock:           -1,
+		OrchestratorCursor: cursor,
+		Mode:               mode,
+		AuditedAt:          time.Now(),
+	}
+
+	var checkpoint string
+	err := db.GetContext(ctx, &checkpoint, "SELECT last_synced_block::text FROM sync_checkpoints WHERE chain_id = $1", chainID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return audit, fmt.Errorf("read checkpoint: %w", err)
+	default:
+		n, perr := strconv.ParseInt(checkpoint, 10, 64)
+		if perr != nil {
+			return audit, fmt.Errorf("invalid checkpoint block number %q: %w", checkpoint, perr)
+		}
+		audit.Checkpoint = n
+	}
+
+	var maxBlock sql.NullInt64
+	if err := db.GetContext(ctx, &maxBlock, "SELECT MAX(number) FROM blocks"); err != nil {
+		return audit, fmt.Errorf("read max block: %w", err)
+	}
+	if maxBlock.Valid {
+		audit.MaxBlock = maxBlock.Int64
+	}
+
+	audit.Resolved, audit.Verdict = reconcileCheckpoint(audit.Checkpoint, audit.MaxBlock)
+	if audit.Verdict == AuditVerdictCheckpointBehind {
+		tip, err := adoptInFlightBlocks(ctx, db, audit.Checkpoint, audit.MaxBlock)
+		if err != nil {
+			Logger.Warn("🧾 [CheckpointAudit] In-flight block verification failed, refetching", "chain_id", chainID, "err", err)
+			tip = audit.Checkpoint
+		}
+		audit.Resolved = tip
+		audit.InFlightAdopted = tip - audit.Checkpoint
+		audit.InFlightRefetched = audit.MaxBlock - tip
+		GetMetrics().RecordCheckpointInFlight(audit.InFlightAdopted, audit.InFlightRefetched)
+	}
+
+	if mode == CheckpointAuditEnforce && audit.Verdict != AuditVerdictConsistent && audit.Verdict != AuditVerdictEmpty {
+		if err := repairCheckpoint(ctx, db, chainID, audit.Resolved); err != nil {
+			Logger.Warn("🧾 [CheckpointAudit] Repair failed", "chain_id", chainID, "err", err)
+		} else {
+			audit.Repaired = true
+		}
+	}
+
+	o.mu.Lock()
+	if audit.Resolved >= 0 {
+		o.state.SyncedCursor = uint64(audit.Resolved) // #nosec G115 - Resolved >= 0
+	} else {
+		o.state.SyncedCursor = 0
+	}
+	o.snapshot = o.state
+	o.lastAudit = &audit
+	o.mu.Unlock()
+
+	GetMetrics().RecordCheckpointAudit(audit.Verdict, audit.Drift())
+
+	level := slog.LevelInfo
+	if audit.Verdict != AuditVerdictConsistent && audit.Verdict != AuditVerdictEmpty {
+		level = slog.LevelWarn
+	}
+	Logger.Log(ctx, level, "🧾 [CheckpointAudit] Startup cursors reconciled",
+		slog.Int64("chain_id", chainID),
+		slog.String("verdict", audit.Verdict),
+		slog.String("mode", mode),
+		slog.Int64("checkpoint", audit.Checkpoint),
+		slog.Int64("max_block", audit.MaxBlock),
+		slog.Uint64("orchestrator_cursor", cursor),
+		slog.Int64("resolved", audit.Resolved),
+		slog.Int64("inflight_adopted", audit.InFlightAdopted),
+		slog.Int64("inflight_refetched", audit.InFlightRefetched),
+		slog.Bool("repaired", audit.Repaired))
+	return audit, nil
+}
+
+// repairCheckpoint 将 checkpoint 改写为对账结果；resolved < 0 时删除该链的 checkpoint
+func repairCheckpoint(ctx context.Context, db *sqlx.DB, chainID, resolved int64) error {
+	if resolved < 0 {
+		return storage.DeleteCheckpointTx(ctx, db, chainID)
+	}
+	return storage.UpsertCheckpointTx(ctx, db, chainID, resolved)
+}
+
+// maxInFlightAdopt 启动时最多校验采用的 checkpoint 之上区块数（更高的部分照常重新抓取）
+const maxInFlightAdopt = 50000
+
+// inFlightBlock checkpoint 之上已落库区块的哈希链字段
+type inFlightBlock struct {
+	Number     int64  `db:"number"`
+	Hash       string `db:"hash"`
+	ParentHash string `db:"parent_hash"`
+}
+
+// adoptInFlightBlocks 读取 [checkpoint, maxBlock] 的区块，返回从 checkpoint 起哈希连续的最高区块。
+// checkpoint 区块本身缺失时无法建立衔接，返回 checkpoint（全部重抓）
+func adoptInFlightBlocks(ctx context.Context, db *sqlx.DB, checkpoint, maxBlock int64) (int64, error) {
+	var rows []inFlightBlock
+	err := db.SelectContext(ctx, &rows, `
+		SELECT number::BIGINT AS number, hash, parent_hash FROM blocks
+		WHERE number >= $1 AND number <= $2 ORDER BY number LIMIT $3`,
+		checkpoint, min(maxBlock, checkpoint+maxInFlightAdopt), maxInFlightAdopt+1)
+	if err != nil {
+		return checkpoint, fmt.Errorf("read in-flight blocks: %w", err)
+	}
+	tip, brokenAt := contiguousTip(rows, checkpoint)
+	if brokenAt >= 0 {
+		Logger.Warn("🧾 [CheckpointAudit] Hash continuity broken above checkpoint",
+			slog.Int64("checkpoint", checkpoint),
+			slog.Int64("adopted_to", tip),
+			slog.Int64("broken_at", brokenAt))
+	}
+	return tip, nil
+}
+
+// contiguousTip 纯函数：rows 按高度升序，首行应为 checkpoint 区块。
+// 返回高度连续且 parent_hash 衔接的最高区块，以及第一个断点高度（全部连续时为 -1）
+func contiguousTip(rows []inFlightBlock, checkpoint int64) (tip int64, brokenAt int64) {
+	if len(rows) == 0 || rows[0].Number != checkpoint {
+		return checkpoint, checkpoint
+	}
+	prev := rows[0]
+	for _, row := range rows[1:] {
+		if row.Number != prev.Number+1 || !strings.EqualFold(row.ParentHash, prev.Hash) {
+			return prev.Number, prev.Number + 1
+		}
+		prev = row
+	}
+	return prev.Number, -1
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcileCheckpoint(t *testing.T) {
+	cases := []struct {
+		name       string
+		checkpoint int64
+		maxBlock   int64
+		resolved   int64
+		verdict    string
+	}{
+		{"fresh database", -1, -1, -1, AuditVerdictEmpty},
+		{"consistent", 100, 100, 100, AuditVerdictConsistent},
+		{"blocks above checkpoint await in-flight verification", 100, 105, 100, AuditVerdictCheckpointBehind},
+		{"checkpoint rewinds to stored blocks", 120, 100, 100, AuditVerdictCheckpointAhead},
+		{"blocks wiped under checkpoint", 120, -1, -1, AuditVerdictCheckpointAhead},
+		{"legacy data without checkpoint", -1, 42, 42, AuditVerdictCheckpointMissing},
+		{"genesis only", 0, 0, 0, AuditVerdictConsistent},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolved, verdict := reconcileCheckpoint(tc.checkpoint, tc.maxBlock)
+			assert.Equal(t, tc.resolved, resolved)
+			assert.Equal(t, tc.verdict, verdict)
+		})
+	}
+}
+
+func TestCheckpointAuditDrift(t *testing.T) {
+	assert.Equal(t, int64(20), CheckpointAudit{Checkpoint: 120, MaxBlock: 100}.Drift())
+	assert.Equal(t, int64(-5), CheckpointAudit{Checkpoint: 100, MaxBlock: 105}.Drift())
+	assert.Zero(t, CheckpointAudit{Checkpoint: -1, MaxBlock: 105}.Drift())
+
+	assert.False(t, CheckpointAudit{Resolved: -1}.HasState())
+	assert.True(t, CheckpointAudit{Resolved: 0}.HasState())
+}
+
+func inFlightRows(blocks []*types.Block) []inFlightBlock {
+	rows := make([]inFlightBlock, 0, len(blocks))
+	for _, b := range blocks {
+		rows = append(rows, inFlightBlock{
+			Number:     b.Number().Int64(),
+			Hash:       b.Hash().Hex(),
+			ParentHash: b.ParentHash().Hex(),
+		})
+	}
+	return rows
+}
+
+func TestContiguousTip(t *testing.T) {
+	blocks := testkit.Chain(100, 6) // 100..105
+	chain := inFlightRows(blocks)
+
+	tip, broken := contiguousTip(chain, 100)
+	assert.Equal(t, int64(105), tip, "hash-continuous blocks above the checkpoint are adopted")
+	assert.Equal(t, int64(-1), broken)
+
+	upper := append([]inFlightBlock(nil), chain...)
+	upper[3].Hash = strings.ToUpper(upper[3].Hash)
+	tip, _ = contiguousTip(upper, 100)
+	assert.Equal(t, int64(105), tip, "hash comparison is case-insensitive")
+
+	_, orphan := testkit.ReorgPair(102, blocks[1].Hash()) // 102 写入了孤块，103 接在规范链 102 上
+	forked := append([]inFlightBlock(nil), chain...)
+	forked[2] = inFlightRows([]*types.Block{orphan})[0]
+	tip, broken = contiguousTip(forked, 100)
+	assert.Equal(t, int64(102), tip)
+	assert.Equal(t, int64(103), broken)
+
+	gap := append(append([]inFlightBlock(nil), chain[:2]...), chain[3:]...)
+	tip, broken = contiguousTip(gap, 100)
+	assert.Equal(t, int64(101), tip, "a missing height stops adoption")
+	assert.Equal(t, int64(102), broken)
+
+	tip, broken = contiguousTip(chain[1:], 100)
+	assert.Equal(t, int64(100), tip, "without the checkpoint block there is no anchor")
+	assert.Equal(t, int64(100), broken)
+}
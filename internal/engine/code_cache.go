@@ -0,0 +1,361 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	defaultCodeCacheSize = 50000
+	defaultCodeEOATTL    = time.Hour // 外部账户可能被 CREATE2 / EIP-7702 变为合约，定期复查
+	codeWarmBatch        = 100       // 单次 eth_getCode batch 的地址数
+	codeWarmInterval     = 500 * time.Millisecond
+	codeWarmQueueSize    = 4096
+)
+
+var (
+	codeCache     *CodeCache
+	codeCacheOnce sync.Once
+
+	errCodeCacheUnbound = errors.New("code cache not configured")
+)
+
+// CodeStore 字节码缓存持久化（storage.Postgres 实现）
+type CodeStore interface {
+	LoadAddressCodes(ctx context.Context, addresses []string) ([]storage.AddressCodeRow, error)
+	SaveAddressCodes(ctx context.Context, rows []storage.AddressCodeRow) error
+}
+
+type codeEntry struct {
+	size      int
+	checkedAt time.Time
+}
+
+type codeWarmReq struct {
+	addr  common.Address
+	force bool // 跳过内存与数据库，直接查询 RPC（已知刚部署的地址）
+}
+
+// CodeCache eth_getCode 结果缓存：内存 LRU → address_code 表 → RPC batch。
+// 严格校验、部署检测、垃圾代币启发式等通过 IsContract / Lookup 共享同一份结果；
+// Processor 把新出现的地址放入预热队列，后台按批查询，热路径不等待 RPC。
+// 合约结果永久有效，外部账户结果超过 eoaTTL 后重新查询。
+type CodeCache struct {
+	mu     sync.Mutex
+	lru    lru.BasicLRU[common.Address, codeEntry]
+	client CodeClient
+	store  CodeStore
+	eoaTTL time.Duration
+	now    func() time.Time
+
+	enabled atomic.Bool
+	warmCh  chan codeWarmReq
+}
+
+// GetCodeCache 返回字节码缓存单例（启动流程通过 Configure 绑定 RPC 池与数据库）
+func GetCodeCache() *CodeCache {
+	codeCacheOnce.Do(func() {
+		codeCache = NewCodeCache(defaultCodeCacheSize, defaultCodeEOATTL)
+	})
+	return codeCache
+}
+
+// NewCodeCache 创建独立的字节码缓存（size / eoaTTL <= 0 使用默认值）
+func NewCodeCache(size int, eoaTTL time.Duration) *CodeCache {
+	if size <= 0 {
+		size = defaultCodeCacheSize
+	}
+	if eoaTTL <= 0 {
+		eoaTTL = defaultCodeEOATTL
+	}
+	return &CodeCache{
+		lru:    lru.NewBasicLRU[common.Address, codeEntry](size),
+		eoaTTL: eoaTTL,
+		now:    time.Now,
+		warmCh: make(chan codeWarmReq, codeWarmQueueSize),
+	}
+}
+
+// Configure 绑定字节码查询客户端与持久化存储（store 可为 nil，仅内存缓存）
+func (c *CodeCache) Configure(client CodeClient, store CodeStore) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.client = client
+	c.store = store
+	c.enabled.Store(client != nil)
+}
+
+// SetLimits 调整 LRU 容量与外部账户复查间隔（<= 0 保持不变）；调整容量会清空内存缓存
+func (c *CodeCache) SetLimits(size int, eoaTTL time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if size > 0 {
+		c.lru = lru.NewBasicLRU[common.Address, codeEntry](size)
+	}
+	if eoaTTL > 0 {
+		c.eoaTTL = eoaTTL
+	}
+}
+
+// Purge 清空内存缓存（链重置后旧链的部署结果不再有效）
+func (c *CodeCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Purge()
+}
+
+// fresh 缓存条目是否仍可直接使用
+func (c *CodeCache) fresh(e codeEntry) bool {
+	return e.size > 0 || c.now().Sub(e.checkedAt) < c.eoaTTL
+}
+
+// IsContract 地址当前是否部署了字节码
+func (c *CodeCache) IsContract(ctx context.Context, addr common.Address) (bool, error) {
+	res, err := c.Lookup(ctx, []common.Address{addr})
+	if err != nil {
+		return false, err
+	}
+	return res[addr], nil
+}
+
+// Lookup 批量判断地址是否为合约；内存与数据库未命中的地址以 RPC batch 查询并回填两级缓存
+func (c *CodeCache) Lookup(ctx context.Context, addrs []common.Address) (map[common.Address]bool, error) {
+	out := make(map[common.Address]bool, len(addrs))
+	var misses []common.Address
+
+	c.mu.Lock()
+	for _, addr := range addrs {
+		if _, done := out[addr]; done {
+			continue
+		}
+		if e, ok := c.lru.Get(addr); ok && c.fresh(e) {
+			out[addr] = e.size > 0
+			continue
+		}
+		out[addr] = false
+		misses = append(misses, addr)
+	}
+	store := c.store
+	c.mu.Unlock()
+	GetMetrics().RecordCodeCacheLookups("memory", len(out)-len(misses))
+
+	if len(misses) == 0 {
+		return out, nil
+	}
+	if store != nil {
+		misses = c.loadFromStore(ctx, store, misses, out)
+	}
+	if len(misses) == 0 {
+		return out, nil
+	}
+	sizes, err := c.fetch(ctx, misses)
+	for addr, size := range sizes {
+		out[addr] = size > 0
+	}
+	return out, err
+}
+
+// Peek 只读内存缓存，返回其中仍有效的地址结果（不查询数据库与 RPC，供写入热路径使用）
+func (c *CodeCache) Peek(addrs []common.Address) map[common.Address]bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[common.Address]bool, len(addrs))
+	for _, addr := range addrs {
+		if e, ok := c.lru.Peek(addr); ok && c.fresh(e) {
+			out[addr] = e.size > 0
+		}
+	}
+	return out
+}
+
+// loadFromStore 从 address_code 表补齐未命中的地址，返回仍需查询 RPC 的地址
+func (c *CodeCache) loadFromStore(ctx context.Context, store CodeStore, misses []common.Address, out map[common.Address]bool) []common.Address {
+	keys := make([]string, len(misses))
+	for i, addr := range misses {
+		keys[i] = strings.ToLower(addr.Hex())
+	}
+	rows, err := store.LoadAddressCodes(ctx, keys)
+	if err != nil {
+		Logger.Debug("code_cache_db_lookup_failed", slog.Int("addresses", len(keys)), slog.String("err", err.Error()))
+		return misses
+	}
+
+	found := make(map[common.Address]struct{}, len(rows))
+	c.mu.Lock()
+	for _, row := range rows {
+		if !common.IsHexAddress(row.Address) {
+			continue
+		}
+		e := codeEntry{size: row.CodeSize, checkedAt: row.CheckedAt}
+		if !c.fresh(e) {
+			continue
+		}
+		addr := common.HexToAddress(row.Address)
+		c.lru.Add(addr, e)
+		out[addr] = e.size > 0
+		found[addr] = struct{}{}
+	}
+	c.mu.Unlock()
+	GetMetrics().RecordCodeCacheLookups("db", len(found))
+
+	remaining := misses[:0]
+	for _, addr := range misses {
+		if _, ok := found[addr]; !ok {
+			remaining = append(remaining, addr)
+		}
+	}
+	return remaining
+}
+
+// fetch 按批查询 RPC 并回填内存与数据库；返回已成功查询的部分与第一个错误
+func (c *CodeCache) fetch(ctx context.Context, addrs []common.Address) (map[common.Address]int, error) {
+	c.mu.Lock()
+	client, store := c.client, c.store
+	c.mu.Unlock()
+	if client == nil {
+		return nil, errCodeCacheUnbound
+	}
+
+	sizes := make(map[common.Address]int, len(addrs))
+	var rows []storage.AddressCodeRow
+	var firstErr error
+	for start := 0; start < len(addrs); start += codeWarmBatch {
+		chunk := addrs[start:min(start+codeWarmBatch, len(addrs))]
+		got, err := client.CodeSizes(ctx, chunk)
+		if err != nil {
+			firstErr = err
+			break
+		}
+		checkedAt := c.now()
+		c.mu.Lock()
+		for addr, size := range got {
+			c.lru.Add(addr, codeEntry{size: size, checkedAt: checkedAt})
+			sizes[addr] = size
+			rows = append(rows, storage.AddressCodeRow{Address: strings.ToLower(addr.Hex()), CodeSize: size, CheckedAt: checkedAt})
+		}
+		c.mu.Unlock()
+	}
+	GetMetrics().RecordCodeCacheLookups("rpc", len(sizes))
+
+	if store != nil && len(rows) > 0 {
+		if err := store.SaveAddressCodes(ctx, rows); err != nil {
+			Logger.Warn("⚠️ [CodeCache] Failed to persist address codes (non-blocking)",
+				slog.Int("addresses", len(rows)),
+				slog.String("err", err.Error()))
+		}
+	}
+	return sizes, firstErr
+}
+
+// Enqueue 把新出现的地址放入后台预热队列（已缓存的跳过；队列满时丢弃，不阻塞调用方）
+func (c *CodeCache) Enqueue(addrs ...common.Address) {
+	if !c.enabled.Load() {
+		return
+	}
+	c.mu.Lock()
+	pending := make([]common.Address, 0, len(addrs))
+	for _, addr := range addrs {
+		if addr == (common.Address{}) || c.lru.Contains(addr) {
+			continue
+		}
+		pending = append(pending, addr)
+	}
+	c.mu.Unlock()
+	for _, addr := range pending {
+		c.push(codeWarmReq{addr: addr})
+	}
+}
+
+// Refresh 地址刚发生部署：丢弃缓存的外部账户结果并在后台重新查询
+func (c *CodeCache) Refresh(addr common.Address) {
+	if !c.enabled.Load() {
+		return
+	}
+	c.mu.Lock()
+	c.lru.Remove(addr)
+	c.mu.Unlock()
+	c.push(codeWarmReq{addr: addr, force: true})
+}
+
+func (c *CodeCache) push(req codeWarmReq) {
+	select {
+	case c.warmCh <- req:
+	default:
+	}
+}
+
+// Run 后台预热循环：攒满一批或每 codeWarmInterval 查询一次，直到 ctx 结束
+func (c *CodeCache) Run(ctx context.Context) {
+	ticker := time.NewTicker(codeWarmInterval)
+	defer ticker.Stop()
+
+	var batch, forced []common.Address
+	flush := func() {
+		if len(forced) > 0 {
+			if _, err := c.fetch(ctx, forced); err != nil {
+				Logger.Debug("code_cache_refresh_failed", slog.Int("addresses", len(forced)), slog.String("err", err.Error()))
+			}
+		}
+		if len(batch) > 0 {
+			if _, err := c.Lookup(ctx, batch); err != nil {
+				Logger.Debug("code_cache_warm_failed", slog.Int("addresses", len(batch)), slog.String("err", err.Error()))
+			}
+		}
+		batch, forced = batch[:0], forced[:0]
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case req := <-c.warmCh:
+			if req.force {
+				forced = append(forced, req.addr)
+			} else {
+				batch = append(batch, req.addr)
+			}
+			if len(batch)+len(forced) >= codeWarmBatch {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// warmActivityAddresses 把活动涉及的地址放入预热队列（跳过合成数据与 0xcontract_creation 等占位值）
+func warmActivityAddresses(activities []models.Transfer) {
+	cache := GetCodeCache()
+	if !cache.enabled.Load() || len(activities) == 0 {
+		return
+	}
+	addrs := make([]common.Address, 0, len(activities)*2)
+	for _, t := range activities {
+		if t.Synthesized {
+			continue
+		}
+		for _, a := range [...]string{t.From, t.To, t.TokenAddress} {
+			if common.IsHexAddress(a) {
+				addrs = append(addrs, common.HexToAddress(a))
+			}
+		}
+	}
+	cache.Enqueue(addrs...)
+}
+
+// refreshDeployedAddress 部署交易的目标地址（CREATE：sender + nonce）重新查询字节码
+func refreshDeployedAddress(sender common.Address, nonce uint64) {
+	GetCodeCache().Refresh(crypto.CreateAddress(sender, nonce))
+}
@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCodeClient 按预设字节码长度应答并记录每次 batch 的地址
+type fakeCodeClient struct {
+	mu      sync.Mutex
+	sizes   map[common.Address]int
+	batches [][]common.Address
+}
+
+func (f *fakeCodeClient) CodeSizes(_ context.Context, addrs []common.Address) (map[common.Address]int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, append([]common.Address(nil), addrs...))
+	out := make(map[common.Address]int, len(addrs))
+	for _, a := range addrs {
+		out[a] = f.sizes[a]
+	}
+	return out, nil
+}
+
+func (f *fakeCodeClient) calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.batches)
+}
+
+// memCodeStore 内存 CodeStore
+type memCodeStore struct {
+	mu   sync.Mutex
+	rows map[string]storage.AddressCodeRow
+}
+
+func (s *memCodeStore) LoadAddressCodes(_ context.Context, addresses []string) ([]storage.AddressCodeRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []storage.AddressCodeRow
+	for _, a := range addresses {
+		if row, ok := s.rows[a]; ok {
+			out = append(out, row)
+		}
+	}
+	return out, nil
+}
+
+func (s *memCodeStore) SaveAddressCodes(_ context.Context, rows []storage.AddressCodeRow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, row := range rows {
+		s.rows[row.Address] = row
+	}
+	return nil
+}
+
+func TestCodeCache_TiersAndEOARecheck(t *testing.T) {
+	contract := common.HexToAddress("0xc0de")
+	eoa := common.HexToAddress("0xe0a")
+	stored := common.HexToAddress("0xdb")
+
+	client := &fakeCodeClient{sizes: map[common.Address]int{contract: 120}}
+	store := &memCodeStore{rows: map[string]storage.AddressCodeRow{
+		strings.ToLower(stored.Hex()): {Address: strings.ToLower(stored.Hex()), CodeSize: 64, CheckedAt: time.Now()},
+	}}
+	now := time.Now()
+	c := NewCodeCache(16, time.Minute)
+	c.now = func() time.Time { return now }
+	c.Configure(client, store)
+
+	res, err := c.Lookup(context.Background(), []common.Address{contract, eoa, stored, contract})
+	require.NoError(t, err)
+	assert.Equal(t, map[common.Address]bool{contract: true, eoa: false, stored: true}, res)
+	require.Equal(t, 1, client.calls(), "database hits are not re-queried over RPC")
+	assert.ElementsMatch(t, []common.Address{contract, eoa}, client.batches[0])
+	assert.Equal(t, 120, store.rows[strings.ToLower(contract.Hex())].CodeSize, "RPC results are persisted")
+
+	// 内存命中不再查询
+	isContract, err := c.IsContract(context.Background(), contract)
+	require.NoError(t, err)
+	assert.True(t, isContract)
+	assert.Equal(t, 1, client.calls())
+
+	// 外部账户结果过期后重新查询；合约结果不过期
+	now = now.Add(2 * time.Minute)
+	client.sizes[eoa] = 23 // EIP-7702 委托后出现代码
+	res, err = c.Lookup(context.Background(), []common.Address{contract, eoa})
+	require.NoError(t, err)
+	assert.True(t, res[eoa])
+	require.Equal(t, 2, client.calls())
+	assert.Equal(t, []common.Address{eoa}, client.batches[1])
+
+	// 链重置：清空 address_code 与内存缓存后，同一地址按新链的字节码重新查询
+	store.rows = map[string]storage.AddressCodeRow{}
+	client.sizes[contract] = 0
+	c.Purge()
+	isContract, err = c.IsContract(context.Background(), contract)
+	require.NoError(t, err)
+	assert.False(t, isContract)
+	assert.Equal(t, 3, client.calls())
+}
+
+func TestCodeCache_BackgroundWarmAndRefresh(t *testing.T) {
+	token := common.HexToAddress("0x70")
+	deployed := common.HexToAddress("0xde")
+	client := &fakeCodeClient{sizes: map[common.Address]int{token: 10}}
+	c := NewCodeCache(16, time.Hour)
+
+	c.Enqueue(token) // 未配置时忽略
+	c.Configure(client, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	c.Enqueue(token, common.Address{})
+	assert.Eventually(t, func() bool { return client.calls() == 1 }, 2*time.Second, 10*time.Millisecond)
+	isContract, err := c.IsContract(context.Background(), token)
+	require.NoError(t, err)
+	assert.True(t, isContract)
+	c.Enqueue(token) // 已缓存，不再排队
+
+	// 先缓存为外部账户，部署后 Refresh 跳过缓存直接重查
+	_, err = c.IsContract(context.Background(), deployed)
+	require.NoError(t, err)
+	client.mu.Lock()
+	client.sizes[deployed] = 99
+	client.mu.Unlock()
+	c.Refresh(deployed)
+	assert.Eventually(t, func() bool {
+		ok, _ := c.IsContract(context.Background(), deployed)
+		return ok
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 3, client.calls())
+}
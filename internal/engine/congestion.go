@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// congestionWindows 进程内填充率滑动平均的区块数（indexer_block_fill_ratio_avg 的 blocks 标签）
+var congestionWindows = []int{10, 100}
+
+// fillRateWindow 最近 N 个区块填充率的滑动平均（环形缓冲，O(1) 更新）
+type fillRateWindow struct {
+	mu   sync.Mutex
+	buf  []float64
+	next int
+	n    int
+	sum  float64
+}
+
+func newFillRateWindows() []*fillRateWindow {
+	windows := make([]*fillRateWindow, 0, len(congestionWindows))
+	for _, size := range congestionWindows {
+		windows = append(windows, &fillRateWindow{buf: make([]float64, size)})
+	}
+	return windows
+}
+
+func (w *fillRateWindow) size() int { return len(w.buf) }
+
+// add 加入一个样本并返回当前窗口平均值
+func (w *fillRateWindow) add(v float64) float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.n == len(w.buf) {
+		w.sum -= w.buf[w.next]
+	} else {
+		w.n++
+	}
+	w.buf[w.next] = v
+	w.sum += v
+	w.next = (w.next + 1) % len(w.buf)
+	return w.sum / float64(w.n)
+}
+
+// CongestionSample 一个时间桶内（按区块时间戳）的拥堵情况与索引延迟
+type CongestionSample struct {
+	Ts         time.Time `db:"ts" json:"ts"`
+	Blocks     int64     `db:"blocks" json:"blocks"`
+	AvgFill    float64   `db:"avg_fill" json:"avg_fill"`
+	MaxFill    float64   `db:"max_fill" json:"max_fill"`
+	AvgGasUsed float64   `db:"avg_gas_used" json:"avg_gas_used"`
+	// LatencyMs 区块出块到落库（processed_at）的平均延迟，用于与拥堵做相关性分析
+	LatencyMs float64 `db:"latency_ms" json:"latency_ms"`
+}
+
+// CongestionRolling 最近 N 个已落库区块的平均填充率
+type CongestionRolling struct {
+	Last10   *float64 `db:"last_10" json:"last_10"`
+	Last100  *float64 `db:"last_100" json:"last_100"`
+	Last1000 *float64 `db:"last_1000" json:"last_1000"`
+}
+
+// QueryCongestionRolling 按区块号取最近 1000 块，计算 10 / 100 / 1000 块滑动平均填充率（无数据时为 null）
+func QueryCongestionRolling(ctx context.Context, db *sqlx.DB) (CongestionRolling, error) {
+	var rolling CongestionRolling
+	err := TimedGet(ctx, db, "congestion_rolling", &rolling, `
+		SELECT AVG(fill) FILTER (WHERE rn <= 10) AS last_10,
+			AVG(fill) FILTER (WHERE rn <= 100) AS last_100,
+			AVG(fill) AS last_1000
+		FROM (
+			SELECT gas_used::DOUBLE PRECISION / gas_limit AS fill, ROW_NUMBER() OVER (ORDER BY number DESC) AS rn
+			FROM blocks WHERE gas_limit > 0
+			ORDER BY number DESC LIMIT 1000
+		) recent`)
+	return rolling, err
+}
+
+// QueryCongestion 读取最近 window 内按区块时间分桶的填充率曲线（旧样本在前），桶宽与吞吐曲线一致
+func QueryCongestion(ctx context.Context, db *sqlx.DB, window time.Duration) ([]CongestionSample, time.Duration, error) {
+	bucket := throughputBucket(window)
+	step := int64(bucket.Seconds())
+
+	samples := []CongestionSample{}
+	err := TimedSelect(ctx, db, "congestion_history", &samples, `
+		SELECT to_timestamp(FLOOR(timestamp / $2) * $2) AS ts,
+			COUNT(*) AS blocks,
+			AVG(gas_used::DOUBLE PRECISION / gas_limit) AS avg_fill,
+			MAX(gas_used::DOUBLE PRECISION / gas_limit) AS max_fill,
+			AVG(gas_used)::DOUBLE PRECISION AS avg_gas_used,
+			COALESCE(AVG(GREATEST(EXTRACT(EPOCH FROM processed_at) - timestamp, 0)) * 1000, 0)::DOUBLE PRECISION AS latency_ms
+		FROM blocks
+		WHERE timestamp >= $1 AND gas_limit > 0
+		GROUP BY 1 ORDER BY 1`, time.Now().Add(-window).Unix(), step)
+	return samples, bucket, err
+}
@@ -0,0 +1,31 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFillRateWindow_RollingAverage(t *testing.T) {
+	w := &fillRateWindow{buf: make([]float64, 3)}
+	assert.InDelta(t, 0.5, w.add(0.5), 1e-9)
+	assert.InDelta(t, 0.75, w.add(1.0), 1e-9)
+	assert.InDelta(t, 0.5, w.add(0), 1e-9)
+	// 窗口满后最旧的样本（0.5）被挤出
+	assert.InDelta(t, 0.5, w.add(0.5), 1e-9)
+	assert.InDelta(t, 0.5, w.add(1.0), 1e-9)
+}
+
+func TestRecordBlockCongestion(t *testing.T) {
+	m := GetMetrics()
+	m.RecordBlockCongestion(15_000_000, 30_000_000)
+	assert.InDelta(t, 0.5, testutil.ToFloat64(m.BlockFillRatio), 1e-9)
+	assert.EqualValues(t, 30_000_000, testutil.ToFloat64(m.BlockGasLimit))
+
+	// gas_limit 为 0 时不更新填充率
+	m.RecordBlockCongestion(0, 0)
+	assert.InDelta(t, 0.5, testutil.ToFloat64(m.BlockFillRatio), 1e-9)
+	assert.Zero(t, testutil.ToFloat64(m.BlockGasUsed))
+	assert.Greater(t, testutil.ToFloat64(m.BlockFillRatioAvg.WithLabelValues("10")), 0.0)
+}
@@ -5,94 +5,26 @@ import (
 	"fmt"
 	"log/slog"
 	"time"
-	"web3-indexer-go/internal/models"
-
-	"github.com/jmoiron/sqlx"
 )
 
-// RepositoryAdapterWrapper wraps sqlx.DB to implement DBUpdater interface for the guard
-type RepositoryAdapterWrapper struct {
-	DB *sqlx.DB
-}
-
-func (r *RepositoryAdapterWrapper) UpdateTokenSymbol(_, _ string) error         { return nil }
-func (r *RepositoryAdapterWrapper) UpdateTokenDecimals(_ string, _ uint8) error { return nil }
-func (r *RepositoryAdapterWrapper) SaveTokenMetadata(_ models.TokenMetadata, _ string) error {
-	return nil
-}
-func (r *RepositoryAdapterWrapper) LoadAllMetadata() (map[string]models.TokenMetadata, error) {
-	return nil, nil
-}
-
-func (r *RepositoryAdapterWrapper) UpdateSyncCursor(ctx context.Context, height int64) error {
-	headStr := fmt.Sprintf("%d", height)
-	tx, err := r.DB.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback() // nolint:errcheck // Rollback is used for safety, error is expected if commit succeeded.
-
-	if _, err := tx.ExecContext(ctx, "UPDATE sync_checkpoints SET last_synced_block = $1, updated_at = NOW()", headStr); err != nil {
-		return err
-	}
-	if _, err := tx.ExecContext(ctx, "UPDATE sync_status SET last_processed_block = $1, last_processed_timestamp = NOW()", headStr); err != nil {
-		return err
-	}
-	return tx.Commit()
-}
-
-func (r *RepositoryAdapterWrapper) GetMaxStoredBlock(ctx context.Context) (int64, error) {
-	var dbMax int64
-	err := r.DB.GetContext(ctx, &dbMax, "SELECT COALESCE(MAX(number), 0) FROM blocks")
-	return dbMax, err
-}
-
-func (r *RepositoryAdapterWrapper) PruneFutureData(ctx context.Context, chainHead int64) error {
-	tx, err := r.DB.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback() // nolint:errcheck // Rollback is standard practice, error is non-critical here
-
-	headStr := fmt.Sprintf("%d", chainHead)
-
-	if _, err := tx.ExecContext(ctx, "DELETE FROM transfers WHERE block_number > $1", headStr); err != nil {
-		return err
-	}
-	if _, err := tx.ExecContext(ctx, "DELETE FROM blocks WHERE number > $1", headStr); err != nil {
-		return err
-	}
-	if _, err := tx.ExecContext(ctx, "UPDATE sync_checkpoints SET last_synced_block = $1, updated_at = NOW()", headStr); err != nil {
-		return err
-	}
-	if _, err := tx.ExecContext(ctx, "UPDATE sync_status SET last_processed_block = $1, last_processed_timestamp = NOW()", headStr); err != nil {
-		return err
-	}
-	return tx.Commit()
-}
-
 // ConsistencyGuard handles data alignment between DB and Chain
 type ConsistencyGuard struct {
 	repo     DBUpdater
 	rpcPool  RPCClient
+	chainID  int64
 	logger   *slog.Logger
-	demoMode bool                                             // 🚀 Leap-Sync toggle
 	OnStatus func(status string, detail string, progress int) // 🚀 UI feedback callback
 }
 
-func NewConsistencyGuard(repo DBUpdater, rpcPool RPCClient) *ConsistencyGuard {
+func NewConsistencyGuard(repo DBUpdater, rpcPool RPCClient, chainID int64) *ConsistencyGuard {
 	return &ConsistencyGuard{
 		repo:    repo,
 		rpcPool: rpcPool,
+		chainID: chainID,
 		logger:  slog.Default(),
 	}
 }
 
-// SetDemoMode enables/disables Leap-Sync logic
-func (g *ConsistencyGuard) SetDemoMode(enabled bool) {
-	g.demoMode = enabled
-}
-
 // PerformLinearityCheck 检查并修复数据越位问题
 func (g *ConsistencyGuard) PerformLinearityCheck(ctx context.Context) error {
 	if g.OnStatus != nil {
@@ -126,7 +58,7 @@ func (g *ConsistencyGuard) PerformLinearityCheck(ctx context.Context) error {
 		}
 
 		// 4. 执行物理剪枝 (Pruning)
-		if err := g.repo.PruneFutureData(ctx, chainHead.Int64()); err != nil {
+		if err := g.repo.RollbackAbove(ctx, g.chainID, chainHead.Int64()); err != nil {
 			return fmt.Errorf("pruning failed: %w", err)
 		}
 
@@ -137,9 +69,15 @@ func (g *ConsistencyGuard) PerformLinearityCheck(ctx context.Context) error {
 		g.logger.Info("✅ [Linearity] Pruning complete. Database aligned with current chain head.", "new_height", chainHead.Int64())
 	}
 
-	// 🚀 4. 深度断层判定 (Leap-Sync): 如果链头远超数据库
+	// 🚀 4. 深度断层判定 (Leap-Sync): 如果链头远超数据库，是否跳跃由 GapSkipPolicy 决定
 	gap := chainHead.Int64() - dbMax
-	if g.demoMode && gap > 1000 {
+	skipper := GetGapSkipper()
+	policy := skipper.Policy()
+	if gap > 0 && policy.LeapSync && uint64(gap) > policy.LeapThreshold && !policy.AllowSkip(uint64(gap)) {
+		g.logger.Warn("🚧 [Linearity] Gap exceeds max skippable gap, catching up instead of Leap-Sync",
+			"gap", gap, "max_skippable_gap", policy.MaxSkippableGap)
+	}
+	if gap > 0 && policy.AllowLeap(uint64(gap)) {
 		g.logger.Warn("🚧 [Linearity] Large gap detected in Demo Mode! Executing State Collapse (Leap-Sync).",
 			"gap", gap)
 
@@ -147,7 +85,7 @@ func (g *ConsistencyGuard) PerformLinearityCheck(ctx context.Context) error {
 			g.OnStatus("LEAPING", "Collapsing state to chain head...", 75)
 		}
 
-		if err := g.repo.UpdateSyncCursor(ctx, chainHead.Int64()-1); err != nil {
+		if err := g.repo.UpdateSyncCursor(ctx, g.chainID, chainHead.Int64()-1); err != nil {
 			return fmt.Errorf("leap-sync failed: %w", err)
 		}
 
@@ -156,6 +94,7 @@ func (g *ConsistencyGuard) PerformLinearityCheck(ctx context.Context) error {
 			orch.ForceSetCursors(chainHead.Uint64() - 1)
 		}
 
+		skipper.Record(ctx, uint64(max(dbMax+1, 0)), chainHead.Uint64()-1, SkipReasonLeapSync)
 		g.logger.Info("✅ [Linearity] Leap-Sync complete. System teleported to chain head.")
 
 		// 🚨 新增：检测异常大gap（>100,000），表明数据库被清理，需要重启引擎
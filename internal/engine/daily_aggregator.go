@@ -0,0 +1,227 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// dailyAggregateWatermark aggregate_watermarks 中的日统计水位名
+	dailyAggregateWatermark = "daily_stats"
+	// dailyTopTokens 每日保留的 top token 数（按转账笔数）
+	dailyTopTokens = 10
+	// maxDaysPerRefresh 单轮最多重算的天数，避免首次启动时长事务
+	maxDaysPerRefresh = 7
+)
+
+// DailyStat 单日聚合统计
+type DailyStat struct {
+	Day             string            `db:"day" json:"day"`
+	Blocks          int64             `db:"blocks" json:"blocks"`
+	Transfers       int64             `db:"transfers" json:"transfers"`
+	UniqueAddresses int64             `db:"unique_addresses" json:"unique_addresses"`
+	TopTokens       []DailyTokenStats `db:"-" json:"top_tokens"`
+}
+
+// DailyTokenStats 单日单代币的转账量
+type DailyTokenStats struct {
+	Day          string `db:"day" json:"-"`
+	TokenAddress string `db:"token_address" json:"token_address"`
+	Symbol       string `db:"symbol" json:"symbol"`
+	Transfers    int64  `db:"transfers" json:"transfers"`
+	Volume       string `db:"volume" json:"volume"`
+}
+
+// DailyAggregator 按水位增量维护 daily_stats / daily_token_stats：
+// 只重算水位之后新区块所覆盖的自然日（UTC），按日整体重算以保证 unique 地址数准确
+type DailyAggregator struct {
+	db       *sqlx.DB
+	interval time.Duration
+}
+
+// NewDailyAggregator 创建日统计聚合器
+func NewDailyAggregator(db *sqlx.DB, interval time.Duration) *DailyAggregator {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &DailyAggregator{db: db, interval: interval}
+}
+
+// Start 启动后台聚合循环（启动时立即执行一轮）
+func (a *DailyAggregator) Start(ctx context.Context) {
+	Logger.Info("📅 [DailyAggregator] Started", slog.Duration("interval", a.interval))
+	go func() {
+		ticker := time.NewTicker(a.interval)
+		defer ticker.Stop()
+		for {
+			if err := a.Refresh(ctx); err != nil && ctx.Err() == nil {
+				Logger.Warn("📅 [DailyAggregator] Refresh failed", slog.String("error", err.Error()))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Refresh 重算水位之后受影响的日期并推进水位
+func (a *DailyAggregator) Refresh(ctx context.Context) error {
+	var pending struct {
+		MinTs    sql.NullInt64  `db:"min_ts"`
+		MaxTs    sql.NullInt64  `db:"max_ts"`
+		MaxBlock sql.NullString `db:"max_block"`
+	}
+	err := a.db.GetContext(ctx, &pending, `
+		SELECT MIN(timestamp) AS min_ts, MAX(timestamp) AS max_ts, MAX(number)::TEXT AS max_block
+		FROM blocks
+		WHERE number > COALESCE((SELECT last_block FROM aggregate_watermarks WHERE name = $1), -1)`,
+		dailyAggregateWatermark)
+	if err != nil {
+		return fmt.Errorf("read pending blocks: %w", err)
+	}
+	if !pending.MinTs.Valid {
+		return nil
+	}
+
+	days := affectedDays(pending.MinTs.Int64, pending.MaxTs.Int64, maxDaysPerRefresh)
+	lastDayEnd := days[len(days)-1].Add(24 * time.Hour)
+
+	tx, err := a.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var prevWatermark string
+	if err := tx.GetContext(ctx, &prevWatermark,
+		"SELECT COALESCE((SELECT last_block FROM aggregate_watermarks WHERE name = $1), -1)::TEXT",
+		dailyAggregateWatermark); err != nil {
+		return fmt.Errorf("read watermark: %w", err)
+	}
+
+	for _, day := range days {
+		if err := a.recomputeDay(ctx, tx, day); err != nil {
+			return fmt.Errorf("recompute %s: %w", day.Format(time.DateOnly), err)
+		}
+	}
+
+	// 水位推进到已重算日期内的最大区块；全部覆盖时直接取 max_block
+	watermark := pending.MaxBlock.String
+	if pending.MaxTs.Int64 >= lastDayEnd.Unix() {
+		if err := tx.GetContext(ctx, &watermark,
+			"SELECT COALESCE(MAX(number), -1)::TEXT FROM blocks WHERE timestamp < $1", lastDayEnd.Unix()); err != nil {
+			return fmt.Errorf("read watermark: %w", err)
+		}
+	}
+	// 记录本轮新聚合区块的逐块增量，供 reorg 回滚时撤销
+	if err := recordAggregateDeltas(ctx, tx, prevWatermark, watermark); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO aggregate_watermarks (name, last_block, updated_at) VALUES ($1, $2::NUMERIC, NOW())
+		ON CONFLICT (name) DO UPDATE SET last_block = EXCLUDED.last_block, updated_at = NOW()`,
+		dailyAggregateWatermark, watermark); err != nil {
+		return fmt.Errorf("update watermark: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	Logger.Debug("📅 [DailyAggregator] Refreshed",
+		slog.Int("days", len(days)),
+		slog.String("from", days[0].Format(time.DateOnly)),
+		slog.String("watermark", watermark))
+	return nil
+}
+
+// recomputeDay 整体重算某一天的统计（先经 blocks 时间索引换算区块区间，再聚合 transfers）
+func (a *DailyAggregator) recomputeDay(ctx context.Context, tx *sqlx.Tx, day time.Time) error {
+	start, end := day.Unix(), day.Add(24*time.Hour).Unix()
+	dayStr := day.Format(time.DateOnly)
+
+	if _, err := tx.ExecContext(ctx, `
+		WITH span AS (
+			SELECT MIN(number) AS lo, MAX(number) AS hi, COUNT(*) AS blocks
+			FROM blocks WHERE timestamp >= $2 AND timestamp < $3
+		), t AS (
+			SELECT from_address, to_address FROM transfers, span
+			WHERE block_number >= span.lo AND block_number <= span.hi
+		)
+		INSERT INTO daily_stats (day, blocks, transfers, unique_addresses, updated_at)
+		SELECT $1::DATE, span.blocks,
+			(SELECT COUNT(*) FROM t),
+			(SELECT COUNT(*) FROM (SELECT from_address FROM t UNION SELECT to_address FROM t) u),
+			NOW()
+		FROM span
+		ON CONFLICT (day) DO UPDATE SET blocks = EXCLUDED.blocks, transfers = EXCLUDED.transfers,
+			unique_addresses = EXCLUDED.unique_addresses, updated_at = NOW()`,
+		dayStr, start, end); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM daily_token_stats WHERE day = $1::DATE", dayStr); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `
+		WITH span AS (
+			SELECT MIN(number) AS lo, MAX(number) AS hi FROM blocks WHERE timestamp >= $2 AND timestamp < $3
+		)
+		INSERT INTO daily_token_stats (day, token_address, symbol, transfers, volume)
+		SELECT $1::DATE, token_address, MAX(symbol), COUNT(*), SUM(amount)
+		FROM transfers, span
+		WHERE block_number >= span.lo AND block_number <= span.hi
+		GROUP BY token_address
+		ORDER BY COUNT(*) DESC
+		LIMIT $4`,
+		dayStr, start, end, dailyTopTokens)
+	return err
+}
+
+// affectedDays 返回 [minTs, maxTs] 覆盖的 UTC 自然日（最多 limit 天）
+func affectedDays(minTs, maxTs int64, limit int) []time.Time {
+	first := time.Unix(minTs, 0).UTC().Truncate(24 * time.Hour)
+	last := time.Unix(maxTs, 0).UTC().Truncate(24 * time.Hour)
+	var days []time.Time
+	for d := first; !d.After(last) && len(days) < limit; d = d.Add(24 * time.Hour) {
+		days = append(days, d)
+	}
+	return days
+}
+
+// QueryDailyStats 读取最近 days 天的日统计（新日期在前）
+func QueryDailyStats(ctx context.Context, db *sqlx.DB, days int) ([]DailyStat, error) {
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -(days - 1)).Format(time.DateOnly)
+
+	stats := []DailyStat{}
+	if err := TimedSelect(ctx, db, "daily_stats", &stats, `
+		SELECT day::TEXT AS day, blocks, transfers, unique_addresses
+		FROM daily_stats WHERE day >= $1::DATE ORDER BY day DESC`, since); err != nil {
+		return nil, err
+	}
+
+	var tokens []DailyTokenStats
+	if err := TimedSelect(ctx, db, "daily_token_stats", &tokens, `
+		SELECT day::TEXT AS day, token_address, COALESCE(symbol, '') AS symbol, transfers, volume::TEXT AS volume
+		FROM daily_token_stats WHERE day >= $1::DATE ORDER BY day DESC, transfers DESC`, since); err != nil {
+		return nil, err
+	}
+
+	byDay := make(map[string][]DailyTokenStats)
+	for _, t := range tokens {
+		byDay[t.Day] = append(byDay[t.Day], t)
+	}
+	for i := range stats {
+		stats[i].TopTokens = byDay[stats[i].Day]
+		if stats[i].TopTokens == nil {
+			stats[i].TopTokens = []DailyTokenStats{}
+		}
+	}
+	return stats, nil
+}
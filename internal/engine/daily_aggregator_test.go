@@ -0,0 +1,24 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAffectedDays(t *testing.T) {
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	days := affectedDays(day.Add(23*time.Hour).Unix(), day.Add(49*time.Hour).Unix(), 7)
+	assert.Len(t, days, 3)
+	assert.Equal(t, "2024-03-10", days[0].Format(time.DateOnly))
+	assert.Equal(t, "2024-03-12", days[2].Format(time.DateOnly))
+
+	single := affectedDays(day.Unix(), day.Add(time.Hour).Unix(), 7)
+	assert.Len(t, single, 1)
+
+	capped := affectedDays(day.Unix(), day.AddDate(0, 0, 30).Unix(), 7)
+	assert.Len(t, capped, 7)
+	assert.Equal(t, "2024-03-16", capped[6].Format(time.DateOnly))
+}
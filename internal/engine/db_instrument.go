@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// defaultDBQueryTimeout 分析/API 查询的默认单条超时
+	defaultDBQueryTimeout = 5 * time.Second
+	// defaultSlowQueryThreshold 慢查询告警阈值
+	defaultSlowQueryThreshold = 500 * time.Millisecond
+	// slowQueryLogChars 慢查询日志中 SQL 的最大长度
+	slowQueryLogChars = 200
+)
+
+var (
+	dbQueryTimeout     atomic.Int64
+	slowQueryThreshold atomic.Int64
+)
+
+func init() {
+	dbQueryTimeout.Store(int64(defaultDBQueryTimeout))
+	slowQueryThreshold.Store(int64(defaultSlowQueryThreshold))
+}
+
+// SetDBQueryPolicy 设置 Timed* 查询的单条超时与慢查询阈值（<= 0 表示不限时 / 不记录慢查询）
+func SetDBQueryPolicy(timeout, slow time.Duration) {
+	dbQueryTimeout.Store(int64(timeout))
+	slowQueryThreshold.Store(int64(slow))
+}
+
+// withQueryTimeout 为单条查询附加超时；超时后 pgx 向服务端发送取消请求，语句不会继续占用连接
+func withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if d := time.Duration(dbQueryTimeout.Load()); d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return ctx, func() {}
+}
+
+// TimedSelect 带超时与耗时统计的 SelectContext（op 为 DBQueryLatency 的 operation 标签）
+func TimedSelect(ctx context.Context, db sqlx.QueryerContext, op string, dest interface{}, query string, args ...interface{}) error {
+	qctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	start := time.Now()
+	err := sqlx.SelectContext(qctx, db, dest, query, args...)
+	observeQuery(qctx, op, query, time.Since(start), err)
+	return err
+}
+
+// TimedGet 带超时与耗时统计的 GetContext
+func TimedGet(ctx context.Context, db sqlx.QueryerContext, op string, dest interface{}, query string, args ...interface{}) error {
+	qctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+	start := time.Now()
+	err := sqlx.GetContext(qctx, db, dest, query, args...)
+	observeQuery(qctx, op, query, time.Since(start), err)
+	return err
+}
+
+// dbTimerKey 请求级 DB 耗时累加器的 context key
+type dbTimerKey struct{}
+
+// WithDBTimer 为请求上下文附加 DB 耗时累加器：经 observeQuery 记录的查询耗时都会计入，
+// 供 API 指标区分“接口本身慢”与“数据库慢”
+func WithDBTimer(ctx context.Context) (context.Context, *atomic.Int64) {
+	acc := new(atomic.Int64)
+	return context.WithValue(ctx, dbTimerKey{}, acc), acc
+}
+
+// observeQuery 记录查询耗时，超过阈值时输出慢查询日志；超时单独告警。
+// sql.ErrNoRows 属于正常结果，不计为错误。
+func observeQuery(ctx context.Context, op, query string, elapsed time.Duration, err error) {
+	ok := err == nil || errors.Is(err, sql.ErrNoRows)
+	GetMetrics().RecordDBQuery(op, elapsed, ok)
+	if acc, _ := ctx.Value(dbTimerKey{}).(*atomic.Int64); acc != nil {
+		acc.Add(int64(elapsed))
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		Logger.Warn("⏱️ [DB] Query timed out",
+			slog.String("op", op),
+			slog.Duration("elapsed", elapsed),
+			slog.String("query", compactQuery(query)))
+		return
+	}
+	if slow := time.Duration(slowQueryThreshold.Load()); slow > 0 && elapsed >= slow {
+		Logger.Warn("🐢 [DB] Slow query",
+			slog.String("op", op),
+			slog.Int64("duration_ms", elapsed.Milliseconds()),
+			slog.Bool("ok", ok),
+			slog.String("query", compactQuery(query)))
+	}
+}
+
+// compactQuery 折叠空白并截断，便于单行日志检索
+func compactQuery(query string) string {
+	q := strings.Join(strings.Fields(query), " ")
+	if len(q) > slowQueryLogChars {
+		q = q[:slowQueryLogChars] + "…"
+	}
+	return q
+}
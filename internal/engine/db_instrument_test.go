@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingQueryer 模拟一条永不返回的查询，直到 ctx 被取消
+type blockingQueryer struct{}
+
+func (blockingQueryer) QueryContext(ctx context.Context, _ string, _ ...interface{}) (*sql.Rows, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (blockingQueryer) QueryxContext(ctx context.Context, _ string, _ ...interface{}) (*sqlx.Rows, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (blockingQueryer) QueryRowxContext(_ context.Context, _ string, _ ...interface{}) *sqlx.Row {
+	return &sqlx.Row{}
+}
+
+func TestTimedSelect_CancelsRunawayQuery(t *testing.T) {
+	SetDBQueryPolicy(50*time.Millisecond, time.Second)
+	defer SetDBQueryPolicy(defaultDBQueryTimeout, defaultSlowQueryThreshold)
+
+	errorsBefore := testutil.ToFloat64(GetMetrics().DBErrors.WithLabelValues("test_runaway"))
+
+	var rows []int
+	start := time.Now()
+	err := TimedSelect(context.Background(), blockingQueryer{}, "test_runaway", &rows, "SELECT pg_sleep(60)")
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, errorsBefore+1, testutil.ToFloat64(GetMetrics().DBErrors.WithLabelValues("test_runaway")))
+}
+
+func TestWithQueryTimeout_DisabledKeepsParentContext(t *testing.T) {
+	SetDBQueryPolicy(0, 0)
+	defer SetDBQueryPolicy(defaultDBQueryTimeout, defaultSlowQueryThreshold)
+
+	ctx, cancel := withQueryTimeout(context.Background())
+	defer cancel()
+	_, hasDeadline := ctx.Deadline()
+	assert.False(t, hasDeadline)
+}
+
+func TestObserveQuery_NoRowsIsNotAnError(t *testing.T) {
+	m := GetMetrics()
+	before := testutil.ToFloat64(m.DBErrors.WithLabelValues("test_no_rows"))
+	observeQuery(context.Background(), "test_no_rows", "SELECT 1", time.Millisecond, sql.ErrNoRows)
+	assert.Equal(t, before, testutil.ToFloat64(m.DBErrors.WithLabelValues("test_no_rows")))
+}
+
+func TestCompactQuery(t *testing.T) {
+	assert.Equal(t, "SELECT * FROM blocks WHERE number > $1",
+		compactQuery("\n\t\tSELECT *\n\t\tFROM blocks\n\t\tWHERE number > $1"))
+
+	long := compactQuery("SELECT " + strings.Repeat("x, ", 200) + "y FROM t")
+	assert.True(t, strings.HasSuffix(long, "…"))
+	assert.Equal(t, slowQueryLogChars, len(strings.TrimSuffix(long, "…")))
+}
+
+func TestWithDBTimer_AccumulatesObservedQueries(t *testing.T) {
+	ctx, acc := WithDBTimer(context.Background())
+	observeQuery(ctx, "test_db_timer", "SELECT 1", 3*time.Millisecond, nil)
+	observeQuery(ctx, "test_db_timer", "SELECT 2", 4*time.Millisecond, sql.ErrNoRows)
+	observeQuery(context.Background(), "test_db_timer", "SELECT 3", time.Second, nil)
+
+	assert.Equal(t, 7*time.Millisecond, time.Duration(acc.Load()))
+}
@@ -4,6 +4,7 @@ package engine
 
 import (
 	"context"
+	"fmt"
 	"math/big"
 	"os"
 	"strings"
@@ -115,3 +116,97 @@ func TestSanity_NotNullConstraints(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "null value in column \"parent_hash\"")
 }
+
+// TestRegression_BlockNumberNumericOrdering 区块号超过 10 位后，排序与区间查询必须按数值而不是字典序
+func TestRegression_BlockNumberNumericOrdering(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	numbers := []string{"9", "10", "9999999999", "10000000000", "12345678901"}
+	blocks := make([]models.Block, len(numbers))
+	for i, n := range numbers {
+		num, ok := models.NewBigIntFromString(n)
+		require.True(t, ok)
+		blocks[i] = models.Block{
+			Number:     num,
+			Hash:       "0x" + strings.Repeat("b", 63) + string(rune('0'+i)),
+			ParentHash: "0x" + strings.Repeat("0", 64),
+			Timestamp:  uint64(1700000000 + i), // #nosec G115 - 测试常量
+		}
+	}
+	require.NoError(t, NewBulkInserter(db).InsertBlocksBatch(ctx, blocks))
+
+	var ordered []models.BigInt
+	require.NoError(t, db.SelectContext(ctx, &ordered, "SELECT number FROM blocks ORDER BY number DESC"))
+	got := make([]string, len(ordered))
+	for i, n := range ordered {
+		got[i] = n.String()
+	}
+	assert.Equal(t, []string{"12345678901", "10000000000", "9999999999", "10", "9"}, got)
+
+	var inRange int
+	require.NoError(t, db.GetContext(ctx, &inRange,
+		"SELECT COUNT(*) FROM blocks WHERE number >= $1::NUMERIC AND number <= $2::NUMERIC", "10", "9999999999"))
+	assert.Equal(t, 2, inRange)
+
+	var maxBlock models.BigInt
+	require.NoError(t, db.GetContext(ctx, &maxBlock, "SELECT MAX(number) FROM blocks"))
+	assert.Equal(t, "12345678901", maxBlock.String())
+}
+
+// TestStore_RollbackAboveTrimsDataAndCursor 验证 RollbackAbove 在单事务内删除区块/转账并只回退该链的游标
+func TestStore_RollbackAboveTrimsDataAndCursor(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	store := NewStore(db)
+
+	blocks := make([]models.Block, 5)
+	transfers := make([]models.Transfer, 5)
+	for i := range blocks {
+		n := int64(i + 1)
+		blocks[i] = models.Block{
+			Number:     models.NewBigInt(n),
+			Hash:       fmt.Sprintf("0x%064x", n),
+			ParentHash: fmt.Sprintf("0x%064x", n-1),
+			Timestamp:  uint64(1700000000 + i), // #nosec G115 - 测试常量
+		}
+		transfers[i] = models.Transfer{
+			BlockNumber:  models.NewBigInt(n),
+			TxHash:       fmt.Sprintf("0x%064x", n+100),
+			From:         "0x0000000000000000000000000000000000000001",
+			To:           "0x0000000000000000000000000000000000000002",
+			TokenAddress: "0x0000000000000000000000000000000000000003",
+			Amount:       models.NewUint256FromBigInt(big.NewInt(n)),
+		}
+	}
+	require.NoError(t, store.InsertBlocks(ctx, blocks))
+	require.NoError(t, store.InsertTransfers(ctx, transfers))
+	_, err := db.ExecContext(ctx, `INSERT INTO sync_checkpoints (chain_id, last_synced_block) VALUES (1, '5'), (2, '5')
+		ON CONFLICT (chain_id) DO UPDATE SET last_synced_block = '5'`)
+	require.NoError(t, err)
+
+	require.NoError(t, store.RollbackAbove(ctx, 1, 3))
+
+	maxStored, err := store.GetMaxStoredBlock(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, maxStored)
+
+	cursor, err := store.GetSyncCursor(ctx, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, cursor)
+	// 游标按 chain_id 隔离：其他链的检查点不受影响
+	cursor, err = store.GetSyncCursor(ctx, 2)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, cursor)
+	cursor, err = store.GetSyncCursor(ctx, 3)
+	require.NoError(t, err)
+	assert.Zero(t, cursor)
+
+	latest, err := store.GetLatestTransfers(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, latest, 3)
+	assert.Equal(t, "3", latest[0].BlockNumber)
+}
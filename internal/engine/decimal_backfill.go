@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDecimalBackfillInterval = time.Minute
+	defaultDecimalBackfillBatch    = 5000
+)
+
+// DecimalBackfillConfig 金额精度回填参数
+type DecimalBackfillConfig struct {
+	Interval  time.Duration // 两轮之间的间隔
+	BatchSize int           // 每批更新的行数
+}
+
+// DecimalBackfillStore normalized_amount 回填读写（*storage.Postgres 实现）
+type DecimalBackfillStore interface {
+	CountUnnormalizedTransfers(ctx context.Context) (int64, error)
+	NormalizeTransferAmounts(ctx context.Context, limit int) (int64, error)
+}
+
+// DecimalBackfillStatus 回填进度（/api/admin/backfill/decimals）
+type DecimalBackfillStatus struct {
+	Enabled    bool      `json:"enabled"`
+	Running    bool      `json:"running"`
+	Pending    int64     `json:"pending"`    // 本轮开始时待回填的行数，随批次递减
+	Normalized int64     `json:"normalized"` // 启动以来累计回填的行数
+	Percent    float64   `json:"percent"`    // 本轮完成百分比
+	LastRunAt  time.Time `json:"last_run_at"`
+	LastError  string    `json:"last_error,omitempty"`
+}
+
+// DecimalBackfiller 代币精度读取后，分批把历史转账的 amount 换算为 normalized_amount。
+// 每轮先统计待回填行数，再逐批更新直到补齐；新落库的转账在下一轮补上
+type DecimalBackfiller struct {
+	cfg   DecimalBackfillConfig
+	store DecimalBackfillStore
+
+	mu     sync.Mutex
+	status DecimalBackfillStatus
+	total  int64 // 本轮开始时的待回填行数
+}
+
+// NewDecimalBackfiller 创建回填器；零值参数取默认值
+func NewDecimalBackfiller(store DecimalBackfillStore, cfg DecimalBackfillConfig) *DecimalBackfiller {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultDecimalBackfillInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultDecimalBackfillBatch
+	}
+	return &DecimalBackfiller{cfg: cfg, store: store, status: DecimalBackfillStatus{Enabled: true}}
+}
+
+// Run 启动即执行一轮，之后按间隔执行直到 ctx 结束
+func (b *DecimalBackfiller) Run(ctx context.Context) {
+	Logger.Info("🔢 decimal_backfill_worker_started", slog.Duration("interval", b.cfg.Interval), slog.Int("batch_size", b.cfg.BatchSize))
+	ticker := time.NewTicker(b.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := b.Pass(ctx); err != nil && ctx.Err() == nil {
+			Logger.Warn("decimal_backfill_pass_failed", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Pass 执行一轮：统计待回填行数，逐批更新直到补齐或出错
+func (b *DecimalBackfiller) Pass(ctx context.Context) error {
+	pending, err := b.store.CountUnnormalizedTransfers(ctx)
+	b.begin(pending, err)
+	if err != nil {
+		return err
+	}
+	if pending == 0 {
+		b.finish(nil)
+		return nil
+	}
+	Logger.Info("🔢 decimal_backfill_pass_started", "pending", pending)
+
+	for ctx.Err() == nil {
+		n, err := b.store.NormalizeTransferAmounts(ctx, b.cfg.BatchSize)
+		if err != nil {
+			b.finish(err)
+			return err
+		}
+		if n == 0 {
+			break
+		}
+		status := b.advance(n)
+		Logger.Info("🔢 decimal_backfill_progress",
+			"normalized", n, "remaining", status.Pending, "percent", status.Percent)
+	}
+	b.finish(ctx.Err())
+	return ctx.Err()
+}
+
+func (b *DecimalBackfiller) begin(pending int64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.status.LastRunAt = time.Now()
+	if err != nil {
+		b.status.LastError = err.Error()
+		return
+	}
+	b.total = pending
+	b.status.Running = pending > 0
+	b.status.Pending = pending
+	b.status.Percent = percentDone(pending, pending)
+	b.status.LastError = ""
+	GetMetrics().RecordDecimalBackfill(0, pending)
+}
+
+// advance 记录一批完成；新落库的行会让实际更新数超过本轮统计，剩余数不低于 0
+func (b *DecimalBackfiller) advance(n int64) DecimalBackfillStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.status.Normalized += n
+	b.status.Pending = max(b.status.Pending-n, 0)
+	b.status.Percent = percentDone(b.total, b.status.Pending)
+	GetMetrics().RecordDecimalBackfill(n, b.status.Pending)
+	return b.status
+}
+
+func (b *DecimalBackfiller) finish(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.status.Running = false
+	if err != nil {
+		b.status.LastError = err.Error()
+		return
+	}
+	b.status.Pending = 0
+	b.status.Percent = 100
+	GetMetrics().RecordDecimalBackfill(0, 0)
+}
+
+// percentDone 本轮完成百分比（保留两位小数）
+func percentDone(total, pending int64) float64 {
+	if total <= 0 {
+		return 100
+	}
+	return float64((total-pending)*10000/total) / 100
+}
+
+// Status 返回当前回填进度；b 为 nil（未启用）时返回 Enabled=false
+func (b *DecimalBackfiller) Status() DecimalBackfillStatus {
+	if b == nil {
+		return DecimalBackfillStatus{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.status
+}
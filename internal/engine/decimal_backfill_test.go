@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDecimalStore struct {
+	pending int64
+	batches []int
+	failAt  int // 第几批返回错误（0 不出错）
+}
+
+func (s *fakeDecimalStore) CountUnnormalizedTransfers(context.Context) (int64, error) {
+	return s.pending, nil
+}
+
+func (s *fakeDecimalStore) NormalizeTransferAmounts(_ context.Context, limit int) (int64, error) {
+	s.batches = append(s.batches, limit)
+	if s.failAt == len(s.batches) {
+		return 0, errors.New("deadlock detected")
+	}
+	n := min(s.pending, int64(limit))
+	s.pending -= n
+	return n, nil
+}
+
+func TestDecimalBackfiller_Pass(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeDecimalStore{pending: 25}
+	b := NewDecimalBackfiller(store, DecimalBackfillConfig{BatchSize: 10})
+
+	require.NoError(t, b.Pass(ctx))
+	assert.Equal(t, []int{10, 10, 10, 10}, store.batches, "batches run until an empty one")
+	status := b.Status()
+	assert.True(t, status.Enabled)
+	assert.False(t, status.Running)
+	assert.Equal(t, int64(25), status.Normalized)
+	assert.Zero(t, status.Pending)
+	assert.Equal(t, 100.0, status.Percent)
+
+	store.pending, store.batches, store.failAt = 40, nil, 2
+	require.Error(t, b.Pass(ctx))
+	status = b.Status()
+	assert.Equal(t, int64(35), status.Normalized)
+	assert.Equal(t, int64(30), status.Pending, "progress is kept when a batch fails")
+	assert.Equal(t, 25.0, status.Percent)
+	assert.Equal(t, "deadlock detected", status.LastError)
+
+	assert.False(t, (*DecimalBackfiller)(nil).Status().Enabled)
+}
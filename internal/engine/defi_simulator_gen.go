@@ -49,6 +49,8 @@ func (s *DeFiSimulator) generateSwapTransfer(blockNumber uint64, seqNum int64) *
 		To:           to,
 		Amount:       amountRaw,
 		Timestamp:    time.Now().Unix(),
+		Symbol:       token0.Symbol,
+		Type:         "SWAP",
 		Synthesized:  true,
 	}
 }
@@ -67,6 +69,8 @@ func (s *DeFiSimulator) generateArbitrageTransfer(blockNumber uint64, seqNum int
 		To:           s.uniswapV3Router,
 		Amount:       amountRaw,
 		Timestamp:    time.Now().Unix(),
+		Symbol:       token0.Symbol,
+		Type:         "ARBITRAGE",
 		Synthesized:  true,
 	}
 }
@@ -84,6 +88,8 @@ func (s *DeFiSimulator) generateFlashloanTransfer(blockNumber uint64, seqNum int
 		To:           s.balancerVault,
 		Amount:       amountRaw,
 		Timestamp:    time.Now().Unix(),
+		Symbol:       token.Symbol,
+		Type:         "FLASHLOAN",
 		Synthesized:  true,
 	}
 }
@@ -102,6 +108,8 @@ func (s *DeFiSimulator) generateMEVTransfer(blockNumber uint64, seqNum int64) *S
 		To:           s.uniswapV3Router,
 		Amount:       amountRaw,
 		Timestamp:    time.Now().Unix(),
+		Symbol:       token.Symbol,
+		Type:         "MEV",
 		Synthesized:  true,
 	}
 }
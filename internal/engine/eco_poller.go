@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultEcoPollSteps 休眠模式下轮询间隔的默认衰减阶梯（基础间隔 → 30s → 2min）
+var DefaultEcoPollSteps = []time.Duration{30 * time.Second, 2 * time.Minute}
+
+// ecoSource 休眠状态来源（Orchestrator 实现）
+type ecoSource interface {
+	GetSnapshot() CoordinatorState
+	WakeSignal() <-chan struct{}
+}
+
+// EcoPoller 随休眠模式衰减的轮询节拍。
+// 活跃时按基础间隔触发；IsEcoMode 期间每次空闲触发后沿阶梯前进一级并停在最后一级；
+// 退出休眠（用户活动 / 手动唤醒）时 WakeSignal 关闭，正在等待的 Wait 立即返回并恢复基础间隔。
+type EcoPoller struct {
+	name  string
+	base  time.Duration
+	steps []time.Duration
+	src   ecoSource
+	level int
+}
+
+// NewEcoPoller 创建轮询节拍；steps 为空时使用 DefaultEcoPollSteps，src 为 nil 时不衰减
+func NewEcoPoller(name string, base time.Duration, steps []time.Duration, src ecoSource) *EcoPoller {
+	if len(steps) == 0 {
+		steps = DefaultEcoPollSteps
+	}
+	return &EcoPoller{name: name, base: base, steps: steps, src: src}
+}
+
+// next 计算下一次等待间隔
+func (p *EcoPoller) next(eco bool) time.Duration {
+	if !eco {
+		p.level = 0
+		return p.base
+	}
+	if p.level < len(p.steps) {
+		p.level++
+	}
+	return p.steps[p.level-1]
+}
+
+// Wait 阻塞到下一次轮询时刻；ctx 结束时返回 false
+func (p *EcoPoller) Wait(ctx context.Context) bool {
+	// 先取唤醒通道再读快照：快照先于通道关闭更新，二者之间的唤醒不会丢失
+	var wake <-chan struct{}
+	eco := false
+	if p.src != nil {
+		wake = p.src.WakeSignal()
+		eco = p.src.GetSnapshot().IsEcoMode
+	}
+	interval := p.next(eco)
+	GetMetrics().SetPollInterval(p.name, interval)
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	case <-wake:
+		p.level = 0
+		return true
+	}
+}
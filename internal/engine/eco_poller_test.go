@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEcoSource 可切换休眠状态的 ecoSource
+type fakeEcoSource struct {
+	mu   sync.Mutex
+	eco  bool
+	wake chan struct{}
+}
+
+func (f *fakeEcoSource) GetSnapshot() CoordinatorState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return CoordinatorState{IsEcoMode: f.eco}
+}
+
+func (f *fakeEcoSource) WakeSignal() <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.wake == nil {
+		f.wake = make(chan struct{})
+	}
+	return f.wake
+}
+
+func (f *fakeEcoSource) setEco(eco bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.eco = eco
+	if !eco && f.wake != nil {
+		close(f.wake)
+		f.wake = nil
+	}
+}
+
+func TestEcoPoller_DecaysAndResets(t *testing.T) {
+	p := NewEcoPoller("test", time.Second, nil, nil)
+	assert.Equal(t, time.Second, p.next(false))
+	assert.Equal(t, 30*time.Second, p.next(true))
+	assert.Equal(t, 2*time.Minute, p.next(true))
+	assert.Equal(t, 2*time.Minute, p.next(true), "stays on the last step")
+	assert.Equal(t, time.Second, p.next(false))
+	assert.Equal(t, 30*time.Second, p.next(true), "decay restarts from the first step")
+}
+
+func TestEcoPoller_WakeInterruptsLongWait(t *testing.T) {
+	src := &fakeEcoSource{eco: true}
+	p := NewEcoPoller("test", 10*time.Millisecond, []time.Duration{time.Hour}, src)
+
+	done := make(chan bool, 1)
+	go func() { done <- p.Wait(context.Background()) }()
+
+	time.Sleep(20 * time.Millisecond)
+	src.setEco(false)
+	select {
+	case ok := <-done:
+		assert.True(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("wake did not interrupt the eco wait")
+	}
+	assert.Equal(t, 0, p.level)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, p.Wait(ctx))
+}
+
+func TestOrchestrator_UserActivityWakesFromEco(t *testing.T) {
+	o := &Orchestrator{broadcastCh: make(chan CoordinatorState, 4)}
+	o.state.IsEcoMode = true
+	o.updateProgressAndSnapshot()
+
+	wake := o.WakeSignal()
+	o.process(Message{Type: CmdRecordUserActivity})
+
+	assert.False(t, o.GetSnapshot().IsEcoMode)
+	select {
+	case <-wake:
+	default:
+		t.Fatal("leaving eco mode did not close the wake signal")
+	}
+	assert.NotEqual(t, wake, o.WakeSignal(), "a fresh channel is handed out after waking")
+}
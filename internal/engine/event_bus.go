@@ -0,0 +1,129 @@
+package engine
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// EventTopic 事件总线的主题类型
+type EventTopic string
+
+const (
+	TopicBlock          EventTopic = "block"
+	TopicTransfer       EventTopic = "transfer"
+	TopicGasLeaderboard EventTopic = "gas_leaderboard"
+	TopicNFTTransfer    EventTopic = "nft_transfer"
+)
+
+// Event 事件总线上传递的消息
+type Event struct {
+	Topic EventTopic
+	Data  interface{}
+}
+
+// eventSubscriber 单个订阅者（独立缓冲区，互不影响）
+type eventSubscriber struct {
+	name   string
+	topics map[EventTopic]bool // 为空表示订阅全部主题
+	ch     chan Event
+}
+
+func (s *eventSubscriber) wants(topic EventTopic) bool {
+	return len(s.topics) == 0 || s.topics[topic]
+}
+
+// EventBus 进程内事件总线
+// 替代单一的 EventHook 回调：每个消费者（WS 推送、指标、Sink）独立订阅，
+// 拥有各自的缓冲区。慢消费者只会丢弃自己的事件，不会阻塞 Processor。
+type EventBus struct {
+	mu      sync.RWMutex
+	subs    []*eventSubscriber
+	closed  bool
+	metrics *Metrics
+}
+
+// NewEventBus 创建事件总线
+func NewEventBus() *EventBus {
+	return &EventBus{
+		metrics: GetMetrics(),
+	}
+}
+
+// Subscribe 注册一个订阅者并返回其事件通道
+// topics 为空时订阅所有主题；buffer 为订阅者独立缓冲区大小
+func (b *EventBus) Subscribe(name string, buffer int, topics ...EventTopic) <-chan Event {
+	if buffer <= 0 {
+		buffer = 1024
+	}
+
+	sub := &eventSubscriber{
+		name:   name,
+		topics: make(map[EventTopic]bool, len(topics)),
+		ch:     make(chan Event, buffer),
+	}
+	for _, t := range topics {
+		sub.topics[t] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(sub.ch)
+		return sub.ch
+	}
+	b.subs = append(b.subs, sub)
+
+	Logger.Info("event_bus_subscribed",
+		slog.String("subscriber", name),
+		slog.Int("buffer", buffer),
+		slog.Int("topics", len(topics)))
+	return sub.ch
+}
+
+// Publish 非阻塞发布事件；订阅者缓冲区满时丢弃并计数
+func (b *EventBus) Publish(topic EventTopic, data interface{}) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+
+	if b.metrics != nil {
+		b.metrics.EventBusPublished.WithLabelValues(string(topic)).Inc()
+	}
+
+	ev := Event{Topic: topic, Data: data}
+	for _, sub := range b.subs {
+		if !sub.wants(topic) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			if b.metrics != nil {
+				b.metrics.EventBusDropped.WithLabelValues(sub.name, string(topic)).Inc()
+			}
+		}
+	}
+}
+
+// HasSubscribers 是否存在订阅者（用于跳过无人消费的事件构造）
+func (b *EventBus) HasSubscribers() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs) > 0
+}
+
+// Close 关闭总线并关闭所有订阅通道
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for _, sub := range b.subs {
+		close(sub.ch)
+	}
+	b.subs = nil
+}
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBus_TopicFilteringAndFanOut(t *testing.T) {
+	bus := NewEventBus()
+	defer bus.Close()
+
+	all := bus.Subscribe("all", 10)
+	transfersOnly := bus.Subscribe("transfers", 10, TopicTransfer)
+
+	bus.Publish(TopicBlock, "b1")
+	bus.Publish(TopicTransfer, "t1")
+
+	assert.Len(t, all, 2)
+	assert.Len(t, transfersOnly, 1)
+
+	ev := <-transfersOnly
+	assert.Equal(t, TopicTransfer, ev.Topic)
+	assert.Equal(t, "t1", ev.Data)
+}
+
+func TestEventBus_SlowSubscriberDropsWithoutBlocking(t *testing.T) {
+	bus := NewEventBus()
+	defer bus.Close()
+
+	slow := bus.Subscribe("slow", 1)
+	fast := bus.Subscribe("fast", 10)
+
+	for i := 0; i < 5; i++ {
+		bus.Publish(TopicBlock, i)
+	}
+
+	// 慢订阅者只保留缓冲区内的事件，快订阅者不受影响
+	assert.Len(t, slow, 1)
+	assert.Len(t, fast, 5)
+}
+
+func TestEventBus_CloseClosesSubscriberChannels(t *testing.T) {
+	bus := NewEventBus()
+	ch := bus.Subscribe("closer", 1)
+	bus.Close()
+
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	// 关闭后发布不应 panic
+	bus.Publish(TopicBlock, nil)
+}
@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"web3-indexer-go/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 事件载荷 schema（models.EventSchemaVersion = 2）：字段名 → JSON 类型。
+// 删除字段或改变类型时必须递增 EventSchemaVersion 并同步更新这里
+var blockEventContract = map[string]string{
+	"number":          "number",
+	"hash":            "string",
+	"parent_hash":     "string",
+	"timestamp":       "number",
+	"tx_count":        "number",
+	"latency_ms":      "number",
+	"latency_display": "string",
+	"latest_chain":    "number",
+	"sync_lag":        "number",
+	"tps":             "number",
+}
+
+var transferEventContract = map[string]string{
+	"tx_hash":           "string",
+	"from":              "string",
+	"to":                "string",
+	"value":             "string",
+	"block_number":      "number",
+	"token_address":     "string",
+	"symbol":            "string",
+	"type":              "string",
+	"log_index":         "number",
+	"decimals":          "number",
+	"amount_normalized": "string",
+}
+
+func assertEventContract(t *testing.T, v interface{}, contract map[string]string) map[string]interface{} {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	require.NoError(t, err)
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	for field, kind := range contract {
+		got, ok := doc[field]
+		if assert.Truef(t, ok, "missing field %q", field) {
+			assert.Equalf(t, kind, jsonKind(got), "field %q", field)
+		}
+	}
+	return doc
+}
+
+func TestEventSchema_BlockEventContract(t *testing.T) {
+	assertEventContract(t, models.BlockEvent{Number: 7, Hash: "0xh", LatencyDisplay: "12ms"}, blockEventContract)
+}
+
+func TestEventSchema_TransferEventContract(t *testing.T) {
+	doc := assertEventContract(t, models.TransferEvent{TxHash: "0xabc", Value: "1000000", BlockNumber: 7}, transferEventContract)
+	_, hasUSD := doc["amount_usd"]
+	assert.False(t, hasUSD, "amount_usd is omitted when the token has no price")
+
+	usd := 1.5
+	doc = assertEventContract(t, models.TransferEvent{AmountUSD: &usd}, transferEventContract)
+	assert.Equal(t, "number", jsonKind(doc["amount_usd"]))
+}
+
+// 旧版本（v1 之前无 schema_version）消费者按字段名读取的载荷仍可解析
+func TestEventSchema_LegacyPayloadDecodes(t *testing.T) {
+	legacy := `{"tx_hash":"0xabc","from":"0x1","to":"0x2","value":"5","block_number":"9","token_address":"0xt","symbol":"USDC","type":"TRANSFER","log_index":2,"decimals":6,"amount_normalized":"0.000005"}`
+	var ev models.TransferEvent
+	require.NoError(t, json.Unmarshal([]byte(legacy), &ev))
+	assert.Equal(t, "USDC", ev.Symbol)
+	assert.Equal(t, uint(2), ev.LogIndex)
+	assert.Equal(t, models.Height(9), ev.BlockNumber, "v1 string heights still decode")
+	assert.Equal(t, uint8(6), ev.Decimals)
+	assert.Nil(t, ev.AmountUSD)
+}
+
+func TestEventSchema_SinkRecordCarriesVersion(t *testing.T) {
+	tr := testTransfers(7, 1)[0]
+	for _, rec := range []SinkRecord{NewTransferRecord(1, tr), NewBlockRecord(1, models.Block{Number: models.BigInt{Int: big.NewInt(7)}, Hash: "0xh"})} {
+		raw, err := json.Marshal(rec)
+		require.NoError(t, err)
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(raw, &doc))
+		assert.Equal(t, float64(models.EventSchemaVersion), doc["schema_version"])
+	}
+}
+
+// Sink 记录：高度为数字，金额为十进制字符串
+func TestEventSchema_SinkRecordEncoding(t *testing.T) {
+	tr := testTransfers(7, 1)[0]
+	raw, err := json.Marshal(NewTransferRecord(1, tr))
+	require.NoError(t, err)
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	data, ok := doc["data"].(map[string]interface{})
+	require.True(t, ok, "record: %s", raw)
+	assert.Equal(t, "number", jsonKind(data["block_number"]))
+	assert.Equal(t, "string", jsonKind(data["amount"]))
+}
@@ -0,0 +1,202 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// EventSignatures 配置的通用事件：topic0 → ABI 事件定义。
+// 抓取时 topic0 并入 eth_getLogs 过滤条件，处理时按 ABI 解码写入 contract_events
+type EventSignatures map[common.Hash]abi.Event
+
+// eventFragmentPattern 人类可读的 ABI 事件片段：[event ]Name(params)
+var eventFragmentPattern = regexp.MustCompile(`^(?:event\s+)?([A-Za-z_$][A-Za-z0-9_$]*)\s*\((.*)\)$`)
+
+// ParseEventSignatures 解析 EVENT_SIGNATURES 条目，格式为 [topic0=]ABI 片段，
+// 如 Swap(address indexed sender, uint256 amount0In, uint256 amount1In)。
+// topic0 由片段的规范签名计算；显式给出时须与之一致（防止类型写错而静默抓不到日志）。
+// 仅支持基础类型及其数组（不支持 tuple）与非匿名事件
+func ParseEventSignatures(specs []string) (EventSignatures, error) {
+	sigs := make(EventSignatures, len(specs))
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		fragment, pinned := spec, ""
+		if topic, rest, ok := strings.Cut(spec, "="); ok {
+			pinned, fragment = strings.TrimSpace(topic), strings.TrimSpace(rest)
+		}
+		event, err := parseEventFragment(fragment)
+		if err != nil {
+			return nil, fmt.Errorf("invalid event signature %q: %w", spec, err)
+		}
+		if pinned != "" && !strings.EqualFold(pinned, event.ID.Hex()) {
+			return nil, fmt.Errorf("invalid event signature %q: topic0 of %s is %s", spec, event.Sig, event.ID.Hex())
+		}
+		if prev, dup := sigs[event.ID]; dup && prev.String() != event.String() {
+			return nil, fmt.Errorf("event signature %s declared twice with different parameters", event.Sig)
+		}
+		sigs[event.ID] = event
+	}
+	return sigs, nil
+}
+
+// parseEventFragment 解析单个事件片段；每个参数为 "type [indexed] [name]"
+func parseEventFragment(fragment string) (abi.Event, error) {
+	m := eventFragmentPattern.FindStringSubmatch(fragment)
+	if m == nil {
+		return abi.Event{}, fmt.Errorf("want Name(type [indexed] [name], ...)")
+	}
+	name, params := m[1], strings.TrimSpace(m[2])
+	if strings.ContainsAny(params, "()") {
+		return abi.Event{}, fmt.Errorf("tuple parameters are not supported")
+	}
+	var inputs abi.Arguments
+	if params != "" {
+		for i, param := range strings.Split(params, ",") {
+			fields := strings.Fields(param)
+			if len(fields) == 0 || len(fields) > 3 {
+				return abi.Event{}, fmt.Errorf("parameter %d: want type [indexed] [name]", i)
+			}
+			typ, err := abi.NewType(fields[0], "", nil)
+			if err != nil {
+				return abi.Event{}, fmt.Errorf("parameter %d: %w", i, err)
+			}
+			arg := abi.Argument{Type: typ}
+			rest := fields[1:]
+			if len(rest) > 0 && rest[0] == "indexed" {
+				arg.Indexed, rest = true, rest[1:]
+			}
+			switch {
+			case len(rest) > 1, len(rest) == 1 && rest[0] == "indexed":
+				return abi.Event{}, fmt.Errorf("parameter %d: want type [indexed] [name]", i)
+			case len(rest) == 1:
+				arg.Name = rest[0]
+			}
+			inputs = append(inputs, arg)
+		}
+	}
+	if indexed := len(inputs) - len(inputs.NonIndexed()); indexed > 3 {
+		return abi.Event{}, fmt.Errorf("%d indexed parameters, at most 3 allowed", indexed)
+	}
+	return abi.NewEvent(name, name, false, inputs), nil
+}
+
+// Hashes 返回全部 topic0（排序，保证过滤条件稳定）
+func (s EventSignatures) Hashes() []common.Hash {
+	hashes := make([]common.Hash, 0, len(s))
+	for h := range s {
+		hashes = append(hashes, h)
+	}
+	slices.SortFunc(hashes, func(a, b common.Hash) int { return a.Cmp(b) })
+	return hashes
+}
+
+// decode 按配置的 ABI 解码日志；topic0 未配置或布局不符（同 topic0 但 indexed 划分不同的合约）时返回 false
+func (s EventSignatures) decode(vLog types.Log) (storage.ContractEventRow, bool) {
+	if len(vLog.Topics) == 0 {
+		return storage.ContractEventRow{}, false
+	}
+	event, ok := s[vLog.Topics[0]]
+	if !ok {
+		return storage.ContractEventRow{}, false
+	}
+	var indexed abi.Arguments
+	for _, arg := range event.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	values := make(map[string]any, len(event.Inputs))
+	if len(vLog.Topics)-1 != len(indexed) || abi.ParseTopicsIntoMap(values, indexed, vLog.Topics[1:]) != nil {
+		return storage.ContractEventRow{}, false
+	}
+	if err := event.Inputs.UnpackIntoMap(values, vLog.Data); err != nil {
+		return storage.ContractEventRow{}, false
+	}
+	args := make(map[string]any, len(values))
+	for k, v := range values {
+		args[k] = jsonArg(v)
+	}
+	raw, err := json.Marshal(args)
+	if err != nil {
+		return storage.ContractEventRow{}, false
+	}
+	return storage.ContractEventRow{
+		BlockNumber:     models.Height(vLog.BlockNumber),
+		LogIndex:        vLog.Index,
+		TxHash:          vLog.TxHash.Hex(),
+		ContractAddress: strings.ToLower(vLog.Address.Hex()),
+		EventName:       event.RawName,
+		Topic0:          event.ID.Hex(),
+		Args:            raw,
+	}, true
+}
+
+// jsonArg 把 ABI 解码值转换为 JSON 友好的形式：整数一律为十进制字符串（避免 JS 精度丢失），
+// 地址、哈希与字节为小写十六进制，数组逐项转换
+func jsonArg(v any) any {
+	switch x := v.(type) {
+	case *big.Int:
+		return x.String()
+	case common.Address:
+		return strings.ToLower(x.Hex())
+	case common.Hash:
+		return x.Hex()
+	case []byte:
+		return hexutil.Encode(x)
+	case bool, string:
+		return x
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprint(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprint(rv.Uint())
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 { // bytesN
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return hexutil.Encode(b)
+		}
+		fallthrough
+	case reflect.Slice:
+		out := make([]any, rv.Len())
+		for i := range out {
+			out[i] = jsonArg(rv.Index(i).Interface())
+		}
+		return out
+	}
+	return fmt.Sprint(v)
+}
+
+// extractContractEvents 解码配置了事件签名的日志
+func (p *Processor) extractContractEvents(logs []types.Log) []storage.ContractEventRow {
+	if len(p.eventSignatures) == 0 {
+		return nil
+	}
+	var rows []storage.ContractEventRow
+	for _, vLog := range logs {
+		row, ok := p.eventSignatures.decode(vLog)
+		if !ok {
+			continue
+		}
+		rows = append(rows, row)
+		if p.metrics != nil && p.metrics.ContractEvents != nil {
+			p.metrics.ContractEvents.WithLabelValues(row.EventName).Inc()
+		}
+	}
+	return rows
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEventSignatures(t *testing.T) {
+	swapV2 := "Swap(address indexed sender, uint256 amount0In, uint256 amount1In, uint256 amount0Out, uint256 amount1Out, address indexed to)"
+	sigs, err := ParseEventSignatures([]string{
+		SwapV2EventHash.Hex() + "=" + swapV2,
+		"event Paused(address)",
+		"Ping()",
+	})
+	require.NoError(t, err)
+	require.Len(t, sigs, 3)
+	assert.Equal(t, "Swap(address,uint256,uint256,uint256,uint256,address)", sigs[SwapV2EventHash].Sig)
+	assert.Contains(t, sigs, crypto.Keccak256Hash([]byte("Paused(address)")))
+	assert.Equal(t, sigs.Hashes()[0].Cmp(sigs.Hashes()[1]), -1, "hashes are sorted")
+
+	for _, spec := range []string{
+		"Swap",
+		"Swap(address indexed sender",
+		"Swap(notatype x)",
+		"Swap(address indexed a, address indexed b, address indexed c, address indexed d)",
+		"Swap(address one two)",
+		SwapEventHash.Hex() + "=" + swapV2,
+		"Swap((address,uint256) pair)",
+	} {
+		_, err := ParseEventSignatures([]string{spec})
+		assert.Error(t, err, spec)
+	}
+	_, err = ParseEventSignatures([]string{"Moved(address indexed who)", "Moved(address who)"})
+	assert.Error(t, err, "one topic0 cannot carry two layouts")
+}
+
+func TestExtractContractEvents(t *testing.T) {
+	sigs, err := ParseEventSignatures([]string{
+		"Tagged(address indexed account, string tag, uint64 count, bytes32 id, uint256[] amounts)",
+	})
+	require.NoError(t, err)
+	p := &Processor{metrics: GetMetrics()}
+	p.SetEventSignatures(sigs)
+
+	topic := sigs.Hashes()[0]
+	account, contract := testkit.Address("acct", 1), testkit.Address("registry", 1)
+	id := common.HexToHash("0xabc")
+	data, err := sigs[topic].Inputs.NonIndexed().Pack("vip", uint64(7), [32]byte(id), []*big.Int{big.NewInt(1), new(big.Int).Lsh(big.NewInt(1), 70)})
+	require.NoError(t, err)
+
+	logs := []types.Log{
+		{Address: contract, BlockNumber: 9, Index: 3, Topics: []common.Hash{topic, common.BytesToHash(account.Bytes())}, Data: data},
+		{Address: contract, BlockNumber: 9, Index: 4, Topics: []common.Hash{topic}, Data: data}, // indexed 划分不同
+		{Address: contract, BlockNumber: 9, Index: 5, Topics: []common.Hash{TransferEventHash}},
+	}
+	rows := p.extractContractEvents(logs)
+	require.Len(t, rows, 1)
+	row := rows[0]
+	assert.Equal(t, models.Height(9), row.BlockNumber)
+	assert.Equal(t, uint(3), row.LogIndex)
+	assert.Equal(t, "Tagged", row.EventName)
+	assert.Equal(t, topic.Hex(), row.Topic0)
+
+	var args map[string]any
+	require.NoError(t, json.Unmarshal(row.Args, &args))
+	assert.Equal(t, map[string]any{
+		"account": strings.ToLower(account.Hex()),
+		"tag":     "vip",
+		"count":   "7",
+		"id":      id.Hex(),
+		"amounts": []any{"1", "1180591620717411303424"},
+	}, args)
+
+	assert.Nil(t, (&Processor{}).extractContractEvents(logs), "no signatures configured")
+}
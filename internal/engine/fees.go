@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"math/big"
+	"sort"
+
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// FeePercentileStep 落库的小费分位步长：0, 5, …, 100 共 21 个点，查询任意分位时在相邻点间插值
+const FeePercentileStep = 5
+
+// feeRewardPoints 每块落库的小费分位点数
+const feeRewardPoints = 100/FeePercentileStep + 1
+
+// txReward 单笔交易的小费（effectiveGasPrice − baseFee）与计权 gas
+type txReward struct {
+	reward *big.Int
+	gas    uint64
+}
+
+// buildBlockFeeRow 计算区块的 EIP-1559 费用统计；London 之前的区块（无 baseFee）返回 nil。
+// 小费分位与 eth_feeHistory 一致：按小费升序累计 gas，取累计 gas 首次达到 p% 的交易的小费。
+// 有回执时以实际 gasUsed 计权，否则以 Gas Limit 估算（Estimated=true）
+func buildBlockFeeRow(block *types.Block, receipts []*types.Receipt) *storage.BlockFeeRow {
+	baseFee := block.BaseFee()
+	if baseFee == nil {
+		return nil
+	}
+	byTx := make(map[common.Hash]*types.Receipt, len(receipts))
+	for _, r := range receipts {
+		if r != nil {
+			byTx[r.TxHash] = r
+		}
+	}
+
+	row := &storage.BlockFeeRow{
+		Block:        block.NumberU64(),
+		BurntFees:    new(big.Int).Mul(baseFee, new(big.Int).SetUint64(block.GasUsed())),
+		PriorityFees: new(big.Int),
+		Rewards:      make([]*big.Int, feeRewardPoints),
+	}
+	rewards := make([]txReward, 0, len(block.Transactions()))
+	var totalGas uint64
+	for _, tx := range block.Transactions() {
+		gas, price, estimated := txGasSpend(tx, byTx[tx.Hash()], baseFee)
+		row.Estimated = row.Estimated || estimated
+		reward := new(big.Int).Sub(price, baseFee)
+		if reward.Sign() < 0 {
+			reward.SetInt64(0) // 系统交易（L2 deposit 等）gasPrice 为 0
+		}
+		row.PriorityFees.Add(row.PriorityFees, new(big.Int).Mul(reward, new(big.Int).SetUint64(gas)))
+		rewards = append(rewards, txReward{reward: reward, gas: gas})
+		totalGas += gas
+	}
+	sort.SliceStable(rewards, func(i, j int) bool { return rewards[i].reward.Cmp(rewards[j].reward) < 0 })
+
+	idx, sumGas := 0, uint64(0)
+	if len(rewards) > 0 {
+		sumGas = rewards[0].gas
+	}
+	for i := range row.Rewards {
+		if len(rewards) == 0 {
+			row.Rewards[i] = new(big.Int)
+			continue
+		}
+		threshold := totalGas * uint64(i*FeePercentileStep) / 100 // #nosec G115 - i 为非负分位序号
+		for sumGas < threshold && idx < len(rewards)-1 {
+			idx++
+			sumGas += rewards[idx].gas
+		}
+		row.Rewards[i] = rewards[idx].reward
+	}
+	return row
+}
+
+// recordFeeStats 更新 EIP-1559 费用指标；London 之前的区块（row 为 nil）不计
+func (p *Processor) recordFeeStats(block *types.Block, row *storage.BlockFeeRow) {
+	if p.metrics == nil || row == nil {
+		return
+	}
+	p.metrics.RecordBlockFees(block.BaseFee(), row.Rewards[50/FeePercentileStep], row.BurntFees)
+}
+
+// InterpolateReward 按落库的 0, 5, …, 100 分位小费求任意分位 p（0–100）的值，相邻点间线性插值
+func InterpolateReward(points []*big.Int, p float64) *big.Int {
+	if len(points) == 0 {
+		return new(big.Int)
+	}
+	pos := p / FeePercentileStep
+	lo := int(pos)
+	if lo >= len(points)-1 {
+		return new(big.Int).Set(points[len(points)-1])
+	}
+	if lo < 0 {
+		return new(big.Int).Set(points[0])
+	}
+	frac := pos - float64(lo)
+	if frac == 0 {
+		return new(big.Int).Set(points[lo])
+	}
+	diff := new(big.Float).SetInt(new(big.Int).Sub(points[lo+1], points[lo]))
+	step, _ := diff.Mul(diff, big.NewFloat(frac)).Int(nil)
+	return step.Add(step, points[lo])
+}
+
+// NextBaseFee 按 EIP-1559 规则（弹性系数 2，调整分母 8）由父块推算下一块的 baseFee
+func NextBaseFee(baseFee *big.Int, gasUsed, gasLimit uint64) *big.Int {
+	const elasticity, denominator = 2, 8
+	if baseFee == nil {
+		return nil
+	}
+	target := gasLimit / elasticity
+	if target == 0 || gasUsed == target {
+		return new(big.Int).Set(baseFee)
+	}
+	if gasUsed > target {
+		delta := new(big.Int).Mul(baseFee, new(big.Int).SetUint64(gasUsed-target))
+		delta.Div(delta, new(big.Int).SetUint64(target))
+		delta.Div(delta, big.NewInt(denominator))
+		if delta.Sign() == 0 {
+			delta.SetInt64(1)
+		}
+		return delta.Add(delta, baseFee)
+	}
+	delta := new(big.Int).Mul(baseFee, new(big.Int).SetUint64(target-gasUsed))
+	delta.Div(delta, new(big.Int).SetUint64(target))
+	delta.Div(delta, big.NewInt(denominator))
+	next := new(big.Int).Sub(baseFee, delta)
+	if next.Sign() < 0 {
+		next.SetInt64(0)
+	}
+	return next
+}
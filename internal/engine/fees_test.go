@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildBlockFeeRow(t *testing.T) {
+	to := testkit.Address("dex", 1)
+	tip1 := types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(1), Nonce: 1, To: &to, Gas: 50_000, GasFeeCap: big.NewInt(30), GasTipCap: big.NewInt(1)})
+	tip5 := types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(1), Nonce: 2, To: &to, Gas: 90_000, GasFeeCap: big.NewInt(30), GasTipCap: big.NewInt(5)})
+	legacy := types.NewTx(&types.LegacyTx{Nonce: 3, To: &to, Gas: 120_000, GasPrice: big.NewInt(12)})
+
+	h := testkit.Header(30, common.Hash{})
+	h.BaseFee, h.GasUsed = big.NewInt(10), 200_000
+	block := types.NewBlockWithHeader(h).WithBody(types.Body{Transactions: []*types.Transaction{tip5, legacy, tip1}})
+	receipts := []*types.Receipt{
+		{TxHash: tip1.Hash(), GasUsed: 21_000, EffectiveGasPrice: big.NewInt(11)},
+		{TxHash: tip5.Hash(), GasUsed: 79_000, EffectiveGasPrice: big.NewInt(15)},
+		{TxHash: legacy.Hash(), GasUsed: 100_000, EffectiveGasPrice: big.NewInt(12)},
+	}
+
+	row := buildBlockFeeRow(block, receipts)
+	require.NotNil(t, row)
+	assert.Equal(t, uint64(30), row.Block)
+	assert.False(t, row.Estimated)
+	assert.Equal(t, big.NewInt(2_000_000), row.BurntFees, "baseFee * gasUsed")
+	assert.Equal(t, big.NewInt(1*21_000+2*100_000+5*79_000), row.PriorityFees)
+
+	// 按小费升序累计 gas：小费 1 覆盖 0–10.5%，2 覆盖到 60.5%，其余为 5
+	require.Len(t, row.Rewards, feeRewardPoints)
+	reward := func(p int) int64 { return row.Rewards[p/FeePercentileStep].Int64() }
+	assert.Equal(t, int64(1), reward(0))
+	assert.Equal(t, int64(1), reward(10))
+	assert.Equal(t, int64(2), reward(15))
+	assert.Equal(t, int64(2), reward(60))
+	assert.Equal(t, int64(5), reward(65))
+	assert.Equal(t, int64(5), reward(100))
+
+	assert.Equal(t, int64(3), InterpolateReward(row.Rewards, 62.5).Int64())
+	assert.Equal(t, int64(2), InterpolateReward(row.Rewards, 50).Int64())
+	assert.Equal(t, int64(5), InterpolateReward(row.Rewards, 100).Int64())
+
+	estimated := buildBlockFeeRow(block, nil)
+	require.NotNil(t, estimated)
+	assert.True(t, estimated.Estimated, "without receipts rewards are weighted by gas limit")
+
+	empty := buildBlockFeeRow(testkit.Block(31, common.Hash{}), nil)
+	require.NotNil(t, empty)
+	assert.Zero(t, empty.Rewards[len(empty.Rewards)-1].Sign(), "empty blocks report zero rewards")
+
+	preLondon := testkit.Header(1, common.Hash{})
+	preLondon.BaseFee = nil
+	assert.Nil(t, buildBlockFeeRow(types.NewBlockWithHeader(preLondon), nil))
+}
+
+func TestNextBaseFee(t *testing.T) {
+	base := big.NewInt(1000)
+	assert.Equal(t, big.NewInt(1125), NextBaseFee(base, 30_000_000, 30_000_000), "full block: +12.5%")
+	assert.Equal(t, big.NewInt(875), NextBaseFee(base, 0, 30_000_000), "empty block: -12.5%")
+	assert.Equal(t, big.NewInt(1000), NextBaseFee(base, 15_000_000, 30_000_000), "on target: unchanged")
+	assert.Nil(t, NextBaseFee(nil, 0, 30_000_000))
+}
@@ -9,14 +9,15 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
 // fetchRangeWithLogs fetches logs for a range of blocks and processes them.
-// If no logs are found, it fetches the header of the latest block in range to update progress.
-func (f *Fetcher) fetchRangeWithLogs(ctx context.Context, start, end *big.Int) {
+// Every block in the range is fetched in full (block body, receipts and traces) and checked for integrity.
+// It returns the number of blocks delivered to Results without error and the last error seen.
+func (f *Fetcher) fetchRangeWithLogs(ctx context.Context, start, end *big.Int) (sent int, lastErr error) {
 	startTime := time.Now()
+	epoch := f.resets.tag()
 
 	GetOrchestrator().DispatchLog("DEBUG", "🌀 Fetcher: Starting block range", "from", start.String(), "to", end.String())
 
@@ -26,14 +27,15 @@ func (f *Fetcher) fetchRangeWithLogs(ctx context.Context, start, end *big.Int) {
 		ToBlock:   end,
 	}
 
-	if len(f.watchedAddresses) > 0 {
-		filterQuery.Addresses = f.watchedAddresses
-		// For specific addresses, we still filter by Transfer event to save RPC weight
-		filterQuery.Topics = [][]common.Hash{{TransferEventHash}}
+	watched := f.watched()
+	if len(watched) > 0 {
+		filterQuery.Addresses = watched
+		// For specific addresses, we still filter by Transfer/Approval (and configured equivalents) to save RPC weight
+		filterQuery.Topics = f.topicFilter()
 		Logger.Debug("🔍 Fetching logs with address filter",
 			slog.String("from", start.String()),
 			slog.String("to", end.String()),
-			slog.Int("watched_count", len(f.watchedAddresses)))
+			slog.Int("watched_count", len(watched)))
 	} else {
 		// 🚀 Industrial Grade: Unfiltered mode captures EVERYTHING
 		// No Topics = No Filter = All contract events captured
@@ -44,14 +46,14 @@ func (f *Fetcher) fetchRangeWithLogs(ctx context.Context, start, end *big.Int) {
 	}
 
 	var logs []types.Log
+	var provider string
 	var err error
 
 	// 🚀 [Elegant Retry] for FilterLogs
 	for retries := 0; retries < 5; retries++ {
-		// 🛡️ 5600U 保护：增加硬超时，防止网络层挂起导致整个 Jobs 队列堵死
-		reqCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
-		logs, err = f.pool.FilterLogs(reqCtx, filterQuery)
-		cancel()
+		// 🛡️ 5600U 保护：每次请求硬超时，防止网络层挂起导致整个 Jobs 队列堵死；
+		// 提供方因结果数 / 跨度上限拒绝时自动二分区间
+		logs, provider, err = f.filterLogsAdaptive(ctx, filterQuery)
 
 		if err == nil {
 			GetOrchestrator().Dispatch(CmdFetchSuccess, nil)
@@ -68,27 +70,30 @@ func (f *Fetcher) fetchRangeWithLogs(ctx context.Context, start, end *big.Int) {
 			case <-time.After(backoff):
 				continue
 			case <-ctx.Done():
-				return
+				return 0, ctx.Err()
 			}
 		}
 		break // Other errors handled by normal flow
 	}
 
 	if err != nil {
+		f.tracker.recordError(start.Uint64(), end.Uint64(), err)
+		traceID := pipelineTrace.begin(start.Uint64())
+		pipelineTrace.record(traceID, start.Uint64(), TraceStageFetchFailed, 0, fmt.Sprintf("range %s-%s: %v", start, end, err))
 		// Log error and send results back
 		select {
-		case f.Results <- BlockData{Number: start, RangeEnd: end, Err: err}:
+		case f.Results <- BlockData{Number: start, RangeEnd: end, Err: err, TraceID: traceID, epoch: epoch}:
 		case <-ctx.Done():
 		case <-f.stopCh:
 		}
-		return
+		return 0, err
 	}
 
 	Logger.Debug("📊 RPC response received",
 		slog.String("from", start.String()),
 		slog.String("to", end.String()),
 		slog.Int("logs_found", len(logs)),
-		slog.Int("watched_addresses", len(f.watchedAddresses)))
+		slog.Int("watched_addresses", len(watched)))
 
 	GetOrchestrator().DispatchLog("INFO", "📡 RPC response received",
 		"range", fmt.Sprintf("%s-%s", start.String(), end.String()),
@@ -100,56 +105,72 @@ func (f *Fetcher) fetchRangeWithLogs(ctx context.Context, start, end *big.Int) {
 		logsByBlock[vLog.BlockNumber] = append(logsByBlock[vLog.BlockNumber], vLog)
 	}
 
+	// 🔍 抽样回执交叉校验：检测提供方丢失日志的情况并就地修正
+	f.verifySampledLogs(ctx, start, end, filterQuery, logsByBlock, provider)
+
 	// Step 3 & 4: Sequential Reporting (The Serpentine Ingestion)
 	// We MUST report every block in chronological order to prevent Sequencer bursts.
 	for i := new(big.Int).Set(start); i.Cmp(end) <= 0; i.Add(i, big.NewInt(1)) {
 		bn := new(big.Int).Set(i)
 		blockLogs := logsByBlock[bn.Uint64()]
 
+		// 每个区块都拉取完整区块：区块行、回执（receipts / block_fees）与 Gas 分析都需要它，
+		// Sequencer 不再自行补抓，所有区块统一经过下面的完整性校验
 		var block *types.Block
 		var err error
-
-		// Only fetch full block if it has logs or it's the range end (to update UI time)
-		if len(blockLogs) > 0 || bn.Cmp(end) == 0 {
-			// 🚀 [Elegant Retry] for BlockByNumber
-			for retries := 0; retries < 5; retries++ {
-				// 🛡️ 5600U 保护：增加硬超时，防止网络层挂起导致消费端死锁
-				reqCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
-				block, err = f.pool.BlockByNumber(reqCtx, bn)
-				cancel()
-
-				if err == nil {
-					GetOrchestrator().Dispatch(CmdFetchSuccess, nil)
-					break
-				}
-
-				if isNotFound(err) {
-					backoff := time.Duration(50*(1<<uint(retries))) * time.Millisecond
-					slog.Debug("⏳ [Fetcher] BlockByNumber not found, retrying...", "block", bn, "backoff", backoff)
-					GetOrchestrator().Dispatch(CmdFetchFailed, "not_found")
-					select {
-					case <-time.After(backoff):
-						continue
-					case <-ctx.Done():
-						return
-					}
-				}
+		// 🚀 [Elegant Retry] for BlockByNumber
+		for retries := 0; retries < 5; retries++ {
+			// 🛡️ 5600U 保护：增加硬超时，防止网络层挂起导致消费端死锁
+			reqCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+			block, err = f.pool.BlockByNumber(reqCtx, bn)
+			cancel()
+
+			if err == nil {
+				GetOrchestrator().Dispatch(CmdFetchSuccess, nil)
 				break
 			}
 
-			if err != nil {
-				slog.Warn("⚠️ [FETCHER] Block fetch failed after retries", "block", bn, "err", err)
+			if isNotFound(err) {
+				backoff := time.Duration(50*(1<<uint(retries))) * time.Millisecond
+				slog.Debug("⏳ [Fetcher] BlockByNumber not found, retrying...", "block", bn, "backoff", backoff)
+				GetOrchestrator().Dispatch(CmdFetchFailed, "not_found")
+				select {
+				case <-time.After(backoff):
+					continue
+				case <-ctx.Done():
+					return sent, ctx.Err()
+				}
 			}
+			break
+		}
+
+		// 🧬 完整性校验：区块号与日志区块哈希必须对应请求的区块，不一致则单块重抓
+		if err == nil {
+			block, blockLogs, err = f.ensureBlockIntegrity(ctx, bn, filterQuery, block, blockLogs)
 		}
 
-		if !f.sendResult(ctx, BlockData{
+		data := BlockData{
 			Number:   bn,
 			RangeEnd: end,
 			Block:    block,
 			Logs:     blockLogs,
 			Err:      err,
-		}) {
-			return // ctx cancelled or stopped — abort remaining blocks in this job
+			TraceID:  pipelineTrace.begin(bn.Uint64()),
+			epoch:    epoch,
+		}
+		if err == nil {
+			data.Receipts = f.fetchBlockReceipts(ctx, block)
+			data.Traces = f.fetchBlockTraces(ctx, bn)
+		}
+		if err != nil {
+			slog.Warn("⚠️ [FETCHER] Block fetch failed after retries", "block", bn, "trace_id", data.TraceID, "err", err)
+			lastErr = err
+		}
+		if !f.sendResult(ctx, data) {
+			return sent, errFetcherAborted // ctx cancelled or stopped — abort remaining blocks in this job
+		}
+		if err == nil {
+			sent++
 		}
 
 		// 🚀 🔥 新增：影子进度更新 (用于 UI 先行跳动)
@@ -159,6 +180,7 @@ func (f *Fetcher) fetchRangeWithLogs(ctx context.Context, start, end *big.Int) {
 	if f.metrics != nil {
 		f.metrics.RecordFetcherJobCompleted(time.Since(startTime))
 	}
+	return sent, lastErr
 }
 
 func isNotFound(err error) bool {
@@ -195,9 +217,17 @@ func (f *Fetcher) fetchHeaderWithRetry(ctx context.Context, bn *big.Int) (*types
 }
 
 // sendResult sends a BlockData to the Results channel.
-// Returns true if the data was sent, false if ctx/stop fired.
-// It NEVER drops data silently — dropping causes Sequencer gaps and deadlocks.
+// Returns true if the data was sent (or discarded as stale after a Reset), false if ctx/stop fired.
+// It NEVER drops current data silently — dropping causes Sequencer gaps and deadlocks.
 func (f *Fetcher) sendResult(ctx context.Context, data BlockData) bool {
+	ensureTraceID(&data)
+
+	// 🔄 Reset 前开始的抓取：重置起点之后的结果属于旧分叉，丢弃且不标记完成（仍返回 true，继续交付其余区块）
+	if f.isStaleResult(data) {
+		traceBlockData(data, TraceStageFetchFailed, "fetcher reset: stale epoch")
+		return true
+	}
+
 	// 💾 录制原始数据：直接录制完整的 BlockData 对象，方便未来 100% 还原回放
 	if f.recorder != nil && data.Err == nil {
 		f.recorder.Record("block_data", data)
@@ -228,6 +258,13 @@ func (f *Fetcher) sendResult(ctx context.Context, data BlockData) bool {
 	// 丢弃会导致 Sequencer expectedBlock 永远等不到该块，形成死锁
 	select {
 	case f.Results <- data:
+		if data.Err == nil && data.Number != nil && data.Number.Sign() >= 0 {
+			f.dedup.complete(data.Number.Uint64(), time.Now())
+			f.tracker.recordFetched(data.Number.Uint64())
+			traceBlockData(data, TraceStageFetched, fmt.Sprintf("logs=%d", len(data.Logs)))
+		} else if data.Err != nil {
+			traceBlockData(data, TraceStageFetchFailed, data.Err.Error())
+		}
 		return true
 	case <-ctx.Done():
 		return false
@@ -247,10 +284,10 @@ func (f *Fetcher) fetchBlockWithLogs(ctx context.Context, bn *big.Int) (*types.B
 	filterQuery := ethereum.FilterQuery{
 		FromBlock: bn,
 		ToBlock:   bn,
-		Topics:    [][]common.Hash{{TransferEventHash}},
+		Topics:    f.topicFilter(),
 	}
-	if len(f.watchedAddresses) > 0 {
-		filterQuery.Addresses = f.watchedAddresses
+	if watched := f.watched(); len(watched) > 0 {
+		filterQuery.Addresses = watched
 	}
 	logs, err := f.pool.FilterLogs(ctx, filterQuery)
 	if err != nil {
@@ -2,6 +2,9 @@ package engine
 
 import (
 	"log"
+	"log/slog"
+	"math/big"
+	"sync"
 
 	"golang.org/x/time/rate"
 )
@@ -63,3 +66,112 @@ func (f *Fetcher) SetRateLimit(rps, burst int) {
 	f.limiter.SetLimit(rate.Limit(rps))
 	f.limiter.SetBurst(burst)
 }
+
+// Reset 在原实例上丢弃 fromBlock 及之后的抓取状态（reorg 后使用），保持 Results 通道与 Sequencer 的连接不变：
+// 清空待执行任务与区间任务，清除 >= fromBlock 的去重标记，丢弃 Results 中 >= fromBlock 的旧分叉数据，然后恢复抓取。
+// 推进 Reset 纪元：重置前已开始的抓取稍后交付的 >= fromBlock 结果由 sendResult 与 Sequencer 丢弃。
+// worker 池不重建；返回丢弃的结果数。调用方随后从 fromBlock 重新调度。
+func (f *Fetcher) Reset(fromBlock *big.Int) int {
+	f.Pause()
+	defer f.Resume()
+
+	if fromBlock.Sign() >= 0 {
+		f.resets.advance(fromBlock.Uint64())
+	}
+	f.ClearJobs()
+	f.InvalidateFrom(fromBlock)
+
+	// 只检查调用时已在通道中的结果，避免与仍在发送的 worker 无限竞争
+	discarded := 0
+	var keep []BlockData
+drain:
+	for pending := len(f.Results); pending > 0; pending-- {
+		select {
+		case data := <-f.Results:
+			if data.Number != nil && data.Number.Cmp(fromBlock) >= 0 {
+				discarded++
+				continue
+			}
+			keep = append(keep, data)
+		default:
+			break drain
+		}
+	}
+	for _, data := range keep {
+		select {
+		case f.Results <- data:
+		default:
+			// 通道已被新结果占满：释放去重标记，交给 gap-fill 重新抓取
+			if data.Number != nil {
+				f.dedup.forget(data.Number.Uint64(), data.Number.Uint64())
+			}
+		}
+	}
+
+	Logger.Info("🔄 [Fetcher] Reset in place",
+		slog.String("from_block", fromBlock.String()),
+		slog.Int("discarded_results", discarded))
+	return discarded
+}
+
+// maxResetHistory 保留的 Reset 记录数；更早纪元开始的抓取结果一律视为过期
+const maxResetHistory = 32
+
+// fetchReset 一次 Reset：把纪元推进到 epoch，起点为 from
+type fetchReset struct {
+	epoch uint64
+	from  uint64
+}
+
+// resetLog 记录 Reset 纪元。抓取开始时取 tag() 写入 BlockData，Reset 之后到达的结果据此判定
+// 是否来自重置前开始、覆盖重置起点之后的抓取（Pause 只阻止新任务，进行中的抓取仍会交付结果）
+type resetLog struct {
+	mu      sync.Mutex
+	epoch   uint64
+	history []fetchReset
+}
+
+// tag 返回当前纪元标记（从 1 开始；0 表示未标记的结果，从不过期）
+func (r *resetLog) tag() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.epoch + 1
+}
+
+// advance 记录一次从 from 开始的 Reset
+func (r *resetLog) advance(from uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.epoch++
+	r.history = append(r.history, fetchReset{epoch: r.epoch, from: from})
+	if len(r.history) > maxResetHistory {
+		r.history = r.history[len(r.history)-maxResetHistory:]
+	}
+}
+
+// stale 判断纪元 tag 下抓取的区块 n 是否已被之后的 Reset 作废
+func (r *resetLog) stale(tag, n uint64) bool {
+	if tag == 0 {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	epoch := tag - 1
+	if epoch == r.epoch {
+		return false
+	}
+	if len(r.history) > 0 && epoch+1 < r.history[0].epoch {
+		return true // 相关的 Reset 记录已淘汰，保守丢弃
+	}
+	for _, reset := range r.history {
+		if reset.epoch > epoch && n >= reset.from {
+			return true
+		}
+	}
+	return false
+}
+
+// isStaleResult 结果是否来自 Reset 前开始、且落在重置起点之后的抓取
+func (f *Fetcher) isStaleResult(data BlockData) bool {
+	return data.Number != nil && data.Number.Sign() >= 0 && f.resets.stale(data.epoch, data.Number.Uint64())
+}
@@ -5,8 +5,10 @@ import (
 	"log/slog"
 	"math/big"
 	"os"
+	"slices"
 	"strconv"
 	"sync"
+	"sync/atomic"
 
 	"web3-indexer-go/internal/limiter"
 
@@ -21,6 +23,10 @@ type BlockData struct {
 	Block    *types.Block
 	Err      error
 	Logs     []types.Log
+	TraceID  string           // 流水线追踪 ID（block-attempt），由 Fetcher 分配
+	epoch    uint64           // 抓取开始时的 Reset 纪元标记（0 表示未标记）
+	Receipts []*types.Receipt // 回执抓取模式下的区块回执（未开启或抓取失败时为空）
+	Traces   []TxCallTrace    // 内部转账模式下的区块调用树（未开启或抓取失败时为空）
 }
 
 type FetchJob struct {
@@ -45,14 +51,39 @@ type Fetcher struct {
 	pauseCond *sync.Cond
 	paused    bool
 
-	// Watched addresses for contract monitoring
+	// Watched addresses for contract monitoring（运行时可由 Watchlist 整体替换，读取走 watched()）
+	watchMu          sync.RWMutex
 	watchedAddresses []common.Address
+	// 监控地址模式下与标准 Transfer 一起抓取的额外 Transfer 等价事件 topic0
+	extraTransferTopics []common.Hash
+	// 监控地址模式下一并抓取的通用事件 topic0（EVENT_SIGNATURES）
+	eventTopics []common.Hash
 
 	headerOnlyMode bool          // 低成本模式：仅获取区块头，不获取Logs
 	recorder       *DataRecorder // 💾 原始数据录制器
 
 	// 🔥 横滨实验室：背压检测
 	sequencer *Sequencer // Sequencer 引用（用于检测 buffer 深度）
+
+	logVerifyRate float64 // FilterLogs 回执交叉校验抽样比例
+	fetchReceipts bool    // 回执抓取模式：为有交易的区块附带 eth_getBlockReceipts 结果
+
+	blockReceiptsUnsupported atomic.Bool // 节点不支持 eth_getBlockReceipts，改为逐笔 eth_getTransactionReceipt
+	traceInternal            atomic.Bool // 内部转账模式：为每个区块附带 debug_traceBlockByNumber 调用树
+
+	dedup   *fetchDedup // 抓取任务去重集合
+	tracker *jobTracker // 调度区间进度（/api/admin/jobs）
+	ranges  *rangeQueue // 区间任务（大范围回填按子区间认领）
+
+	// 运行时扩缩容：每个 worker 持有独立的退出信号
+	workersMu    sync.Mutex
+	workerCtx    context.Context
+	workerWg     *sync.WaitGroup
+	workerQuits  []chan struct{}
+	nextWorkerID int
+	active       atomic.Int32 // 实际运行中的 worker 数（缩容后逐个归零）
+
+	resets resetLog // Reset 纪元：识别重置前开始的抓取交付的旧分叉结果
 }
 
 // 🔥 QueueDepth 返回队列深度（用于上游背压检测）
@@ -80,9 +111,13 @@ func (f *Fetcher) ClearJobs() {
 	count := 0
 	for {
 		select {
-		case <-f.jobs:
+		case job := <-f.jobs:
+			f.dedup.release(job.Start.Uint64(), job.End.Uint64())
 			count++
 		default:
+			if n := f.ranges.cancelAll(); n > 0 {
+				slog.Warn("🌀 [Fetcher] Range jobs cancelled", "cancelled", n)
+			}
 			if count > 0 {
 				slog.Warn("🌀 [Fetcher] Jobs queue purged", "cleared", count)
 			}
@@ -159,16 +194,6 @@ func NewFetcherWithLimiter(pool RPCClient, concurrency, rps, burst int) *Fetcher
 
 	bpsLimiter := rate.NewLimiter(rate.Inf, 0)
 
-	// 💾 初始化录制器 (默认存储路径)
-
-	recorder, err := NewDataRecorder("")
-
-	if err != nil {
-
-		slog.Warn("failed_to_initialize_recorder", "err", err)
-
-	}
-
 	// 🔥 16G RAM 调优：提升至 15,000
 
 	f := &Fetcher{
@@ -187,26 +212,68 @@ func NewFetcherWithLimiter(pool RPCClient, concurrency, rps, burst int) *Fetcher
 
 		bpsLimiter: bpsLimiter,
 
-		recorder: recorder,
-
 		stopCh: make(chan struct{}),
 
 		paused: false,
 
 		metrics: GetMetrics(),
+
+		logVerifyRate: defaultLogVerifyRate,
+
+		dedup: newFetchDedup(),
+
+		tracker: newJobTracker(),
+
+		ranges: newRangeQueue(),
 	}
 	f.pauseCond = sync.NewCond(&f.pauseMu)
 	return f
 }
 
+// EnableCapture 开启原始数据录制（path 为空时写入 logs/ 下按时间戳命名的文件）；需在 Start 之前调用。
+// 默认不录制，测试构造的 Fetcher 不会在工作目录留下录制文件
+func (f *Fetcher) EnableCapture(path string) error {
+	recorder, err := NewDataRecorder(path)
+	if err != nil {
+		return err
+	}
+	f.recorder = recorder
+	return nil
+}
+
 // SetWatchedAddresses sets the contract addresses to monitor for Transfer events
 func (f *Fetcher) SetWatchedAddresses(addresses []string) {
-	f.watchedAddresses = make([]common.Address, 0, len(addresses))
+	watched := make([]common.Address, 0, len(addresses))
 	for _, addr := range addresses {
 		if addr != "" {
-			f.watchedAddresses = append(f.watchedAddresses, common.HexToAddress(addr))
+			watched = append(watched, common.HexToAddress(addr))
 		}
 	}
+	f.watchMu.Lock()
+	f.watchedAddresses = watched
+	f.watchMu.Unlock()
+}
+
+// watched 返回当前监控地址（切片只会被整体替换，调用方只读即可）；为空表示不按地址过滤
+func (f *Fetcher) watched() []common.Address {
+	f.watchMu.RLock()
+	defer f.watchMu.RUnlock()
+	return f.watchedAddresses
+}
+
+// SetExtraTransferTopics 设置额外的 Transfer 等价事件，Transfer 过滤的 eth_getLogs 会一并抓取
+func (f *Fetcher) SetExtraTransferTopics(topics TransferTopics) {
+	f.extraTransferTopics = topics.Hashes()
+}
+
+// SetEventSignatures 设置通用解码的事件签名，监控地址模式下的 eth_getLogs 会一并抓取
+func (f *Fetcher) SetEventSignatures(sigs EventSignatures) {
+	f.eventTopics = sigs.Hashes()
+}
+
+// topicFilter 监控地址模式下 eth_getLogs 的 topic0 过滤条件
+func (f *Fetcher) topicFilter() [][]common.Hash {
+	return logTopicFilter(append(slices.Clone(f.extraTransferTopics), f.eventTopics...))
 }
 
 // SetThroughputLimit updates the target processing speed.
@@ -232,68 +299,126 @@ func (f *Fetcher) SetThroughputLimit(tps float64) {
 }
 
 func (f *Fetcher) Start(ctx context.Context, wg *sync.WaitGroup) {
+	f.workersMu.Lock()
+	defer f.workersMu.Unlock()
 	Logger.Info("📢 [Fetcher] 引擎协程已进入 Start 函数！",
 		slog.Int("concurrency", f.concurrency),
 	)
+	f.workerCtx, f.workerWg = ctx, wg
 	for i := 0; i < f.concurrency; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			Logger.Info("🌀 [Fetcher] 循环抓取协程正式启动...",
-				slog.Int("worker_id", workerID),
-			)
-			f.worker(ctx, wg)
-		}(i)
+		f.spawnWorkerLocked()
 	}
 }
 
-func (f *Fetcher) worker(ctx context.Context, wg *sync.WaitGroup) {
+// spawnWorkerLocked 启动一个 worker；调用方需持有 f.workersMu
+func (f *Fetcher) spawnWorkerLocked() {
+	quit := make(chan struct{})
+	f.workerQuits = append(f.workerQuits, quit)
+	workerID := f.nextWorkerID
+	f.nextWorkerID++
+
+	ctx, wg := f.workerCtx, f.workerWg
+	wg.Add(1)
+	go func() {
+		Logger.Info("🌀 [Fetcher] 循环抓取协程正式启动...",
+			slog.Int("worker_id", workerID),
+		)
+		f.worker(ctx, wg, quit)
+	}()
+}
+
+// worker 优先处理 jobs 通道中的常规任务（追尾同步），空闲时认领区间任务的子区间。
+// quit 关闭后在当前任务完成时退出（Resize 缩容），不会中断进行中的抓取。
+func (f *Fetcher) worker(ctx context.Context, wg *sync.WaitGroup, quit <-chan struct{}) {
 	defer wg.Done()
+	f.metrics.SetFetcherWorkers(int(f.active.Add(1)))
+	defer func() { f.metrics.SetFetcherWorkers(int(f.active.Add(-1))) }()
 
 	for {
 		select {
-		case <-ctx.Done():
-			return
-		case <-f.stopCh:
+		case <-quit:
 			return
+		default:
+		}
+
+		select {
 		case job, ok := <-f.jobs:
-			if !ok {
+			if !ok || !f.runJob(ctx, job) {
 				return
 			}
+			continue
+		default:
+		}
 
-			// 🚀 🔥 新增：工作脉搏，帮助定位为何 Jobs 堵塞
-			slog.Debug("🌀 [Fetcher] Worker picking up job", "start", job.Start.String(), "end", job.End.String())
-
-			// 检查是否暂停（Reorg 处理期间）
-			f.pauseMu.Lock()
-			for f.paused {
-				// 等待恢复信号（使用 Cond.Wait 避免竞态）
-				f.pauseCond.Wait()
+		if c, ok := f.ranges.claim(); ok {
+			if !f.runRangeChunk(ctx, c) {
+				return
 			}
-			f.pauseMu.Unlock()
+			continue
+		}
 
-			// 检查是否已停止（在 unlock 后再检查）
-			select {
-			case <-ctx.Done():
-				return
-			case <-f.stopCh:
+		select {
+		case <-ctx.Done():
+			return
+		case <-f.stopCh:
+			return
+		case <-quit:
+			return
+		case job, ok := <-f.jobs:
+			if !ok || !f.runJob(ctx, job) {
 				return
-			default:
 			}
+		case <-f.ranges.kick:
+		}
+	}
+}
 
-			// 等待速率限制令牌
-			if err := f.limiter.Wait(ctx); err != nil {
-				select {
-				case f.Results <- BlockData{Number: job.Start, RangeEnd: job.End, Err: err}:
-				case <-ctx.Done():
-					return
-				case <-f.stopCh:
-					return
-				}
-				continue
-			}
+// waitIfPaused 在 Reorg 暂停期间阻塞；恢复后若已停止则返回 false
+func (f *Fetcher) waitIfPaused(ctx context.Context) bool {
+	f.pauseMu.Lock()
+	for f.paused {
+		// 等待恢复信号（使用 Cond.Wait 避免竞态）
+		f.pauseCond.Wait()
+	}
+	f.pauseMu.Unlock()
+
+	// 检查是否已停止（在 unlock 后再检查）
+	select {
+	case <-ctx.Done():
+		return false
+	case <-f.stopCh:
+		return false
+	default:
+		return true
+	}
+}
+
+// runJob 执行单个 FetchJob；返回 false 表示 worker 应退出
+func (f *Fetcher) runJob(ctx context.Context, job FetchJob) bool {
+	// 🚀 🔥 新增：工作脉搏，帮助定位为何 Jobs 堵塞
+	slog.Debug("🌀 [Fetcher] Worker picking up job", "start", job.Start.String(), "end", job.End.String())
+
+	// 检查是否暂停（Reorg 处理期间）
+	if !f.waitIfPaused(ctx) {
+		return false
+	}
 
-			// 获取范围区块数据
-			f.fetchRangeWithLogs(ctx, job.Start, job.End)
+	// 等待速率限制令牌
+	if err := f.limiter.Wait(ctx); err != nil {
+		f.dedup.release(job.Start.Uint64(), job.End.Uint64())
+		f.tracker.recordError(job.Start.Uint64(), job.End.Uint64(), err)
+		select {
+		case f.Results <- BlockData{Number: job.Start, RangeEnd: job.End, Err: err}:
+			return true
+		case <-ctx.Done():
+			return false
+		case <-f.stopCh:
+			return false
 		}
 	}
+
+	// 获取范围区块数据；成功发送的区块已在 sendResult 中转入 recently-completed
+	_, _ = f.fetchRangeWithLogs(ctx, job.Start, job.End)
+	f.dedup.release(job.Start.Uint64(), job.End.Uint64())
+	return true
 }
@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const (
+	// recentlyCompletedTTL 已完成区块在去重集合中的保留时间
+	recentlyCompletedTTL = 2 * time.Minute
+	// maxRecentlyCompleted 已完成集合上限，超出时按 TTL 提前清理
+	maxRecentlyCompleted = 50000
+)
+
+// fetchDedup 抓取任务去重集合：in-flight（已入队/抓取中）+ recently-completed（已成功发往 Sequencer）
+// catch-up 调度、gap-fill 自愈与 reorg 重调度重叠时，同一区块只抓取一次
+type fetchDedup struct {
+	mu        sync.Mutex
+	inflight  map[uint64]struct{}
+	completed map[uint64]time.Time
+	ttl       time.Duration
+}
+
+func newFetchDedup() *fetchDedup {
+	return &fetchDedup{
+		inflight:  make(map[uint64]struct{}),
+		completed: make(map[uint64]time.Time),
+		ttl:       recentlyCompletedTTL,
+	}
+}
+
+// claim 对 [start, end] 去重：返回需要实际抓取的连续子区间（并标记为 in-flight）及被丢弃的区块数
+func (d *fetchDedup) claim(start, end uint64, now time.Time) ([][2]uint64, int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var (
+		ranges  [][2]uint64
+		dropped int
+		open    bool
+		from    uint64
+	)
+	for n := start; n <= end; n++ {
+		if d.isDuplicateLocked(n, now) {
+			dropped++
+			if open {
+				ranges = append(ranges, [2]uint64{from, n - 1})
+				open = false
+			}
+		} else {
+			d.inflight[n] = struct{}{}
+			if !open {
+				from = n
+				open = true
+			}
+		}
+		if n == end { // 防止 end == MaxUint64 时溢出死循环
+			break
+		}
+	}
+	if open {
+		ranges = append(ranges, [2]uint64{from, end})
+	}
+	return ranges, dropped
+}
+
+func (d *fetchDedup) isDuplicateLocked(n uint64, now time.Time) bool {
+	if _, ok := d.inflight[n]; ok {
+		return true
+	}
+	if at, ok := d.completed[n]; ok {
+		if now.Sub(at) < d.ttl {
+			return true
+		}
+		delete(d.completed, n)
+	}
+	return false
+}
+
+// complete 区块已成功发往 Sequencer：移出 in-flight，进入 recently-completed
+func (d *fetchDedup) complete(n uint64, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.inflight, n)
+	d.completed[n] = now
+	if len(d.completed) > maxRecentlyCompleted {
+		d.pruneLocked(now)
+	}
+}
+
+// release 释放 [start, end] 的 in-flight 标记（任务结束、失败或被清空时调用）
+func (d *fetchDedup) release(start, end uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for n := start; n <= end; n++ {
+		delete(d.inflight, n)
+		if n == end {
+			break
+		}
+	}
+}
+
+// forget 清除 [start, end] 的 recently-completed 标记，使其可被重新抓取
+func (d *fetchDedup) forget(start, end uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for n := range d.completed {
+		if n >= start && n <= end {
+			delete(d.completed, n)
+		}
+	}
+}
+
+func (d *fetchDedup) pruneLocked(now time.Time) {
+	for n, at := range d.completed {
+		if now.Sub(at) >= d.ttl {
+			delete(d.completed, n)
+		}
+	}
+}
+
+// Reschedule 强制重新抓取 [start, end]：清除 recently-completed 标记后调度（仍跳过 in-flight 区块）
+// 用于 gap-fill、reorg 重抓与死锁自愈——这些区块此前已调度过，因此只截断到最新链高而非 TargetHeight
+func (f *Fetcher) Reschedule(ctx context.Context, start, end *big.Int) error {
+	if start.Sign() >= 0 && end.Sign() >= 0 {
+		f.dedup.forget(start.Uint64(), end.Uint64())
+	}
+	_, err := f.schedule(ctx, start, end, GetOrchestrator().GetSnapshot().LatestHeight)
+	return err
+}
+
+// InvalidateFrom 清除 >= from 的 recently-completed 标记（reorg 回滚后这些区块必须重抓）
+func (f *Fetcher) InvalidateFrom(from *big.Int) {
+	if from.Sign() < 0 {
+		return
+	}
+	f.dedup.forget(from.Uint64(), ^uint64(0))
+	slog.Debug("🌀 [Fetcher] Dedup set invalidated", "from", from.String())
+}
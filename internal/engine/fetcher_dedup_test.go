@@ -0,0 +1,129 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchDedup_DropsInflightAndRecentlyCompleted(t *testing.T) {
+	d := newFetchDedup()
+	now := time.Now()
+
+	ranges, dropped := d.claim(100, 110, now)
+	assert.Equal(t, [][2]uint64{{100, 110}}, ranges)
+	assert.Equal(t, 0, dropped)
+
+	// 与 in-flight 区间重叠：只派发未覆盖的部分
+	ranges, dropped = d.claim(105, 120, now)
+	assert.Equal(t, [][2]uint64{{111, 120}}, ranges)
+	assert.Equal(t, 6, dropped)
+
+	// 105 已完成，其余释放：再次调度时 105 被视为近期完成而跳过
+	d.complete(105, now)
+	d.release(100, 120)
+	ranges, dropped = d.claim(100, 110, now)
+	assert.Equal(t, [][2]uint64{{100, 104}, {106, 110}}, ranges)
+	assert.Equal(t, 1, dropped)
+}
+
+func TestFetchDedup_CompletedExpiresAndForget(t *testing.T) {
+	d := newFetchDedup()
+	now := time.Now()
+
+	d.claim(1, 3, now)
+	for n := uint64(1); n <= 3; n++ {
+		d.complete(n, now)
+	}
+
+	_, dropped := d.claim(1, 3, now.Add(time.Second))
+	assert.Equal(t, 3, dropped)
+
+	// TTL 过期后允许重新抓取
+	ranges, dropped := d.claim(1, 3, now.Add(recentlyCompletedTTL+time.Second))
+	assert.Equal(t, [][2]uint64{{1, 3}}, ranges)
+	assert.Equal(t, 0, dropped)
+
+	// forget 清除已完成标记（gap-fill / reorg 重抓）
+	d.release(1, 3)
+	d.complete(2, now)
+	d.forget(0, 10)
+	ranges, _ = d.claim(1, 3, now)
+	assert.Equal(t, [][2]uint64{{1, 3}}, ranges)
+}
+
+func TestFetcherReset_KeepsResultsChannelAndDropsForkedState(t *testing.T) {
+	f := NewFetcher(&lossyLogClient{}, 1)
+	results := f.Results
+	now := time.Now()
+
+	f.dedup.claim(1, 5, now)
+	f.jobs <- FetchJob{Start: big.NewInt(1), End: big.NewInt(5)}
+	for n := uint64(90); n <= 110; n++ {
+		f.dedup.complete(n, now)
+	}
+	for _, n := range []int64{98, 99, 100, 101, 105} {
+		f.Results <- BlockData{Number: big.NewInt(n)}
+	}
+	f.Pause()
+
+	discarded := f.Reset(big.NewInt(100))
+	assert.Equal(t, 3, discarded)
+	assert.True(t, results == f.Results, "Sequencer wiring must survive a reset")
+	assert.False(t, f.IsPaused())
+	assert.Zero(t, f.QueueDepth())
+
+	var kept []uint64
+	for len(f.Results) > 0 {
+		kept = append(kept, (<-f.Results).Number.Uint64())
+	}
+	assert.Equal(t, []uint64{98, 99}, kept)
+
+	// 排队任务的 in-flight 标记已释放，分叉点之后的已完成标记被清除，之前的保留
+	ranges, dropped := f.dedup.claim(1, 5, now)
+	assert.Equal(t, [][2]uint64{{1, 5}}, ranges)
+	assert.Zero(t, dropped)
+	ranges, dropped = f.dedup.claim(95, 105, now)
+	assert.Equal(t, [][2]uint64{{100, 105}}, ranges)
+	assert.Equal(t, 5, dropped)
+}
+
+func TestFetcherReset_DropsResultsFromInFlightFetches(t *testing.T) {
+	f := NewFetcher(&lossyLogClient{}, 1)
+	ctx := context.Background()
+	before := f.resets.tag() // 重置前开始的抓取
+
+	f.Reset(big.NewInt(100))
+	after := f.resets.tag()
+
+	require.True(t, f.sendResult(ctx, BlockData{Number: big.NewInt(105), epoch: before}))
+	require.True(t, f.sendResult(ctx, BlockData{Number: big.NewInt(99), epoch: before}))
+	require.True(t, f.sendResult(ctx, BlockData{Number: big.NewInt(105), epoch: after}))
+	var delivered []uint64
+	for len(f.Results) > 0 {
+		delivered = append(delivered, (<-f.Results).Number.Uint64())
+	}
+	assert.Equal(t, []uint64{99, 105}, delivered)
+	ranges, _ := f.dedup.claim(105, 105, time.Now().Add(time.Second))
+	assert.Empty(t, ranges, "only the current-epoch delivery marks block 105 complete")
+
+	// 第二次 Reset：每个纪元按其之后全部重置的起点判定
+	f.Reset(big.NewInt(200))
+	assert.True(t, f.isStaleResult(BlockData{Number: big.NewInt(150), epoch: before}))
+	assert.False(t, f.isStaleResult(BlockData{Number: big.NewInt(150), epoch: after}))
+	assert.True(t, f.isStaleResult(BlockData{Number: big.NewInt(250), epoch: after}))
+	assert.False(t, f.isStaleResult(BlockData{Number: big.NewInt(250)}), "untagged results never expire")
+
+	// 发送方在 Reset 清空通道时阻塞、之后才送达的旧结果由 Sequencer 丢弃
+	s := NewSequencerWithFetcher(nil, f, big.NewInt(100), 1, f.Results, make(chan error, 1), nil, nil)
+	kept := s.dropStaleResults([]BlockData{
+		{Number: big.NewInt(150), epoch: after},
+		{Number: big.NewInt(210), epoch: after},
+	})
+	require.Len(t, kept, 1)
+	assert.Equal(t, uint64(150), kept[0].Number.Uint64())
+}
@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// maxIntegrityRefetches 响应不一致时单块重抓的次数上限（之后交给 Sequencer 的失败重试路径）
+const maxIntegrityRefetches = 3
+
+// ErrFetchIntegrity 提供方返回的区块 / 日志与请求不一致
+var ErrFetchIntegrity = errors.New("fetch integrity check failed")
+
+// blockIntegrityIssue 校验响应是否属于请求的区块：区块号一致，且每条日志的区块号与区块哈希都指向该块。
+// 返回不一致原因（一致时为空串）；block 为 nil（未取得区块）时不校验
+func blockIntegrityIssue(bn *big.Int, block *types.Block, logs []types.Log) string {
+	if block == nil {
+		return ""
+	}
+	if block.Number() == nil || block.Number().Cmp(bn) != 0 {
+		return "block_number"
+	}
+	hash := block.Hash()
+	for i := range logs {
+		if logs[i].BlockNumber != bn.Uint64() {
+			return "log_block_number"
+		}
+		if logs[i].BlockHash != hash {
+			return "log_block_hash"
+		}
+	}
+	return ""
+}
+
+// ensureBlockIntegrity 交给 Sequencer 之前校验区块与日志；不一致时单块重抓区块与日志（链头附近的
+// 重组也会表现为日志哈希与区块不符，重抓后两者来自同一分叉）。仍不一致返回 ErrFetchIntegrity
+func (f *Fetcher) ensureBlockIntegrity(ctx context.Context, bn *big.Int, q ethereum.FilterQuery, block *types.Block, logs []types.Log) (*types.Block, []types.Log, error) {
+	issue := blockIntegrityIssue(bn, block, logs)
+	for attempt := 0; issue != ""; attempt++ {
+		f.recordIntegrityRejected(issue)
+		got := ""
+		if block != nil && block.Number() != nil {
+			got = block.Number().String()
+		}
+		Logger.Warn("🧬 [Fetcher] Response does not match requested block, refetching",
+			slog.String("block", bn.String()),
+			slog.String("reason", issue),
+			slog.String("got_number", got),
+			slog.Int("attempt", attempt+1))
+
+		if attempt >= maxIntegrityRefetches {
+			return block, logs, fmt.Errorf("%w: block %s: %s", ErrFetchIntegrity, bn, issue)
+		}
+		select {
+		case <-ctx.Done():
+			return block, logs, ctx.Err()
+		case <-time.After(time.Duration(50*(1<<attempt)) * time.Millisecond):
+		}
+
+		var err error
+		if block, logs, err = f.refetchBlock(ctx, bn, q); err != nil {
+			return block, logs, err
+		}
+		issue = blockIntegrityIssue(bn, block, logs)
+	}
+	return block, logs, nil
+}
+
+// refetchBlock 单块重新抓取区块与日志
+func (f *Fetcher) refetchBlock(ctx context.Context, bn *big.Int, q ethereum.FilterQuery) (*types.Block, []types.Log, error) {
+	single := q
+	single.FromBlock = bn
+	single.ToBlock = bn
+
+	reqCtx, cancel := context.WithTimeout(ctx, filterLogsTimeout)
+	logs, _, err := f.filterLogs(reqCtx, single)
+	cancel()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reqCtx, cancel = context.WithTimeout(ctx, 2*time.Second)
+	block, err := f.pool.BlockByNumber(reqCtx, bn)
+	cancel()
+	if err != nil {
+		return nil, nil, err
+	}
+	return block, logs, nil
+}
+
+// logQuery 区间日志过滤条件：与实时抓取一致，有监控地址时按地址 + 主题过滤，否则不过滤
+func (f *Fetcher) logQuery(start, end *big.Int) ethereum.FilterQuery {
+	q := ethereum.FilterQuery{FromBlock: start, ToBlock: end}
+	if watched := f.watched(); len(watched) > 0 {
+		q.Addresses = watched
+		q.Topics = f.topicFilter()
+	}
+	return q
+}
+
+// FetchVerifiedBlock 单块抓取区块与日志并经过完整性校验，附带回执与 trace；
+// Sequencer 重试抓取失败或校验不通过的区块时使用，与区间抓取走同一条校验路径
+func (f *Fetcher) FetchVerifiedBlock(ctx context.Context, bn *big.Int) (BlockData, error) {
+	q := f.logQuery(bn, bn)
+	block, logs, err := f.refetchBlock(ctx, bn, q)
+	if err != nil {
+		return BlockData{}, err
+	}
+	if block, logs, err = f.ensureBlockIntegrity(ctx, bn, q, block, logs); err != nil {
+		return BlockData{}, err
+	}
+	return BlockData{
+		Number:   bn,
+		Block:    block,
+		Logs:     logs,
+		Receipts: f.fetchBlockReceipts(ctx, block),
+		Traces:   f.fetchBlockTraces(ctx, bn),
+	}, nil
+}
+
+func (f *Fetcher) recordIntegrityRejected(reason string) {
+	if f.metrics == nil || f.metrics.FetchIntegrityRejected == nil {
+		return
+	}
+	f.metrics.FetchIntegrityRejected.WithLabelValues(reason).Inc()
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockIntegrityIssue(t *testing.T) {
+	bn := big.NewInt(10)
+	block := testkit.Block(10, common.Hash{})
+	logs := testkit.TransferLogs(block, testkit.USDC, 2)
+
+	assert.Empty(t, blockIntegrityIssue(bn, block, logs))
+	assert.Empty(t, blockIntegrityIssue(bn, nil, nil), "blocks that were not fetched are not checked")
+	assert.Equal(t, "block_number", blockIntegrityIssue(bn, testkit.Block(11, common.Hash{}), nil))
+
+	_, orphan := testkit.ReorgPair(10, common.Hash{})
+	assert.Equal(t, "log_block_hash", blockIntegrityIssue(bn, orphan, logs))
+
+	stray := append([]types.Log(nil), logs...)
+	stray[1].BlockNumber = 9
+	assert.Equal(t, "log_block_number", blockIntegrityIssue(bn, block, stray))
+}
+
+// flakyBlockClient 前 bad 次 BlockByNumber 返回错误高度的区块
+type flakyBlockClient struct {
+	lossyLogClient
+	bad   int
+	calls int
+	logs  []types.Log
+}
+
+func (c *flakyBlockClient) BlockByNumber(_ context.Context, n *big.Int) (*types.Block, error) {
+	c.calls++
+	if c.calls <= c.bad {
+		return testkit.Block(n.Uint64()+1, common.Hash{}), nil
+	}
+	return testkit.Block(n.Uint64(), common.Hash{}), nil
+}
+
+func (c *flakyBlockClient) FilterLogs(context.Context, ethereum.FilterQuery) ([]types.Log, error) {
+	return c.logs, nil
+}
+
+func TestEnsureBlockIntegrity_RefetchesMismatchedBlock(t *testing.T) {
+	good := testkit.Block(10, common.Hash{})
+	client := &flakyBlockClient{bad: 1, logs: testkit.TransferLogs(good, testkit.USDC, 1)}
+	f := &Fetcher{pool: client, metrics: GetMetrics()}
+	before := testutil.ToFloat64(GetMetrics().FetchIntegrityRejected.WithLabelValues("block_number"))
+
+	wrong, err := client.BlockByNumber(context.Background(), big.NewInt(10))
+	require.NoError(t, err)
+	block, logs, err := f.ensureBlockIntegrity(context.Background(), big.NewInt(10), ethereum.FilterQuery{}, wrong, client.logs)
+	require.NoError(t, err)
+	assert.Equal(t, good.Hash(), block.Hash())
+	assert.Len(t, logs, 1)
+	assert.Equal(t, before+1, testutil.ToFloat64(GetMetrics().FetchIntegrityRejected.WithLabelValues("block_number")))
+}
+
+func TestEnsureBlockIntegrity_GivesUpWithSentinel(t *testing.T) {
+	client := &flakyBlockClient{bad: 1 << 10}
+	f := &Fetcher{pool: client}
+	wrong := testkit.Block(11, common.Hash{})
+	_, _, err := f.ensureBlockIntegrity(context.Background(), big.NewInt(10), ethereum.FilterQuery{}, wrong, nil)
+	require.ErrorIs(t, err, ErrFetchIntegrity)
+	assert.Equal(t, maxIntegrityRefetches, client.calls)
+}
@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxFinishedScheduleRanges 已完成（全部落盘）的调度区间保留条数，便于排查最近的补洞/回填
+	maxFinishedScheduleRanges = 20
+	// maxTrackedScheduleRanges 跟踪的调度区间总上限，防止异常情况下无限增长
+	maxTrackedScheduleRanges = 500
+)
+
+// 调度来源标签
+const (
+	ScheduleSourceUnknown    = "unknown"
+	ScheduleSourceTailFollow = "tail_follow"
+	ScheduleSourceCatchUp    = "catch_up"
+	ScheduleSourceGapFill    = "gap_fill"
+	ScheduleSourceWatchdog   = "watchdog"
+)
+
+type scheduleSourceKey struct{}
+
+// WithScheduleSource 在 ctx 中标记调度来源，供 /api/admin/jobs 区分持续调度与补洞任务
+func WithScheduleSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, scheduleSourceKey{}, source)
+}
+
+func scheduleSourceFrom(ctx context.Context) string {
+	if s, ok := ctx.Value(scheduleSourceKey{}).(string); ok && s != "" {
+		return s
+	}
+	return ScheduleSourceUnknown
+}
+
+// ScheduleRange 一次 Schedule 调用对应的区间及其进度
+type ScheduleRange struct {
+	ID           uint64    `json:"id"`
+	Source       string    `json:"source"`
+	From         uint64    `json:"from"`
+	To           uint64    `json:"to"`
+	Blocks       uint64    `json:"blocks"`
+	Deduplicated int       `json:"deduplicated"`
+	Fetched      int       `json:"fetched"`
+	Committed    uint64    `json:"committed"`
+	Errors       int       `json:"errors"`
+	LastError    string    `json:"last_error,omitempty"`
+	Done         bool      `json:"done"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// jobTracker 记录调度区间的抓取/错误计数；落盘进度按 SyncedCursor 在读取时计算
+type jobTracker struct {
+	mu     sync.Mutex
+	nextID uint64
+	ranges []*ScheduleRange
+}
+
+func newJobTracker() *jobTracker {
+	return &jobTracker{}
+}
+
+func (t *jobTracker) open(source string, from, to uint64) uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextID++
+	now := time.Now()
+	t.ranges = append(t.ranges, &ScheduleRange{
+		ID:        t.nextID,
+		Source:    source,
+		From:      from,
+		To:        to,
+		Blocks:    to - from + 1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if len(t.ranges) > maxTrackedScheduleRanges {
+		t.ranges = t.ranges[len(t.ranges)-maxTrackedScheduleRanges:]
+	}
+	return t.nextID
+}
+
+func (t *jobTracker) addDeduplicated(id uint64, n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, r := range t.ranges {
+		if r.ID == id {
+			r.Deduplicated += n
+			r.UpdatedAt = time.Now()
+			return
+		}
+	}
+}
+
+// recordFetched 区块成功发往 Sequencer，累加到所有覆盖该块的未完成区间
+func (t *jobTracker) recordFetched(n uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	for _, r := range t.ranges {
+		if !r.Done && n >= r.From && n <= r.To {
+			r.Fetched++
+			r.UpdatedAt = now
+		}
+	}
+}
+
+// recordError 抓取失败，计入所有与 [start, end] 重叠的未完成区间
+func (t *jobTracker) recordError(start, end uint64, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	for _, r := range t.ranges {
+		if !r.Done && start <= r.To && end >= r.From {
+			r.Errors++
+			r.LastError = err.Error()
+			r.UpdatedAt = now
+		}
+	}
+}
+
+// snapshot 按落盘游标刷新进度并返回副本（新区间在前）；已完成区间只保留最近若干条
+func (t *jobTracker) snapshot(syncedCursor uint64) []ScheduleRange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, r := range t.ranges {
+		switch {
+		case syncedCursor >= r.To:
+			r.Committed = r.Blocks
+			r.Done = true
+		case syncedCursor >= r.From:
+			r.Committed = syncedCursor - r.From + 1
+		default:
+			r.Committed = 0
+		}
+	}
+
+	// 从新到旧遍历，只保留最近 maxFinishedScheduleRanges 条已完成区间
+	finished := 0
+	kept := make([]*ScheduleRange, 0, len(t.ranges))
+	out := make([]ScheduleRange, 0, len(t.ranges))
+	for i := len(t.ranges) - 1; i >= 0; i-- {
+		r := t.ranges[i]
+		if r.Done {
+			finished++
+			if finished > maxFinishedScheduleRanges {
+				continue
+			}
+		}
+		kept = append(kept, r)
+		out = append(out, *r)
+	}
+	// kept 为倒序，恢复为时间正序存储
+	sort.Slice(kept, func(i, j int) bool { return kept[i].ID < kept[j].ID })
+	t.ranges = kept
+	return out
+}
+
+// ScheduleRanges 返回调度区间进度（committed 基于传入的落盘游标计算）
+func (f *Fetcher) ScheduleRanges(syncedCursor uint64) []ScheduleRange {
+	return f.tracker.snapshot(syncedCursor)
+}
@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobTracker_ProgressAndCommitted(t *testing.T) {
+	tr := newJobTracker()
+	id := tr.open(ScheduleSourceGapFill, 100, 109)
+	tr.addDeduplicated(id, 2)
+	for n := uint64(100); n < 105; n++ {
+		tr.recordFetched(n)
+	}
+	tr.recordError(105, 109, errors.New("timeout"))
+
+	jobs := tr.snapshot(102)
+	assert.Len(t, jobs, 1)
+	j := jobs[0]
+	assert.Equal(t, ScheduleSourceGapFill, j.Source)
+	assert.Equal(t, uint64(10), j.Blocks)
+	assert.Equal(t, 2, j.Deduplicated)
+	assert.Equal(t, 5, j.Fetched)
+	assert.Equal(t, uint64(3), j.Committed)
+	assert.Equal(t, 1, j.Errors)
+	assert.Equal(t, "timeout", j.LastError)
+	assert.False(t, j.Done)
+
+	jobs = tr.snapshot(200)
+	assert.True(t, jobs[0].Done)
+	assert.Equal(t, uint64(10), jobs[0].Committed)
+}
+
+func TestJobTracker_KeepsRecentFinished(t *testing.T) {
+	tr := newJobTracker()
+	for i := uint64(0); i < maxFinishedScheduleRanges+5; i++ {
+		tr.open(ScheduleSourceTailFollow, i*10, i*10+9)
+	}
+	tr.open(ScheduleSourceTailFollow, 10000, 10009) // 仍在进行中
+
+	jobs := tr.snapshot(5000)
+	assert.Len(t, jobs, maxFinishedScheduleRanges+1)
+	assert.Equal(t, uint64(10000), jobs[0].From, "newest first")
+	assert.False(t, jobs[0].Done)
+	assert.Equal(t, uint64(50), jobs[len(jobs)-1].From, "oldest finished ranges are pruned")
+}
+
+func TestScheduleSourceFromContext(t *testing.T) {
+	assert.Equal(t, ScheduleSourceUnknown, scheduleSourceFrom(context.Background()))
+	ctx := WithScheduleSource(context.Background(), ScheduleSourceWatchdog)
+	assert.Equal(t, ScheduleSourceWatchdog, scheduleSourceFrom(ctx))
+}
@@ -0,0 +1,303 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// defaultRangeChunk 区间任务每次认领的子区间大小（与 Schedule 的步长一致，单次 eth_getLogs 覆盖）
+const defaultRangeChunk = 50
+
+// errFetcherAborted 抓取因 ctx 取消或 Fetcher 停止而中断
+var errFetcherAborted = errors.New("fetcher aborted")
+
+// RangeJob 大区间回填任务：整个区间只在调度队列中占一个条目，
+// worker 每次认领一段连续子区间（单次 eth_getLogs 批量抓取），逐段上报完成情况。
+// 相比 Schedule 为每 50 块入队一个 FetchJob，10 万块以上的回填不再挤占 jobs 通道。
+type RangeJob struct {
+	ID    uint64
+	Start uint64
+	End   uint64
+	Chunk uint64
+
+	trackerID uint64
+
+	mu        sync.Mutex
+	next      uint64 // 下一个待认领的区块
+	inflight  int    // 已认领未完成的子区间数
+	fetched   uint64 // 成功送达 Sequencer 的区块数
+	skipped   uint64 // 去重跳过的区块数
+	failed    int    // 失败的子区间数
+	lastErr   error
+	cancelled bool
+	done      chan struct{}
+	createdAt time.Time
+	doneAt    time.Time
+}
+
+// RangeJobProgress 区间任务进度快照
+type RangeJobProgress struct {
+	ID        uint64    `json:"id"`
+	Start     uint64    `json:"start"`
+	End       uint64    `json:"end"`
+	Claimed   uint64    `json:"claimed"`
+	Fetched   uint64    `json:"fetched"`
+	Skipped   uint64    `json:"skipped"`
+	Failed    int       `json:"failed_chunks"`
+	InFlight  int       `json:"in_flight"`
+	Done      bool      `json:"done"`
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// rangeChunk 一次认领的子区间
+type rangeChunk struct {
+	job        *RangeJob
+	start, end uint64
+}
+
+// claim 认领下一段子区间；全部认领完后返回 false
+func (j *RangeJob) claim() (rangeChunk, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.cancelled || j.next > j.End {
+		return rangeChunk{}, false
+	}
+	start := j.next
+	end := min(start+j.Chunk-1, j.End)
+	j.next = end + 1
+	j.inflight++
+	return rangeChunk{job: j, start: start, end: end}, true
+}
+
+// exhausted 所有子区间均已认领
+func (j *RangeJob) exhausted() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.cancelled || j.next > j.End
+}
+
+// report 上报子区间完成情况；最后一段完成时关闭 done
+func (j *RangeJob) report(fetched, skipped uint64, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.inflight--
+	j.fetched += fetched
+	j.skipped += skipped
+	if err != nil {
+		j.failed++
+		j.lastErr = err
+	}
+	if j.inflight == 0 && (j.cancelled || j.next > j.End) && j.doneAt.IsZero() {
+		j.doneAt = time.Now()
+		close(j.done)
+	}
+}
+
+// cancel 停止认领新的子区间（已认领的子区间继续完成）
+func (j *RangeJob) cancel() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.cancelled = true
+	if j.inflight == 0 && j.doneAt.IsZero() {
+		j.doneAt = time.Now()
+		close(j.done)
+	}
+}
+
+// Done 所有子区间完成（或任务取消且在途子区间结束）时关闭
+func (j *RangeJob) Done() <-chan struct{} {
+	return j.done
+}
+
+// Wait 等待任务完成；存在失败子区间时返回最后一个错误
+func (j *RangeJob) Wait(ctx context.Context) error {
+	select {
+	case <-j.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.failed > 0 {
+		return fmt.Errorf("range job %d: %d chunk(s) failed: %w", j.ID, j.failed, j.lastErr)
+	}
+	return nil
+}
+
+// Progress 返回任务进度快照
+func (j *RangeJob) Progress() RangeJobProgress {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	p := RangeJobProgress{
+		ID:        j.ID,
+		Start:     j.Start,
+		End:       j.End,
+		Claimed:   min(j.next, j.End+1) - j.Start,
+		Fetched:   j.fetched,
+		Skipped:   j.skipped,
+		Failed:    j.failed,
+		InFlight:  j.inflight,
+		Done:      !j.doneAt.IsZero(),
+		CreatedAt: j.createdAt,
+	}
+	if j.lastErr != nil {
+		p.LastError = j.lastErr.Error()
+	}
+	return p
+}
+
+// rangeQueue 活跃区间任务队列（先进先出认领，保证回填大体按高度推进）
+type rangeQueue struct {
+	mu     sync.Mutex
+	nextID uint64
+	jobs   []*RangeJob
+	kick   chan struct{}
+}
+
+func newRangeQueue() *rangeQueue {
+	return &rangeQueue{kick: make(chan struct{}, 1)}
+}
+
+func (q *rangeQueue) add(start, end, chunk uint64) *RangeJob {
+	q.mu.Lock()
+	q.nextID++
+	job := &RangeJob{
+		ID:        q.nextID,
+		Start:     start,
+		End:       end,
+		Chunk:     chunk,
+		next:      start,
+		done:      make(chan struct{}),
+		createdAt: time.Now(),
+	}
+	q.jobs = append(q.jobs, job)
+	q.mu.Unlock()
+	q.notify()
+	return job
+}
+
+// claim 从最早的未认领完的任务中认领一段；仍有剩余时继续唤醒其他 worker
+func (q *rangeQueue) claim() (rangeChunk, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.jobs) > 0 {
+		job := q.jobs[0]
+		if c, ok := job.claim(); ok {
+			if !job.exhausted() || len(q.jobs) > 1 {
+				q.notify()
+			}
+			return c, true
+		}
+		q.jobs = q.jobs[1:]
+	}
+	return rangeChunk{}, false
+}
+
+func (q *rangeQueue) notify() {
+	select {
+	case q.kick <- struct{}{}:
+	default:
+	}
+}
+
+// cancelAll 取消所有未认领完的任务（ClearJobs / 停止时使用）
+func (q *rangeQueue) cancelAll() int {
+	q.mu.Lock()
+	jobs := q.jobs
+	q.jobs = nil
+	q.mu.Unlock()
+	for _, j := range jobs {
+		j.cancel()
+	}
+	return len(jobs)
+}
+
+// ScheduleRangeJob 以区间任务方式调度 [start, end]（立即返回，不阻塞等待入队）。
+// 与 Schedule 相同地按链高截断；背压由 worker 向 Results 的阻塞发送自然形成。
+func (f *Fetcher) ScheduleRangeJob(ctx context.Context, start, end *big.Int) (*RangeJob, error) {
+	if start.Sign() < 0 || end.Sign() < 0 {
+		return nil, fmt.Errorf("invalid range %s-%s", start, end)
+	}
+	chainHeight := GetOrchestrator().GetSnapshot().LatestHeight
+	if start.Uint64() > chainHeight {
+		return nil, ErrBlockNotYetAvailable
+	}
+	to := min(end.Uint64(), chainHeight)
+	if start.Uint64() > to {
+		return nil, fmt.Errorf("empty range %s-%d", start, to)
+	}
+
+	job := f.ranges.add(start.Uint64(), to, defaultRangeChunk)
+	job.trackerID = f.tracker.open(scheduleSourceFrom(ctx), job.Start, job.End)
+
+	Logger.Info("📋 [Fetcher] Range job scheduled",
+		slog.Uint64("job_id", job.ID),
+		slog.Uint64("start_block", job.Start),
+		slog.Uint64("end_block", job.End),
+		slog.Uint64("chunk", job.Chunk))
+	return job, nil
+}
+
+// RangeJobs 返回仍在队列中的区间任务进度
+func (f *Fetcher) RangeJobs() []RangeJobProgress {
+	f.ranges.mu.Lock()
+	jobs := append([]*RangeJob(nil), f.ranges.jobs...)
+	f.ranges.mu.Unlock()
+	out := make([]RangeJobProgress, 0, len(jobs))
+	for _, j := range jobs {
+		out = append(out, j.Progress())
+	}
+	return out
+}
+
+// runRangeChunk 抓取一段子区间：先去重认领，再对剩余部分执行区间抓取，最后上报
+func (f *Fetcher) runRangeChunk(ctx context.Context, c rangeChunk) bool {
+	if !f.waitIfPaused(ctx) {
+		c.job.report(0, 0, errFetcherAborted)
+		return false
+	}
+
+	subRanges, dropped := f.dedup.claim(c.start, c.end, time.Now())
+	if dropped > 0 {
+		f.tracker.addDeduplicated(c.job.trackerID, dropped)
+		if f.metrics != nil {
+			f.metrics.FetcherJobsDeduplicated.Add(float64(dropped))
+		}
+	}
+
+	var fetched uint64
+	var lastErr error
+	for i, r := range subRanges {
+		if err := f.limiter.Wait(ctx); err != nil {
+			f.releaseRanges(subRanges[i:])
+			c.job.report(fetched, uint64(dropped), err) // #nosec G115 - dropped >= 0
+			return false
+		}
+		sent, err := f.fetchRangeWithLogs(ctx, new(big.Int).SetUint64(r[0]), new(big.Int).SetUint64(r[1]))
+		f.dedup.release(r[0], r[1])
+		fetched += uint64(sent) // #nosec G115 - sent >= 0
+		if errors.Is(err, errFetcherAborted) || ctx.Err() != nil {
+			f.releaseRanges(subRanges[i+1:])
+			c.job.report(fetched, uint64(dropped), errFetcherAborted) // #nosec G115
+			return false
+		}
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	c.job.report(fetched, uint64(dropped), lastErr) // #nosec G115 - dropped >= 0
+	Logger.Debug("📋 [Fetcher] Range chunk completed",
+		slog.Uint64("job_id", c.job.ID),
+		slog.Uint64("from", c.start),
+		slog.Uint64("to", c.end),
+		slog.Uint64("fetched", fetched),
+		slog.Int("deduplicated", dropped))
+	return true
+}
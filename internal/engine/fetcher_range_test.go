@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeQueue_ClaimsContiguousChunksInOrder(t *testing.T) {
+	q := newRangeQueue()
+	a := q.add(100, 219, 50)
+	b := q.add(300, 309, 50)
+
+	var got [][2]uint64
+	for {
+		c, ok := q.claim()
+		if !ok {
+			break
+		}
+		got = append(got, [2]uint64{c.start, c.end})
+	}
+	assert.Equal(t, [][2]uint64{{100, 149}, {150, 199}, {200, 219}, {300, 309}}, got)
+
+	p := a.Progress()
+	assert.Equal(t, uint64(120), p.Claimed)
+	assert.Equal(t, 3, p.InFlight)
+	assert.False(t, p.Done)
+	assert.Equal(t, uint64(10), b.Progress().Claimed)
+}
+
+func TestRangeJob_ReportsCompletionAndErrors(t *testing.T) {
+	q := newRangeQueue()
+	job := q.add(1, 100, 50)
+
+	c1, _ := q.claim()
+	c2, _ := q.claim()
+	c1.job.report(50, 0, nil)
+
+	select {
+	case <-job.Done():
+		t.Fatal("job must not complete while a chunk is in flight")
+	default:
+	}
+
+	c2.job.report(45, 5, errors.New("rpc timeout"))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := job.Wait(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 chunk(s) failed")
+
+	p := job.Progress()
+	assert.True(t, p.Done)
+	assert.Equal(t, uint64(95), p.Fetched)
+	assert.Equal(t, uint64(5), p.Skipped)
+	assert.Equal(t, "rpc timeout", p.LastError)
+}
+
+func TestRangeQueue_CancelAllStopsClaiming(t *testing.T) {
+	q := newRangeQueue()
+	job := q.add(1, 1000, 50)
+	c, ok := q.claim()
+	require.True(t, ok)
+
+	assert.Equal(t, 1, q.cancelAll())
+	_, ok = q.claim()
+	assert.False(t, ok)
+
+	c.job.report(50, 0, nil)
+	assert.NoError(t, job.Wait(context.Background()))
+	assert.Equal(t, uint64(50), job.Progress().Claimed)
+}
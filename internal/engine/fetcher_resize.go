@@ -0,0 +1,61 @@
+package engine
+
+import "log/slog"
+
+// Resize 运行时调整抓取 worker 数量（n < 1 按 1 处理）。
+// 扩容立即启动新 worker；缩容向多余 worker 发送退出信号，它们完成手头任务后退出，
+// 不会丢弃已认领的任务。Start 之前调用只修改初始并发数。jobs 通道容量保持不变。
+func (f *Fetcher) Resize(n int) {
+	if n < 1 {
+		n = 1
+	}
+
+	f.workersMu.Lock()
+	defer f.workersMu.Unlock()
+
+	prev := f.concurrency
+	f.concurrency = n
+	if f.workerWg == nil {
+		return
+	}
+
+	current := len(f.workerQuits)
+	switch {
+	case n > current:
+		for i := current; i < n; i++ {
+			f.spawnWorkerLocked()
+		}
+	case n < current:
+		for _, quit := range f.workerQuits[n:] {
+			close(quit)
+		}
+		f.workerQuits = f.workerQuits[:n]
+	default:
+		return
+	}
+
+	Logger.Info("🌀 [Fetcher] Worker pool resized",
+		slog.Int("from", prev),
+		slog.Int("to", n))
+}
+
+// Concurrency 返回当前目标 worker 数
+func (f *Fetcher) Concurrency() int {
+	f.workersMu.Lock()
+	defer f.workersMu.Unlock()
+	return f.concurrency
+}
+
+// ActiveWorkers 返回实际运行中的 worker 数（缩容时在在途任务完成前仍计入）
+func (f *Fetcher) ActiveWorkers() int {
+	return int(f.active.Load())
+}
+
+// WatchConfig 订阅 ConfigManager 热更新，FetcherConcurrency 变化时调整 worker 池
+func (f *Fetcher) WatchConfig(cm *ConfigManager) {
+	cm.OnChange(func(cfg IndexerConfig) {
+		if cfg.FetcherConcurrency != f.Concurrency() {
+			f.Resize(cfg.FetcherConcurrency)
+		}
+	})
+}
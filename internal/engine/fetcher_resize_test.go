@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetcherResize_BeforeStartOnlySetsConcurrency(t *testing.T) {
+	f := NewFetcher(&lossyLogClient{}, 2)
+	f.Resize(6)
+	assert.Equal(t, 6, f.Concurrency())
+	assert.Empty(t, f.workerQuits)
+
+	f.Resize(0)
+	assert.Equal(t, 1, f.Concurrency())
+}
+
+func TestFetcherResize_ShrinkDrainsInFlightJobs(t *testing.T) {
+	srv := newMockRPCServer(t, 20)
+	srv.AddLog(mockTransferLog(2, common.HexToAddress("0x1")))
+	srv.SetLatency(100 * time.Millisecond)
+	pool, err := NewRPCClientPoolWithTimeout([]string{srv.URL}, time.Second)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	f := NewFetcher(pool, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	f.Start(ctx, &wg)
+	require.Eventually(t, func() bool { return f.ActiveWorkers() == 4 }, time.Second, 5*time.Millisecond)
+
+	for i := int64(1); i <= 4; i++ {
+		f.jobs <- FetchJob{Start: big.NewInt(i), End: big.NewInt(i)}
+	}
+	time.Sleep(20 * time.Millisecond) // 让 4 个 worker 都认领到任务
+	f.Resize(1)
+	assert.Equal(t, 1, f.Concurrency())
+	assert.Len(t, f.workerQuits, 1)
+
+	// 被缩容的 worker 必须先完成手头任务
+	got := map[uint64]bool{}
+	for len(got) < 4 {
+		select {
+		case data := <-f.Results:
+			require.NoError(t, data.Err)
+			got[data.Number.Uint64()] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("in-flight jobs lost after shrink, got %v", got)
+		}
+	}
+	assert.Eventually(t, func() bool { return f.ActiveWorkers() == 1 },
+		2*time.Second, 10*time.Millisecond, "shrunk workers must exit")
+
+	// 剩余 worker 仍在消费
+	f.jobs <- FetchJob{Start: big.NewInt(5), End: big.NewInt(5)}
+	select {
+	case data := <-f.Results:
+		assert.Equal(t, uint64(5), data.Number.Uint64())
+	case <-time.After(5 * time.Second):
+		t.Fatal("remaining worker did not pick up the job")
+	}
+
+	cancel()
+	wg.Wait()
+}
+
+func TestFetcherWatchConfig_ResizesOnConcurrencyChange(t *testing.T) {
+	f := NewFetcher(&lossyLogClient{}, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	f.Start(ctx, &wg)
+
+	cm := NewConfigManager(DefaultConfig())
+	f.WatchConfig(cm)
+
+	next := cm.Get()
+	next.FetcherConcurrency = 5
+	require.NoError(t, cm.Update(ctx, next))
+	assert.Equal(t, 5, f.Concurrency())
+	assert.Len(t, f.workerQuits, 5)
+	assert.Eventually(t, func() bool { return f.ActiveWorkers() == 5 }, time.Second, 5*time.Millisecond)
+
+	next.MaxRPS = 30 // 与并发无关的变更不触发扩缩容
+	require.NoError(t, cm.Update(ctx, next))
+	assert.Len(t, f.workerQuits, 5)
+
+	cancel()
+	wg.Wait()
+}
@@ -6,31 +6,45 @@ import (
 	"fmt"
 	"log/slog"
 	"math/big"
+	"time"
 )
 
 // ErrBlockNotYetAvailable 表示请求的区块高度超过了当前链高度
 var ErrBlockNotYetAvailable = errors.New("block not yet available")
 
+// Schedule 调度 [start, end] 的前向抓取；end 截断到 Orchestrator 的 TargetHeight（链高 - SafetyBuffer），
+// 不向节点请求尚未传播的链尖区块，避免 404 重试
 func (f *Fetcher) Schedule(ctx context.Context, start, end *big.Int) error {
+	_, err := f.ScheduleCapped(ctx, start, end)
+	return err
+}
+
+// ScheduleCapped 同 Schedule，并返回实际调度到的高度；调用方据此推进游标，被截断的部分留待下次调度
+func (f *Fetcher) ScheduleCapped(ctx context.Context, start, end *big.Int) (*big.Int, error) {
+	return f.schedule(ctx, start, end, GetOrchestrator().GetSnapshot().TargetHeight)
+}
+
+func (f *Fetcher) schedule(ctx context.Context, start, end *big.Int, ceilingHeight uint64) (*big.Int, error) {
 	// 🚀 🔥 边界卫兵：绝对禁止抓取还未产生的块 (Ghost Chase Defense)
-	orch := GetOrchestrator()
-	snap := orch.GetSnapshot()
-	chainHeight := big.NewInt(SafeUint64ToInt64(snap.LatestHeight))
+	ceiling := new(big.Int).SetUint64(ceilingHeight)
 
-	if start.Cmp(chainHeight) > 0 {
+	if start.Cmp(ceiling) > 0 {
 		// 如果是 Anvil 模式，仅记录 Debug 而非 Error，减少日志噪音
-		slog.Debug("🌀 [Fetcher] Boundary skip: start block is ahead of chain", "start", start.String(), "chain", chainHeight.String())
-		return ErrBlockNotYetAvailable // 返回特定错误让调用方能区分"跳过"和"成功"
+		slog.Debug("🌀 [Fetcher] Boundary skip: start block is ahead of fetch ceiling", "start", start.String(), "ceiling", ceiling.String())
+		return nil, ErrBlockNotYetAvailable // 返回特定错误让调用方能区分"跳过"和"成功"
 	}
 
-	// 如果 end 超过了 chainHeight，自动截断到 chainHeight
-	if end.Cmp(chainHeight) > 0 {
-		slog.Debug("🌀 [Fetcher] Truncating schedule range to chain height", "original_end", end.String(), "new_end", chainHeight.String())
-		end = chainHeight
+	// 如果 end 超过了抓取上限，自动截断
+	if end.Cmp(ceiling) > 0 {
+		slog.Debug("🌀 [Fetcher] Truncating schedule range to fetch ceiling", "original_end", end.String(), "new_end", ceiling.String())
+		if f.metrics != nil {
+			f.metrics.FetcherScheduleClamped.Add(float64(new(big.Int).Sub(end, ceiling).Uint64()))
+		}
+		end = ceiling
 	}
 
 	if start.Cmp(end) > 0 {
-		return nil
+		return end, nil
 	}
 
 	Logger.Info("📋 [Fetcher] Schedule 开始调度任务",
@@ -55,7 +69,7 @@ func (f *Fetcher) Schedule(ctx context.Context, start, end *big.Int) error {
 			slog.Int("jobs_watermark", jobsWatermark),
 			slog.Int("jobs_capacity", maxJobsCapacity),
 			slog.Int("results_depth", resultsDepth))
-		return fmt.Errorf("fetcher jobs queue backpressure: depth=%d/%d", jobsDepth, maxJobsCapacity)
+		return nil, fmt.Errorf("fetcher jobs queue backpressure: depth=%d/%d", jobsDepth, maxJobsCapacity)
 	}
 
 	if resultsDepth > resultsWatermark {
@@ -64,7 +78,7 @@ func (f *Fetcher) Schedule(ctx context.Context, start, end *big.Int) error {
 			slog.Int("results_watermark", resultsWatermark),
 			slog.Int("results_capacity", maxResultsCapacity),
 			slog.Int("jobs_depth", jobsDepth))
-		return fmt.Errorf("fetcher results channel backpressure: depth=%d/%d", resultsDepth, maxResultsCapacity)
+		return nil, fmt.Errorf("fetcher results channel backpressure: depth=%d/%d", resultsDepth, maxResultsCapacity)
 	}
 
 	// 🔥 检查 Sequencer buffer 深度
@@ -75,7 +89,7 @@ func (f *Fetcher) Schedule(ctx context.Context, start, end *big.Int) error {
 				slog.Int("sequencer_buffer_size", seqBufferSize),
 				slog.Int("jobs_depth", jobsDepth),
 				slog.Int("results_depth", resultsDepth))
-			return fmt.Errorf("sequencer buffer backpressure: size=%d", seqBufferSize)
+			return nil, fmt.Errorf("sequencer buffer backpressure: size=%d", seqBufferSize)
 		}
 	}
 
@@ -85,32 +99,61 @@ func (f *Fetcher) Schedule(ctx context.Context, start, end *big.Int) error {
 	current := new(big.Int).Set(start)
 	jobCount := 0
 
+	droppedBlocks := 0
+	rangeID := f.tracker.open(scheduleSourceFrom(ctx), start.Uint64(), end.Uint64())
+
 	for current.Cmp(end) <= 0 {
 		batchEnd := new(big.Int).Add(current, batchSize)
 		if batchEnd.Cmp(end) > 0 {
 			batchEnd = new(big.Int).Set(end)
 		}
 
-		job := FetchJob{
-			Start: new(big.Int).Set(current),
-			End:   new(big.Int).Set(batchEnd),
-		}
-
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-f.stopCh:
-			return fmt.Errorf("fetcher stopped")
-		case f.jobs <- job:
-			jobCount++
+		// 🔁 去重：跳过 in-flight 与近期已完成的区块，仅为剩余子区间派发任务
+		ranges, dropped := f.dedup.claim(current.Uint64(), batchEnd.Uint64(), time.Now())
+		droppedBlocks += dropped
+
+		for i, r := range ranges {
+			job := FetchJob{
+				Start: new(big.Int).SetUint64(r[0]),
+				End:   new(big.Int).SetUint64(r[1]),
+			}
+
+			select {
+			case <-ctx.Done():
+				f.releaseRanges(ranges[i:])
+				return nil, ctx.Err()
+			case <-f.stopCh:
+				f.releaseRanges(ranges[i:])
+				return nil, fmt.Errorf("fetcher stopped")
+			case f.jobs <- job:
+				jobCount++
+			}
 		}
 
 		// 移动到下一批
 		current = new(big.Int).Add(batchEnd, big.NewInt(1))
 	}
 
+	if droppedBlocks > 0 {
+		f.tracker.addDeduplicated(rangeID, droppedBlocks)
+		if f.metrics != nil {
+			f.metrics.FetcherJobsDeduplicated.Add(float64(droppedBlocks))
+		}
+		Logger.Debug("🔁 [Fetcher] Duplicate blocks dropped from schedule",
+			slog.String("start_block", start.String()),
+			slog.String("end_block", end.String()),
+			slog.Int("dropped_blocks", droppedBlocks))
+	}
+
 	Logger.Info("📋 [Fetcher] Schedule 完成，所有任务已发送",
 		slog.Int("total_jobs", jobCount),
 	)
-	return nil
+	return end, nil
+}
+
+// releaseRanges 释放未能入队的子区间的 in-flight 标记
+func (f *Fetcher) releaseRanges(ranges [][2]uint64) {
+	for _, r := range ranges {
+		f.dedup.release(r[0], r[1])
+	}
 }
@@ -0,0 +1,36 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetcherSchedule_ClampsToFetchCeiling(t *testing.T) {
+	f := NewFetcher(&lossyLogClient{}, 1)
+	clamped := testutil.ToFloat64(GetMetrics().FetcherScheduleClamped)
+
+	// 链高 100、安全垫 3：请求 [90, 110] 只调度到 97
+	end, err := f.schedule(context.Background(), big.NewInt(90), big.NewInt(110), 97)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(97), end.Uint64())
+	assert.Equal(t, clamped+13, testutil.ToFloat64(GetMetrics().FetcherScheduleClamped))
+
+	var maxEnd uint64
+	for len(f.jobs) > 0 {
+		job := <-f.jobs
+		if job.End.Uint64() > maxEnd {
+			maxEnd = job.End.Uint64()
+		}
+	}
+	assert.Equal(t, uint64(97), maxEnd, "no job reaches into the safety buffer")
+
+	// 起点已在安全垫内：不调度，调用方保持游标
+	_, err = f.schedule(context.Background(), big.NewInt(98), big.NewInt(110), 97)
+	assert.ErrorIs(t, err, ErrBlockNotYetAvailable)
+	assert.Zero(t, f.QueueDepth())
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// filterLogsTimeout 单次 eth_getLogs 的硬超时（二分后的每个子区间各自计时）
+const filterLogsTimeout = 3 * time.Second
+
+// 提供方拒绝过大查询时的错误特征（小写匹配）→ 拆分原因
+var logRangeLimitPatterns = []struct {
+	needle string
+	reason string
+}{
+	{"query returned more than", "result_limit"},    // Infura / geth: query returned more than 10000 results
+	{"log response size exceeded", "response_size"}, // Alchemy
+	{"response size should not greater than", "response_size"},
+	{"query exceeds max results", "result_limit"},
+	{"too many results", "result_limit"},
+	{"exceed maximum block range", "range_limit"}, // Ankr / BSC
+	{"block range is too wide", "range_limit"},    // QuickNode
+	{"block range too large", "range_limit"},
+	{"eth_getlogs is limited to", "range_limit"}, // Alchemy (free tier)
+	{"range limit exceeded", "range_limit"},
+	{"max block range", "range_limit"},
+}
+
+// logRangeLimitReason 判断 err 是否为区间过大导致的拒绝，返回拆分原因（非此类错误返回空串）
+func logRangeLimitReason(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := strings.ToLower(err.Error())
+	for _, p := range logRangeLimitPatterns {
+		if strings.Contains(msg, p.needle) {
+			return p.reason
+		}
+	}
+	return ""
+}
+
+// logFilterFunc 单次 eth_getLogs 调用（返回日志与响应节点）
+type logFilterFunc func(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, string, error)
+
+// filterLogsAdaptive 调用 eth_getLogs；提供方因结果数或区块跨度上限拒绝时把区间二分后分别重试，
+// 直到单块仍被拒绝为止。子区间结果按区块顺序拼接，响应节点一致时返回该节点，否则返回空串
+func (f *Fetcher) filterLogsAdaptive(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, string, error) {
+	return splitFilterLogs(ctx, q, func(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, string, error) {
+		reqCtx, cancel := context.WithTimeout(ctx, filterLogsTimeout)
+		defer cancel()
+		return f.filterLogs(reqCtx, q)
+	}, func(reason string) {
+		if f.metrics != nil && f.metrics.LogRangeSplits != nil {
+			f.metrics.LogRangeSplits.WithLabelValues(reason).Inc()
+		}
+	})
+}
+
+func splitFilterLogs(ctx context.Context, q ethereum.FilterQuery, call logFilterFunc, onSplit func(reason string)) ([]types.Log, string, error) {
+	logs, provider, err := call(ctx, q)
+	reason := logRangeLimitReason(err)
+	if reason == "" || q.FromBlock == nil || q.ToBlock == nil || q.FromBlock.Cmp(q.ToBlock) >= 0 {
+		return logs, provider, err
+	}
+	if ctx.Err() != nil {
+		return nil, "", ctx.Err()
+	}
+
+	mid := new(big.Int).Add(q.FromBlock, q.ToBlock)
+	mid.Rsh(mid, 1)
+	onSplit(reason)
+	Logger.Debug("getlogs_range_split",
+		slog.String("from", q.FromBlock.String()),
+		slog.String("to", q.ToBlock.String()),
+		slog.String("mid", mid.String()),
+		slog.String("reason", reason))
+
+	left, right := q, q
+	left.ToBlock = mid
+	right.FromBlock = new(big.Int).Add(mid, big.NewInt(1))
+
+	leftLogs, leftProvider, err := splitFilterLogs(ctx, left, call, onSplit)
+	if err != nil {
+		return nil, "", err
+	}
+	rightLogs, rightProvider, err := splitFilterLogs(ctx, right, call, onSplit)
+	if err != nil {
+		return nil, "", err
+	}
+	if leftProvider != rightProvider {
+		leftProvider = ""
+	}
+	return append(leftLogs, rightLogs...), leftProvider, nil
+}
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogRangeLimitReason(t *testing.T) {
+	cases := map[string]string{
+		"query returned more than 10000 results":                           "result_limit",
+		"Log response size exceeded. You can make eth_getLogs requests...": "response_size",
+		"exceed maximum block range: 5000":                                 "range_limit",
+		"eth_getLogs is limited to a 10 block range":                       "range_limit",
+		"429 Too Many Requests":                                            "",
+		"connection reset by peer":                                         "",
+	}
+	for msg, want := range cases {
+		assert.Equal(t, want, logRangeLimitReason(errors.New(msg)), msg)
+	}
+	assert.Empty(t, logRangeLimitReason(nil))
+}
+
+// cappedLogFilter 模拟 Infura 结果上限：区间内日志数超过 limit 时拒绝；每块一条日志
+func cappedLogFilter(limit uint64, calls *int) logFilterFunc {
+	return func(_ context.Context, q ethereum.FilterQuery) ([]types.Log, string, error) {
+		*calls++
+		from, to := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+		if to-from+1 > limit {
+			return nil, "", fmt.Errorf("query returned more than %d results", limit)
+		}
+		var logs []types.Log
+		for n := from; n <= to; n++ {
+			logs = append(logs, types.Log{BlockNumber: n})
+		}
+		return logs, "http://node", nil
+	}
+}
+
+func TestSplitFilterLogs_BisectsUntilUnderCap(t *testing.T) {
+	var calls, splits int
+	q := ethereum.FilterQuery{FromBlock: big.NewInt(100), ToBlock: big.NewInt(199)}
+	logs, provider, err := splitFilterLogs(context.Background(), q, cappedLogFilter(30, &calls), func(reason string) {
+		assert.Equal(t, "result_limit", reason)
+		splits++
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 100)
+	for i, l := range logs {
+		assert.Equal(t, uint64(100+i), l.BlockNumber, "results stay in block order")
+	}
+	assert.Equal(t, "http://node", provider)
+	assert.Equal(t, 3, splits) // 100 → 50+50 → 25×4
+	assert.Equal(t, 7, calls)
+}
+
+func TestSplitFilterLogs_SingleBlockRejectionIsReturned(t *testing.T) {
+	var calls, splits int
+	q := ethereum.FilterQuery{FromBlock: big.NewInt(5), ToBlock: big.NewInt(6)}
+	_, _, err := splitFilterLogs(context.Background(), q, cappedLogFilter(0, &calls), func(string) { splits++ })
+	require.Error(t, err)
+	assert.Equal(t, 1, splits)
+	assert.Equal(t, 2, calls, "stops after the first single-block rejection")
+}
+
+func TestSplitFilterLogs_OtherErrorsAreNotSplit(t *testing.T) {
+	calls := 0
+	call := func(context.Context, ethereum.FilterQuery) ([]types.Log, string, error) {
+		calls++
+		return nil, "", errors.New("connection reset")
+	}
+	q := ethereum.FilterQuery{FromBlock: big.NewInt(1), ToBlock: big.NewInt(100)}
+	_, _, err := splitFilterLogs(context.Background(), q, call, func(string) { t.Fatal("unexpected split") })
+	require.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/big"
+)
+
+// SetInternalTraceMode 开启后为每个区块调用 debug_traceBlockByNumber（callTracer），
+// 由 Processor 从调用树中提取内部 ETH 转账（activity_type=INTERNAL）
+func (f *Fetcher) SetInternalTraceMode(enabled bool) {
+	f.traceInternal.Store(enabled)
+}
+
+// fetchBlockTraces 内部转账模式下获取区块调用树；失败时返回 nil（该块不产生内部转账）。
+// 节点池中没有任何节点支持 debug_trace 时关闭该模式，避免每块都白白请求
+func (f *Fetcher) fetchBlockTraces(ctx context.Context, bn *big.Int) []TxCallTrace {
+	if !f.traceInternal.Load() {
+		return nil
+	}
+	tc, ok := f.pool.(TraceClient)
+	if !ok {
+		return nil
+	}
+
+	traces, err := tc.TraceBlockCalls(ctx, bn)
+	switch {
+	case errors.Is(err, ErrTraceUnsupported):
+		if f.traceInternal.CompareAndSwap(true, false) {
+			Logger.Warn("🔍 [Fetcher] No RPC node supports debug_traceBlockByNumber, internal transfer indexing disabled")
+		}
+		f.recordTraceFetch("unsupported")
+		return nil
+	case err != nil:
+		f.recordTraceFetch("error")
+		Logger.Debug("block_trace_fetch_failed", slog.String("block", bn.String()), slog.String("err", err.Error()))
+		return nil
+	}
+	f.recordTraceFetch("ok")
+	return traces
+}
+
+func (f *Fetcher) recordTraceFetch(result string) {
+	if f.metrics == nil || f.metrics.TraceFetches == nil {
+		return
+	}
+	f.metrics.TraceFetches.WithLabelValues(result).Inc()
+}
@@ -0,0 +1,266 @@
+package engine
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math/big"
+	mathrand "math/rand/v2"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/sync/errgroup"
+)
+
+// defaultLogVerifyRate 默认抽样校验比例（约 1% 的区块）
+const defaultLogVerifyRate = 0.01
+
+// SetLogVerificationRate 设置 FilterLogs 回执交叉校验的抽样比例（0 关闭，1 全量）
+func (f *Fetcher) SetLogVerificationRate(rate float64) {
+	if rate < 0 || rate != rate {
+		rate = 0
+	}
+	if rate > 1 {
+		rate = 1
+	}
+	f.logVerifyRate = rate
+}
+
+// SetReceiptFetchMode 开启后为每个有交易的区块抓取回执，Gas 排行榜改用实际 gasUsed / effectiveGasPrice
+func (f *Fetcher) SetReceiptFetchMode(enabled bool) {
+	f.fetchReceipts = enabled
+}
+
+// fetchBlockReceipts 回执抓取模式下获取区块回执：优先 eth_getBlockReceipts，节点不支持时改为逐笔获取。
+// 失败时返回 nil（下游回退为按 Gas Limit 估算）
+func (f *Fetcher) fetchBlockReceipts(ctx context.Context, block *types.Block) []*types.Receipt {
+	if !f.fetchReceipts || block == nil || len(block.Transactions()) == 0 {
+		return nil
+	}
+
+	if rc, ok := f.pool.(ReceiptClient); ok && !f.blockReceiptsUnsupported.Load() {
+		reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		receipts, err := rc.BlockReceipts(reqCtx, block.Number())
+		cancel()
+		if err == nil {
+			f.recordReceiptFetch("block", "ok")
+			return receipts
+		}
+		if !isMethodUnsupported(err) {
+			f.recordReceiptFetch("block", "error")
+			Logger.Debug("block_receipts_fetch_failed", slog.String("block", block.Number().String()), slog.String("err", err.Error()))
+			return nil
+		}
+		f.blockReceiptsUnsupported.Store(true)
+		Logger.Warn("⛽ [Fetcher] eth_getBlockReceipts unsupported by provider, falling back to per-transaction receipts",
+			slog.String("err", err.Error()))
+	}
+	return f.fetchTxReceipts(ctx, block)
+}
+
+// txReceiptConcurrency 逐笔获取回执时的并发数
+const txReceiptConcurrency = 8
+
+// fetchTxReceipts 逐笔获取区块内交易回执（有界并发）；任一笔失败返回 nil，避免半数实际、半数估算的混合结果
+func (f *Fetcher) fetchTxReceipts(ctx context.Context, block *types.Block) []*types.Receipt {
+	tc, ok := f.pool.(TxReceiptClient)
+	if !ok {
+		return nil
+	}
+
+	txs := block.Transactions()
+	receipts := make([]*types.Receipt, len(txs))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(txReceiptConcurrency)
+	for i, tx := range txs {
+		g.Go(func() error {
+			reqCtx, cancel := context.WithTimeout(gctx, 5*time.Second)
+			defer cancel()
+			r, err := tc.TransactionReceipt(reqCtx, tx.Hash())
+			if err != nil {
+				return fmt.Errorf("tx %s: %w", tx.Hash().Hex(), err)
+			}
+			receipts[i] = r
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		f.recordReceiptFetch("tx", "error")
+		Logger.Debug("tx_receipts_fetch_failed", slog.String("block", block.Number().String()), slog.String("err", err.Error()))
+		return nil
+	}
+	f.recordReceiptFetch("tx", "ok")
+	return receipts
+}
+
+func (f *Fetcher) recordReceiptFetch(method, result string) {
+	if f.metrics == nil || f.metrics.ReceiptFetches == nil {
+		return
+	}
+	f.metrics.ReceiptFetches.WithLabelValues(method, result).Inc()
+}
+
+// shouldVerifyLogs 按抽样比例决定是否校验该区块
+func (f *Fetcher) shouldVerifyLogs() bool {
+	if f.logVerifyRate <= 0 {
+		return false
+	}
+	// #nosec G404 - sampling does not need a cryptographic RNG
+	return mathrand.Float64() < f.logVerifyRate
+}
+
+// filterLogs 调用 FilterLogs，若节点池支持归因则一并返回响应节点
+func (f *Fetcher) filterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, string, error) {
+	if af, ok := f.pool.(AttributedLogFilter); ok {
+		return af.FilterLogsFrom(ctx, q)
+	}
+	logs, err := f.pool.FilterLogs(ctx, q)
+	return logs, "", err
+}
+
+// verifySampledLogs 对抽样区块用回执重建日志，与 FilterLogs 结果比对。
+// 发现不一致时标记提供方，并用单块重新抓取（仍不一致则以回执为准）修正 logsByBlock。
+func (f *Fetcher) verifySampledLogs(ctx context.Context, start, end *big.Int, q ethereum.FilterQuery, logsByBlock map[uint64][]types.Log, provider string) {
+	rc, ok := f.pool.(ReceiptClient)
+	if !ok || f.logVerifyRate <= 0 {
+		return
+	}
+
+	for i := new(big.Int).Set(start); i.Cmp(end) <= 0; i.Add(i, big.NewInt(1)) {
+		if !f.shouldVerifyLogs() {
+			continue
+		}
+		bn := new(big.Int).Set(i)
+
+		reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		receipts, err := rc.BlockReceipts(reqCtx, bn)
+		cancel()
+		if err != nil {
+			f.recordLogVerification("error", provider)
+			Logger.Debug("log_verification_receipts_failed", slog.String("block", bn.String()), slog.String("err", err.Error()))
+			continue
+		}
+
+		expected := logsFromReceipts(receipts, q)
+		got := logsByBlock[bn.Uint64()]
+		if logSetDigest(expected) == logSetDigest(got) {
+			f.recordLogVerification("match", provider)
+			continue
+		}
+
+		f.recordLogVerification("mismatch", provider)
+		Logger.Warn("🚩 [Fetcher] FilterLogs result incomplete, re-fetching block",
+			slog.String("block", bn.String()),
+			slog.String("provider", maskURL(provider)),
+			slog.Int("filter_logs", len(got)),
+			slog.Int("receipt_logs", len(expected)))
+
+		if flagger, ok := f.pool.(ProviderFlagger); ok && provider != "" {
+			flagger.FlagProvider(provider, "filter_logs_incomplete")
+		}
+
+		logsByBlock[bn.Uint64()] = f.refetchBlockLogs(ctx, bn, q, expected)
+	}
+}
+
+// refetchBlockLogs 单块重新抓取日志；若结果仍与回执不一致则直接使用回执派生的日志
+func (f *Fetcher) refetchBlockLogs(ctx context.Context, bn *big.Int, q ethereum.FilterQuery, expected []types.Log) []types.Log {
+	single := q
+	single.FromBlock = bn
+	single.ToBlock = bn
+
+	reqCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	logs, _, err := f.filterLogs(reqCtx, single)
+	cancel()
+
+	if err == nil && logSetDigest(logs) == logSetDigest(expected) {
+		return logs
+	}
+	return expected
+}
+
+func (f *Fetcher) recordLogVerification(result, provider string) {
+	if f.metrics == nil {
+		return
+	}
+	f.metrics.LogVerifications.WithLabelValues(result).Inc()
+	if result == "mismatch" {
+		if provider == "" {
+			provider = "unknown"
+		}
+		f.metrics.LogVerificationMismatch.WithLabelValues(maskURL(provider)).Inc()
+	}
+}
+
+// logsFromReceipts 从回执中重建与 FilterQuery 匹配的日志
+func logsFromReceipts(receipts []*types.Receipt, q ethereum.FilterQuery) []types.Log {
+	var out []types.Log
+	for _, r := range receipts {
+		if r == nil {
+			continue
+		}
+		for _, l := range r.Logs {
+			if l != nil && logMatchesQuery(l, q) {
+				out = append(out, *l)
+			}
+		}
+	}
+	return out
+}
+
+// logMatchesQuery 复刻节点端的 FilterLogs 地址/主题匹配规则
+func logMatchesQuery(l *types.Log, q ethereum.FilterQuery) bool {
+	if len(q.Addresses) > 0 {
+		matched := false
+		for _, addr := range q.Addresses {
+			if addr == l.Address {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(q.Topics) > len(l.Topics) {
+		return false
+	}
+	for i, alternatives := range q.Topics {
+		if len(alternatives) == 0 {
+			continue
+		}
+		matched := false
+		for _, topic := range alternatives {
+			if topic == l.Topics[i] {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// logSetDigest 计算日志集合的顺序无关摘要（tx_hash/log_index 异或哈希 + 完整的 64 位数量）
+func logSetDigest(logs []types.Log) [40]byte {
+	var digest [40]byte
+	for _, l := range logs {
+		var idx [8]byte
+		for i := 0; i < 8; i++ {
+			idx[i] = byte(l.Index >> (8 * i))
+		}
+		h := crypto.Keccak256Hash(l.TxHash.Bytes(), idx[:])
+		for i := 0; i < common.HashLength; i++ {
+			digest[i] ^= h[i]
+		}
+	}
+	binary.BigEndian.PutUint64(digest[common.HashLength:], uint64(len(logs)))
+	return digest
+}
@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// lossyLogClient 模拟丢失日志的提供方：FilterLogs 返回不完整结果，回执是完整的
+type lossyLogClient struct {
+	filtered []types.Log
+	receipts []*types.Receipt
+	flagged  []string
+}
+
+func (c *lossyLogClient) BlockByNumber(context.Context, *big.Int) (*types.Block, error) {
+	return nil, nil
+}
+func (c *lossyLogClient) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return nil, nil
+}
+func (c *lossyLogClient) FilterLogs(context.Context, ethereum.FilterQuery) ([]types.Log, error) {
+	return c.filtered, nil
+}
+func (c *lossyLogClient) GetLatestBlockNumber(context.Context) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+func (c *lossyLogClient) GetHealthyNodeCount() int   { return 1 }
+func (c *lossyLogClient) GetTotalNodeCount() int     { return 1 }
+func (c *lossyLogClient) SetRateLimit(float64, int)  {}
+func (c *lossyLogClient) Close()                     {}
+func (c *lossyLogClient) FlagProvider(url, _ string) { c.flagged = append(c.flagged, url) }
+func (c *lossyLogClient) BlockReceipts(context.Context, *big.Int) ([]*types.Receipt, error) {
+	return c.receipts, nil
+}
+
+func TestLogMatchesQuery(t *testing.T) {
+	token := common.HexToAddress("0x1")
+	other := common.HexToAddress("0x2")
+	l := &types.Log{Address: token, Topics: []common.Hash{TransferEventHash, common.HexToHash("0xa")}}
+
+	assert.True(t, logMatchesQuery(l, ethereum.FilterQuery{}))
+	assert.True(t, logMatchesQuery(l, ethereum.FilterQuery{Addresses: []common.Address{other, token}}))
+	assert.False(t, logMatchesQuery(l, ethereum.FilterQuery{Addresses: []common.Address{other}}))
+	assert.True(t, logMatchesQuery(l, ethereum.FilterQuery{Topics: [][]common.Hash{{TransferEventHash}}}))
+	assert.True(t, logMatchesQuery(l, ethereum.FilterQuery{Topics: [][]common.Hash{{}, {common.HexToHash("0xa")}}}))
+	assert.False(t, logMatchesQuery(l, ethereum.FilterQuery{Topics: [][]common.Hash{{common.HexToHash("0xb")}}}))
+	assert.False(t, logMatchesQuery(l, ethereum.FilterQuery{Topics: [][]common.Hash{{}, {}, {}}}))
+}
+
+func TestLogSetDigestOrderIndependent(t *testing.T) {
+	a := types.Log{TxHash: common.HexToHash("0x01"), Index: 0}
+	b := types.Log{TxHash: common.HexToHash("0x02"), Index: 1}
+
+	assert.Equal(t, logSetDigest([]types.Log{a, b}), logSetDigest([]types.Log{b, a}))
+	assert.NotEqual(t, logSetDigest([]types.Log{a, b}), logSetDigest([]types.Log{a}))
+	assert.NotEqual(t, logSetDigest(nil), logSetDigest([]types.Log{a}))
+
+	// 偶数份相同日志的哈希异或相互抵消，只能靠数量区分；数量不能在 256 处回绕
+	repeated := make([]types.Log, 256)
+	for i := range repeated {
+		repeated[i] = a
+	}
+	assert.NotEqual(t, logSetDigest(nil), logSetDigest(repeated))
+}
+
+func TestVerifySampledLogsRepairsIncompleteResult(t *testing.T) {
+	a := types.Log{BlockNumber: 10, TxHash: common.HexToHash("0x01"), Index: 0}
+	b := types.Log{BlockNumber: 10, TxHash: common.HexToHash("0x02"), Index: 1}
+	client := &lossyLogClient{
+		filtered: []types.Log{a},
+		receipts: []*types.Receipt{{Logs: []*types.Log{&a}}, {Logs: []*types.Log{&b}}},
+	}
+
+	f := &Fetcher{pool: client}
+	f.SetLogVerificationRate(1)
+
+	logsByBlock := map[uint64][]types.Log{10: {a}}
+	f.verifySampledLogs(context.Background(), big.NewInt(10), big.NewInt(10), ethereum.FilterQuery{}, logsByBlock, "http://lossy")
+
+	assert.Len(t, logsByBlock[10], 2, "receipt-derived logs should replace the incomplete result")
+	assert.Equal(t, []string{"http://lossy"}, client.flagged)
+}
+
+func TestVerifySampledLogsDisabled(t *testing.T) {
+	client := &lossyLogClient{receipts: []*types.Receipt{{Logs: []*types.Log{{TxHash: common.HexToHash("0x01")}}}}}
+	f := &Fetcher{pool: client}
+	f.SetLogVerificationRate(0)
+
+	logsByBlock := map[uint64][]types.Log{}
+	f.verifySampledLogs(context.Background(), big.NewInt(1), big.NewInt(1), ethereum.FilterQuery{}, logsByBlock, "http://lossy")
+
+	assert.Empty(t, logsByBlock[1])
+	assert.Empty(t, client.flagged)
+}
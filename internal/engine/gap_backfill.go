@@ -0,0 +1,268 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"time"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	defaultBackfillInterval    = 30 * time.Second
+	defaultBackfillChunkBlocks = 20
+	defaultBackfillMinQuota    = 0.8
+	backfillCommitWaitTicks    = 3 // 分发后最多等待几轮落盘确认，超出则重做该批（写入幂等）
+)
+
+// GapBackfillConfig 跳过区间回填参数
+type GapBackfillConfig struct {
+	Interval         time.Duration // 两轮之间的间隔
+	ChunkBlocks      uint64        // 每轮最多回填的块数
+	MinQuotaHeadroom float64       // RPC 全局限流桶剩余比例不低于该值才回填（0–1）
+}
+
+// GapBackfillStore 回填进度读写（*storage.Postgres 实现）
+type GapBackfillStore interface {
+	NextPendingSkippedRange(ctx context.Context) (*storage.SkippedRangeRow, error)
+	MarkSkippedRangeBackfilled(ctx context.Context, id int64, to models.Height, done bool) error
+	GetBlockHash(ctx context.Context, number string) (string, error)
+}
+
+type backfillSource interface {
+	fetchBackfillRange(ctx context.Context, start, end uint64) ([]BlockData, error)
+}
+
+type backfillSink interface {
+	BackfillBlock(ctx context.Context, data BlockData) error
+}
+
+// quotaHeadroomer RPC 池剩余配额（*EnhancedRPCClientPool 实现；未实现的客户端视为配额充裕）
+type quotaHeadroomer interface {
+	QuotaHeadroom() float64
+}
+
+// backfillChunk 已分发、等待落盘确认的一批回填
+type backfillChunk struct {
+	rangeID  int64
+	from, to uint64
+	last     bool // 补完即整段结束
+	waited   int
+}
+
+// GapBackfiller 低优先级后台回填：同步追平链头且 RPC 配额充裕时，逐批补齐 skipped_ranges 中的区间，
+// 随时间自动关闭数据空洞。回填区块走正常落盘路径，但不推进 checkpoint（PersistTask.Backfill），
+// 每批落盘确认后才记录进度，进程重启后从进度处继续
+type GapBackfiller struct {
+	cfg      GapBackfillConfig
+	store    GapBackfillStore
+	source   backfillSource
+	sink     backfillSink
+	quota    quotaHeadroomer
+	snapshot func() CoordinatorState
+	inflight *backfillChunk
+}
+
+// NewGapBackfiller 创建回填器；零值参数取默认值
+func NewGapBackfiller(store GapBackfillStore, fetcher *Fetcher, processor *Processor, client RPCClient, cfg GapBackfillConfig) *GapBackfiller {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultBackfillInterval
+	}
+	if cfg.ChunkBlocks == 0 {
+		cfg.ChunkBlocks = defaultBackfillChunkBlocks
+	}
+	if cfg.MinQuotaHeadroom <= 0 {
+		cfg.MinQuotaHeadroom = defaultBackfillMinQuota
+	}
+	b := &GapBackfiller{
+		cfg:      cfg,
+		store:    store,
+		source:   fetcher,
+		sink:     processor,
+		snapshot: GetOrchestrator().GetSnapshot,
+	}
+	if q, ok := client.(quotaHeadroomer); ok {
+		b.quota = q
+	}
+	return b
+}
+
+// Run 按间隔执行回填直到 ctx 结束
+func (b *GapBackfiller) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.cfg.Interval)
+	defer ticker.Stop()
+	Logger.Info("🩹 gap_backfill_worker_started", slog.Duration("interval", b.cfg.Interval),
+		slog.Uint64("chunk_blocks", b.cfg.ChunkBlocks), slog.Float64("min_quota_headroom", b.cfg.MinQuotaHeadroom))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.Tick(ctx); err != nil {
+				Logger.Warn("gap_backfill_tick_failed", "err", err)
+			}
+		}
+	}
+}
+
+// Tick 执行一轮：先确认上一批已落盘并记录进度，空闲时再抓取并分发下一批
+func (b *GapBackfiller) Tick(ctx context.Context) error {
+	if b.inflight != nil {
+		if ready, err := b.confirm(ctx); err != nil || !ready {
+			return err
+		}
+	}
+	if reason := b.deferReason(); reason != "" {
+		Logger.Debug("gap_backfill_deferred", "reason", reason)
+		return nil
+	}
+
+	r, err := b.store.NextPendingSkippedRange(ctx)
+	if err != nil || r == nil {
+		return err
+	}
+	from, to := r.NextBackfill(), uint64(r.ToBlock)
+	if from > to {
+		return b.store.MarkSkippedRangeBackfilled(ctx, r.ID, r.ToBlock, true)
+	}
+	end := min(to, from+b.cfg.ChunkBlocks-1)
+
+	blocks, err := b.source.fetchBackfillRange(ctx, from, end)
+	if err != nil {
+		return fmt.Errorf("fetch %d-%d: %w", from, end, err)
+	}
+	for _, data := range blocks {
+		if err := b.sink.BackfillBlock(ctx, data); err != nil {
+			return fmt.Errorf("backfill block %s: %w", data.Number, err)
+		}
+	}
+	b.inflight = &backfillChunk{rangeID: r.ID, from: from, to: end, last: end == to}
+	Logger.Info("🩹 gap_backfill_dispatched", "range_id", r.ID, "from", from, "to", end, "reason", r.Reason)
+	return nil
+}
+
+// deferReason 返回本轮不回填的原因（空串表示可以回填）：同步落后于目标高度，或 RPC 配额不充裕
+func (b *GapBackfiller) deferReason() string {
+	snap := b.snapshot()
+	target := snap.TargetHeight
+	if target == 0 {
+		target = snap.LatestHeight
+	}
+	if target == 0 {
+		return "no_head"
+	}
+	if snap.SyncedCursor < target {
+		return "sync_lag"
+	}
+	if b.quota != nil && b.quota.QuotaHeadroom() < b.cfg.MinQuotaHeadroom {
+		return "quota"
+	}
+	return ""
+}
+
+// confirm 检查上一批的最后一块是否已落盘；已落盘则记录进度，返回 true 表示可以继续下一批
+func (b *GapBackfiller) confirm(ctx context.Context) (bool, error) {
+	c := b.inflight
+	_, err := b.store.GetBlockHash(ctx, strconv.FormatUint(c.to, 10))
+	if errors.Is(err, sql.ErrNoRows) {
+		c.waited++
+		if c.waited < backfillCommitWaitTicks {
+			return false, nil
+		}
+		Logger.Warn("gap_backfill_commit_missing", "range_id", c.rangeID, "from", c.from, "to", c.to)
+		b.inflight = nil
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := b.store.MarkSkippedRangeBackfilled(ctx, c.rangeID, models.Height(c.to), c.last); err != nil {
+		return false, err
+	}
+	GetMetrics().RecordBackfilledBlocks(c.to - c.from + 1)
+	if c.last {
+		Logger.Info("🩹 gap_backfill_range_closed", "range_id", c.rangeID, "to", c.to)
+	}
+	b.inflight = nil
+	return true, nil
+}
+
+// fetchBackfillRange 抓取回填区间：日志过滤与实时抓取一致，但逐块拉取完整区块（区块行本身也是空洞的一部分）
+func (f *Fetcher) fetchBackfillRange(ctx context.Context, start, end uint64) ([]BlockData, error) {
+	q := f.logQuery(new(big.Int).SetUint64(start), new(big.Int).SetUint64(end))
+	logs, _, err := f.filterLogsAdaptive(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	logsByBlock := make(map[uint64][]types.Log)
+	for _, vLog := range logs {
+		logsByBlock[vLog.BlockNumber] = append(logsByBlock[vLog.BlockNumber], vLog)
+	}
+
+	out := make([]BlockData, 0, end-start+1)
+	for n := start; n <= end; n++ {
+		bn := new(big.Int).SetUint64(n)
+		block, err := f.pool.BlockByNumber(ctx, bn)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", n, err)
+		}
+		block, blockLogs, err := f.ensureBlockIntegrity(ctx, bn, q, block, logsByBlock[n])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, BlockData{
+			Number:   bn,
+			Block:    block,
+			Logs:     blockLogs,
+			TraceID:  pipelineTrace.begin(n),
+			Receipts: f.fetchBlockReceipts(ctx, block),
+			Traces:   f.fetchBlockTraces(ctx, bn),
+		})
+	}
+	return out, nil
+}
+
+// BackfillBlock 回填一个此前被跳过的区块：提取与落盘同 ProcessBlock，但不做 reorg 检测、
+// 不合成模拟数据、不推送实时事件，落盘时不推进 checkpoint
+func (p *Processor) BackfillBlock(ctx context.Context, data BlockData) error {
+	if data.Err != nil {
+		return fmt.Errorf("fetch error: %w", data.Err)
+	}
+	if data.Block == nil {
+		return fmt.Errorf("block %s not fetched", data.Number)
+	}
+	block := data.Block
+	blockNum := block.Number()
+
+	activities := p.extractActivities(ctx, blockNum, data.Logs, block.Transactions())
+	activities = dropFailedTxActivities(blockNum, activities, data.Receipts)
+	activities = append(activities, p.extractInternalTransfers(blockNum, data.Traces)...)
+	warmActivityAddresses(activities)
+
+	mBlock := newBlockModel(block)
+	task := PersistTask{
+		Height:    blockNum.Uint64(),
+		Block:     mBlock,
+		Transfers: activities,
+		Arbitrage: detectArbitrage(blockNum.Uint64(), data.Logs),
+		Receipts:  buildReceiptRows(block, data.Receipts),
+		NFTs:      p.extractNFTTransfers(data.Logs),
+		Approvals: p.extractApprovals(data.Logs),
+		Events:    p.extractContractEvents(data.Logs),
+		Blobs:     buildBlobTxRows(block),
+		Fees:      buildBlockFeeRow(block, data.Receipts),
+		TraceID:   data.TraceID,
+		Backfill:  true,
+	}
+	seq := GetOrchestrator().Dispatch(CmdCommitBatch, task)
+	pipelineTrace.record(task.TraceID, task.Height, TraceStageDispatched, seq, fmt.Sprintf("backfill transfers=%d", len(activities)))
+	return nil
+}
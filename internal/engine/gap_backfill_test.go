@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBackfillStore struct {
+	ranges    []storage.SkippedRangeRow
+	committed map[uint64]bool
+}
+
+func (s *fakeBackfillStore) NextPendingSkippedRange(context.Context) (*storage.SkippedRangeRow, error) {
+	for i := range s.ranges {
+		if s.ranges[i].Pending() {
+			row := s.ranges[i]
+			return &row, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeBackfillStore) MarkSkippedRangeBackfilled(_ context.Context, id int64, to models.Height, done bool) error {
+	for i := range s.ranges {
+		if s.ranges[i].ID == id {
+			s.ranges[i].BackfilledTo = &to
+			if done {
+				now := s.ranges[i].SkippedAt
+				s.ranges[i].BackfilledAt = &now
+			}
+		}
+	}
+	return nil
+}
+
+func (s *fakeBackfillStore) GetBlockHash(_ context.Context, number string) (string, error) {
+	n, _ := strconv.ParseUint(number, 10, 64)
+	if s.committed[n] {
+		return "0x01", nil
+	}
+	return "", sql.ErrNoRows
+}
+
+type fakeBackfillSource struct{ fetched [][2]uint64 }
+
+func (s *fakeBackfillSource) fetchBackfillRange(_ context.Context, start, end uint64) ([]BlockData, error) {
+	s.fetched = append(s.fetched, [2]uint64{start, end})
+	var out []BlockData
+	for n := start; n <= end; n++ {
+		out = append(out, BlockData{Number: new(big.Int).SetUint64(n)})
+	}
+	return out, nil
+}
+
+// fakeBackfillSink 模拟 AsyncWriter：分发即视为落盘
+type fakeBackfillSink struct{ store *fakeBackfillStore }
+
+func (s *fakeBackfillSink) BackfillBlock(_ context.Context, data BlockData) error {
+	s.store.committed[data.Number.Uint64()] = true
+	return nil
+}
+
+type fixedQuota float64
+
+func (q fixedQuota) QuotaHeadroom() float64 { return float64(q) }
+
+func TestGapBackfiller_Tick(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeBackfillStore{
+		ranges:    []storage.SkippedRangeRow{{ID: 1, FromBlock: 100, ToBlock: 124, Reason: SkipReasonLeapSync}},
+		committed: map[uint64]bool{},
+	}
+	source := &fakeBackfillSource{}
+	snap := CoordinatorState{LatestHeight: 1000, TargetHeight: 1000, SyncedCursor: 990}
+	quota := fixedQuota(1)
+	b := &GapBackfiller{
+		cfg:      GapBackfillConfig{ChunkBlocks: 20, MinQuotaHeadroom: 0.8},
+		store:    store,
+		source:   source,
+		sink:     &fakeBackfillSink{store: store},
+		snapshot: func() CoordinatorState { return snap },
+	}
+	b.quota = &quota
+
+	require.NoError(t, b.Tick(ctx))
+	assert.Empty(t, source.fetched, "no backfill while the index lags the target height")
+
+	snap.SyncedCursor = 1000
+	quota = 0.5
+	require.NoError(t, b.Tick(ctx))
+	assert.Empty(t, source.fetched, "no backfill while the RPC quota is low")
+
+	quota = 1
+	require.NoError(t, b.Tick(ctx))
+	assert.Equal(t, [][2]uint64{{100, 119}}, source.fetched)
+	assert.Nil(t, store.ranges[0].BackfilledTo, "progress is only recorded once the chunk is on disk")
+
+	require.NoError(t, b.Tick(ctx))
+	require.NotNil(t, store.ranges[0].BackfilledTo)
+	assert.Equal(t, models.Height(119), *store.ranges[0].BackfilledTo)
+	assert.True(t, store.ranges[0].Pending())
+	assert.Equal(t, [][2]uint64{{100, 119}, {120, 124}}, source.fetched)
+
+	require.NoError(t, b.Tick(ctx))
+	assert.False(t, store.ranges[0].Pending(), "the last chunk closes the range")
+	assert.Len(t, source.fetched, 2)
+}
+
+func TestGapBackfiller_RedoUncommittedChunk(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeBackfillStore{
+		ranges:    []storage.SkippedRangeRow{{ID: 7, FromBlock: 10, ToBlock: 12, Reason: SkipReasonDLQExhausted}},
+		committed: map[uint64]bool{},
+	}
+	source := &fakeBackfillSource{}
+	b := &GapBackfiller{
+		cfg:      GapBackfillConfig{ChunkBlocks: 20, MinQuotaHeadroom: 0.8},
+		store:    store,
+		source:   source,
+		sink:     backfillSinkFunc(func(context.Context, BlockData) error { return nil }), // 写入丢失
+		snapshot: func() CoordinatorState { return CoordinatorState{LatestHeight: 50, SyncedCursor: 50} },
+	}
+
+	for range backfillCommitWaitTicks + 1 {
+		require.NoError(t, b.Tick(ctx))
+	}
+	assert.Equal(t, [][2]uint64{{10, 12}, {10, 12}}, source.fetched, "an unconfirmed chunk is fetched again")
+	assert.Nil(t, store.ranges[0].BackfilledTo)
+}
+
+type backfillSinkFunc func(context.Context, BlockData) error
+
+func (f backfillSinkFunc) BackfillBlock(ctx context.Context, data BlockData) error {
+	return f(ctx, data)
+}
@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+)
+
+const (
+	// SkipReason* 区块被跳过的原因（skipped_ranges.reason 与 indexer_skipped_blocks_total 标签）
+	SkipReasonLeapSync       = "leap_sync"       // 演示模式：链头远超数据库，游标直接对齐链头
+	SkipReasonGapBypass      = "gap_bypass"      // Sequencer 缺口 gap-fill 重试耗尽后越过
+	SkipReasonBufferOverflow = "buffer_overflow" // Sequencer 缓冲区溢出，越过缺口释放内存
+	SkipReasonStallSkip      = "stall_skip"      // 处理器卡死且缓冲区无后续块，强制跳过一个块
+	SkipReasonDLQExhausted   = "dlq_exhausted"   // 重试队列中的区块重试耗尽，进入死信
+
+	defaultLeapThreshold  = 1000
+	skippedRangesInMemory = 100 // 未落库时 API 展示的最近区间数
+	skippedRangeTimeout   = 5 * time.Second
+)
+
+var (
+	gapSkipper     *GapSkipper
+	gapSkipperOnce sync.Once
+)
+
+// GapSkipPolicy 区块跳过策略。
+// LeapSync 只在演示模式开启：链头领先数据库超过 LeapThreshold 时不追赶历史，游标直接对齐链头。
+// MaxSkippableGap 限制 Leap-Sync 与 gap-fill 耗尽后的越过：超出时 Leap-Sync 改为正常追赶，
+// Sequencer 继续 gap-fill 重试；缓冲区溢出与单块卡死的跳过是内存 / 活性保护，不受限制。
+type GapSkipPolicy struct {
+	LeapSync        bool   `json:"leap_sync"`
+	LeapThreshold   uint64 `json:"leap_threshold"`
+	MaxSkippableGap uint64 `json:"max_skippable_gap"` // 0 不限
+	RecordSkipped   bool   `json:"record_skipped"`    // 跳过的区间写入 skipped_ranges 供后续回填
+}
+
+// DefaultGapSkipPolicy 默认策略：不做 Leap-Sync，越过不设上限，记录跳过区间
+func DefaultGapSkipPolicy() GapSkipPolicy {
+	return GapSkipPolicy{LeapThreshold: defaultLeapThreshold, RecordSkipped: true}
+}
+
+// AllowSkip 一次跳过 blocks 个块是否在策略上限之内
+func (p GapSkipPolicy) AllowSkip(blocks uint64) bool {
+	return p.MaxSkippableGap == 0 || blocks <= p.MaxSkippableGap
+}
+
+// AllowLeap 链头领先数据库 gap 个块时是否执行 Leap-Sync
+func (p GapSkipPolicy) AllowLeap(gap uint64) bool {
+	return p.LeapSync && gap > p.LeapThreshold && p.AllowSkip(gap)
+}
+
+// SkippedRangeStore 跳过区间持久化（*storage.Postgres 实现）
+type SkippedRangeStore interface {
+	InsertSkippedRange(ctx context.Context, row storage.SkippedRangeRow) (int64, error)
+}
+
+// GapSkipper 持有跳过策略并记录每一次跳过：指标、最近区间（内存）与 skipped_ranges 表
+type GapSkipper struct {
+	mu     sync.Mutex
+	policy GapSkipPolicy
+	store  SkippedRangeStore
+	recent []storage.SkippedRangeRow
+	now    func() time.Time
+}
+
+// GetGapSkipper 返回跳过策略单例（启动流程通过 Configure 设置策略与存储）
+func GetGapSkipper() *GapSkipper {
+	gapSkipperOnce.Do(func() {
+		gapSkipper = newGapSkipper()
+	})
+	return gapSkipper
+}
+
+func newGapSkipper() *GapSkipper {
+	return &GapSkipper{policy: DefaultGapSkipPolicy(), now: time.Now}
+}
+
+// Configure 设置策略与存储（store 可为 nil，仅保留内存记录）
+func (g *GapSkipper) Configure(policy GapSkipPolicy, store SkippedRangeStore) {
+	if policy.LeapThreshold == 0 {
+		policy.LeapThreshold = defaultLeapThreshold
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.policy = policy
+	g.store = store
+}
+
+// Policy 当前策略
+func (g *GapSkipper) Policy() GapSkipPolicy {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.policy
+}
+
+// Record 记录 [from, to] 被跳过；RecordSkipped 开启且配置了存储时异步写入 skipped_ranges（写入失败只告警）。
+// 不阻塞调用方，Sequencer 可在持锁时调用
+func (g *GapSkipper) Record(ctx context.Context, from, to uint64, reason string) {
+	if to < from {
+		return
+	}
+	row := storage.SkippedRangeRow{
+		FromBlock: models.Height(from),
+		ToBlock:   models.Height(to),
+		Reason:    reason,
+	}
+	g.mu.Lock()
+	row.SkippedAt = g.now()
+	g.recent = append(g.recent, row)
+	if over := len(g.recent) - skippedRangesInMemory; over > 0 {
+		g.recent = append(g.recent[:0], g.recent[over:]...)
+	}
+	store := g.store
+	if !g.policy.RecordSkipped {
+		store = nil
+	}
+	g.mu.Unlock()
+
+	GetMetrics().RecordSkippedBlocks(reason, to-from+1)
+	if store != nil {
+		go func() {
+			writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), skippedRangeTimeout)
+			defer cancel()
+			if _, err := store.InsertSkippedRange(writeCtx, row); err != nil {
+				slog.Warn("skipped_range_record_failed", "from", from, "to", to, "reason", reason, "err", err)
+			}
+		}()
+	}
+	Logger.Warn("⏭️ blocks_skipped", "from", from, "to", to, "blocks", to-from+1, "reason", reason)
+}
+
+// Recent 本进程记录的最近 limit 个跳过区间（新的在前）
+func (g *GapSkipper) Recent(limit int) []storage.SkippedRangeRow {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	out := make([]storage.SkippedRangeRow, 0, min(limit, len(g.recent)))
+	for i := len(g.recent) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, g.recent[i])
+	}
+	return out
+}
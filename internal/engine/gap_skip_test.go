@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSkippedRangeStore struct {
+	mu   sync.Mutex
+	rows []storage.SkippedRangeRow
+}
+
+func (s *fakeSkippedRangeStore) InsertSkippedRange(_ context.Context, row storage.SkippedRangeRow) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rows = append(s.rows, row)
+	return int64(len(s.rows)), nil
+}
+
+func (s *fakeSkippedRangeStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.rows)
+}
+
+func TestGapSkipPolicy(t *testing.T) {
+	p := DefaultGapSkipPolicy()
+	assert.False(t, p.AllowLeap(1_000_000), "Leap-Sync is off outside demo mode")
+	assert.True(t, p.AllowSkip(1_000_000), "no cap by default")
+
+	p.LeapSync = true
+	assert.False(t, p.AllowLeap(1000))
+	assert.True(t, p.AllowLeap(1001))
+
+	p.MaxSkippableGap = 5000
+	assert.True(t, p.AllowLeap(5000))
+	assert.False(t, p.AllowLeap(5001))
+	assert.False(t, p.AllowSkip(5001))
+}
+
+func TestGapSkipper_Record(t *testing.T) {
+	store := &fakeSkippedRangeStore{}
+	g := newGapSkipper()
+	g.now = func() time.Time { return time.Unix(1_700_000_000, 0) }
+	g.Configure(GapSkipPolicy{RecordSkipped: true}, store)
+	assert.Equal(t, uint64(defaultLeapThreshold), g.Policy().LeapThreshold)
+
+	g.Record(context.Background(), 101, 101, SkipReasonStallSkip)
+	g.Record(context.Background(), 200, 249, SkipReasonGapBypass)
+	g.Record(context.Background(), 300, 299, SkipReasonBufferOverflow) // 空区间忽略
+
+	recent := g.Recent(10)
+	require.Len(t, recent, 2)
+	assert.Equal(t, SkipReasonGapBypass, recent[0].Reason)
+	assert.Equal(t, models.Height(200), recent[0].FromBlock)
+	assert.Equal(t, models.Height(249), recent[0].ToBlock)
+	assert.Len(t, g.Recent(1), 1)
+	require.Eventually(t, func() bool { return store.len() == 2 }, time.Second, 10*time.Millisecond)
+
+	// 关闭记录后只保留内存
+	g.Configure(GapSkipPolicy{}, store)
+	g.Record(context.Background(), 400, 409, SkipReasonLeapSync)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 2, store.len())
+	assert.Len(t, g.Recent(10), 3)
+
+	for i := uint64(0); i < skippedRangesInMemory+5; i++ {
+		g.Record(context.Background(), 1000+i, 1000+i, SkipReasonStallSkip)
+	}
+	assert.Len(t, g.Recent(1000), skippedRangesInMemory)
+}
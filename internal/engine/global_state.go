@@ -98,6 +98,9 @@ type GlobalState struct {
 	resultsDepth    int32
 	sequencerBuffer int32
 
+	// 🚨 Sequencer 停滞状态（缺口 / 无进度）
+	sequencerStall SequencerStallState
+
 	// 订阅者
 	subscribers []chan Snapshot
 }
@@ -220,6 +223,22 @@ func (s *GlobalState) RecordQuota(usage float64) {
 	s.lastUpdate = time.Now()
 }
 
+// SetSequencerStall 更新 Sequencer 停滞状态（由 Sequencer 调用，零值表示已恢复）
+func (s *GlobalState) SetSequencerStall(state SequencerStallState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sequencerStall = state
+	s.lastUpdate = time.Now()
+}
+
+// SequencerStall 获取 Sequencer 停滞状态
+func (s *GlobalState) SequencerStall() SequencerStallState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sequencerStall.withIdle()
+}
+
 // notifySubscribers 通知所有订阅者
 func (s *GlobalState) notifySubscribers() {
 	snap := s.Snapshot()
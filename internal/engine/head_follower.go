@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"time"
+)
+
+const (
+	headSourceWSS  = "wss"
+	headSourcePoll = "poll"
+
+	// DefaultHeadSafetyPoll WSS 在线时的兜底轮询间隔：推送静默超过该时长仍查询一次链头，防止订阅“假活”
+	DefaultHeadSafetyPoll = 30 * time.Second
+)
+
+// HeadFollower TailFollow 的链头节拍。
+// 配置了 WSS 监听器且订阅在线时由 newHeads 推送驱动，新块号直接交给调度；
+// 订阅断开（Dropped）或未连接时回落到 EcoPoller 轮询 RPC，重连后自动切回推送
+type HeadFollower struct {
+	poller   *EcoPoller
+	client   RPCClient
+	listener *WSSListener
+	safety   time.Duration
+	eco      ecoSource
+}
+
+// NewHeadFollower 创建链头节拍；listener 为 nil 时为纯轮询；safety <= 0 使用 DefaultHeadSafetyPoll
+func NewHeadFollower(poller *EcoPoller, client RPCClient, listener *WSSListener, safety time.Duration) *HeadFollower {
+	if safety <= 0 {
+		safety = DefaultHeadSafetyPoll
+	}
+	return &HeadFollower{poller: poller, client: client, listener: listener, safety: safety, eco: poller.src}
+}
+
+// pushMode 订阅在线且未休眠时使用推送（休眠模式保持轮询衰减，不随每个新块唤醒调度）
+func (h *HeadFollower) pushMode() bool {
+	if h.listener == nil || !h.listener.IsConnected() {
+		return false
+	}
+	return h.eco == nil || !h.eco.GetSnapshot().IsEcoMode
+}
+
+// Next 阻塞到下一个链头。ctx 结束时返回 ctx.Err()；轮询失败返回 RPC 错误，调用方跳过本轮即可
+func (h *HeadFollower) Next(ctx context.Context) (*big.Int, error) {
+	if h.pushMode() {
+		if head, ok := h.waitPush(ctx); ok {
+			GetChainHeadCache().Observe(head)
+			GetMetrics().RecordHeadUpdate(headSourceWSS)
+			GetUpstreamWatch().Observe(head.Uint64())
+			return head, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	} else if !h.poller.Wait(ctx) {
+		return nil, ctx.Err()
+	}
+
+	head, err := LatestChainHead(ctx, h.client)
+	if err != nil {
+		return nil, err
+	}
+	GetMetrics().RecordHeadUpdate(headSourcePoll)
+	GetUpstreamWatch().Observe(head.Uint64())
+	return head, nil
+}
+
+// waitPush 等待推送的块号（积压时取最新）；订阅断开、兜底周期到期或 ctx 结束时返回 false
+func (h *HeadFollower) waitPush(ctx context.Context) (*big.Int, bool) {
+	timer := time.NewTimer(h.safety)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return nil, false
+	case <-h.listener.Dropped():
+		return nil, false
+	case <-timer.C:
+		return nil, false
+	case head := <-h.listener.GetNewBlocks():
+		for {
+			select {
+			case newer := <-h.listener.GetNewBlocks():
+				if newer.Cmp(head) > 0 {
+					head = newer
+				}
+			default:
+				return head, true
+			}
+		}
+	}
+}
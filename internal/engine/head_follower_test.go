@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHeadSubscriber 把 heads 转发给订阅者，向 fail 发送错误模拟断线
+type fakeHeadSubscriber struct {
+	heads chan *types.Header
+	fail  chan error
+	subs  atomic.Int32
+}
+
+func newFakeHeadSubscriber() *fakeHeadSubscriber {
+	return &fakeHeadSubscriber{heads: make(chan *types.Header), fail: make(chan error)}
+}
+
+func (f *fakeHeadSubscriber) SubscribeNewHead(_ context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	f.subs.Add(1)
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		for {
+			select {
+			case h := <-f.heads:
+				ch <- h
+			case err := <-f.fail:
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+func (f *fakeHeadSubscriber) Close() {}
+
+// polledHeadClient 轮询链头固定返回 head 并计数
+type polledHeadClient struct {
+	lossyLogClient
+	head  int64
+	polls atomic.Int32
+}
+
+func (c *polledHeadClient) GetLatestBlockNumber(context.Context) (*big.Int, error) {
+	c.polls.Add(1)
+	return big.NewInt(c.head), nil
+}
+
+func startFakeListener(t *testing.T, ctx context.Context, sub *fakeHeadSubscriber) *WSSListener {
+	t.Helper()
+	l, err := NewWSSListener("ws://fake")
+	require.NoError(t, err)
+	l.dial = func(context.Context, string) (headSubscriber, error) { return sub, nil }
+	l.baseBackoff, l.maxBackoff = time.Millisecond, time.Millisecond
+	l.Start(ctx)
+	t.Cleanup(l.Stop)
+	require.Eventually(t, l.IsConnected, time.Second, time.Millisecond)
+	return l
+}
+
+func TestHeadFollower_PushDrivesSchedulingWithoutPolling(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := newFakeHeadSubscriber()
+	listener := startFakeListener(t, ctx, sub)
+	client := &polledHeadClient{head: 1}
+	f := NewHeadFollower(NewEcoPoller("test_head", time.Hour, nil, nil), client, listener, time.Hour)
+
+	go func() { sub.heads <- &types.Header{Number: big.NewInt(42)} }()
+	head, err := f.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), head.Int64())
+	assert.Zero(t, client.polls.Load(), "pushed heads must not hit the RPC")
+}
+
+func TestHeadFollower_FallsBackToPollingWhenSocketDrops(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := newFakeHeadSubscriber()
+	listener := startFakeListener(t, ctx, sub)
+	listener.baseBackoff, listener.maxBackoff = time.Hour, time.Hour // 断线后保持离线
+	client := &polledHeadClient{head: 7}
+	f := NewHeadFollower(NewEcoPoller("test_head", time.Millisecond, nil, nil), client, listener, time.Hour)
+
+	sub.fail <- errors.New("connection reset")
+	require.Eventually(t, func() bool { return !listener.IsConnected() }, time.Second, time.Millisecond)
+
+	head, err := f.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), head.Int64())
+	assert.Equal(t, int32(1), client.polls.Load())
+}
+
+func TestHeadFollower_ReconnectsAfterDrop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := newFakeHeadSubscriber()
+	listener := startFakeListener(t, ctx, sub)
+
+	sub.fail <- errors.New("connection reset")
+	require.Eventually(t, func() bool { return sub.subs.Load() >= 2 && listener.IsConnected() }, time.Second, time.Millisecond)
+}
+
+func TestWSSListener_PublishKeepsNewestWhenConsumerLags(t *testing.T) {
+	l, err := NewWSSListener("ws://fake")
+	require.NoError(t, err)
+	for i := int64(1); i <= int64(cap(l.newBlocks))+5; i++ {
+		l.publish(big.NewInt(i))
+	}
+	var last *big.Int
+	for len(l.newBlocks) > 0 {
+		last = <-l.newBlocks
+	}
+	assert.Equal(t, int64(cap(l.newBlocks))+5, last.Int64())
+}
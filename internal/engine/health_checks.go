@@ -6,7 +6,37 @@ import (
 	"time"
 )
 
-const healthyStatus = "healthy"
+const (
+	healthyStatus   = "healthy"
+	warningStatus   = "warning"
+	unhealthyStatus = "unhealthy"
+)
+
+// overallStatus 汇总各检查项：任一 unhealthy 即 unhealthy，否则任一 warning 即 warning
+func overallStatus(checks map[string]Check) string {
+	result := healthyStatus
+	for _, c := range checks {
+		switch c.Status {
+		case unhealthyStatus:
+			return unhealthyStatus
+		case warningStatus:
+			result = warningStatus
+		}
+	}
+	return result
+}
+
+// gradeThreshold 按阈值分级：value > limit 为 warning，> 2*limit 为 unhealthy；limit <= 0 不检查
+func gradeThreshold(value, limit float64) string {
+	switch {
+	case limit <= 0 || value <= limit:
+		return healthyStatus
+	case value > 2*limit:
+		return unhealthyStatus
+	default:
+		return warningStatus
+	}
+}
 
 // checkDatabase 检查数据库连接
 func (h *HealthServer) checkDatabase(ctx context.Context) Check {
@@ -17,7 +47,7 @@ func (h *HealthServer) checkDatabase(ctx context.Context) Check {
 
 	if err != nil {
 		return Check{
-			Status:  "unhealthy",
+			Status:  unhealthyStatus,
 			Message: err.Error(),
 			Latency: latency.String(),
 		}
@@ -42,15 +72,17 @@ func (h *HealthServer) checkRPC(ctx context.Context) Check {
 
 	if err != nil {
 		return Check{
-			Status:  "unhealthy",
+			Status:  unhealthyStatus,
 			Message: fmt.Sprintf("rpc_nodes: %d/%d healthy, error: %s", healthyCount, totalCount, err.Error()),
 			Latency: latency.String(),
 		}
 	}
 
 	status := healthyStatus
-	if healthyCount < totalCount {
-		status = "degraded"
+	if healthyCount < h.thresholds.MinHealthyNodes {
+		status = unhealthyStatus
+	} else if healthyCount < totalCount {
+		status = warningStatus
 	}
 
 	return Check{
@@ -64,7 +96,7 @@ func (h *HealthServer) checkRPC(ctx context.Context) Check {
 func (h *HealthServer) checkSequencer(_ context.Context) Check {
 	if h.sequencer == nil {
 		return Check{
-			Status:  "unhealthy",
+			Status:  unhealthyStatus,
 			Message: "sequencer not initialized",
 		}
 	}
@@ -72,11 +104,24 @@ func (h *HealthServer) checkSequencer(_ context.Context) Check {
 	bufferSize := h.sequencer.GetBufferSize()
 	expectedBlock := h.sequencer.GetExpectedBlock()
 
+	// 🚨 停滞（缺口或长时间无进度）直接判定为不健康，便于外部监控告警
+	if stall := h.sequencer.StallState(); stall.Stalled {
+		msg := fmt.Sprintf("stalled (%s) at %s, idle %.0fs, gap_fill_attempts: %d",
+			stall.Reason, stall.ExpectedBlock, stall.IdleSeconds, stall.GapFillAttempts)
+		if stall.Reason == stallReasonGap {
+			msg += fmt.Sprintf(", gap %s-%s", stall.GapFrom, stall.GapTo)
+		}
+		return Check{
+			Status:  unhealthyStatus,
+			Message: msg,
+		}
+	}
+
 	// 如果 buffer 过大，可能有问题
-	if bufferSize > 500 {
+	if status := gradeThreshold(float64(bufferSize), float64(h.thresholds.MaxSequencerBuffer)); status != healthyStatus {
 		return Check{
-			Status:  "degraded",
-			Message: fmt.Sprintf("buffer_size: %d (high)", bufferSize),
+			Status:  status,
+			Message: fmt.Sprintf("buffer_size: %d (limit %d)", bufferSize, h.thresholds.MaxSequencerBuffer),
 		}
 	}
 
@@ -86,11 +131,31 @@ func (h *HealthServer) checkSequencer(_ context.Context) Check {
 	}
 }
 
+// checkUpstream 检查上游链头是否仍在前进。停滞时为 warning：实例本身无故障，摘除也无济于事，
+// 但外部监控可据此区分“索引器卡住”与“链 / 节点不出块”
+func (h *HealthServer) checkUpstream(_ context.Context) Check {
+	up := GetUpstreamWatch().State()
+	switch {
+	case up.ThresholdSeconds <= 0:
+		return Check{Status: healthyStatus, Message: "upstream stall detection disabled"}
+	case up.Stalled:
+		return Check{
+			Status: warningStatus,
+			Message: fmt.Sprintf("%s: chain head %s unchanged for %.0fs (threshold %.0fs)",
+				StateUpstreamStalled, up.ChainHead, up.UnchangedSeconds, up.ThresholdSeconds),
+		}
+	}
+	return Check{
+		Status:  healthyStatus,
+		Message: fmt.Sprintf("chain_head: %s, unchanged_for: %.0fs", up.ChainHead, up.UnchangedSeconds),
+	}
+}
+
 // checkFetcher 检查 Fetcher 状态
 func (h *HealthServer) checkFetcher(_ context.Context) Check {
 	if h.fetcher == nil {
 		return Check{
-			Status:  "unhealthy",
+			Status:  unhealthyStatus,
 			Message: "fetcher not initialized",
 		}
 	}
@@ -98,7 +163,7 @@ func (h *HealthServer) checkFetcher(_ context.Context) Check {
 	// 检查是否暂停
 	if h.fetcher.IsPaused() {
 		return Check{
-			Status:  "degraded",
+			Status:  warningStatus,
 			Message: "fetcher paused (likely reorg handling)",
 		}
 	}
@@ -108,3 +173,18 @@ func (h *HealthServer) checkFetcher(_ context.Context) Check {
 		Message: "fetcher running",
 	}
 }
+
+// checkSync 检查同步落后块数与端到端延迟
+func (h *HealthServer) checkSync(_ context.Context) Check {
+	lag := GetHeightOracle().Snapshot().SyncLag
+	latency := GetMetrics().GetE2ELatency()
+
+	lagStatus := gradeThreshold(float64(lag), float64(h.thresholds.MaxSyncLag))
+	latencyStatus := gradeThreshold(latency, h.thresholds.MaxE2ELatency.Seconds())
+
+	return Check{
+		Status: overallStatus(map[string]Check{"lag": {Status: lagStatus}, "latency": {Status: latencyStatus}}),
+		Message: fmt.Sprintf("sync_lag: %d (limit %d), e2e_latency: %.1fs (limit %.0fs)",
+			lag, h.thresholds.MaxSyncLag, latency, h.thresholds.MaxE2ELatency.Seconds()),
+	}
+}
@@ -0,0 +1,29 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGradeThreshold(t *testing.T) {
+	assert.Equal(t, healthyStatus, gradeThreshold(50, 100))
+	assert.Equal(t, healthyStatus, gradeThreshold(100, 100))
+	assert.Equal(t, warningStatus, gradeThreshold(150, 100))
+	assert.Equal(t, unhealthyStatus, gradeThreshold(201, 100))
+	assert.Equal(t, healthyStatus, gradeThreshold(1e9, 0), "zero limit disables the check")
+}
+
+func TestOverallStatus(t *testing.T) {
+	assert.Equal(t, healthyStatus, overallStatus(map[string]Check{
+		"a": {Status: healthyStatus},
+	}))
+	assert.Equal(t, warningStatus, overallStatus(map[string]Check{
+		"a": {Status: healthyStatus},
+		"b": {Status: warningStatus},
+	}))
+	assert.Equal(t, unhealthyStatus, overallStatus(map[string]Check{
+		"a": {Status: warningStatus},
+		"b": {Status: unhealthyStatus},
+	}))
+}
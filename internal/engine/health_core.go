@@ -12,7 +12,6 @@ package engine
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"time"
 
@@ -33,23 +32,48 @@ type Check struct {
 	Latency string `json:"latency,omitempty"`
 }
 
+// HealthThresholds 健康判定阈值：超过阈值判为 warning，超过 2 倍判为 unhealthy（0 表示不检查该项）
+type HealthThresholds struct {
+	MaxSyncLag         int64         // 最大同步落后块数
+	MaxE2ELatency      time.Duration // 最大端到端延迟（区块时间 → 处理完成）
+	MinHealthyNodes    int           // 最少健康 RPC 节点数（低于即 unhealthy）
+	MaxSequencerBuffer int           // Sequencer 乱序缓冲上限
+}
+
+// DefaultHealthThresholds 默认阈值
+func DefaultHealthThresholds() HealthThresholds {
+	return HealthThresholds{
+		MaxSyncLag:         100,
+		MaxE2ELatency:      60 * time.Second,
+		MinHealthyNodes:    1,
+		MaxSequencerBuffer: 500,
+	}
+}
+
 // HealthServer 健康检查服务器
 type HealthServer struct {
-	db        *sqlx.DB
-	rpcPool   *RPCClientPool
-	sequencer *Sequencer
-	fetcher   *Fetcher
+	db         *sqlx.DB
+	rpcPool    RPCClient
+	sequencer  *Sequencer
+	fetcher    *Fetcher
+	thresholds HealthThresholds
 }
 
-func NewHealthServer(db *sqlx.DB, rpcPool *RPCClientPool, sequencer *Sequencer, fetcher *Fetcher) *HealthServer {
+func NewHealthServer(db *sqlx.DB, rpcPool RPCClient, sequencer *Sequencer, fetcher *Fetcher) *HealthServer {
 	return &HealthServer{
-		db:        db,
-		rpcPool:   rpcPool,
-		sequencer: sequencer,
-		fetcher:   fetcher,
+		db:         db,
+		rpcPool:    rpcPool,
+		sequencer:  sequencer,
+		fetcher:    fetcher,
+		thresholds: DefaultHealthThresholds(),
 	}
 }
 
+// SetThresholds 设置健康判定阈值
+func (h *HealthServer) SetThresholds(t HealthThresholds) {
+	h.thresholds = t
+}
+
 // RegisterRoutes 注册健康检查路由
 func (h *HealthServer) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/healthz", h.Healthz)
@@ -58,47 +82,16 @@ func (h *HealthServer) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/status", h.Status) // 详细的状态 API
 }
 
-// Status 返回索引器的实时运行状态
+// Status 返回索引器的实时运行状态（与 API 服务的 /api/status 同源于 StatusService）
 func (h *HealthServer) Status(w http.ResponseWriter, r *http.Request) {
-	_, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	// 🔥 横滨实验室：使用 HeightOracle 快照，确保一致性
-	snap := GetHeightOracle().Snapshot()
-
-	// 获取索引器进度
-	bufferSize := 0
-	if h.sequencer != nil {
-		bufferSize = h.sequencer.GetBufferSize()
-	}
-
-	// 🔥 从快照读取数据，避免竞态条件
-	syncLag := snap.SyncLag
-	driftBlocks := snap.DriftBlocks
-	isTimeTravel := snap.IsTimeTravel
-	chainHead := snap.ChainHead
-	indexedHead := snap.IndexedHead
-
-	latestBlockStr := "0"
-	if chainHead > 0 {
-		latestBlockStr = fmt.Sprintf("%d", chainHead)
-	}
-
-	status := map[string]interface{}{
-		"is_healthy":         h.rpcPool.GetHealthyNodeCount() > 0,
-		"latest_chain_block": latestBlockStr,
-		"indexed_block":      fmt.Sprintf("%d", indexedHead),
-		"sync_lag":           syncLag,
-		"drift_blocks":       driftBlocks,
-		"time_travel":        isTimeTravel,
-		"buffer_size":        bufferSize,
-		"rpc_nodes": map[string]int{
-			"healthy": h.rpcPool.GetHealthyNodeCount(),
-			"total":   h.rpcPool.GetTotalNodeCount(),
-		},
-		"timestamp":            time.Now().Format(time.RFC3339),
-		"height_oracle_age_ms": snap.UpdatedAt.UnixMilli(),
+	svc := GetStatusService()
+	if !svc.Ready() {
+		svc = &StatusService{orchestrator: GetOrchestrator(), db: h.db, rpcPool: h.rpcPool, sequencer: h.sequencer}
 	}
+	status := svc.Status(ctx)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -117,42 +110,20 @@ func (h *HealthServer) Healthz(w http.ResponseWriter, r *http.Request) {
 		Checks:    make(map[string]Check),
 	}
 
-	allHealthy := true
+	status.Checks["database"] = h.checkDatabase(ctx)   // 1. 数据库连接检查
+	status.Checks["rpc"] = h.checkRPC(ctx)             // 2. RPC 连接检查
+	status.Checks["sequencer"] = h.checkSequencer(ctx) // 3. Sequencer 状态检查
+	status.Checks["fetcher"] = h.checkFetcher(ctx)     // 4. Fetcher 状态检查
+	status.Checks["sync"] = h.checkSync(ctx)           // 5. 同步落后与 E2E 延迟
+	status.Checks["upstream"] = h.checkUpstream(ctx)   // 6. 上游链头是否停滞
 
-	// 1. 数据库连接检查
-	dbCheck := h.checkDatabase(ctx)
-	status.Checks["database"] = dbCheck
-	if dbCheck.Status != healthyStatus {
-		allHealthy = false
-	}
-
-	// 2. RPC 连接检查
-	rpcCheck := h.checkRPC(ctx)
-	status.Checks["rpc"] = rpcCheck
-	if rpcCheck.Status != healthyStatus {
-		allHealthy = false
-	}
-
-	// 3. Sequencer 状态检查
-	sequencerCheck := h.checkSequencer(ctx)
-	status.Checks["sequencer"] = sequencerCheck
-	if sequencerCheck.Status != healthyStatus {
-		allHealthy = false
-	}
-
-	// 4. Fetcher 状态检查
-	fetcherCheck := h.checkFetcher(ctx)
-	status.Checks["fetcher"] = fetcherCheck
-	if fetcherCheck.Status != healthyStatus {
-		allHealthy = false
-	}
-
-	if allHealthy {
-		status.Status = healthyStatus
-		w.WriteHeader(http.StatusOK)
-	} else {
-		status.Status = "unhealthy"
+	// warning 仍返回 200，只有 unhealthy 才让负载均衡摘除实例
+	status.Status = overallStatus(status.Checks)
+	w.Header().Set("Content-Type", "application/json")
+	if status.Status == unhealthyStatus {
 		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
 	}
 
 	if err := json.NewEncoder(w).Encode(status); err != nil {
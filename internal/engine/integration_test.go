@@ -7,9 +7,13 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
 	"testing"
 	"time"
 
+	"web3-indexer-go/internal/database"
+
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
 	"github.com/testcontainers/testcontainers-go"
@@ -121,13 +125,13 @@ func setupDatabase(dsn string) error {
 	}
 	defer db.Close()
 
-	// 🚀 从 migrations 目录顺序加载 Schema
-	migrationFiles := []string{
-		"../../migrations/001_init.sql",
-		"../../migrations/002_visitor_stats.sql",
-		"../../migrations/003_add_activity_type.sql",
-		"../../migrations/004_token_metadata.sql",
+	// 🚀 先按编号顺序加载 migrations 目录，再执行启动时的 InitSchema 补齐之后新增的表与列，
+	// 与从 migrations 初始化、随版本升级的部署保持同一份表结构
+	migrationFiles, err := filepath.Glob("../../migrations/*.sql")
+	if err != nil {
+		return err
 	}
+	sort.Strings(migrationFiles)
 
 	for _, file := range migrationFiles {
 		schema, err := os.ReadFile(file)
@@ -140,5 +144,8 @@ func setupDatabase(dsn string) error {
 		}
 	}
 
+	if err := database.InitSchema(context.Background(), db); err != nil {
+		return fmt.Errorf("failed to initialize schema: %w", err)
+	}
 	return nil
 }
@@ -31,22 +31,13 @@ func (p *Processor) AlignInfrastructure(ctx context.Context, rpcPool RPCClient)
 		diff := dbHeight - rpcHeight.Int64()
 		Logger.Warn("🚨 [Integrity] 检测到时空穿越，执行物理剪枝", "surplus", diff)
 		// 强制回滚数据库到 RPC 链头高度
-		if err := repo.PruneFutureData(ctx, rpcHeight.Int64()); err != nil {
+		if err := repo.RollbackAbove(ctx, p.chainID, rpcHeight.Int64()); err != nil {
 			return fmt.Errorf("critical pruning failure: %w", err)
 		}
 		Logger.Info("✅ [Integrity] 剪枝成功，数据库已回滚至 RPC 锚点", "new_height", rpcHeight.Int64())
 	}
 
-	// 🚀 场景 B：演示模式下的深度追赶 (Gap > 1000)
-	// 注意：Processor 需要有 DemoMode 标志
-	// 这里假设 Gap > 1000 就需要跳跃
-	if rpcHeight.Int64() > dbHeight+1000 {
-		Logger.Warn("🚧 [Integrity] 检测到深度断层，演示模式：执行状态坍缩(Jump)")
-		if err := repo.UpdateSyncCursor(ctx, rpcHeight.Int64()-1); err != nil {
-			return err
-		}
-		Logger.Info("✅ [Integrity] 状态坍缩完成，游标已对齐")
-	}
+	// 场景 B（深度断层）不在这里处理：是否 Leap-Sync 由 ConsistencyGuard 按 GapSkipPolicy 决定并记录跳过区间
 
 	Logger.Info("✅ [Integrity] 高度校验通过", "height", rpcHeight.String())
 	return nil
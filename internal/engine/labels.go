@@ -19,3 +19,52 @@ func GetAddressLabel(addr string) string {
 	}
 	return ""
 }
+
+// ExchangeLabels 中心化交易所热钱包 / 充值归集地址标签（用于稳定币交易所净流入统计）
+var ExchangeLabels = map[string]string{
+	"0x28C6c06298d514Db089934071355E5743bf21d60": "Binance",
+	"0x21a31Ee1afC51d94C2eFcCAa2092aD1028285549": "Binance",
+	"0xDFd5293D8e347dFe59E90eFd55b2956a1343963d": "Binance",
+	"0x71660c4005BA85c37ccec55d0C4493E66Fe775d3": "Coinbase",
+	"0xA9D1e08C7793af67e9d92fe308d5697FB81d3E43": "Coinbase",
+	"0x2910543Af39abA0Cd09dBb2D50200b3E800A63D2": "Kraken",
+	"0x6cC5F688a315f3dC28A7781717a9A798a59fDA7b": "OKX",
+}
+
+// GetExchangeLabel 返回交易所标签，非交易所地址返回空
+func GetExchangeLabel(addr string) string {
+	for k, v := range ExchangeLabels {
+		if strings.EqualFold(k, addr) {
+			return v
+		}
+	}
+	return ""
+}
+
+// SpenderLabels 常见的授权对象（DEX 路由、聚合器、NFT 市场）标签，用于授权风险报告区分知名协议与未知合约
+var SpenderLabels = map[string]string{
+	"0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D": "Uniswap V2 Router",
+	"0xE592427A0AEce92De3Edee1F18E0157C05861564": "Uniswap V3 Router",
+	"0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45": "Uniswap V3 Router 2",
+	"0x3fC91A3afd70395Cd496C647d5a6CC9D4B2b7FAD": "Uniswap Universal Router",
+	"0x000000000022D473030F116dDEE9F6B43aC78BA3": "Uniswap Permit2",
+	"0xd9e1cE17f2641f24aE83637ab66a2cca9C378B9F": "SushiSwap Router",
+	"0x1111111254EEB25477B68fb85Ed929f73A960582": "1inch Router v5",
+	"0x111111125421cA6dc452d289314280a0f8842A65": "1inch Router v6",
+	"0xDef1C0ded9bec7F1a1670819833240f027b25EfF": "0x Exchange Proxy",
+	"0x00000000000000ADc04C56Bf30aC9d3c0aAF14dC": "OpenSea Seaport 1.5",
+	"0x1E0049783F008A0085193E00003D00cd54003c71": "OpenSea Conduit",
+}
+
+// GetSpenderLabel 返回授权对象的已知标签（协议、交易所或水龙头），未知返回空
+func GetSpenderLabel(addr string) string {
+	for k, v := range SpenderLabels {
+		if strings.EqualFold(k, addr) {
+			return v
+		}
+	}
+	if label := GetExchangeLabel(addr); label != "" {
+		return label
+	}
+	return GetAddressLabel(addr)
+}
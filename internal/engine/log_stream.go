@@ -0,0 +1,186 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const defaultLogStreamCapacity = 500
+
+// LogEvent UI 日志流中的一条事件（DispatchLog → CmdLogEvent → Orchestrator 循环写入）
+type LogEvent struct {
+	Seq    uint64                 `json:"seq"` // 单调递增序号，断线重连时用作续传位置
+	Level  string                 `json:"level"`
+	Msg    string                 `json:"msg"`
+	TS     int64                  `json:"ts"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// logLevelRank 级别排序（AUDIT 与 WARN 同级；未知级别按 INFO）
+func logLevelRank(level string) int {
+	switch strings.ToUpper(level) {
+	case "DEBUG":
+		return 0
+	case "WARN", "WARNING", "AUDIT":
+		return 2
+	case "ERROR":
+		return 3
+	default:
+		return 1
+	}
+}
+
+// LogLevelAtLeast 事件级别是否不低于 minLevel（minLevel 为空时不过滤）
+func LogLevelAtLeast(level, minLevel string) bool {
+	return minLevel == "" || logLevelRank(level) >= logLevelRank(minLevel)
+}
+
+// LogStream 最近日志的有界环形缓冲 + 实时订阅者扇出。
+// 订阅者通道满时丢弃该条（慢消费者不阻塞编排器循环）。
+type LogStream struct {
+	mu   sync.RWMutex
+	buf  []LogEvent
+	next int // 下一条写入位置
+	size int
+	seq  uint64
+	subs map[chan LogEvent]struct{}
+}
+
+var (
+	logStream     *LogStream
+	logStreamOnce sync.Once
+)
+
+// GetLogStream 全局日志流单例
+func GetLogStream() *LogStream {
+	logStreamOnce.Do(func() {
+		logStream = NewLogStream(defaultLogStreamCapacity)
+	})
+	return logStream
+}
+
+// NewLogStream 创建容量为 capacity 的日志流（<= 0 使用默认容量）
+func NewLogStream(capacity int) *LogStream {
+	if capacity <= 0 {
+		capacity = defaultLogStreamCapacity
+	}
+	return &LogStream{
+		buf:  make([]LogEvent, capacity),
+		subs: make(map[chan LogEvent]struct{}),
+	}
+}
+
+// SetCapacity 调整环形缓冲容量，保留最新的事件
+func (s *LogStream) SetCapacity(capacity int) {
+	if capacity <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.snapshotLocked()
+	if len(events) > capacity {
+		events = events[len(events)-capacity:]
+	}
+	s.buf = make([]LogEvent, capacity)
+	copy(s.buf, events)
+	s.size = len(events)
+	s.next = len(events) % capacity
+}
+
+// Append 写入一条事件（分配序号）并推送给订阅者
+func (s *LogStream) Append(ev LogEvent) LogEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	ev.Seq = s.seq
+	s.buf[s.next] = ev
+	s.next = (s.next + 1) % len(s.buf)
+	if s.size < len(s.buf) {
+		s.size++
+	}
+
+	for ch := range s.subs {
+		select {
+		case ch <- ev:
+		default:
+			GetMetrics().RecordLogStreamDropped()
+		}
+	}
+	return ev
+}
+
+// snapshotLocked 按时间顺序返回缓冲内容；调用方需持有锁
+func (s *LogStream) snapshotLocked() []LogEvent {
+	out := make([]LogEvent, 0, s.size)
+	start := (s.next - s.size + len(s.buf)) % len(s.buf)
+	for i := 0; i < s.size; i++ {
+		out = append(out, s.buf[(start+i)%len(s.buf)])
+	}
+	return out
+}
+
+// Recent 返回序号大于 afterSeq、级别不低于 minLevel 的最近 limit 条事件（按时间升序；limit <= 0 不限）
+func (s *LogStream) Recent(limit int, minLevel string, afterSeq uint64) []LogEvent {
+	s.mu.RLock()
+	events := s.snapshotLocked()
+	s.mu.RUnlock()
+
+	out := make([]LogEvent, 0, len(events))
+	for _, ev := range events {
+		if ev.Seq > afterSeq && LogLevelAtLeast(ev.Level, minLevel) {
+			out = append(out, ev)
+		}
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out
+}
+
+// Subscribe 订阅实时事件；返回的 cancel 必须调用以释放订阅
+func (s *LogStream) Subscribe(buffer int) (<-chan LogEvent, func()) {
+	if buffer <= 0 {
+		buffer = 64
+	}
+	ch := make(chan LogEvent, buffer)
+	s.mu.Lock()
+	s.subs[ch] = struct{}{}
+	s.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.subs, ch)
+			s.mu.Unlock()
+		})
+	}
+}
+
+// logEventFromDispatch 把 DispatchLog 产生的 map（level/msg/ts/details 键值对）转为 LogEvent
+func logEventFromDispatch(data map[string]interface{}) LogEvent {
+	ev := LogEvent{}
+	ev.Level, _ = data["level"].(string)
+	ev.Msg, _ = data["msg"].(string)
+	ev.TS, _ = data["ts"].(int64)
+
+	details, _ := data["details"].([]interface{})
+	if len(details) > 0 {
+		ev.Fields = make(map[string]interface{}, len(details)/2+1)
+		for i := 0; i < len(details); i += 2 {
+			key := fmt.Sprint(details[i])
+			if i+1 >= len(details) {
+				ev.Fields["!BADKEY"] = details[i]
+				break
+			}
+			val := details[i+1]
+			if err, ok := val.(error); ok {
+				val = err.Error()
+			}
+			ev.Fields[key] = val
+		}
+	}
+	return ev
+}
@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogStream_RingBufferAndFilters(t *testing.T) {
+	s := NewLogStream(3)
+	for _, level := range []string{"DEBUG", "INFO", "WARN", "AUDIT", "ERROR"} {
+		s.Append(LogEvent{Level: level, Msg: level})
+	}
+
+	all := s.Recent(0, "", 0)
+	require.Len(t, all, 3, "oldest events are evicted")
+	assert.Equal(t, []uint64{3, 4, 5}, []uint64{all[0].Seq, all[1].Seq, all[2].Seq})
+
+	warn := s.Recent(0, "warn", 0)
+	assert.Len(t, warn, 3, "AUDIT ranks with WARN")
+	assert.Len(t, s.Recent(0, "ERROR", 0), 1)
+	assert.Equal(t, uint64(5), s.Recent(1, "", 0)[0].Seq)
+	assert.Len(t, s.Recent(0, "", 4), 1, "since skips already-seen events")
+
+	// 缩容保留最新事件，扩容后继续按序写入
+	s.SetCapacity(2)
+	assert.Equal(t, "ERROR", s.Recent(0, "", 0)[1].Msg)
+	s.SetCapacity(4)
+	s.Append(LogEvent{Level: "INFO", Msg: "next"})
+	got := s.Recent(0, "", 0)
+	require.Len(t, got, 3)
+	assert.Equal(t, []string{"AUDIT", "ERROR", "next"}, []string{got[0].Msg, got[1].Msg, got[2].Msg})
+}
+
+func TestLogStream_SubscribeFanOutAndDrop(t *testing.T) {
+	s := NewLogStream(10)
+	ch, cancel := s.Subscribe(1)
+
+	s.Append(LogEvent{Level: "INFO", Msg: "first"})
+	s.Append(LogEvent{Level: "INFO", Msg: "dropped"}) // 订阅者缓冲已满，不阻塞写入
+
+	select {
+	case ev := <-ch:
+		assert.Equal(t, "first", ev.Msg)
+	case <-time.After(time.Second):
+		t.Fatal("subscriber did not receive event")
+	}
+
+	cancel()
+	cancel() // 幂等
+	s.Append(LogEvent{Level: "INFO", Msg: "after cancel"})
+	select {
+	case ev := <-ch:
+		t.Fatalf("unexpected event after cancel: %+v", ev)
+	default:
+	}
+	assert.Len(t, s.Recent(0, "", 0), 3, "buffer keeps events dropped for slow subscribers")
+}
+
+func TestLogEventFromDispatch(t *testing.T) {
+	ev := logEventFromDispatch(map[string]interface{}{
+		"level":   "AUDIT",
+		"msg":     "GAP_BYPASS",
+		"ts":      int64(1700000000),
+		"details": []interface{}{"from", "10", "err", errors.New("boom"), "dangling"},
+	})
+	assert.Equal(t, "AUDIT", ev.Level)
+	assert.Equal(t, "GAP_BYPASS", ev.Msg)
+	assert.Equal(t, int64(1700000000), ev.TS)
+	assert.Equal(t, map[string]interface{}{"from": "10", "err": "boom", "!BADKEY": "dangling"}, ev.Fields)
+}
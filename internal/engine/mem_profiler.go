@@ -0,0 +1,243 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	memProfileCheckInterval = 15 * time.Second
+	memProfileTopAllocators = 10
+)
+
+// MemProfilerConfig 内存压力自动 profile 配置（阈值为 0 表示不检查该项）
+type MemProfilerConfig struct {
+	HeapInuseThreshold uint64        // heap in-use 字节阈值
+	RSSThreshold       uint64        // 进程 RSS 字节阈值（仅 Linux 可读）
+	Cooldown           time.Duration // 两次 profile 的最小间隔
+	Dir                string        // profile 输出目录
+}
+
+// MemProfiler 在 RSS/heap 越过阈值时自动写入 heap profile 并记录 top allocators，
+// 用于实验室中捕获 HotBuffer / Sequencer buffer 膨胀而无需挂调试器
+type MemProfiler struct {
+	cfg MemProfilerConfig
+
+	mu          sync.Mutex
+	lastProfile time.Time
+
+	readRSS func() uint64 // 可替换，便于测试
+}
+
+// NewMemProfiler 创建内存 profiler
+func NewMemProfiler(cfg MemProfilerConfig) *MemProfiler {
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 15 * time.Minute
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = "profiles"
+	}
+	return &MemProfiler{cfg: cfg, readRSS: readProcessRSS}
+}
+
+// Enabled 是否配置了任一阈值
+func (m *MemProfiler) Enabled() bool {
+	return m.cfg.HeapInuseThreshold > 0 || m.cfg.RSSThreshold > 0
+}
+
+// Start 启动后台检查循环
+func (m *MemProfiler) Start(ctx context.Context) {
+	if !m.Enabled() {
+		return
+	}
+	Logger.Info("🧠 [MemProfiler] Started",
+		slog.Uint64("heap_threshold_mb", m.cfg.HeapInuseThreshold>>20),
+		slog.Uint64("rss_threshold_mb", m.cfg.RSSThreshold>>20),
+		slog.Duration("cooldown", m.cfg.Cooldown),
+		slog.String("dir", m.cfg.Dir))
+
+	go func() {
+		ticker := time.NewTicker(memProfileCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.check(time.Now())
+			}
+		}
+	}()
+}
+
+// check 读取内存指标，越过阈值且不在冷却期内时写 profile；返回触发原因（未触发为空）
+func (m *MemProfiler) check(now time.Time) string {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	rss := m.readRSS()
+
+	trigger := ""
+	switch {
+	case m.cfg.HeapInuseThreshold > 0 && ms.HeapInuse >= m.cfg.HeapInuseThreshold:
+		trigger = "heap_inuse"
+	case m.cfg.RSSThreshold > 0 && rss >= m.cfg.RSSThreshold:
+		trigger = "rss"
+	default:
+		return ""
+	}
+
+	m.mu.Lock()
+	if !m.lastProfile.IsZero() && now.Sub(m.lastProfile) < m.cfg.Cooldown {
+		m.mu.Unlock()
+		return ""
+	}
+	m.lastProfile = now
+	m.mu.Unlock()
+
+	path, err := m.writeHeapProfile(now)
+	if err != nil {
+		Logger.Error("🧠 [MemProfiler] Failed to write heap profile", slog.String("trigger", trigger), slog.String("error", err.Error()))
+		return trigger
+	}
+	GetMetrics().MemProfilesWritten.WithLabelValues(trigger).Inc()
+
+	snap := GetOrchestrator().GetSnapshot()
+	Logger.Warn("🧠 [MemProfiler] Memory pressure, heap profile written",
+		slog.String("trigger", trigger),
+		slog.String("path", path),
+		slog.Uint64("heap_inuse_mb", ms.HeapInuse>>20),
+		slog.Uint64("rss_mb", rss>>20),
+		slog.Int("goroutines", runtime.NumGoroutine()),
+		slog.Int("jobs_depth", snap.JobsDepth),
+		slog.Int("results_depth", snap.ResultsDepth))
+
+	for i, a := range topAllocators(memProfileTopAllocators) {
+		Logger.Warn("🧠 [MemProfiler] Top allocator",
+			slog.Int("rank", i+1),
+			slog.String("func", a.Func),
+			slog.Uint64("inuse_mb", uint64(a.InuseBytes)>>20), // #nosec G115 - in-use bytes are non-negative
+			slog.Int64("inuse_objects", a.InuseObjects))
+	}
+	return trigger
+}
+
+func (m *MemProfiler) writeHeapProfile(now time.Time) (string, error) {
+	if err := os.MkdirAll(m.cfg.Dir, 0o750); err != nil {
+		return "", err
+	}
+	path := filepath.Join(m.cfg.Dir, fmt.Sprintf("heap-%s.pb.gz", now.Format("20060102-150405")))
+	f, err := os.Create(path) // #nosec G304 - path built from configured directory
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := pprof.Lookup("heap").WriteTo(f, 0); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// allocatorStat 按分配点（首个非 runtime 帧）聚合的在用内存
+type allocatorStat struct {
+	Func         string
+	InuseBytes   int64
+	InuseObjects int64
+}
+
+// topAllocators 基于 runtime.MemProfile 汇总在用内存最多的分配点
+func topAllocators(n int) []allocatorStat {
+	var records []runtime.MemProfileRecord
+	count, _ := runtime.MemProfile(nil, true)
+	for {
+		records = make([]runtime.MemProfileRecord, count+50)
+		var ok bool
+		count, ok = runtime.MemProfile(records, true)
+		if ok {
+			records = records[:count]
+			break
+		}
+	}
+
+	byFunc := make(map[string]*allocatorStat)
+	for _, r := range records {
+		if r.InUseBytes() <= 0 {
+			continue
+		}
+		name := allocationSite(r.Stack())
+		s, ok := byFunc[name]
+		if !ok {
+			s = &allocatorStat{Func: name}
+			byFunc[name] = s
+		}
+		s.InuseBytes += r.InUseBytes()
+		s.InuseObjects += r.InUseObjects()
+	}
+
+	out := make([]allocatorStat, 0, len(byFunc))
+	for _, s := range byFunc {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].InuseBytes > out[j].InuseBytes })
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+func allocationSite(stack []uintptr) string {
+	frames := runtime.CallersFrames(stack)
+	first := ""
+	for {
+		fr, more := frames.Next()
+		if first == "" {
+			first = fr.Function
+		}
+		if fr.Function != "" && !strings.HasPrefix(fr.Function, "runtime.") {
+			return fr.Function
+		}
+		if !more {
+			break
+		}
+	}
+	if first == "" {
+		return "unknown"
+	}
+	return first
+}
+
+// readProcessRSS 读取 /proc/self/status 中的 VmRSS（非 Linux 返回 0）
+func readProcessRSS() uint64 {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "VmRSS:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return 0
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0
+		}
+		return kb << 10
+	}
+	return 0
+}
@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemProfilerCooldown(t *testing.T) {
+	InitLogger("error")
+	dir := t.TempDir()
+	m := NewMemProfiler(MemProfilerConfig{HeapInuseThreshold: 1, Cooldown: 10 * time.Minute, Dir: dir})
+	m.readRSS = func() uint64 { return 0 }
+
+	now := time.Now()
+	assert.Equal(t, "heap_inuse", m.check(now))
+	assert.Equal(t, "", m.check(now.Add(time.Minute)), "cooldown should suppress a second profile")
+	assert.Equal(t, "heap_inuse", m.check(now.Add(11*time.Minute)))
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestMemProfilerRSSTrigger(t *testing.T) {
+	InitLogger("error")
+	m := NewMemProfiler(MemProfilerConfig{RSSThreshold: 512 << 20, Dir: t.TempDir()})
+
+	m.readRSS = func() uint64 { return 256 << 20 }
+	assert.Equal(t, "", m.check(time.Now()))
+
+	m.readRSS = func() uint64 { return 600 << 20 }
+	assert.Equal(t, "rss", m.check(time.Now()))
+}
+
+func TestMemProfilerDisabled(t *testing.T) {
+	m := NewMemProfiler(MemProfilerConfig{})
+	assert.False(t, m.Enabled())
+}
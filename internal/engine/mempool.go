@@ -0,0 +1,495 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"web3-indexer-go/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/holiman/uint256"
+)
+
+const (
+	// PendingStatus* 待打包转账的生命周期
+	PendingStatusPending = "pending"
+	PendingStatusMined   = "mined"
+	PendingStatusDropped = "dropped"
+
+	// 丢弃原因：超过 TTL 仍未上链 / 同 sender+nonce 的另一笔交易先上链或进入 mempool（加速、取消）
+	pendingDropExpired  = "expired"
+	pendingDropReplaced = "replaced"
+
+	// MempoolEvent* WS 推送的事件类型
+	MempoolEventPending = "pending_transfer"
+	MempoolEventMined   = "pending_mined"
+	MempoolEventDropped = "pending_dropped"
+
+	DefaultMempoolTTL = 10 * time.Minute
+	DefaultMempoolMax = 5000
+
+	mempoolFetchWorkers = 4    // 并发 eth_getTransactionByHash 的 worker 数
+	mempoolHashBacklog  = 1024 // 待查询哈希缓冲；满时丢弃新哈希，不阻塞订阅读取
+)
+
+var (
+	erc20TransferSelector     = []byte{0xa9, 0x05, 0x9c, 0xbb} // transfer(address,uint256)
+	erc20TransferFromSelector = []byte{0x23, 0xb8, 0x72, 0xdd} // transferFrom(address,address,uint256)
+)
+
+// PendingTransfer 从 mempool 交易 calldata 解码出的 ERC-20 转账（未上链，金额与收款方以调用参数为准）
+type PendingTransfer struct {
+	TxHash       string         `json:"tx_hash"`
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	Sender       string         `json:"sender"` // 交易发起方；transferFrom 时与 from 不同
+	TokenAddress string         `json:"token_address"`
+	Symbol       string         `json:"symbol,omitempty"`
+	Amount       models.Uint256 `json:"amount"`
+	Method       string         `json:"method"` // transfer | transferFrom
+	Nonce        uint64         `json:"nonce"`
+	FirstSeen    time.Time      `json:"first_seen"`
+	Status       string         `json:"status"`
+	BlockNumber  models.Height  `json:"block_number,omitempty"` // status=mined 时的所在区块
+	Reason       string         `json:"reason,omitempty"`       // status=dropped 时的原因
+}
+
+// MempoolConfig 待打包转账视图配置
+type MempoolConfig struct {
+	ChainID int64
+	TTL     time.Duration // 超过该时长仍未上链视为丢弃（0 使用 DefaultMempoolTTL）
+	MaxSize int           // 视图容量上限，满时不再接收新交易（0 使用 DefaultMempoolMax）
+	Filter  *TokenFilter  // 与已确认转账相同的代币允许 / 拒绝名单（nil 不过滤）
+	Symbol  func(common.Address) string
+	OnEvent func(kind string, t PendingTransfer)
+}
+
+// pendingTxSource eth_subscribe("newPendingTransactions") 与按哈希取交易的最小接口
+type pendingTxSource interface {
+	SubscribePendingTransactions(ctx context.Context, ch chan<- common.Hash) (ethereum.Subscription, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	Close()
+}
+
+type wssPendingSource struct {
+	rpc *rpc.Client
+	eth *ethclient.Client
+}
+
+func dialPendingSource(ctx context.Context, url string) (pendingTxSource, error) {
+	c, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return &wssPendingSource{rpc: c, eth: ethclient.NewClient(c)}, nil
+}
+
+func (s *wssPendingSource) SubscribePendingTransactions(ctx context.Context, ch chan<- common.Hash) (ethereum.Subscription, error) {
+	return s.rpc.EthSubscribe(ctx, ch, "newPendingTransactions")
+}
+
+func (s *wssPendingSource) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	return s.eth.TransactionByHash(ctx, hash)
+}
+
+func (s *wssPendingSource) Close() { s.rpc.Close() }
+
+type senderNonce struct {
+	sender common.Address
+	nonce  uint64
+}
+
+type pendingFetch struct {
+	src  pendingTxSource
+	hash common.Hash
+}
+
+// Mempool 短时的待打包转账视图：订阅 newPendingTransactions，解码 ERC-20 transfer / transferFrom 调用，
+// 在交易上链（ObserveBlock）、被同 nonce 交易替换或超过 TTL 时移出视图并推送对应事件。
+// 未启用时所有方法都是空操作，处理器可无条件调用 ObserveBlock
+type Mempool struct {
+	mu      sync.RWMutex
+	enabled bool
+	ttl     time.Duration
+	maxSize int
+	signer  types.Signer
+	filter  *TokenFilter
+	symbol  func(common.Address) string
+	onEvent func(kind string, t PendingTransfer)
+
+	pending map[common.Hash]*PendingTransfer
+	byKey   map[senderNonce]common.Hash
+	nonces  map[uint64]int // 视图中各 nonce 的交易数：区块里只有 nonce 命中的交易才需要恢复 sender 判断替换
+
+	queue chan pendingFetch
+	dial  func(ctx context.Context, url string) (pendingTxSource, error)
+	now   func() time.Time
+}
+
+var (
+	mempool     *Mempool
+	mempoolOnce sync.Once
+)
+
+// GetMempool 返回全局待打包转账视图（默认未启用）
+func GetMempool() *Mempool {
+	mempoolOnce.Do(func() {
+		mempool = newMempool()
+	})
+	return mempool
+}
+
+func newMempool() *Mempool {
+	return &Mempool{
+		pending: make(map[common.Hash]*PendingTransfer),
+		byKey:   make(map[senderNonce]common.Hash),
+		nonces:  make(map[uint64]int),
+		queue:   make(chan pendingFetch, mempoolHashBacklog),
+		dial:    dialPendingSource,
+		now:     time.Now,
+	}
+}
+
+// Configure 启用视图；须在 Start 之前调用
+func (m *Mempool) Configure(cfg MempoolConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.enabled = true
+	m.ttl = cfg.TTL
+	if m.ttl <= 0 {
+		m.ttl = DefaultMempoolTTL
+	}
+	m.maxSize = cfg.MaxSize
+	if m.maxSize <= 0 {
+		m.maxSize = DefaultMempoolMax
+	}
+	m.signer = types.LatestSignerForChainID(big.NewInt(cfg.ChainID))
+	m.filter = cfg.Filter
+	m.symbol = cfg.Symbol
+	m.onEvent = cfg.OnEvent
+}
+
+// Enabled 是否启用了待打包视图
+func (m *Mempool) Enabled() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.enabled
+}
+
+// Start 订阅 WSS 节点的 newPendingTransactions（断线按指数退避重连），并启动过期清理
+func (m *Mempool) Start(ctx context.Context, wssURL string) {
+	if !m.Enabled() {
+		return
+	}
+	for i := 0; i < mempoolFetchWorkers; i++ {
+		go m.fetchLoop(ctx)
+	}
+	go m.subscribeLoop(ctx, wssURL)
+	go m.sweepLoop(ctx)
+	Logger.Info("🫧 [Mempool] Pending transfer view enabled", slog.Duration("ttl", m.ttl), slog.Int("max", m.maxSize))
+}
+
+func (m *Mempool) subscribeLoop(ctx context.Context, url string) {
+	backoff := time.Second
+	for {
+		src, err := m.dial(ctx, url)
+		if err == nil {
+			hashes := make(chan common.Hash, 256)
+			var sub ethereum.Subscription
+			if sub, err = src.SubscribePendingTransactions(ctx, hashes); err == nil {
+				Logger.Info("✅ [Mempool] Subscribed to newPendingTransactions")
+				backoff = time.Second
+				err = m.consume(ctx, src, sub, hashes)
+			}
+			src.Close()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		msg := "closed"
+		if err != nil {
+			msg = err.Error()
+		}
+		Logger.Warn("⚠️ [Mempool] Pending subscription lost, reconnecting", slog.String("error", msg), slog.Duration("in", backoff))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
+}
+
+// consume 把订阅到的哈希交给 fetch worker；worker 跟不上时丢弃（mempool 视图允许有损）
+func (m *Mempool) consume(ctx context.Context, src pendingTxSource, sub ethereum.Subscription, hashes <-chan common.Hash) error {
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			return err
+		case h := <-hashes:
+			select {
+			case m.queue <- pendingFetch{src: src, hash: h}:
+			default:
+				GetMetrics().RecordMempoolHashDropped()
+			}
+		}
+	}
+}
+
+func (m *Mempool) fetchLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-m.queue:
+			fctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			tx, isPending, err := job.src.TransactionByHash(fctx, job.hash)
+			cancel()
+			// 查询时已上链或已被节点丢弃的交易不进入视图
+			if err != nil || tx == nil || !isPending {
+				continue
+			}
+			m.Add(tx)
+		}
+	}
+}
+
+func (m *Mempool) sweepLoop(ctx context.Context) {
+	ticker := time.NewTicker(max(m.ttl/10, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.sweep()
+		}
+	}
+}
+
+// Add 解码待打包交易；是 ERC-20 转账调用且通过代币过滤时加入视图。
+// 同 sender+nonce 已有另一笔交易时视为替换（加速 / 取消），旧交易以 replaced 丢弃
+func (m *Mempool) Add(tx *types.Transaction) (PendingTransfer, bool) {
+	if !m.Enabled() {
+		return PendingTransfer{}, false
+	}
+	t, sender, ok := decodePendingTransfer(tx, m.signer)
+	if !ok {
+		return PendingTransfer{}, false
+	}
+	token := common.HexToAddress(t.TokenAddress)
+	if allowed, _ := m.filter.Allows(token); !allowed {
+		return PendingTransfer{}, false
+	}
+	if m.symbol != nil {
+		t.Symbol = m.symbol(token)
+	}
+
+	m.mu.Lock()
+	if _, exists := m.pending[tx.Hash()]; exists {
+		m.mu.Unlock()
+		return PendingTransfer{}, false
+	}
+	var events []mempoolEvent
+	key := senderNonce{sender: sender, nonce: tx.Nonce()}
+	if old, ok := m.byKey[key]; ok {
+		events = append(events, m.resolveLocked(old, PendingStatusDropped, 0, pendingDropReplaced))
+	}
+	if len(m.pending) >= m.maxSize {
+		m.mu.Unlock()
+		m.emit(events)
+		GetMetrics().RecordMempoolTransfer("overflow")
+		return PendingTransfer{}, false
+	}
+	t.FirstSeen = m.now()
+	t.Status = PendingStatusPending
+	m.pending[tx.Hash()] = &t
+	m.byKey[key] = tx.Hash()
+	m.nonces[key.nonce]++
+	size := len(m.pending)
+	m.mu.Unlock()
+
+	GetMetrics().RecordMempoolTransfer("seen")
+	GetMetrics().SetMempoolPending(size)
+	m.emit(append(events, mempoolEvent{kind: MempoolEventPending, t: t}))
+	return t, true
+}
+
+// ObserveBlock 对账已处理的区块：视图中的交易标记为 mined；同 sender+nonce 的其他交易上链则旧交易以 replaced 丢弃
+func (m *Mempool) ObserveBlock(block *types.Block) {
+	if block == nil {
+		return
+	}
+	m.mu.Lock()
+	if !m.enabled || len(m.pending) == 0 {
+		m.mu.Unlock()
+		return
+	}
+	var events []mempoolEvent
+	for _, tx := range block.Transactions() {
+		if _, ok := m.pending[tx.Hash()]; ok {
+			events = append(events, m.resolveLocked(tx.Hash(), PendingStatusMined, block.NumberU64(), ""))
+			continue
+		}
+		if m.nonces[tx.Nonce()] == 0 {
+			continue
+		}
+		sender, err := types.Sender(m.signer, tx)
+		if err != nil {
+			continue
+		}
+		if old, ok := m.byKey[senderNonce{sender: sender, nonce: tx.Nonce()}]; ok {
+			events = append(events, m.resolveLocked(old, PendingStatusDropped, 0, pendingDropReplaced))
+		}
+	}
+	size := len(m.pending)
+	m.mu.Unlock()
+
+	if len(events) > 0 {
+		GetMetrics().SetMempoolPending(size)
+		m.emit(events)
+	}
+}
+
+// sweep 丢弃超过 TTL 仍未上链的交易
+func (m *Mempool) sweep() {
+	m.mu.Lock()
+	cutoff := m.now().Add(-m.ttl)
+	var events []mempoolEvent
+	for hash, t := range m.pending {
+		if t.FirstSeen.Before(cutoff) {
+			events = append(events, m.resolveLocked(hash, PendingStatusDropped, 0, pendingDropExpired))
+		}
+	}
+	size := len(m.pending)
+	m.mu.Unlock()
+
+	if len(events) > 0 {
+		GetMetrics().SetMempoolPending(size)
+		m.emit(events)
+	}
+}
+
+type mempoolEvent struct {
+	kind string
+	t    PendingTransfer
+}
+
+// resolveLocked 把交易移出视图并返回待推送的事件；调用方须持有写锁
+func (m *Mempool) resolveLocked(hash common.Hash, status string, block uint64, reason string) mempoolEvent {
+	t := *m.pending[hash]
+	delete(m.pending, hash)
+	key := senderNonce{sender: common.HexToAddress(t.Sender), nonce: t.Nonce}
+	if m.byKey[key] == hash {
+		delete(m.byKey, key)
+	}
+	if m.nonces[t.Nonce]--; m.nonces[t.Nonce] <= 0 {
+		delete(m.nonces, t.Nonce)
+	}
+
+	t.Status = status
+	t.BlockNumber = models.Height(block)
+	t.Reason = reason
+	kind := MempoolEventMined
+	outcome := PendingStatusMined
+	if status == PendingStatusDropped {
+		kind, outcome = MempoolEventDropped, reason
+	}
+	GetMetrics().RecordMempoolTransfer(outcome)
+	return mempoolEvent{kind: kind, t: t}
+}
+
+func (m *Mempool) emit(events []mempoolEvent) {
+	if m.onEvent == nil {
+		return
+	}
+	for _, ev := range events {
+		m.onEvent(ev.kind, ev.t)
+	}
+}
+
+// Pending 返回视图中满足 match 的待打包转账（最新的在前，最多 limit 条）及视图总数
+func (m *Mempool) Pending(match func(PendingTransfer) bool, limit int) ([]PendingTransfer, int) {
+	m.mu.RLock()
+	total := len(m.pending)
+	out := make([]PendingTransfer, 0, min(total, limit))
+	for _, t := range m.pending {
+		if match == nil || match(*t) {
+			out = append(out, *t)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].FirstSeen.Equal(out[j].FirstSeen) {
+			return out[i].FirstSeen.After(out[j].FirstSeen)
+		}
+		return out[i].TxHash < out[j].TxHash
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, total
+}
+
+// decodePendingTransfer 解码 ERC-20 transfer / transferFrom 调用；参数长度或地址填充不符的调用返回 false
+func decodePendingTransfer(tx *types.Transaction, signer types.Signer) (PendingTransfer, common.Address, bool) {
+	data := tx.Data()
+	if tx.To() == nil || len(data) < 4 {
+		return PendingTransfer{}, common.Address{}, false
+	}
+	var from, to, amount []byte
+	var method string
+	switch {
+	case bytes.Equal(data[:4], erc20TransferSelector) && len(data) == 4+64:
+		method, to, amount = "transfer", data[4:36], data[36:68]
+	case bytes.Equal(data[:4], erc20TransferFromSelector) && len(data) == 4+96:
+		method, from, to, amount = "transferFrom", data[4:36], data[36:68], data[68:100]
+	default:
+		return PendingTransfer{}, common.Address{}, false
+	}
+	if !isPaddedAddress(to) || (from != nil && !isPaddedAddress(from)) {
+		return PendingTransfer{}, common.Address{}, false
+	}
+	sender, err := types.Sender(signer, tx)
+	if err != nil {
+		return PendingTransfer{}, common.Address{}, false
+	}
+	fromAddr := sender
+	if from != nil {
+		fromAddr = common.BytesToAddress(from)
+	}
+	return PendingTransfer{
+		TxHash:       tx.Hash().Hex(),
+		From:         strings.ToLower(fromAddr.Hex()),
+		To:           strings.ToLower(common.BytesToAddress(to).Hex()),
+		Sender:       strings.ToLower(sender.Hex()),
+		TokenAddress: strings.ToLower(tx.To().Hex()),
+		Amount:       models.Uint256{Int: new(uint256.Int).SetBytes(amount)},
+		Method:       method,
+		Nonce:        tx.Nonce(),
+	}, sender, true
+}
+
+// isPaddedAddress ABI 编码的 address 参数高 12 字节必须为零
+func isPaddedAddress(word []byte) bool {
+	for _, b := range word[:12] {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mempoolFixture struct {
+	m      *Mempool
+	key    *ecdsa.PrivateKey
+	signer types.Signer
+	now    time.Time
+	events []string
+}
+
+func newMempoolFixture(t *testing.T) *mempoolFixture {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	f := &mempoolFixture{key: key, signer: types.LatestSignerForChainID(big.NewInt(1)), now: time.Unix(1_700_000_000, 0)}
+	f.m = newMempool()
+	f.m.now = func() time.Time { return f.now }
+	f.m.Configure(MempoolConfig{
+		ChainID: 1,
+		TTL:     time.Minute,
+		OnEvent: func(kind string, pt PendingTransfer) { f.events = append(f.events, kind+":"+pt.Status+":"+pt.Reason) },
+	})
+	return f
+}
+
+func (f *mempoolFixture) tx(t *testing.T, nonce uint64, to common.Address, data []byte) *types.Transaction {
+	tx, err := types.SignNewTx(f.key, f.signer, &types.DynamicFeeTx{
+		ChainID: big.NewInt(1), Nonce: nonce, To: &to, Gas: 60_000,
+		GasFeeCap: big.NewInt(2e9), GasTipCap: big.NewInt(1e9), Data: data,
+	})
+	require.NoError(t, err)
+	return tx
+}
+
+func transferCalldata(to common.Address, amount int64) []byte {
+	data := append([]byte{}, erc20TransferSelector...)
+	data = append(data, common.LeftPadBytes(to.Bytes(), 32)...)
+	return append(data, common.LeftPadBytes(big.NewInt(amount).Bytes(), 32)...)
+}
+
+func TestMempool_DecodesAndReconcilesMined(t *testing.T) {
+	f := newMempoolFixture(t)
+	recipient := testkit.Address("recipient", 1)
+	tx := f.tx(t, 0, testkit.USDC, transferCalldata(recipient, 2500))
+
+	pt, ok := f.m.Add(tx)
+	require.True(t, ok)
+	assert.Equal(t, "transfer", pt.Method)
+	assert.Equal(t, strings.ToLower(recipient.Hex()), pt.To)
+	assert.Equal(t, strings.ToLower(crypto.PubkeyToAddress(f.key.PublicKey).Hex()), pt.From)
+	assert.Equal(t, pt.From, pt.Sender)
+	assert.Equal(t, "2500", pt.Amount.String())
+	_, dup := f.m.Add(tx)
+	assert.False(t, dup, "a hash is only added once")
+
+	list, total := f.m.Pending(nil, 10)
+	require.Len(t, list, 1)
+	assert.Equal(t, 1, total)
+
+	f.m.ObserveBlock(testkit.BlockWithTxs(7, common.Hash{}, []*types.Transaction{tx}))
+	_, total = f.m.Pending(nil, 10)
+	assert.Zero(t, total)
+	assert.Equal(t, []string{"pending_transfer:pending:", "pending_mined:mined:"}, f.events)
+}
+
+func TestMempool_ReplacementAndExpiry(t *testing.T) {
+	f := newMempoolFixture(t)
+	recipient := testkit.Address("recipient", 1)
+	first := f.tx(t, 3, testkit.USDC, transferCalldata(recipient, 1))
+	speedup := f.tx(t, 3, testkit.USDC, transferCalldata(recipient, 2))
+	other := f.tx(t, 4, testkit.USDC, transferCalldata(recipient, 3))
+
+	_, ok := f.m.Add(first)
+	require.True(t, ok)
+	_, ok = f.m.Add(speedup)
+	require.True(t, ok)
+	assert.Contains(t, f.events, "pending_dropped:dropped:replaced")
+
+	// 同 nonce 的非转账交易（取消）上链同样替换视图中的交易
+	cancel := f.tx(t, 3, crypto.PubkeyToAddress(f.key.PublicKey), nil)
+	f.m.ObserveBlock(testkit.BlockWithTxs(8, common.Hash{}, []*types.Transaction{cancel}))
+	_, total := f.m.Pending(nil, 10)
+	assert.Zero(t, total)
+
+	_, ok = f.m.Add(other)
+	require.True(t, ok)
+	f.now = f.now.Add(2 * time.Minute)
+	f.m.sweep()
+	_, total = f.m.Pending(nil, 10)
+	assert.Zero(t, total)
+	assert.Equal(t, "pending_dropped:dropped:expired", f.events[len(f.events)-1])
+}
+
+func TestDecodePendingTransfer_RejectsNonTransfers(t *testing.T) {
+	f := newMempoolFixture(t)
+	recipient := testkit.Address("recipient", 1)
+
+	good := transferCalldata(recipient, 1)
+	dirty := append([]byte{}, good...)
+	dirty[4] = 0xff // address 参数高位非零
+	for name, data := range map[string][]byte{
+		"no calldata": nil,
+		"truncated":   good[:40],
+		"dirty word":  dirty,
+		"other call":  append([]byte{0x09, 0x5e, 0xa7, 0xb3}, good[4:]...),
+	} {
+		_, _, ok := decodePendingTransfer(f.tx(t, 0, testkit.USDC, data), f.signer)
+		assert.False(t, ok, name)
+	}
+
+	from := testkit.Address("owner", 1)
+	data := append([]byte{}, erc20TransferFromSelector...)
+	data = append(data, common.LeftPadBytes(from.Bytes(), 32)...)
+	data = append(data, good[4:]...)
+	pt, sender, ok := decodePendingTransfer(f.tx(t, 0, testkit.USDC, data), f.signer)
+	require.True(t, ok)
+	assert.Equal(t, "transferFrom", pt.Method)
+	assert.Equal(t, strings.ToLower(from.Hex()), pt.From)
+	assert.Equal(t, crypto.PubkeyToAddress(f.key.PublicKey), sender)
+}
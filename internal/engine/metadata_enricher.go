@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"web3-indexer-go/internal/models"
@@ -19,10 +20,13 @@ import (
 var Multicall3Address = common.HexToAddress("0xca11bde05977b3631167028862be2a173976ca11")
 
 const (
-	erc20ABIJSON = `[{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"type":"function"},{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"type":"function"}]`
+	erc20ABIJSON = `[{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"type":"function"},{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"type":"function"},{"constant":true,"inputs":[],"name":"name","outputs":[{"name":"","type":"string"}],"type":"function"}]`
 	multiABIJSON = `[{"inputs":[{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bool","name":"allowFailure","type":"bool"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall3.Call3[]","name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall3.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"view","type":"function"}]`
 )
 
+// metadataCallsPerToken 每个代币在 Multicall3 中的子调用数（symbol、decimals、name）
+const metadataCallsPerToken = 3
+
 // DBUpdater 定义数据库更新接口（解耦依赖）
 type DBUpdater interface {
 	UpdateTokenSymbol(tokenAddress, symbol string) error
@@ -30,9 +34,9 @@ type DBUpdater interface {
 	SaveTokenMetadata(meta models.TokenMetadata, address string) error
 	LoadAllMetadata() (map[string]models.TokenMetadata, error)
 	GetMaxStoredBlock(ctx context.Context) (int64, error)
-	GetSyncCursor(ctx context.Context) (int64, error)
-	PruneFutureData(ctx context.Context, chainHead int64) error
-	UpdateSyncCursor(ctx context.Context, height int64) error
+	GetSyncCursor(ctx context.Context, chainID int64) (int64, error)
+	RollbackAbove(ctx context.Context, chainID, height int64) error
+	UpdateSyncCursor(ctx context.Context, chainID, height int64) error
 }
 
 // MetadataEnricher 异步元数据丰富器
@@ -51,6 +55,7 @@ type MetadataEnricher struct {
 	batchInterval time.Duration
 	erc20ABI      abi.ABI
 	multicallABI  abi.ABI
+	spam          atomic.Pointer[SpamGuard] // 上报 symbol()/decimals() 均不可读的代币
 }
 
 // mustParseABI 辅助函数
@@ -207,47 +212,71 @@ func (me *MetadataEnricher) batchWorker() {
 // processBatch 批量处理（使用 Multicall3 优化）
 func (me *MetadataEnricher) processBatch(addresses []common.Address) {
 	startTime := time.Now()
-	addrCount := len(addresses)
 
-	// 1. 构造 Multicall 调用列表 (每个地址请求 Symbol 和 Decimals)
+	ctx, cancel := context.WithTimeout(me.ctx, me.timeout)
+	defer cancel()
+
+	metas, found, err := me.fetchBatch(ctx, addresses)
+	if err != nil {
+		me.logger.Warn("⚠️ [MetadataEnricher] Multicall3 execution failed", "err", err)
+		return
+	}
+
+	for i, addr := range addresses {
+		if found[i] {
+			me.store(addr, metas[i])
+		} else {
+			me.spam.Load().ReportUnverifiable(addr)
+		}
+		// 任务完成，移除 inflight 标记
+		me.inflight.Delete(addr.Hex())
+	}
+
+	me.logger.Debug("📦 [MetadataEnricher] batch processed",
+		"addr_count", len(addresses),
+		"duration", time.Since(startTime))
+}
+
+// fetchBatch 通过一次 Multicall3 读取每个地址的 symbol / decimals / name。
+// found[i] 表示 symbol 或 decimals 至少一项可读；name 缺失不影响判定
+func (me *MetadataEnricher) fetchBatch(ctx context.Context, addresses []common.Address) ([]models.TokenMetadata, []bool, error) {
+	// 1. 构造 Multicall 调用列表 (每个地址请求 Symbol、Decimals 和 Name)
 	// 使用 struct 匹配 Multicall3 Result ABI
 	type Call3 struct {
 		Target       common.Address
 		AllowFailure bool
 		CallData     []byte
 	}
-	calls := make([]Call3, 0, addrCount*2)
-
+	symData, err := me.erc20ABI.Pack("symbol")
+	if err != nil {
+		return nil, nil, err
+	}
+	decData, err := me.erc20ABI.Pack("decimals")
+	if err != nil {
+		return nil, nil, err
+	}
+	nameData, err := me.erc20ABI.Pack("name")
+	if err != nil {
+		return nil, nil, err
+	}
+	calls := make([]Call3, 0, len(addresses)*metadataCallsPerToken)
 	for _, addr := range addresses {
-		symData, err := me.erc20ABI.Pack("symbol")
-		if err != nil {
-			continue
-		}
-		decData, err := me.erc20ABI.Pack("decimals")
-		if err != nil {
-			continue
-		}
 		calls = append(calls,
 			Call3{addr, true, symData},
 			Call3{addr, true, decData},
+			Call3{addr, true, nameData},
 		)
 	}
 
 	// 2. 打包并发送请求
 	input, err := me.multicallABI.Pack("aggregate3", calls)
 	if err != nil {
-		me.logger.Error("❌ [MetadataEnricher] Pack failed", "err", err)
-		return
+		return nil, nil, fmt.Errorf("pack aggregate3: %w", err)
 	}
-
-	ctx, cancel := context.WithTimeout(me.ctx, me.timeout)
-	defer cancel()
-
 	msg := ethereum.CallMsg{To: &Multicall3Address, Data: input}
 	output, err := me.client.CallContract(ctx, msg, nil)
 	if err != nil {
-		me.logger.Warn("⚠️ [MetadataEnricher] Multicall3 execution failed", "err", err)
-		return
+		return nil, nil, err
 	}
 
 	// 3. 解析结果
@@ -257,65 +286,72 @@ func (me *MetadataEnricher) processBatch(addresses []common.Address) {
 	}
 	var multiRes []MultiResult
 	if err := me.multicallABI.UnpackIntoInterface(&multiRes, "aggregate3", output); err != nil {
-		me.logger.Error("❌ [MetadataEnricher] Unpack failed", "err", err)
-		return
+		return nil, nil, fmt.Errorf("unpack aggregate3: %w", err)
+	}
+	if len(multiRes) != len(calls) {
+		return nil, nil, fmt.Errorf("aggregate3 returned %d results for %d calls", len(multiRes), len(calls))
 	}
 
-	// 4. 对齐结果并分发更新
-	for i, addr := range addresses {
-		addrHex := addr.Hex()
-		meta := models.TokenMetadata{Symbol: "UNKNOWN", Decimals: 18}
-		found := false
-
-		// 解析 Symbol (结果索引为 i*2)
-		if multiRes[i*2].Success && len(multiRes[i*2].ReturnData) >= 64 {
-			// ERC20 symbol 返回 string，需要 Unpack
-			if out, err := me.erc20ABI.Unpack("symbol", multiRes[i*2].ReturnData); err == nil && len(out) > 0 {
+	// 4. 对齐结果（结果索引为 i*3 + 偏移）
+	metas := make([]models.TokenMetadata, len(addresses))
+	found := make([]bool, len(addresses))
+	for i := range addresses {
+		metas[i] = models.TokenMetadata{Symbol: "UNKNOWN", Decimals: 18}
+		sym, dec, name := multiRes[i*3], multiRes[i*3+1], multiRes[i*3+2]
+
+		// ERC20 symbol / name 返回 string，需要 Unpack
+		if sym.Success && len(sym.ReturnData) >= 64 {
+			if out, err := me.erc20ABI.Unpack("symbol", sym.ReturnData); err == nil && len(out) > 0 {
 				if s, ok := out[0].(string); ok {
-					meta.Symbol = s
-					found = true
+					metas[i].Symbol = s
+					found[i] = true
 				}
 			}
 		}
-
-		// 解析 Decimals (结果索引为 i*2+1)
-		if multiRes[i*2+1].Success && len(multiRes[i*2+1].ReturnData) >= 32 {
-			// decimals 返回 uint8
-			if out, err := me.erc20ABI.Unpack("decimals", multiRes[i*2+1].ReturnData); err == nil && len(out) > 0 {
+		// decimals 返回 uint8
+		if dec.Success && len(dec.ReturnData) >= 32 {
+			if out, err := me.erc20ABI.Unpack("decimals", dec.ReturnData); err == nil && len(out) > 0 {
 				if d, ok := out[0].(uint8); ok {
-					meta.Decimals = d
-					found = true
+					metas[i].Decimals = d
+					found[i] = true
 				}
 			}
 		}
-
-		if found {
-			// 更新 L1 缓存 (Memory)
-			me.cache.Store(addrHex, meta)
-
-			// 🚀 工业级故障隔离：持久化到 L2 (DB) 采用“尽力而为”模式
-			// 即使数据库表不存在或写入失败，也不应导致整个同步逻辑回滚
-			if me.db != nil {
-				if err := me.db.SaveTokenMetadata(meta, addrHex); err != nil {
-					me.logger.Warn("⚠️ [MetadataEnricher] L2 persistence failed (non-blocking)",
-						"address", addrHex[:10],
-						"err", err)
+		if name.Success && len(name.ReturnData) >= 64 {
+			if out, err := me.erc20ABI.Unpack("name", name.ReturnData); err == nil && len(out) > 0 {
+				if s, ok := out[0].(string); ok {
+					metas[i].Name = s
 				}
 			}
+		}
+	}
+	return metas, found, nil
+}
+
+// store 更新 L1 缓存并尽力持久化到 L2
+func (me *MetadataEnricher) store(addr common.Address, meta models.TokenMetadata) {
+	addrHex := addr.Hex()
+	me.cache.Store(addrHex, meta)
 
-			me.logger.Debug("🎯 [MetadataEnricher] discovered",
+	// 🚀 工业级故障隔离：持久化到 L2 (DB) 采用“尽力而为”模式
+	// 即使数据库表不存在或写入失败，也不应导致整个同步逻辑回滚
+	if me.db != nil {
+		if err := me.db.SaveTokenMetadata(meta, addrHex); err != nil {
+			me.logger.Warn("⚠️ [MetadataEnricher] L2 persistence failed (non-blocking)",
 				"address", addrHex[:10],
-				"symbol", meta.Symbol,
-				"decimals", meta.Decimals)
+				"err", err)
 		}
-
-		// 任务完成，移除 inflight 标记
-		me.inflight.Delete(addrHex)
 	}
 
-	me.logger.Debug("📦 [MetadataEnricher] batch processed",
-		"addr_count", addrCount,
-		"duration", time.Since(startTime))
+	me.logger.Debug("🎯 [MetadataEnricher] discovered",
+		"address", addrHex[:10],
+		"symbol", meta.Symbol,
+		"decimals", meta.Decimals)
+}
+
+// SetSpamGuard 设置元数据不可读时的上报目标（nil 关闭上报）
+func (me *MetadataEnricher) SetSpamGuard(g *SpamGuard) {
+	me.spam.Store(g)
 }
 
 // Stop 停止丰富器
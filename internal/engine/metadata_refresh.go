@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// DefaultMetadataTTL 元数据超过该时长未刷新即视为过期（/api/admin/enrich 批量刷新）
+	DefaultMetadataTTL = 7 * 24 * time.Hour
+	// MaxMetadataRefresh 单次同步刷新的代币数上限（需在 HTTP 写超时内完成）
+	MaxMetadataRefresh = 200
+)
+
+// 刷新结果状态
+const (
+	MetadataRefreshed  = "refreshed"  // 至少 symbol 或 decimals 可读，已写入缓存与数据库
+	MetadataUnreadable = "unreadable" // 合约调用成功但 symbol()/decimals() 均不可读
+	MetadataFailed     = "failed"     // Multicall3 调用失败（RPC 错误、超时）
+)
+
+// ErrStaleListingUnsupported 元数据存储不支持列出过期代币
+var ErrStaleListingUnsupported = errors.New("metadata store cannot list stale tokens")
+
+// StaleMetadataSource 可列出缺失或过期元数据代币的存储（*storage.Postgres 实现）
+type StaleMetadataSource interface {
+	ListStaleTokens(ctx context.Context, updatedBefore time.Time, limit int) ([]string, error)
+}
+
+var _ StaleMetadataSource = (*storage.Postgres)(nil)
+
+// MetadataRefreshResult 单个代币的强制刷新结果
+type MetadataRefreshResult struct {
+	Address  string `json:"address"`
+	Status   string `json:"status"`
+	Symbol   string `json:"symbol,omitempty"`
+	Decimals uint8  `json:"decimals,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Refresh 绕过缓存同步重新抓取 tokens 的 symbol / decimals / name，按批走 Multicall3，
+// 成功的结果写入缓存与数据库。单批失败只影响该批代币（状态为 failed）
+func (me *MetadataEnricher) Refresh(ctx context.Context, tokens []common.Address) []MetadataRefreshResult {
+	results := make([]MetadataRefreshResult, 0, len(tokens))
+	for start := 0; start < len(tokens); start += me.batchSize {
+		batch := tokens[start:min(start+me.batchSize, len(tokens))]
+
+		reqCtx, cancel := context.WithTimeout(ctx, me.timeout)
+		metas, found, err := me.fetchBatch(reqCtx, batch)
+		cancel()
+
+		for i, addr := range batch {
+			res := MetadataRefreshResult{Address: addr.Hex()}
+			switch {
+			case err != nil:
+				res.Status, res.Error = MetadataFailed, err.Error()
+			case !found[i]:
+				res.Status = MetadataUnreadable
+				me.spam.Load().ReportUnverifiable(addr)
+			default:
+				res.Status = MetadataRefreshed
+				res.Symbol, res.Decimals, res.Name = metas[i].Symbol, metas[i].Decimals, metas[i].Name
+				me.store(addr, metas[i])
+			}
+			results = append(results, res)
+		}
+	}
+
+	refreshed := 0
+	for _, r := range results {
+		if r.Status == MetadataRefreshed {
+			refreshed++
+		}
+	}
+	me.logger.Info("🔄 [MetadataEnricher] forced refresh", "requested", len(tokens), "refreshed", refreshed)
+	return results
+}
+
+// RefreshStale 刷新元数据缺失（无记录、symbol 未知、name 为空）或早于 ttl 未更新的代币，最多 limit 个
+func (me *MetadataEnricher) RefreshStale(ctx context.Context, ttl time.Duration, limit int) ([]MetadataRefreshResult, error) {
+	source, ok := me.db.(StaleMetadataSource)
+	if !ok {
+		return nil, ErrStaleListingUnsupported
+	}
+	if ttl <= 0 {
+		ttl = DefaultMetadataTTL
+	}
+	if limit <= 0 || limit > MaxMetadataRefresh {
+		limit = MaxMetadataRefresh
+	}
+
+	addrs, err := source.ListStaleTokens(ctx, time.Now().Add(-ttl), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list stale tokens: %w", err)
+	}
+	tokens := make([]common.Address, 0, len(addrs))
+	for _, a := range addrs {
+		if common.IsHexAddress(a) {
+			tokens = append(tokens, common.HexToAddress(a))
+		}
+	}
+	return me.Refresh(ctx, tokens), nil
+}
@@ -0,0 +1,153 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// multicallStub 按代币地址返回预设元数据，未登记的地址三个子调用全部失败
+type multicallStub struct {
+	tokens map[common.Address]models.TokenMetadata
+	err    error
+	calls  int
+}
+
+func (m *multicallStub) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	erc20, multi := mustParseABI(erc20ABIJSON), mustParseABI(multiABIJSON)
+	args, err := multi.Methods["aggregate3"].Inputs.Unpack(msg.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	calls := args[0].([]struct {
+		Target       common.Address `json:"target"`
+		AllowFailure bool           `json:"allowFailure"`
+		CallData     []byte         `json:"callData"`
+	})
+
+	type result struct {
+		Success    bool   `json:"success"`
+		ReturnData []byte `json:"returnData"`
+	}
+	results := make([]result, 0, len(calls))
+	for i, c := range calls {
+		meta, ok := m.tokens[c.Target]
+		if !ok {
+			results = append(results, result{})
+			continue
+		}
+		var out []byte
+		switch i % metadataCallsPerToken {
+		case 0:
+			out, err = erc20.Methods["symbol"].Outputs.Pack(meta.Symbol)
+		case 1:
+			out, err = erc20.Methods["decimals"].Outputs.Pack(meta.Decimals)
+		default:
+			out, err = erc20.Methods["name"].Outputs.Pack(meta.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result{Success: true, ReturnData: out})
+	}
+	return multi.Methods["aggregate3"].Outputs.Pack(results)
+}
+
+func (m *multicallStub) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *multicallStub) BlockByNumber(context.Context, *big.Int) (*types.Block, error) {
+	return nil, errors.New("not implemented")
+}
+
+// staleMetadataStore 记录写入的元数据并返回预设的过期代币
+type staleMetadataStore struct {
+	DBUpdater
+	stale []string
+	saved map[string]models.TokenMetadata
+}
+
+func (s *staleMetadataStore) SaveTokenMetadata(meta models.TokenMetadata, address string) error {
+	s.saved[address] = meta
+	return nil
+}
+
+func (s *staleMetadataStore) LoadAllMetadata() (map[string]models.TokenMetadata, error) {
+	return nil, nil
+}
+
+func (s *staleMetadataStore) ListStaleTokens(_ context.Context, _ time.Time, limit int) ([]string, error) {
+	return s.stale[:min(limit, len(s.stale))], nil
+}
+
+func newTestEnricher(t *testing.T, client LowLevelRPCClient, db DBUpdater) *MetadataEnricher {
+	t.Helper()
+	me := NewMetadataEnricher(client, db, nil, 10, time.Hour)
+	t.Cleanup(me.Stop)
+	return me
+}
+
+func TestMetadataEnricher_RefreshReportsPerTokenStatus(t *testing.T) {
+	unknown := testkit.Address("token", 2)
+	client := &multicallStub{tokens: map[common.Address]models.TokenMetadata{
+		testkit.USDC: {Symbol: "USDC", Decimals: 6, Name: "USD Coin"},
+	}}
+	store := &staleMetadataStore{saved: map[string]models.TokenMetadata{}}
+	me := newTestEnricher(t, client, store)
+
+	results := me.Refresh(context.Background(), []common.Address{testkit.USDC, unknown})
+	require.Len(t, results, 2)
+	assert.Equal(t, MetadataRefreshResult{Address: testkit.USDC.Hex(), Status: MetadataRefreshed, Symbol: "USDC", Decimals: 6, Name: "USD Coin"}, results[0])
+	assert.Equal(t, MetadataUnreadable, results[1].Status)
+
+	assert.Equal(t, "USDC", me.GetSymbol(testkit.USDC), "refreshed metadata is served from the cache")
+	assert.Equal(t, uint8(6), me.GetDecimals(testkit.USDC))
+	assert.Equal(t, "USD Coin", store.saved[testkit.USDC.Hex()].Name)
+	assert.NotContains(t, store.saved, unknown.Hex())
+}
+
+func TestMetadataEnricher_RefreshMarksBatchFailures(t *testing.T) {
+	me := newTestEnricher(t, &multicallStub{err: errors.New("execution timeout")}, nil)
+	results := me.Refresh(context.Background(), []common.Address{testkit.USDC})
+	require.Len(t, results, 1)
+	assert.Equal(t, MetadataFailed, results[0].Status)
+	assert.Equal(t, "execution timeout", results[0].Error)
+}
+
+func TestMetadataEnricher_RefreshStale(t *testing.T) {
+	tokens := map[common.Address]models.TokenMetadata{}
+	var stale []string
+	for i := uint64(0); i < 120; i++ {
+		addr := testkit.Address("token", i)
+		tokens[addr] = models.TokenMetadata{Symbol: "T", Decimals: 18, Name: "Token"}
+		stale = append(stale, addr.Hex())
+	}
+	client := &multicallStub{tokens: tokens}
+	store := &staleMetadataStore{stale: stale, saved: map[string]models.TokenMetadata{}}
+	me := newTestEnricher(t, client, store)
+
+	results, err := me.RefreshStale(context.Background(), 0, 70)
+	require.NoError(t, err)
+	assert.Len(t, results, 70)
+	assert.Len(t, store.saved, 70)
+	assert.Equal(t, 2, client.calls, "refreshes in Multicall3 batches of batchSize")
+
+	_, err = newTestEnricher(t, client, nil).RefreshStale(context.Background(), 0, 10)
+	assert.ErrorIs(t, err, ErrStaleListingUnsupported)
+}
@@ -2,12 +2,14 @@ package engine
 
 import (
 	"log/slog"
+	"strconv"
 	"sync"
 	"sync/atomic"
 
 	"web3-indexer-go/internal/monitor"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
@@ -21,18 +23,84 @@ type Metrics struct {
 	ReorgsDetected  prometheus.Counter
 	ReorgsHandled   prometheus.Counter
 
+	// 🔀 Reorg depth & recovery timing
+	ReorgDepth            prometheus.Histogram
+	ReorgRecoveryDuration *prometheus.HistogramVec
+
 	// Transfer metrics
 	TransfersProcessed prometheus.Counter
 	TransfersFailed    prometheus.Counter
 
 	// Fetcher metrics
-	FetcherJobsQueued     prometheus.Counter
-	FetcherJobsComplete   prometheus.Counter
-	FetcherJobsFailed     prometheus.Counter
-	FetcherRateLimited    prometheus.Counter
-	FetcherJobsQueueDepth prometheus.Gauge // 📊 当前任务队列深度
-	FetcherResultsDepth   prometheus.Gauge // 📊 当前结果队列深度
-	FetchTime             prometheus.Histogram
+	FetcherJobsQueued       prometheus.Counter
+	FetcherJobsComplete     prometheus.Counter
+	FetcherJobsFailed       prometheus.Counter
+	FetcherRateLimited      prometheus.Counter
+	FetcherJobsDeduplicated prometheus.Counter // 🔁 去重丢弃的区块数
+	FetcherJobsQueueDepth   prometheus.Gauge   // 📊 当前任务队列深度
+	FetcherResultsDepth     prometheus.Gauge   // 📊 当前结果队列深度
+	FetchTime               prometheus.Histogram
+
+	FetcherWorkers prometheus.Gauge // 运行中的抓取 worker 数（随 Resize 变化）
+
+	ChainHeadLookups *prometheus.CounterVec // 链头缓存查询（result=hit|miss）
+
+	TokenFilteredEvents *prometheus.CounterVec // 被代币允许 / 拒绝名单过滤的事件（reason=denied|not_allowed|spam）
+
+	SpamTokensMarked *prometheus.CounterVec // 被启发式判定为垃圾的代币（reason=zero_value_flood|airdrop|unverifiable_metadata）
+
+	LogStreamDropped prometheus.Counter // 日志流订阅者缓冲满而丢弃的事件
+
+	FetcherScheduleClamped prometheus.Counter // 超出 TargetHeight（链高 - SafetyBuffer）而被截断、留待下次调度的区块数
+
+	LogRangeSplits *prometheus.CounterVec // eth_getLogs 因结果数 / 区块跨度上限被二分的次数（reason=result_limit|range_limit|response_size）
+
+	PollInterval *prometheus.GaugeVec // 轮询者当前等待间隔（秒，休眠模式下衰减）
+
+	HeadUpdates        *prometheus.CounterVec // 链头跟踪获得的链头（source=wss|poll）
+	HeadSubscriptionUp prometheus.Gauge       // WSS newHeads 订阅是否在线（1=推送驱动，0=回落轮询）
+	UpstreamStalled    prometheus.Gauge       // 上游链头是否停滞（1=超过阈值未出块）
+	UpstreamStalls     prometheus.Counter     // 上游链头停滞告警次数
+
+	ChainResets prometheus.Counter // 检测到的本地链重置次数（Anvil 重启后自动归零）
+
+	SkippedBlocks    *prometheus.CounterVec // 未索引即被跳过的块数（reason=leap_sync|gap_bypass|buffer_overflow|stall_skip|dlq_exhausted）
+	BackfilledBlocks prometheus.Counter     // 后台回填补齐的已跳过块数
+
+	DecimalBackfillRows    prometheus.Counter // 回填 normalized_amount 的转账行数
+	DecimalBackfillPending prometheus.Gauge   // 已知精度但尚未回填 normalized_amount 的转账行数
+
+	WatchedAddresses prometheus.Gauge // 当前生效的监控地址数（0 表示不按地址过滤）
+
+	MempoolPending       prometheus.Gauge       // 待打包转账视图中的交易数
+	MempoolTransfers     *prometheus.CounterVec // 待打包转账按结局计数（seen|mined|expired|replaced|overflow）
+	MempoolHashesDropped prometheus.Counter     // 查询队列已满而丢弃的 pending 交易哈希
+
+	CodeCacheLookups *prometheus.CounterVec // 字节码缓存查询的地址数（source=memory|db|rpc）
+
+	MetricsPushes *prometheus.CounterVec // 指标主动导出次数（mode=pushgateway|remote_write, result=ok|error）
+
+	RPCCoalesced *prometheus.CounterVec // 与在途相同请求合并、未单独发往上游的 RPC 调用（按 method）
+
+	ArbitrageCandidates prometheus.Counter // 检测到的同块环形套利候选数
+
+	// 区块拥堵：最近处理区块的 gas 使用与填充率（gas_used / gas_limit），以及最近 N 块的滑动平均
+	BlockGasUsed      prometheus.Gauge
+	BlockGasLimit     prometheus.Gauge
+	BlockFillRatio    prometheus.Gauge
+	BlockFillRatioAvg *prometheus.GaugeVec // blocks=10|100
+
+	// EIP-4844：最近处理区块的 blob gas 与利用率（blob_gas_used / 该分叉每块上限），以及累计 blob 交易与 blob 数
+	BlobGasUsed      prometheus.Gauge
+	ExcessBlobGas    prometheus.Gauge
+	BlobUtilization  prometheus.Gauge
+	BlobTransactions prometheus.Counter
+	Blobs            prometheus.Counter
+
+	// EIP-1559：最近处理区块的 baseFee 与小费中位数，以及累计销毁量
+	BaseFeeGwei       prometheus.Gauge
+	PriorityFeeP50    prometheus.Gauge
+	BurntFeesEthTotal prometheus.Counter
 
 	// Sequencer metrics
 	SequencerBufferSize prometheus.Gauge
@@ -87,14 +155,84 @@ type Metrics struct {
 	// 🎬 Replay Metrics
 	ReplayProgress prometheus.Gauge
 
+	ReplayFetchBlocks   prometheus.Counter // replay fetch 已写入的区块数
+	ReplayFetchProgress prometheus.Gauge   // replay fetch 进度百分比
+
 	// 🛡️ Self-healing metrics
 	SelfHealingTriggered prometheus.Counter
 	SelfHealingSuccess   prometheus.Counter
 	SelfHealingFailure   prometheus.Counter
 
+	// 📡 Event bus metrics
+	EventBusPublished *prometheus.CounterVec
+	EventBusDropped   *prometheus.CounterVec
+
+	// 🧾 Sink idempotency metrics
+	SinkDuplicatesSkipped *prometheus.CounterVec
+
+	// 🔍 FilterLogs receipt cross-check metrics
+	LogVerifications        *prometheus.CounterVec
+	LogVerificationMismatch *prometheus.CounterVec
+	FetchIntegrityRejected  *prometheus.CounterVec // 区块号 / 日志区块哈希不一致而被拒收重抓的响应（reason）
+
+	// 🛡️ Byzantine provider metrics
+	RPCProviderConflicts   *prometheus.CounterVec
+	RPCProviderQuarantined *prometheus.CounterVec
+
+	// 🐢 Head drift metrics
+	RPCHeadDrift  *prometheus.HistogramVec
+	RPCStaleHeads *prometheus.CounterVec
+
+	// 🔁 Supervisor metrics
+	SupervisorRestarts *prometheus.CounterVec
+	SupervisorGiveUps  *prometheus.CounterVec
+
+	// 🌐 HTTP API metrics
+	HTTPRequestDuration *prometheus.HistogramVec
+	HTTPRequests        *prometheus.CounterVec
+	HTTPRequestDBTime   *prometheus.HistogramVec
+	HTTPPanics          *prometheus.CounterVec
+
+	// 🛑 Shutdown metrics
+	ShutdownDuration      prometheus.Gauge
+	ShutdownFlushDuration prometheus.Histogram
+
+	// 🧠 Memory profiler
+	MemProfilesWritten *prometheus.CounterVec
+
+	// 🏭 Simulator synthesized transfers
+	SynthesizedTransfers  *prometheus.CounterVec
+	SyntheticFallbackRows prometheus.Counter // 空块合成兜底生成的 mock 行
+
+	// 🪣 Object storage sink
+	ObjectUploads        *prometheus.CounterVec
+	ObjectUploadBytes    prometheus.Counter
+	ObjectUploadDuration prometheus.Histogram
+
+	// 🧾 Checkpoint audit（启动时 checkpoint / MAX(blocks) / 协调器游标对账）
+	CheckpointAudits     *prometheus.CounterVec
+	CheckpointAuditDrift prometheus.Gauge       // checkpoint - MAX(blocks.number)
+	CheckpointInFlight   *prometheus.CounterVec // checkpoint 之上已落库区块的启动处理（result=adopted|refetched）
+	ReceiptFetches       *prometheus.CounterVec // 回执抓取次数（method=block|tx, result=ok|error）
+	TraceFetches         *prometheus.CounterVec // 调用树抓取次数（result=ok|error|unsupported）
+	InternalTransfers    prometheus.Counter     // 从调用树提取的内部 ETH 转账数
+	NFTTransfers         prometheus.Counter     // 提取的 ERC-721 转账数
+	Approvals            *prometheus.CounterVec // 提取的授权事件数（kind=erc20|erc721|for_all）
+	ContractEvents       *prometheus.CounterVec // 按配置签名通用解码的事件数（event=事件名）
+
+	// 📡 WebSocket hub 背压
+	WSConnectedClients prometheus.Gauge
+	WSMessagesDropped  prometheus.Counter // 客户端发送缓冲已满而丢弃的消息
+	WSClientsEvicted   prometheus.Counter // 连续丢弃超限被踢出的慢客户端
+
+	WSConnectionsRejected *prometheus.CounterVec // 握手被拒：reason=origin/token/capacity
+	WSResumes             *prometheus.CounterVec // 断线续传：outcome=replayed/snapshot
+
 	// 📊 内部缓存用于计算 Lag
 	lastChainHeight atomic.Int64
 	lastSyncHeight  atomic.Int64
+	lastE2ELatency  atomic.Uint64 // float64 bits，供健康检查读取
+	fillWindows     []*fillRateWindow
 }
 
 var (
@@ -102,6 +240,25 @@ var (
 	metricsOnce sync.Once
 )
 
+// NewChainRegistry 创建独立的指标注册表，返回的 Registerer 为其注册的全部指标附加常量标签 chain_id，
+// Go 运行时与进程采集器已经由它注册；Registry 用于 /metrics 与指标推送的采集
+func NewChainRegistry(chainID int64) (prometheus.Registerer, *prometheus.Registry) {
+	reg := prometheus.NewRegistry()
+	labelled := prometheus.WrapRegistererWith(prometheus.Labels{"chain_id": strconv.FormatInt(chainID, 10)}, reg)
+	labelled.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return labelled, reg
+}
+
+// UseChainRegistry 用 NewChainRegistry 替换 Prometheus 默认注册表与默认采集器，使 promauto 指标、
+// /metrics 与指标推送都带 chain_id。多链模式（CHAINS）下每条链是独立的子进程（各自的 goroutine 组、RPC 池与 DB 连接），
+// 汇聚到同一 Prometheus / 推送端时依靠该标签区分。须在任何指标注册之前调用（首次 GetMetrics、仿真器指标、promhttp.Handler）
+func UseChainRegistry(chainID int64) {
+	prometheus.DefaultRegisterer, prometheus.DefaultGatherer = NewChainRegistry(chainID)
+}
+
 // GetMetrics returns the singleton Metrics instance
 func GetMetrics() *Metrics {
 	metricsOnce.Do(func() {
@@ -139,6 +296,16 @@ func NewMetrics() *Metrics {
 			Name: "indexer_reorgs_handled_total",
 			Help: "Total number of reorganizations successfully handled",
 		}),
+		ReorgDepth: promauto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "indexer_reorg_depth_blocks",
+			Help:    "Depth of handled chain reorganizations in blocks",
+			Buckets: []float64{1, 2, 3, 4, 6, 8, 12, 16, 32, 64, 128, 256, 1000},
+		}),
+		ReorgRecoveryDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "indexer_reorg_recovery_duration_seconds",
+			Help:    "Time from reorg detection to each recovery phase (ancestor_found, rollback_committed, resumed)",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		}, []string{"phase"}),
 
 		TransfersProcessed: promauto.NewCounter(prometheus.CounterOpts{
 			Name: "indexer_transfers_processed_total",
@@ -165,6 +332,10 @@ func NewMetrics() *Metrics {
 			Name: "indexer_fetcher_rate_limited_total",
 			Help: "Total number of times fetcher was rate limited",
 		}),
+		FetcherJobsDeduplicated: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_fetcher_jobs_deduplicated_total",
+			Help: "Total number of blocks dropped from scheduling because they were in-flight or recently fetched",
+		}),
 		FetcherJobsQueueDepth: promauto.NewGauge(prometheus.GaugeOpts{
 			Name: "indexer_fetcher_jobs_queue_depth",
 			Help: "Current number of jobs in the fetcher queue",
@@ -291,7 +462,7 @@ func NewMetrics() *Metrics {
 
 		TransactionTypesTotal: promauto.NewCounterVec(prometheus.CounterOpts{
 			Name: "indexer_transaction_types_total",
-			Help: "Total number of transactions by type (deploy, approve, eth_transfer, etc.)",
+			Help: "Committed transfers by activity type (DEPLOY, ETH_TRANSFER, FAUCET_CLAIM, etc.); same source as /api/stats/types",
 		}, []string{"type"}),
 
 		// 📊 代币转账统计指标
@@ -308,6 +479,14 @@ func NewMetrics() *Metrics {
 			Name: "indexer_replay_progress_percentage",
 			Help: "Current replay progress percentage (0-100)",
 		}),
+		ReplayFetchBlocks: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_replay_fetch_blocks_total",
+			Help: "Blocks written to the replay dataset by replay fetch",
+		}),
+		ReplayFetchProgress: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_replay_fetch_progress_percentage",
+			Help: "Replay fetch progress percentage (0-100)",
+		}),
 
 		// 🛡️ Self-healing metrics
 		SelfHealingTriggered: promauto.NewCounter(prometheus.CounterOpts{
@@ -322,6 +501,332 @@ func NewMetrics() *Metrics {
 			Name: "indexer_self_healing_failure_total",
 			Help: "Total number of failed self-healing operations",
 		}),
+
+		// 📡 Event bus metrics
+		EventBusPublished: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_event_bus_published_total",
+			Help: "Total number of events published to the in-process event bus",
+		}, []string{"topic"}),
+		EventBusDropped: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_event_bus_dropped_total",
+			Help: "Total number of events dropped because a subscriber buffer was full",
+		}, []string{"subscriber", "topic"}),
+
+		// 🧾 Sink idempotency metrics
+		SinkDuplicatesSkipped: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_sink_duplicates_skipped_total",
+			Help: "Total number of records skipped by sinks because their idempotency key was already written",
+		}, []string{"sink", "kind"}),
+
+		// 🔍 FilterLogs receipt cross-check metrics
+		LogVerifications: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_log_verification_total",
+			Help: "Total number of sampled blocks whose FilterLogs result was cross-checked against receipts",
+		}, []string{"result"}),
+		LogVerificationMismatch: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_log_verification_mismatch_total",
+			Help: "Total number of FilterLogs results that disagreed with receipt-derived logs, by provider",
+		}, []string{"provider"}),
+		FetchIntegrityRejected: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_fetch_integrity_rejected_total",
+			Help: "Fetched blocks rejected and refetched because the block number or log block hashes did not match the request",
+		}, []string{"reason"}),
+
+		// 🛡️ Byzantine provider metrics
+		RPCProviderConflicts: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_rpc_provider_conflicts_total",
+			Help: "Total number of times a provider reported a block height or hash that conflicted with the quorum",
+		}, []string{"provider", "kind"}),
+		RPCProviderQuarantined: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_rpc_provider_quarantined_total",
+			Help: "Total number of times a provider was quarantined for repeated quorum conflicts",
+		}, []string{"provider"}),
+
+		// 🐢 Head drift metrics
+		RPCHeadDrift: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "indexer_rpc_head_drift_seconds",
+			Help:    "Difference between receive time and block timestamp of chain heads served by each node",
+			Buckets: []float64{1, 2, 5, 12, 24, 60, 120, 300, 600},
+		}, []string{"node"}),
+		RPCStaleHeads: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_rpc_stale_heads_total",
+			Help: "Total number of times a node started serving stale chain heads",
+		}, []string{"node"}),
+
+		// 🔁 Supervisor metrics
+		SupervisorRestarts: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_supervisor_restarts_total",
+			Help: "Total number of supervised component restarts",
+		}, []string{"component"}),
+		SupervisorGiveUps: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_supervisor_giveups_total",
+			Help: "Total number of times a supervisor exhausted its restart policy",
+		}, []string{"component"}),
+
+		// 🌐 HTTP API metrics
+		HTTPRequestDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "indexer_http_request_duration_seconds",
+			Help:    "HTTP request latency by route, method and status code",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		}, []string{"route", "method", "status"}),
+		HTTPRequests: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_http_requests_total",
+			Help: "Total number of HTTP requests by route, method and status code (including streams and WebSocket upgrades)",
+		}, []string{"route", "method", "status"}),
+		HTTPRequestDBTime: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "indexer_http_request_db_seconds",
+			Help:    "Time each HTTP request spent in instrumented database queries, by route",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		}, []string{"route"}),
+		HTTPPanics: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_http_panics_total",
+			Help: "Total number of panics recovered in HTTP handlers",
+		}, []string{"route"}),
+
+		// 🛑 Shutdown metrics
+		ShutdownDuration: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_shutdown_duration_seconds",
+			Help: "Duration of the last graceful shutdown",
+		}),
+		ShutdownFlushDuration: promauto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "indexer_shutdown_flush_duration_seconds",
+			Help:    "Duration of the final AsyncWriter flush and checkpoint write on shutdown",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 2.5, 5, 10, 20},
+		}),
+
+		// 🧠 Memory profiler
+		MemProfilesWritten: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_mem_profiles_written_total",
+			Help: "Total number of heap profiles written automatically under memory pressure",
+		}, []string{"trigger"}),
+		// 🏭 Simulator synthesized transfers
+		SynthesizedTransfers: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_synthesized_transfers_total",
+			Help: "Simulator-synthesized transfers by outcome (attached to a block or dropped)",
+		}, []string{"result"}),
+		// 🪣 Object storage sink
+		ObjectUploads: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_object_uploads_total",
+			Help: "Object storage uploads of spooled event files by result",
+		}, []string{"result"}),
+		ObjectUploadBytes: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_object_upload_bytes_total",
+			Help: "Total bytes uploaded to object storage",
+		}),
+		ObjectUploadDuration: promauto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "indexer_object_upload_duration_seconds",
+			Help:    "Duration of a complete multipart upload of one spooled file",
+			Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+		}),
+		CheckpointAudits: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_checkpoint_audits_total",
+			Help: "Startup checkpoint audits by verdict (consistent, checkpoint_behind, checkpoint_ahead, checkpoint_missing, empty)",
+		}, []string{"verdict"}),
+		CheckpointAuditDrift: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_checkpoint_audit_drift_blocks",
+			Help: "sync_checkpoints.last_synced_block minus MAX(blocks.number) observed by the last audit",
+		}),
+		TraceFetches: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_trace_fetches_total",
+			Help: "debug_traceBlockByNumber (callTracer) fetches by result",
+		}, []string{"result"}),
+		InternalTransfers: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_internal_transfers_total",
+			Help: "Internal ETH transfers extracted from call traces",
+		}),
+		NFTTransfers: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_nft_transfers_total",
+			Help: "ERC-721 transfers extracted from Transfer logs with an indexed tokenId",
+		}),
+		Approvals: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_approvals_total",
+			Help: "Approval and ApprovalForAll events extracted into the approvals table, by kind",
+		}, []string{"kind"}),
+		ContractEvents: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_contract_events_total",
+			Help: "Logs decoded into contract_events by the configured EVENT_SIGNATURES, by event name",
+		}, []string{"event"}),
+		ReceiptFetches: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_receipt_fetches_total",
+			Help: "Per-block receipt fetches by method (eth_getBlockReceipts or per-transaction fallback) and result",
+		}, []string{"method", "result"}),
+		CheckpointInFlight: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_checkpoint_inflight_blocks_total",
+			Help: "Blocks found above the checkpoint at startup, adopted after hash-continuity verification or left to be refetched",
+		}, []string{"result"}),
+		SyntheticFallbackRows: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_synthetic_fallback_rows_total",
+			Help: "Mock transfers inserted by the empty-block synthetic fallback (SYNTHETIC_FALLBACK)",
+		}),
+		WSConnectedClients: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_ws_connected_clients",
+			Help: "Number of connected WebSocket clients",
+		}),
+		WSMessagesDropped: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_ws_messages_dropped_total",
+			Help: "WebSocket messages dropped because a client's send buffer was full",
+		}),
+		WSClientsEvicted: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_ws_clients_evicted_total",
+			Help: "Slow WebSocket clients disconnected after too many consecutive drops",
+		}),
+		WSConnectionsRejected: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_ws_connections_rejected_total",
+			Help: "WebSocket handshakes rejected by origin, token or connection limit checks",
+		}, []string{"reason"}),
+		WSResumes: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_ws_resumes_total",
+			Help: "WebSocket reconnects carrying a resume token, by outcome (replayed from the hub buffer or snapshot refresh required)",
+		}, []string{"outcome"}),
+		FetcherWorkers: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_fetcher_workers",
+			Help: "Number of running fetcher worker goroutines",
+		}),
+		ChainHeadLookups: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_chain_head_cache_lookups_total",
+			Help: "Chain head lookups served by the TTL cache (hit) or the RPC pool (miss)",
+		}, []string{"result"}),
+		TokenFilteredEvents: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_token_filtered_events_total",
+			Help: "Log events skipped by the token allow/deny lists or spam muting",
+		}, []string{"reason"}),
+		SpamTokensMarked: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_spam_tokens_marked_total",
+			Help: "Tokens automatically muted by the spam heuristics",
+		}, []string{"reason"}),
+		LogStreamDropped: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_log_stream_dropped_total",
+			Help: "Log stream events dropped because a subscriber was too slow",
+		}),
+		FetcherScheduleClamped: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_fetcher_schedule_clamped_blocks_total",
+			Help: "Blocks trimmed from schedule requests above the orchestrator target height (chain head minus safety buffer)",
+		}),
+		LogRangeSplits: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_getlogs_range_splits_total",
+			Help: "eth_getLogs ranges bisected after the provider rejected them for result count, block span or response size",
+		}, []string{"reason"}),
+		PollInterval: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "indexer_poll_interval_seconds",
+			Help: "Current wait interval of each poller; decays while the orchestrator is in eco mode",
+		}, []string{"poller"}),
+		HeadUpdates: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_head_updates_total",
+			Help: "Chain heads observed by tail follow, by source (wss newHeads push or HTTP poll)",
+		}, []string{"source"}),
+		HeadSubscriptionUp: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_head_subscription_up",
+			Help: "Whether the WSS newHeads subscription is live (1) or tail follow has fallen back to polling (0)",
+		}),
+		UpstreamStalled: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_upstream_stalled",
+			Help: "Whether the chain head has stopped advancing for longer than the expected block cadence allows (1) or not (0)",
+		}),
+		UpstreamStalls: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_upstream_stalls_total",
+			Help: "Times the chain head was detected as stalled upstream",
+		}),
+		ChainResets: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_chain_resets_total",
+			Help: "Local dev chain restarts detected (genesis hash changed or head fell below the cursor on a different chain); each one resets the index to genesis",
+		}),
+		SkippedBlocks: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_skipped_blocks_total",
+			Help: "Blocks skipped without being indexed, by reason (leap_sync, gap_bypass, buffer_overflow, stall_skip, dlq_exhausted)",
+		}, []string{"reason"}),
+		BackfilledBlocks: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_backfilled_blocks_total",
+			Help: "Previously skipped blocks indexed by the background backfill worker",
+		}),
+		DecimalBackfillRows: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_decimal_backfill_rows_total",
+			Help: "Transfers whose normalized_amount was filled in once the token's decimals became known",
+		}),
+		DecimalBackfillPending: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_decimal_backfill_pending_rows",
+			Help: "Transfers of tokens with known decimals still waiting for normalized_amount",
+		}),
+		WatchedAddresses: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_watched_addresses",
+			Help: "Addresses in the active watchlist (0 means logs are not filtered by address)",
+		}),
+		MempoolPending: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_mempool_pending_transfers",
+			Help: "ERC-20 transfers currently held in the pending (mempool) view",
+		}),
+		MempoolTransfers: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_mempool_transfers_total",
+			Help: "Pending ERC-20 transfers by outcome (seen, mined, expired, replaced, overflow)",
+		}, []string{"outcome"}),
+		MempoolHashesDropped: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_mempool_hashes_dropped_total",
+			Help: "Pending transaction hashes dropped because the lookup queue was full",
+		}),
+		CodeCacheLookups: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_code_cache_lookups_total",
+			Help: "Addresses resolved by the eth_getCode cache, by source (memory, db, rpc)",
+		}, []string{"source"}),
+		MetricsPushes: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_metrics_pushes_total",
+			Help: "Metric exports to a Pushgateway or remote-write endpoint, by mode and result",
+		}, []string{"mode", "result"}),
+		RPCCoalesced: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "indexer_rpc_coalesced_total",
+			Help: "RPC calls served by an identical in-flight request instead of a separate upstream call",
+		}, []string{"method"}),
+		ArbitrageCandidates: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_arbitrage_candidates_total",
+			Help: "Same-block circular arbitrage candidates detected across indexed swaps",
+		}),
+		BlockGasUsed: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_block_gas_used",
+			Help: "Gas used by the most recently processed block",
+		}),
+		BlockGasLimit: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_block_gas_limit",
+			Help: "Gas limit of the most recently processed block",
+		}),
+		BlockFillRatio: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_block_fill_ratio",
+			Help: "gas_used / gas_limit of the most recently processed block",
+		}),
+		BlockFillRatioAvg: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "indexer_block_fill_ratio_avg",
+			Help: "Rolling average block fill ratio over the last N processed blocks",
+		}, []string{"blocks"}),
+		BlobGasUsed: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_block_blob_gas_used",
+			Help: "Blob gas used by the most recently processed post-Dencun block",
+		}),
+		ExcessBlobGas: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_block_excess_blob_gas",
+			Help: "Excess blob gas of the most recently processed post-Dencun block (drives the blob base fee)",
+		}),
+		BlobUtilization: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_block_blob_utilization",
+			Help: "blob_gas_used / max blob gas per block of the most recently processed post-Dencun block",
+		}),
+		BlobTransactions: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_blob_transactions_total",
+			Help: "EIP-4844 blob-carrying (type 3) transactions indexed",
+		}),
+		Blobs: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_blobs_total",
+			Help: "Blobs (versioned hashes) referenced by indexed blob transactions",
+		}),
+		BaseFeeGwei: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_block_base_fee_gwei",
+			Help: "Base fee per gas (gwei) of the most recently processed post-London block",
+		}),
+		PriorityFeeP50: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "indexer_block_priority_fee_p50_gwei",
+			Help: "Gas-weighted median priority fee (gwei) of the most recently processed post-London block",
+		}),
+		BurntFeesEthTotal: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "indexer_burnt_fees_eth_total",
+			Help: "Base fee burnt (base_fee_per_gas * gas_used, in ETH) across processed blocks",
+		}),
+		fillWindows: newFillRateWindows(),
 	}
 }
 
@@ -347,3 +852,12 @@ func (m *Metrics) UpdateReplayProgress(percentage float64) {
 		m.ReplayProgress.Set(percentage)
 	}
 }
+
+// RecordReplayFetch 记录 replay fetch 写入的区块数与当前进度
+func (m *Metrics) RecordReplayFetch(blocks int, percentage float64) {
+	if m == nil || m.ReplayFetchBlocks == nil {
+		return
+	}
+	m.ReplayFetchBlocks.Add(float64(blocks))
+	m.ReplayFetchProgress.Set(percentage)
+}
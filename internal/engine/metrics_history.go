@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// MetricsHistoryRetention 吞吐采样保留时长（也是 /api/stats/throughput 的最大窗口）
+	MetricsHistoryRetention = 7 * 24 * time.Hour
+	// maxThroughputPoints 单次查询最多返回的点数，超出时按更粗的桶降采样
+	maxThroughputPoints = 720
+)
+
+// ThroughputSample 一个采样桶内的平均吞吐
+type ThroughputSample struct {
+	Ts        time.Time `db:"ts" json:"ts"`
+	TPS       float64   `db:"tps" json:"tps"`
+	BPS       float64   `db:"bps" json:"bps"`
+	LatencyMs float64   `db:"latency_ms" json:"latency_ms"`
+	Blocks    int64     `db:"blocks" json:"blocks"`
+	Transfers int64     `db:"transfers" json:"transfers"`
+}
+
+// ThroughputRecorder 周期性把进程内计数器折算成平均 TPS/BPS 写入 metrics_history。
+// GetWindowTPS 只有 5 秒滑动窗口；这里用两次采样之间的计数差求整分钟平均，不受瞬时抖动影响。
+type ThroughputRecorder struct {
+	db       *sqlx.DB
+	metrics  *Metrics
+	interval time.Duration
+
+	lastAt        time.Time
+	lastBlocks    uint64
+	lastTransfers uint64
+}
+
+// NewThroughputRecorder 创建吞吐采样器（默认每分钟一条）
+func NewThroughputRecorder(db *sqlx.DB, interval time.Duration) *ThroughputRecorder {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &ThroughputRecorder{db: db, metrics: GetMetrics(), interval: interval}
+}
+
+// Start 启动后台采样循环；首轮只建立基线，不写入
+func (r *ThroughputRecorder) Start(ctx context.Context) {
+	Logger.Info("📈 [ThroughputRecorder] Started", slog.Duration("interval", r.interval))
+	r.lastAt = time.Now()
+	r.lastBlocks = r.metrics.GetTotalBlocksProcessed()
+	r.lastTransfers = r.metrics.GetTotalTransfersProcessed()
+
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if err := r.Sample(ctx, now); err != nil && ctx.Err() == nil {
+					Logger.Warn("📈 [ThroughputRecorder] Sample failed", slog.String("error", err.Error()))
+				}
+			}
+		}
+	}()
+}
+
+// Sample 写入自上次采样以来的平均吞吐，并清理超出保留期的旧样本
+func (r *ThroughputRecorder) Sample(ctx context.Context, now time.Time) error {
+	blocks := r.metrics.GetTotalBlocksProcessed()
+	transfers := r.metrics.GetTotalTransfersProcessed()
+	sample := throughputSince(now.Sub(r.lastAt), r.lastBlocks, blocks, r.lastTransfers, transfers)
+	sample.Ts = now.UTC().Truncate(time.Second)
+	sample.LatencyMs = r.metrics.GetE2ELatency() * 1000
+
+	r.lastAt, r.lastBlocks, r.lastTransfers = now, blocks, transfers
+
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO metrics_history (ts, tps, bps, latency_ms, blocks, transfers)
+		VALUES (:ts, :tps, :bps, :latency_ms, :blocks, :transfers)
+		ON CONFLICT (ts) DO UPDATE SET
+			tps = EXCLUDED.tps, bps = EXCLUDED.bps, latency_ms = EXCLUDED.latency_ms,
+			blocks = EXCLUDED.blocks, transfers = EXCLUDED.transfers`, sample); err != nil {
+		return fmt.Errorf("insert sample: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM metrics_history WHERE ts < $1", now.Add(-MetricsHistoryRetention)); err != nil {
+		return fmt.Errorf("prune samples: %w", err)
+	}
+	return nil
+}
+
+// throughputSince 由两次计数快照求区间平均速率；计数器回绕（进程重启或重置）时该区间记为 0
+func throughputSince(elapsed time.Duration, prevBlocks, blocks, prevTransfers, transfers uint64) ThroughputSample {
+	var s ThroughputSample
+	if blocks >= prevBlocks {
+		s.Blocks = int64(blocks - prevBlocks) // #nosec G115 - 单个采样间隔内的增量
+	}
+	if transfers >= prevTransfers {
+		s.Transfers = int64(transfers - prevTransfers) // #nosec G115 - 单个采样间隔内的增量
+	}
+	if secs := elapsed.Seconds(); secs > 0 {
+		s.BPS = float64(s.Blocks) / secs
+		s.TPS = float64(s.Transfers) / secs
+	}
+	return s
+}
+
+// throughputBucket 给定查询窗口，返回使点数不超过 maxThroughputPoints 的桶宽（整分钟）
+func throughputBucket(window time.Duration) time.Duration {
+	bucket := time.Minute
+	for window/bucket > maxThroughputPoints {
+		bucket += time.Minute
+	}
+	return bucket
+}
+
+// QueryThroughput 读取最近 window 内的吞吐曲线（旧样本在前），按桶内平均降采样
+func QueryThroughput(ctx context.Context, db *sqlx.DB, window time.Duration) ([]ThroughputSample, time.Duration, error) {
+	bucket := throughputBucket(window)
+	step := int64(bucket.Seconds())
+
+	samples := []ThroughputSample{}
+	err := TimedSelect(ctx, db, "throughput_history", &samples, `
+		SELECT to_timestamp(FLOOR(EXTRACT(EPOCH FROM ts) / $2) * $2) AS ts,
+			AVG(tps) AS tps, AVG(bps) AS bps, AVG(latency_ms) AS latency_ms,
+			SUM(blocks)::BIGINT AS blocks, SUM(transfers)::BIGINT AS transfers
+		FROM metrics_history
+		WHERE ts >= $1
+		GROUP BY 1 ORDER BY 1`, time.Now().Add(-window), step)
+	return samples, bucket, err
+}
@@ -0,0 +1,30 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThroughputSince(t *testing.T) {
+	s := throughputSince(time.Minute, 100, 160, 1000, 4000)
+	assert.EqualValues(t, 60, s.Blocks)
+	assert.EqualValues(t, 3000, s.Transfers)
+	assert.InDelta(t, 1.0, s.BPS, 1e-9)
+	assert.InDelta(t, 50.0, s.TPS, 1e-9)
+
+	// 计数器回绕：该区间记为 0，而不是溢出成巨大的值
+	s = throughputSince(time.Minute, 500, 10, 500, 10)
+	assert.Zero(t, s.Blocks)
+	assert.Zero(t, s.TPS)
+
+	assert.Zero(t, throughputSince(0, 0, 10, 0, 10).BPS)
+}
+
+func TestThroughputBucket(t *testing.T) {
+	assert.Equal(t, time.Minute, throughputBucket(time.Hour))
+	assert.Equal(t, 2*time.Minute, throughputBucket(24*time.Hour))
+	assert.Equal(t, 14*time.Minute, throughputBucket(MetricsHistoryRetention))
+	assert.LessOrEqual(t, int(MetricsHistoryRetention/throughputBucket(MetricsHistoryRetention)), maxThroughputPoints)
+}
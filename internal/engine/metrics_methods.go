@@ -1,6 +1,9 @@
 package engine
 
 import (
+	"math"
+	"math/big"
+	"strconv"
 	"sync/atomic"
 	"time"
 )
@@ -28,9 +31,17 @@ func (m *Metrics) RecordReorgDetected() {
 	m.ReorgsDetected.Inc()
 }
 
-// RecordReorgHandled records a successful reorg handling
-func (m *Metrics) RecordReorgHandled(_ int) {
+// RecordReorgHandled records a successful reorg handling and its depth
+func (m *Metrics) RecordReorgHandled(depth int) {
 	m.ReorgsHandled.Inc()
+	if depth > 0 {
+		m.ReorgDepth.Observe(float64(depth))
+	}
+}
+
+// RecordReorgPhase records time from reorg detection to the given recovery phase
+func (m *Metrics) RecordReorgPhase(phase string, d time.Duration) {
+	m.ReorgRecoveryDuration.WithLabelValues(phase).Observe(d.Seconds())
 }
 
 // RecordTransferProcessed records a processed transfer
@@ -123,6 +134,21 @@ func (m *Metrics) RecordDBQuery(operation string, duration time.Duration, succes
 	}
 }
 
+// RecordHTTPRequest records an HTTP request latency by route, method and status code
+func (m *Metrics) RecordHTTPRequest(route, method string, status int, duration time.Duration) {
+	m.HTTPRequestDuration.WithLabelValues(route, method, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// RecordHTTPStatus counts a finished HTTP request by route, method and status code
+func (m *Metrics) RecordHTTPStatus(route, method string, status int) {
+	m.HTTPRequests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+}
+
+// RecordHTTPRequestDB records the database time spent serving one HTTP request
+func (m *Metrics) RecordHTTPRequestDB(route string, duration time.Duration) {
+	m.HTTPRequestDBTime.WithLabelValues(route).Observe(duration.Seconds())
+}
+
 // RecordCheckpointUpdate records a checkpoint update
 func (m *Metrics) RecordCheckpointUpdate() {
 	m.CheckpointUpdates.Inc()
@@ -177,6 +203,12 @@ func (m *Metrics) recalculateLag() {
 // UpdateE2ELatency 更新 E2E 延迟指标 (秒)
 func (m *Metrics) UpdateE2ELatency(seconds float64) {
 	m.E2ELatency.Set(seconds)
+	m.lastE2ELatency.Store(math.Float64bits(seconds))
+}
+
+// GetE2ELatency 返回最近一次记录的 E2E 延迟 (秒)
+func (m *Metrics) GetE2ELatency() float64 {
+	return math.Float64frombits(m.lastE2ELatency.Load())
 }
 
 // UpdateRealtimeTPS 更新实时 TPS 指标
@@ -230,3 +262,318 @@ func (m *Metrics) UpdateDiskFree(freePercent float64) {
 	}
 	m.DiskFree.Set(freePercent)
 }
+
+// RecordSynthesizedTransfers 记录模拟器合成转账的去向（attached / dropped）
+func (m *Metrics) RecordSynthesizedTransfers(result string, n int) {
+	if m == nil || m.SynthesizedTransfers == nil || n <= 0 {
+		return
+	}
+	m.SynthesizedTransfers.WithLabelValues(result).Add(float64(n))
+}
+
+// RecordObjectUpload 记录一次对象存储上传（result: success / failed）
+func (m *Metrics) RecordObjectUpload(result string, bytes int64, duration time.Duration) {
+	if m == nil || m.ObjectUploads == nil {
+		return
+	}
+	m.ObjectUploads.WithLabelValues(result).Inc()
+	if result == "success" {
+		m.ObjectUploadBytes.Add(float64(bytes))
+		m.ObjectUploadDuration.Observe(duration.Seconds())
+	}
+}
+
+// RecordCheckpointAudit 记录一次启动对账结果
+func (m *Metrics) RecordCheckpointAudit(verdict string, drift int64) {
+	if m == nil || m.CheckpointAudits == nil {
+		return
+	}
+	m.CheckpointAudits.WithLabelValues(verdict).Inc()
+	m.CheckpointAuditDrift.Set(float64(drift))
+}
+
+// RecordCheckpointInFlight 记录启动时 checkpoint 之上区块的采用 / 重抓数量
+func (m *Metrics) RecordCheckpointInFlight(adopted, refetched int64) {
+	if m == nil || m.CheckpointInFlight == nil {
+		return
+	}
+	m.CheckpointInFlight.WithLabelValues("adopted").Add(float64(adopted))
+	m.CheckpointInFlight.WithLabelValues("refetched").Add(float64(refetched))
+}
+
+// RecordSyntheticFallbackRows 记录空块合成兜底生成的 mock 行数
+func (m *Metrics) RecordSyntheticFallbackRows(n int) {
+	if m == nil || m.SyntheticFallbackRows == nil || n <= 0 {
+		return
+	}
+	m.SyntheticFallbackRows.Add(float64(n))
+}
+
+// SetWSConnectedClients 更新 WebSocket 在线客户端数
+func (m *Metrics) SetWSConnectedClients(n int) {
+	if m == nil || m.WSConnectedClients == nil {
+		return
+	}
+	m.WSConnectedClients.Set(float64(n))
+}
+
+// RecordWSDrop 记录一次客户端级消息丢弃；evicted 表示该客户端因此被踢出
+func (m *Metrics) RecordWSDrop(evicted bool) {
+	if m == nil || m.WSMessagesDropped == nil {
+		return
+	}
+	m.WSMessagesDropped.Inc()
+	if evicted {
+		m.WSClientsEvicted.Inc()
+	}
+}
+
+// RecordWSRejected 记录一次被拒绝的 WebSocket 握手
+func (m *Metrics) RecordWSRejected(reason string) {
+	if m == nil || m.WSConnectionsRejected == nil {
+		return
+	}
+	m.WSConnectionsRejected.WithLabelValues(reason).Inc()
+}
+
+// RecordWSResume 记录一次携带续传令牌的重连结果
+func (m *Metrics) RecordWSResume(outcome string) {
+	if m == nil || m.WSResumes == nil {
+		return
+	}
+	m.WSResumes.WithLabelValues(outcome).Inc()
+}
+
+// SetFetcherWorkers 更新抓取 worker 数
+func (m *Metrics) SetFetcherWorkers(n int) {
+	if m == nil || m.FetcherWorkers == nil {
+		return
+	}
+	m.FetcherWorkers.Set(float64(n))
+}
+
+// RecordChainHeadLookup 记录一次链头缓存查询
+func (m *Metrics) RecordChainHeadLookup(hit bool) {
+	if m == nil || m.ChainHeadLookups == nil {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.ChainHeadLookups.WithLabelValues(result).Inc()
+}
+
+// RecordTokenFiltered 记录一条被代币过滤跳过的事件
+func (m *Metrics) RecordTokenFiltered(reason string) {
+	if m == nil || m.TokenFilteredEvents == nil {
+		return
+	}
+	m.TokenFilteredEvents.WithLabelValues(reason).Inc()
+}
+
+// RecordSpamTokenMarked 记录一个被启发式判定为垃圾的代币
+func (m *Metrics) RecordSpamTokenMarked(reason string) {
+	if m == nil || m.SpamTokensMarked == nil {
+		return
+	}
+	m.SpamTokensMarked.WithLabelValues(reason).Inc()
+}
+
+// RecordLogStreamDropped 记录一条因订阅者过慢而丢弃的日志流事件
+func (m *Metrics) RecordLogStreamDropped() {
+	if m == nil || m.LogStreamDropped == nil {
+		return
+	}
+	m.LogStreamDropped.Inc()
+}
+
+// SetPollInterval 记录轮询者当前的等待间隔
+func (m *Metrics) SetPollInterval(poller string, d time.Duration) {
+	if m == nil || m.PollInterval == nil {
+		return
+	}
+	m.PollInterval.WithLabelValues(poller).Set(d.Seconds())
+}
+
+// RecordHeadUpdate 记录一次链头跟踪获得的链头
+func (m *Metrics) RecordHeadUpdate(source string) {
+	if m == nil || m.HeadUpdates == nil {
+		return
+	}
+	m.HeadUpdates.WithLabelValues(source).Inc()
+}
+
+// SetHeadSubscriptionUp 记录 WSS newHeads 订阅在线状态
+func (m *Metrics) SetHeadSubscriptionUp(up bool) {
+	if m == nil || m.HeadSubscriptionUp == nil {
+		return
+	}
+	v := 0.0
+	if up {
+		v = 1
+	}
+	m.HeadSubscriptionUp.Set(v)
+}
+
+// SetUpstreamStalled 记录上游链头停滞状态；进入停滞时累加告警次数
+func (m *Metrics) SetUpstreamStalled(stalled bool) {
+	if m == nil || m.UpstreamStalled == nil {
+		return
+	}
+	if !stalled {
+		m.UpstreamStalled.Set(0)
+		return
+	}
+	m.UpstreamStalled.Set(1)
+	m.UpstreamStalls.Inc()
+}
+
+// SetMempoolPending 记录待打包转账视图的当前大小
+func (m *Metrics) SetMempoolPending(n int) {
+	if m == nil || m.MempoolPending == nil {
+		return
+	}
+	m.MempoolPending.Set(float64(n))
+}
+
+// RecordMempoolTransfer 按结局累加待打包转账
+func (m *Metrics) RecordMempoolTransfer(outcome string) {
+	if m == nil || m.MempoolTransfers == nil {
+		return
+	}
+	m.MempoolTransfers.WithLabelValues(outcome).Inc()
+}
+
+// RecordMempoolHashDropped 累加因查询队列已满而丢弃的 pending 哈希
+func (m *Metrics) RecordMempoolHashDropped() {
+	if m == nil || m.MempoolHashesDropped == nil {
+		return
+	}
+	m.MempoolHashesDropped.Inc()
+}
+
+// RecordActivityTypes 按活动类型累加已提交的转账数
+func (m *Metrics) RecordActivityTypes(counts map[string]int) {
+	if m == nil || m.TransactionTypesTotal == nil {
+		return
+	}
+	for t, n := range counts {
+		m.TransactionTypesTotal.WithLabelValues(t).Add(float64(n))
+	}
+}
+
+// RecordCodeCacheLookups 记录字节码缓存从某一级解析出的地址数
+func (m *Metrics) RecordCodeCacheLookups(source string, n int) {
+	if m == nil || m.CodeCacheLookups == nil || n <= 0 {
+		return
+	}
+	m.CodeCacheLookups.WithLabelValues(source).Add(float64(n))
+}
+
+// RecordMetricsPush 记录一次指标主动导出的结果
+func (m *Metrics) RecordMetricsPush(mode, result string) {
+	if m == nil || m.MetricsPushes == nil {
+		return
+	}
+	m.MetricsPushes.WithLabelValues(mode, result).Inc()
+}
+
+// RecordRPCCoalesced 记录一次被合并到在途请求的 RPC 调用
+func (m *Metrics) RecordRPCCoalesced(method string) {
+	if m == nil || m.RPCCoalesced == nil {
+		return
+	}
+	m.RPCCoalesced.WithLabelValues(method).Inc()
+}
+
+// RecordArbitrageCandidates 记录本块检测到的套利候选数
+func (m *Metrics) RecordArbitrageCandidates(n int) {
+	if m == nil || m.ArbitrageCandidates == nil || n <= 0 {
+		return
+	}
+	m.ArbitrageCandidates.Add(float64(n))
+}
+
+// RecordBlockCongestion 记录区块的 gas 使用与填充率，并更新滑动平均（gas_limit 为 0 的区块只记录原始值）
+func (m *Metrics) RecordBlockCongestion(gasUsed, gasLimit uint64) {
+	if m == nil || m.BlockFillRatio == nil {
+		return
+	}
+	m.BlockGasUsed.Set(float64(gasUsed))
+	m.BlockGasLimit.Set(float64(gasLimit))
+	if gasLimit == 0 {
+		return
+	}
+	ratio := float64(gasUsed) / float64(gasLimit)
+	m.BlockFillRatio.Set(ratio)
+	for _, w := range m.fillWindows {
+		m.BlockFillRatioAvg.WithLabelValues(strconv.Itoa(w.size())).Set(w.add(ratio))
+	}
+}
+
+// RecordBlobUsage 记录一个 Dencun 之后区块的 blob 使用情况（maxBlobGas 为 0 时不更新利用率）
+func (m *Metrics) RecordBlobUsage(blobGasUsed, excessBlobGas, maxBlobGas uint64, blobTxs, blobs int) {
+	if m == nil || m.BlobGasUsed == nil {
+		return
+	}
+	m.BlobGasUsed.Set(float64(blobGasUsed))
+	m.ExcessBlobGas.Set(float64(excessBlobGas))
+	if maxBlobGas > 0 {
+		m.BlobUtilization.Set(float64(blobGasUsed) / float64(maxBlobGas))
+	}
+	m.BlobTransactions.Add(float64(blobTxs))
+	m.Blobs.Add(float64(blobs))
+}
+
+// RecordBlockFees 记录一个 London 之后区块的 baseFee、小费中位数（wei）与销毁量（wei）
+func (m *Metrics) RecordBlockFees(baseFee, priorityP50, burnt *big.Int) {
+	if m == nil || m.BaseFeeGwei == nil {
+		return
+	}
+	m.BaseFeeGwei.Set(weiToUnit(baseFee, 1e9))
+	m.PriorityFeeP50.Set(weiToUnit(priorityP50, 1e9))
+	m.BurntFeesEthTotal.Add(weiToUnit(burnt, 1e18))
+}
+
+// weiToUnit wei 换算为 gwei / ETH 等浮点单位（nil 为 0）
+func weiToUnit(wei *big.Int, unit float64) float64 {
+	if wei == nil {
+		return 0
+	}
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(unit)).Float64()
+	return f
+}
+
+// RecordSkippedBlocks 累加未索引即被跳过的块数
+func (m *Metrics) RecordSkippedBlocks(reason string, blocks uint64) {
+	if m == nil || m.SkippedBlocks == nil {
+		return
+	}
+	m.SkippedBlocks.WithLabelValues(reason).Add(float64(blocks))
+}
+
+// RecordDecimalBackfill 累加回填 normalized_amount 的行数并更新剩余行数
+func (m *Metrics) RecordDecimalBackfill(rows, pending int64) {
+	if m == nil || m.DecimalBackfillRows == nil {
+		return
+	}
+	m.DecimalBackfillRows.Add(float64(rows))
+	m.DecimalBackfillPending.Set(float64(pending))
+}
+
+// SetWatchedAddresses 更新当前生效的监控地址数
+func (m *Metrics) SetWatchedAddresses(n int) {
+	if m == nil || m.WatchedAddresses == nil {
+		return
+	}
+	m.WatchedAddresses.Set(float64(n))
+}
+
+// RecordBackfilledBlocks 累加后台回填补齐的块数
+func (m *Metrics) RecordBackfilledBlocks(blocks uint64) {
+	if m == nil || m.BackfilledBlocks == nil {
+		return
+	}
+	m.BackfilledBlocks.Add(float64(blocks))
+}
@@ -0,0 +1,239 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// OTLP/HTTP JSON 编码（opentelemetry-proto ExportMetricsServiceRequest 的 JSON 映射），
+// 不引入 OTel SDK：按采集快照直接构造请求体，累计值使用 CUMULATIVE 时间性。
+// 64 位整数按 protobuf JSON 规则编码为字符串。
+
+const otlpCumulative = 2 // AGGREGATION_TEMPORALITY_CUMULATIVE
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpNumberPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	AsDouble          float64        `json:"asDouble"`
+}
+
+type otlpHistogramPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	Count             string         `json:"count"`
+	Sum               float64        `json:"sum"`
+	BucketCounts      []string       `json:"bucketCounts"`
+	ExplicitBounds    []float64      `json:"explicitBounds"`
+}
+
+type otlpQuantile struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+type otlpSummaryPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	Count             string         `json:"count"`
+	Sum               float64        `json:"sum"`
+	QuantileValues    []otlpQuantile `json:"quantileValues"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpNumberPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberPoint `json:"dataPoints"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpHistogramPoint `json:"dataPoints"`
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+}
+
+type otlpSummary struct {
+	DataPoints []otlpSummaryPoint `json:"dataPoints"`
+}
+
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Sum         *otlpSum       `json:"sum,omitempty"`
+	Gauge       *otlpGauge     `json:"gauge,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+	Summary     *otlpSummary   `json:"summary,omitempty"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpExportRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+// encodeOTLPMetrics 把采集快照转换为 OTLP 导出请求；job / instance 映射为 service.name / service.instance.id
+func encodeOTLPMetrics(families []*dto.MetricFamily, job, instance string, start, now time.Time) otlpExportRequest {
+	startNs := strconv.FormatInt(start.UnixNano(), 10)
+	nowNs := strconv.FormatInt(now.UnixNano(), 10)
+
+	metrics := make([]otlpMetric, 0, len(families))
+	for _, mf := range families {
+		om := otlpMetric{Name: mf.GetName(), Description: mf.GetHelp()}
+		for _, m := range mf.GetMetric() {
+			attrs := make([]otlpKeyValue, 0, len(m.GetLabel()))
+			for _, lp := range m.GetLabel() {
+				attrs = append(attrs, otlpKeyValue{Key: lp.GetName(), Value: otlpAnyValue{StringValue: lp.GetValue()}})
+			}
+			number := func(v float64) otlpNumberPoint {
+				return otlpNumberPoint{Attributes: attrs, StartTimeUnixNano: startNs, TimeUnixNano: nowNs, AsDouble: v}
+			}
+
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				if om.Sum == nil {
+					om.Sum = &otlpSum{AggregationTemporality: otlpCumulative, IsMonotonic: true}
+				}
+				if v := m.GetCounter().GetValue(); isFinite(v) {
+					om.Sum.DataPoints = append(om.Sum.DataPoints, number(v))
+				}
+			case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+				if om.Gauge == nil {
+					om.Gauge = &otlpGauge{}
+				}
+				v := m.GetGauge().GetValue()
+				if mf.GetType() == dto.MetricType_UNTYPED {
+					v = m.GetUntyped().GetValue()
+				}
+				if isFinite(v) {
+					om.Gauge.DataPoints = append(om.Gauge.DataPoints, number(v))
+				}
+			case dto.MetricType_HISTOGRAM:
+				if om.Histogram == nil {
+					om.Histogram = &otlpHistogram{AggregationTemporality: otlpCumulative}
+				}
+				h := m.GetHistogram()
+				// OTLP 桶计数为非累计值，且末尾有一个隐含的 +Inf 桶
+				var bounds []float64
+				var counts []string
+				var prev uint64
+				for _, b := range h.GetBucket() {
+					if math.IsInf(b.GetUpperBound(), +1) {
+						continue
+					}
+					bounds = append(bounds, b.GetUpperBound())
+					counts = append(counts, strconv.FormatUint(b.GetCumulativeCount()-prev, 10))
+					prev = b.GetCumulativeCount()
+				}
+				counts = append(counts, strconv.FormatUint(h.GetSampleCount()-prev, 10))
+				om.Histogram.DataPoints = append(om.Histogram.DataPoints, otlpHistogramPoint{
+					Attributes:        attrs,
+					StartTimeUnixNano: startNs,
+					TimeUnixNano:      nowNs,
+					Count:             strconv.FormatUint(h.GetSampleCount(), 10),
+					Sum:               h.GetSampleSum(),
+					BucketCounts:      counts,
+					ExplicitBounds:    bounds,
+				})
+			case dto.MetricType_SUMMARY:
+				if om.Summary == nil {
+					om.Summary = &otlpSummary{}
+				}
+				s := m.GetSummary()
+				point := otlpSummaryPoint{
+					Attributes:        attrs,
+					StartTimeUnixNano: startNs,
+					TimeUnixNano:      nowNs,
+					Count:             strconv.FormatUint(s.GetSampleCount(), 10),
+					Sum:               s.GetSampleSum(),
+				}
+				for _, q := range s.GetQuantile() {
+					if !isFinite(q.GetValue()) { // 无观测值时 quantile 为 NaN，JSON 无法表示
+						continue
+					}
+					point.QuantileValues = append(point.QuantileValues, otlpQuantile{Quantile: q.GetQuantile(), Value: q.GetValue()})
+				}
+				om.Summary.DataPoints = append(om.Summary.DataPoints, point)
+			}
+		}
+		if (om.Sum != nil && len(om.Sum.DataPoints) > 0) || (om.Gauge != nil && len(om.Gauge.DataPoints) > 0) ||
+			om.Histogram != nil || om.Summary != nil {
+			metrics = append(metrics, om)
+		}
+	}
+
+	rm := otlpResourceMetrics{}
+	rm.Resource.Attributes = []otlpKeyValue{
+		{Key: "service.name", Value: otlpAnyValue{StringValue: job}},
+		{Key: "service.instance.id", Value: otlpAnyValue{StringValue: instance}},
+	}
+	rm.ScopeMetrics = []otlpScopeMetrics{{Scope: otlpScope{Name: "web3-indexer-go"}, Metrics: metrics}}
+	return otlpExportRequest{ResourceMetrics: []otlpResourceMetrics{rm}}
+}
+
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// postOTLP 以 OTLP/HTTP JSON 发送到 endpoint（通常为 http://collector:4318/v1/metrics）
+func postOTLP(ctx context.Context, client *http.Client, endpoint, user, pass string, payload otlpExportRequest) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if user != "" {
+		req.SetBasicAuth(user, pass)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("otlp export returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
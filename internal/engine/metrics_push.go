@@ -0,0 +1,336 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MetricsPushMode 指标主动导出方式：Prometheus 推送（用于无法被抓取的实验室环境），
+// 或 StatsD / OTLP 第二后端（面向 Datadog / OpenTelemetry 体系，无需 Prometheus 桥接）
+type MetricsPushMode string
+
+const (
+	MetricsPushNone        MetricsPushMode = "none"
+	MetricsPushGateway     MetricsPushMode = "pushgateway"
+	MetricsPushRemoteWrite MetricsPushMode = "remote_write"
+	MetricsPushStatsD      MetricsPushMode = "statsd"
+	MetricsPushOTLP        MetricsPushMode = "otlp"
+)
+
+const metricsPushTimeout = 10 * time.Second
+
+// MetricsPusher 按 ConfigManager 中的配置周期性地把 Prometheus 默认采集器（即 Metrics 门面记录的全部指标，带 chain_id）
+// 推送到 Pushgateway、remote-write、StatsD 或 OTLP 端点；配置热更新后立即按新设置重新调度
+type MetricsPusher struct {
+	cm       *ConfigManager
+	gatherer prometheus.Gatherer
+	client   *http.Client
+	instance string
+	wake     chan struct{}
+	now      func() time.Time
+	started  time.Time       // OTLP 累计值的起始时间
+	statsd   *statsdExporter // StatsD 计数器增量状态（仅推送循环访问）
+}
+
+// NewMetricsPusher 创建推送器并订阅配置变更；instance 标签取主机名
+func NewMetricsPusher(cm *ConfigManager) *MetricsPusher {
+	instance, err := os.Hostname()
+	if err != nil || instance == "" {
+		instance = "unknown"
+	}
+	p := &MetricsPusher{
+		cm:       cm,
+		gatherer: prometheus.DefaultGatherer,
+		client:   &http.Client{Timeout: metricsPushTimeout},
+		instance: instance,
+		wake:     make(chan struct{}, 1),
+		now:      time.Now,
+		started:  time.Now(),
+		statsd:   newStatsdExporter(),
+	}
+	cm.OnChange(func(IndexerConfig) {
+		select {
+		case p.wake <- struct{}{}:
+		default:
+		}
+	})
+	return p
+}
+
+// metricsPushEnabled 配置是否启用了推送
+func metricsPushEnabled(cfg IndexerConfig) bool {
+	switch cfg.MetricsPushMode {
+	case MetricsPushGateway, MetricsPushRemoteWrite, MetricsPushStatsD, MetricsPushOTLP:
+		return cfg.MetricsPushURL != ""
+	}
+	return false
+}
+
+// Run 推送循环，直到 ctx 取消；退出前若已启用则做最后一次推送，避免短时回放丢失末尾数据
+func (p *MetricsPusher) Run(ctx context.Context) {
+	announce := true
+	for {
+		cfg := p.cm.Get()
+		enabled := metricsPushEnabled(cfg)
+		if enabled && announce {
+			Logger.Info("📤 [MetricsPush] Export scheduled",
+				slog.String("mode", string(cfg.MetricsPushMode)),
+				slog.String("url", redactURL(cfg.MetricsPushURL)),
+				slog.Duration("interval", cfg.MetricsPushInterval))
+		}
+		if !enabled && announce && cfg.MetricsPushMode != "" && cfg.MetricsPushMode != MetricsPushNone {
+			Logger.Warn("⚠️ [MetricsPush] Export disabled: unknown mode or missing url",
+				slog.String("mode", string(cfg.MetricsPushMode)))
+		}
+		announce = false
+
+		var tick <-chan time.Time
+		timer := time.NewTimer(max(cfg.MetricsPushInterval, time.Second))
+		if enabled {
+			tick = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if enabled {
+				finalCtx, cancel := context.WithTimeout(context.Background(), metricsPushTimeout)
+				p.pushLogged(finalCtx, cfg)
+				cancel()
+			}
+			return
+		case <-p.wake:
+			timer.Stop()
+			announce = true
+		case <-tick:
+			p.pushLogged(ctx, cfg)
+		}
+	}
+}
+
+func (p *MetricsPusher) pushLogged(ctx context.Context, cfg IndexerConfig) {
+	if err := p.Push(ctx, cfg); err != nil {
+		Logger.Warn("⚠️ [MetricsPush] Export failed",
+			slog.String("mode", string(cfg.MetricsPushMode)),
+			slog.String("error", err.Error()))
+	}
+}
+
+// Push 按 cfg 推送一次当前指标
+func (p *MetricsPusher) Push(ctx context.Context, cfg IndexerConfig) error {
+	var err error
+	switch cfg.MetricsPushMode {
+	case MetricsPushGateway:
+		err = p.pushGateway(ctx, cfg)
+	case MetricsPushRemoteWrite:
+		err = p.remoteWrite(ctx, cfg)
+	case MetricsPushStatsD:
+		err = p.pushStatsD(ctx, cfg)
+	case MetricsPushOTLP:
+		err = p.pushOTLP(ctx, cfg)
+	default:
+		return nil
+	}
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	GetMetrics().RecordMetricsPush(string(cfg.MetricsPushMode), result)
+	return err
+}
+
+// pushGateway 以 PUT 覆盖 job/instance 分组下的全部指标
+func (p *MetricsPusher) pushGateway(ctx context.Context, cfg IndexerConfig) error {
+	base, user, pass, err := splitURLCredentials(cfg.MetricsPushURL)
+	if err != nil {
+		return err
+	}
+	pusher := push.New(base, cfg.MetricsPushJob).
+		Grouping("instance", p.instance).
+		Gatherer(p.gatherer).
+		Client(p.client)
+	if user != "" {
+		pusher = pusher.BasicAuth(user, pass)
+	}
+	return pusher.PushContext(ctx)
+}
+
+// remoteWrite 按 Prometheus remote-write 1.0 协议（snappy 压缩的 protobuf WriteRequest）发送
+func (p *MetricsPusher) remoteWrite(ctx context.Context, cfg IndexerConfig) error {
+	families, err := p.gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("gather metrics: %w", err)
+	}
+	body := s2.EncodeSnappy(nil, encodeWriteRequest(families, cfg.MetricsPushJob, p.instance, p.now().UnixMilli()))
+
+	endpoint, user, pass, err := splitURLCredentials(cfg.MetricsPushURL)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if user != "" {
+		req.SetBasicAuth(user, pass)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote write returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// pushStatsD 以 DogStatsD 行协议经 UDP 发送（计数器为距上次导出的增量）
+func (p *MetricsPusher) pushStatsD(ctx context.Context, cfg IndexerConfig) error {
+	families, err := p.gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("gather metrics: %w", err)
+	}
+	return p.statsd.send(ctx, cfg.MetricsPushURL, p.statsd.encode(families, cfg.MetricsPushJob))
+}
+
+// pushOTLP 以 OTLP/HTTP JSON 发送累计值
+func (p *MetricsPusher) pushOTLP(ctx context.Context, cfg IndexerConfig) error {
+	families, err := p.gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("gather metrics: %w", err)
+	}
+	endpoint, user, pass, err := splitURLCredentials(cfg.MetricsPushURL)
+	if err != nil {
+		return err
+	}
+	return postOTLP(ctx, p.client, endpoint, user, pass, encodeOTLPMetrics(families, cfg.MetricsPushJob, p.instance, p.started, p.now()))
+}
+
+// splitURLCredentials 拆出 URL 中内嵌的 basic-auth 凭据
+func splitURLCredentials(raw string) (string, string, string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid metrics push url: %w", err)
+	}
+	if u.User == nil {
+		return raw, "", "", nil
+	}
+	user := u.User.Username()
+	pass, _ := u.User.Password()
+	u.User = nil
+	return u.String(), user, pass, nil
+}
+
+// redactURL 日志中隐藏 URL 内嵌的密码
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "<invalid>"
+	}
+	return u.Redacted()
+}
+
+type promLabel struct{ name, value string }
+
+// encodeWriteRequest 把采集结果展开为时间序列并编码为 prometheus.WriteRequest（未压缩）。
+// 直方图展开为 _bucket{le}/_sum/_count，summary 展开为 {quantile}/_sum/_count。
+func encodeWriteRequest(families []*dto.MetricFamily, job, instance string, nowMs int64) []byte {
+	var buf []byte
+	for _, mf := range families {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			base := make([]promLabel, 0, len(m.GetLabel())+2)
+			base = append(base, promLabel{"job", job}, promLabel{"instance", instance})
+			for _, lp := range m.GetLabel() {
+				base = append(base, promLabel{lp.GetName(), lp.GetValue()})
+			}
+			ts := nowMs
+			if m.TimestampMs != nil {
+				ts = m.GetTimestampMs()
+			}
+			emit := func(suffix string, v float64, extra ...promLabel) {
+				buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+				buf = protowire.AppendBytes(buf, encodeTimeSeries(name+suffix, base, extra, v, ts))
+			}
+
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				emit("", m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				emit("", m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				emit("", m.GetUntyped().GetValue())
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				for _, b := range h.GetBucket() {
+					if math.IsInf(b.GetUpperBound(), +1) {
+						continue
+					}
+					emit("_bucket", float64(b.GetCumulativeCount()), promLabel{"le", strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64)})
+				}
+				emit("_bucket", float64(h.GetSampleCount()), promLabel{"le", "+Inf"})
+				emit("_sum", h.GetSampleSum())
+				emit("_count", float64(h.GetSampleCount()))
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					emit("", q.GetValue(), promLabel{"quantile", strconv.FormatFloat(q.GetQuantile(), 'g', -1, 64)})
+				}
+				emit("_sum", s.GetSampleSum())
+				emit("_count", float64(s.GetSampleCount()))
+			}
+		}
+	}
+	return buf
+}
+
+// encodeTimeSeries 编码单个 TimeSeries（标签按名称排序，remote-write 协议要求）
+func encodeTimeSeries(name string, base, extra []promLabel, value float64, ts int64) []byte {
+	labels := make([]promLabel, 0, len(base)+len(extra)+1)
+	labels = append(labels, promLabel{"__name__", name})
+	labels = append(labels, base...)
+	labels = append(labels, extra...)
+	sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+
+	var out []byte
+	for _, l := range labels {
+		var lb []byte
+		lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.name)
+		lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.value)
+		out = protowire.AppendTag(out, 1, protowire.BytesType)
+		out = protowire.AppendBytes(out, lb)
+	}
+
+	var sb []byte
+	sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+	sb = protowire.AppendFixed64(sb, math.Float64bits(value))
+	sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+	sb = protowire.AppendVarint(sb, uint64(ts)) // #nosec G115 - int64 按 protobuf 语义按位转换
+	out = protowire.AppendTag(out, 2, protowire.BytesType)
+	return protowire.AppendBytes(out, sb)
+}
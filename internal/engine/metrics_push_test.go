@@ -0,0 +1,247 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func newTestPusher(t *testing.T, cfg IndexerConfig) (*MetricsPusher, *ConfigManager) {
+	t.Helper()
+	reg := prometheus.NewRegistry()
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_blocks_total", Help: "h"}, []string{"chain"})
+	c.WithLabelValues("1").Add(3)
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_latency_seconds", Help: "h", Buckets: []float64{0.5, 1}})
+	h.Observe(0.2)
+	h.Observe(2)
+	g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_queue_depth", Help: "h"})
+	g.Set(7)
+	reg.MustRegister(c, h, g)
+
+	cm := NewConfigManager(cfg)
+	p := NewMetricsPusher(cm)
+	p.gatherer = reg
+	p.instance = "lab1"
+	p.now = func() time.Time { return time.UnixMilli(1700000000000) }
+	return p, cm
+}
+
+// decodeWriteRequest 把 WriteRequest 解码为 "name{k=v,...}" -> value
+func decodeWriteRequest(t *testing.T, data []byte) map[string]float64 {
+	t.Helper()
+	fields := func(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) int) {
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			require.GreaterOrEqual(t, n, 0)
+			b = b[n:]
+			n = fn(num, typ, b)
+			require.GreaterOrEqual(t, n, 0)
+			b = b[n:]
+		}
+	}
+
+	out := make(map[string]float64)
+	fields(data, func(_ protowire.Number, _ protowire.Type, b []byte) int {
+		series, n := protowire.ConsumeBytes(b)
+		var labels []string
+		var value float64
+		fields(series, func(num protowire.Number, _ protowire.Type, b []byte) int {
+			msg, n := protowire.ConsumeBytes(b)
+			switch num {
+			case 1:
+				var kv [2]string
+				fields(msg, func(num protowire.Number, _ protowire.Type, b []byte) int {
+					s, n := protowire.ConsumeString(b)
+					kv[num-1] = s
+					return n
+				})
+				labels = append(labels, kv[0]+"="+kv[1])
+			case 2:
+				fields(msg, func(num protowire.Number, _ protowire.Type, b []byte) int {
+					if num == 1 {
+						v, n := protowire.ConsumeFixed64(b)
+						value = math.Float64frombits(v)
+						return n
+					}
+					v, n := protowire.ConsumeVarint(b)
+					assert.EqualValues(t, 1700000000000, v)
+					return n
+				})
+			}
+			return n
+		})
+		out["{"+strings.Join(labels, ",")+"}"] = value
+		return n
+	})
+	return out
+}
+
+func TestMetricsPusher_RemoteWrite(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "0.1.0", r.Header.Get("X-Prometheus-Remote-Write-Version"))
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "u:p", user+":"+pass)
+		raw, _ := io.ReadAll(r.Body)
+		var err error
+		body, err = s2.Decode(nil, raw)
+		assert.NoError(t, err)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	cfg := DefaultConfig()
+	cfg.MetricsPushMode = MetricsPushRemoteWrite
+	cfg.MetricsPushURL = strings.Replace(srv.URL, "http://", "http://u:p@", 1) + "/api/v1/write"
+	p, _ := newTestPusher(t, cfg)
+	require.NoError(t, p.Push(context.Background(), cfg))
+
+	series := decodeWriteRequest(t, body)
+	assert.Equal(t, map[string]float64{
+		"{__name__=test_blocks_total,chain=1,instance=lab1,job=web3_indexer}":           3,
+		"{__name__=test_latency_seconds_bucket,instance=lab1,job=web3_indexer,le=0.5}":  1,
+		"{__name__=test_latency_seconds_bucket,instance=lab1,job=web3_indexer,le=1}":    1,
+		"{__name__=test_latency_seconds_bucket,instance=lab1,job=web3_indexer,le=+Inf}": 2,
+		"{__name__=test_latency_seconds_sum,instance=lab1,job=web3_indexer}":            2.2,
+		"{__name__=test_latency_seconds_count,instance=lab1,job=web3_indexer}":          2,
+		"{__name__=test_queue_depth,instance=lab1,job=web3_indexer}":                    7,
+	}, series)
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	})
+	assert.ErrorContains(t, p.Push(context.Background(), cfg), "out of order sample")
+}
+
+func TestMetricsPusher_HotReloadToPushgateway(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Contains(t, string(body), "test_blocks_total")
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+	pushed := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(paths)
+	}
+
+	p, cm := newTestPusher(t, DefaultConfig())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Run(ctx)
+	}()
+
+	// 默认不推送；热更新启用后按新间隔开始推送
+	time.Sleep(50 * time.Millisecond)
+	assert.Zero(t, pushed())
+	next := cm.Get()
+	next.MetricsPushMode = MetricsPushGateway
+	next.MetricsPushURL = srv.URL
+	next.MetricsPushInterval = time.Second
+	require.NoError(t, cm.Update(ctx, next))
+	require.Eventually(t, func() bool { return pushed() >= 1 }, 3*time.Second, 20*time.Millisecond)
+
+	// 退出前做最后一次推送
+	before := pushed()
+	cancel()
+	<-done
+	assert.Equal(t, before+1, pushed())
+	assert.Equal(t, "/metrics/job/web3_indexer/instance/lab1", paths[0])
+
+	bad := cm.Get()
+	bad.MetricsPushURL = ""
+	assert.ErrorContains(t, cm.Update(context.Background(), bad), "metrics_push_url is required")
+}
+
+func TestMetricsPusher_StatsDSendsCounterDeltas(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+	read := func() []string {
+		buf := make([]byte, statsdMaxPacket)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		return strings.Split(string(buf[:n]), "\n")
+	}
+
+	cfg := DefaultConfig()
+	cfg.MetricsPushMode = MetricsPushStatsD
+	cfg.MetricsPushURL = "udp://" + conn.LocalAddr().String()
+	p, _ := newTestPusher(t, cfg)
+
+	require.NoError(t, p.Push(context.Background(), cfg))
+	assert.ElementsMatch(t, []string{
+		"test_blocks_total:3|c|#chain:1,job:web3_indexer",
+		"test_latency_seconds_bucket:1|c|#job:web3_indexer,le:0.5",
+		"test_latency_seconds_bucket:1|c|#job:web3_indexer,le:1",
+		"test_latency_seconds_sum:2.2|c|#job:web3_indexer",
+		"test_latency_seconds_count:2|c|#job:web3_indexer",
+		"test_queue_depth:7|g|#job:web3_indexer",
+	}, read())
+
+	// 累计值未变化时只上报 gauge
+	require.NoError(t, p.Push(context.Background(), cfg))
+	assert.Equal(t, []string{"test_queue_depth:7|g|#job:web3_indexer"}, read())
+}
+
+func TestMetricsPusher_OTLP(t *testing.T) {
+	var req map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/metrics", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+	}))
+	defer srv.Close()
+
+	cfg := DefaultConfig()
+	cfg.MetricsPushMode = MetricsPushOTLP
+	cfg.MetricsPushURL = srv.URL + "/v1/metrics"
+	p, _ := newTestPusher(t, cfg)
+	require.NoError(t, p.Push(context.Background(), cfg))
+
+	rm := req["resourceMetrics"].([]any)[0].(map[string]any)
+	assert.Contains(t, rm["resource"].(map[string]any)["attributes"], map[string]any{"key": "service.name", "value": map[string]any{"stringValue": "web3_indexer"}})
+	metrics := make(map[string]map[string]any)
+	for _, m := range rm["scopeMetrics"].([]any)[0].(map[string]any)["metrics"].([]any) {
+		metrics[m.(map[string]any)["name"].(string)] = m.(map[string]any)
+	}
+
+	sum := metrics["test_blocks_total"]["sum"].(map[string]any)
+	assert.Equal(t, true, sum["isMonotonic"])
+	assert.EqualValues(t, 2, sum["aggregationTemporality"])
+	assert.EqualValues(t, 3, sum["dataPoints"].([]any)[0].(map[string]any)["asDouble"])
+
+	hist := metrics["test_latency_seconds"]["histogram"].(map[string]any)["dataPoints"].([]any)[0].(map[string]any)
+	assert.Equal(t, "2", hist["count"])
+	assert.Equal(t, []any{"1", "0", "1"}, hist["bucketCounts"], "bucket counts are per-bucket with a trailing +Inf bucket")
+	assert.Equal(t, []any{0.5, 1.0}, hist["explicitBounds"])
+	assert.Equal(t, "1700000000000000000", hist["timeUnixNano"])
+
+	assert.Contains(t, metrics["test_queue_depth"], "gauge")
+}
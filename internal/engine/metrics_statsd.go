@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// statsdMaxPacket 单个 UDP 数据报上限（以太网 MTU 内，DogStatsD 推荐值）
+const statsdMaxPacket = 1432
+
+// statsdExporter 把 Prometheus 快照转换为 DogStatsD 行协议：
+// gauge 原样上报（|g），counter / 直方图 / summary 的累计值换算为两次导出之间的增量（|c），
+// 标签转为 #k:v 标签。非并发安全，由 MetricsPusher 的推送循环独占。
+type statsdExporter struct {
+	prev map[string]float64 // 上次导出的累计值，key 为行前缀（名称 + 标签）
+}
+
+func newStatsdExporter() *statsdExporter {
+	return &statsdExporter{prev: make(map[string]float64)}
+}
+
+// encode 生成本次需要发送的行
+func (e *statsdExporter) encode(families []*dto.MetricFamily, job string) []string {
+	var lines []string
+	for _, mf := range families {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			tags := make([]string, 0, len(m.GetLabel())+1)
+			tags = append(tags, "job:"+statsdTag(job))
+			for _, lp := range m.GetLabel() {
+				tags = append(tags, statsdTag(lp.GetName())+":"+statsdTag(lp.GetValue()))
+			}
+			gauge := func(suffix string, v float64, extra ...string) {
+				if !isFinite(v) {
+					return
+				}
+				lines = append(lines, name+suffix+":"+formatStatsdValue(v)+"|g|#"+joinTags(tags, extra))
+			}
+			counter := func(suffix string, v float64, extra ...string) {
+				tagStr := joinTags(tags, extra)
+				key := name + suffix + "|" + tagStr
+				delta := v - e.prev[key]
+				e.prev[key] = v
+				if delta < 0 { // 计数器被重置
+					delta = v
+				}
+				if delta == 0 {
+					return
+				}
+				lines = append(lines, name+suffix+":"+formatStatsdValue(delta)+"|c|#"+tagStr)
+			}
+
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				counter("", m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				gauge("", m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				gauge("", m.GetUntyped().GetValue())
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				for _, b := range h.GetBucket() {
+					counter("_bucket", float64(b.GetCumulativeCount()), "le:"+strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64))
+				}
+				counter("_sum", h.GetSampleSum())
+				counter("_count", float64(h.GetSampleCount()))
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					gauge("", q.GetValue(), "quantile:"+strconv.FormatFloat(q.GetQuantile(), 'g', -1, 64))
+				}
+				counter("_sum", s.GetSampleSum())
+				counter("_count", float64(s.GetSampleCount()))
+			}
+		}
+	}
+	return lines
+}
+
+// send 把行打包成不超过 statsdMaxPacket 的数据报发往 addr（host:port，可带 udp:// 前缀）
+func (e *statsdExporter) send(ctx context.Context, addr string, lines []string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", strings.TrimPrefix(addr, "udp://"))
+	if err != nil {
+		return fmt.Errorf("dial statsd: %w", err)
+	}
+	defer conn.Close()
+
+	var packet []byte
+	flush := func() error {
+		if len(packet) == 0 {
+			return nil
+		}
+		_, err := conn.Write(packet)
+		packet = packet[:0]
+		return err
+	}
+	for _, line := range lines {
+		if len(packet) > 0 && len(packet)+1+len(line) > statsdMaxPacket {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+	}
+	return flush()
+}
+
+func joinTags(tags, extra []string) string {
+	all := append(append(make([]string, 0, len(tags)+len(extra)), tags...), extra...)
+	sort.Strings(all)
+	return strings.Join(all, ",")
+}
+
+// statsdTag 替换 DogStatsD 保留字符
+func statsdTag(s string) string {
+	return strings.NewReplacer("|", "_", ",", "_", "#", "_", "\n", "_").Replace(s)
+}
+
+func formatStatsdValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
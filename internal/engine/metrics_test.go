@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,6 +18,7 @@ func TestMetrics_Recorders(t *testing.T) {
 	m.RecordBlockSkipped()
 	m.RecordReorgDetected()
 	m.RecordReorgHandled(5)
+	m.RecordReorgPhase(reorgPhaseResumed, 2*time.Second)
 	m.RecordTransferProcessed()
 	m.RecordTransferFailed()
 	m.RecordFetcherJobQueued()
@@ -38,3 +40,14 @@ func TestMetrics_Recorders(t *testing.T) {
 	assert.GreaterOrEqual(t, txProcessed, uint64(0))
 	assert.GreaterOrEqual(t, blocksProcessed, uint64(1))
 }
+
+func TestMetrics_HTTPStatusAndDBTime(t *testing.T) {
+	m := GetMetrics()
+	before := testutil.ToFloat64(m.HTTPRequests.WithLabelValues("/api/test", "GET", "503"))
+
+	m.RecordHTTPStatus("/api/test", "GET", 503)
+	m.RecordHTTPRequestDB("/api/test", 12*time.Millisecond)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(m.HTTPRequests.WithLabelValues("/api/test", "GET", "503")))
+	assert.GreaterOrEqual(t, testutil.CollectAndCount(m.HTTPRequestDBTime, "indexer_http_request_db_seconds"), 1)
+}
@@ -0,0 +1,280 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// UploadedPart 已上传的分片（CompleteMultipartUpload 需要按序提交）
+type UploadedPart struct {
+	PartNumber int    `json:"part_number" xml:"PartNumber"`
+	ETag       string `json:"etag" xml:"ETag"`
+}
+
+// ObjectUploader 对象存储分片上传接口（S3 multipart 语义）
+type ObjectUploader interface {
+	CreateMultipartUpload(ctx context.Context, key string) (uploadID string, err error)
+	UploadPart(ctx context.Context, key, uploadID string, partNumber int, body []byte) (etag string, err error)
+	CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []UploadedPart) error
+}
+
+// S3UploaderConfig S3 兼容端点配置
+// GCS 通过 XML 互操作 API（storage.googleapis.com + HMAC 密钥）同样适用，MinIO 亦然
+type S3UploaderConfig struct {
+	Endpoint  string // 例如 https://s3.us-east-1.amazonaws.com、https://storage.googleapis.com
+	Region    string // GCS 使用 "auto"
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Timeout   time.Duration
+}
+
+// S3Uploader 基于 net/http + SigV4 签名的最小 S3 客户端（path-style 寻址）：
+// multipart 上传供对象存储 sink 使用，ListObjects / OpenObject 供回放源流式读取
+type S3Uploader struct {
+	cfg    S3UploaderConfig
+	client *http.Client
+	stream *http.Client // 流式下载不设整体超时（大对象读取时长不可预估），由 ctx 取消
+	now    func() time.Time
+}
+
+// S3Object ListObjectsV2 返回的对象
+type S3Object struct {
+	Key  string `xml:"Key"`
+	Size int64  `xml:"Size"`
+}
+
+// NewS3Uploader 创建 S3 兼容上传器
+func NewS3Uploader(cfg S3UploaderConfig) (*S3Uploader, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 uploader: endpoint and bucket are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Minute
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	return &S3Uploader{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}, stream: &http.Client{}, now: time.Now}, nil
+}
+
+func (u *S3Uploader) CreateMultipartUpload(ctx context.Context, key string) (string, error) {
+	body, _, err := u.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.Unmarshal(body, &out); err != nil || out.UploadID == "" {
+		return "", fmt.Errorf("s3 create multipart: unexpected response %q", truncateBody(body))
+	}
+	return out.UploadID, nil
+}
+
+func (u *S3Uploader) UploadPart(ctx context.Context, key, uploadID string, partNumber int, data []byte) (string, error) {
+	q := url.Values{"partNumber": {fmt.Sprint(partNumber)}, "uploadId": {uploadID}}
+	_, header, err := u.do(ctx, http.MethodPut, key, q, data)
+	if err != nil {
+		return "", err
+	}
+	etag := header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("s3 upload part %d: missing ETag", partNumber)
+	}
+	return etag, nil
+}
+
+func (u *S3Uploader) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []UploadedPart) error {
+	payload, err := xml.Marshal(struct {
+		XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+		Parts   []UploadedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	body, _, err := u.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, payload)
+	if err != nil {
+		return err
+	}
+	// S3 可能以 200 返回错误体
+	if bytes.Contains(body, []byte("<Error>")) {
+		return fmt.Errorf("s3 complete multipart: %s", truncateBody(body))
+	}
+	return nil
+}
+
+// ListObjects 列出 prefix 下的全部对象（ListObjectsV2，自动翻页，按键字典序返回）
+func (u *S3Uploader) ListObjects(ctx context.Context, prefix string) ([]S3Object, error) {
+	var objects []S3Object
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		body, _, err := u.do(ctx, http.MethodGet, "", q, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Contents              []S3Object `xml:"Contents"`
+			IsTruncated           bool       `xml:"IsTruncated"`
+			NextContinuationToken string     `xml:"NextContinuationToken"`
+		}
+		if err := xml.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("s3 list objects: unexpected response %q", truncateBody(body))
+		}
+		objects = append(objects, page.Contents...)
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// OpenObject 从 offset 开始流式读取对象（offset > 0 时带 Range 头，用于断线续读）
+func (u *S3Uploader) OpenObject(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	req, err := u.newRequest(ctx, http.MethodGet, key, url.Values{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := u.stream.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return nil, fmt.Errorf("s3 GET %s: status %d: %s", key, resp.StatusCode, truncateBody(body))
+	}
+	return resp.Body, nil
+}
+
+// newRequest 构造已签名的请求
+func (u *S3Uploader) newRequest(ctx context.Context, method, key string, query url.Values, payload []byte) (*http.Request, error) {
+	path := "/" + u.cfg.Bucket + "/" + strings.TrimLeft(key, "/")
+	rawQuery := canonicalQuery(query)
+	req, err := http.NewRequestWithContext(ctx, method, u.cfg.Endpoint+uriEncodePath(path)+"?"+rawQuery, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(payload))
+	u.sign(req, path, rawQuery, payload)
+	return req, nil
+}
+
+// do 发送签名请求，非 2xx 返回错误
+func (u *S3Uploader) do(ctx context.Context, method, key string, query url.Values, payload []byte) ([]byte, http.Header, error) {
+	req, err := u.newRequest(ctx, method, key, query, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, nil, fmt.Errorf("s3 %s %s: status %d: %s", method, key, resp.StatusCode, truncateBody(body))
+	}
+	return body, resp.Header, nil
+}
+
+// sign 按 AWS Signature Version 4 为请求签名
+func (u *S3Uploader) sign(req *http.Request, path, rawQuery string, payload []byte) {
+	now := u.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	canonicalRequest := strings.Join([]string{
+		req.Method, uriEncodePath(path), rawQuery, canonicalHeaders, signedHeaders, payloadHash,
+	}, "\n")
+
+	scope := date + "/" + u.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+u.cfg.SecretKey), date)
+	key = hmacSHA256(key, u.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		u.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery 按键排序并以 RFC 3986 编码（SigV4 要求 %20 而非 +）
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func uriEncodePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = uriEncode(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+func uriEncode(s string) string {
+	return strings.NewReplacer("+", "%20", "%7E", "~").Replace(url.QueryEscape(s))
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func truncateBody(b []byte) string {
+	const limit = 256
+	if len(b) > limit {
+		return string(b[:limit]) + "..."
+	}
+	return string(b)
+}
@@ -1,18 +1,19 @@
 package engine
 
 import (
-	"context"
 	"fmt"
 	"log/slog"
 	"sync/atomic"
 	"time"
-
-	"github.com/jmoiron/sqlx"
 )
 
 // Dispatch 发送异步命令（非阻塞）
 func (o *Orchestrator) Dispatch(t MsgType, data interface{}) uint64 {
 	seq := atomic.AddUint64(&o.msgSeq, 1)
+	if task, ok := data.(PersistTask); ok && t == CmdCommitBatch {
+		task.Epoch = o.persistEpoch.Load()
+		data = task
+	}
 	msg := Message{Type: t, Data: data, Sequence: seq}
 
 	select {
@@ -72,6 +73,61 @@ func (o *Orchestrator) UpdateChainHead(height uint64) {
 	o.Dispatch(CmdUpdateChainHeight, height)
 }
 
+// NotifyScheduled 记录调度游标（已成功提交给 Fetcher 的最高块）
+func (o *Orchestrator) NotifyScheduled(height uint64) {
+	o.Dispatch(CmdNotifyScheduled, height)
+}
+
+// ScheduleResumePoint 返回调度循环（重新）启动时应调度的下一个块。
+// 取 startBlock、已落盘游标 +1、进程内调度游标 +1 三者最大值：
+// 进程内重启（监督者）跳过已提交给 Fetcher 的范围；进程重启后调度游标归零，
+// 从已落盘 checkpoint 续跑（内存中已抓取未落盘的块随进程丢失，必须重抓）。
+func (o *Orchestrator) ScheduleResumePoint(startBlock uint64) uint64 {
+	snap := o.GetSnapshot()
+	next := startBlock
+	if snap.SyncedCursor > 0 && snap.SyncedCursor+1 > next {
+		next = snap.SyncedCursor + 1
+	}
+	if snap.ScheduledHeight > 0 && snap.ScheduledHeight+1 > next {
+		next = snap.ScheduledHeight + 1
+	}
+	return next
+}
+
+// ScheduleJobs 汇总调度区间进度（抓取计数来自 Fetcher，落盘进度来自 SyncedCursor）
+func (o *Orchestrator) ScheduleJobs() map[string]interface{} {
+	snap := o.GetSnapshot()
+	o.mu.RLock()
+	fetcher := o.fetcher
+	o.mu.RUnlock()
+
+	jobs := []ScheduleRange{}
+	rangeJobs := []RangeJobProgress{}
+	queueDepth := 0
+	if fetcher != nil {
+		jobs = fetcher.ScheduleRanges(snap.SyncedCursor)
+		rangeJobs = fetcher.RangeJobs()
+		queueDepth = fetcher.QueueDepth()
+	}
+
+	active := 0
+	for _, j := range jobs {
+		if !j.Done {
+			active++
+		}
+	}
+
+	return map[string]interface{}{
+		"jobs":             jobs,
+		"range_jobs":       rangeJobs,
+		"active":           active,
+		"queue_depth":      queueDepth,
+		"latest_scheduled": fmt.Sprintf("%d", snap.ScheduledHeight),
+		"latest_fetched":   fmt.Sprintf("%d", snap.FetchedHeight),
+		"latest_indexed":   fmt.Sprintf("%d", snap.SyncedCursor),
+	}
+}
+
 // AdvanceDBCursor 前进数据库游标
 // 🔥 FINDING-1 修复：通过 Actor 通道路由，复用 CmdCommitDisk 处理逻辑
 func (o *Orchestrator) AdvanceDBCursor(height uint64) {
@@ -96,63 +152,6 @@ func (o *Orchestrator) GetSyncLag() int64 {
 	return lag
 }
 
-// GetStatus 返回全面的 API 响应 Map
-func (o *Orchestrator) GetStatus(ctx context.Context, db *sqlx.DB, rpcPool RPCClient, version string) map[string]interface{} {
-	snap := o.GetSnapshot()
-	syncLag := SafeInt64Diff(snap.LatestHeight, snap.SyncedCursor)
-	if syncLag < 0 {
-		syncLag = 0
-	}
-
-	fetchProgress := 0.0
-	if snap.LatestHeight > 0 {
-		fetchProgress = float64(snap.FetchedHeight) / float64(snap.LatestHeight) * 100
-		if fetchProgress > 100.0 {
-			fetchProgress = 100.0
-		}
-	}
-
-	status := map[string]interface{}{
-		"version":        version,
-		"state":          snap.SystemState.String(),
-		"latest_block":   fmt.Sprintf("%d", snap.LatestHeight),
-		"target_height":  fmt.Sprintf("%d", snap.TargetHeight),
-		"latest_fetched": fmt.Sprintf("%d", snap.FetchedHeight),
-		"fetch_progress": fetchProgress,
-		"safety_buffer":  snap.SafetyBuffer,
-		"latest_indexed": fmt.Sprintf("%d", snap.SyncedCursor),
-		"sync_lag":       syncLag,
-		"transfers":      snap.Transfers,
-		"is_eco_mode":    snap.IsEcoMode,
-		"progress":       snap.Progress,
-		"updated_at":     snap.UpdatedAt.Format(time.RFC3339),
-		"is_healthy":     rpcPool.GetHealthyNodeCount() > 0,
-		"rpc_nodes": map[string]int{
-			"healthy": rpcPool.GetHealthyNodeCount(),
-			"total":   rpcPool.GetTotalNodeCount(),
-		},
-		"jobs_depth":       snap.JobsDepth,
-		"results_depth":    snap.ResultsDepth,
-		"jobs_capacity":    0,
-		"results_capacity": 0,
-		"tps":              GetMetrics().GetWindowTPS(),
-		"bps":              GetMetrics().GetWindowBPS(),
-	}
-
-	if o.fetcher != nil {
-		status["jobs_capacity"] = o.fetcher.JobsCapacity()
-		status["results_capacity"] = o.fetcher.ResultsCapacity()
-	}
-
-	if o.asyncWriter != nil {
-		writerMetrics := o.asyncWriter.GetMetrics()
-		for k, v := range writerMetrics {
-			status["writer_"+k] = v
-		}
-	}
-	return status
-}
-
 // broadcaster 广播快照协程
 func (o *Orchestrator) broadcaster() {
 	ticker := time.NewTicker(500 * time.Millisecond)
@@ -189,6 +188,26 @@ func (o *Orchestrator) RecordUserActivity() {
 	o.Dispatch(CmdRecordUserActivity, nil)
 }
 
+// WakeSignal 返回在下一次退出休眠时关闭的通道（供 EcoPoller 立即恢复轮询频率）
+func (o *Orchestrator) WakeSignal() <-chan struct{} {
+	o.wakeMu.Lock()
+	defer o.wakeMu.Unlock()
+	if o.wakeCh == nil {
+		o.wakeCh = make(chan struct{})
+	}
+	return o.wakeCh
+}
+
+// signalWake 唤醒所有等待中的轮询者
+func (o *Orchestrator) signalWake() {
+	o.wakeMu.Lock()
+	defer o.wakeMu.Unlock()
+	if o.wakeCh != nil {
+		close(o.wakeCh)
+		o.wakeCh = nil
+	}
+}
+
 func (o *Orchestrator) DispatchLog(level string, message string, args ...interface{}) {
 	data := map[string]interface{}{
 		"level":   level,
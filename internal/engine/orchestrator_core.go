@@ -2,9 +2,7 @@ package engine
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
-	"math/big"
 	"sync"
 	"time"
 
@@ -56,29 +54,21 @@ func (o *Orchestrator) Init(_ context.Context, fetcher *Fetcher, strategy Strate
 	slog.Info("🎼 Orchestrator initialized", "strategy", strategy.Name(), "safety_buffer", o.state.SafetyBuffer)
 }
 
-// LoadInitialState 从数据库加载初始状态
+// LoadInitialState 从数据库加载初始状态：复用本次启动已有的对账结果，否则执行一次对账
 func (o *Orchestrator) LoadInitialState(db *sqlx.DB, chainID int64) error {
-	var lastSyncedBlock string
-	err := db.GetContext(context.Background(), &lastSyncedBlock, "SELECT last_synced_block FROM sync_checkpoints WHERE chain_id = $1", chainID)
-
-	// 🚀 增强逻辑：如果 checkpoint 没找到，尝试从 blocks 表直接获取最大值
-	if err != nil || lastSyncedBlock == "" || lastSyncedBlock == "0" {
-		var maxInDB int64
-		err = db.GetContext(context.Background(), &maxInDB, "SELECT COALESCE(MAX(number), 0) FROM blocks")
-		if err == nil && maxInDB > 0 {
-			lastSyncedBlock = fmt.Sprintf("%d", maxInDB)
+	if a := o.CheckpointAudit(); a != nil && a.ChainID == chainID {
+		o.mu.Lock()
+		if a.Resolved >= 0 {
+			o.state.SyncedCursor = uint64(a.Resolved) // #nosec G115 - Resolved >= 0
 		}
+		o.snapshot = o.state
+		o.mu.Unlock()
+		slog.Info("🎼 Orchestrator: Initial state aligned from audit", "cursor", a.Resolved, "verdict", a.Verdict)
+		return nil
 	}
 
-	if lastSyncedBlock != "" && lastSyncedBlock != "0" {
-		height, ok := new(big.Int).SetString(lastSyncedBlock, 10)
-		if ok {
-			o.mu.Lock()
-			o.state.SyncedCursor = height.Uint64()
-			o.snapshot = o.state
-			o.mu.Unlock()
-			slog.Info("🎼 Orchestrator: Initial state aligned from DB", "cursor", lastSyncedBlock)
-		}
+	if _, err := o.AuditCheckpoints(context.Background(), db, chainID); err != nil {
+		slog.Warn("🎼 Orchestrator: checkpoint audit failed, cursor unchanged", "err", err)
 	}
 	return nil
 }
@@ -121,6 +111,7 @@ func (o *Orchestrator) SnapToReality(rpcHeight uint64) {
 		slog.Warn("🎼 Orchestrator: Ghost state detected! Snapping to reality", "ghost", o.state.LatestHeight, "real", rpcHeight)
 		o.state.LatestHeight = rpcHeight
 		o.state.FetchedHeight = rpcHeight
+		o.state.ScheduledHeight = rpcHeight
 		o.state.SyncedCursor = rpcHeight
 		o.state.TargetHeight = rpcHeight
 		o.snapshot = o.state
@@ -135,10 +126,11 @@ func (o *Orchestrator) ForceSetCursors(height uint64) {
 	slog.Warn("🎼 Orchestrator: Force setting cursors", "new_height", height)
 	o.state.LatestHeight = height
 	o.state.FetchedHeight = height
+	o.state.ScheduledHeight = height
 	o.state.SyncedCursor = height
 	o.state.TargetHeight = height
 	o.snapshot = o.state
-	
+
 	// 如果配置了 Fetcher，也必须清空任务队列并重置
 	if o.fetcher != nil {
 		o.fetcher.ClearJobs()
@@ -151,6 +143,7 @@ func (o *Orchestrator) ResetToZero() {
 	defer o.mu.Unlock()
 	o.state.SyncedCursor = 0
 	o.state.FetchedHeight = 0
+	o.state.ScheduledHeight = 0
 	o.state.LatestHeight = 0
 	o.state.TargetHeight = 0
 	o.snapshot = o.state
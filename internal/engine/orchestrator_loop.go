@@ -66,7 +66,7 @@ func (o *Orchestrator) process(msg Message) {
 		o.handleLogEvent(msg.Data)
 
 	case CmdCommitBatch:
-		o.handleCommitBatch(msg.Data)
+		o.handleCommitBatch(msg.Data, msg.Sequence)
 
 	case CmdCommitDisk:
 		o.handleCommitDisk(msg.Data)
@@ -88,6 +88,13 @@ func (o *Orchestrator) process(msg Message) {
 
 	case CmdRecordUserActivity:
 		o.state.LastUserActivity = time.Now()
+		// 用户活动立即退出休眠，不等下一次决策周期
+		if o.state.IsEcoMode {
+			o.evaluateEcoMode()
+		}
+
+	case CmdNotifyScheduled:
+		o.handleNotifyScheduled(msg.Data)
 	}
 
 	o.updateProgressAndSnapshot()
@@ -108,11 +115,21 @@ func (o *Orchestrator) handleUpdateChainHeight(data interface{}) {
 	if ok && h > o.state.LatestHeight {
 		o.pendingHeightUpdate = &h
 		o.lastHeightMergeTime = time.Now()
-		if h > o.state.SafetyBuffer {
-			o.state.TargetHeight = h - o.state.SafetyBuffer
-		} else {
-			o.state.TargetHeight = 0
-		}
+		o.recomputeTargetHeight()
+	}
+}
+
+// recomputeTargetHeight TargetHeight = 最新链高（含待合并的更新）- SafetyBuffer；
+// Fetcher 的前向调度以此为上限，因此安全垫变化时必须同步重算
+func (o *Orchestrator) recomputeTargetHeight() {
+	h := o.state.LatestHeight
+	if o.pendingHeightUpdate != nil && *o.pendingHeightUpdate > h {
+		h = *o.pendingHeightUpdate
+	}
+	if h > o.state.SafetyBuffer {
+		o.state.TargetHeight = h - o.state.SafetyBuffer
+	} else {
+		o.state.TargetHeight = 0
 	}
 }
 
@@ -122,6 +139,7 @@ func (o *Orchestrator) handleFetchFailed(data interface{}) {
 		o.state.SuccessCount = 0
 		if o.state.SafetyBuffer < 20 {
 			o.state.SafetyBuffer++
+			o.recomputeTargetHeight()
 		}
 	}
 }
@@ -131,6 +149,7 @@ func (o *Orchestrator) handleFetchSuccess() {
 	if o.state.SuccessCount >= 50 && o.state.SafetyBuffer > 1 {
 		o.state.SafetyBuffer--
 		o.state.SuccessCount = 0
+		o.recomputeTargetHeight()
 	}
 }
 
@@ -141,19 +160,29 @@ func (o *Orchestrator) handleNotifyFetch(data interface{}) {
 	}
 }
 
+func (o *Orchestrator) handleNotifyScheduled(data interface{}) {
+	h, ok := data.(uint64)
+	if ok && h > o.state.ScheduledHeight {
+		o.state.ScheduledHeight = h
+	}
+}
+
 func (o *Orchestrator) handleLogEvent(data interface{}) {
 	logData, ok := data.(map[string]interface{})
 	if ok {
 		o.state.LogEntry = logData
 		o.state.UpdatedAt = time.Now()
+		GetLogStream().Append(logEventFromDispatch(logData))
 	}
 }
 
-func (o *Orchestrator) handleCommitBatch(data interface{}) {
+func (o *Orchestrator) handleCommitBatch(data interface{}, seq uint64) {
 	task, ok := data.(PersistTask)
 	if ok && o.asyncWriter != nil {
+		task.Sequence = seq
 		if err := o.asyncWriter.Enqueue(task); err != nil {
-			slog.Error("🎼 Orchestrator: Failed to enqueue persist task", "err", err, "height", task.Height)
+			slog.Error("🎼 Orchestrator: Failed to enqueue persist task", "err", err, "height", task.Height, "trace_id", task.TraceID, "seq", seq)
+			pipelineTrace.record(task.TraceID, task.Height, TraceStageFailed, seq, "enqueue: "+err.Error())
 		}
 	}
 }
@@ -208,8 +237,13 @@ func (o *Orchestrator) updateProgressAndSnapshot() {
 	}
 	o.state.UpdatedAt = time.Now()
 	o.mu.Lock()
+	woke := o.snapshot.IsEcoMode && !o.state.IsEcoMode
 	o.snapshot = o.state
 	o.mu.Unlock()
+	// 快照更新后再唤醒，被唤醒的轮询者读到的一定是活跃状态
+	if woke {
+		o.signalWake()
+	}
 	select {
 	case o.broadcastCh <- o.snapshot:
 	default:
@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrchestrator_ScheduledCursorMonotonic(t *testing.T) {
+	o := &Orchestrator{}
+	o.handleNotifyScheduled(uint64(120))
+	o.handleNotifyScheduled(uint64(80))
+	assert.Equal(t, uint64(120), o.state.ScheduledHeight)
+}
+
+func TestOrchestrator_ScheduleResumePoint(t *testing.T) {
+	o := &Orchestrator{}
+
+	// 冷启动：无进程内状态，使用 startBlock
+	assert.Equal(t, uint64(100), o.ScheduleResumePoint(100))
+
+	// 仅有落盘游标：从 checkpoint+1 续跑
+	o.RestoreState(CoordinatorState{SyncedCursor: 150})
+	assert.Equal(t, uint64(151), o.ScheduleResumePoint(100))
+
+	// 调度游标领先于落盘游标：跳过已提交给 Fetcher 的范围
+	o.RestoreState(CoordinatorState{SyncedCursor: 150, ScheduledHeight: 210})
+	assert.Equal(t, uint64(211), o.ScheduleResumePoint(100))
+}
+
+func TestOrchestrator_TargetHeightFollowsSafetyBuffer(t *testing.T) {
+	o := &Orchestrator{}
+	o.state.SafetyBuffer = 1
+	o.handleUpdateChainHeight(uint64(100))
+	assert.Equal(t, uint64(99), o.state.TargetHeight)
+
+	// 链尖 404 调大安全垫：尚未合并的链高也计入，目标高度立即回落
+	o.handleFetchFailed("not_found")
+	o.handleFetchFailed("not_found")
+	assert.Equal(t, uint64(3), o.state.SafetyBuffer)
+	assert.Equal(t, uint64(97), o.state.TargetHeight)
+
+	// 连续成功后安全垫收缩，目标高度随之前移
+	o.flushPendingHeightUpdate()
+	for i := 0; i < 50; i++ {
+		o.handleFetchSuccess()
+	}
+	assert.Equal(t, uint64(2), o.state.SafetyBuffer)
+	assert.Equal(t, uint64(98), o.state.TargetHeight)
+}
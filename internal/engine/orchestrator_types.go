@@ -3,6 +3,7 @@ package engine
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -39,6 +40,8 @@ func (t MsgType) String() string {
 		return "GetStatus"
 	case ReqGetSnapshot:
 		return "GetSnapshot"
+	case CmdNotifyScheduled:
+		return "NotifyScheduled"
 	default:
 		return "Unknown"
 	}
@@ -60,6 +63,7 @@ const (
 	ReqGetStatus                          // UI 查询状态 (REQ/REP)
 	ReqGetSnapshot                        // 获取状态快照 (REQ/REP)
 	CmdRecordUserActivity                 // 用户活动记录
+	CmdNotifyScheduled                    // 📅 调度游标推进 (已提交给 Fetcher 的最高块)
 )
 
 // Message ZeroMQ 风格的消息结构
@@ -76,6 +80,7 @@ type CoordinatorState struct {
 	LatestHeight     uint64  // 链上最新高度
 	TargetHeight     uint64  // 🎯 考虑安全垫后的目标高度
 	FetchedHeight    uint64  // 🚀 🔥 新增：内存同步高度 (Fetcher 进度)
+	ScheduledHeight  uint64  // 📅 调度游标：已提交给 Fetcher 的最高块
 	SyncedCursor     uint64  // 数据库游标（已索引）
 	Transfers        uint64  // 总转账数
 	IsEcoMode        bool    // 是否处于休眠模式
@@ -117,9 +122,18 @@ type Orchestrator struct {
 	lastHeightMergeTime time.Time
 
 	// 🔥 异步持久化流水线
-	asyncWriter *AsyncWriter // 异步写入器引用
+	asyncWriter  *AsyncWriter  // 异步写入器引用
+	persistEpoch atomic.Uint64 // 落盘纪元：分发 CmdCommitBatch 时写入 PersistTask，链重置时递增
 
 	// 🔥 组件引用 (用于监控)
 	fetcher  *Fetcher
 	strategy Strategy // 🚀 🔥 新增：运行策略 (Anvil vs Testnet)
+
+	// 🧾 启动对账（checkpoint / MAX(blocks) / 游标）
+	auditMode string
+	lastAudit *CheckpointAudit
+
+	// 💤 退出休眠的唤醒信号（关闭即广播，随后换新通道）
+	wakeMu sync.Mutex
+	wakeCh chan struct{}
 }
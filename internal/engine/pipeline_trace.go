@@ -0,0 +1,205 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// traceRingSize 追踪事件环形缓冲容量（每块约 4~6 个事件，足够覆盖最近上千个区块）
+	traceRingSize = 8192
+	// maxTraceAttempts 保留尝试计数的区块数上限，超出后淘汰较旧区块
+	maxTraceAttempts = 20000
+)
+
+// 流水线阶段
+const (
+	TraceStageFetched     = "fetched"      // Fetcher 已发往 Sequencer
+	TraceStageFetchFailed = "fetch_failed" // Fetcher 抓取失败
+	TraceStageBuffered    = "buffered"     // Sequencer 乱序暂存
+	TraceStageSequenced   = "sequenced"    // Sequencer 按序交给 Processor
+	TraceStageReorg       = "reorg"        // Sequencer 检测到 reorg，等待重抓
+	TraceStageDispatched  = "dispatched"   // Processor 通过 Orchestrator 分发落盘任务
+	TraceStageCommitted   = "committed"    // AsyncWriter 事务已提交
+	TraceStageFailed      = "failed"       // 入队/落盘失败
+)
+
+// TraceEvent 单个区块在流水线某一阶段的记录
+type TraceEvent struct {
+	TraceID string    `json:"trace_id"`
+	Block   uint64    `json:"block"`
+	Attempt uint32    `json:"attempt"`
+	Stage   string    `json:"stage"`
+	Seq     uint64    `json:"seq,omitempty"` // Orchestrator 消息序列号
+	Detail  string    `json:"detail,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// TraceJourney 同一 trace id 下的完整链路
+type TraceJourney struct {
+	TraceID    string       `json:"trace_id"`
+	Attempt    uint32       `json:"attempt"`
+	Events     []TraceEvent `json:"events"`
+	Committed  bool         `json:"committed"`
+	DurationMs int64        `json:"duration_ms"`
+}
+
+// pipelineTracer 以环形缓冲记录 Fetcher→Sequencer→Processor→AsyncWriter 的区块流转
+type pipelineTracer struct {
+	mu       sync.Mutex
+	ring     []TraceEvent
+	next     int
+	full     bool
+	attempts map[uint64]uint32
+	maxBlock uint64
+}
+
+func newPipelineTracer(size int) *pipelineTracer {
+	return &pipelineTracer{
+		ring:     make([]TraceEvent, size),
+		attempts: make(map[uint64]uint32),
+	}
+}
+
+var pipelineTrace = newPipelineTracer(traceRingSize)
+
+// begin 为区块分配新的 trace id（block-attempt），每次重新抓取 attempt 递增
+func (t *pipelineTracer) begin(block uint64) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.attempts[block]++
+	if block > t.maxBlock {
+		t.maxBlock = block
+	}
+	if len(t.attempts) > maxTraceAttempts && t.maxBlock > maxTraceAttempts/2 {
+		floor := t.maxBlock - maxTraceAttempts/2
+		for b := range t.attempts {
+			if b < floor {
+				delete(t.attempts, b)
+			}
+		}
+	}
+	return formatTraceID(block, t.attempts[block])
+}
+
+func formatTraceID(block uint64, attempt uint32) string {
+	return fmt.Sprintf("%d-%d", block, attempt)
+}
+
+func parseTraceAttempt(traceID string) uint32 {
+	var block uint64
+	var attempt uint32
+	if _, err := fmt.Sscanf(traceID, "%d-%d", &block, &attempt); err != nil {
+		return 0
+	}
+	return attempt
+}
+
+// record 写入一条追踪事件，同时以 trace_id 输出 Debug 日志便于按 id 检索
+func (t *pipelineTracer) record(traceID string, block uint64, stage string, seq uint64, detail string) {
+	if traceID == "" {
+		return
+	}
+	ev := TraceEvent{
+		TraceID: traceID,
+		Block:   block,
+		Attempt: parseTraceAttempt(traceID),
+		Stage:   stage,
+		Seq:     seq,
+		Detail:  detail,
+		At:      time.Now(),
+	}
+
+	t.mu.Lock()
+	t.ring[t.next] = ev
+	t.next = (t.next + 1) % len(t.ring)
+	if t.next == 0 {
+		t.full = true
+	}
+	t.mu.Unlock()
+
+	Logger.Debug("pipeline_trace",
+		slog.String("trace_id", traceID),
+		slog.String("stage", stage),
+		slog.Uint64("block", block),
+		slog.Uint64("seq", seq),
+		slog.String("detail", detail))
+}
+
+// journeys 从环形缓冲中重建指定区块的链路（按 attempt 升序）
+func (t *pipelineTracer) journeys(block uint64) []TraceJourney {
+	t.mu.Lock()
+	n := t.next
+	if t.full {
+		n = len(t.ring)
+	}
+	var events []TraceEvent
+	for i := 0; i < n; i++ {
+		if ev := t.ring[i]; ev.Block == block && ev.TraceID != "" {
+			events = append(events, ev)
+		}
+	}
+	t.mu.Unlock()
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At.Before(events[j].At) })
+
+	byID := make(map[string]*TraceJourney)
+	var order []string
+	for _, ev := range events {
+		j, ok := byID[ev.TraceID]
+		if !ok {
+			j = &TraceJourney{TraceID: ev.TraceID, Attempt: ev.Attempt}
+			byID[ev.TraceID] = j
+			order = append(order, ev.TraceID)
+		}
+		j.Events = append(j.Events, ev)
+		if ev.Stage == TraceStageCommitted {
+			j.Committed = true
+		}
+	}
+
+	out := make([]TraceJourney, 0, len(order))
+	for _, id := range order {
+		j := byID[id]
+		j.DurationMs = j.Events[len(j.Events)-1].At.Sub(j.Events[0].At).Milliseconds()
+		out = append(out, *j)
+	}
+	sort.SliceStable(out, func(a, b int) bool { return out[a].Attempt < out[b].Attempt })
+	return out
+}
+
+// ensureTraceID 为未经 Fetcher 的数据（回放/模拟源）补齐 trace id
+func ensureTraceID(data *BlockData) {
+	if data.TraceID != "" {
+		return
+	}
+	if n := blockNumberOf(*data); n != nil && n.Sign() >= 0 {
+		data.TraceID = pipelineTrace.begin(n.Uint64())
+	}
+}
+
+func blockNumberOf(data BlockData) *big.Int {
+	if data.Number != nil {
+		return data.Number
+	}
+	if data.Block != nil {
+		return data.Block.Number()
+	}
+	return nil
+}
+
+// traceBlockData 记录 BlockData 所处阶段
+func traceBlockData(data BlockData, stage, detail string) {
+	if n := blockNumberOf(data); n != nil && n.Sign() >= 0 {
+		pipelineTrace.record(data.TraceID, n.Uint64(), stage, 0, detail)
+	}
+}
+
+// BlockTrace 返回指定区块在流水线中的链路（来自最近的环形缓冲，较早区块可能已被覆盖）
+func BlockTrace(block uint64) []TraceJourney {
+	return pipelineTrace.journeys(block)
+}
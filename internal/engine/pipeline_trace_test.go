@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineTracerReconstructsJourney(t *testing.T) {
+	InitLogger("error")
+	tr := newPipelineTracer(64)
+
+	first := tr.begin(100)
+	assert.Equal(t, "100-1", first)
+	tr.record(first, 100, TraceStageFetched, 0, "")
+	tr.record(first, 100, TraceStageReorg, 0, "")
+
+	retry := tr.begin(100)
+	assert.Equal(t, "100-2", retry)
+	tr.record(retry, 100, TraceStageFetched, 0, "")
+	tr.record(retry, 100, TraceStageSequenced, 0, "")
+	tr.record(retry, 100, TraceStageDispatched, 42, "")
+	tr.record(retry, 100, TraceStageCommitted, 42, "")
+	tr.record(tr.begin(101), 101, TraceStageFetched, 0, "")
+
+	journeys := tr.journeys(100)
+	assert.Len(t, journeys, 2)
+	assert.Equal(t, uint32(1), journeys[0].Attempt)
+	assert.False(t, journeys[0].Committed)
+	assert.Equal(t, "100-2", journeys[1].TraceID)
+	assert.True(t, journeys[1].Committed)
+	assert.Len(t, journeys[1].Events, 4)
+	assert.Equal(t, uint64(42), journeys[1].Events[3].Seq)
+}
+
+func TestPipelineTracerRingEvicts(t *testing.T) {
+	InitLogger("error")
+	tr := newPipelineTracer(4)
+
+	tr.record(tr.begin(1), 1, TraceStageFetched, 0, "")
+	for b := uint64(2); b <= 5; b++ {
+		tr.record(tr.begin(b), b, TraceStageFetched, 0, "")
+	}
+
+	assert.Empty(t, tr.journeys(1))
+	assert.Len(t, tr.journeys(5), 1)
+}
+
+func TestEnsureTraceIDKeepsExisting(t *testing.T) {
+	data := BlockData{TraceID: "7-3"}
+	ensureTraceID(&data)
+	assert.Equal(t, "7-3", data.TraceID)
+	assert.Equal(t, uint32(3), parseTraceAttempt(data.TraceID))
+}
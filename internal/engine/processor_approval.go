@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"math/big"
+	"strings"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// UnlimitedAllowance 达到该额度（2^255）的 ERC-20 授权视为无限授权：
+// 钱包通常授权 type(uint256).max，部分代币在每次 transferFrom 后递减，额度仍远高于该阈值
+var UnlimitedAllowance = new(big.Int).Lsh(big.NewInt(1), 255)
+
+// IsUnlimitedApproval ApprovalForAll 总是覆盖整个合集；ERC-20 额度达到 UnlimitedAllowance 时为无限授权
+func IsUnlimitedApproval(kind, amount string) bool {
+	switch kind {
+	case storage.ApprovalKindForAll:
+		return true
+	case storage.ApprovalKindERC20:
+		v, ok := new(big.Int).SetString(amount, 10)
+		return ok && v.Cmp(UnlimitedAllowance) >= 0
+	}
+	return false
+}
+
+// extractApprovals 从区块日志提取 Approval / ApprovalForAll 事件（经过与转账相同的代币过滤与垃圾静音）并按类型计数
+func (p *Processor) extractApprovals(logs []types.Log) []storage.ApprovalRow {
+	var rows []storage.ApprovalRow
+	for _, vLog := range logs {
+		row, ok := approvalRow(vLog)
+		if !ok {
+			continue
+		}
+		if allowed, _ := p.tokenFilter.Allows(vLog.Address); !allowed || p.spam.IsMuted(vLog.Address) {
+			continue
+		}
+		rows = append(rows, row)
+		if p.metrics != nil && p.metrics.Approvals != nil {
+			p.metrics.Approvals.WithLabelValues(row.Kind).Inc()
+		}
+	}
+	return rows
+}
+
+// approvalRow 把授权日志转换为 approvals 行；布局不符（topic 数或 data 长度不对）的日志返回 false。
+// ERC-20 与 ERC-721 共用 Approval 的 topic0，区别同 Transfer：ERC-721 的 tokenId 为第三个 indexed 参数
+func approvalRow(vLog types.Log) (storage.ApprovalRow, bool) {
+	if len(vLog.Topics) < 3 {
+		return storage.ApprovalRow{}, false
+	}
+	row := storage.ApprovalRow{
+		BlockNumber:  models.Height(vLog.BlockNumber),
+		LogIndex:     vLog.Index,
+		TxHash:       vLog.TxHash.Hex(),
+		TokenAddress: strings.ToLower(vLog.Address.Hex()),
+		Owner:        strings.ToLower(common.BytesToAddress(vLog.Topics[1].Bytes()).Hex()),
+		Spender:      strings.ToLower(common.BytesToAddress(vLog.Topics[2].Bytes()).Hex()),
+	}
+
+	switch {
+	case vLog.Topics[0] == ApprovalEventHash && len(vLog.Topics) == 3 && len(vLog.Data) == 32:
+		amount := new(big.Int).SetBytes(vLog.Data)
+		row.Kind = storage.ApprovalKindERC20
+		row.Amount = amount.String()
+		row.Approved = amount.Sign() > 0
+	case vLog.Topics[0] == ApprovalEventHash && len(vLog.Topics) == 4 && len(vLog.Data) == 0:
+		row.Kind = storage.ApprovalKindERC721
+		row.TokenID = vLog.Topics[3].Big().String()
+		row.Approved = common.BytesToAddress(vLog.Topics[2].Bytes()) != (common.Address{})
+	case vLog.Topics[0] == ApprovalForAllEventHash && len(vLog.Topics) == 3 && len(vLog.Data) == 32:
+		row.Kind = storage.ApprovalKindForAll
+		row.Approved = new(big.Int).SetBytes(vLog.Data).Sign() != 0
+	default:
+		return storage.ApprovalRow{}, false
+	}
+	return row, true
+}
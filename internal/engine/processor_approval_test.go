@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"web3-indexer-go/internal/storage"
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractApprovals(t *testing.T) {
+	block := testkit.Block(42, common.Hash{})
+	collection := testkit.Address("collection", 1)
+	owner, spender := testkit.Address("owner", 1), testkit.Address("spender", 1)
+	lower := func(a common.Address) string { return strings.ToLower(a.Hex()) }
+
+	logs := []types.Log{
+		testkit.InBlock(testkit.ERC20Transfer(testkit.USDC, owner, spender, big.NewInt(5)), block, 0),
+		testkit.InBlock(testkit.ERC20Approval(testkit.USDC, owner, spender, big.NewInt(1000)), block, 1),
+		testkit.InBlock(testkit.ERC20Approval(testkit.USDC, owner, spender, big.NewInt(0)), block, 2),
+		testkit.InBlock(testkit.ERC721Approval(collection, owner, spender, big.NewInt(7)), block, 3),
+		testkit.InBlock(testkit.ERC721Approval(collection, owner, common.Address{}, big.NewInt(7)), block, 4),
+		testkit.InBlock(testkit.ApprovalForAll(collection, owner, spender, true), block, 5),
+	}
+
+	p := &Processor{metrics: GetMetrics(), chainID: 1}
+	rows := p.extractApprovals(logs)
+	require.Len(t, rows, 5, "transfers are not approvals")
+
+	assert.Equal(t, storage.ApprovalRow{
+		BlockNumber:  42,
+		LogIndex:     1,
+		TxHash:       logs[1].TxHash.Hex(),
+		TokenAddress: lower(testkit.USDC),
+		Kind:         storage.ApprovalKindERC20,
+		Owner:        lower(owner),
+		Spender:      lower(spender),
+		Amount:       "1000",
+		Approved:     true,
+	}, rows[0])
+	assert.False(t, rows[1].Approved, "a zero allowance revokes")
+
+	assert.Equal(t, storage.ApprovalKindERC721, rows[2].Kind)
+	assert.Equal(t, "7", rows[2].TokenID)
+	assert.Empty(t, rows[2].Amount)
+	assert.True(t, rows[2].Approved)
+	assert.False(t, rows[3].Approved, "approving the zero address clears the token approval")
+
+	assert.Equal(t, storage.ApprovalKindForAll, rows[4].Kind)
+	assert.True(t, rows[4].Approved)
+
+	p.tokenFilter = NewTokenFilter(nil, []string{collection.Hex()})
+	assert.Len(t, p.extractApprovals(logs), 2, "denied collections are skipped")
+}
+
+func TestApprovalRow_RejectsMalformedLayouts(t *testing.T) {
+	owner, spender := testkit.Address("owner", 1), testkit.Address("spender", 1)
+
+	noData := testkit.ERC20Approval(testkit.USDC, owner, spender, big.NewInt(1))
+	noData.Data = nil
+	_, ok := approvalRow(noData)
+	assert.False(t, ok)
+
+	revoked := testkit.ApprovalForAll(testkit.USDC, owner, spender, false)
+	row, ok := approvalRow(revoked)
+	require.True(t, ok)
+	assert.False(t, row.Approved)
+
+	revoked.Topics = revoked.Topics[:2]
+	_, ok = approvalRow(revoked)
+	assert.False(t, ok)
+}
+
+func TestIsUnlimitedApproval(t *testing.T) {
+	maxU256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	decremented := new(big.Int).Sub(maxU256, big.NewInt(1_000_000))
+
+	assert.True(t, IsUnlimitedApproval(storage.ApprovalKindERC20, maxU256.String()))
+	assert.True(t, IsUnlimitedApproval(storage.ApprovalKindERC20, decremented.String()), "allowances spent down from max stay unlimited")
+	assert.False(t, IsUnlimitedApproval(storage.ApprovalKindERC20, "1000000"))
+	assert.False(t, IsUnlimitedApproval(storage.ApprovalKindERC20, ""))
+	assert.True(t, IsUnlimitedApproval(storage.ApprovalKindForAll, ""))
+	assert.False(t, IsUnlimitedApproval(storage.ApprovalKindERC721, ""), "single-token approvals are bounded")
+}
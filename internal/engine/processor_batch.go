@@ -2,11 +2,11 @@ package engine
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"math"
 	"math/big"
 	"strings"
-	"time"
 	"web3-indexer-go/internal/models"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -31,51 +31,47 @@ func (p *Processor) ProcessBatch(ctx context.Context, blocks []BlockData, chainI
 
 		// 1. 提取活动 (内存提取)
 		txWithRealLogs := make(map[string]bool)
-		activities := []models.Transfer{}
-
-		// 提取 Logs
-		for _, vLog := range data.Logs {
-			activity := p.ProcessLog(vLog)
-			if activity != nil {
-				activities = append(activities, *activity)
-				txWithRealLogs[activity.TxHash] = true
-			}
+
+		// 提取 Logs（先跑垃圾代币启发式，本块即命中的代币不再落盘）
+		p.spam.ScanBlock(data.Logs)
+		activities := p.decodeLogs(data.Logs)
+		for _, activity := range activities {
+			txWithRealLogs[activity.TxHash] = true
 		}
 
 		// 提取 Transactions (Deploy, ETH transfer, etc.)
 		p.processBatchTransactions(block, chainID, txWithRealLogs, &activities)
 
 		// Anvil 模拟数据
-		p.processBatchSynthetic(block, chainID, &activities)
+		p.processBatchSynthetic(block, &activities)
+		activities = append(activities, p.takeSynthesized(block)...)
+		warmActivityAddresses(activities)
 
 		// 2. 构建 PersistTask
-		var baseFee *models.BigInt
-		if block.BaseFee() != nil {
-			baseFee = &models.BigInt{Int: block.BaseFee()}
-		}
-		mBlock := models.Block{
-			Number:           models.BigInt{Int: block.Number()},
-			Hash:             block.Hash().Hex(),
-			ParentHash:       block.ParentHash().Hex(),
-			Timestamp:        block.Time(),
-			GasLimit:         block.GasLimit(),
-			GasUsed:          block.GasUsed(),
-			BaseFeePerGas:    baseFee,
-			TransactionCount: len(block.Transactions()),
-		}
+		mBlock := newBlockModel(block)
 
 		task := PersistTask{
 			Height:    blockNum.Uint64(),
 			Block:     mBlock,
 			Transfers: activities,
-			Sequence:  uint64(time.Now().UnixNano()) & uint64(math.MaxInt64),
+			NFTs:      p.extractNFTTransfers(data.Logs),
+			Approvals: p.extractApprovals(data.Logs),
+			Events:    p.extractContractEvents(data.Logs),
+			Blobs:     buildBlobTxRows(block),
+			Fees:      buildBlockFeeRow(block, data.Receipts),
+			TraceID:   data.TraceID,
 		}
 
 		// 3. 核心分发 (SSOT)
-		GetOrchestrator().Dispatch(CmdCommitBatch, task)
+		seq := GetOrchestrator().Dispatch(CmdCommitBatch, task)
+		pipelineTrace.record(task.TraceID, task.Height, TraceStageDispatched, seq, fmt.Sprintf("transfers=%d", len(activities)))
 
 		// 4. 事件推送 (UI 即时响应)
 		p.pushEvents(block, activities, nil)
+		p.pushNFTEvents(task.NFTs)
+		GetMempool().ObserveBlock(block) // 对账待打包视图：已上链的交易移出
+		p.recordBlobUsage(block, task.Blobs)
+		p.recordFeeStats(block, task.Fees)
 	}
 
 	p.updateBatchMetrics(blocks)
@@ -94,6 +90,9 @@ func (p *Processor) processBatchTransactions(block *types.Block, chainID int64,
 		}
 
 		if tx.To() == nil {
+			if err == nil {
+				refreshDeployedAddress(msg, tx.Nonce())
+			}
 			*validTransfers = append(*validTransfers, models.Transfer{
 				BlockNumber:  models.BigInt{Int: blockNum},
 				TxHash:       tx.Hash().Hex(),
@@ -118,7 +117,7 @@ func (p *Processor) processBatchTransactions(block *types.Block, chainID int64,
 				To:           strings.ToLower(tx.To().Hex()),
 				Amount:       models.NewUint256FromBigInt(tx.Value()),
 				TokenAddress: "0x0000000000000000000000000000000000000000",
-				Symbol:       "ETH",
+				Symbol:       GetChainProfile(chainID).NativeSymbol,
 				Type:         "ETH_TRANSFER",
 			})
 			syntheticIdx++
@@ -126,17 +125,20 @@ func (p *Processor) processBatchTransactions(block *types.Block, chainID int64,
 	}
 }
 
-func (p *Processor) processBatchSynthetic(block *types.Block, chainID int64, validTransfers *[]models.Transfer) {
+func (p *Processor) processBatchSynthetic(block *types.Block, validTransfers *[]models.Transfer) {
 	blockNum := block.Number()
 	transfersBeforeThisBlock := len(*validTransfers)
-	if p.enableSimulator && p.networkMode == networkAnvil {
+	if !p.syntheticFallback {
+		return
+	}
+	if p.networkMode == networkAnvil {
 		Logger.Info("🔍 [ANVIL-BATCH] Checking if synthetic transfer needed",
 			slog.String("block", blockNum.String()),
 			slog.Int("existing_transfers", transfersBeforeThisBlock),
 		)
 	}
 
-	if transfersBeforeThisBlock == 0 && chainID == 31337 && p.enableSimulator {
+	if transfersBeforeThisBlock == 0 {
 		mockTokens := []struct {
 			addr   common.Address
 			symbol string
@@ -190,8 +192,10 @@ func (p *Processor) processBatchSynthetic(block *types.Block, chainID int64, val
 			TokenAddress: strings.ToLower(selectedToken.addr.Hex()),
 			Symbol:       selectedToken.symbol,
 			Type:         selectedType,
+			Synthesized:  true,
 		}
 		*validTransfers = append(*validTransfers, anvilTransfer)
+		p.metrics.RecordSyntheticFallbackRows(1)
 
 		Logger.Info("🏭 [ANVIL-BATCH] Synthetic Transfer generated",
 			slog.String("block", blockNum.String()),
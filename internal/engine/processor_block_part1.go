@@ -12,6 +12,7 @@ package engine
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"math"
 	"math/big"
 	"sort"
@@ -28,6 +29,9 @@ func (p *Processor) ProcessBlock(ctx context.Context, data BlockData) error {
 	if data.Err != nil {
 		return fmt.Errorf("fetch error: %w", data.Err)
 	}
+	if data.Block == nil {
+		return fmt.Errorf("block %s not fetched", data.Number)
+	}
 
 	block := data.Block
 	blockNum := block.Number()
@@ -40,45 +44,56 @@ func (p *Processor) ProcessBlock(ctx context.Context, data BlockData) error {
 
 	// 2. 🔥 逻辑转换：提取所有活动 (不写库)
 	activities := p.extractActivities(ctx, blockNum, data.Logs, block.Transactions())
+	activities = dropFailedTxActivities(blockNum, activities, data.Receipts)
+	activities = append(activities, p.extractInternalTransfers(blockNum, data.Traces)...)
 
 	// 🚀 模拟模式：强制生成 Synthetic Transfer（让空链也有数据）
 	activities = p.processAnvilSyntheticNoDB(ctx, blockNum, block, activities)
+	activities = append(activities, p.takeSynthesized(block)...)
+	warmActivityAddresses(activities)
 
 	// 3. 🔥 物理准备：构建 PersistTask
-	var baseFee *models.BigInt
-	if block.BaseFee() != nil {
-		baseFee = &models.BigInt{Int: block.BaseFee()}
-	}
-	mBlock := models.Block{
-		Number:           models.BigInt{Int: block.Number()},
-		Hash:             block.Hash().Hex(),
-		ParentHash:       block.ParentHash().Hex(),
-		Timestamp:        block.Time(),
-		GasLimit:         block.GasLimit(),
-		GasUsed:          block.GasUsed(),
-		BaseFeePerGas:    baseFee,
-		TransactionCount: len(block.Transactions()),
+	mBlock := newBlockModel(block)
+
+	arbitrage := detectArbitrage(blockNum.Uint64(), data.Logs)
+	if len(arbitrage) > 0 {
+		p.metrics.RecordArbitrageCandidates(len(arbitrage))
+		Logger.Debug("🔁 [Arbitrage] Circular trade candidates detected",
+			slog.String("block", blockNum.String()),
+			slog.Int("count", len(arbitrage)))
 	}
 
 	task := PersistTask{
 		Height:    blockNum.Uint64(),
 		Block:     mBlock,
 		Transfers: activities,
-		Sequence:  uint64(time.Now().UnixNano()) & uint64(math.MaxInt64),
+		Arbitrage: arbitrage,
+		Receipts:  buildReceiptRows(block, data.Receipts),
+		NFTs:      p.extractNFTTransfers(data.Logs),
+		Approvals: p.extractApprovals(data.Logs),
+		Events:    p.extractContractEvents(data.Logs),
+		Blobs:     buildBlobTxRows(block),
+		Fees:      buildBlockFeeRow(block, data.Receipts),
+		TraceID:   data.TraceID,
 	}
 
 	// 4. 🔥 核心调度：通过 Orchestrator 分发落盘任务 (SSOT)
-	GetOrchestrator().Dispatch(CmdCommitBatch, task)
+	seq := GetOrchestrator().Dispatch(CmdCommitBatch, task)
+	pipelineTrace.record(task.TraceID, task.Height, TraceStageDispatched, seq, fmt.Sprintf("transfers=%d", len(activities)))
 
 	// 5. 更新 reorg 检测缓存（供下一个块使用，避免 DB 查询）
 	p.updateReorgCache(blockNum, block.Hash().Hex())
 
 	// 6. 实时推送 (UI 即时响应)
-	leaderboard := p.AnalyzeGas(block)
+	leaderboard := p.AnalyzeGas(block, data.Receipts)
 	p.pushEvents(block, activities, leaderboard)
+	p.pushNFTEvents(task.NFTs)
+	GetMempool().ObserveBlock(block) // 对账待打包视图：已上链的交易移出
 
 	// 记录处理耗时 and 更新同步高度 (逻辑水位)
 	p.updateMetrics(start, block)
+	p.recordBlobUsage(block, task.Blobs)
+	p.recordFeeStats(block, task.Fees)
 
 	return nil
 }
@@ -109,7 +124,7 @@ func (p *Processor) handleReorgReadOnly(ctx context.Context, blockNum *big.Int,
 
 	// 缓存未命中，回退到 DB 查询
 	var lastBlock models.Block
-	err := p.db.GetContext(ctx, &lastBlock, "SELECT number, hash, parent_hash, timestamp FROM blocks WHERE number = $1", prevNum.String())
+	err := p.db.GetContext(ctx, &lastBlock, "SELECT number, hash, parent_hash, timestamp FROM blocks WHERE number = $1::NUMERIC", prevNum.String())
 	if err == nil && lastBlock.Hash != parentHash.Hex() {
 		return ReorgError{At: new(big.Int).Set(blockNum)}
 	}
@@ -129,15 +144,13 @@ func (p *Processor) updateReorgCache(blockNum *big.Int, blockHash string) {
 
 // extractActivities 提取活动 (纯内存逻辑)
 func (p *Processor) extractActivities(ctx context.Context, blockNum *big.Int, logs []types.Log, transactions types.Transactions) []models.Transfer {
-	var activities []models.Transfer
 	txWithRealLogs := make(map[string]bool)
 
-	for _, vLog := range logs {
-		activity := p.ProcessLog(vLog)
-		if activity != nil {
-			txWithRealLogs[activity.TxHash] = true
-			activities = append(activities, *activity)
-		}
+	// 先跑垃圾代币启发式，本块即命中的代币不再落盘
+	p.spam.ScanBlock(logs)
+	activities := p.decodeLogs(logs)
+	for _, activity := range activities {
+		txWithRealLogs[activity.TxHash] = true
 	}
 
 	syntheticIdx := uint(20000)
@@ -198,6 +211,9 @@ func (p *Processor) detectDeployNoDB(ctx context.Context, blockNum *big.Int, tx
 	if tx.To() != nil {
 		return nil
 	}
+	if common.IsHexAddress(fromAddr) {
+		refreshDeployedAddress(common.HexToAddress(fromAddr), tx.Nonce())
+	}
 	return &models.Transfer{
 		BlockNumber:  models.BigInt{Int: blockNum},
 		TxHash:       tx.Hash().Hex(),
@@ -224,14 +240,14 @@ func (p *Processor) detectEthTransferNoDB(ctx context.Context, blockNum *big.Int
 		To:           strings.ToLower(tx.To().Hex()),
 		Amount:       models.NewUint256FromBigInt(tx.Value()),
 		TokenAddress: "0x0000000000000000000000000000000000000000",
-		Symbol:       "ETH",
+		Symbol:       GetChainProfile(p.chainID).NativeSymbol,
 		Type:         "ETH_TRANSFER",
 	}
 }
 
 // processAnvilSyntheticNoDB 不写库的 Anvil 模拟逻辑
 func (p *Processor) processAnvilSyntheticNoDB(ctx context.Context, blockNum *big.Int, block *types.Block, activities []models.Transfer) []models.Transfer {
-	if len(activities) > 0 || !p.enableSimulator || p.networkMode != "anvil" {
+	if len(activities) > 0 || !p.syntheticFallback {
 		return activities
 	}
 
@@ -251,9 +267,11 @@ func (p *Processor) processAnvilSyntheticNoDB(ctx context.Context, blockNum *big
 			TokenAddress: "0x0000000000000000000000000000000000000000",
 			Symbol:       "ETH",
 			Type:         "TRANSFER",
+			Synthesized:  true,
 		}
 		activities = append(activities, anvilTransfer)
 	}
+	p.metrics.RecordSyntheticFallbackRows(numMocks)
 	return activities
 }
 
@@ -269,7 +287,7 @@ func (p *Processor) getAnvilAccount(index int) string {
 }
 
 func (p *Processor) pushEvents(block *types.Block, activities []models.Transfer, leaderboard []models.GasSpender) {
-	if p.EventHook == nil {
+	if !p.events.HasSubscribers() {
 		return
 	}
 	latencyMs := max(0, time.Since(time.Unix(int64(block.Time()), 0)).Milliseconds())
@@ -286,29 +304,37 @@ func (p *Processor) pushEvents(block *types.Block, activities []models.Transfer,
 		syncLag = max(0, latestChain-int64(block.NumberU64()))
 	}
 
-	p.EventHook("block", map[string]interface{}{
-		"number":          block.NumberU64(),
-		"hash":            block.Hash().Hex(),
-		"parent_hash":     block.ParentHash().Hex(),
-		"timestamp":       block.Time(),
-		"tx_count":        len(block.Transactions()),
-		"latency_ms":      latencyMs,
-		"latency_display": latencyDisplay,
-		"latest_chain":    latestChain,
-		"sync_lag":        syncLag,
-		"tps":             p.metrics.GetWindowTPS(),
+	p.events.Publish(TopicBlock, models.BlockEvent{
+		Number:         block.NumberU64(),
+		Hash:           block.Hash().Hex(),
+		ParentHash:     block.ParentHash().Hex(),
+		Timestamp:      block.Time(),
+		TxCount:        len(block.Transactions()),
+		LatencyMs:      latencyMs,
+		LatencyDisplay: latencyDisplay,
+		LatestChain:    latestChain,
+		SyncLag:        syncLag,
+		TPS:            p.metrics.GetWindowTPS(),
 	})
-	p.EventHook("gas_leaderboard", leaderboard)
+	p.events.Publish(TopicGasLeaderboard, leaderboard)
 	if p.metrics != nil {
 		p.metrics.RecordActivity(len(activities))
 	}
 	for _, t := range activities {
 		if p.metrics != nil {
 			p.metrics.TransfersProcessed.Inc()
-			p.metrics.TransactionTypesTotal.WithLabelValues(t.Type).Inc()
 		}
 
-		p.EventHook("transfer", map[string]interface{}{"tx_hash": t.TxHash, "from": t.From, "to": t.To, "value": t.Amount.String(), "block_number": t.BlockNumber.String(), "token_address": t.TokenAddress, "symbol": t.Symbol, "type": t.Type, "log_index": t.LogIndex})
+		raw := t.Amount.String()
+		decimals := p.GetDecimals(common.HexToAddress(t.TokenAddress))
+		normalized := FormatTokenAmount(raw, decimals)
+		event := models.TransferEvent{
+			TxHash: t.TxHash, From: t.From, To: t.To, Value: raw, BlockNumber: models.NewHeight(t.BlockNumber.Int),
+			TokenAddress: t.TokenAddress, Symbol: t.Symbol, Type: t.Type, LogIndex: t.LogIndex,
+			Decimals: decimals, AmountNormalized: normalized,
+			AmountUSD: TokenAmountUSD(t.TokenAddress, normalized),
+		}
+		p.events.Publish(TopicTransfer, event)
 	}
 }
 
@@ -317,6 +343,7 @@ func (p *Processor) updateMetrics(start time.Time, block *types.Block) {
 		return
 	}
 	p.metrics.RecordBlockProcessed(time.Since(start))
+	p.metrics.RecordBlockCongestion(block.GasUsed(), block.GasLimit())
 
 	// 🚀 G115 安全转换：防止高度或时间戳溢出 int64
 	num := block.Number()
@@ -337,32 +364,43 @@ func (p *Processor) updateMetrics(start time.Time, block *types.Block) {
 	p.metrics.UpdateE2ELatency(latency)
 }
 
-// AnalyzeGas 实时分析区块中的 Gas 消耗大户
-func (p *Processor) AnalyzeGas(block *types.Block) []models.GasSpender {
+// AnalyzeGas 实时分析区块中的 Gas 消耗大户。
+// 有回执时使用实际 gasUsed × effectiveGasPrice；否则按 Gas Limit × GasPrice 估算并标记 Estimated。
+func (p *Processor) AnalyzeGas(block *types.Block, receipts []*types.Receipt) []models.GasSpender {
 	spenders := make(map[string]*models.GasSpender)
 
+	byTx := make(map[common.Hash]*types.Receipt, len(receipts))
+	for _, r := range receipts {
+		if r != nil {
+			byTx[r.TxHash] = r
+		}
+	}
+
 	for _, tx := range block.Transactions() {
 		to := "0xcontract_creation"
 		if tx.To() != nil {
 			to = strings.ToLower(tx.To().Hex())
 		}
 
-		fee := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasPrice())
+		gas, price, estimated := txGasSpend(tx, byTx[tx.Hash()], block.BaseFee())
+		fee := new(big.Int).Mul(new(big.Int).SetUint64(gas), price)
 
 		if s, exists := spenders[to]; exists {
-			s.TotalGas += tx.Gas()
+			s.TotalGas += gas
 			existingFee, _ := new(big.Int).SetString(s.TotalFee, 10)
 			if existingFee == nil {
 				existingFee = big.NewInt(0)
 			}
 			s.TotalFee = new(big.Int).Add(existingFee, fee).String()
+			s.Estimated = s.Estimated || estimated
 		} else {
 			label := GetAddressLabel(to)
 			spenders[to] = &models.GasSpender{
-				Address:  to,
-				Label:    label,
-				TotalGas: tx.Gas(),
-				TotalFee: fee.String(),
+				Address:   to,
+				Label:     label,
+				TotalGas:  gas,
+				TotalFee:  fee.String(),
+				Estimated: estimated,
 			}
 		}
 	}
@@ -385,3 +423,21 @@ func (p *Processor) AnalyzeGas(block *types.Block) []models.GasSpender {
 	}
 	return result
 }
+
+// txGasSpend 单笔交易的 Gas 消耗与单价：优先取回执，否则以 Gas Limit 估算（estimated=true）
+func txGasSpend(tx *types.Transaction, receipt *types.Receipt, baseFee *big.Int) (gas uint64, price *big.Int, estimated bool) {
+	if receipt == nil {
+		return tx.Gas(), tx.GasPrice(), true
+	}
+	price = receipt.EffectiveGasPrice
+	if price == nil {
+		// 旧节点回执不含 effectiveGasPrice：按 EIP-1559 规则 min(feeCap, baseFee + tipCap) 推算
+		price = tx.GasPrice()
+		if baseFee != nil {
+			if tip, err := tx.EffectiveGasTip(baseFee); err == nil {
+				price = new(big.Int).Add(baseFee, tip)
+			}
+		}
+	}
+	return receipt.GasUsed, price, false
+}
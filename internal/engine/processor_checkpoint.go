@@ -6,20 +6,14 @@ import (
 	"fmt"
 	"math/big"
 
+	"web3-indexer-go/internal/storage"
+
 	"github.com/jmoiron/sqlx"
 )
 
 // updateCheckpointInTx 在事务内更新 checkpoint（保证原子性）
 func (p *Processor) updateCheckpointInTx(ctx context.Context, tx *sqlx.Tx, chainID int64, blockNumber *big.Int) error {
-	_, err := tx.ExecContext(ctx, `
-		INSERT INTO sync_checkpoints (chain_id, last_synced_block)
-		VALUES ($1, $2)
-		ON CONFLICT (chain_id) DO UPDATE SET
-			last_synced_block = EXCLUDED.last_synced_block,
-			updated_at = NOW()
-	`, chainID, blockNumber.String())
-
-	if err != nil {
+	if err := storage.UpsertCheckpointTx(ctx, tx, chainID, blockNumber.Int64()); err != nil {
 		return fmt.Errorf("failed to update checkpoint: %w", err)
 	}
 
@@ -34,23 +28,7 @@ func (p *Processor) updateCheckpointInTx(ctx context.Context, tx *sqlx.Tx, chain
 			chainHeight = h
 		}
 	}
-	lag := chainHeight - syncedBlock
-	if lag < 0 {
-		lag = 0
-	}
-
-	_, err = tx.ExecContext(ctx, `
-		INSERT INTO sync_status (chain_id, last_synced_block, latest_block, sync_lag, status, updated_at)
-		VALUES ($1, $2, $3, $4, 'syncing', NOW())
-		ON CONFLICT (chain_id) DO UPDATE SET
-			last_synced_block = EXCLUDED.last_synced_block,
-			latest_block = EXCLUDED.latest_block,
-			sync_lag = EXCLUDED.sync_lag,
-			status = EXCLUDED.status,
-			updated_at = EXCLUDED.updated_at
-	`, chainID, syncedBlock, chainHeight, lag)
-
-	if err != nil {
+	if err := storage.UpsertSyncStatusTx(ctx, tx, chainID, syncedBlock, chainHeight); err != nil {
 		// 记录错误但不中断流程
 		Logger.Warn("failed_to_update_sync_status", "error", err)
 	}
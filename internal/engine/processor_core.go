@@ -2,128 +2,22 @@ package engine
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
 	"math/big"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/jmoiron/sqlx"
 )
 
-// repositoryAdapter 适配 sqlx.DB 到 DBUpdater 接口
-type repositoryAdapter struct {
-	db *sqlx.DB
-}
-
-func (r *repositoryAdapter) UpdateTokenSymbol(tokenAddress, symbol string) error {
-	query := `UPDATE transfers SET symbol = $1 WHERE token_address = $2 AND (symbol IS NULL OR symbol = '')`
-	_, err := r.db.Exec(query, symbol, tokenAddress)
-	return err
-}
-
-func (r *repositoryAdapter) UpdateTokenDecimals(_ string, _ uint8) error {
-	// 预留方法，当前 schema 没有 decimals 字段
-	return nil
-}
-
-func (r *repositoryAdapter) SaveTokenMetadata(meta models.TokenMetadata, address string) error {
-	query := `
-		INSERT INTO token_metadata (address, symbol, decimals, name, updated_at)
-		VALUES ($1, $2, $3, $4, NOW())
-		ON CONFLICT (address) DO UPDATE SET
-			symbol = EXCLUDED.symbol,
-			decimals = EXCLUDED.decimals,
-			name = EXCLUDED.name,
-			updated_at = NOW()
-	`
-	_, err := r.db.Exec(query, strings.ToLower(address), meta.Symbol, meta.Decimals, meta.Name)
-	return err
-}
-
-func (r *repositoryAdapter) LoadAllMetadata() (map[string]models.TokenMetadata, error) {
-	var rows []struct {
-		Address  string `db:"address"`
-		Symbol   string `db:"symbol"`
-		Decimals uint8  `db:"decimals"`
-		Name     string `db:"name"`
-	}
-
-	err := r.db.Select(&rows, "SELECT address, symbol, decimals, name FROM token_metadata")
-	if err != nil {
-		return nil, err
-	}
-
-	result := make(map[string]models.TokenMetadata)
-	for _, row := range rows {
-		result[strings.ToLower(row.Address)] = models.TokenMetadata{
-			Symbol:   row.Symbol,
-			Decimals: row.Decimals,
-			Name:     row.Name,
-		}
-	}
-	return result, nil
-}
-
-func (r *repositoryAdapter) GetMaxStoredBlock(ctx context.Context) (int64, error) {
-	var dbMax int64
-	err := r.db.GetContext(ctx, &dbMax, "SELECT COALESCE(MAX(number), 0) FROM blocks")
-	return dbMax, err
-}
-
-func (r *repositoryAdapter) GetSyncCursor(ctx context.Context) (int64, error) {
-	var cursor string
-	err := r.db.GetContext(ctx, &cursor, "SELECT COALESCE(last_synced_block, '0') FROM sync_checkpoints LIMIT 1")
-	if err != nil {
-		return 0, err
-	}
-	num, _ := new(big.Int).SetString(cursor, 10)
-	if num == nil {
-		return 0, nil
-	}
-	return num.Int64(), nil
-}
-
-func (r *repositoryAdapter) PruneFutureData(ctx context.Context, chainHead int64) error {
-	// Directly execute delete queries
-	tx, err := r.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if rbErr := tx.Rollback(); rbErr != nil && rbErr != sql.ErrTxDone {
-			slog.Error("📝 PruneFutureData: Rollback failed", "err", rbErr)
-		}
-	}()
-
-	headStr := fmt.Sprintf("%d", chainHead)
-
-	if _, err := tx.ExecContext(ctx, "DELETE FROM transfers WHERE block_number > $1", headStr); err != nil {
-		return err
-	}
-	if _, err := tx.ExecContext(ctx, "DELETE FROM blocks WHERE number > $1", headStr); err != nil {
-		return err
-	}
-	if _, err := tx.ExecContext(ctx, "UPDATE sync_checkpoints SET last_synced_block = $1, updated_at = NOW()", headStr); err != nil {
-		return err
-	}
-	return tx.Commit()
-}
-
-func (r *repositoryAdapter) UpdateSyncCursor(ctx context.Context, height int64) error {
-	headStr := fmt.Sprintf("%d", height)
-	if _, err := r.db.ExecContext(ctx, "UPDATE sync_checkpoints SET last_synced_block = $1, updated_at = NOW()", headStr); err != nil {
-		return err
-	}
-	return nil
-}
-
 // TransferEventHash defined in signatures.go
 
 // ErrReorgDetected is returned when a blockchain reorganization is detected
@@ -143,10 +37,12 @@ func (e ReorgError) Error() string {
 
 type Processor struct {
 	db               *sqlx.DB
-	client           RPCClient // RPC client interface for reorg recovery
-	metrics          *Metrics  // Prometheus metrics
+	store            *storage.Postgres // 类型化读写（回滚、游标、元数据）
+	client           RPCClient         // RPC client interface for reorg recovery
+	metrics          *Metrics          // Prometheus metrics
+	watchMu          sync.RWMutex      // 监控地址可由 Watchlist 在运行时替换
 	watchedAddresses map[common.Address]bool
-	EventHook        func(eventType string, data interface{}) // 实时事件回调
+	events           *EventBus // 实时事件总线（WS 推送、指标、Sink 等各自订阅）
 
 	// DLQ / Retry Queue
 	retryQueue chan BlockData
@@ -160,14 +56,33 @@ type Processor struct {
 	enableSimulator bool
 	networkMode     string
 
+	// 🏭 空块合成兜底：无真实活动时写入硬编码 Anvil 账户间的 mock 转账（默认仅 Anvil + 模拟器）
+	syntheticFallback bool
+
+	// 🧹 代币级允许 / 拒绝名单（nil 不过滤）
+	tokenFilter *TokenFilter
+
+	// 🗑️ 垃圾代币启发式与自动静音（nil 不检测）
+	spam *SpamGuard
+
+	// 🌉 额外的 Transfer 等价事件 topic0 → origin 标签（EXTRA_TRANSFER_TOPICS）
+	extraTransferTopics TransferTopics
+
+	// 🧾 通用解码的事件签名 topic0 → ABI 事件（EVENT_SIGNATURES）
+	eventSignatures EventSignatures
+
 	// 🎨 Metadata Enricher (异步元数据解析器)
 	enricher *MetadataEnricher
 
 	// 🚀 HotBuffer (内存热数据池)
 	hotBuffer *HotBuffer
 
-	// 🚀 DataSink (多路分发支持)
-	sink DataSink
+	// 🚀 DataSink (多路分发支持)：由 AsyncWriter 提交成功后经 EmitCommitted 转发
+	sink     DataSink
+	sinkSeed sync.Once // 首次转发前按 sink 水位预填去重集合
+
+	// 🏭 模拟器合成转账队列（SIMULATOR_PERSIST 开启时挂接到下一个处理的区块）
+	synthesized atomic.Pointer[synthesizedQueue]
 
 	// 🚀 Reorg 检测缓存：避免每块都查 DB
 	lastBlockHashMu sync.Mutex
@@ -178,6 +93,7 @@ type Processor struct {
 func NewProcessor(db *sqlx.DB, client RPCClient, retryQueueSize int, chainID int64, enableSimulator bool, networkMode string) *Processor {
 	p := &Processor{
 		db:                        db,
+		store:                     NewStore(db),
 		client:                    client,
 		metrics:                   GetMetrics(),
 		watchedAddresses:          make(map[common.Address]bool),
@@ -189,7 +105,9 @@ func NewProcessor(db *sqlx.DB, client RPCClient, retryQueueSize int, chainID int
 		enableSimulator:           enableSimulator,
 		networkMode:               networkMode,
 		hotBuffer:                 NewHotBuffer(50000), // 默认 5 万条热数据
+		events:                    NewEventBus(),
 	}
+	p.syntheticFallback = enableSimulator && (networkMode == networkAnvil || chainID == 31337)
 
 	// 🎨 初始化元数据丰富器（仅用于生产网络，Anvil 不需要）
 	if chainID != 31337 {
@@ -200,9 +118,7 @@ func NewProcessor(db *sqlx.DB, client RPCClient, retryQueueSize int, chainID int
 		}
 
 		if metadataClient != nil {
-			// 使用 Repository 包装 db 以满足 DBUpdater 接口
-			repo := &repositoryAdapter{db: db}
-			p.enricher = NewMetadataEnricher(metadataClient, repo, Logger, 1000, 200*time.Millisecond)
+			p.enricher = NewMetadataEnricher(metadataClient, p.store, Logger, 1000, 200*time.Millisecond)
 			Logger.Info("🎨 [Processor] Metadata Enricher initialized", "chain_id", chainID)
 		}
 	}
@@ -241,20 +157,76 @@ func (p *Processor) StartRetryWorker(ctx context.Context, wg *sync.WaitGroup) {
 						slog.String("block", data.Number.String()),
 						slog.String("error", err.Error()),
 					)
-					// 这里可以进一步将 data 写入持久化存储（如数据库中的 dead_letter_blocks 表）
+					// 记入 skipped_ranges，由后台回填在空闲时补齐
+					if data.Number != nil {
+						GetGapSkipper().Record(ctx, data.Number.Uint64(), data.Number.Uint64(), SkipReasonDLQExhausted)
+					}
 				}
 			}
 		}
 	}()
 }
 
+// SetSyntheticFallback 显式开关空块合成兜底（SYNTHETIC_FALLBACK），生成的行带 synthesized=true
+func (p *Processor) SetSyntheticFallback(enabled bool) {
+	p.syntheticFallback = enabled
+}
+
+// SetTokenFilter 设置代币级允许 / 拒绝名单（nil 表示不过滤）；须在开始处理前调用
+func (p *Processor) SetTokenFilter(f *TokenFilter) {
+	p.tokenFilter = f
+	if f != nil {
+		allow, deny := f.Sizes()
+		Logger.Info("🧹 [Processor] Token filter enabled", slog.Int("allow", allow), slog.Int("deny", deny))
+	}
+}
+
+// SetSpamGuard 设置垃圾代币守卫，并让元数据丰富器上报不可读元数据的代币；须在开始处理前调用
+func (p *Processor) SetSpamGuard(g *SpamGuard) {
+	p.spam = g
+	if p.enricher != nil {
+		p.enricher.SetSpamGuard(g)
+	}
+}
+
+// SetExtraTransferTopics 设置额外的 Transfer 等价事件（按标准转账解析并带 origin 标签入库）；须在开始处理前调用
+func (p *Processor) SetExtraTransferTopics(topics TransferTopics) {
+	p.extraTransferTopics = topics
+	for topic, origin := range topics {
+		Logger.Info("🌉 [Processor] Transfer-equivalent event enabled", slog.String("origin", origin), slog.String("topic0", topic.Hex()))
+	}
+}
+
+// SetEventSignatures 设置通用解码的事件签名（写入 contract_events）；须在开始处理前调用
+func (p *Processor) SetEventSignatures(sigs EventSignatures) {
+	p.eventSignatures = sigs
+	for topic, event := range sigs {
+		Logger.Info("🧾 [Processor] Event signature enabled", slog.String("event", event.Sig), slog.String("topic0", topic.Hex()))
+	}
+}
+
+// SpamGuard returns the spam token guard (nil when spam detection is disabled)
+func (p *Processor) SpamGuard() *SpamGuard {
+	return p.spam
+}
+
 // SetWatchedAddresses sets the addresses to monitor
 func (p *Processor) SetWatchedAddresses(addresses []string) {
-	p.watchedAddresses = make(map[common.Address]bool)
+	watched := make(map[common.Address]bool, len(addresses))
 	for _, addr := range addresses {
-		p.watchedAddresses[common.HexToAddress(addr)] = true
+		watched[common.HexToAddress(addr)] = true
 		Logger.Info("processor_watching_address", slog.String("address", strings.ToLower(addr)))
 	}
+	p.watchMu.Lock()
+	p.watchedAddresses = watched
+	p.watchMu.Unlock()
+}
+
+// IsWatched 地址是否在监控列表中
+func (p *Processor) IsWatched(addr common.Address) bool {
+	p.watchMu.RLock()
+	defer p.watchMu.RUnlock()
+	return p.watchedAddresses[addr]
 }
 
 // GetDB returns the underlying sqlx.DB instance
@@ -275,9 +247,22 @@ func (p *Processor) GetSymbol(addr common.Address) string {
 	return addr.Hex()[:10] + "..."
 }
 
-// GetRepoAdapter returns the underlying repository adapter for the guard
+// GetDecimals returns the token decimals (18 until metadata enrichment completes)
+func (p *Processor) GetDecimals(addr common.Address) uint8 {
+	if p.enricher != nil {
+		return p.enricher.GetDecimals(addr)
+	}
+	return defaultTokenDecimals
+}
+
+// MetadataEnricher returns the token metadata enricher (nil when enrichment is disabled)
+func (p *Processor) MetadataEnricher() *MetadataEnricher {
+	return p.enricher
+}
+
+// GetRepoAdapter returns the processor's store for the guard and watchdog
 func (p *Processor) GetRepoAdapter() DBUpdater {
-	return &repositoryAdapter{db: p.db}
+	return p.store
 }
 
 // GetHotBuffer returns the HotBuffer instance
@@ -285,16 +270,64 @@ func (p *Processor) GetHotBuffer() *HotBuffer {
 	return p.hotBuffer
 }
 
-// SetSink sets the data sink for the processor
+// SetSink sets the data sink for the processor.
+// The sink is wrapped with idempotency-key de-duplication so that
+// retried or reorg-replayed blocks are not written downstream twice.
 func (p *Processor) SetSink(sink DataSink) {
+	if sink == nil {
+		p.sink = nil
+		return
+	}
+	if _, ok := sink.(*DedupSink); !ok {
+		sink = NewDedupSink(sink, "processor", p.chainID, 0)
+	}
 	p.sink = sink
 }
 
+// AddSink appends another downstream sink next to the current one,
+// sharing a single de-duplication layer in front of both.
+func (p *Processor) AddSink(sink DataSink) {
+	if sink == nil {
+		return
+	}
+	switch cur := p.sink.(type) {
+	case nil:
+		p.SetSink(sink)
+	case *DedupSink:
+		p.SetSink(NewMultiSink(cur.inner, sink))
+	default:
+		p.SetSink(NewMultiSink(cur, sink))
+	}
+}
+
+// writeSink forwards a committed block to the downstream sink (best effort),
+// reporting whether both writes succeeded
+func (p *Processor) writeSink(ctx context.Context, block models.Block, transfers []models.Transfer) bool {
+	if p.sink == nil {
+		return true
+	}
+	ok := true
+	if err := p.sink.WriteBlocks(ctx, []models.Block{block}); err != nil {
+		Logger.Warn("sink_write_block_failed", "block", block.Number.String(), "err", err)
+		ok = false
+	}
+	if err := p.sink.WriteTransfers(ctx, transfers); err != nil {
+		Logger.Warn("sink_write_transfer_failed", "block", block.Number.String(), "err", err)
+		ok = false
+	}
+	return ok
+}
+
 // GetSink returns the current data sink
 func (p *Processor) GetSink() DataSink {
 	return p.sink
 }
 
+// Events returns the processor's event bus for subscribing to real-time events
+func (p *Processor) Events() *EventBus {
+	return p.events
+}
+
 // ProcessBlockWithRetry 带重试的区块处理
 func (p *Processor) ProcessBlockWithRetry(ctx context.Context, data BlockData, maxRetries int) error {
 	var err error
@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeGas_ReceiptsGiveActualSpend(t *testing.T) {
+	routerA := common.HexToAddress("0xa0")
+	routerB := common.HexToAddress("0xb0")
+	txA := types.NewTx(&types.LegacyTx{Nonce: 1, To: &routerA, Gas: 1_000_000, GasPrice: big.NewInt(10)})
+	txB := types.NewTx(&types.LegacyTx{Nonce: 2, To: &routerB, Gas: 500_000, GasPrice: big.NewInt(10)})
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)}).WithBody(types.Body{Transactions: []*types.Transaction{txA, txB}})
+
+	p := &Processor{}
+
+	// 无回执：按 Gas Limit 估算
+	estimated := p.AnalyzeGas(block, nil)
+	require.Len(t, estimated, 2)
+	assert.Equal(t, uint64(1_000_000), estimated[0].TotalGas)
+	assert.True(t, estimated[0].Estimated)
+
+	// txA 有回执：实际消耗远低于 Gas Limit，排名随之变化；txB 仍为估算
+	receipts := []*types.Receipt{{TxHash: txA.Hash(), GasUsed: 21_000, EffectiveGasPrice: big.NewInt(8)}}
+	actual := p.AnalyzeGas(block, receipts)
+	require.Len(t, actual, 2)
+	assert.Equal(t, uint64(500_000), actual[0].TotalGas)
+	assert.True(t, actual[0].Estimated)
+	assert.Equal(t, uint64(21_000), actual[1].TotalGas)
+	assert.False(t, actual[1].Estimated)
+}
+
+func TestTxGasSpend_DerivesPriceWithoutEffectiveGasPrice(t *testing.T) {
+	to := common.HexToAddress("0xc0")
+	tx := types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(1), To: &to, Gas: 100_000, GasFeeCap: big.NewInt(50), GasTipCap: big.NewInt(2)})
+
+	gas, price, estimated := txGasSpend(tx, &types.Receipt{GasUsed: 30_000}, big.NewInt(20))
+	assert.Equal(t, uint64(30_000), gas)
+	assert.Equal(t, big.NewInt(22), price, "baseFee + tip, capped by feeCap")
+	assert.False(t, estimated)
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"math/big"
+	"strings"
+
+	"web3-indexer-go/internal/models"
+)
+
+// internalLogIndexBase 内部转账的 log_index 起点（介于真实日志 / Anvil mock 与合成转账之间）
+const internalLogIndexBase = 500_000
+
+// extractInternalTransfers 从调用树提取内部 ETH 转账并计数
+func (p *Processor) extractInternalTransfers(blockNum *big.Int, traces []TxCallTrace) []models.Transfer {
+	if len(traces) == 0 {
+		return nil
+	}
+	transfers := internalTransfers(blockNum, traces, GetChainProfile(p.chainID).NativeSymbol)
+	if len(transfers) > 0 && p.metrics != nil && p.metrics.InternalTransfers != nil {
+		p.metrics.InternalTransfers.Add(float64(len(transfers)))
+	}
+	return transfers
+}
+
+// internalTransfers 提取深度 ≥1 且 value > 0 的 CALL / CREATE / CREATE2 / SELFDESTRUCT 帧。
+// 顶层帧即交易自身的 value，已由 ETH_TRANSFER 记录；revert 的帧连同其子调用不计入
+func internalTransfers(blockNum *big.Int, traces []TxCallTrace, nativeSymbol string) []models.Transfer {
+	var out []models.Transfer
+	var walk func(txHash string, frame CallFrame)
+	walk = func(txHash string, frame CallFrame) {
+		if frame.Error != "" {
+			return
+		}
+		for _, call := range frame.Calls {
+			if call.Error != "" {
+				continue
+			}
+			if movesValue(call) {
+				out = append(out, models.Transfer{
+					BlockNumber:  models.BigInt{Int: blockNum},
+					TxHash:       txHash,
+					LogIndex:     uint(internalLogIndexBase + len(out)), // #nosec G115 - len(out) is non-negative
+					From:         strings.ToLower(call.From.Hex()),
+					To:           strings.ToLower(call.To.Hex()),
+					Amount:       models.NewUint256FromBigInt(call.Value.ToInt()),
+					TokenAddress: "0x0000000000000000000000000000000000000000",
+					Symbol:       nativeSymbol,
+					Type:         models.ActivityInternal,
+				})
+			}
+			walk(txHash, call)
+		}
+	}
+	for _, trace := range traces {
+		if trace.Error != "" {
+			continue
+		}
+		walk(trace.TxHash.Hex(), trace.Result)
+	}
+	return out
+}
+
+// movesValue 调用帧是否实际转移了 ETH（DELEGATECALL / STATICCALL 不转移 value）
+func movesValue(frame CallFrame) bool {
+	if frame.Value == nil || frame.Value.ToInt().Sign() <= 0 {
+		return false
+	}
+	switch strings.ToUpper(frame.Type) {
+	case "CALL", "CREATE", "CREATE2", "SELFDESTRUCT":
+		return true
+	}
+	return false
+}
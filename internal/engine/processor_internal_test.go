@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func wei(v int64) *hexutil.Big { return (*hexutil.Big)(big.NewInt(v)) }
+
+func TestInternalTransfers(t *testing.T) {
+	user, router, vault, pool, proxy := testkit.Address("user", 1), testkit.Address("router", 1), testkit.Address("vault", 1), testkit.Address("pool", 1), testkit.Address("proxy", 1)
+	traces := []TxCallTrace{
+		{
+			TxHash: testkit.TxHash(7, 0),
+			Result: CallFrame{Type: "CALL", From: user, To: router, Value: wei(100), Calls: []CallFrame{
+				{Type: "CALL", From: router, To: vault, Value: wei(60), Calls: []CallFrame{
+					{Type: "CALL", From: vault, To: user, Value: wei(5)},
+				}},
+				{Type: "DELEGATECALL", From: router, To: proxy, Value: wei(100)}, // 不转移 value
+				{Type: "STATICCALL", From: router, To: pool},                     // 无 value
+				{Type: "CALL", From: router, To: pool, Value: wei(0)},            // 零值
+				{Type: "CALL", From: router, To: pool, Value: wei(40), Error: "reverted", Calls: []CallFrame{
+					{Type: "CALL", From: pool, To: user, Value: wei(1)}, // 随父帧撤销
+				}},
+			}},
+		},
+		{
+			TxHash: testkit.TxHash(7, 1),
+			Result: CallFrame{Type: "CALL", From: user, To: router, Error: "execution reverted", Calls: []CallFrame{
+				{Type: "CALL", From: router, To: vault, Value: wei(9)},
+			}},
+		},
+		{TxHash: testkit.TxHash(7, 2), Error: "tracer timeout"},
+	}
+
+	got := internalTransfers(big.NewInt(7), traces, "ETH")
+	require.Len(t, got, 2)
+
+	assert.Equal(t, strings.ToLower(router.Hex()), got[0].From)
+	assert.Equal(t, strings.ToLower(vault.Hex()), got[0].To)
+	assert.Equal(t, "60", got[0].Amount.String())
+	assert.Equal(t, models.ActivityInternal, got[0].Type)
+	assert.Equal(t, testkit.TxHash(7, 0).Hex(), got[0].TxHash)
+	assert.Equal(t, "ETH", got[0].Symbol)
+
+	assert.Equal(t, strings.ToLower(user.Hex()), got[1].To, "nested calls are walked depth-first")
+	assert.Equal(t, "5", got[1].Amount.String())
+	assert.NotEqual(t, got[0].LogIndex, got[1].LogIndex)
+	assert.GreaterOrEqual(t, got[0].LogIndex, uint(internalLogIndexBase))
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"strings"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// isERC721Transfer ERC-721 与 ERC-20 共用 Transfer(address,address,uint256) 的 topic0，
+// 区别在于 tokenId 为 indexed 参数：共 4 个 topic，data 为空
+func isERC721Transfer(vLog types.Log) bool {
+	return len(vLog.Topics) == 4 && vLog.Topics[0] == TransferEventHash && len(vLog.Data) == 0
+}
+
+// extractNFTTransfers 从区块日志提取 ERC-721 转账（经过与 ERC-20 相同的代币过滤与垃圾静音）并计数
+func (p *Processor) extractNFTTransfers(logs []types.Log) []storage.NFTTransferRow {
+	var rows []storage.NFTTransferRow
+	for _, vLog := range logs {
+		if !isERC721Transfer(vLog) {
+			continue
+		}
+		if ok, _ := p.tokenFilter.Allows(vLog.Address); !ok || p.spam.IsMuted(vLog.Address) {
+			continue
+		}
+		rows = append(rows, nftTransferRow(vLog))
+	}
+	if len(rows) > 0 && p.metrics != nil && p.metrics.NFTTransfers != nil {
+		p.metrics.NFTTransfers.Add(float64(len(rows)))
+	}
+	return rows
+}
+
+// nftTransferRow 把 ERC-721 Transfer 日志转换为 nft_transfers 行
+func nftTransferRow(vLog types.Log) storage.NFTTransferRow {
+	return storage.NFTTransferRow{
+		BlockNumber: models.Height(vLog.BlockNumber),
+		LogIndex:    vLog.Index,
+		TxHash:      vLog.TxHash.Hex(),
+		Collection:  strings.ToLower(vLog.Address.Hex()),
+		FromAddress: strings.ToLower(common.BytesToAddress(vLog.Topics[1].Bytes()).Hex()),
+		ToAddress:   strings.ToLower(common.BytesToAddress(vLog.Topics[2].Bytes()).Hex()),
+		TokenID:     vLog.Topics[3].Big().String(),
+	}
+}
+
+// pushNFTEvents 推送 "nft_transfer" 事件（collection 符号取自元数据缓存）
+func (p *Processor) pushNFTEvents(rows []storage.NFTTransferRow) {
+	if len(rows) == 0 || !p.events.HasSubscribers() {
+		return
+	}
+	for _, row := range rows {
+		p.events.Publish(TopicNFTTransfer, models.NFTTransferEvent{
+			TxHash:      row.TxHash,
+			BlockNumber: row.BlockNumber,
+			LogIndex:    row.LogIndex,
+			Collection:  row.Collection,
+			Symbol:      p.GetSymbol(common.HexToAddress(row.Collection)),
+			From:        row.FromAddress,
+			To:          row.ToAddress,
+			TokenID:     row.TokenID,
+		})
+	}
+}
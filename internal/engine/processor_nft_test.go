@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"web3-indexer-go/internal/storage"
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractNFTTransfers(t *testing.T) {
+	block := testkit.Block(42, common.Hash{})
+	collection, other := testkit.Address("collection", 1), testkit.Address("collection", 2)
+	from, to := testkit.Address("from", 1), testkit.Address("to", 1)
+	tokenID, _ := new(big.Int).SetString("115792089237316195423570985008687907853269984665640564039457584007913129639935", 10)
+
+	logs := []types.Log{
+		testkit.InBlock(testkit.ERC20Transfer(testkit.USDC, from, to, big.NewInt(5)), block, 0),
+		testkit.InBlock(testkit.ERC721Transfer(collection, from, to, tokenID), block, 1),
+		testkit.InBlock(testkit.ERC721Transfer(other, from, to, big.NewInt(7)), block, 2),
+	}
+
+	p := &Processor{metrics: GetMetrics(), chainID: 1}
+	assert.Nil(t, p.ProcessLog(logs[1]), "ERC-721 transfers are not decoded as fungible transfers")
+	require.NotNil(t, p.ProcessLog(logs[0]))
+
+	rows := p.extractNFTTransfers(logs)
+	require.Len(t, rows, 2)
+	assert.Equal(t, storage.NFTTransferRow{
+		BlockNumber: 42,
+		LogIndex:    1,
+		TxHash:      logs[1].TxHash.Hex(),
+		Collection:  strings.ToLower(collection.Hex()),
+		FromAddress: strings.ToLower(from.Hex()),
+		ToAddress:   strings.ToLower(to.Hex()),
+		TokenID:     tokenID.String(),
+	}, rows[0])
+
+	p.tokenFilter = NewTokenFilter([]string{collection.Hex()}, nil)
+	rows = p.extractNFTTransfers(logs)
+	require.Len(t, rows, 1, "collections outside the allow list are skipped")
+	assert.Equal(t, strings.ToLower(collection.Hex()), rows[0].Collection)
+}
+
+func TestIsERC721Transfer(t *testing.T) {
+	from, to := testkit.Address("from", 1), testkit.Address("to", 1)
+	assert.True(t, isERC721Transfer(testkit.ERC721Transfer(testkit.USDC, from, to, big.NewInt(1))))
+	assert.False(t, isERC721Transfer(testkit.ERC20Transfer(testkit.USDC, from, to, big.NewInt(1))))
+
+	withData := testkit.ERC721Transfer(testkit.USDC, from, to, big.NewInt(1))
+	withData.Data = common.LeftPadBytes([]byte{1}, 32)
+	assert.False(t, isERC721Transfer(withData), "four topics plus data is not the ERC-721 layout")
+}
@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"web3-indexer-go/internal/models"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// parallelDecodeMinLogs 单块日志数达到该值才并行解码（小块的 goroutine 调度开销大于收益）
+	parallelDecodeMinLogs = 512
+	// decodeChunkSize 每个 worker 一次领取的日志数
+	decodeChunkSize = 128
+)
+
+// decodeLogs 把区块日志解码为活动，结果保持原日志顺序（被过滤的日志跳过）。
+// 大块按分片交给多个 worker 并行执行 ProcessLog（纯 CPU 解码与校验，依赖的过滤器 / 缓存均为并发安全），
+// 结果写入按下标预分配的槽位后顺序收集；之后的落库阶段仍是单个有序事务。
+func (p *Processor) decodeLogs(logs []types.Log) []models.Transfer {
+	workers := min(runtime.GOMAXPROCS(0), (len(logs)+decodeChunkSize-1)/decodeChunkSize)
+	if len(logs) < parallelDecodeMinLogs || workers < 2 {
+		var activities []models.Transfer
+		for _, vLog := range logs {
+			if activity := p.ProcessLog(vLog); activity != nil {
+				activities = append(activities, *activity)
+			}
+		}
+		return activities
+	}
+
+	slots := make([]*models.Transfer, len(logs))
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				start := int(next.Add(decodeChunkSize)) - decodeChunkSize
+				if start >= len(logs) {
+					return
+				}
+				end := min(start+decodeChunkSize, len(logs))
+				for i := start; i < end; i++ {
+					slots[i] = p.ProcessLog(logs[i])
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	activities := make([]models.Transfer, 0, len(logs))
+	for _, activity := range slots {
+		if activity != nil {
+			activities = append(activities, *activity)
+		}
+	}
+	return activities
+}
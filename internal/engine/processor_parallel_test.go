@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeLogs_ParallelPreservesOrder(t *testing.T) {
+	p := &Processor{metrics: GetMetrics(), chainID: 1}
+	p.SetTokenFilter(NewTokenFilter(nil, []string{filterSpam.Hex()}))
+
+	logs := make([]types.Log, 3*parallelDecodeMinLogs)
+	for i := range logs {
+		token := filterTokenA
+		if i%7 == 0 {
+			token = filterSpam // 被过滤的日志不占位
+		}
+		logs[i] = types.Log{
+			Address:     token,
+			Topics:      []common.Hash{TransferEventHash, common.BytesToHash(filterTokenA.Bytes()), common.BytesToHash(filterTokenB.Bytes())},
+			Data:        common.LeftPadBytes(big.NewInt(int64(i)).Bytes(), 32),
+			BlockNumber: 10,
+			Index:       uint(i),
+		}
+	}
+
+	got := p.decodeLogs(logs)
+	var sequential []uint
+	for _, l := range logs {
+		if a := p.ProcessLog(l); a != nil {
+			sequential = append(sequential, a.LogIndex)
+		}
+	}
+	require.Len(t, got, len(sequential))
+	for i, a := range got {
+		assert.Equal(t, sequential[i], a.LogIndex)
+		assert.Equal(t, uint64(a.LogIndex), a.Amount.ToBig().Uint64())
+	}
+
+	assert.Len(t, p.decodeLogs(logs[:10]), 8, "small blocks decode sequentially")
+}
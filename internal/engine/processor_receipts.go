@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"log/slog"
+	"math/big"
+	"strings"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// buildReceiptRows 把区块回执转换为 receipts 表行；缺少回执的交易不写入
+func buildReceiptRows(block *types.Block, receipts []*types.Receipt) []storage.ReceiptRow {
+	if len(receipts) == 0 {
+		return nil
+	}
+	byTx := make(map[common.Hash]*types.Receipt, len(receipts))
+	for _, r := range receipts {
+		if r != nil {
+			byTx[r.TxHash] = r
+		}
+	}
+
+	rows := make([]storage.ReceiptRow, 0, len(byTx))
+	for i, tx := range block.Transactions() {
+		r := byTx[tx.Hash()]
+		if r == nil {
+			continue
+		}
+		gas, price, _ := txGasSpend(tx, r, block.BaseFee())
+		row := storage.ReceiptRow{
+			TxHash:            tx.Hash().Hex(),
+			Block:             block.NumberU64(),
+			TxIndex:           uint(i),
+			Status:            r.Status,
+			GasUsed:           gas,
+			EffectiveGasPrice: price,
+			Fee:               new(big.Int).Mul(new(big.Int).SetUint64(gas), price),
+		}
+		if r.ContractAddress != (common.Address{}) {
+			row.ContractAddress = strings.ToLower(r.ContractAddress.Hex())
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// dropFailedTxActivities 去掉回执状态为失败（revert）的交易派生的活动：失败交易的 value 转账、
+// 合约部署并未生效。日志派生的转账不受影响（失败交易不产生日志）；没有回执时原样返回
+func dropFailedTxActivities(blockNum *big.Int, activities []models.Transfer, receipts []*types.Receipt) []models.Transfer {
+	failed := make(map[string]bool)
+	for _, r := range receipts {
+		if r != nil && r.Status == types.ReceiptStatusFailed {
+			failed[r.TxHash.Hex()] = true
+		}
+	}
+	if len(failed) == 0 {
+		return activities
+	}
+
+	kept := activities[:0]
+	dropped := 0
+	for _, a := range activities {
+		if failed[a.TxHash] {
+			dropped++
+			continue
+		}
+		kept = append(kept, a)
+	}
+	if dropped > 0 {
+		Logger.Debug("⛽ [Processor] Dropped activities of reverted transactions",
+			slog.String("block", blockNum.String()),
+			slog.Int("failed_txs", len(failed)),
+			slog.Int("dropped", dropped))
+	}
+	return kept
+}
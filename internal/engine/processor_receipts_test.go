@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receiptTestBlock() (*types.Block, []*types.Transaction) {
+	to := testkit.Address("router", 1)
+	txs := []*types.Transaction{
+		types.NewTx(&types.LegacyTx{Nonce: 1, To: &to, Value: big.NewInt(5), Gas: 100_000, GasPrice: big.NewInt(10)}),
+		types.NewTx(&types.LegacyTx{Nonce: 2, To: &to, Value: big.NewInt(7), Gas: 100_000, GasPrice: big.NewInt(10)}),
+		types.NewTx(&types.LegacyTx{Nonce: 3, Gas: 500_000, GasPrice: big.NewInt(10)}),
+	}
+	return testkit.BlockWithTxs(50, common.Hash{}, txs), txs
+}
+
+func TestBuildReceiptRows(t *testing.T) {
+	block, txs := receiptTestBlock()
+	created := testkit.Address("contract", 1)
+	receipts := []*types.Receipt{
+		{TxHash: txs[0].Hash(), Status: types.ReceiptStatusSuccessful, GasUsed: 21_000, EffectiveGasPrice: big.NewInt(9)},
+		{TxHash: txs[2].Hash(), Status: types.ReceiptStatusFailed, GasUsed: 300_000, EffectiveGasPrice: big.NewInt(9), ContractAddress: created},
+	}
+
+	rows := buildReceiptRows(block, receipts)
+	require.Len(t, rows, 2, "transactions without a receipt are skipped")
+	assert.Equal(t, txs[0].Hash().Hex(), rows[0].TxHash)
+	assert.Equal(t, uint64(50), rows[0].Block)
+	assert.Equal(t, big.NewInt(189_000), rows[0].Fee)
+	assert.Empty(t, rows[0].ContractAddress)
+
+	assert.Equal(t, uint(2), rows[1].TxIndex)
+	assert.Equal(t, types.ReceiptStatusFailed, rows[1].Status)
+	assert.Equal(t, big.NewInt(2_700_000), rows[1].Fee)
+	assert.Equal(t, strings.ToLower(created.Hex()), rows[1].ContractAddress)
+
+	assert.Nil(t, buildReceiptRows(block, nil))
+}
+
+func TestDropFailedTxActivities(t *testing.T) {
+	_, txs := receiptTestBlock()
+	activities := []models.Transfer{
+		{TxHash: txs[0].Hash().Hex(), Type: "ETH_TRANSFER"},
+		{TxHash: txs[1].Hash().Hex(), Type: "ETH_TRANSFER"},
+		{TxHash: txs[1].Hash().Hex(), Type: "TRANSFER"},
+	}
+	receipts := []*types.Receipt{
+		{TxHash: txs[0].Hash(), Status: types.ReceiptStatusSuccessful},
+		{TxHash: txs[1].Hash(), Status: types.ReceiptStatusFailed},
+	}
+
+	kept := dropFailedTxActivities(big.NewInt(50), append([]models.Transfer(nil), activities...), receipts)
+	require.Len(t, kept, 1)
+	assert.Equal(t, txs[0].Hash().Hex(), kept[0].TxHash)
+
+	assert.Len(t, dropFailedTxActivities(big.NewInt(50), activities, nil), 3, "without receipts nothing is dropped")
+}
+
+// noBlockReceiptsClient 不支持 eth_getBlockReceipts 的提供方，只能逐笔获取回执
+type noBlockReceiptsClient struct {
+	lossyLogClient
+	blockCalls atomic.Int32
+	txCalls    atomic.Int32
+	failTx     common.Hash
+}
+
+type methodNotFoundError struct{}
+
+func (methodNotFoundError) Error() string {
+	return "the method eth_getBlockReceipts does not exist/is not available"
+}
+func (methodNotFoundError) ErrorCode() int { return -32601 }
+
+func (c *noBlockReceiptsClient) BlockReceipts(context.Context, *big.Int) ([]*types.Receipt, error) {
+	c.blockCalls.Add(1)
+	return nil, methodNotFoundError{}
+}
+
+func (c *noBlockReceiptsClient) TransactionReceipt(_ context.Context, hash common.Hash) (*types.Receipt, error) {
+	c.txCalls.Add(1)
+	if hash == c.failTx {
+		return nil, errors.New("timeout")
+	}
+	return &types.Receipt{TxHash: hash, Status: types.ReceiptStatusSuccessful, GasUsed: 21_000}, nil
+}
+
+func TestFetchBlockReceipts_FallsBackToPerTxReceipts(t *testing.T) {
+	block, txs := receiptTestBlock()
+	client := &noBlockReceiptsClient{}
+	f := &Fetcher{pool: client, fetchReceipts: true, metrics: GetMetrics()}
+
+	receipts := f.fetchBlockReceipts(context.Background(), block)
+	require.Len(t, receipts, len(txs))
+	for i, r := range receipts {
+		assert.Equal(t, txs[i].Hash(), r.TxHash, "receipts keep transaction order")
+	}
+	assert.True(t, f.blockReceiptsUnsupported.Load())
+
+	// 之后的区块直接逐笔获取，不再尝试 eth_getBlockReceipts
+	client.failTx = txs[1].Hash()
+	assert.Nil(t, f.fetchBlockReceipts(context.Background(), block), "a missing receipt discards the partial set")
+	assert.Equal(t, int32(1), client.blockCalls.Load())
+}
+
+func TestIsMethodUnsupported(t *testing.T) {
+	assert.True(t, isMethodUnsupported(methodNotFoundError{}))
+	assert.True(t, isMethodUnsupported(errors.New("Method not found")))
+	assert.False(t, isMethodUnsupported(errors.New("execution timeout")))
+	assert.False(t, isMethodUnsupported(nil))
+}
+
+// receiptBlockClient 每个区块都带交易、无日志，回执按区块交易生成
+type receiptBlockClient struct {
+	lossyLogClient
+	txs          []*types.Transaction
+	receiptCalls atomic.Int32
+}
+
+func (c *receiptBlockClient) BlockByNumber(_ context.Context, n *big.Int) (*types.Block, error) {
+	return testkit.BlockWithTxs(n.Uint64(), common.Hash{}, c.txs), nil
+}
+
+func (c *receiptBlockClient) BlockReceipts(context.Context, *big.Int) ([]*types.Receipt, error) {
+	c.receiptCalls.Add(1)
+	receipts := make([]*types.Receipt, len(c.txs))
+	for i, tx := range c.txs {
+		receipts[i] = &types.Receipt{TxHash: tx.Hash(), Status: types.ReceiptStatusSuccessful, GasUsed: 21_000}
+	}
+	return receipts, nil
+}
+
+// TestFetchRange_ReceiptsForBlocksWithoutLogs 回执模式下没有日志的区块同样带完整区块与回执（receipts / block_fees 不留空洞）
+func TestFetchRange_ReceiptsForBlocksWithoutLogs(t *testing.T) {
+	_, txs := receiptTestBlock()
+	client := &receiptBlockClient{txs: txs}
+	f := NewFetcher(client, 1)
+	f.fetchReceipts = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := f.fetchRangeWithLogs(ctx, big.NewInt(1), big.NewInt(4))
+		done <- err
+	}()
+	for want := uint64(1); want <= 4; want++ {
+		data := <-f.Results
+		require.NoError(t, data.Err)
+		require.NotNil(t, data.Block, "block %d", want)
+		assert.Empty(t, data.Logs)
+		require.Len(t, data.Receipts, len(txs), "block %d", want)
+	}
+	require.NoError(t, <-done)
+	assert.Equal(t, int32(4), client.receiptCalls.Load())
+}
@@ -3,11 +3,14 @@ package engine
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/big"
 	"strings"
-	"web3-indexer-go/internal/models"
+	"time"
+
+	"web3-indexer-go/internal/storage"
 )
 
 // FindCommonAncestor 递归查找共同祖先（处理深度重组）
@@ -27,11 +30,8 @@ func (p *Processor) FindCommonAncestor(ctx context.Context, blockNum *big.Int) (
 		}
 
 		// 查询本地数据库中相同高度的区块
-		var localBlock models.Block
-		err = p.db.GetContext(ctx, &localBlock,
-			"SELECT hash FROM blocks WHERE number = $1", currentNum.String())
-
-		if err == sql.ErrNoRows {
+		localHash, err := p.store.GetBlockHash(ctx, currentNum.String())
+		if errors.Is(err, sql.ErrNoRows) {
 			// 本地没有这个区块，继续往前找
 			toDelete = append(toDelete, new(big.Int).Set(currentNum))
 			currentNum.Sub(currentNum, big.NewInt(1))
@@ -42,13 +42,13 @@ func (p *Processor) FindCommonAncestor(ctx context.Context, blockNum *big.Int) (
 		}
 
 		// 检查哈希是否匹配
-		if strings.EqualFold(localBlock.Hash, rpcBlock.Hash().Hex()) {
+		if strings.EqualFold(localHash, rpcBlock.Hash().Hex()) {
 			// 找到共同祖先！
 			Logger.Info("common_ancestor_found",
 				slog.String("block", currentNum.String()),
-				slog.String("hash", localBlock.Hash),
+				slog.String("hash", localHash),
 			)
-			return currentNum, localBlock.Hash, toDelete, nil
+			return currentNum, localHash, toDelete, nil
 		}
 
 		// 哈希不匹配，这个区块也在重组链上，需要删除
@@ -65,16 +65,19 @@ func (p *Processor) FindCommonAncestor(ctx context.Context, blockNum *big.Int) (
 // HandleDeepReorg 处理深度重组（超过1个块的重组）
 // 调用此函数前必须停止Fetcher并清空其队列
 func (p *Processor) HandleDeepReorg(ctx context.Context, blockNum *big.Int) (*big.Int, error) {
+	reorgTimer.detect(blockNum.Uint64(), time.Now())
+
 	// 查找共同祖先
 	ancestorNum, _, toDelete, err := p.FindCommonAncestor(ctx, blockNum)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find common ancestor: %w", err)
 	}
+	reorgTimer.phase(reorgPhaseAncestorFound, time.Now())
 
 	LogReorgHandled(len(toDelete), ancestorNum.String())
 
 	// 在单个事务内执行回滚（保证原子性）
-	dbTx, err := p.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	dbTx, err := p.db.BeginTxx(ctx, writeTxOptions())
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin reorg transaction: %w", err)
 	}
@@ -83,8 +86,13 @@ func (p *Processor) HandleDeepReorg(ctx context.Context, blockNum *big.Int) (*bi
 			Logger.Warn("reorg_rollback_failed", "err", err)
 		}
 	}()
+	if lock := ActiveWriteLock(); lock != nil {
+		if err := lock.Guard(ctx, dbTx); err != nil {
+			return nil, fmt.Errorf("reorg rollback refused: %w", err)
+		}
+	}
 
-	// 批量删除所有分叉区块（cascade 会自动删除 transfers）
+	// 批量删除所有分叉区块及其转账
 	if len(toDelete) > 0 {
 		// 找到最小的要删除的块号
 		minDelete := toDelete[0]
@@ -93,22 +101,14 @@ func (p *Processor) HandleDeepReorg(ctx context.Context, blockNum *big.Int) (*bi
 				minDelete = num
 			}
 		}
-		// 删除所有 >= minDelete 的块（更高效）
-		_, err := dbTx.ExecContext(ctx, "DELETE FROM blocks WHERE number >= $1", minDelete.String())
-		if err != nil {
+		// 删除所有 >= minDelete 的块（同一事务内先撤销这些区块对日统计的贡献，避免派生表与回滚后的 blocks 不一致）
+		if err := p.store.RollbackTx(ctx, dbTx, minDelete.String()); err != nil {
 			return nil, fmt.Errorf("failed to delete reorg blocks: %w", err)
 		}
 	}
 
 	// 更新 checkpoint 回退到祖先高度
-	_, err = dbTx.ExecContext(ctx, `
-		INSERT INTO sync_checkpoints (chain_id, last_synced_block)
-		VALUES ($1, $2)
-		ON CONFLICT (chain_id) DO UPDATE SET
-			last_synced_block = EXCLUDED.last_synced_block,
-			updated_at = NOW()
-	`, 1, ancestorNum.String())
-	if err != nil {
+	if err := storage.UpsertCheckpointTx(ctx, dbTx, p.chainID, ancestorNum.Int64()); err != nil {
 		return nil, fmt.Errorf("failed to update checkpoint during reorg: %w", err)
 	}
 
@@ -116,6 +116,10 @@ func (p *Processor) HandleDeepReorg(ctx context.Context, blockNum *big.Int) (*bi
 	if err := dbTx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit reorg transaction: %w", err)
 	}
+	reorgTimer.phase(reorgPhaseRollbackCommitted, time.Now())
+	if p.metrics != nil {
+		p.metrics.RecordReorgHandled(int(new(big.Int).Sub(blockNum, ancestorNum).Int64()))
+	}
 
 	// 🔥 SSOT: 通过 Orchestrator 强制重置游标 (单一控制面)
 	GetOrchestrator().Dispatch(CmdResetCursor, ancestorNum.Uint64())
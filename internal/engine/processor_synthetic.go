@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"web3-indexer-go/internal/models"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// maxPendingSynthesized 合成转账待挂接队列上限，超出后丢弃最旧的记录
+	maxPendingSynthesized = 4096
+	// synthesizedLogIndexBase 合成转账的 log_index 起点，避开真实日志与 Anvil mock (9999x)
+	synthesizedLogIndexBase = 1_000_000
+)
+
+// synthesizedQueue 缓存模拟器产出的合成转账，等待下一个被处理的区块挂接
+type synthesizedQueue struct {
+	mu      sync.Mutex
+	pending []*SynthesizedTransfer
+}
+
+func (q *synthesizedQueue) push(t *SynthesizedTransfer) (dropped int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, t)
+	if over := len(q.pending) - maxPendingSynthesized; over > 0 {
+		q.pending = q.pending[over:]
+		dropped = over
+	}
+	return dropped
+}
+
+func (q *synthesizedQueue) drain() []*SynthesizedTransfer {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := q.pending
+	q.pending = nil
+	return out
+}
+
+// ConsumeSynthesized 接管模拟器（DeFiSimulator / SyntheticTransferInjector）的输出通道。
+// 合成转账不再旁路写库，而是挂接到下一个处理的区块，随正常的 Orchestrator 落盘与 DataSink 分发，
+// 并以 synthesized=true 标记，便于查询时与链上真实数据隔离。
+func (p *Processor) ConsumeSynthesized(ctx context.Context, ch <-chan *SynthesizedTransfer) {
+	p.synthesized.CompareAndSwap(nil, &synthesizedQueue{})
+	q := p.synthesized.Load()
+	Logger.Info("🏭 [Processor] Synthesized transfers routed through pipeline",
+		slog.Int("max_pending", maxPendingSynthesized))
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case t, ok := <-ch:
+				if !ok {
+					return
+				}
+				if t == nil {
+					continue
+				}
+				if dropped := q.push(t); dropped > 0 {
+					p.metrics.RecordSynthesizedTransfers("dropped", dropped)
+				}
+			}
+		}
+	}()
+}
+
+// takeSynthesized 取出待挂接的合成转账并转换为当前区块的 Transfer
+// （区块号统一改写为当前区块，保证外键与 block_number 索引一致）
+func (p *Processor) takeSynthesized(block *types.Block) []models.Transfer {
+	q := p.synthesized.Load()
+	if q == nil || block == nil {
+		return nil
+	}
+	pending := q.drain()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	out := make([]models.Transfer, 0, len(pending))
+	for i, t := range pending {
+		out = append(out, synthesizedToTransfer(t, block, i))
+	}
+	p.metrics.RecordSynthesizedTransfers("attached", len(out))
+	return out
+}
+
+// synthesizedToTransfer 将合成转账转换为挂接在 block 上的 models.Transfer
+func synthesizedToTransfer(t *SynthesizedTransfer, block *types.Block, idx int) models.Transfer {
+	activityType := t.Type
+	if activityType == "" {
+		activityType = "TRANSFER"
+	}
+	return models.Transfer{
+		BlockNumber:  models.BigInt{Int: block.Number()},
+		TxHash:       t.TxHash.Hex(),
+		LogIndex:     uint(synthesizedLogIndexBase + idx), // #nosec G115 - idx bounded by maxPendingSynthesized
+		From:         strings.ToLower(t.From.Hex()),
+		To:           strings.ToLower(t.To.Hex()),
+		Amount:       models.NewUint256FromBigInt(t.Amount),
+		TokenAddress: strings.ToLower(t.TokenAddress.Hex()),
+		Symbol:       t.Symbol,
+		Type:         activityType,
+		Synthesized:  true,
+	}
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"web3-indexer-go/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSynthesizedQueue_DropsOldest(t *testing.T) {
+	q := &synthesizedQueue{}
+	dropped := 0
+	for i := 0; i < maxPendingSynthesized+3; i++ {
+		dropped += q.push(&SynthesizedTransfer{BlockNumber: uint64(i)})
+	}
+	assert.Equal(t, 3, dropped)
+
+	pending := q.drain()
+	assert.Len(t, pending, maxPendingSynthesized)
+	assert.Equal(t, uint64(3), pending[0].BlockNumber)
+	assert.Empty(t, q.drain())
+}
+
+func TestTakeSynthesized_AttachesToProcessedBlock(t *testing.T) {
+	p := &Processor{metrics: GetMetrics()}
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(42)})
+	assert.Nil(t, p.takeSynthesized(block), "no queue until ConsumeSynthesized is called")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan *SynthesizedTransfer, 2)
+	p.ConsumeSynthesized(ctx, ch)
+
+	ch <- &SynthesizedTransfer{
+		TxHash:       common.HexToHash("0x01"),
+		BlockNumber:  99, // 模拟器看到的链头，与实际处理的区块不同
+		TokenAddress: common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+		From:         common.HexToAddress("0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0"),
+		To:           common.HexToAddress("0xE592427A0AEce92De3Edee1F18E0157C05861564"),
+		Amount:       big.NewInt(1000),
+		Symbol:       "USDC",
+		Type:         "SWAP",
+		Synthesized:  true,
+	}
+	ch <- &SynthesizedTransfer{TxHash: common.HexToHash("0x02"), Amount: big.NewInt(1)}
+
+	assert.Eventually(t, func() bool {
+		q := p.synthesized.Load()
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return len(q.pending) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	transfers := p.takeSynthesized(block)
+	assert.Len(t, transfers, 2)
+	assert.Equal(t, "42", transfers[0].BlockNumber.String())
+	assert.True(t, transfers[0].Synthesized)
+	assert.Equal(t, "SWAP", transfers[0].Type)
+	assert.Equal(t, "USDC", transfers[0].Symbol)
+	assert.Equal(t, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", transfers[0].TokenAddress)
+	assert.Equal(t, uint(synthesizedLogIndexBase), transfers[0].LogIndex)
+	assert.Equal(t, "TRANSFER", transfers[1].Type)
+	assert.Equal(t, uint(synthesizedLogIndexBase+1), transfers[1].LogIndex)
+
+	assert.Empty(t, p.takeSynthesized(block), "queue is drained after attaching")
+}
+
+func TestSyntheticFallback_DefaultsAndFlag(t *testing.T) {
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(7)})
+
+	assert.True(t, NewProcessor(nil, nil, 1, 31337, true, networkAnvil).syntheticFallback)
+	assert.False(t, NewProcessor(nil, nil, 1, 31337, false, networkAnvil).syntheticFallback)
+
+	p := NewProcessor(nil, nil, 1, 11155111, false, "sepolia")
+	assert.False(t, p.syntheticFallback, "off outside Anvil by default")
+	assert.Empty(t, p.processAnvilSyntheticNoDB(context.Background(), block.Number(), block, nil))
+	var batch []models.Transfer
+	p.processBatchSynthetic(block, &batch)
+	assert.Empty(t, batch)
+
+	p.SetSyntheticFallback(true)
+	rows := p.processAnvilSyntheticNoDB(context.Background(), block.Number(), block, nil)
+	assert.NotEmpty(t, rows)
+	for _, r := range rows {
+		assert.True(t, r.Synthesized)
+	}
+	p.processBatchSynthetic(block, &batch)
+	assert.Len(t, batch, 1)
+	assert.True(t, batch[0].Synthesized)
+}
@@ -34,22 +34,35 @@ func (p *Processor) ProcessLog(vLog types.Log) *models.Transfer {
 	if len(vLog.Topics) == 0 {
 		return nil
 	}
+	// 代币过滤放在解析与元数据查询之前，被拒绝的垃圾代币不会触发 enricher 抓取
+	if ok, reason := p.tokenFilter.Allows(vLog.Address); !ok {
+		p.metrics.RecordTokenFiltered(reason)
+		return nil
+	}
+	if p.spam.IsMuted(vLog.Address) {
+		p.metrics.RecordTokenFiltered(tokenFilterSpam)
+		return nil
+	}
+	// ERC-721 转账的第三个参数是 tokenId 而非金额，由 extractNFTTransfers 写入 nft_transfers
+	if isERC721Transfer(vLog) {
+		return nil
+	}
 
-	var activityType string
+	var activityType, origin string
 	from := ""
 	to := ""
 	var amount models.Uint256
 
 	switch vLog.Topics[0] {
 	case TransferEventHash:
-		activityType = "TRANSFER"
 		if len(vLog.Topics) >= 3 {
 			from = common.BytesToAddress(vLog.Topics[1].Bytes()).Hex()
 			to = common.BytesToAddress(vLog.Topics[2].Bytes()).Hex()
 		}
 		amount = models.NewUint256FromBigInt(new(big.Int).SetBytes(vLog.Data))
+		activityType = p.transferActivityType(from, to)
 
-	case SwapEventHash:
+	case SwapEventHash, SwapV2EventHash:
 		activityType = "SWAP"
 		if len(vLog.Topics) >= 3 {
 			from = common.BytesToAddress(vLog.Topics[1].Bytes()).Hex()
@@ -73,7 +86,32 @@ func (p *Processor) ProcessLog(vLog types.Log) *models.Transfer {
 		}
 		amount = models.NewUint256FromBigInt(new(big.Int).SetBytes(vLog.Data))
 
+	case DepositEventHash, WithdrawalEventHash:
+		// WETH 包装 / 解包不发 Transfer：按与零地址之间的等额转账入库，包装资产余额与供应量随之一致。
+		// 其他合约的同名事件（如金库存取）不入库
+		account, wad, ok := decodeWrapEvent(vLog)
+		if !ok || !GetChainProfile(p.chainID).IsWrappedNative(vLog.Address) {
+			return nil
+		}
+		amount = models.NewUint256FromBigInt(wad)
+		if vLog.Topics[0] == DepositEventHash {
+			activityType = models.ActivityWrap
+			from, to = common.Address{}.Hex(), account.Hex()
+		} else {
+			activityType = models.ActivityUnwrap
+			from, to = account.Hex(), common.Address{}.Hex()
+		}
+
 	default:
+		// 🌉 配置的 Transfer 等价事件（桥 / 包装资产），按标准转账入库并带 origin 标签
+		if tag, ok := p.extraTransferTopics[vLog.Topics[0]]; ok {
+			if f, t, v, ok := decodeTransferLike(vLog); ok {
+				from, to, origin = f.Hex(), t.Hex(), tag
+				amount = models.NewUint256FromBigInt(v)
+				activityType = p.transferActivityType(from, to)
+				break
+			}
+		}
 		// 🚀 记录为通用合约交互
 		activityType = "CONTRACT_EVENT"
 		from = vLog.Address.Hex()
@@ -90,6 +128,7 @@ func (p *Processor) ProcessLog(vLog types.Log) *models.Transfer {
 		Amount:       amount,
 		TokenAddress: strings.ToLower(vLog.Address.Hex()),
 		Type:         activityType,
+		Origin:       origin,
 	}
 
 	// 🚀 核心：识别已知实体（如领水）
@@ -123,6 +162,17 @@ func (p *Processor) ProcessLog(vLog types.Log) *models.Transfer {
 	return activity
 }
 
+// transferActivityType 按链约定识别销毁（转入零地址 / 0x...dEaD）与铸造（从零地址转出），其余为普通转账
+func (p *Processor) transferActivityType(from, to string) string {
+	switch {
+	case to != "" && GetChainProfile(p.chainID).IsBurnAddress(to):
+		return models.ActivityBurn
+	case from != "" && common.HexToAddress(from) == (common.Address{}):
+		return models.ActivityMint
+	}
+	return models.ActivityTransfer
+}
+
 // ProcessTransaction 扫描原始交易以发现部署或原生 ETH 转账
 func (p *Processor) ProcessTransaction(_ *big.Int, _ types.Transactions, _ int64) []models.Transfer {
 	activities := []models.Transfer{}
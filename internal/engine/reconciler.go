@@ -6,12 +6,15 @@ import (
 	"math/big"
 	"time"
 
+	"web3-indexer-go/internal/storage"
+
 	"github.com/jmoiron/sqlx"
 )
 
 // Reconciler 负责对已索引数据进行终态一致性审计
 type Reconciler struct {
 	db      *sqlx.DB
+	store   *storage.Postgres
 	rpcPool RPCClient
 	metrics *Metrics
 	logger  *slog.Logger
@@ -20,6 +23,7 @@ type Reconciler struct {
 func NewReconciler(db *sqlx.DB, rpcPool RPCClient, metrics *Metrics) *Reconciler {
 	return &Reconciler{
 		db:      db,
+		store:   NewStore(db),
 		rpcPool: rpcPool,
 		metrics: metrics,
 		logger:  Logger,
@@ -46,8 +50,7 @@ func (r *Reconciler) StartPeriodicAudit(ctx context.Context, interval time.Durat
 
 func (r *Reconciler) performAudit(ctx context.Context, lookback int64) {
 	// 1. 获取本地最高块号
-	var maxNum int64
-	err := r.db.GetContext(ctx, &maxNum, "SELECT COALESCE(MAX(number), 0) FROM blocks")
+	maxNum, err := r.store.GetMaxStoredBlock(ctx)
 	if err != nil || maxNum == 0 {
 		return
 	}
@@ -80,8 +83,7 @@ func (r *Reconciler) auditBlock(ctx context.Context, number *big.Int) {
 	}
 
 	// 获取 DB 哈希
-	var dbHash string
-	err = r.db.GetContext(ctx, &dbHash, "SELECT hash FROM blocks WHERE number = $1", number.String())
+	dbHash, err := r.store.GetBlockHash(ctx, number.String())
 	if err != nil {
 		r.logger.Error("🚨 AUDIT_DATA_MISSING", slog.String("block", number.String()))
 		return
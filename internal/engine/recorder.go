@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -27,9 +28,9 @@ func NewDataRecorder(path string) (*DataRecorder, error) {
 		path = fmt.Sprintf("logs/sepolia_capture_%s.jsonl", timestamp)
 	}
 
-	// 🛡️ 确保 logs 目录存在（防止 Docker 容器启动时报错）
+	// 🛡️ 确保录制目录存在（防止 Docker 容器启动时报错）
 	// 🛡️ Security: Use 0750 for stricter directory permissions (gosec G301)
-	if err := os.MkdirAll("logs", 0o750); err != nil {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
 		return nil, fmt.Errorf("failed_to_create_logs_dir: %w", err)
 	}
 
@@ -0,0 +1,25 @@
+package engine
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetcherCaptureIsOptIn(t *testing.T) {
+	f := NewFetcher(&lossyLogClient{}, 1)
+	assert.Nil(t, f.recorder, "fetchers do not record unless asked to")
+
+	path := filepath.Join(t.TempDir(), "captures", "run.jsonl")
+	require.NoError(t, f.EnableCapture(path))
+	f.recorder.Record("block_data", BlockData{Number: big.NewInt(7)})
+	require.NoError(t, f.recorder.Close())
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"type":"block_data"`)
+}
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Reorg 恢复阶段（均自检测时刻起计时）
+const (
+	reorgPhaseAncestorFound     = "ancestor_found"
+	reorgPhaseRollbackCommitted = "rollback_committed"
+	reorgPhaseResumed           = "resumed"
+)
+
+// reorgTracker 跟踪当前 reorg 的恢复进度：detect → ancestor found → rollback committed → resumed
+// Sequencer（检测/恢复）与 Processor（找祖先/回滚）共享同一个实例
+type reorgTracker struct {
+	active     atomic.Bool // 快速路径：无进行中的 reorg 时 resumed 不加锁
+	mu         sync.Mutex
+	at         uint64
+	detectedAt time.Time
+	observed   map[string]bool
+}
+
+var reorgTimer = &reorgTracker{}
+
+// detect 开始计时；已有进行中的 reorg 时保留最早的检测时刻（连续 reorg 视为一次恢复）
+func (t *reorgTracker) detect(at uint64, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.active.Load() {
+		if at < t.at {
+			t.at = at
+		}
+		return
+	}
+	t.at = at
+	t.detectedAt = now
+	t.observed = make(map[string]bool)
+	t.active.Store(true)
+}
+
+// phase 记录某阶段自检测起的耗时，每次 reorg 每阶段只记录一次
+func (t *reorgTracker) phase(name string, now time.Time) {
+	if !t.active.Load() {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.active.Load() || t.observed[name] {
+		return
+	}
+	t.observed[name] = true
+	GetMetrics().RecordReorgPhase(name, now.Sub(t.detectedAt))
+}
+
+// resumed Sequencer 成功处理到 reorg 高度时调用，记录总恢复耗时并结束计时
+func (t *reorgTracker) resumed(height uint64, now time.Time) {
+	if !t.active.Load() {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.active.Load() || height < t.at {
+		return
+	}
+	GetMetrics().RecordReorgPhase(reorgPhaseResumed, now.Sub(t.detectedAt))
+	t.active.Store(false)
+}
@@ -0,0 +1,36 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReorgTracker_PhasesAndResume(t *testing.T) {
+	tr := &reorgTracker{}
+	t0 := time.Now()
+
+	tr.detect(100, t0)
+	assert.True(t, tr.active.Load())
+
+	// 连续检测保留最早时刻与最低高度
+	tr.detect(98, t0.Add(time.Second))
+	assert.Equal(t, uint64(98), tr.at)
+	assert.Equal(t, t0, tr.detectedAt)
+
+	tr.phase(reorgPhaseAncestorFound, t0.Add(2*time.Second))
+	tr.phase(reorgPhaseAncestorFound, t0.Add(3*time.Second))
+	assert.True(t, tr.observed[reorgPhaseAncestorFound])
+
+	// 尚未处理到 reorg 高度：不结束
+	tr.resumed(97, t0.Add(4*time.Second))
+	assert.True(t, tr.active.Load())
+
+	tr.resumed(98, t0.Add(5*time.Second))
+	assert.False(t, tr.active.Load())
+
+	// 结束后阶段记录为空操作
+	tr.phase(reorgPhaseRollbackCommitted, t0.Add(6*time.Second))
+	assert.False(t, tr.observed[reorgPhaseRollbackCommitted])
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// ReplayChaos 回放投递扰动：人为延迟、抖动与窗口内乱序，
+// 让回放经过 Sequencer 的缓冲与缺口逻辑，而不只是按序消费的理想路径
+type ReplayChaos struct {
+	Latency       time.Duration // 每块固定投递延迟
+	Jitter        time.Duration // 额外随机延迟 [0, Jitter)
+	ReorderWindow int           // 乱序窗口：从最近读出的 N 块中随机挑一块先投递（<= 1 保持顺序）
+	Seed          int64         // 随机种子（0 取当前时间）；固定种子可复现同一投递顺序
+}
+
+// Enabled 是否配置了任何扰动
+func (c ReplayChaos) Enabled() bool {
+	return c.Latency > 0 || c.Jitter > 0 || c.ReorderWindow > 1
+}
+
+// chaosShuffler 按 ReplayChaos 重排并延迟区块投递（非并发安全，由回放源的推送循环独占）
+type chaosShuffler struct {
+	cfg     ReplayChaos
+	rng     *rand.Rand
+	pending []BlockData
+}
+
+func newChaosShuffler(cfg ReplayChaos) *chaosShuffler {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	// #nosec G404 - 回放扰动只需可复现的伪随机
+	return &chaosShuffler{cfg: cfg, rng: rand.New(rand.NewSource(seed))}
+}
+
+// push 放入一块；窗口填满时随机取出一块返回
+func (c *chaosShuffler) push(bd BlockData) (BlockData, bool) {
+	c.pending = append(c.pending, bd)
+	if len(c.pending) < max(c.cfg.ReorderWindow, 1) {
+		return BlockData{}, false
+	}
+	return c.take(), true
+}
+
+// take 随机取出一块（调用方保证 pending 非空）
+func (c *chaosShuffler) take() BlockData {
+	i := c.rng.Intn(len(c.pending))
+	bd := c.pending[i]
+	last := len(c.pending) - 1
+	c.pending[i] = c.pending[last]
+	c.pending[last] = BlockData{}
+	c.pending = c.pending[:last]
+	return bd
+}
+
+// drain 文件结束时按随机顺序取出窗口内剩余的块
+func (c *chaosShuffler) drain() []BlockData {
+	out := make([]BlockData, 0, len(c.pending))
+	for len(c.pending) > 0 {
+		out = append(out, c.take())
+	}
+	return out
+}
+
+// delay 本次投递前的等待时长
+func (c *chaosShuffler) delay() time.Duration {
+	d := c.cfg.Latency
+	if c.cfg.Jitter > 0 {
+		d += time.Duration(c.rng.Int63n(int64(c.cfg.Jitter)))
+	}
+	return d
+}
+
+// reset 丢弃窗口内尚未投递的块（回放源 Reset 时调用）
+func (c *chaosShuffler) reset() {
+	c.pending = nil
+}
+
+// send 等待扰动延迟后把块推送到 out
+func (c *chaosShuffler) send(ctx context.Context, out chan<- BlockData, bd BlockData) error {
+	if d := c.delay(); d > 0 {
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+	select {
+	case out <- bd:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
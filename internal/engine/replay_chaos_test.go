@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func shuffleBlocks(cfg ReplayChaos, from, n int64) []BlockData {
+	c := newChaosShuffler(cfg)
+	var out []BlockData
+	for i := from; i < from+n; i++ {
+		bd := BlockData{Number: big.NewInt(i), Block: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(i)})}
+		if next, ok := c.push(bd); ok {
+			out = append(out, next)
+		}
+	}
+	return append(out, c.drain()...)
+}
+
+func TestChaosShuffler_ReorderWithinWindow(t *testing.T) {
+	cfg := ReplayChaos{ReorderWindow: 4, Seed: 42}
+	out := shuffleBlocks(cfg, 100, 50)
+
+	require.Len(t, out, 50)
+	seen := make(map[int64]bool)
+	inOrder := true
+	for i, bd := range out {
+		n := bd.Number.Int64()
+		seen[n] = true
+		assert.LessOrEqual(t, n-100, int64(i+cfg.ReorderWindow-1), "block %d delivered earlier than the window allows", n)
+		if n != int64(100+i) {
+			inOrder = false
+		}
+	}
+	assert.Len(t, seen, 50, "every block is delivered exactly once")
+	assert.False(t, inOrder)
+	assert.Equal(t, out, shuffleBlocks(cfg, 100, 50), "same seed reproduces the delivery order")
+
+	ordered := shuffleBlocks(ReplayChaos{Latency: time.Millisecond}, 0, 5)
+	for i, bd := range ordered {
+		assert.EqualValues(t, i, bd.Number.Int64())
+	}
+}
+
+func TestChaosShuffler_Delay(t *testing.T) {
+	assert.False(t, ReplayChaos{ReorderWindow: 1}.Enabled())
+	c := newChaosShuffler(ReplayChaos{Latency: 10 * time.Millisecond, Jitter: 5 * time.Millisecond, Seed: 1})
+	for i := 0; i < 20; i++ {
+		d := c.delay()
+		assert.GreaterOrEqual(t, d, 10*time.Millisecond)
+		assert.Less(t, d, 15*time.Millisecond)
+	}
+}
+
+func TestChaosShuffler_SequencerRestoresOrder(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ch := make(chan BlockData, 64)
+	seq := NewSequencer(&MockProcessor{}, big.NewInt(100), 1, ch, make(chan error, 1), nil)
+	c := newChaosShuffler(ReplayChaos{ReorderWindow: 8, Jitter: time.Millisecond, Seed: 7})
+	go func() {
+		defer close(ch)
+		for _, bd := range shuffleBlocks(ReplayChaos{ReorderWindow: 8, Seed: 7}, 100, 40) {
+			if err := c.send(ctx, ch, bd); err != nil {
+				return
+			}
+		}
+	}()
+
+	seq.Run(ctx)
+	assert.Equal(t, "140", seq.GetExpectedBlock().String())
+	assert.Zero(t, seq.GetBufferSize())
+}
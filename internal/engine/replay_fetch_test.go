@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replaySourceClient 按区块号返回固定区块头与日志；failAt 指定的区块头请求失败（模拟中途断线）
+type replaySourceClient struct {
+	lossyLogClient
+	logs   []types.Log
+	failAt uint64
+}
+
+func (c *replaySourceClient) HeaderByNumber(_ context.Context, n *big.Int) (*types.Header, error) {
+	if c.failAt != 0 && n.Uint64() == c.failAt {
+		return nil, errors.New("connection reset")
+	}
+	return &types.Header{Number: new(big.Int).Set(n), Time: 1700000000 + n.Uint64()*12, Difficulty: big.NewInt(0)}, nil
+}
+
+func (c *replaySourceClient) FilterLogs(_ context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	var out []types.Log
+	for _, l := range c.logs {
+		if l.BlockNumber >= q.FromBlock.Uint64() && l.BlockNumber <= q.ToBlock.Uint64() {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func replayTestLog(block uint64, index uint) types.Log {
+	l := testkit.ERC20Transfer(common.HexToAddress("0x1"), common.HexToAddress("0xa"), common.HexToAddress("0xb"), big.NewInt(1000))
+	l.BlockNumber, l.TxHash, l.Index = block, testkit.TxHash(block, 0), index
+	return l
+}
+
+func TestReplayFetcher_ResumeAfterFailure(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "history.jsonl.lz4")
+	client := &replaySourceClient{
+		logs:   []types.Log{replayTestLog(5, 0), replayTestLog(5, 1), replayTestLog(7, 0)},
+		failAt: 8,
+	}
+	fetcher := &ReplayFetcher{Client: client, Out: out, From: 1, To: 10, Batch: 3}
+
+	// 第一次：在第 3 个分片（7-9）失败，断点停在 7
+	require.Error(t, fetcher.Run(context.Background()))
+	progress, err := fetcher.loadProgress()
+	require.NoError(t, err)
+	require.NotNil(t, progress)
+	assert.EqualValues(t, 7, progress.NextBlock)
+
+	// 续传：范围不一致的断点被拒绝，原范围正常完成并删除断点文件
+	_, err = (&ReplayFetcher{Out: out, From: 1, To: 20}).loadProgress()
+	assert.Error(t, err)
+
+	client.failAt = 0
+	require.NoError(t, fetcher.Run(context.Background()))
+	_, err = os.Stat(out + ".progress")
+	assert.True(t, os.IsNotExist(err))
+
+	source, err := NewLz4ReplaySource(out, 0)
+	require.NoError(t, err)
+	defer source.Close()
+
+	ch := make(chan BlockData, 32)
+	require.NoError(t, source.StreamBlocks(context.Background(), ch))
+	close(ch)
+
+	var numbers []uint64
+	logCount := map[uint64]int{}
+	for bd := range ch {
+		require.NotNil(t, bd.Block, "block %s should be rebuilt from its header", bd.Number)
+		assert.Equal(t, 1700000000+bd.Number.Uint64()*12, bd.Block.Time())
+		numbers = append(numbers, bd.Number.Uint64())
+		logCount[bd.Number.Uint64()] = len(bd.Logs)
+	}
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, numbers, "no duplicates or gaps across the resumed frame boundary")
+	assert.Equal(t, 2, logCount[5])
+	assert.Equal(t, 1, logCount[7])
+	assert.Zero(t, logCount[6])
+}
@@ -0,0 +1,574 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRPCFault 注入的故障：HTTPStatus 非 0 时整个 HTTP 请求以该状态码失败（如 429），否则返回 JSON-RPC 错误
+type mockRPCFault struct {
+	HTTPStatus int
+	Code       int
+	Message    string
+	remaining  int // 剩余次数，<0 表示永久
+}
+
+// mockRPCServer 基于 httptest 的可编程 JSON-RPC 节点（区块、日志、故障、延迟），
+// 用于在没有 Anvil / 网络的情况下测试 Fetcher 与 RPC 节点池行为。支持 JSON-RPC 批量请求。
+type mockRPCServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	chainID  uint64
+	headers  map[uint64]*types.Header
+	head     uint64
+	logs     []types.Log
+	latency  time.Duration
+	faults   map[string]*mockRPCFault
+	calls    map[string]int
+	requests int
+	traces   map[uint64][]TxCallTrace // 非 nil 时支持 debug_traceBlockByNumber
+}
+
+type mockRPCRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type mockRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type mockRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *mockRPCError   `json:"error,omitempty"`
+}
+
+// newMockRPCServer 启动一个带 0..head 连续区块的模拟节点，测试结束时自动关闭
+func newMockRPCServer(t *testing.T, head uint64) *mockRPCServer {
+	t.Helper()
+	m := &mockRPCServer{
+		chainID: 31337,
+		headers: make(map[uint64]*types.Header),
+		faults:  make(map[string]*mockRPCFault),
+		calls:   make(map[string]int),
+	}
+	m.ExtendTo(head)
+	m.Server = httptest.NewServer(http.HandlerFunc(m.serveHTTP))
+	t.Cleanup(m.Close)
+	return m
+}
+
+// ExtendTo 追加区块直到 head（父哈希首尾相连）
+func (m *mockRPCServer) ExtendTo(head uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for n := uint64(len(m.headers)); n <= head; n++ {
+		var parent common.Hash
+		if n > 0 {
+			parent = m.headers[n-1].Hash()
+		}
+		h := testkit.Header(n, parent)
+		m.headers[n] = h
+	}
+	if head > m.head {
+		m.head = head
+	}
+}
+
+// AddLog 在指定区块追加一条日志（自动补全区块哈希与日志索引）
+func (m *mockRPCServer) AddLog(l types.Log) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if h, ok := m.headers[l.BlockNumber]; ok {
+		l.BlockHash = h.Hash()
+	}
+	for _, existing := range m.logs {
+		if existing.BlockNumber == l.BlockNumber {
+			l.Index++
+		}
+	}
+	m.logs = append(m.logs, l)
+}
+
+// Fail 让 method 接下来 times 次调用返回 fault（times < 0 表示永久）
+func (m *mockRPCServer) Fail(method string, times int, fault mockRPCFault) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fault.remaining = times
+	m.faults[method] = &fault
+}
+
+// SetTraces 开启 debug_traceBlockByNumber 支持，并设置 block 的调用树
+func (m *mockRPCServer) SetTraces(block uint64, traces []TxCallTrace) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.traces == nil {
+		m.traces = make(map[uint64][]TxCallTrace)
+	}
+	m.traces[block] = traces
+}
+
+// SetLatency 每个 HTTP 请求的人为延迟
+func (m *mockRPCServer) SetLatency(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latency = d
+}
+
+// Calls 返回 method 被调用的次数（批量请求中的每一项分别计数）
+func (m *mockRPCServer) Calls(method string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls[method]
+}
+
+// HTTPRequests 返回收到的 HTTP 请求数（一个批量请求计为 1）
+func (m *mockRPCServer) HTTPRequests() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.requests
+}
+
+func (m *mockRPCServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	batch := len(bytes.TrimSpace(body)) > 0 && bytes.TrimSpace(body)[0] == '['
+	var reqs []mockRPCRequest
+	if batch {
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		var req mockRPCRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		reqs = []mockRPCRequest{req}
+	}
+
+	m.mu.Lock()
+	m.requests++
+	latency := m.latency
+	for _, req := range reqs {
+		m.calls[req.Method]++
+		if f := m.faults[req.Method]; f != nil && f.HTTPStatus != 0 && m.takeFault(req.Method) != nil {
+			m.mu.Unlock()
+			http.Error(w, http.StatusText(f.HTTPStatus), f.HTTPStatus)
+			return
+		}
+	}
+	m.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	resps := make([]mockRPCResponse, len(reqs))
+	for i, req := range reqs {
+		resps[i] = m.handle(req)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if batch {
+		_ = json.NewEncoder(w).Encode(resps)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(resps[0])
+}
+
+// takeFault 消耗一次 method 的故障注入；调用方需持有 m.mu
+func (m *mockRPCServer) takeFault(method string) *mockRPCFault {
+	f := m.faults[method]
+	if f == nil || f.remaining == 0 {
+		return nil
+	}
+	if f.remaining > 0 {
+		f.remaining--
+	}
+	return f
+}
+
+func (m *mockRPCServer) handle(req mockRPCRequest) mockRPCResponse {
+	resp := mockRPCResponse{JSONRPC: "2.0", ID: req.ID}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if f := m.faults[req.Method]; f != nil && f.HTTPStatus == 0 && f.remaining != 0 {
+		m.takeFault(req.Method)
+		resp.Error = &mockRPCError{Code: f.Code, Message: f.Message}
+		return resp
+	}
+
+	switch req.Method {
+	case "eth_chainId":
+		resp.Result = hexutil.Uint64(m.chainID)
+	case "eth_blockNumber":
+		resp.Result = hexutil.Uint64(m.head)
+	case "eth_getBlockByNumber":
+		h := m.headerByArg(req.Params)
+		if h == nil {
+			resp.Result = json.RawMessage("null")
+			return resp
+		}
+		resp.Result = mockBlockJSON(h)
+	case "eth_getLogs":
+		logs, err := m.filterLogs(req.Params)
+		if err != nil {
+			resp.Error = &mockRPCError{Code: -32602, Message: err.Error()}
+			return resp
+		}
+		resp.Result = logs
+	case "eth_getBlockReceipts":
+		resp.Result = m.receiptsByArg(req.Params)
+	case "debug_traceBlockByNumber":
+		if m.traces == nil {
+			resp.Error = &mockRPCError{Code: -32601, Message: "the method debug_traceBlockByNumber does not exist/is not available"}
+			return resp
+		}
+		h := m.headerByArg(req.Params)
+		if h == nil {
+			resp.Error = &mockRPCError{Code: -32000, Message: "block not found"}
+			return resp
+		}
+		traces := m.traces[h.Number.Uint64()]
+		if traces == nil {
+			traces = []TxCallTrace{}
+		}
+		resp.Result = traces
+	default:
+		resp.Error = &mockRPCError{Code: -32601, Message: "method " + req.Method + " not supported by mock"}
+	}
+	return resp
+}
+
+// headerByArg 解析 "latest" / 十六进制区块号；调用方需持有 m.mu
+func (m *mockRPCServer) headerByArg(params []json.RawMessage) *types.Header {
+	if len(params) == 0 {
+		return nil
+	}
+	var tag string
+	if err := json.Unmarshal(params[0], &tag); err != nil {
+		return nil
+	}
+	switch tag {
+	case "latest", "pending", "safe", "finalized":
+		return m.headers[m.head]
+	}
+	n, err := hexutil.DecodeUint64(tag)
+	if err != nil || n > m.head {
+		return nil
+	}
+	return m.headers[n]
+}
+
+// receiptsByArg 按交易聚合该区块的日志生成回执，使抽样日志校验与 eth_getLogs 结果一致；调用方需持有 m.mu
+func (m *mockRPCServer) receiptsByArg(params []json.RawMessage) []*types.Receipt {
+	receipts := []*types.Receipt{}
+	h := m.headerByArg(params)
+	if h == nil {
+		return receipts
+	}
+	byTx := make(map[common.Hash]*types.Receipt)
+	for i := range m.logs {
+		l := m.logs[i]
+		if l.BlockNumber != h.Number.Uint64() {
+			continue
+		}
+		r, ok := byTx[l.TxHash]
+		if !ok {
+			r = &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: l.TxHash, BlockNumber: h.Number, Logs: []*types.Log{}}
+			byTx[l.TxHash] = r
+			receipts = append(receipts, r)
+		}
+		r.Logs = append(r.Logs, &l)
+	}
+	return receipts
+}
+
+// filterLogs 按区块范围、合约地址与 topic0 过滤；调用方需持有 m.mu
+func (m *mockRPCServer) filterLogs(params []json.RawMessage) ([]types.Log, error) {
+	var arg struct {
+		FromBlock string           `json:"fromBlock"`
+		ToBlock   string           `json:"toBlock"`
+		Address   []common.Address `json:"address"`
+		Topics    [][]common.Hash  `json:"topics"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params[0], &arg); err != nil {
+			return nil, err
+		}
+	}
+	from, to := uint64(0), m.head
+	if arg.FromBlock != "" && !strings.HasPrefix(arg.FromBlock, "l") {
+		v, err := hexutil.DecodeUint64(arg.FromBlock)
+		if err != nil {
+			return nil, err
+		}
+		from = v
+	}
+	if arg.ToBlock != "" && !strings.HasPrefix(arg.ToBlock, "l") {
+		v, err := hexutil.DecodeUint64(arg.ToBlock)
+		if err != nil {
+			return nil, err
+		}
+		to = v
+	}
+
+	out := []types.Log{}
+	for _, l := range m.logs {
+		if l.BlockNumber < from || l.BlockNumber > to {
+			continue
+		}
+		if len(arg.Address) > 0 && !containsAddress(arg.Address, l.Address) {
+			continue
+		}
+		if len(arg.Topics) > 0 && len(arg.Topics[0]) > 0 {
+			if len(l.Topics) == 0 || !containsHash(arg.Topics[0], l.Topics[0]) {
+				continue
+			}
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+func containsAddress(set []common.Address, a common.Address) bool {
+	for _, v := range set {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
+
+func containsHash(set []common.Hash, h common.Hash) bool {
+	for _, v := range set {
+		if v == h {
+			return true
+		}
+	}
+	return false
+}
+
+// mockBlockJSON 无交易区块的 JSON（Header 字段 + 空 transactions / uncles）
+func mockBlockJSON(h *types.Header) map[string]interface{} {
+	raw, _ := json.Marshal(h)
+	block := map[string]interface{}{}
+	_ = json.Unmarshal(raw, &block)
+	block["transactions"] = []interface{}{}
+	block["uncles"] = []interface{}{}
+	return block
+}
+
+func mockTransferLog(block uint64, token common.Address) types.Log {
+	l := testkit.ERC20Transfer(token, common.HexToAddress("0xa"), common.HexToAddress("0xb"), big.NewInt(1000))
+	l.BlockNumber, l.TxHash = block, testkit.TxHash(block, 0)
+	return l
+}
+
+func TestMockRPC_EnhancedPool429OpensBreaker(t *testing.T) {
+	limited := newMockRPCServer(t, 10)
+	healthy := newMockRPCServer(t, 10)
+
+	pool, err := NewEnhancedRPCClientPoolWithTimeout([]string{limited.URL, healthy.URL}, false, 10, 2*time.Second)
+	require.NoError(t, err)
+	defer pool.Close()
+	require.Equal(t, 2, pool.GetHealthyNodeCount())
+
+	limited.Fail("eth_getBlockByNumber", -1, mockRPCFault{HTTPStatus: http.StatusTooManyRequests})
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		header, err := pool.HeaderByNumber(ctx, big.NewInt(5))
+		require.NoError(t, err, "pool must fail over to the healthy node")
+		assert.Equal(t, uint64(5), header.Number.Uint64())
+	}
+
+	var limitedNode *rpcNode
+	for _, n := range pool.clients {
+		if n.url == limited.URL {
+			limitedNode = n
+		}
+	}
+	require.NotNil(t, limitedNode)
+	assert.False(t, limitedNode.isHealthy)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), limitedNode.retryAfter, 5*time.Second)
+	assert.Equal(t, 1, pool.GetHealthyNodeCount())
+
+	// 熔断期间不再打到被限流节点：初始化探测 1 次 + 触发熔断的 1 次
+	assert.Equal(t, 2, limited.Calls("eth_getBlockByNumber"))
+}
+
+func TestMockRPC_JSONRPCErrorTriggersBackoffNotBreaker(t *testing.T) {
+	srv := newMockRPCServer(t, 3)
+	pool, err := NewEnhancedRPCClientPoolWithTimeout([]string{srv.URL}, false, 10, 2*time.Second)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	q := ethereum.FilterQuery{FromBlock: big.NewInt(0), ToBlock: big.NewInt(3)}
+	srv.Fail("eth_getLogs", 1, mockRPCFault{Code: -32000, Message: "internal error"})
+	_, err = pool.FilterLogs(context.Background(), q)
+	require.Error(t, err)
+
+	node := pool.clients[0]
+	assert.Equal(t, 1, node.failCount)
+	assert.WithinDuration(t, time.Now().Add(time.Second), node.retryAfter, 2*time.Second)
+
+	// 故障只注入一次，退避结束后恢复
+	time.Sleep(time.Until(node.retryAfter) + 10*time.Millisecond)
+	logs, err := pool.FilterLogs(context.Background(), q)
+	require.NoError(t, err)
+	assert.Empty(t, logs)
+}
+
+func TestMockRPC_BatchAndLatency(t *testing.T) {
+	srv := newMockRPCServer(t, 20)
+	client, err := rpc.Dial(srv.URL)
+	require.NoError(t, err)
+	defer client.Close()
+
+	var head hexutil.Uint64
+	var block map[string]interface{}
+	batch := []rpc.BatchElem{
+		{Method: "eth_blockNumber", Result: &head},
+		{Method: "eth_getBlockByNumber", Args: []interface{}{"0x7", false}, Result: &block},
+		{Method: "eth_unknown", Result: new(interface{})},
+	}
+	require.NoError(t, client.BatchCallContext(context.Background(), batch))
+	assert.Equal(t, 1, srv.HTTPRequests(), "batch must travel in a single HTTP request")
+	assert.NoError(t, batch[0].Error)
+	assert.EqualValues(t, 20, head)
+	assert.NoError(t, batch[1].Error)
+	assert.Equal(t, "0x7", block["number"])
+	assert.Error(t, batch[2].Error)
+
+	srv.SetLatency(300 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = client.CallContext(ctx, &head, "eth_blockNumber")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMockRPC_FetcherRangeDeliversLogsInOrder(t *testing.T) {
+	srv := newMockRPCServer(t, 12)
+	token := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	srv.AddLog(mockTransferLog(3, token))
+	srv.AddLog(mockTransferLog(3, token))
+	srv.AddLog(mockTransferLog(7, token))
+
+	pool, err := NewRPCClientPoolWithTimeout([]string{srv.URL}, 2*time.Second)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	f := NewFetcher(pool, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sent, err := f.fetchRangeWithLogs(ctx, big.NewInt(1), big.NewInt(10))
+	require.NoError(t, err)
+	assert.Equal(t, 10, sent)
+
+	for want := uint64(1); want <= 10; want++ {
+		data := <-f.Results
+		require.NoError(t, data.Err)
+		assert.Equal(t, want, data.Number.Uint64())
+		require.NotNil(t, data.Block, "every block is delivered in full, with or without logs")
+		assert.Equal(t, want, data.Block.NumberU64())
+		switch want {
+		case 3:
+			assert.Len(t, data.Logs, 2)
+			assert.Equal(t, data.Block.Hash(), data.Logs[0].BlockHash)
+		case 7:
+			assert.Len(t, data.Logs, 1)
+		default:
+			assert.Empty(t, data.Logs)
+		}
+	}
+	assert.Equal(t, 1, srv.Calls("eth_getLogs"))
+	assert.Equal(t, 10, srv.Calls("eth_getBlockByNumber"), "the Fetcher hydrates every block; the Sequencer no longer refetches")
+}
+
+func TestMockRPC_RangeLimitErrorKeepsNodeHealthyAndSplits(t *testing.T) {
+	srv := newMockRPCServer(t, 10)
+	token := common.HexToAddress("0x1")
+	srv.AddLog(mockTransferLog(2, token))
+	srv.AddLog(mockTransferLog(9, token))
+	pool, err := NewEnhancedRPCClientPoolWithTimeout([]string{srv.URL}, false, 10, 2*time.Second)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	q := ethereum.FilterQuery{FromBlock: big.NewInt(1), ToBlock: big.NewInt(10)}
+	srv.Fail("eth_getLogs", 1, mockRPCFault{Code: -32005, Message: "query returned more than 10000 results"})
+	_, err = pool.FilterLogs(context.Background(), q)
+	require.Error(t, err)
+	assert.NotEmpty(t, logRangeLimitReason(err), "the provider message survives the pool")
+	assert.Zero(t, pool.clients[0].failCount, "oversized ranges are not held against the node")
+
+	f := NewFetcher(pool, 1)
+	splits := testutil.ToFloat64(GetMetrics().LogRangeSplits.WithLabelValues("result_limit"))
+	srv.Fail("eth_getLogs", 1, mockRPCFault{Code: -32005, Message: "query returned more than 10000 results"})
+	logs, _, err := f.filterLogsAdaptive(context.Background(), q)
+	require.NoError(t, err)
+	assert.Len(t, logs, 2)
+	assert.Equal(t, splits+1, testutil.ToFloat64(GetMetrics().LogRangeSplits.WithLabelValues("result_limit")))
+}
+
+func TestMockRPC_TraceSkipsNodesWithoutDebugNamespace(t *testing.T) {
+	plain := newMockRPCServer(t, 10)
+	archive := newMockRPCServer(t, 10)
+	traces := []TxCallTrace{{
+		TxHash: testkit.TxHash(5, 0),
+		Result: CallFrame{Type: "CALL", Calls: []CallFrame{{Type: "CALL", Value: (*hexutil.Big)(big.NewInt(1))}}},
+	}}
+	archive.SetTraces(5, traces)
+
+	pool, err := NewEnhancedRPCClientPoolWithTimeout([]string{plain.URL, archive.URL}, false, 10, 2*time.Second)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	for i := 0; i < 3; i++ {
+		got, err := pool.TraceBlockCalls(context.Background(), big.NewInt(5))
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, traces[0].TxHash, got[0].TxHash)
+	}
+	assert.LessOrEqual(t, plain.Calls("debug_traceBlockByNumber"), 1, "unsupported nodes are probed at most once")
+	assert.Equal(t, 2, pool.GetHealthyNodeCount(), "missing debug namespace is not a node failure")
+	assert.Equal(t, 1, pool.traceCapableNodes())
+
+	only, err := NewEnhancedRPCClientPoolWithTimeout([]string{plain.URL}, false, 10, 2*time.Second)
+	require.NoError(t, err)
+	defer only.Close()
+	_, err = only.TraceBlockCalls(context.Background(), big.NewInt(5))
+	require.ErrorIs(t, err, ErrTraceUnsupported)
+}
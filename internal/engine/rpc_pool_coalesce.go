@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// coalesce 合并 key 相同的在途请求（gap-fill 与追块并发请求同一区块时只发一次上游请求）。
+// 首个调用者以脱离取消的 ctx 发起请求，避免其取消连带失败其他等待者；
+// 每个调用者仍可因自己的 ctx 取消而提前返回。shared 为 true 时结果被多个调用者共享。
+func coalesce[T any](ctx context.Context, p *EnhancedRPCClientPool, method, key string, fn func(context.Context) (T, error)) (res T, shared bool, err error) {
+	leader := false
+	ch := p.inflight.DoChan(method+"|"+key, func() (interface{}, error) {
+		leader = true
+		return fn(context.WithoutCancel(ctx))
+	})
+
+	select {
+	case r := <-ch:
+		if !leader {
+			p.metrics.RecordRPCCoalesced(method)
+		}
+		if r.Err != nil {
+			return res, r.Shared, r.Err
+		}
+		return r.Val.(T), r.Shared, nil
+	case <-ctx.Done():
+		return res, false, ctx.Err()
+	}
+}
+
+func blockKey(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	return number.String()
+}
+
+// filterQueryKey FilterQuery 的规范化 key
+func filterQueryKey(q ethereum.FilterQuery) string {
+	var b strings.Builder
+	if q.BlockHash != nil {
+		b.WriteString(q.BlockHash.Hex())
+	}
+	fmt.Fprintf(&b, "|%s|%s|", blockKey(q.FromBlock), blockKey(q.ToBlock))
+	for _, a := range q.Addresses {
+		b.WriteString(a.Hex())
+		b.WriteByte(',')
+	}
+	for _, pos := range q.Topics {
+		b.WriteByte('|')
+		for _, t := range pos {
+			b.WriteString(t.Hex())
+			b.WriteByte(',')
+		}
+	}
+	return b.String()
+}
+
+// callKey eth_call 参数的规范化 key
+func callKey(msg ethereum.CallMsg, blockNumber *big.Int) string {
+	to := ""
+	if msg.To != nil {
+		to = msg.To.Hex()
+	}
+	value := ""
+	if msg.Value != nil {
+		value = msg.Value.String()
+	}
+	return fmt.Sprintf("%s|%s|%s|%d|%s|%s", msg.From.Hex(), to, hexutil.Encode(msg.Data), msg.Gas, value, blockKey(blockNumber))
+}
+
+func (p *EnhancedRPCClientPool) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	block, _, err := coalesce(ctx, p, "BlockByNumber", blockKey(number), func(ctx context.Context) (*types.Block, error) {
+		return p.fetchBlockByNumber(ctx, number)
+	})
+	return block, err
+}
+
+func (p *EnhancedRPCClientPool) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	header, shared, err := coalesce(ctx, p, "HeaderByNumber", blockKey(number), func(ctx context.Context) (*types.Header, error) {
+		return p.fetchHeaderByNumber(ctx, number)
+	})
+	if shared && header != nil {
+		header = types.CopyHeader(header) // Header 为可变结构，共享时各自持有副本
+	}
+	return header, err
+}
+
+func (p *EnhancedRPCClientPool) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	logs, shared, err := coalesce(ctx, p, "FilterLogs", filterQueryKey(q), func(ctx context.Context) ([]types.Log, error) {
+		return p.fetchFilterLogs(ctx, q)
+	})
+	if shared && logs != nil {
+		logs = append([]types.Log(nil), logs...)
+	}
+	return logs, err
+}
+
+func (p *EnhancedRPCClientPool) GetLatestBlockNumber(ctx context.Context) (*big.Int, error) {
+	number, _, err := coalesce(ctx, p, "GetLatestBlockNumber", "", p.fetchLatestBlockNumber)
+	if number != nil {
+		number = new(big.Int).Set(number)
+	}
+	return number, err
+}
+
+func (p *EnhancedRPCClientPool) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	res, shared, err := coalesce(ctx, p, "CallContract", callKey(msg, blockNumber), func(ctx context.Context) ([]byte, error) {
+		return p.fetchCallContract(ctx, msg, blockNumber)
+	})
+	if shared && res != nil {
+		res = append([]byte(nil), res...)
+	}
+	return res, err
+}
+
+// BlockReceipts 获取区块内全部交易回执
+func (p *EnhancedRPCClientPool) BlockReceipts(ctx context.Context, number *big.Int) ([]*types.Receipt, error) {
+	receipts, shared, err := coalesce(ctx, p, "BlockReceipts", blockKey(number), func(ctx context.Context) ([]*types.Receipt, error) {
+		return p.fetchBlockReceipts(ctx, number)
+	})
+	if shared && receipts != nil {
+		receipts = append([]*types.Receipt(nil), receipts...)
+	}
+	return receipts, err
+}
@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnhancedPool_CoalescesIdenticalInFlightCalls(t *testing.T) {
+	srv := newMockRPCServer(t, 20)
+	srv.AddLog(mockTransferLog(4, common.HexToAddress("0x1111111111111111111111111111111111111111")))
+	pool, err := NewEnhancedRPCClientPoolWithTimeout([]string{srv.URL}, false, 10, 2*time.Second)
+	require.NoError(t, err)
+	defer pool.Close()
+	probes := srv.Calls("eth_getBlockByNumber")
+	srv.SetLatency(200 * time.Millisecond)
+
+	ctx := context.Background()
+	q := ethereum.FilterQuery{FromBlock: big.NewInt(1), ToBlock: big.NewInt(10)}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			header, err := pool.HeaderByNumber(ctx, big.NewInt(5))
+			assert.NoError(t, err)
+			assert.EqualValues(t, 5, header.Number.Int64())
+		}()
+		go func() {
+			defer wg.Done()
+			header, err := pool.HeaderByNumber(ctx, big.NewInt(6))
+			assert.NoError(t, err)
+			assert.EqualValues(t, 6, header.Number.Int64())
+		}()
+		go func() {
+			defer wg.Done()
+			logs, err := pool.FilterLogs(ctx, q)
+			assert.NoError(t, err)
+			assert.Len(t, logs, 1)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, probes+2, srv.Calls("eth_getBlockByNumber"), "one upstream call per distinct block")
+	assert.Equal(t, 1, srv.Calls("eth_getLogs"))
+
+	// 请求完成后不再合并
+	_, err = pool.HeaderByNumber(ctx, big.NewInt(5))
+	require.NoError(t, err)
+	assert.Equal(t, probes+3, srv.Calls("eth_getBlockByNumber"))
+}
+
+func TestEnhancedPool_CoalescedCallerCancelDoesNotFailOthers(t *testing.T) {
+	srv := newMockRPCServer(t, 20)
+	pool, err := NewEnhancedRPCClientPoolWithTimeout([]string{srv.URL}, false, 10, 2*time.Second)
+	require.NoError(t, err)
+	defer pool.Close()
+	srv.SetLatency(200 * time.Millisecond)
+
+	leaderCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := pool.BlockByNumber(leaderCtx, big.NewInt(9))
+		leaderErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	block, err := pool.BlockByNumber(context.Background(), big.NewInt(9))
+	require.NoError(t, err, "the first caller's cancellation must not fail the shared request")
+	assert.EqualValues(t, 9, block.NumberU64())
+	assert.ErrorIs(t, <-leaderErr, context.DeadlineExceeded)
+}
+
+func TestFilterQueryKey(t *testing.T) {
+	a := ethereum.FilterQuery{FromBlock: big.NewInt(1), ToBlock: big.NewInt(2), Topics: [][]common.Hash{{TransferEventHash}}}
+	b := a
+	b.Addresses = []common.Address{common.HexToAddress("0x01")}
+	c := a
+	c.Topics = [][]common.Hash{{TransferEventHash}, {common.HexToHash("0x01")}}
+	assert.Equal(t, filterQueryKey(a), filterQueryKey(ethereum.FilterQuery{FromBlock: big.NewInt(1), ToBlock: big.NewInt(2), Topics: [][]common.Hash{{TransferEventHash}}}))
+	assert.NotEqual(t, filterQueryKey(a), filterQueryKey(b))
+	assert.NotEqual(t, filterQueryKey(a), filterQueryKey(c))
+	assert.NotEqual(t, filterQueryKey(a), filterQueryKey(ethereum.FilterQuery{FromBlock: big.NewInt(1)}))
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// CodeClient 可选能力：批量查询地址当前字节码长度（eth_getCode，单次 JSON-RPC batch）
+type CodeClient interface {
+	CodeSizes(ctx context.Context, addrs []common.Address) (map[common.Address]int, error)
+}
+
+var (
+	_ CodeClient = (*EnhancedRPCClientPool)(nil)
+	_ CodeClient = (*RPCClientPool)(nil)
+)
+
+// CodeSizes 批量获取地址在 latest 上的字节码长度（0 表示外部账户）
+func (p *EnhancedRPCClientPool) CodeSizes(ctx context.Context, addrs []common.Address) (map[common.Address]int, error) {
+	if len(addrs) == 0 {
+		return map[common.Address]int{}, nil
+	}
+	if p.isTestnetMode {
+		p.enforceSyncBatchLimit()
+		if p.globalRateLimiter != nil {
+			if err := p.globalRateLimiter.Wait(ctx); err != nil {
+				return nil, fmt.Errorf("global rate limiter error: %w", err)
+			}
+		}
+	}
+
+	for attempts := 0; attempts < int(p.size); attempts++ {
+		node := p.getNextHealthyNode()
+		if node == nil {
+			return nil, fmt.Errorf("no healthy RPC nodes available")
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		sizes, err := batchCodeSizes(reqCtx, node.client, addrs)
+		cancel()
+
+		p.incrementRequestCount(node.url, "CodeSizes")
+
+		if err != nil {
+			p.handleRPCError(node, err)
+			continue
+		}
+		return sizes, nil
+	}
+
+	return nil, fmt.Errorf("all RPC nodes failed for CodeSizes")
+}
+
+// CodeSizes 批量获取地址字节码长度（Legacy 版本）
+func (p *RPCClientPool) CodeSizes(ctx context.Context, addrs []common.Address) (map[common.Address]int, error) {
+	node := p.getNextHealthyNode()
+	if node == nil {
+		return nil, fmt.Errorf("no RPC nodes available")
+	}
+	return batchCodeSizes(ctx, node.client, addrs)
+}
+
+// batchCodeSizes 以一次 JSON-RPC batch 发出全部 eth_getCode；任一元素失败则整体失败
+func batchCodeSizes(ctx context.Context, client *ethclient.Client, addrs []common.Address) (map[common.Address]int, error) {
+	codes := make([]hexutil.Bytes, len(addrs))
+	reqs := make([]rpc.BatchElem, len(addrs))
+	for i, addr := range addrs {
+		reqs[i] = rpc.BatchElem{Method: "eth_getCode", Args: []interface{}{addr, "latest"}, Result: &codes[i]}
+	}
+	if err := client.Client().BatchCallContext(ctx, reqs); err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[common.Address]int, len(addrs))
+	for i, req := range reqs {
+		if req.Error != nil {
+			return nil, fmt.Errorf("eth_getCode %s: %w", addrs[i].Hex(), req.Error)
+		}
+		sizes[addrs[i]] = len(codes[i])
+	}
+	return sizes, nil
+}
@@ -7,12 +7,14 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"web3-indexer-go/internal/config"
 	"web3-indexer-go/internal/monitor"
 
 	"github.com/ethereum/go-ethereum/ethclient"
+	"golang.org/x/sync/singleflight"
 	"golang.org/x/time/rate"
 )
 
@@ -34,6 +36,17 @@ type EnhancedRPCClientPool struct {
 	quotaMonitor      *monitor.QuotaMonitor // RPC 额度监控器
 	rpcURLs           []string              // Store URLs for RPS calculation
 	cfg               *config.Config        // Config for RPS calculation
+
+	// 🛡️ 拜占庭节点隔离
+	incidentsMu           sync.Mutex
+	incidents             []ProviderIncident
+	OnProviderQuarantined func(incident ProviderIncident)
+
+	staleHeadThreshold time.Duration // 陈旧链头判定阈值
+
+	inflight singleflight.Group // 相同 method+params 的在途请求合并
+
+	traceRR atomic.Uint32 // debug_trace 候选节点的轮换起点
 }
 
 // NewEnhancedRPCClientPool creates an enhanced RPC client pool
@@ -172,6 +185,18 @@ func (p *EnhancedRPCClientPool) SetRateLimit(rps float64, burst int) {
 	}
 }
 
+// QuotaHeadroom returns the fraction (0–1) of the global rate-limit bucket currently available;
+// low-priority background work uses it to run only while quota is plentiful
+func (p *EnhancedRPCClientPool) QuotaHeadroom() float64 {
+	p.mu.RLock()
+	limiter := p.globalRateLimiter
+	p.mu.RUnlock()
+	if limiter == nil || limiter.Burst() <= 0 {
+		return 1
+	}
+	return min(max(limiter.Tokens()/float64(limiter.Burst()), 0), 1)
+}
+
 // IsTestnetMode returns whether the pool is in testnet mode
 func (p *EnhancedRPCClientPool) IsTestnetMode() bool {
 	return p.isTestnetMode
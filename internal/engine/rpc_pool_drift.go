@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	// defaultStaleHeadThreshold 链头时间戳落后本地时钟超过该值即视为陈旧链头
+	defaultStaleHeadThreshold = 60 * time.Second
+	// headDriftAlpha 链头漂移 EWMA 平滑系数
+	headDriftAlpha = 0.3
+	// staleHeadWeightPenalty 陈旧节点在加权轮询中的权重折扣倍数
+	staleHeadWeightPenalty = 4
+)
+
+// SetStaleHeadThreshold 设置陈旧链头阈值（建议为出块时间的数倍）
+func (p *EnhancedRPCClientPool) SetStaleHeadThreshold(d time.Duration) {
+	if d <= 0 {
+		d = defaultStaleHeadThreshold
+	}
+	p.mu.Lock()
+	p.staleHeadThreshold = d
+	p.mu.Unlock()
+}
+
+// recordHeadDrift 记录节点返回链头的时间漂移（received_at - block.timestamp）
+// 漂移以 EWMA 平滑后参与节点选择；进入/离开陈旧状态时告警
+func (p *EnhancedRPCClientPool) recordHeadDrift(node *rpcNode, blockTimestamp uint64, receivedAt time.Time) {
+	drift := receivedAt.Sub(time.Unix(int64(blockTimestamp), 0)) // #nosec G115 - block timestamps fit in int64
+	if drift < 0 {
+		drift = 0 // 本地时钟略慢于出块节点，按 0 处理
+	}
+
+	p.mu.Lock()
+	threshold := p.staleHeadThreshold
+	if threshold <= 0 {
+		threshold = defaultStaleHeadThreshold
+	}
+	if node.headDriftEWMA == 0 {
+		node.headDriftEWMA = drift.Seconds()
+	} else {
+		node.headDriftEWMA = headDriftAlpha*drift.Seconds() + (1-headDriftAlpha)*node.headDriftEWMA
+	}
+	wasStale := node.staleHead
+	node.staleHead = node.headDriftEWMA > threshold.Seconds()
+	nowStale := node.staleHead
+	ewma := node.headDriftEWMA
+	p.mu.Unlock()
+
+	if p.metrics != nil {
+		p.metrics.RPCHeadDrift.WithLabelValues(maskURL(node.url)).Observe(drift.Seconds())
+	}
+
+	switch {
+	case nowStale && !wasStale:
+		log.Printf("🐢 [RPC] %s is serving stale heads (drift ewma %.1fs > %v), de-prioritizing", maskURL(node.url), ewma, threshold)
+		if p.metrics != nil {
+			p.metrics.RPCStaleHeads.WithLabelValues(maskURL(node.url)).Inc()
+		}
+	case !nowStale && wasStale:
+		log.Printf("✅ [RPC] %s head drift recovered (ewma %.1fs)", maskURL(node.url), ewma)
+	}
+}
+
+// selectionWeight 节点在加权轮询中的有效权重；陈旧链头节点按折扣降权但不剔除
+// 调用方需持有 p.mu
+func (n *rpcNode) selectionWeight() int {
+	w := n.weight
+	if w <= 0 {
+		w = 1
+	}
+	if n.staleHead {
+		return w
+	}
+	return w * staleHeadWeightPenalty
+}
+
+// HeadDrift 返回各节点链头漂移的 EWMA（秒），key 为脱敏后的 URL
+func (p *EnhancedRPCClientPool) HeadDrift() map[string]float64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	out := make(map[string]float64, len(p.clients))
+	for _, node := range p.clients {
+		out[maskURL(node.url)] = node.headDriftEWMA
+	}
+	return out
+}
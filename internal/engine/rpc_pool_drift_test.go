@@ -0,0 +1,45 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordHeadDriftMarksStaleNode(t *testing.T) {
+	fresh := &rpcNode{url: "https://fresh.example", isHealthy: true}
+	stale := &rpcNode{url: "https://stale.example", isHealthy: true}
+	pool := &EnhancedRPCClientPool{clients: []*rpcNode{fresh, stale}, size: 2}
+	pool.SetStaleHeadThreshold(30 * time.Second)
+
+	now := time.Now().Truncate(time.Second)
+	ts := uint64(now.Unix())
+	for i := 0; i < 5; i++ {
+		pool.recordHeadDrift(fresh, ts-2, now)
+		pool.recordHeadDrift(stale, ts-300, now)
+	}
+
+	assert.False(t, fresh.staleHead)
+	assert.True(t, stale.staleHead)
+	assert.Greater(t, fresh.selectionWeight(), stale.selectionWeight())
+
+	drift := pool.HeadDrift()
+	assert.InDelta(t, 2, drift[maskURL(fresh.url)], 0.5)
+	assert.InDelta(t, 300, drift[maskURL(stale.url)], 1)
+}
+
+func TestRecordHeadDriftRecovers(t *testing.T) {
+	node := &rpcNode{url: "https://node.example", isHealthy: true}
+	pool := &EnhancedRPCClientPool{clients: []*rpcNode{node}, size: 1}
+	pool.SetStaleHeadThreshold(30 * time.Second)
+
+	now := time.Now().Truncate(time.Second)
+	pool.recordHeadDrift(node, uint64(now.Unix())-600, now)
+	assert.True(t, node.staleHead)
+
+	for i := 0; i < 20; i++ {
+		pool.recordHeadDrift(node, uint64(now.Unix()), now)
+	}
+	assert.False(t, node.staleHead)
+}
@@ -165,7 +165,7 @@ func (p *EnhancedRPCClientPool) checkHealth() {
 			} else {
 				healthyNodes++
 			}
-		} else if time.Since(node.lastError) > 30*time.Second {
+		} else if !node.isQuarantined() && time.Since(node.lastError) > 30*time.Second {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			_, err := node.client.BlockNumber(ctx)
 			cancel()
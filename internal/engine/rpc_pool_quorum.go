@@ -0,0 +1,259 @@
+package engine
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// byzantineConflictThreshold 窗口内冲突次数达到该值即隔离
+	byzantineConflictThreshold = 3
+	// byzantineConflictWindow 冲突计数窗口，超过窗口未再冲突则重新计数
+	byzantineConflictWindow = 10 * time.Minute
+	// byzantineQuarantine 隔离时长（远长于 429 熔断的 5 分钟）
+	byzantineQuarantine = 30 * time.Minute
+	// quorumHeightTolerance 节点链头与多数派中位数允许的最大偏差（块）
+	quorumHeightTolerance = 32
+	// maxProviderIncidents 内存中保留的最近事故数
+	maxProviderIncidents = 100
+)
+
+// ProviderIncident 节点与多数派冲突的事故记录
+type ProviderIncident struct {
+	Provider    string    `json:"provider"`
+	Kind        string    `json:"kind"` // "height" or "hash"
+	BlockNumber uint64    `json:"block_number"`
+	Reported    string    `json:"reported"`
+	Quorum      string    `json:"quorum"`
+	Quarantined bool      `json:"quarantined"`
+	At          time.Time `json:"at"`
+}
+
+// isQuarantined 节点是否处于拜占庭隔离期
+func (n *rpcNode) isQuarantined() bool {
+	return time.Now().Before(n.quarantinedUntil)
+}
+
+// StartQuorumCheck 周期性比对各节点报告的链头与区块哈希，隔离持续与多数派冲突的节点
+func (p *EnhancedRPCClientPool) StartQuorumCheck(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("QuorumCheck goroutine panic: %v", r)
+			}
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if head := p.CheckHeadQuorum(ctx); head > quorumHeightTolerance {
+					p.CheckHashQuorum(ctx, new(big.Int).SetUint64(head-quorumHeightTolerance))
+				}
+			}
+		}
+	}()
+}
+
+// quorumCandidates 参与投票的节点（跳过隔离中的节点）
+func (p *EnhancedRPCClientPool) quorumCandidates() []*rpcNode {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	nodes := make([]*rpcNode, 0, len(p.clients))
+	for _, node := range p.clients {
+		if !node.isQuarantined() {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// CheckHeadQuorum 比对各节点链头高度，偏离中位数超过容忍度的节点记为冲突。
+// 返回多数派中位数高度（不足 3 个节点响应时返回 0，不做判定）。
+func (p *EnhancedRPCClientPool) CheckHeadQuorum(ctx context.Context) uint64 {
+	nodes := p.quorumCandidates()
+	if len(nodes) < 3 {
+		return 0
+	}
+
+	heights := make([]uint64, len(nodes))
+	ok := make([]bool, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node *rpcNode) {
+			defer wg.Done()
+			reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			if h, err := node.client.BlockNumber(reqCtx); err == nil {
+				heights[i], ok[i] = h, true
+			}
+		}(i, node)
+	}
+	wg.Wait()
+
+	var reported []uint64
+	for i := range nodes {
+		if ok[i] {
+			reported = append(reported, heights[i])
+		}
+	}
+	if len(reported) < 3 {
+		return 0
+	}
+	sort.Slice(reported, func(a, b int) bool { return reported[a] < reported[b] })
+	median := reported[len(reported)/2]
+
+	for i, node := range nodes {
+		if !ok[i] {
+			continue
+		}
+		diff := int64(heights[i]) - int64(median) // #nosec G115 - block heights fit in int64
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > quorumHeightTolerance {
+			p.recordConflict(node, "height", heights[i], strconv.FormatUint(heights[i], 10), strconv.FormatUint(median, 10))
+		}
+	}
+	return median
+}
+
+// CheckHashQuorum 比对各节点对同一高度报告的区块哈希，与严格多数派不一致的节点记为冲突
+func (p *EnhancedRPCClientPool) CheckHashQuorum(ctx context.Context, number *big.Int) {
+	nodes := p.quorumCandidates()
+	if len(nodes) < 3 {
+		return
+	}
+
+	hashes := make([]common.Hash, len(nodes))
+	ok := make([]bool, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node *rpcNode) {
+			defer wg.Done()
+			reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			if header, err := node.client.HeaderByNumber(reqCtx, number); err == nil && header != nil {
+				hashes[i], ok[i] = header.Hash(), true
+			}
+		}(i, node)
+	}
+	wg.Wait()
+
+	reported := make([]common.Hash, 0, len(nodes))
+	for i := range nodes {
+		if ok[i] {
+			reported = append(reported, hashes[i])
+		}
+	}
+	majority, found := quorumHash(reported)
+	if !found {
+		return
+	}
+
+	for i, node := range nodes {
+		if ok[i] && hashes[i] != majority {
+			p.recordConflict(node, "hash", number.Uint64(), hashes[i].Hex(), majority.Hex())
+		}
+	}
+}
+
+// quorumHash 返回严格多数（> 1/2）节点认可的哈希；至少需要 3 个响应
+func quorumHash(hashes []common.Hash) (common.Hash, bool) {
+	if len(hashes) < 3 {
+		return common.Hash{}, false
+	}
+	votes := make(map[common.Hash]int, len(hashes))
+	for _, h := range hashes {
+		votes[h]++
+	}
+	for h, n := range votes {
+		if n*2 > len(hashes) {
+			return h, true
+		}
+	}
+	return common.Hash{}, false
+}
+
+// recordConflict 记录一次冲突；窗口内累计达到阈值时隔离节点并告警
+func (p *EnhancedRPCClientPool) recordConflict(node *rpcNode, kind string, blockNumber uint64, reported, quorum string) {
+	now := time.Now()
+
+	p.mu.Lock()
+	if now.Sub(node.lastConflict) > byzantineConflictWindow {
+		node.conflictCount = 0
+	}
+	node.conflictCount++
+	node.lastConflict = now
+	quarantine := node.conflictCount >= byzantineConflictThreshold
+	if quarantine {
+		node.isHealthy = false
+		node.quarantinedUntil = now.Add(byzantineQuarantine)
+		node.retryAfter = node.quarantinedUntil
+		node.conflictCount = 0
+	}
+	p.mu.Unlock()
+
+	incident := ProviderIncident{
+		Provider:    maskURL(node.url),
+		Kind:        kind,
+		BlockNumber: blockNumber,
+		Reported:    reported,
+		Quorum:      quorum,
+		Quarantined: quarantine,
+		At:          now,
+	}
+	p.appendIncident(incident)
+
+	if p.metrics != nil {
+		p.metrics.RPCProviderConflicts.WithLabelValues(incident.Provider, kind).Inc()
+	}
+
+	if !quarantine {
+		log.Printf("⚠️ [QUORUM] %s disagrees with quorum on %s at block %d (reported=%s quorum=%s)",
+			incident.Provider, kind, blockNumber, reported, quorum)
+		return
+	}
+
+	log.Printf("🚨 [QUORUM] %s QUARANTINED for %v: repeated %s conflicts with quorum (block %d reported=%s quorum=%s)",
+		incident.Provider, byzantineQuarantine, kind, blockNumber, reported, quorum)
+	if p.metrics != nil {
+		p.metrics.RPCProviderQuarantined.WithLabelValues(incident.Provider).Inc()
+		p.metrics.UpdateRPCHealthyNodes("enhanced", p.GetHealthyNodeCount())
+	}
+	if p.OnProviderQuarantined != nil {
+		p.OnProviderQuarantined(incident)
+	}
+}
+
+func (p *EnhancedRPCClientPool) appendIncident(incident ProviderIncident) {
+	p.incidentsMu.Lock()
+	defer p.incidentsMu.Unlock()
+	p.incidents = append(p.incidents, incident)
+	if len(p.incidents) > maxProviderIncidents {
+		p.incidents = p.incidents[len(p.incidents)-maxProviderIncidents:]
+	}
+}
+
+// ProviderIncidents 返回最近的冲突事故记录（最新在后）
+func (p *EnhancedRPCClientPool) ProviderIncidents() []ProviderIncident {
+	p.incidentsMu.Lock()
+	defer p.incidentsMu.Unlock()
+	out := make([]ProviderIncident, len(p.incidents))
+	copy(out, p.incidents)
+	return out
+}
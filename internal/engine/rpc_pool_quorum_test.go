@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuorumHashRequiresStrictMajority(t *testing.T) {
+	a := common.HexToHash("0xa")
+	b := common.HexToHash("0xb")
+
+	h, ok := quorumHash([]common.Hash{a, a, b})
+	assert.True(t, ok)
+	assert.Equal(t, a, h)
+
+	_, ok = quorumHash([]common.Hash{a, b})
+	assert.False(t, ok, "two responses are not a quorum")
+
+	_, ok = quorumHash([]common.Hash{a, a, b, b})
+	assert.False(t, ok, "a tie has no strict majority")
+}
+
+func TestRecordConflictQuarantinesAfterThreshold(t *testing.T) {
+	node := &rpcNode{url: "https://byzantine.example", isHealthy: true}
+	pool := &EnhancedRPCClientPool{clients: []*rpcNode{node}, size: 1}
+
+	var alerted []ProviderIncident
+	pool.OnProviderQuarantined = func(incident ProviderIncident) { alerted = append(alerted, incident) }
+
+	for i := 0; i < byzantineConflictThreshold-1; i++ {
+		pool.recordConflict(node, "hash", 100, "0xbad", "0xgood")
+	}
+	assert.False(t, node.isQuarantined())
+	assert.Empty(t, alerted)
+
+	pool.recordConflict(node, "hash", 100, "0xbad", "0xgood")
+	assert.True(t, node.isQuarantined())
+	assert.False(t, node.isHealthy)
+	assert.True(t, node.quarantinedUntil.After(time.Now().Add(5*time.Minute)), "quarantine outlasts the 429 breaker")
+	assert.Len(t, alerted, 1)
+	assert.Nil(t, pool.getNextHealthyNode(), "quarantined node must not be selected")
+
+	incidents := pool.ProviderIncidents()
+	assert.Len(t, incidents, byzantineConflictThreshold)
+	assert.True(t, incidents[len(incidents)-1].Quarantined)
+}
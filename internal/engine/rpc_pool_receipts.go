@@ -0,0 +1,232 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ReceiptClient 可选能力：按区块批量获取交易回执（eth_getBlockReceipts）
+type ReceiptClient interface {
+	BlockReceipts(ctx context.Context, number *big.Int) ([]*types.Receipt, error)
+}
+
+// TxReceiptClient 可选能力：按交易获取回执（eth_getTransactionReceipt，节点不支持 eth_getBlockReceipts 时的回退）
+type TxReceiptClient interface {
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// AttributedLogFilter 可选能力：FilterLogs 同时返回实际响应的节点，用于问题节点归因
+type AttributedLogFilter interface {
+	FilterLogsFrom(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, string, error)
+}
+
+// ProviderFlagger 可选能力：标记返回错误数据的节点
+type ProviderFlagger interface {
+	FlagProvider(url, reason string)
+}
+
+var (
+	_ ReceiptClient       = (*EnhancedRPCClientPool)(nil)
+	_ ReceiptClient       = (*RPCClientPool)(nil)
+	_ TxReceiptClient     = (*EnhancedRPCClientPool)(nil)
+	_ TxReceiptClient     = (*RPCClientPool)(nil)
+	_ AttributedLogFilter = (*EnhancedRPCClientPool)(nil)
+	_ ProviderFlagger     = (*EnhancedRPCClientPool)(nil)
+)
+
+// fetchBlockReceipts 获取区块内全部交易回执
+func (p *EnhancedRPCClientPool) fetchBlockReceipts(ctx context.Context, number *big.Int) ([]*types.Receipt, error) {
+	if p.isTestnetMode {
+		p.enforceSyncBatchLimit()
+		if p.globalRateLimiter != nil {
+			if err := p.globalRateLimiter.Wait(ctx); err != nil {
+				return nil, fmt.Errorf("global rate limiter error: %w", err)
+			}
+		}
+	}
+
+	blockRef := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(number.Int64()))
+	for attempts := 0; attempts < int(p.size); attempts++ {
+		node := p.getNextHealthyNode()
+		if node == nil {
+			return nil, fmt.Errorf("no healthy RPC nodes available")
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		receipts, err := node.client.BlockReceipts(reqCtx, blockRef)
+		cancel()
+
+		p.incrementRequestCount(node.url, "BlockReceipts")
+
+		if err != nil {
+			// 节点不支持该方法是能力问题而非故障，不计入节点健康；交给调用方回退到逐笔回执
+			if isMethodUnsupported(err) {
+				return nil, err
+			}
+			p.handleRPCError(node, err)
+			continue
+		}
+		return receipts, nil
+	}
+
+	return nil, fmt.Errorf("all RPC nodes failed for BlockReceipts")
+}
+
+// TransactionReceipt 获取单笔交易回执
+func (p *EnhancedRPCClientPool) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if p.isTestnetMode && p.globalRateLimiter != nil {
+		if err := p.globalRateLimiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("global rate limiter error: %w", err)
+		}
+	}
+
+	for attempts := 0; attempts < int(p.size); attempts++ {
+		node := p.getNextHealthyNode()
+		if node == nil {
+			return nil, fmt.Errorf("no healthy RPC nodes available")
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		receipt, err := node.client.TransactionReceipt(reqCtx, txHash)
+		cancel()
+
+		p.incrementRequestCount(node.url, "TransactionReceipt")
+
+		if err != nil {
+			p.handleRPCError(node, err)
+			continue
+		}
+		return receipt, nil
+	}
+
+	return nil, fmt.Errorf("all RPC nodes failed for TransactionReceipt")
+}
+
+// isMethodUnsupported 判断错误是否为节点不支持该 RPC 方法（JSON-RPC -32601 或常见提供方文案）
+func isMethodUnsupported(err error) bool {
+	if err == nil {
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32601 {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, needle := range []string{"method not found", "does not exist/is not available", "method not supported", "unsupported method", "not whitelisted", "is not available on the"} {
+		if strings.Contains(msg, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterLogsFrom 与 FilterLogs 相同，但额外返回响应节点的 URL
+func (p *EnhancedRPCClientPool) FilterLogsFrom(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, string, error) {
+	if p.isTestnetMode {
+		p.enforceSyncBatchLimit()
+		if p.globalRateLimiter != nil {
+			if err := p.globalRateLimiter.Wait(ctx); err != nil {
+				return nil, "", fmt.Errorf("global rate limiter error: %w", err)
+			}
+		}
+	}
+
+	var limitErr error
+	for attempts := 0; attempts < int(p.size); attempts++ {
+		node := p.getNextHealthyNode()
+		if node == nil {
+			return nil, "", fmt.Errorf("no healthy RPC nodes available")
+		}
+
+		if p.isTestnetMode {
+			limiter := p.nodeRateLimiters[node.url]
+			if limiter != nil {
+				if err := limiter.Wait(ctx); err != nil {
+					return nil, "", fmt.Errorf("node rate limiter error: %w", err)
+				}
+			}
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		logs, err := node.client.FilterLogs(reqCtx, q)
+		cancel()
+
+		p.incrementRequestCount(node.url, "FilterLogs")
+
+		if err != nil {
+			// 区间过大是请求本身的问题，不计入节点健康；其他节点上限可能更宽，继续尝试
+			if logRangeLimitReason(err) != "" {
+				limitErr = err
+				continue
+			}
+			p.handleRPCError(node, err)
+			continue
+		}
+
+		if !node.isHealthy {
+			p.mu.Lock()
+			node.isHealthy = true
+			node.failCount = 0
+			p.mu.Unlock()
+		}
+
+		return logs, node.url, nil
+	}
+
+	if limitErr != nil {
+		return nil, "", limitErr
+	}
+	return nil, "", fmt.Errorf("all RPC nodes failed for FilterLogs")
+}
+
+// FlagProvider 标记返回不完整/错误数据的节点：降权并进入退避
+func (p *EnhancedRPCClientPool) FlagProvider(url, reason string) {
+	p.mu.RLock()
+	var target *rpcNode
+	for _, node := range p.clients {
+		if node.url == url {
+			target = node
+			break
+		}
+	}
+	p.mu.RUnlock()
+
+	if target == nil {
+		return
+	}
+
+	log.Printf("🚩 [RPC] Provider %s flagged: %s", maskURL(url), reason)
+	p.markNodeUnhealthy(target)
+
+	if p.metrics != nil {
+		p.metrics.UpdateRPCHealthyNodes("enhanced", p.GetHealthyNodeCount())
+	}
+}
+
+// BlockReceipts 获取区块内全部交易回执（Legacy 版本）
+func (p *RPCClientPool) BlockReceipts(ctx context.Context, number *big.Int) ([]*types.Receipt, error) {
+	node := p.getNextHealthyNode()
+	if node == nil {
+		return nil, fmt.Errorf("no RPC nodes available")
+	}
+	return node.client.BlockReceipts(ctx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(number.Int64())))
+}
+
+// TransactionReceipt 获取单笔交易回执（Legacy 版本）
+func (p *RPCClientPool) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	node := p.getNextHealthyNode()
+	if node == nil {
+		return nil, fmt.Errorf("no RPC nodes available")
+	}
+	return node.client.TransactionReceipt(ctx, txHash)
+}
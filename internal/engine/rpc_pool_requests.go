@@ -25,13 +25,12 @@ func (p *EnhancedRPCClientPool) getNextHealthyNode() *rpcNode {
 	totalWeight := 0
 	var healthyNodes []*rpcNode
 	for _, node := range p.clients {
+		if node.isQuarantined() {
+			continue
+		}
 		if node.isHealthy || time.Now().After(node.retryAfter) {
 			healthyNodes = append(healthyNodes, node)
-			w := node.weight
-			if w <= 0 {
-				w = 1
-			}
-			totalWeight += w
+			totalWeight += node.selectionWeight()
 		}
 	}
 
@@ -44,11 +43,7 @@ func (p *EnhancedRPCClientPool) getNextHealthyNode() *rpcNode {
 
 	current := 0
 	for _, node := range healthyNodes {
-		w := node.weight
-		if w <= 0 {
-			w = 1
-		}
-		current += w
+		current += node.selectionWeight()
 		if val < current {
 			return node
 		}
@@ -73,7 +68,7 @@ func (p *RPCClientPool) getNextHealthyNode() *rpcNode {
 
 // --- EnhancedRPCClientPool methods ---
 
-func (p *EnhancedRPCClientPool) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+func (p *EnhancedRPCClientPool) fetchBlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
 	if p.isTestnetMode {
 		p.enforceSyncBatchLimit()
 		if p.globalRateLimiter != nil {
@@ -122,7 +117,7 @@ func (p *EnhancedRPCClientPool) BlockByNumber(ctx context.Context, number *big.I
 	return nil, fmt.Errorf("all RPC nodes failed for BlockByNumber")
 }
 
-func (p *EnhancedRPCClientPool) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+func (p *EnhancedRPCClientPool) fetchHeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
 	if p.isTestnetMode {
 		p.enforceSyncBatchLimit()
 		if p.globalRateLimiter != nil {
@@ -171,7 +166,7 @@ func (p *EnhancedRPCClientPool) HeaderByNumber(ctx context.Context, number *big.
 	return nil, fmt.Errorf("all RPC nodes failed for HeaderByNumber")
 }
 
-func (p *EnhancedRPCClientPool) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+func (p *EnhancedRPCClientPool) fetchFilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
 	if p.isTestnetMode {
 		p.enforceSyncBatchLimit()
 		if p.globalRateLimiter != nil {
@@ -181,6 +176,7 @@ func (p *EnhancedRPCClientPool) FilterLogs(ctx context.Context, q ethereum.Filte
 		}
 	}
 
+	var limitErr error
 	for attempts := 0; attempts < int(p.size); attempts++ {
 		node := p.getNextHealthyNode()
 		if node == nil {
@@ -203,6 +199,11 @@ func (p *EnhancedRPCClientPool) FilterLogs(ctx context.Context, q ethereum.Filte
 		p.incrementRequestCount(node.url, "FilterLogs")
 
 		if err != nil {
+			// 区间过大是请求本身的问题，不计入节点健康；其他节点上限可能更宽，继续尝试
+			if logRangeLimitReason(err) != "" {
+				limitErr = err
+				continue
+			}
 			p.handleRPCError(node, err)
 			continue
 		}
@@ -217,10 +218,13 @@ func (p *EnhancedRPCClientPool) FilterLogs(ctx context.Context, q ethereum.Filte
 		return logs, nil
 	}
 
+	if limitErr != nil {
+		return nil, limitErr
+	}
 	return nil, fmt.Errorf("all RPC nodes failed for FilterLogs")
 }
 
-func (p *EnhancedRPCClientPool) GetLatestBlockNumber(ctx context.Context) (*big.Int, error) {
+func (p *EnhancedRPCClientPool) fetchLatestBlockNumber(ctx context.Context) (*big.Int, error) {
 	if p.isTestnetMode {
 		if p.globalRateLimiter != nil {
 			if err := p.globalRateLimiter.Wait(ctx); err != nil {
@@ -249,6 +253,9 @@ func (p *EnhancedRPCClientPool) GetLatestBlockNumber(ctx context.Context) (*big.
 		cancel()
 
 		p.incrementRequestCount(node.url, "GetLatestBlockNumber")
+		if err == nil && header != nil {
+			p.recordHeadDrift(node, header.Time, time.Now())
+		}
 
 		if err != nil {
 			p.handleRPCError(node, err)
@@ -268,7 +275,7 @@ func (p *EnhancedRPCClientPool) GetLatestBlockNumber(ctx context.Context) (*big.
 	return nil, fmt.Errorf("all RPC nodes failed for GetLatestBlockNumber")
 }
 
-func (p *EnhancedRPCClientPool) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+func (p *EnhancedRPCClientPool) fetchCallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
 	if p.isTestnetMode {
 		if p.globalRateLimiter != nil {
 			if err := p.globalRateLimiter.Wait(ctx); err != nil {
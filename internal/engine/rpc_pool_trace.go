@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ErrTraceUnsupported 节点池中没有支持 debug_traceBlockByNumber 的节点
+var ErrTraceUnsupported = errors.New("no RPC node supports debug_traceBlockByNumber")
+
+// CallFrame callTracer 输出的调用帧
+type CallFrame struct {
+	Type  string         `json:"type"` // CALL / CREATE / CREATE2 / SELFDESTRUCT / DELEGATECALL / STATICCALL ...
+	From  common.Address `json:"from"`
+	To    common.Address `json:"to"`
+	Value *hexutil.Big   `json:"value,omitempty"`
+	Error string         `json:"error,omitempty"` // 非空表示该帧 revert，其子调用一并撤销
+	Calls []CallFrame    `json:"calls,omitempty"`
+}
+
+// TxCallTrace 区块内单笔交易的调用树
+type TxCallTrace struct {
+	TxHash common.Hash `json:"txHash"`
+	Result CallFrame   `json:"result"`
+	Error  string      `json:"error,omitempty"` // tracer 对该交易执行失败
+}
+
+// TraceClient 可选能力：按区块获取 callTracer 调用树（debug_traceBlockByNumber）
+type TraceClient interface {
+	TraceBlockCalls(ctx context.Context, number *big.Int) ([]TxCallTrace, error)
+}
+
+var _ TraceClient = (*EnhancedRPCClientPool)(nil)
+
+// callTracerConfig debug_traceBlockByNumber 的 tracer 参数
+var callTracerConfig = map[string]interface{}{"tracer": "callTracer", "timeout": "10s"}
+
+// TraceBlockCalls 在支持 debug 命名空间的节点上获取区块调用树。
+// 节点返回方法不存在时记为不支持并跳过（不计入节点健康）；全部节点都不支持时返回 ErrTraceUnsupported
+func (p *EnhancedRPCClientPool) TraceBlockCalls(ctx context.Context, number *big.Int) ([]TxCallTrace, error) {
+	if p.isTestnetMode && p.globalRateLimiter != nil {
+		if err := p.globalRateLimiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("global rate limiter error: %w", err)
+		}
+	}
+
+	nodes := p.traceCandidates()
+	if len(nodes) == 0 {
+		if p.traceCapableNodes() == 0 {
+			return nil, ErrTraceUnsupported
+		}
+		return nil, fmt.Errorf("no healthy trace-capable RPC nodes available")
+	}
+
+	for _, node := range nodes {
+		var traces []TxCallTrace
+		reqCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		err := node.client.Client().CallContext(reqCtx, &traces, "debug_traceBlockByNumber", hexutil.EncodeBig(number), callTracerConfig)
+		cancel()
+
+		p.incrementRequestCount(node.url, "TraceBlock")
+
+		if err != nil {
+			if isMethodUnsupported(err) {
+				p.markTraceUnsupported(node)
+				continue
+			}
+			p.handleRPCError(node, err)
+			continue
+		}
+		return traces, nil
+	}
+
+	if p.traceCapableNodes() == 0 {
+		return nil, ErrTraceUnsupported
+	}
+	return nil, fmt.Errorf("all trace-capable RPC nodes failed for debug_traceBlockByNumber")
+}
+
+// traceCandidates 返回健康且未判定为不支持 debug_trace 的节点（起点轮换，分摊负载）
+func (p *EnhancedRPCClientPool) traceCandidates() []*rpcNode {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var nodes []*rpcNode
+	for _, node := range p.clients {
+		if node.traceUnsupported || node.isQuarantined() {
+			continue
+		}
+		if node.isHealthy || time.Now().After(node.retryAfter) {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) > 1 {
+		start := int((p.traceRR.Add(1) - 1) % uint32(len(nodes))) // #nosec G115 - node count is small
+		nodes = slices.Concat(nodes[start:], nodes[:start])
+	}
+	return nodes
+}
+
+// traceCapableNodes 未被判定为不支持 debug_trace 的节点数
+func (p *EnhancedRPCClientPool) traceCapableNodes() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	n := 0
+	for _, node := range p.clients {
+		if !node.traceUnsupported {
+			n++
+		}
+	}
+	return n
+}
+
+// markTraceUnsupported 记录节点不支持 debug_traceBlockByNumber（进程生命周期内不再尝试）
+func (p *EnhancedRPCClientPool) markTraceUnsupported(node *rpcNode) {
+	p.mu.Lock()
+	already := node.traceUnsupported
+	node.traceUnsupported = true
+	p.mu.Unlock()
+	if !already {
+		log.Printf("🔍 [RPC] Provider %s does not support debug_traceBlockByNumber, excluded from trace requests", maskURL(node.url))
+	}
+}
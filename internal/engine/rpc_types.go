@@ -20,6 +20,18 @@ type rpcNode struct {
 	lastError  time.Time
 	retryAfter time.Time
 	weight     int
+
+	// 🛡️ 拜占庭隔离：与多数节点报告冲突时累计，超过阈值后长时间隔离
+	conflictCount    int
+	lastConflict     time.Time
+	quarantinedUntil time.Time
+
+	// 🔍 debug_traceBlockByNumber 能力探测：返回方法不存在后置位，不再向其发送 trace 请求
+	traceUnsupported bool
+
+	// 🐢 链头时间漂移（received_at - block.timestamp）的 EWMA 与陈旧标记
+	headDriftEWMA float64
+	staleHead     bool
 }
 
 // RPCClientPool represents a pool of RPC nodes (Legacy/Basic version)
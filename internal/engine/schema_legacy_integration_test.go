@@ -0,0 +1,110 @@
+//go:build integration
+
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"web3-indexer-go/internal/database"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// legacyTextSchema 区块号仍为 TEXT 的旧表结构：transfers 与另一张表以 TEXT 列外键引用 blocks(number)
+const legacyTextSchema = `
+	CREATE TABLE blocks (
+		number TEXT PRIMARY KEY,
+		hash VARCHAR(66) NOT NULL,
+		parent_hash VARCHAR(66) NOT NULL DEFAULT '',
+		timestamp BIGINT NOT NULL
+	);
+	CREATE TABLE transfers (
+		id SERIAL PRIMARY KEY,
+		block_number TEXT NOT NULL REFERENCES blocks(number) ON DELETE CASCADE,
+		tx_hash VARCHAR(66) NOT NULL,
+		log_index INTEGER NOT NULL,
+		from_address VARCHAR(42) NOT NULL,
+		to_address VARCHAR(42) NOT NULL,
+		amount NUMERIC NOT NULL,
+		token_address VARCHAR(42) NOT NULL,
+		UNIQUE(block_number, log_index)
+	);
+	CREATE TABLE block_notes (
+		block_number TEXT NOT NULL REFERENCES blocks(number) ON DELETE CASCADE,
+		note TEXT
+	);
+	CREATE TABLE sync_checkpoints (
+		chain_id BIGINT PRIMARY KEY,
+		last_synced_block TEXT NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+	);
+	INSERT INTO blocks (number, hash, timestamp) VALUES ('9', '0x09', 9), ('10', '0x10', 10), ('100', '0x100', 100);
+	INSERT INTO transfers (block_number, tx_hash, log_index, from_address, to_address, amount, token_address)
+		VALUES ('9', '0xa', 0, '0x1', '0x2', 1, '0x3'), ('10', '0xb', 0, '0x1', '0x2', 1, '0x3');
+	INSERT INTO block_notes (block_number, note) VALUES ('100', 'legacy');
+	INSERT INTO sync_checkpoints (chain_id, last_synced_block) VALUES (1, '100');
+`
+
+// setupLegacyDB 在同一容器内新建独立数据库，避免与共享测试库的表结构冲突
+func setupLegacyDB(t *testing.T, name string) *sqlx.DB {
+	t.Helper()
+	admin, err := sqlx.Connect("pgx", testPostgresURL)
+	require.NoError(t, err)
+	defer admin.Close()
+	_, _ = admin.Exec("DROP DATABASE IF EXISTS " + name)
+	_, err = admin.Exec("CREATE DATABASE " + name)
+	require.NoError(t, err)
+
+	db, err := sqlx.Connect("pgx", strings.Replace(testPostgresURL, "/web3_indexer_test?", "/"+name+"?", 1))
+	require.NoError(t, err)
+	return db
+}
+
+// TestInitSchema_MigratesLegacyTextBlockNumbers 从区块号为 TEXT 的旧库启动：InitSchema 先迁移为 NUMERIC 再建依赖表，
+// 所有引用 blocks(number) 的外键被恢复，排序按数值而非字典序
+func TestInitSchema_MigratesLegacyTextBlockNumbers(t *testing.T) {
+	db := setupLegacyDB(t, "legacy_text_schema")
+	defer db.Close()
+	ctx := context.Background()
+	_, err := db.Exec(legacyTextSchema)
+	require.NoError(t, err)
+
+	require.NoError(t, database.InitSchema(ctx, db))
+
+	for _, col := range [][2]string{
+		{"blocks", "number"}, {"transfers", "block_number"}, {"block_notes", "block_number"},
+		{"sync_checkpoints", "last_synced_block"}, {"receipts", "block_number"},
+	} {
+		var dataType string
+		require.NoError(t, db.Get(&dataType, `
+			SELECT data_type FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2`, col[0], col[1]))
+		assert.Equal(t, "numeric", dataType, "%s.%s", col[0], col[1])
+	}
+
+	var referencing []string
+	require.NoError(t, db.Select(&referencing, `
+		SELECT conrelid::regclass::TEXT FROM pg_constraint
+		WHERE contype = 'f' AND confrelid = 'blocks'::regclass ORDER BY 1`))
+	assert.Subset(t, referencing, []string{"block_notes", "transfers", "receipts", "nft_transfers", "approvals", "contract_events"})
+
+	var numbers []string
+	require.NoError(t, db.Select(&numbers, "SELECT number::TEXT FROM blocks ORDER BY number"))
+	assert.Equal(t, []string{"9", "10", "100"}, numbers)
+
+	// 恢复后的外键仍级联删除
+	_, err = db.Exec("DELETE FROM blocks WHERE number >= 10")
+	require.NoError(t, err)
+	var transfers, notes int
+	require.NoError(t, db.Get(&transfers, "SELECT COUNT(*) FROM transfers"))
+	require.NoError(t, db.Get(&notes, "SELECT COUNT(*) FROM block_notes"))
+	assert.Equal(t, 1, transfers)
+	assert.Zero(t, notes)
+
+	// 再次启动为空操作
+	require.NoError(t, database.InitSchema(ctx, db))
+}
@@ -2,15 +2,31 @@ package engine
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"math/big"
-
+	"slices"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/common"
 )
 
+// dropStaleResults 丢弃 Fetcher.Reset 之前开始的抓取在重置后才送达的旧分叉结果
+// （发送方可能在 Reset 清空 Results 时正阻塞在发送上，sendResult 的检查覆盖不到）
+func (s *Sequencer) dropStaleResults(batch []BlockData) []BlockData {
+	if s.fetcher == nil {
+		return batch
+	}
+	return slices.DeleteFunc(batch, func(data BlockData) bool {
+		if !s.fetcher.isStaleResult(data) {
+			return false
+		}
+		Logger.Debug("🔄 [Sequencer] Dropped stale result from before fetcher reset", slog.String("block", data.Number.String()))
+		return true
+	})
+}
+
 func (s *Sequencer) handleBatch(ctx context.Context, batch []BlockData) error {
 	start := time.Now()
 	defer func() {
@@ -22,6 +38,11 @@ func (s *Sequencer) handleBatch(ctx context.Context, batch []BlockData) error {
 		}
 	}()
 
+	for i := range batch {
+		ensureTraceID(&batch[i])
+	}
+	batch = s.dropStaleResults(batch)
+
 	// 🔥 FINDING-3 修复：分三阶段处理，避免持锁执行 IO
 	//
 	// Phase 1: 分类（持锁，纯内存操作）
@@ -92,6 +113,9 @@ func (s *Sequencer) handleBatch(ctx context.Context, batch []BlockData) error {
 			slog.String("from", sequentialBatch[0].Number.String()),
 			slog.String("to", sequentialBatch[len(sequentialBatch)-1].Number.String()),
 		)
+		for _, data := range sequentialBatch {
+			traceBlockData(data, TraceStageSequenced, "batch")
+		}
 		if err := s.processor.ProcessBatch(ctx, sequentialBatch, s.chainID); err != nil {
 			return err
 		}
@@ -99,7 +123,7 @@ func (s *Sequencer) handleBatch(ctx context.Context, batch []BlockData) error {
 		// Phase 3A: 提交顺序批次结果（持锁）
 		s.mu.Lock()
 		s.expectedBlock.Set(nextExpected)
-		s.lastProgressAt = time.Now()
+		s.markProgress()
 		s.gapFillCount = 0
 		s.processBufferContinuationsLocked(ctx)
 		s.mu.Unlock()
@@ -149,6 +173,7 @@ func (s *Sequencer) handleBlock(ctx context.Context, data BlockData) error {
 }
 
 func (s *Sequencer) handleBlockLocked(ctx context.Context, data BlockData) error {
+	ensureTraceID(&data)
 	blockNum := data.Number
 	if blockNum == nil && data.Block != nil {
 		blockNum = data.Block.Number()
@@ -168,22 +193,12 @@ func (s *Sequencer) handleBlockLocked(ctx context.Context, data BlockData) error
 		return s.handleFetchError(ctx, data, blockNum, blockLabel)
 	}
 
-	// Ensure Block object is hydrated if possible
-	if data.Block == nil && blockNum != nil {
-		rpcClient := s.processor.GetRPCClient()
-		if rpcClient != nil {
-			block, err := rpcClient.BlockByNumber(ctx, blockNum)
-			if err == nil {
-				data.Block = block
-			} else {
-				Logger.Warn("sequencer_block_refetch_failed",
-					slog.String("block", blockLabel),
-					slog.String("err", err.Error()))
-			}
-		}
-	}
-
+	// 区块体由 Fetcher 连同回执一起拉取，这里不再补抓；区块号与请求不符时按抓取失败走校验重抓
 	if data.Block != nil {
+		if data.Number != nil && data.Block.Number().Cmp(data.Number) != 0 {
+			data.Err = fmt.Errorf("%w: requested block %s, got %s", ErrFetchIntegrity, data.Number, data.Block.Number())
+			return s.handleFetchError(ctx, data, data.Number, blockLabel)
+		}
 		blockNum = data.Block.Number()
 	}
 
@@ -201,6 +216,7 @@ func (s *Sequencer) handleBlockLocked(ctx context.Context, data BlockData) error
 
 	if blockNum != nil {
 		s.buffer[blockNum.String()] = data
+		traceBlockData(data, TraceStageBuffered, "expected="+s.expectedBlock.String())
 		s.enforceBufferLimit(ctx)
 	}
 	return nil
@@ -214,7 +230,7 @@ func (s *Sequencer) teleportProgress(ctx context.Context, rangeEnd *big.Int) {
 	if rangeEnd.Cmp(s.expectedBlock) >= 0 {
 		nextBlock := new(big.Int).Add(rangeEnd, big.NewInt(1))
 		s.expectedBlock.Set(nextBlock)
-		s.lastProgressAt = time.Now()
+		s.markProgress()
 		Logger.Debug("sequencer_range_teleport",
 			slog.String("from", s.expectedBlock.String()),
 			slog.String("to", rangeEnd.String()))
@@ -223,31 +239,53 @@ func (s *Sequencer) teleportProgress(ctx context.Context, rangeEnd *big.Int) {
 }
 
 func (s *Sequencer) handleFetchError(ctx context.Context, data BlockData, blockNum *big.Int, blockLabel string) error {
-	Logger.Warn("sequencer_fetch_error_retrying", slog.String("block", blockLabel))
+	Logger.Warn("sequencer_fetch_error_retrying", slog.String("block", blockLabel), slog.String("trace_id", data.TraceID))
 	if blockNum != nil {
-		rpcClient := s.processor.GetRPCClient()
-		if rpcClient != nil {
-			block, err := rpcClient.BlockByNumber(ctx, blockNum)
-			if err == nil {
-				q := ethereum.FilterQuery{FromBlock: blockNum, ToBlock: blockNum, Topics: [][]common.Hash{{TransferEventHash}}}
-				logs, err := rpcClient.FilterLogs(ctx, q)
-				if err == nil {
-					data.Block = block
-					data.Logs = logs
-					data.Err = nil
-					Logger.Info("sequencer_retry_success", slog.String("block", blockNum.String()))
-					// Retry processing with hydrated data
-					return s.handleBlockLocked(ctx, data)
-				}
-			}
+		refetched, err := s.refetchVerified(ctx, blockNum)
+		if err == nil {
+			refetched.RangeEnd = data.RangeEnd
+			refetched.TraceID = data.TraceID
+			Logger.Info("sequencer_retry_success", slog.String("block", blockNum.String()))
+			// Retry processing with hydrated data
+			return s.handleBlockLocked(ctx, refetched)
 		}
+		Logger.Debug("sequencer_retry_failed", slog.String("block", blockLabel), slog.String("err", err.Error()))
 	}
 	Logger.Warn("⚠️ Sequencer: temporary fetch failure, holding block",
 		slog.String("block", blockLabel),
+		slog.String("trace_id", data.TraceID),
 		slog.String("err", data.Err.Error()))
 	return nil
 }
 
+// refetchVerified 重抓单个区块：有 Fetcher 时走其完整性校验路径（监控地址过滤、回执、trace 一并带上）；
+// 否则直接经 RPC 抓取，区块号或日志与请求不符时返回 ErrFetchIntegrity
+func (s *Sequencer) refetchVerified(ctx context.Context, blockNum *big.Int) (BlockData, error) {
+	if s.fetcher != nil {
+		return s.fetcher.FetchVerifiedBlock(ctx, blockNum)
+	}
+	rpcClient := s.processor.GetRPCClient()
+	if rpcClient == nil {
+		return BlockData{}, errors.New("no rpc client")
+	}
+	block, err := rpcClient.BlockByNumber(ctx, blockNum)
+	if err != nil {
+		return BlockData{}, err
+	}
+	q := ethereum.FilterQuery{FromBlock: blockNum, ToBlock: blockNum, Topics: logTopicFilter(nil)}
+	logs, err := rpcClient.FilterLogs(ctx, q)
+	if err != nil {
+		return BlockData{}, err
+	}
+	if block == nil {
+		return BlockData{}, fmt.Errorf("block %s not found", blockNum)
+	}
+	if issue := blockIntegrityIssue(blockNum, block, logs); issue != "" {
+		return BlockData{}, fmt.Errorf("%w: block %s: %s", ErrFetchIntegrity, blockNum, issue)
+	}
+	return BlockData{Number: blockNum, Block: block, Logs: logs}, nil
+}
+
 func (s *Sequencer) enforceBufferLimit(ctx context.Context) {
 	bufferLimit := 1000
 	if s.chainID == 31337 {
@@ -264,6 +302,9 @@ func (s *Sequencer) enforceBufferLimit(ctx context.Context) {
 			}
 		}
 		if minBuffered != nil {
+			if minBuffered.Cmp(s.expectedBlock) > 0 {
+				GetGapSkipper().Record(ctx, s.expectedBlock.Uint64(), minBuffered.Uint64()-1, SkipReasonBufferOverflow)
+			}
 			Logger.Warn("🚫 sequencer_buffer_overflow_skipping_gap",
 				slog.Int("buffer_size", len(s.buffer)),
 				slog.String("skipping_to", minBuffered.String()))
@@ -36,6 +36,9 @@ type Sequencer struct {
 
 	lastProgressAt time.Time // 上次处理成功的时刻
 	gapFillCount   int       // 连续 gap-fill 尝试次数（防止无限重试）
+
+	stallMu sync.RWMutex
+	stall   SequencerStallState // 当前停滞状态（对外暴露给健康检查）
 }
 
 func NewSequencer(processor BlockProcessor, startBlock *big.Int, chainID int64, resultCh <-chan BlockData, fatalErrCh chan<- error, metrics *Metrics) *Sequencer {
@@ -135,6 +138,15 @@ func (s *Sequencer) handleStall(ctx context.Context) {
 	}
 	s.mu.RUnlock()
 
+	// 🧊 已追平且链头不再前进：无块可处理不是索引器故障，不上报停滞也不强制跳块（下一个块尚不存在）
+	if bufferLen == 0 && expectedCopy.IsUint64() && GetUpstreamWatch().WaitingOnUpstream(expectedCopy.Uint64()) {
+		Logger.Info("🧊 Sequencer idle: upstream chain head stalled, waiting for new blocks",
+			slog.String("expected", expectedStr),
+			slog.Duration("idle_time", idleTime))
+		s.clearStall()
+		return
+	}
+
 	if idleTime > 55*time.Second {
 		if minBuffered != nil && minBuffered.Cmp(expectedCopy) > 0 {
 			gapEnd := new(big.Int).Sub(minBuffered, big.NewInt(1))
@@ -146,13 +158,25 @@ func (s *Sequencer) handleStall(ctx context.Context) {
 				slog.Int("buffered_blocks", bufferLen),
 				slog.Int("gap_fill_attempt", s.gapFillCount+1))
 
-			if s.fetcher != nil && s.gapFillCount < 3 {
+			s.reportStall(SequencerStallState{
+				Reason:          stallReasonGap,
+				ExpectedBlock:   expectedStr,
+				GapFrom:         expectedStr,
+				GapTo:           gapEnd.String(),
+				BufferedBlocks:  bufferLen,
+				GapFillAttempts: s.gapFillCount + 1,
+				LastProgressAt:  s.lastProgressAt,
+			})
+
+			// 超出 GapSkipPolicy 上限的缺口不越过，持续 gap-fill 重试
+			withinPolicy := GetGapSkipper().Policy().AllowSkip(uint64(gapSize)) // #nosec G115 - minBuffered > expected
+			if s.fetcher != nil && (s.gapFillCount < 3 || !withinPolicy) {
 				Logger.Info("🛡️ SELF_HEALING: Triggering batch gap-fill",
 					slog.String("from", expectedStr),
 					slog.String("to", gapEnd.String()),
 					slog.Int("attempt", s.gapFillCount+1))
 				go func(gapCtx context.Context) {
-					if serr := s.fetcher.Schedule(gapCtx, expectedCopy, gapEnd); serr != nil {
+					if serr := s.fetcher.Reschedule(WithScheduleSource(gapCtx, ScheduleSourceGapFill), expectedCopy, gapEnd); serr != nil {
 						Logger.Warn("gap_refetch_schedule_failed", "err", serr)
 					}
 				}(ctx)
@@ -177,7 +201,9 @@ func (s *Sequencer) handleStall(ctx context.Context) {
 					s.metrics.SelfHealingTriggered.Inc()
 				}
 
-				s.lastProgressAt = time.Now()
+				GetGapSkipper().Record(ctx, expectedCopy.Uint64(), skippedTo.Uint64(), SkipReasonGapBypass)
+
+				s.markProgress()
 				s.mu.Lock()
 				s.expectedBlock.Set(minBuffered)
 				s.gapFillCount = 0
@@ -189,7 +215,9 @@ func (s *Sequencer) handleStall(ctx context.Context) {
 				slog.Duration("idle_time", idleTime),
 				slog.Int("buffer_size", bufferLen))
 
-			s.lastProgressAt = time.Now()
+			GetGapSkipper().Record(ctx, expectedCopy.Uint64(), expectedCopy.Uint64(), SkipReasonStallSkip)
+
+			s.markProgress()
 			s.mu.Lock()
 			s.expectedBlock.Add(s.expectedBlock, big.NewInt(1))
 			s.gapFillCount = 0
@@ -200,6 +228,12 @@ func (s *Sequencer) handleStall(ctx context.Context) {
 			slog.String("expected", expectedStr),
 			slog.Int("buffer_size", bufferLen),
 			slog.Duration("idle_time", idleTime))
+		s.reportStall(SequencerStallState{
+			Reason:         stallReasonIdle,
+			ExpectedBlock:  expectedStr,
+			BufferedBlocks: bufferLen,
+			LastProgressAt: s.lastProgressAt,
+		})
 	}
 }
 
@@ -281,7 +315,7 @@ func (s *Sequencer) ResetExpectedBlock(block *big.Int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.expectedBlock.Set(block)
-	s.lastProgressAt = time.Now() // 重置闲置计时器
+	s.markProgress() // 重置闲置计时器
 	Logger.Debug("🛡️ Sequencer: Expected block reset by watchdog",
 		slog.String("new_expected", block.String()))
 }
@@ -9,8 +9,9 @@ import (
 	"testing"
 	"time"
 
+	"web3-indexer-go/internal/testkit"
+
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
@@ -69,7 +70,7 @@ func TestSequencerBlockProcessing(t *testing.T) {
 
 	sequencer := NewSequencer(processor, big.NewInt(100), 1, make(chan BlockData), make(chan error, 1), GetMetrics())
 
-	testBlock := createTestBlockForSequencer(big.NewInt(100))
+	testBlock := testkit.Block(100, common.Hash{})
 	err = sequencer.handleBlock(context.Background(), BlockData{Block: testBlock})
 
 	require.NoError(t, err)
@@ -105,16 +106,3 @@ func TestSequencerWithRealRPC(t *testing.T) {
 	sequencer := NewSequencer(processor, big.NewInt(1), 31337, make(chan BlockData), make(chan error, 1), GetMetrics())
 	require.NotNil(t, sequencer)
 }
-
-// 辅助函数：创建测试区块
-func createTestBlockForSequencer(blockNumber *big.Int) *types.Block {
-	header := &types.Header{
-		Number:   blockNumber,
-		GasLimit: 30000000,
-		GasUsed:  15000000,
-		// #nosec G115
-		Time: uint64(time.Now().Unix()),
-		Root: common.Hash{},
-	}
-	return types.NewBlockWithHeader(header).WithBody(types.Body{})
-}
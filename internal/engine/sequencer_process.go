@@ -8,15 +8,19 @@ import (
 )
 
 func (s *Sequencer) processSequentialLocked(ctx context.Context, data BlockData) error {
+	traceBlockData(data, TraceStageSequenced, "")
 	if err := s.processor.ProcessBlockWithRetry(ctx, data, 3); err != nil {
 		if _, ok := err.(ReorgError); ok {
 			return s.handleReorgLocked(ctx, data)
 		}
 		return err
 	}
+	if data.Number != nil {
+		reorgTimer.resumed(data.Number.Uint64(), time.Now())
+	}
 	s.expectedBlock.Add(s.expectedBlock, big.NewInt(1))
-	s.lastProgressAt = time.Now() // 💡 成功推进，重置计时
-	s.gapFillCount = 0            // 重置 gap-fill 计数器
+	s.markProgress()   // 💡 成功推进，重置计时
+	s.gapFillCount = 0 // 重置 gap-fill 计数器
 	return nil
 }
 
@@ -43,8 +47,14 @@ func (s *Sequencer) processBufferContinuationsLocked(ctx context.Context) {
 
 func (s *Sequencer) handleReorgLocked(ctx context.Context, data BlockData) error {
 	blockNum := data.Block.Number()
+	reorgTimer.detect(blockNum.Uint64(), time.Now())
+	traceBlockData(data, TraceStageReorg, "parent="+data.Block.ParentHash().Hex())
+	if s.metrics != nil {
+		s.metrics.RecordReorgDetected()
+	}
 	if s.fetcher != nil {
 		s.fetcher.Pause()
+		s.fetcher.InvalidateFrom(blockNum)
 	}
 	for numStr := range s.buffer {
 		num, _ := new(big.Int).SetString(numStr, 10)
@@ -0,0 +1,66 @@
+package engine
+
+import "time"
+
+const (
+	stallReasonGap  = "gap"  // 缓冲区存在缺口，等待 gap-fill
+	stallReasonIdle = "idle" // 长时间无进度
+)
+
+// SequencerStallState Sequencer 停滞状态（供 /healthz 与 /api/status 对外暴露，外部监控据此告警）
+type SequencerStallState struct {
+	Stalled         bool      `json:"stalled"`
+	Reason          string    `json:"reason,omitempty"`
+	ExpectedBlock   string    `json:"expected_block,omitempty"`
+	GapFrom         string    `json:"gap_from,omitempty"`
+	GapTo           string    `json:"gap_to,omitempty"`
+	BufferedBlocks  int       `json:"buffered_blocks"`
+	GapFillAttempts int       `json:"gap_fill_attempts"`
+	IdleSeconds     float64   `json:"idle_seconds"`
+	LastProgressAt  time.Time `json:"last_progress_at"`
+	DetectedAt      time.Time `json:"detected_at"`
+}
+
+// reportStall 记录停滞状态并同步到 GlobalState
+func (s *Sequencer) reportStall(state SequencerStallState) {
+	state.Stalled = true
+	state.DetectedAt = time.Now()
+
+	s.stallMu.Lock()
+	s.stall = state
+	s.stallMu.Unlock()
+
+	GetGlobalState().SetSequencerStall(state)
+}
+
+// markProgress 推进成功：重置闲置计时并清除停滞状态
+func (s *Sequencer) markProgress() {
+	s.lastProgressAt = time.Now()
+	s.clearStall()
+}
+
+// clearStall 清除停滞状态（已停滞时同步到 GlobalState）
+func (s *Sequencer) clearStall() {
+	s.stallMu.Lock()
+	wasStalled := s.stall.Stalled
+	s.stall = SequencerStallState{}
+	s.stallMu.Unlock()
+
+	if wasStalled {
+		GetGlobalState().SetSequencerStall(SequencerStallState{})
+	}
+}
+
+// StallState 返回当前停滞状态（IdleSeconds 按读取时刻计算）
+func (s *Sequencer) StallState() SequencerStallState {
+	s.stallMu.RLock()
+	defer s.stallMu.RUnlock()
+	return s.stall.withIdle()
+}
+
+func (st SequencerStallState) withIdle() SequencerStallState {
+	if st.Stalled && !st.LastProgressAt.IsZero() {
+		st.IdleSeconds = time.Since(st.LastProgressAt).Seconds()
+	}
+	return st
+}
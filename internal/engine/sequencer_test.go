@@ -6,8 +6,12 @@ import (
 	"testing"
 	"time"
 
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // MockProcessor implements BlockProcessor for testing
@@ -91,3 +95,67 @@ func TestSequencer_GapFillTrigger(_ *testing.T) {
 	// Just verify handleStall doesn't panic
 	seq.handleStall(context.Background())
 }
+
+func TestSequencer_StallStateSurfaced(t *testing.T) {
+	resultCh := make(chan BlockData, 1)
+	seq := NewSequencer(&MockProcessor{}, big.NewInt(100), 1, resultCh, make(chan error, 1), nil)
+
+	seq.mu.Lock()
+	seq.lastProgressAt = time.Now().Add(-40 * time.Second)
+	seq.mu.Unlock()
+
+	seq.handleStall(context.Background())
+
+	stall := seq.StallState()
+	assert.True(t, stall.Stalled)
+	assert.Equal(t, stallReasonIdle, stall.Reason)
+	assert.Equal(t, "100", stall.ExpectedBlock)
+	assert.GreaterOrEqual(t, stall.IdleSeconds, 40.0)
+	assert.True(t, GetGlobalState().SequencerStall().Stalled)
+
+	// Progress clears the stall everywhere
+	err := seq.handleBatch(context.Background(), []BlockData{{
+		Number: big.NewInt(100),
+		Block:  types.NewBlockWithHeader(&types.Header{Number: big.NewInt(100)}),
+	}})
+	assert.NoError(t, err)
+	assert.False(t, seq.StallState().Stalled)
+	assert.False(t, GetGlobalState().SequencerStall().Stalled)
+}
+
+// recordingProcessor 记录处理过的区块哈希，GetRPCClient 返回指定客户端
+type recordingProcessor struct {
+	MockProcessor
+	client    RPCClient
+	processed []common.Hash
+}
+
+func (p *recordingProcessor) ProcessBlockWithRetry(_ context.Context, data BlockData, _ int) error {
+	p.processed = append(p.processed, data.Block.Hash())
+	return nil
+}
+
+func (p *recordingProcessor) GetRPCClient() RPCClient {
+	return p.client
+}
+
+// TestSequencer_RejectsMismatchedBlockNumber 区块号与请求不符的结果不按区块自身的号码入列，而是校验重抓请求的区块
+func TestSequencer_RejectsMismatchedBlockNumber(t *testing.T) {
+	ctx := context.Background()
+	wrong := BlockData{Number: big.NewInt(100), Block: testkit.Block(101, common.Hash{})}
+
+	// 无法重抓：暂扣该块，既不处理也不当作 101 缓冲
+	held := &recordingProcessor{}
+	seq := NewSequencer(held, big.NewInt(100), 1, make(chan BlockData), make(chan error, 1), nil)
+	require.NoError(t, seq.handleBlock(ctx, wrong))
+	assert.Empty(t, held.processed)
+	assert.Empty(t, seq.buffer)
+	assert.Equal(t, "100", seq.expectedBlock.String())
+
+	// 可重抓：经完整性校验取得正确的 100 后处理
+	proc := &recordingProcessor{client: &flakyBlockClient{}}
+	seq = NewSequencer(proc, big.NewInt(100), 1, make(chan BlockData), make(chan error, 1), nil)
+	require.NoError(t, seq.handleBlock(ctx, wrong))
+	assert.Equal(t, []common.Hash{testkit.Block(100, common.Hash{}).Hash()}, proc.processed)
+	assert.Equal(t, "101", seq.expectedBlock.String())
+}
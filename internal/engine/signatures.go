@@ -4,14 +4,26 @@ import "github.com/ethereum/go-ethereum/common"
 
 var (
 	// TransferEventHash: Transfer(address,address,uint256)
-	TransferEventHash = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f5514cfc0afda6")
+	TransferEventHash = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
 
 	// ApprovalEventHash: Approval(address,address,uint256)
 	ApprovalEventHash = common.HexToHash("0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925")
 
+	// ApprovalForAllEventHash (ERC721 / ERC1155): ApprovalForAll(address,address,bool)
+	ApprovalForAllEventHash = common.HexToHash("0x17307eab39ab6107e8899845ad3d59bd9653f200f220920489ca2b5937696c31")
+
+	// DepositEventHash (WETH9): Deposit(address,uint256)，包装原生币
+	DepositEventHash = common.HexToHash("0xe1fffcc4923d04b559f4d29a8bfc6cda04eb5b0d3c460751c2402c5c5cc9109c")
+
+	// WithdrawalEventHash (WETH9): Withdrawal(address,uint256)，解包为原生币
+	WithdrawalEventHash = common.HexToHash("0x7fcf532c15f0a6db0bd6d0e038bea71d30d808c7d98cb3bf7268a95bf5081b65")
+
 	// SwapEventHash (Uniswap V3): Swap(address,address,int256,int256,uint160,uint128,int24)
 	SwapEventHash = common.HexToHash("0xc42079f94a6350d7e5735f2a1538197108a858e5111b9ad0a72f5db98e4c0388")
 
+	// SwapV2EventHash (Uniswap V2 及其分叉): Swap(address,uint256,uint256,uint256,uint256,address)
+	SwapV2EventHash = common.HexToHash("0xd78ad95fa46c994b6551d0da85fc275fe613ce37657fb8d5e3d130840159d822")
+
 	// MintEventHash (Common ERC20/721 Mint)
 	MintEventHash = common.HexToHash("0x0f6711612c5b94d76f9d34343434343434343434343434343434343434343434")
 )
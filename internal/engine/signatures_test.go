@@ -0,0 +1,33 @@
+package engine
+
+import (
+	"testing"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+// 事件签名常量必须等于规范签名的 Keccak，否则过滤器与解析器都匹配不到真实日志
+func TestSignatures_MatchKeccak(t *testing.T) {
+	assert.Equal(t, crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")), TransferEventHash)
+	assert.Equal(t, crypto.Keccak256Hash([]byte("Approval(address,address,uint256)")), ApprovalEventHash)
+	assert.Equal(t, crypto.Keccak256Hash([]byte("ApprovalForAll(address,address,bool)")), ApprovalForAllEventHash)
+	assert.Equal(t, crypto.Keccak256Hash([]byte("Deposit(address,uint256)")), DepositEventHash)
+	assert.Equal(t, crypto.Keccak256Hash([]byte("Withdrawal(address,uint256)")), WithdrawalEventHash)
+	assert.Equal(t, testkit.TransferTopic, TransferEventHash)
+}
+
+func TestProcessLog_ParsesTestkitTransfer(t *testing.T) {
+	p := &Processor{metrics: GetMetrics(), chainID: 1}
+	p.SetTokenFilter(NewTokenFilter(nil, nil))
+	block := testkit.Block(7, common.Hash{})
+	activity := p.ProcessLog(testkit.TransferLogs(block, testkit.USDC, 1)[0])
+	if assert.NotNil(t, activity) {
+		assert.Equal(t, models.ActivityTransfer, activity.Type)
+		assert.Equal(t, "1000", activity.Amount.String())
+	}
+}
@@ -139,6 +139,7 @@ func (s *SyntheticTransferInjector) generateMockTransfer(seqNum int64) *Synthesi
 		To:           to,
 		Amount:       amount,
 		Timestamp:    time.Now().Unix(),
+		Type:         "TRANSFER",
 		Synthesized:  true,
 	}
 
@@ -163,5 +164,7 @@ type SynthesizedTransfer struct {
 	To           common.Address
 	Amount       *big.Int
 	Timestamp    int64
-	Synthesized  bool // 标记为合成数据
+	Symbol       string // 代币符号（可为空）
+	Type         string // 活动类型（SWAP / ARBITRAGE / FLASHLOAN / MEV / TRANSFER）
+	Synthesized  bool   // 标记为合成数据
 }
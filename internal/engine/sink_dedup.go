@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"context"
+	"sync"
+
+	"web3-indexer-go/internal/models"
+)
+
+// SinkRecord 下游 Sink / Webhook 统一载荷信封
+// 每条记录都携带确定性的幂等键，消费方可据此在重试或 Reorg 回放时去重
+type SinkRecord struct {
+	IdempotencyKey string      `json:"idempotency_key"`
+	ChainID        int64       `json:"chain_id"`
+	Kind           string      `json:"kind"` // "transfer" or "block"
+	Data           interface{} `json:"data"`
+	SchemaVersion  int         `json:"schema_version"`
+}
+
+const (
+	sinkKindTransfer = "transfer"
+	sinkKindBlock    = "block"
+)
+
+// NewTransferRecord 构造带幂等键的转账载荷
+func NewTransferRecord(chainID int64, t models.Transfer) SinkRecord {
+	return SinkRecord{IdempotencyKey: t.IdempotencyKey(chainID), ChainID: chainID, Kind: sinkKindTransfer, Data: t, SchemaVersion: models.EventSchemaVersion}
+}
+
+// NewBlockRecord 构造带幂等键的区块载荷
+func NewBlockRecord(chainID int64, b models.Block) SinkRecord {
+	return SinkRecord{IdempotencyKey: b.IdempotencyKey(chainID), ChainID: chainID, Kind: sinkKindBlock, Data: b, SchemaVersion: models.EventSchemaVersion}
+}
+
+// idempotencySet 有界的已见键集合（FIFO 淘汰），防止长时间运行时内存无限增长
+type idempotencySet struct {
+	mu    sync.Mutex
+	seen  map[string]struct{}
+	order []string
+	next  int
+}
+
+func newIdempotencySet(capacity int) *idempotencySet {
+	if capacity <= 0 {
+		capacity = 100000
+	}
+	return &idempotencySet{
+		seen:  make(map[string]struct{}, capacity),
+		order: make([]string, 0, capacity),
+	}
+}
+
+// has 判断键是否已写入过
+func (s *idempotencySet) has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.seen[key]
+	return ok
+}
+
+// mark 记录已成功写入的键
+func (s *idempotencySet) mark(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.seen[key]; ok {
+		return
+	}
+	if len(s.order) < cap(s.order) {
+		s.order = append(s.order, key)
+	} else {
+		delete(s.seen, s.order[s.next])
+		s.order[s.next] = key
+		s.next = (s.next + 1) % len(s.order)
+	}
+	s.seen[key] = struct{}{}
+}
+
+// DedupSink 幂等去重包装器
+// 包装任意 DataSink，按幂等键过滤已写入过的转账与区块，
+// 使 Reorg 回放或重试不会在下游重复计数
+type DedupSink struct {
+	inner   DataSink
+	name    string
+	chainID int64
+	keys    *idempotencySet
+	metrics *Metrics
+}
+
+// NewDedupSink 创建去重包装器，capacity 为记忆的最近键数量
+func NewDedupSink(inner DataSink, name string, chainID int64, capacity int) *DedupSink {
+	return &DedupSink{
+		inner:   inner,
+		name:    name,
+		chainID: chainID,
+		keys:    newIdempotencySet(capacity),
+		metrics: GetMetrics(),
+	}
+}
+
+// WriteTransfers 仅转发未写入过的转账；下游写入成功后才记录键，失败时允许重试
+func (d *DedupSink) WriteTransfers(ctx context.Context, transfers []models.Transfer) error {
+	fresh := make([]models.Transfer, 0, len(transfers))
+	keys := make([]string, 0, len(transfers))
+	batch := make(map[string]struct{}, len(transfers))
+	for _, t := range transfers {
+		key := t.IdempotencyKey(d.chainID)
+		if _, dup := batch[key]; dup || d.keys.has(key) {
+			d.recordDuplicate(sinkKindTransfer)
+			continue
+		}
+		batch[key] = struct{}{}
+		fresh = append(fresh, t)
+		keys = append(keys, key)
+	}
+	if len(fresh) == 0 {
+		return nil
+	}
+	if err := d.inner.WriteTransfers(ctx, fresh); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		d.keys.mark(key)
+	}
+	return nil
+}
+
+// WriteBlocks 仅转发未写入过的区块
+func (d *DedupSink) WriteBlocks(ctx context.Context, blocks []models.Block) error {
+	fresh := make([]models.Block, 0, len(blocks))
+	keys := make([]string, 0, len(blocks))
+	batch := make(map[string]struct{}, len(blocks))
+	for _, b := range blocks {
+		key := b.IdempotencyKey(d.chainID)
+		if _, dup := batch[key]; dup || d.keys.has(key) {
+			d.recordDuplicate(sinkKindBlock)
+			continue
+		}
+		batch[key] = struct{}{}
+		fresh = append(fresh, b)
+		keys = append(keys, key)
+	}
+	if len(fresh) == 0 {
+		return nil
+	}
+	if err := d.inner.WriteBlocks(ctx, fresh); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		d.keys.mark(key)
+	}
+	return nil
+}
+
+// Seed 将已转发过的区块与转账标记为已见（重启后由持久化的 sink 水位预填）
+func (d *DedupSink) Seed(blocks []models.Block, transfers []models.Transfer) {
+	for _, b := range blocks {
+		d.keys.mark(b.IdempotencyKey(d.chainID))
+	}
+	for _, t := range transfers {
+		d.keys.mark(t.IdempotencyKey(d.chainID))
+	}
+}
+
+func (d *DedupSink) Close() error {
+	return d.inner.Close()
+}
+
+func (d *DedupSink) recordDuplicate(kind string) {
+	if d.metrics != nil {
+		d.metrics.SinkDuplicatesSkipped.WithLabelValues(d.name, kind).Inc()
+	}
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"web3-indexer-go/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSink struct {
+	transfers []models.Transfer
+	blocks    []models.Block
+	fail      bool
+}
+
+func (r *recordingSink) WriteTransfers(_ context.Context, transfers []models.Transfer) error {
+	if r.fail {
+		return errors.New("sink unavailable")
+	}
+	r.transfers = append(r.transfers, transfers...)
+	return nil
+}
+
+func (r *recordingSink) WriteBlocks(_ context.Context, blocks []models.Block) error {
+	if r.fail {
+		return errors.New("sink unavailable")
+	}
+	r.blocks = append(r.blocks, blocks...)
+	return nil
+}
+
+func (r *recordingSink) Close() error { return nil }
+
+func TestTransfer_IdempotencyKeyIsDeterministic(t *testing.T) {
+	tr := models.Transfer{BlockNumber: models.NewBigInt(100), TxHash: "0xABC", LogIndex: 3}
+	assert.Equal(t, "11155111:100:0xabc:3", tr.IdempotencyKey(11155111))
+	assert.Equal(t, tr.IdempotencyKey(1), tr.IdempotencyKey(1))
+	assert.NotEqual(t, tr.IdempotencyKey(1), tr.IdempotencyKey(2))
+}
+
+func TestDedupSink_SkipsReplayedRecords(t *testing.T) {
+	inner := &recordingSink{}
+	sink := NewDedupSink(inner, "test", 1, 10)
+	ctx := context.Background()
+
+	transfers := []models.Transfer{
+		{BlockNumber: models.BigInt{Int: big.NewInt(10)}, TxHash: "0x01", LogIndex: 0},
+		{BlockNumber: models.BigInt{Int: big.NewInt(10)}, TxHash: "0x01", LogIndex: 1},
+	}
+	block := models.Block{Number: models.NewBigInt(10), Hash: "0xaa"}
+
+	assert.NoError(t, sink.WriteTransfers(ctx, transfers))
+	assert.NoError(t, sink.WriteBlocks(ctx, []models.Block{block}))
+
+	// Reorg 回放 / 重试：相同幂等键不应再次写入下游
+	assert.NoError(t, sink.WriteTransfers(ctx, transfers))
+	assert.NoError(t, sink.WriteBlocks(ctx, []models.Block{block}))
+
+	assert.Len(t, inner.transfers, 2)
+	assert.Len(t, inner.blocks, 1)
+}
+
+func TestDedupSink_FailedWriteCanBeRetried(t *testing.T) {
+	inner := &recordingSink{fail: true}
+	sink := NewDedupSink(inner, "test", 1, 10)
+	ctx := context.Background()
+	transfers := []models.Transfer{{BlockNumber: models.NewBigInt(1), TxHash: "0x01"}}
+
+	assert.Error(t, sink.WriteTransfers(ctx, transfers))
+
+	inner.fail = false
+	assert.NoError(t, sink.WriteTransfers(ctx, transfers))
+	assert.Len(t, inner.transfers, 1)
+}
+
+func TestDedupSink_SeedSkipsPreviouslyEmitted(t *testing.T) {
+	inner := &recordingSink{}
+	sink := NewDedupSink(inner, "test", 1, 10)
+	ctx := context.Background()
+	emitted := models.Block{Number: models.NewBigInt(10), Hash: "0xaa"}
+	transfer := models.Transfer{BlockNumber: models.NewBigInt(10), TxHash: "0x01"}
+	sink.Seed([]models.Block{emitted}, []models.Transfer{transfer})
+
+	reorged := models.Block{Number: models.NewBigInt(10), Hash: "0xbb"}
+	assert.NoError(t, sink.WriteBlocks(ctx, []models.Block{emitted, reorged}))
+	assert.NoError(t, sink.WriteTransfers(ctx, []models.Transfer{transfer}))
+
+	assert.Equal(t, []models.Block{reorged}, inner.blocks)
+	assert.Empty(t, inner.transfers)
+}
+
+func TestIdempotencySet_EvictsOldestKeys(t *testing.T) {
+	set := newIdempotencySet(2)
+	set.mark("a")
+	set.mark("b")
+	set.mark("c")
+
+	assert.False(t, set.has("a"))
+	assert.True(t, set.has("b"))
+	assert.True(t, set.has("c"))
+}
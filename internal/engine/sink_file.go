@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"web3-indexer-go/internal/models"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+// defaultFileSinkMaxBytes 单个 JSONL 文件的默认轮转阈值
+const defaultFileSinkMaxBytes = 256 << 20
+
+// FileSinkConfig JSONL 文件导出配置
+type FileSinkConfig struct {
+	Dir      string // 输出目录
+	Prefix   string // 文件名前缀（默认 "events"）
+	MaxBytes int64  // 单文件写入字节数上限，超过后轮转（<=0 使用默认值）
+	Compress bool   // 是否以 LZ4 帧压缩（文件后缀 .jsonl.lz4）
+	ChainID  int64
+}
+
+// FileSink 以 JSON Lines 追加写出区块/转账事件（每行一个 SinkRecord），
+// 按文件大小轮转，可选 LZ4 压缩；适用于离线环境导出，也可作为测试中的默认 sink
+type FileSink struct {
+	cfg FileSinkConfig
+
+	mu      sync.Mutex
+	file    *os.File
+	counter *countingWriter
+	lz4w    *lz4.Writer
+	w       io.Writer
+	path    string
+	index   int
+	lines   int // 当前文件已写出的记录数
+	files   []string
+}
+
+// countingWriter 统计实际落盘字节数（压缩模式下为压缩后大小）
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// NewFileSink 创建 JSONL 文件 sink 并打开第一个文件
+func NewFileSink(cfg FileSinkConfig) (*FileSink, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("file sink: empty dir")
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "events"
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = defaultFileSinkMaxBytes
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("file sink: create dir: %w", err)
+	}
+
+	s := &FileSink{cfg: cfg}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// open 打开下一个轮转文件：<prefix>-<yyyymmdd_hhmmss>-<index>.jsonl[.lz4]
+func (s *FileSink) open() error {
+	s.index++
+	name := fmt.Sprintf("%s-%s-%04d.jsonl", s.cfg.Prefix, time.Now().UTC().Format("20060102_150405"), s.index)
+	if s.cfg.Compress {
+		name += ".lz4"
+	}
+	path := filepath.Join(s.cfg.Dir, name)
+
+	// #nosec G304 - 导出目录由系统配置控制
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("file sink: open %s: %w", path, err)
+	}
+
+	s.file = f
+	s.path = path
+	s.counter = &countingWriter{w: f}
+	s.w = s.counter
+	s.lz4w = nil
+	if s.cfg.Compress {
+		s.lz4w = lz4.NewWriter(s.counter)
+		s.w = s.lz4w
+	}
+	s.files = append(s.files, path)
+	s.lines = 0
+	return nil
+}
+
+// closeCurrent 刷新并关闭当前文件（LZ4 模式下写出帧尾）
+func (s *FileSink) closeCurrent() error {
+	if s.file == nil {
+		return nil
+	}
+	var err error
+	if s.lz4w != nil {
+		err = s.lz4w.Close()
+	}
+	if cerr := s.file.Close(); err == nil {
+		err = cerr
+	}
+	s.file, s.lz4w, s.w = nil, nil, nil
+	return err
+}
+
+// rotateIfNeeded 当前文件超过阈值时切换到新文件
+func (s *FileSink) rotateIfNeeded() error {
+	if s.counter.n < s.cfg.MaxBytes {
+		return nil
+	}
+	return s.rotateLocked()
+}
+
+func (s *FileSink) rotateLocked() error {
+	prev := s.path
+	if err := s.closeCurrent(); err != nil {
+		return fmt.Errorf("file sink: close %s: %w", prev, err)
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+	Logger.Info("🗂️ [FileSink] Rotated", "from", prev, "to", s.path)
+	return nil
+}
+
+// writeRecords 逐行写出记录，批次结束后刷新并检查轮转
+func (s *FileSink) writeRecords(records []SinkRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.w == nil {
+		return fmt.Errorf("file sink: closed")
+	}
+	for _, rec := range records {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			continue
+		}
+		data = append(data, '\n')
+		if _, err := s.w.Write(data); err != nil {
+			return fmt.Errorf("file sink: write: %w", err)
+		}
+		s.lines++
+	}
+	if s.lz4w != nil {
+		if err := s.lz4w.Flush(); err != nil {
+			return fmt.Errorf("file sink: flush: %w", err)
+		}
+	}
+	return s.rotateIfNeeded()
+}
+
+func (s *FileSink) WriteTransfers(_ context.Context, transfers []models.Transfer) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+	records := make([]SinkRecord, 0, len(transfers))
+	for _, t := range transfers {
+		records = append(records, NewTransferRecord(s.cfg.ChainID, t))
+	}
+	return s.writeRecords(records)
+}
+
+func (s *FileSink) WriteBlocks(_ context.Context, blocks []models.Block) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+	records := make([]SinkRecord, 0, len(blocks))
+	for _, b := range blocks {
+		records = append(records, NewBlockRecord(s.cfg.ChainID, b))
+	}
+	return s.writeRecords(records)
+}
+
+// Rotate 强制切换到新文件（当前文件为空时不切换），供按时间阈值轮转
+func (s *FileSink) Rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.w == nil || s.lines == 0 {
+		return nil
+	}
+	return s.rotateLocked()
+}
+
+// CurrentPath 返回正在写入的文件路径（已关闭时为空）
+func (s *FileSink) CurrentPath() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.w == nil {
+		return ""
+	}
+	return s.path
+}
+
+// Files 返回已写出的文件路径（按创建顺序）
+func (s *FileSink) Files() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.files...)
+}
+
+// Close 关闭当前文件；未写入任何记录的空文件直接删除
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.w == nil {
+		return nil
+	}
+	if err := s.closeCurrent(); err != nil {
+		return err
+	}
+	if s.lines == 0 {
+		s.files = s.files[:len(s.files)-1]
+		return os.Remove(s.path)
+	}
+	return nil
+}
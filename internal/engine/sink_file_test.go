@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"os"
+	"testing"
+
+	"web3-indexer-go/internal/models"
+
+	"github.com/pierrec/lz4/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readSinkRecords(t *testing.T, path string, compressed bool) []SinkRecord {
+	t.Helper()
+	f, err := os.Open(path) // #nosec G304 - test temp dir
+	require.NoError(t, err)
+	defer f.Close()
+
+	var r io.Reader = f
+	if compressed {
+		r = lz4.NewReader(f)
+	}
+	var out []SinkRecord
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		var rec SinkRecord
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &rec))
+		out = append(out, rec)
+	}
+	require.NoError(t, sc.Err())
+	return out
+}
+
+func testTransfers(block int64, n int) []models.Transfer {
+	out := make([]models.Transfer, n)
+	for i := range out {
+		out[i] = models.Transfer{
+			BlockNumber:  models.BigInt{Int: big.NewInt(block)},
+			TxHash:       "0xabc",
+			LogIndex:     uint(i),
+			From:         "0x1",
+			To:           "0x2",
+			TokenAddress: "0x3",
+			Amount:       models.NewUint256(uint64(i + 1)),
+		}
+	}
+	return out
+}
+
+func TestFileSink_WritesJSONLinesAndRotates(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(FileSinkConfig{Dir: dir, MaxBytes: 512, ChainID: 1})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, sink.WriteBlocks(ctx, []models.Block{{Number: models.BigInt{Int: big.NewInt(7)}, Hash: "0xh"}}))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, sink.WriteTransfers(ctx, testTransfers(7, 2)))
+	}
+	require.NoError(t, sink.Close())
+
+	files := sink.Files()
+	assert.Greater(t, len(files), 1, "small MaxBytes should force rotation")
+
+	var total []SinkRecord
+	for _, f := range files {
+		total = append(total, readSinkRecords(t, f, false)...)
+	}
+	require.Len(t, total, 11)
+	assert.Equal(t, sinkKindBlock, total[0].Kind)
+	assert.Equal(t, "1:7:0xh", total[0].IdempotencyKey)
+	assert.Equal(t, sinkKindTransfer, total[1].Kind)
+	assert.Equal(t, "1:7:0xabc:0", total[1].IdempotencyKey)
+}
+
+func TestFileSink_LZ4RoundTrip(t *testing.T) {
+	sink, err := NewFileSink(FileSinkConfig{Dir: t.TempDir(), Prefix: "export", Compress: true, ChainID: 5})
+	require.NoError(t, err)
+	require.NoError(t, sink.WriteTransfers(context.Background(), testTransfers(9, 3)))
+	require.NoError(t, sink.Close())
+
+	files := sink.Files()
+	require.Len(t, files, 1)
+	assert.Contains(t, files[0], "export-")
+	assert.Contains(t, files[0], ".jsonl.lz4")
+
+	records := readSinkRecords(t, files[0], true)
+	require.Len(t, records, 3)
+	assert.Equal(t, "5:9:0xabc:2", records[2].IdempotencyKey)
+
+	assert.Error(t, sink.WriteTransfers(context.Background(), testTransfers(9, 1)), "writes after Close must fail")
+}
+
+func TestProcessor_AddSinkFansOut(t *testing.T) {
+	p := &Processor{chainID: 1}
+	first, second := &recordingSink{}, &recordingSink{}
+	p.AddSink(first)
+	p.AddSink(second)
+
+	_, ok := p.GetSink().(*DedupSink)
+	assert.True(t, ok, "fan-out stays behind a single dedup layer")
+
+	block := models.Block{Number: models.BigInt{Int: big.NewInt(3)}, Hash: "0xb"}
+	p.writeSink(context.Background(), block, testTransfers(3, 2))
+	p.writeSink(context.Background(), block, testTransfers(3, 2))
+
+	assert.Len(t, first.transfers, 2)
+	assert.Len(t, second.transfers, 2)
+	assert.Len(t, second.blocks, 1)
+}
@@ -18,10 +18,11 @@ type Lz4Sink struct {
 	lz4Writer *lz4.Writer
 	mu        sync.Mutex
 	path      string
+	chainID   int64
 	suspended bool // 🚀 空间不足时自动挂起
 }
 
-func NewLz4Sink(path string) (*Lz4Sink, error) {
+func NewLz4Sink(path string, chainID int64) (*Lz4Sink, error) {
 	// #nosec G304 - 录制路径由系统配置控制
 	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
 	if err != nil {
@@ -36,6 +37,7 @@ func NewLz4Sink(path string) (*Lz4Sink, error) {
 		file:      f,
 		lz4Writer: zw,
 		path:      path,
+		chainID:   chainID,
 	}, nil
 }
 
@@ -67,7 +69,7 @@ func (s *Lz4Sink) WriteTransfers(_ context.Context, transfers []models.Transfer)
 	}
 
 	for _, t := range transfers {
-		data, err := json.Marshal(t)
+		data, err := json.Marshal(NewTransferRecord(s.chainID, t))
 		if err != nil {
 			continue
 		}
@@ -92,7 +94,7 @@ func (s *Lz4Sink) WriteBlocks(_ context.Context, blocks []models.Block) error {
 	}
 
 	for _, b := range blocks {
-		data, err := json.Marshal(b)
+		data, err := json.Marshal(NewBlockRecord(s.chainID, b))
 		if err != nil {
 			continue
 		}
@@ -0,0 +1,318 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"web3-indexer-go/internal/models"
+)
+
+const (
+	// minObjectPartSize S3 要求除最后一片外每片至少 5 MiB
+	minObjectPartSize = 5 << 20
+	// defaultObjectPartSize 默认分片大小
+	defaultObjectPartSize = 8 << 20
+	// objectUploadStateSuffix 分片上传断点文件后缀（与 spool 文件同目录）
+	objectUploadStateSuffix = ".upload.json"
+	// objectSpoolPrefix spool 文件名前缀
+	objectSpoolPrefix = "events"
+)
+
+// ObjectSinkConfig 对象存储 sink 配置
+type ObjectSinkConfig struct {
+	SpoolDir      string        // 本地暂存目录（上传完成前的 LZ4 文件与断点状态）
+	KeyPrefix     string        // 对象键前缀，例如 "indexer/mainnet"
+	MaxBytes      int64         // 单文件大小阈值，超过后封存并上传
+	FlushInterval time.Duration // 时间阈值：到期后即使未满也封存并上传
+	PartSize      int64         // 分片大小（不足 5 MiB 时按 5 MiB）
+	ChainID       int64
+}
+
+// objectUploadState 可恢复的分片上传断点（重启后跳过已完成的分片）
+type objectUploadState struct {
+	Key      string         `json:"key"`
+	UploadID string         `json:"upload_id"`
+	Parts    []UploadedPart `json:"parts"`
+}
+
+// ObjectSink 将事件批量写入本地 LZ4 JSONL 文件，按大小或时间阈值封存后
+// 以分片方式上传到 S3/GCS，供数据湖直接摄取。
+// 上传成功后删除本地文件；失败或进程重启时根据断点文件续传。
+type ObjectSink struct {
+	cfg      ObjectSinkConfig
+	spool    *FileSink
+	uploader ObjectUploader
+	metrics  *Metrics
+
+	kick      chan struct{}
+	uploadMu  sync.Mutex   // 串行化上传轮次
+	lastFiles atomic.Int64 // 已知的 spool 文件数，变化即说明发生了轮转
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewObjectSink 创建对象存储 sink 并启动后台封存/上传循环
+func NewObjectSink(cfg ObjectSinkConfig, uploader ObjectUploader) (*ObjectSink, error) {
+	if uploader == nil {
+		return nil, fmt.Errorf("object sink: nil uploader")
+	}
+	if cfg.SpoolDir == "" {
+		cfg.SpoolDir = "spool"
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 5 * time.Minute
+	}
+	if cfg.PartSize <= 0 {
+		cfg.PartSize = defaultObjectPartSize
+	}
+	cfg.PartSize = max(cfg.PartSize, minObjectPartSize)
+
+	spool, err := NewFileSink(FileSinkConfig{
+		Dir:      cfg.SpoolDir,
+		Prefix:   objectSpoolPrefix,
+		MaxBytes: cfg.MaxBytes,
+		Compress: true,
+		ChainID:  cfg.ChainID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &ObjectSink{
+		cfg:      cfg,
+		spool:    spool,
+		uploader: uploader,
+		metrics:  GetMetrics(),
+		kick:     make(chan struct{}, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	s.lastFiles.Store(1)
+	s.wg.Add(1)
+	go s.loop()
+	s.notify() // 启动即尝试续传上次遗留的文件
+	return s, nil
+}
+
+func (s *ObjectSink) WriteTransfers(ctx context.Context, transfers []models.Transfer) error {
+	err := s.spool.WriteTransfers(ctx, transfers)
+	s.checkRotated()
+	return err
+}
+
+func (s *ObjectSink) WriteBlocks(ctx context.Context, blocks []models.Block) error {
+	err := s.spool.WriteBlocks(ctx, blocks)
+	s.checkRotated()
+	return err
+}
+
+// Close 封存当前文件并做最后一轮上传（限时），未完成的部分下次启动续传
+func (s *ObjectSink) Close() error {
+	s.cancel()
+	s.wg.Wait()
+	err := s.spool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	s.uploadPending(ctx)
+	return err
+}
+
+// checkRotated 按大小轮转产生新文件时唤醒上传循环
+func (s *ObjectSink) checkRotated() {
+	n := int64(len(s.spool.Files()))
+	if s.lastFiles.Swap(n) != n {
+		s.notify()
+	}
+}
+
+func (s *ObjectSink) notify() {
+	select {
+	case s.kick <- struct{}{}:
+	default:
+	}
+}
+
+// loop 时间阈值到期时封存当前文件；收到唤醒或到期时上传所有已封存文件
+func (s *ObjectSink) loop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.spool.Rotate(); err != nil {
+				Logger.Warn("🪣 [ObjectSink] Rotate failed", "err", err)
+			}
+			s.uploadPending(s.ctx)
+		case <-s.kick:
+			s.uploadPending(s.ctx)
+		}
+	}
+}
+
+// sealedFiles 返回 spool 目录中已封存（非当前写入）的文件，按文件名排序
+func (s *ObjectSink) sealedFiles() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(s.cfg.SpoolDir, objectSpoolPrefix+"-*.jsonl.lz4"))
+	if err != nil {
+		return nil, err
+	}
+	current := s.spool.CurrentPath()
+	sealed := matches[:0]
+	for _, m := range matches {
+		if m != current {
+			sealed = append(sealed, m)
+		}
+	}
+	sort.Strings(sealed)
+	return sealed, nil
+}
+
+// uploadPending 逐个上传已封存文件，单个失败不影响后续文件，下一轮自动重试
+func (s *ObjectSink) uploadPending(ctx context.Context) {
+	s.uploadMu.Lock()
+	defer s.uploadMu.Unlock()
+
+	files, err := s.sealedFiles()
+	if err != nil {
+		Logger.Warn("🪣 [ObjectSink] List spool failed", "err", err)
+		return
+	}
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return
+		}
+		start := time.Now()
+		size, err := s.uploadFile(ctx, f)
+		if err != nil {
+			s.metrics.RecordObjectUpload("failed", 0, 0)
+			Logger.Warn("🪣 [ObjectSink] Upload failed, will resume", "file", f, "err", err)
+			continue
+		}
+		s.metrics.RecordObjectUpload("success", size, time.Since(start))
+		Logger.Info("🪣 [ObjectSink] Uploaded", "file", filepath.Base(f), "bytes", size, "duration", time.Since(start))
+	}
+}
+
+// objectKey 生成数据湖分区风格的对象键：<prefix>/chain=<id>/dt=<yyyy-mm-dd>/<file>
+func (s *ObjectSink) objectKey(file string, modTime time.Time) string {
+	return path.Join(s.cfg.KeyPrefix,
+		fmt.Sprintf("chain=%d", s.cfg.ChainID),
+		"dt="+modTime.UTC().Format(time.DateOnly),
+		filepath.Base(file))
+}
+
+// uploadFile 以可恢复的分片上传发送单个文件，完成后删除本地文件与断点
+func (s *ObjectSink) uploadFile(ctx context.Context, file string) (int64, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return 0, err
+	}
+	statePath := file + objectUploadStateSuffix
+
+	state, err := loadUploadState(statePath)
+	if err != nil {
+		return 0, err
+	}
+	if state == nil {
+		key := s.objectKey(file, info.ModTime())
+		uploadID, err := s.uploader.CreateMultipartUpload(ctx, key)
+		if err != nil {
+			return 0, fmt.Errorf("create upload: %w", err)
+		}
+		state = &objectUploadState{Key: key, UploadID: uploadID}
+		if err := saveUploadState(statePath, state); err != nil {
+			return 0, err
+		}
+	}
+
+	// #nosec G304 - spool 目录由系统配置控制
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	done := make(map[int]bool, len(state.Parts))
+	for _, p := range state.Parts {
+		done[p.PartNumber] = true
+	}
+
+	size := info.Size()
+	buf := make([]byte, s.cfg.PartSize)
+	for part, offset := 1, int64(0); offset < size || part == 1; part, offset = part+1, offset+s.cfg.PartSize {
+		if done[part] {
+			continue
+		}
+		n, err := f.ReadAt(buf, offset)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+		etag, err := s.uploader.UploadPart(ctx, state.Key, state.UploadID, part, buf[:n])
+		if err != nil {
+			return 0, fmt.Errorf("upload part %d: %w", part, err)
+		}
+		state.Parts = append(state.Parts, UploadedPart{PartNumber: part, ETag: etag})
+		if err := saveUploadState(statePath, state); err != nil {
+			return 0, err
+		}
+	}
+
+	sort.Slice(state.Parts, func(i, j int) bool { return state.Parts[i].PartNumber < state.Parts[j].PartNumber })
+	if err := s.uploader.CompleteMultipartUpload(ctx, state.Key, state.UploadID, state.Parts); err != nil {
+		return 0, fmt.Errorf("complete upload: %w", err)
+	}
+
+	if err := os.Remove(file); err != nil {
+		return size, err
+	}
+	_ = os.Remove(statePath)
+	return size, nil
+}
+
+func loadUploadState(p string) (*objectUploadState, error) {
+	// #nosec G304 - 断点文件与 spool 文件同目录
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st objectUploadState
+	if err := json.Unmarshal(data, &st); err != nil || st.UploadID == "" {
+		// 损坏的断点直接丢弃，重新发起上传
+		Logger.Warn("🪣 [ObjectSink] Discarding corrupt upload state", "path", p)
+		return nil, nil
+	}
+	return &st, nil
+}
+
+// saveUploadState 先写临时文件再 rename，避免崩溃时留下半截断点
+func saveUploadState(p string, st *objectUploadState) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
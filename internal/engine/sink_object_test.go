@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memUploader 内存版 ObjectUploader，记录调用序列
+type memUploader struct {
+	mu        sync.Mutex
+	creates   int
+	parts     map[string][]byte
+	completed map[string][]UploadedPart
+	failParts bool
+}
+
+func newMemUploader() *memUploader {
+	return &memUploader{parts: map[string][]byte{}, completed: map[string][]UploadedPart{}}
+}
+
+func (m *memUploader) CreateMultipartUpload(_ context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.creates++
+	return fmt.Sprintf("upload-%d", m.creates), nil
+}
+
+func (m *memUploader) UploadPart(_ context.Context, key, uploadID string, n int, body []byte) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failParts {
+		return "", fmt.Errorf("network down")
+	}
+	m.parts[fmt.Sprintf("%s/%s/%d", key, uploadID, n)] = append([]byte(nil), body...)
+	return fmt.Sprintf("\"etag-%d\"", n), nil
+}
+
+func (m *memUploader) CompleteMultipartUpload(_ context.Context, key, uploadID string, parts []UploadedPart) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.completed[key] = parts
+	return nil
+}
+
+func TestObjectSink_UploadsSealedFilesAndResumes(t *testing.T) {
+	dir := t.TempDir()
+	up := newMemUploader()
+	up.failParts = true
+
+	sink, err := NewObjectSink(ObjectSinkConfig{SpoolDir: dir, KeyPrefix: "lake", ChainID: 1}, up)
+	require.NoError(t, err)
+	require.NoError(t, sink.WriteTransfers(context.Background(), testTransfers(10, 3)))
+	require.NoError(t, sink.spool.Rotate())
+	sink.uploadPending(context.Background())
+
+	// 上传失败：文件与断点都保留，upload id 已记录
+	sealed, err := sink.sealedFiles()
+	require.NoError(t, err)
+	require.Len(t, sealed, 1)
+	state, err := loadUploadState(sealed[0] + objectUploadStateSuffix)
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.Equal(t, "upload-1", state.UploadID)
+	assert.True(t, strings.HasPrefix(state.Key, "lake/chain=1/dt="))
+
+	// 恢复后复用同一 upload id，不再重新发起
+	up.mu.Lock()
+	up.failParts = false
+	up.mu.Unlock()
+	require.NoError(t, sink.Close())
+
+	assert.Equal(t, 1, up.creates)
+	require.Len(t, up.completed[state.Key], 1)
+	assert.Equal(t, "\"etag-1\"", up.completed[state.Key][0].ETag)
+
+	left, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	assert.Empty(t, left, "spool and upload state are removed after completion")
+}
+
+func TestS3Uploader_MultipartFlowIsSigned(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AK/") ||
+			!strings.Contains(auth, "/auto/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+			http.Error(w, "bad auth "+auth, http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+		mu.Unlock()
+
+		q := r.URL.Query()
+		switch {
+		case r.Method == http.MethodPost && q.Has("uploads"):
+			fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>abc</UploadId></InitiateMultipartUploadResult>`)
+		case r.Method == http.MethodPut:
+			assert.Equal(t, "hello", string(body))
+			w.Header().Set("ETag", `"e1"`)
+		case r.Method == http.MethodPost && q.Get("uploadId") == "abc":
+			var req struct {
+				Parts []UploadedPart `xml:"Part"`
+			}
+			assert.NoError(t, xml.Unmarshal(body, &req))
+			assert.Equal(t, []UploadedPart{{PartNumber: 1, ETag: `"e1"`}}, req.Parts)
+			fmt.Fprint(w, `<CompleteMultipartUploadResult/>`)
+		default:
+			http.Error(w, "unexpected", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	u, err := NewS3Uploader(S3UploaderConfig{Endpoint: srv.URL, Region: "auto", Bucket: "bkt", AccessKey: "AK", SecretKey: "SK"})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	id, err := u.CreateMultipartUpload(ctx, "lake/dt=2026-01-01/f.jsonl.lz4")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", id)
+	etag, err := u.UploadPart(ctx, "lake/dt=2026-01-01/f.jsonl.lz4", id, 1, []byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, u.CompleteMultipartUpload(ctx, "lake/dt=2026-01-01/f.jsonl.lz4", id, []UploadedPart{{PartNumber: 1, ETag: etag}}))
+
+	assert.Equal(t, []string{
+		"POST /bkt/lake/dt=2026-01-01/f.jsonl.lz4?uploads=",
+		"PUT /bkt/lake/dt=2026-01-01/f.jsonl.lz4?partNumber=1&uploadId=abc",
+		"POST /bkt/lake/dt=2026-01-01/f.jsonl.lz4?uploadId=abc",
+	}, calls)
+
+	denied, err := NewS3Uploader(S3UploaderConfig{Endpoint: srv.URL, Bucket: "bkt", AccessKey: "other", SecretKey: "SK"})
+	require.NoError(t, err)
+	_, err = denied.CreateMultipartUpload(ctx, "k")
+	assert.ErrorContains(t, err, "status 403")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -22,15 +23,8 @@ func (s *PostgresSink) WriteTransfers(ctx context.Context, transfers []models.Tr
 		return nil
 	}
 
-	// 采用批处理写入以压榨普通 SSD 性能
-	_, err := s.db.NamedExecContext(ctx, `
-		INSERT INTO transfers 
-		(block_number, tx_hash, log_index, from_address, to_address, amount, token_address, symbol, activity_type)
-		VALUES 
-		(:block_number, :tx_hash, :log_index, :from_address, :to_address, :amount, :token_address, :symbol, :activity_type)
-		ON CONFLICT (block_number, log_index) DO NOTHING
-	`, transfers)
-
+	// UNNEST 批量写入：SQL 文本与批次大小无关，连接上的预编译语句可跨批次复用
+	err := storage.InsertTransfersTx(ctx, s.db, transfers)
 	if err != nil {
 		return fmt.Errorf("postgres_sink_transfer_failed: %w", err)
 	}
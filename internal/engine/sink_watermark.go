@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+
+	"web3-indexer-go/internal/models"
+)
+
+const (
+	// sinkWatermark aggregate_watermarks 中的 sink 水位名：已提交且成功转发给下游的最高实时区块
+	sinkWatermark = "sink_emitted"
+	// sinkSeedWindow 启动时从水位向前预填去重集合的区块数（覆盖重启后自愈回退重放的范围）
+	sinkSeedWindow = 256
+)
+
+// EmitCommitted 把已提交的批次转发给下游 sink（由 AsyncWriter 在事务提交成功后调用，
+// 回滚或被丢弃的批次不会到达下游）。durable 为 true 时首次调用前按持久化水位预填去重集合，
+// 整批转发成功后推进水位
+func (p *Processor) EmitCommitted(ctx context.Context, batch []PersistTask, durable bool) {
+	if p.sink == nil || len(batch) == 0 {
+		return
+	}
+	if durable {
+		p.sinkSeed.Do(func() { p.seedSinkDedup(ctx) })
+	}
+
+	var high uint64
+	advances, delivered := false, true
+	for _, task := range batch {
+		if !p.writeSink(ctx, task.Block, task.Transfers) {
+			delivered = false
+			continue
+		}
+		if !task.Backfill {
+			advances = true
+			high = max(high, task.Height)
+		}
+	}
+	if durable && advances && delivered {
+		p.saveSinkWatermark(ctx, high)
+	}
+}
+
+// saveSinkWatermark 推进 sink 水位（只增不减；reorg 回滚由 RevertAggregates 回退）
+func (p *Processor) saveSinkWatermark(ctx context.Context, height uint64) {
+	if p.db == nil {
+		return
+	}
+	if _, err := p.db.ExecContext(ctx, `
+		INSERT INTO aggregate_watermarks (name, last_block, updated_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (name) DO UPDATE SET last_block = GREATEST(aggregate_watermarks.last_block, EXCLUDED.last_block), updated_at = NOW()`,
+		sinkWatermark, SafeUint64ToInt64(height)); err != nil {
+		Logger.Warn("sink_watermark_update_failed", slog.Uint64("height", height), slog.String("err", err.Error()))
+	}
+}
+
+// seedSinkDedup 以水位及其之前 sinkSeedWindow 个区块的已入库记录预填去重集合，
+// 使重启后重放的已转发区块不会再次写到下游
+func (p *Processor) seedSinkDedup(ctx context.Context) {
+	dedup, ok := p.sink.(*DedupSink)
+	if !ok || p.db == nil {
+		return
+	}
+	var blocks []models.Block
+	if err := TimedSelect(ctx, p.db, "sink_seed_blocks", &blocks, `
+		SELECT number, hash FROM blocks
+		WHERE number <= (SELECT last_block FROM aggregate_watermarks WHERE name = $1)
+		  AND number > (SELECT last_block FROM aggregate_watermarks WHERE name = $1) - $2`,
+		sinkWatermark, sinkSeedWindow); err != nil {
+		Logger.Warn("sink_dedup_seed_failed", slog.String("err", err.Error()))
+		return
+	}
+	var transfers []models.Transfer
+	if err := TimedSelect(ctx, p.db, "sink_seed_transfers", &transfers, `
+		SELECT block_number, tx_hash, log_index FROM transfers
+		WHERE block_number <= (SELECT last_block FROM aggregate_watermarks WHERE name = $1)
+		  AND block_number > (SELECT last_block FROM aggregate_watermarks WHERE name = $1) - $2`,
+		sinkWatermark, sinkSeedWindow); err != nil {
+		Logger.Warn("sink_dedup_seed_failed", slog.String("err", err.Error()))
+		return
+	}
+	dedup.Seed(blocks, transfers)
+	if len(blocks) > 0 {
+		Logger.Info("sink_dedup_seeded", slog.Int("blocks", len(blocks)), slog.Int("transfers", len(transfers)))
+	}
+}
@@ -0,0 +1,68 @@
+//go:build integration
+
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"web3-indexer-go/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// committedTasks 从已入库的区块与转账构造 [from, to] 的落盘任务（模拟提交后回调收到的批次）
+func committedTasks(t *testing.T, db *sqlx.DB, from, to uint64) []PersistTask {
+	t.Helper()
+	var tasks []PersistTask
+	for n := from; n <= to; n++ {
+		var block models.Block
+		require.NoError(t, db.Get(&block, "SELECT number, hash FROM blocks WHERE number = $1", n))
+		var transfers []models.Transfer
+		require.NoError(t, db.Select(&transfers,
+			"SELECT block_number, tx_hash, log_index FROM transfers WHERE block_number = $1 ORDER BY log_index", n))
+		tasks = append(tasks, PersistTask{Height: n, Block: block, Transfers: transfers})
+	}
+	return tasks
+}
+
+// sinkWatermarkValue 当前持久化的 sink 水位
+func sinkWatermarkValue(t *testing.T, db *sqlx.DB) string {
+	t.Helper()
+	var watermark string
+	require.NoError(t, db.Get(&watermark,
+		"SELECT last_block::TEXT FROM aggregate_watermarks WHERE name = $1", sinkWatermark))
+	return watermark
+}
+
+// TestSinkWatermark_SeedsDedupAfterRestart 重启后重放水位以内的区块不会再次写到下游；reorg 回滚回退水位
+func TestSinkWatermark_SeedsDedupAfterRestart(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+	store := NewStore(db)
+	require.NoError(t, store.Reset(ctx))
+	insertAggregateBlocks(t, db, 1, 6, "aa", 1)
+
+	first := &recordingSink{}
+	p := NewProcessor(db, nil, 10, 1, false, "test")
+	p.SetSink(first)
+	p.EmitCommitted(ctx, committedTasks(t, db, 1, 5), true)
+	require.Len(t, first.blocks, 5)
+	assert.Equal(t, "5", sinkWatermarkValue(t, db))
+
+	// 模拟重启：新的 Processor 与空的内存去重集合，自愈回退后重放 4..6
+	second := &recordingSink{}
+	restarted := NewProcessor(db, nil, 10, 1, false, "test")
+	restarted.SetSink(second)
+	restarted.EmitCommitted(ctx, committedTasks(t, db, 4, 6), true)
+	require.Len(t, second.blocks, 1)
+	assert.Equal(t, "6", second.blocks[0].Number.String())
+	assert.Len(t, second.transfers, 1, "only block 6's transfers are new")
+	assert.Equal(t, "6", sinkWatermarkValue(t, db))
+
+	require.NoError(t, store.RollbackAbove(ctx, 1, 3))
+	assert.Equal(t, "3", sinkWatermarkValue(t, db))
+}
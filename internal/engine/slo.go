@@ -0,0 +1,201 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// DefaultSLOLagBlocks 同步滞后 SLO 阈值：滞后小于该块数的分钟计为达标
+	DefaultSLOLagBlocks = 10
+	// sloStateEco 休眠模式单独计时（休眠不是 SystemState，而是协调器快照上的标志）
+	sloStateEco = "eco"
+)
+
+// processStartedAt 进程启动时间（包初始化时刻），用于上报本次进程的在线时长
+var processStartedAt = time.Now()
+
+// sloSample 单分钟采样：当前状态与同步滞后
+type sloSample struct {
+	State string
+	Lag   uint64
+	LagOK bool
+}
+
+// sloSampleOf 从协调器快照取一分钟的状态与滞后（游标超过链头时滞后记 0）
+func sloSampleOf(snap CoordinatorState, lagThreshold uint64) sloSample {
+	s := sloSample{State: snap.SystemState.String()}
+	if snap.IsEcoMode {
+		s.State = sloStateEco
+	}
+	if snap.LatestHeight > snap.SyncedCursor {
+		s.Lag = snap.LatestHeight - snap.SyncedCursor
+	}
+	s.LagOK = s.Lag < lagThreshold
+	return s
+}
+
+// SLORecorder 每分钟把进程在线、所处状态与滞后达标情况累加进 slo_minutes（按 UTC 日 + 状态一行）
+type SLORecorder struct {
+	db           *sqlx.DB
+	orchestrator *Orchestrator
+	lagThreshold uint64
+}
+
+// NewSLORecorder 创建 SLO 采样器；lagThreshold <= 0 时使用 DefaultSLOLagBlocks
+func NewSLORecorder(db *sqlx.DB, lagThreshold int64) *SLORecorder {
+	if lagThreshold <= 0 {
+		lagThreshold = DefaultSLOLagBlocks
+	}
+	return &SLORecorder{db: db, orchestrator: GetOrchestrator(), lagThreshold: uint64(lagThreshold)}
+}
+
+// Start 启动后台采样循环（每分钟一条）
+func (r *SLORecorder) Start(ctx context.Context) {
+	Logger.Info("🎯 [SLO] Recorder started", slog.Uint64("lag_threshold", r.lagThreshold))
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if err := r.Sample(ctx, now); err != nil && ctx.Err() == nil {
+					Logger.Warn("🎯 [SLO] Sample failed", slog.String("error", err.Error()))
+				}
+			}
+		}
+	}()
+}
+
+// Sample 记录一分钟
+func (r *SLORecorder) Sample(ctx context.Context, now time.Time) error {
+	s := sloSampleOf(r.orchestrator.GetSnapshot(), r.lagThreshold)
+	lagOK := 0
+	if s.LagOK {
+		lagOK = 1
+	}
+	if _, err := r.db.ExecContext(ctx, `
+		INSERT INTO slo_minutes (day, state, minutes, lag_ok_minutes, lag_sum, max_lag, lag_threshold)
+		VALUES ($1::DATE, $2, 1, $3, $4, $4, $5)
+		ON CONFLICT (day, state) DO UPDATE SET
+			minutes = slo_minutes.minutes + 1,
+			lag_ok_minutes = slo_minutes.lag_ok_minutes + EXCLUDED.lag_ok_minutes,
+			lag_sum = slo_minutes.lag_sum + EXCLUDED.lag_sum,
+			max_lag = GREATEST(slo_minutes.max_lag, EXCLUDED.max_lag),
+			lag_threshold = EXCLUDED.lag_threshold`,
+		now.UTC().Format("2006-01-02"), s.State, lagOK, s.Lag, r.lagThreshold); err != nil {
+		return fmt.Errorf("record slo minute: %w", err)
+	}
+	return nil
+}
+
+// SLODay 单日 SLO 汇总
+type SLODay struct {
+	Day           string           `json:"day"`
+	MinutesUp     int64            `json:"minutes_up"`
+	UptimePercent float64          `json:"uptime_percent"` // 在线分钟 / 当日已过分钟
+	LagOKMinutes  int64            `json:"lag_ok_minutes"`
+	LagSLOPercent float64          `json:"lag_slo_percent"` // 滞后达标分钟 / 在线分钟
+	AvgLag        float64          `json:"avg_lag"`
+	MaxLag        int64            `json:"max_lag"`
+	States        map[string]int64 `json:"states"` // 各状态分钟数
+}
+
+// SLOReport /api/stats/slo 响应
+type SLOReport struct {
+	Days                 int      `json:"days"`
+	LagThreshold         int64    `json:"lag_threshold"`
+	ProcessUptimeSeconds int64    `json:"process_uptime_seconds"`
+	UptimePercent        float64  `json:"uptime_percent"`
+	LagSLOPercent        float64  `json:"lag_slo_percent"`
+	Daily                []SLODay `json:"daily"`
+}
+
+type sloRow struct {
+	Day          string `db:"day"`
+	State        string `db:"state"`
+	Minutes      int64  `db:"minutes"`
+	LagOKMinutes int64  `db:"lag_ok_minutes"`
+	LagSum       int64  `db:"lag_sum"`
+	MaxLag       int64  `db:"max_lag"`
+	LagThreshold int64  `db:"lag_threshold"`
+}
+
+// QuerySLO 读取最近 days 个 UTC 日（含当天）的 SLO 汇总，新日期在前
+func QuerySLO(ctx context.Context, db *sqlx.DB, days int, now time.Time) (SLOReport, error) {
+	now = now.UTC()
+	today := now.Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(days - 1))
+
+	var rows []sloRow
+	if err := TimedSelect(ctx, db, "slo_minutes", &rows, `
+		SELECT to_char(day, 'YYYY-MM-DD') AS day, state, minutes, lag_ok_minutes,
+			lag_sum::BIGINT AS lag_sum, max_lag, lag_threshold
+		FROM slo_minutes WHERE day >= $1::DATE ORDER BY day DESC, state`, since.Format("2006-01-02")); err != nil {
+		return SLOReport{}, err
+	}
+	report := buildSLOReport(rows, today, now)
+	report.Days = days
+	report.ProcessUptimeSeconds = int64(time.Since(processStartedAt).Seconds())
+	return report, nil
+}
+
+// buildSLOReport 按日合并各状态行并计算百分比；窗口汇总以在线分钟加权
+func buildSLOReport(rows []sloRow, today, now time.Time) SLOReport {
+	byDay := make(map[string]*SLODay)
+	lagSums := make(map[string]int64)
+	var report SLOReport
+	for _, row := range rows {
+		d, ok := byDay[row.Day]
+		if !ok {
+			d = &SLODay{Day: row.Day, States: make(map[string]int64)}
+			byDay[row.Day] = d
+		}
+		d.MinutesUp += row.Minutes
+		d.LagOKMinutes += row.LagOKMinutes
+		d.States[row.State] += row.Minutes
+		d.MaxLag = max(d.MaxLag, row.MaxLag)
+		lagSums[row.Day] += row.LagSum
+		report.LagThreshold = max(report.LagThreshold, row.LagThreshold)
+	}
+
+	var elapsedTotal, upTotal, okTotal int64
+	for day, d := range byDay {
+		elapsed := int64(24 * 60)
+		if day == today.Format("2006-01-02") {
+			elapsed = max(int64(now.Sub(today).Minutes()), d.MinutesUp)
+		}
+		if elapsed > 0 {
+			d.UptimePercent = float64(d.MinutesUp) / float64(elapsed) * 100
+		}
+		if d.MinutesUp > 0 {
+			d.LagSLOPercent = float64(d.LagOKMinutes) / float64(d.MinutesUp) * 100
+			d.AvgLag = float64(lagSums[day]) / float64(d.MinutesUp)
+		}
+		elapsedTotal += elapsed
+		upTotal += d.MinutesUp
+		okTotal += d.LagOKMinutes
+		report.Daily = append(report.Daily, *d)
+	}
+	sort.Slice(report.Daily, func(i, j int) bool { return report.Daily[i].Day > report.Daily[j].Day })
+	if report.Daily == nil {
+		report.Daily = []SLODay{}
+	}
+	if elapsedTotal > 0 {
+		report.UptimePercent = float64(upTotal) / float64(elapsedTotal) * 100
+	}
+	if upTotal > 0 {
+		report.LagSLOPercent = float64(okTotal) / float64(upTotal) * 100
+	}
+	if report.LagThreshold == 0 {
+		report.LagThreshold = DefaultSLOLagBlocks
+	}
+	return report
+}
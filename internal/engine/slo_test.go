@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSLOSampleOf(t *testing.T) {
+	s := sloSampleOf(CoordinatorState{LatestHeight: 120, SyncedCursor: 115, SystemState: SystemStateRunning}, 10)
+	assert.Equal(t, sloSample{State: "running", Lag: 5, LagOK: true}, s)
+
+	s = sloSampleOf(CoordinatorState{LatestHeight: 120, SyncedCursor: 110, SystemState: SystemStateThrottled}, 10)
+	assert.Equal(t, sloSample{State: "throttled", Lag: 10, LagOK: false}, s)
+
+	// 休眠优先于 SystemState；游标超过链头时滞后记 0
+	s = sloSampleOf(CoordinatorState{LatestHeight: 100, SyncedCursor: 105, SystemState: SystemStateRunning, IsEcoMode: true}, 10)
+	assert.Equal(t, sloSample{State: sloStateEco, Lag: 0, LagOK: true}, s)
+}
+
+func TestBuildSLOReport(t *testing.T) {
+	now := time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC)
+	today := now.Truncate(24 * time.Hour)
+	rows := []sloRow{
+		{Day: "2026-03-02", State: "running", Minutes: 300, LagOKMinutes: 300, LagSum: 600, MaxLag: 4, LagThreshold: 10},
+		{Day: "2026-03-02", State: "eco", Minutes: 60, LagOKMinutes: 0, LagSum: 1200, MaxLag: 30, LagThreshold: 10},
+		{Day: "2026-03-01", State: "running", Minutes: 720, LagOKMinutes: 648, LagSum: 2880, MaxLag: 50, LagThreshold: 10},
+	}
+
+	report := buildSLOReport(rows, today, now)
+	require.Len(t, report.Daily, 2)
+	assert.EqualValues(t, 10, report.LagThreshold)
+
+	cur := report.Daily[0]
+	assert.Equal(t, "2026-03-02", cur.Day)
+	assert.EqualValues(t, 360, cur.MinutesUp)
+	assert.InDelta(t, 100.0, cur.UptimePercent, 0.001) // 当天只过去 360 分钟
+	assert.InDelta(t, 300.0/360*100, cur.LagSLOPercent, 0.001)
+	assert.InDelta(t, 5.0, cur.AvgLag, 0.001)
+	assert.EqualValues(t, 30, cur.MaxLag)
+	assert.Equal(t, map[string]int64{"running": 300, "eco": 60}, cur.States)
+
+	prev := report.Daily[1]
+	assert.InDelta(t, 50.0, prev.UptimePercent, 0.001)
+	assert.InDelta(t, 90.0, prev.LagSLOPercent, 0.001)
+
+	assert.InDelta(t, float64(360+720)/float64(360+1440)*100, report.UptimePercent, 0.001)
+	assert.InDelta(t, float64(300+648)/float64(360+720)*100, report.LagSLOPercent, 0.001)
+}
+
+func TestBuildSLOReport_Empty(t *testing.T) {
+	now := time.Now().UTC()
+	report := buildSLOReport(nil, now.Truncate(24*time.Hour), now)
+	assert.NotNil(t, report.Daily)
+	assert.EqualValues(t, DefaultSLOLagBlocks, report.LagThreshold)
+	assert.Zero(t, report.UptimePercent)
+}
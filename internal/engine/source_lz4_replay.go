@@ -5,12 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math/big"
-	"os"
 	"time"
 
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/pierrec/lz4/v4"
 )
 
 // parseBigInt 从 JSON 值解析 *big.Int（支持数字和字符串）
@@ -46,45 +45,80 @@ func parseBigInt(val interface{}) *big.Int {
 }
 
 // Lz4ReplaySource LZ4 轨迹回放源
-// 实现了 BlockSource 接口，将压缩文件伪装成实时区块链
+// 实现了 BlockSource 接口，将压缩文件伪装成实时区块链；
+// 轨迹可以是单个文件、分片目录或 s3:// 前缀，多个分片按序拼接为一个连续流
 type Lz4ReplaySource struct {
-	file        *os.File
-	lz4Reader   *lz4.Reader
+	stream      *chunkStream
 	scanner     *bufio.Scanner
 	path        string
 	totalSize   int64
 	lastNum     uint64
-	lastTime    uint64  // 链上最后一个区块的时间戳
-	speedFactor float64 // 0: 全速, 1: 真实速度, 10: 十倍速
+	lastTime    uint64         // 链上最后一个区块的时间戳
+	speedFactor float64        // 0: 全速, 1: 真实速度, 10: 十倍速
+	chaos       *chaosShuffler // 推送模式的投递扰动（nil 表示按文件顺序立即投递）
 }
 
-// NewLz4ReplaySource 创建回放源
+// NewLz4ReplaySource 创建本地回放源（path 为单个 .lz4 文件或分片目录）
 func NewLz4ReplaySource(path string, speed float64) (*Lz4ReplaySource, error) {
-	// #nosec G304 - path is from controlled configuration
-	f, err := os.Open(path)
+	return OpenReplaySource(context.Background(), path, speed, S3UploaderConfig{})
+}
+
+// OpenReplaySource 创建回放源：location 为单个文件、分片目录或 s3://bucket/prefix（s3cfg 提供端点与凭证，Bucket 取自 URL）。
+// 对象存储分片在回放时流式下载，多 GB 的主网轨迹无需先拷贝到本地。
+func OpenReplaySource(ctx context.Context, location string, speed float64, s3cfg S3UploaderConfig) (*Lz4ReplaySource, error) {
+	chunks, err := listReplayChunks(ctx, location, s3cfg)
 	if err != nil {
 		return nil, err
 	}
+	var total int64
+	for _, c := range chunks {
+		total += c.size
+	}
 
-	fi, err := f.Stat()
-	if err != nil {
-		_ = f.Close()
-		return nil, err
+	s := &Lz4ReplaySource{
+		stream:      newChunkStream(chunks),
+		path:        location,
+		totalSize:   total,
+		speedFactor: speed,
 	}
+	s.scanner = newReplayScanner(s.stream)
+	return s, nil
+}
 
-	zr := lz4.NewReader(f)
-	scanner := bufio.NewScanner(zr)
+func newReplayScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
 	buf := make([]byte, 0, 1024*1024)
 	scanner.Buffer(buf, 10*1024*1024)
+	return scanner
+}
 
-	return &Lz4ReplaySource{
-		file:        f,
-		lz4Reader:   zr,
-		scanner:     scanner,
-		path:        path,
-		totalSize:   fi.Size(),
-		speedFactor: speed,
-	}, nil
+// SetChaos 为推送模式（StreamBlocks）配置延迟、抖动与乱序投递；未启用任何扰动时恢复按序投递
+func (s *Lz4ReplaySource) SetChaos(cfg ReplayChaos) {
+	if !cfg.Enabled() {
+		s.chaos = nil
+		return
+	}
+	s.chaos = newChaosShuffler(cfg)
+}
+
+// PeekFirstBlock 返回文件中第一个区块号并回到文件开头（推送模式据此初始化 Sequencer 的期望区块）
+func (s *Lz4ReplaySource) PeekFirstBlock() (*big.Int, error) {
+	defer func() { _ = s.Reset() }()
+	for s.scanner.Scan() {
+		var entry RecordEntry
+		if err := json.Unmarshal(s.scanner.Bytes(), &entry); err != nil || entry.Type != "block_data" {
+			continue
+		}
+		if dataMap, ok := entry.Data.(map[string]interface{}); ok {
+			if n := parseBigInt(dataMap["Number"]); n != nil {
+				return n, nil
+			}
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("lz4_scan_failed: %w", err)
+	}
+	return nil, fmt.Errorf("no block_data in %s", s.path)
 }
 
 // GetProgress 返回当前回放进度百分比
@@ -92,12 +126,8 @@ func (s *Lz4ReplaySource) GetProgress() float64 {
 	if s.totalSize == 0 {
 		return 0
 	}
-	// 通过底层文件指针位置估算压缩流进度
-	pos, err := s.file.Seek(0, 1) // io.SeekCurrent
-	if err != nil {
-		return 0
-	}
-	return float64(pos) / float64(s.totalSize) * 100
+	// 通过已读取的压缩字节数估算进度（跨分片累计）
+	return float64(s.stream.position()) / float64(s.totalSize) * 100
 }
 
 // FetchLogs 从 LZ4 轨迹中提取区块数据，并执行倍速休眠
@@ -141,9 +171,10 @@ func (s *Lz4ReplaySource) FetchLogs(ctx context.Context, start, end *big.Int) ([
 
 			// 🔥 FINDING-8 修复：直接从 RawMessage 解析，避免 Marshal 往返
 			var tempStruct struct {
-				Block interface{}            `json:"Block"`
-				Err   map[string]interface{} `json:"Err"`
-				Logs  []interface{}          `json:"Logs"`
+				Block  interface{}            `json:"Block"`
+				Header *types.Header          `json:"Header"` // replay fetch 生成的文件只含区块头
+				Err    map[string]interface{} `json:"Err"`
+				Logs   []interface{}          `json:"Logs"`
 			}
 
 			if err := json.Unmarshal(entry.Data, &tempStruct); err != nil {
@@ -171,6 +202,10 @@ func (s *Lz4ReplaySource) FetchLogs(ctx context.Context, start, end *big.Int) ([
 				}
 			}
 
+			if bd.Block == nil && tempStruct.Header != nil {
+				bd.Block = types.NewBlockWithHeader(tempStruct.Header)
+			}
+
 			// 处理 Err 字段（通常为 null）
 			if tempStruct.Err == nil {
 				bd.Err = nil
@@ -237,22 +272,21 @@ func (s *Lz4ReplaySource) GetLatestHeight(_ context.Context) (*big.Int, error) {
 }
 
 func (s *Lz4ReplaySource) Close() error {
-	if s.file != nil {
-		return s.file.Close()
+	if s.stream != nil {
+		return s.stream.Close()
 	}
 	return nil
 }
 
-// Reset 重置回放，回到文件开头
+// Reset 重置回放，回到第一个分片开头
 func (s *Lz4ReplaySource) Reset() error {
-	_, err := s.file.Seek(0, 0)
-	if err != nil {
+	if err := s.stream.rewind(); err != nil {
 		return err
 	}
-	s.lz4Reader.Reset(s.file)
-	s.scanner = bufio.NewScanner(s.lz4Reader)
-	buf := make([]byte, 0, 1024*1024)
-	s.scanner.Buffer(buf, 10*1024*1024)
+	s.scanner = newReplayScanner(s.stream)
+	if s.chaos != nil {
+		s.chaos.reset()
+	}
 	return nil
 }
 
@@ -299,8 +333,9 @@ func (s *Lz4ReplaySource) StreamBlocks(ctx context.Context, out chan<- BlockData
 			continue
 		}
 		var tempStruct struct {
-			Block interface{}           `json:"Block"`
-			Logs  []interface{}         `json:"Logs"`
+			Block  interface{}   `json:"Block"`
+			Header *types.Header `json:"Header"`
+			Logs   []interface{} `json:"Logs"`
 		}
 		if err := json.Unmarshal(tempJSON, &tempStruct); err != nil {
 			continue
@@ -318,6 +353,9 @@ func (s *Lz4ReplaySource) StreamBlocks(ctx context.Context, out chan<- BlockData
 				}
 			}
 		}
+		if bd.Block == nil && tempStruct.Header != nil {
+			bd.Block = types.NewBlockWithHeader(tempStruct.Header)
+		}
 		if tempStruct.Logs != nil {
 			logsJSON, err := json.Marshal(tempStruct.Logs)
 			if err == nil {
@@ -350,12 +388,38 @@ func (s *Lz4ReplaySource) StreamBlocks(ctx context.Context, out chan<- BlockData
 		s.lastNum = bd.Number.Uint64()
 
 		// 推送到 channel
+		if err := s.deliver(ctx, out, bd); err != nil {
+			return err
+		}
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return err
+	}
+	// 文件结束：投递乱序窗口内剩余的块
+	if s.chaos != nil {
+		for _, bd := range s.chaos.drain() {
+			if err := s.chaos.send(ctx, out, bd); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deliver 推送一块；配置了扰动时先进入乱序窗口，由窗口决定投递哪一块及延迟多久
+func (s *Lz4ReplaySource) deliver(ctx context.Context, out chan<- BlockData, bd BlockData) error {
+	if s.chaos == nil {
 		select {
 		case out <- bd:
+			return nil
 		case <-ctx.Done():
 			return ctx.Err()
 		}
 	}
-
-	return s.scanner.Err()
+	next, ok := s.chaos.push(bd)
+	if !ok {
+		return nil
+	}
+	return s.chaos.send(ctx, out, next)
 }
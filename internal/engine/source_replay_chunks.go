@@ -0,0 +1,249 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+const (
+	replayChunkSuffix   = ".lz4"
+	replayS3Scheme      = "s3://"
+	replayObjectRetries = 3 // 单个对象流式读取中断后的续读次数
+)
+
+// replayChunk 回放轨迹的一个分片（本地文件或对象存储中的对象），各自是独立的 LZ4 流
+type replayChunk struct {
+	name string
+	size int64 // 压缩后字节数，用于估算进度
+	open func() (io.ReadCloser, error)
+}
+
+// listReplayChunks 解析回放位置：单个文件、目录（按文件名排序的 *.lz4 分片）或 s3://bucket/prefix（按键排序的 *.lz4 对象）。
+// 分片按名称字典序依次回放，因此分片命名需保证字典序即区块顺序（例如零填充的区块区间）。
+func listReplayChunks(ctx context.Context, location string, s3cfg S3UploaderConfig) ([]replayChunk, error) {
+	if strings.HasPrefix(location, replayS3Scheme) {
+		return listS3ReplayChunks(ctx, location, s3cfg)
+	}
+
+	fi, err := os.Stat(location)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []replayChunk{fileReplayChunk(location, fi.Size())}, nil
+	}
+
+	entries, err := os.ReadDir(location)
+	if err != nil {
+		return nil, err
+	}
+	var chunks []replayChunk
+	for _, e := range entries { // ReadDir 已按文件名排序
+		if e.IsDir() || !strings.HasSuffix(e.Name(), replayChunkSuffix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, fileReplayChunk(filepath.Join(location, e.Name()), info.Size()))
+	}
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("no %s replay chunks in %s", replayChunkSuffix, location)
+	}
+	return chunks, nil
+}
+
+func fileReplayChunk(path string, size int64) replayChunk {
+	return replayChunk{name: path, size: size, open: func() (io.ReadCloser, error) {
+		// #nosec G304 - path is from controlled configuration
+		return os.Open(path)
+	}}
+}
+
+// listS3ReplayChunks 列出 s3://bucket/prefix 下的 *.lz4 对象；读取时流式下载，不落本地盘
+func listS3ReplayChunks(ctx context.Context, location string, s3cfg S3UploaderConfig) ([]replayChunk, error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid replay location %q (want s3://bucket/prefix)", location)
+	}
+	s3cfg.Bucket = u.Host
+	client, err := NewS3Uploader(s3cfg)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := client.ListObjects(ctx, strings.TrimPrefix(u.Path, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	var chunks []replayChunk
+	for _, obj := range objects {
+		if !strings.HasSuffix(obj.Key, replayChunkSuffix) {
+			continue
+		}
+		chunks = append(chunks, replayChunk{
+			name: replayS3Scheme + s3cfg.Bucket + "/" + obj.Key,
+			size: obj.Size,
+			open: func() (io.ReadCloser, error) {
+				return &s3ObjectStream{ctx: ctx, client: client, key: obj.Key, size: obj.Size}, nil
+			},
+		})
+	}
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("no %s replay objects under %s", replayChunkSuffix, location)
+	}
+	return chunks, nil
+}
+
+// s3ObjectStream 流式读取单个对象；连接中途断开时带 Range 从已读位置续读
+type s3ObjectStream struct {
+	ctx     context.Context
+	client  *S3Uploader
+	key     string
+	size    int64
+	offset  int64
+	retries int
+	body    io.ReadCloser
+}
+
+func (s *s3ObjectStream) Read(p []byte) (int, error) {
+	for {
+		if s.body == nil {
+			body, err := s.client.OpenObject(s.ctx, s.key, s.offset)
+			if err != nil {
+				return 0, err
+			}
+			s.body = body
+		}
+		n, err := s.body.Read(p)
+		s.offset += int64(n)
+		if err == nil || errors.Is(err, io.EOF) || s.offset >= s.size || s.ctx.Err() != nil || s.retries >= replayObjectRetries {
+			return n, err
+		}
+		s.retries++
+		Logger.Warn("⚠️ [Replay] Object stream interrupted, resuming",
+			slog.String("key", s.key),
+			slog.Int64("offset", s.offset),
+			slog.Int("attempt", s.retries),
+			slog.String("err", err.Error()))
+		_ = s.body.Close()
+		s.body = nil
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (s *s3ObjectStream) Close() error {
+	if s.body == nil {
+		return nil
+	}
+	err := s.body.Close()
+	s.body = nil
+	return err
+}
+
+// chunkStream 把多个 LZ4 分片按序解压拼接为一个连续的 JSONL 流（分片之间补换行，避免首尾行粘连）
+type chunkStream struct {
+	chunks []replayChunk
+	idx    int
+	raw    io.ReadCloser
+	zr     *lz4.Reader
+	sep    bool // 当前分片已读完，待输出分隔换行
+
+	done    atomic.Int64 // 已读完分片的压缩字节数
+	current atomic.Int64 // 当前分片已读取的压缩字节数
+}
+
+func newChunkStream(chunks []replayChunk) *chunkStream {
+	return &chunkStream{chunks: chunks, zr: lz4.NewReader(nil)}
+}
+
+func (c *chunkStream) Read(p []byte) (int, error) {
+	for {
+		if c.sep {
+			c.sep = false
+			if len(p) == 0 {
+				return 0, nil
+			}
+			p[0] = '\n'
+			return 1, nil
+		}
+		if c.raw == nil {
+			if c.idx >= len(c.chunks) {
+				return 0, io.EOF
+			}
+			raw, err := c.chunks[c.idx].open()
+			if err != nil {
+				return 0, fmt.Errorf("open replay chunk %s: %w", c.chunks[c.idx].name, err)
+			}
+			c.raw = raw
+			c.current.Store(0)
+			c.zr.Reset(countingReader{r: raw, n: &c.current})
+		}
+
+		n, err := c.zr.Read(p)
+		if errors.Is(err, io.EOF) {
+			_ = c.raw.Close()
+			c.raw = nil
+			c.done.Add(c.chunks[c.idx].size)
+			c.current.Store(0)
+			c.idx++
+			c.sep = true
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		if err != nil {
+			return n, fmt.Errorf("read replay chunk %s: %w", c.chunks[c.idx].name, err)
+		}
+		return n, nil
+	}
+}
+
+// position 已读取的压缩字节数（跨分片累计）
+func (c *chunkStream) position() int64 {
+	return c.done.Load() + c.current.Load()
+}
+
+// rewind 回到第一个分片
+func (c *chunkStream) rewind() error {
+	err := c.Close()
+	c.idx, c.sep = 0, false
+	c.done.Store(0)
+	c.current.Store(0)
+	return err
+}
+
+func (c *chunkStream) Close() error {
+	if c.raw == nil {
+		return nil
+	}
+	err := c.raw.Close()
+	c.raw = nil
+	return err
+}
+
+// countingReader 统计底层已读字节数
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeReplayChunk 写出包含 [from, to] 区块头的回放分片
+func writeReplayChunk(t *testing.T, path string, from, to int64) []byte {
+	t.Helper()
+	var records []ReplayBlockRecord
+	for n := from; n <= to; n++ {
+		records = append(records, ReplayBlockRecord{
+			Number: big.NewInt(n),
+			Header: &types.Header{Number: big.NewInt(n), Time: uint64(1700000000 + n*12), Difficulty: big.NewInt(0)},
+		})
+	}
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, writeReplayFrame(f, records))
+	require.NoError(t, f.Close())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return data
+}
+
+func streamNumbers(t *testing.T, source *Lz4ReplaySource) []uint64 {
+	t.Helper()
+	ch := make(chan BlockData, 64)
+	require.NoError(t, source.StreamBlocks(context.Background(), ch))
+	close(ch)
+	var numbers []uint64
+	for bd := range ch {
+		numbers = append(numbers, bd.Number.Uint64())
+	}
+	return numbers
+}
+
+func TestReplaySource_ChunkDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeReplayChunk(t, filepath.Join(dir, "0002.jsonl.lz4"), 4, 6)
+	writeReplayChunk(t, filepath.Join(dir, "0001.jsonl.lz4"), 1, 3)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o600))
+
+	source, err := NewLz4ReplaySource(dir, 0)
+	require.NoError(t, err)
+	defer source.Close()
+
+	first, err := source.PeekFirstBlock()
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, first.Int64())
+
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6}, streamNumbers(t, source), "chunks are replayed in file name order")
+	assert.InDelta(t, 100, source.GetProgress(), 0.01)
+
+	require.NoError(t, source.Reset())
+	assert.Zero(t, source.GetProgress())
+	blocks, err := source.FetchLogs(context.Background(), big.NewInt(3), big.NewInt(5))
+	require.NoError(t, err)
+	require.Len(t, blocks, 3, "range reads cross the chunk boundary")
+	assert.EqualValues(t, 5, blocks[2].Number.Int64())
+
+	_, err = NewLz4ReplaySource(t.TempDir(), 0)
+	assert.ErrorContains(t, err, "no .lz4 replay chunks")
+}
+
+func TestReplaySource_S3PrefixWithResume(t *testing.T) {
+	dir := t.TempDir()
+	objects := map[string][]byte{
+		"history/a.jsonl.lz4": writeReplayChunk(t, filepath.Join(dir, "a"), 10, 12),
+		"history/b.jsonl.lz4": writeReplayChunk(t, filepath.Join(dir, "b"), 13, 15),
+	}
+
+	var mu sync.Mutex
+	var ranges []string
+	cut := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AK/"))
+		q := r.URL.Query()
+		if r.URL.Path == "/bkt/" && q.Get("list-type") == "2" {
+			assert.Equal(t, "history/", q.Get("prefix"))
+			// 分两页返回，验证 continuation-token 翻页
+			if q.Get("continuation-token") == "" {
+				fmt.Fprintf(w, `<ListBucketResult><Contents><Key>history/a.jsonl.lz4</Key><Size>%d</Size></Contents><Contents><Key>history/readme.md</Key><Size>3</Size></Contents><IsTruncated>true</IsTruncated><NextContinuationToken>p2</NextContinuationToken></ListBucketResult>`, len(objects["history/a.jsonl.lz4"]))
+				return
+			}
+			assert.Equal(t, "p2", q.Get("continuation-token"))
+			fmt.Fprintf(w, `<ListBucketResult><Contents><Key>history/b.jsonl.lz4</Key><Size>%d</Size></Contents><IsTruncated>false</IsTruncated></ListBucketResult>`, len(objects["history/b.jsonl.lz4"]))
+			return
+		}
+
+		data, ok := objects[strings.TrimPrefix(r.URL.Path, "/bkt/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		offset := 0
+		if rg := r.Header.Get("Range"); rg != "" {
+			offset, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rg, "bytes="), "-"))
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		mu.Lock()
+		ranges = append(ranges, r.URL.Path+"@"+strconv.Itoa(offset))
+		interrupt := cut && offset == 0 && strings.HasSuffix(r.URL.Path, "b.jsonl.lz4")
+		cut = cut && !interrupt
+		mu.Unlock()
+		if interrupt {
+			// 只发送一半后断开连接，客户端应带 Range 续读
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			_, _ = w.Write(data[:len(data)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		_, _ = w.Write(data[offset:])
+	}))
+	defer srv.Close()
+
+	source, err := OpenReplaySource(context.Background(), "s3://bkt/history/", 0,
+		S3UploaderConfig{Endpoint: srv.URL, Region: "auto", AccessKey: "AK", SecretKey: "SK"})
+	require.NoError(t, err)
+	defer source.Close()
+
+	assert.Equal(t, []uint64{10, 11, 12, 13, 14, 15}, streamNumbers(t, source))
+	assert.InDelta(t, 100, source.GetProgress(), 0.01)
+
+	half := len(objects["history/b.jsonl.lz4"]) / 2
+	assert.Equal(t, []string{"/bkt/history/a.jsonl.lz4@0", "/bkt/history/b.jsonl.lz4@0", fmt.Sprintf("/bkt/history/b.jsonl.lz4@%d", half)}, ranges)
+}
@@ -0,0 +1,288 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// 垃圾代币判定原因（写入 token_metadata.spam_reason，亦作为 indexer_spam_tokens_marked_total 的 reason 标签）
+const (
+	spamReasonZeroValueFlood = "zero_value_flood"      // 单块内大量零金额 Transfer（地址投毒）
+	spamReasonAirdrop        = "airdrop"               // 单笔交易向大量地址发出相同金额
+	spamReasonUnverifiable   = "unverifiable_metadata" // symbol()/decimals() 均不可读却在发 Transfer
+)
+
+// SpamConfig 垃圾代币启发式阈值（<= 0 关闭对应规则）
+type SpamConfig struct {
+	ZeroTransfersPerBlock int  // 单块内同一代币零金额 Transfer 数达到该值即判定
+	AirdropRecipients     int  // 单笔交易向不同地址发出相同金额的 Transfer 数达到该值即判定
+	FlagUnverifiable      bool // 元数据不可读的代币发出 Transfer 即判定
+}
+
+// SpamStore 垃圾代币状态持久化（storage.Postgres 实现）
+type SpamStore interface {
+	MarkTokenSpam(ctx context.Context, address, reason string) error
+	SetTokenSpamOverride(ctx context.Context, address string, spam *bool) error
+	LoadSpamTokens(ctx context.Context) ([]storage.SpamTokenRow, error)
+}
+
+// SpamToken 单个代币的垃圾判定状态（管理 API 输出）
+type SpamToken struct {
+	Address  string    `json:"address"`
+	Reason   string    `json:"reason,omitempty"`   // 启发式判定原因（空表示未被自动判定）
+	Override *bool     `json:"override,omitempty"` // 管理员覆盖：true 强制静音，false 永不静音，nil 跟随启发式
+	Muted    bool      `json:"muted"`
+	MarkedAt time.Time `json:"marked_at"`
+}
+
+type spamState struct {
+	reason   string
+	override *bool
+	markedAt time.Time
+}
+
+func (s *spamState) muted() bool {
+	if s.override != nil {
+		return *s.override
+	}
+	return s.reason != ""
+}
+
+// SpamGuard 垃圾代币识别与自动静音。
+// ScanBlock 在解析区块日志前运行，命中的代币当块起即被 ProcessLog 跳过；
+// 判定结果写入 token_metadata，重启后由 Load 恢复。管理员覆盖优先于启发式。
+type SpamGuard struct {
+	cfg   SpamConfig
+	store SpamStore
+
+	mu           sync.RWMutex
+	tokens       map[common.Address]*spamState
+	unverifiable map[common.Address]struct{} // 元数据抓取失败、等待其下一次 Transfer 的代币
+}
+
+// NewSpamGuard 创建垃圾代币守卫；store 为 nil 时只在内存中生效
+func NewSpamGuard(cfg SpamConfig, store SpamStore) *SpamGuard {
+	return &SpamGuard{
+		cfg:          cfg,
+		store:        store,
+		tokens:       make(map[common.Address]*spamState),
+		unverifiable: make(map[common.Address]struct{}),
+	}
+}
+
+// Load 从数据库恢复已判定的代币与管理员覆盖
+func (g *SpamGuard) Load(ctx context.Context) error {
+	if g == nil || g.store == nil {
+		return nil
+	}
+	rows, err := g.store.LoadSpamTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, row := range rows {
+		if !common.IsHexAddress(row.Address) {
+			continue
+		}
+		st := &spamState{override: row.Override, markedAt: row.MarkedAt}
+		if row.IsSpam {
+			st.reason = row.Reason
+		}
+		g.tokens[common.HexToAddress(row.Address)] = st
+	}
+	Logger.Info("🗑️ [SpamGuard] Spam token state loaded", slog.Int("tokens", len(rows)))
+	return nil
+}
+
+// IsMuted 该代币的事件是否应被丢弃（nil 安全）
+func (g *SpamGuard) IsMuted(token common.Address) bool {
+	if g == nil {
+		return false
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	st, ok := g.tokens[token]
+	return ok && st.muted()
+}
+
+// airdropKey 同一交易内同一代币、同一金额的转账分组
+type airdropKey struct {
+	tx     common.Hash
+	token  common.Address
+	amount string
+}
+
+// ScanBlock 对单个区块的原始日志运行启发式规则，命中的代币立即静音
+func (g *SpamGuard) ScanBlock(logs []types.Log) {
+	if g == nil || len(logs) == 0 {
+		return
+	}
+
+	zeroCounts := make(map[common.Address]int)
+	recipients := make(map[airdropKey]map[common.Hash]struct{})
+	var unverifiedSeen []common.Address
+
+	g.mu.RLock()
+	for i := range logs {
+		vLog := &logs[i]
+		// 只看 ERC-20 形式的 Transfer（3 个 topic）；ERC-721 的 tokenId 在 topic 中，data 为空
+		if len(vLog.Topics) != 3 || vLog.Topics[0] != TransferEventHash {
+			continue
+		}
+		if st, ok := g.tokens[vLog.Address]; ok && (st.override != nil || st.reason != "") {
+			continue // 已判定或已被管理员覆盖
+		}
+
+		if g.cfg.ZeroTransfersPerBlock > 0 && isZeroAmount(vLog.Data) {
+			zeroCounts[vLog.Address]++
+		}
+		if g.cfg.AirdropRecipients > 0 {
+			key := airdropKey{tx: vLog.TxHash, token: vLog.Address, amount: string(vLog.Data)}
+			set, ok := recipients[key]
+			if !ok {
+				set = make(map[common.Hash]struct{})
+				recipients[key] = set
+			}
+			set[vLog.Topics[2]] = struct{}{}
+		}
+		if _, ok := g.unverifiable[vLog.Address]; ok {
+			unverifiedSeen = append(unverifiedSeen, vLog.Address)
+		}
+	}
+	g.mu.RUnlock()
+
+	for token, n := range zeroCounts {
+		if n >= g.cfg.ZeroTransfersPerBlock {
+			g.mark(token, spamReasonZeroValueFlood)
+		}
+	}
+	for key, set := range recipients {
+		if len(set) >= g.cfg.AirdropRecipients {
+			g.mark(key.token, spamReasonAirdrop)
+		}
+	}
+	for _, token := range unverifiedSeen {
+		g.mark(token, spamReasonUnverifiable)
+	}
+}
+
+func isZeroAmount(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ReportUnverifiable 元数据丰富器报告 symbol()/decimals() 均不可读的代币
+func (g *SpamGuard) ReportUnverifiable(token common.Address) {
+	if g == nil || !g.cfg.FlagUnverifiable {
+		return
+	}
+	g.mu.Lock()
+	g.unverifiable[token] = struct{}{}
+	g.mu.Unlock()
+}
+
+// mark 记录启发式判定（已判定或已被覆盖的代币忽略），持久化为尽力而为
+func (g *SpamGuard) mark(token common.Address, reason string) {
+	g.mu.Lock()
+	st, ok := g.tokens[token]
+	if ok && (st.override != nil || st.reason != "") {
+		g.mu.Unlock()
+		return
+	}
+	if !ok {
+		st = &spamState{}
+		g.tokens[token] = st
+	}
+	st.reason = reason
+	st.markedAt = time.Now()
+	delete(g.unverifiable, token)
+	g.mu.Unlock()
+
+	GetMetrics().RecordSpamTokenMarked(reason)
+	Logger.Warn("🗑️ [SpamGuard] Token muted as spam",
+		slog.String("token", strings.ToLower(token.Hex())),
+		slog.String("reason", reason))
+
+	if g.store == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := g.store.MarkTokenSpam(ctx, strings.ToLower(token.Hex()), reason); err != nil {
+			Logger.Warn("⚠️ [SpamGuard] Failed to persist spam mark (non-blocking)",
+				slog.String("token", strings.ToLower(token.Hex())),
+				slog.String("err", err.Error()))
+		}
+	}()
+}
+
+// SetOverride 管理员覆盖：true 强制静音，false 永不静音，nil 恢复跟随启发式。先持久化再生效。
+func (g *SpamGuard) SetOverride(ctx context.Context, token common.Address, spam *bool) (SpamToken, error) {
+	if g.store != nil {
+		if err := g.store.SetTokenSpamOverride(ctx, strings.ToLower(token.Hex()), spam); err != nil {
+			return SpamToken{}, err
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	st, ok := g.tokens[token]
+	if !ok {
+		st = &spamState{markedAt: time.Now()}
+		g.tokens[token] = st
+	}
+	st.override = spam
+	if spam == nil && st.reason == "" {
+		delete(g.tokens, token)
+	}
+	return toSpamToken(token, st), nil
+}
+
+// Status 单个代币的当前状态
+func (g *SpamGuard) Status(token common.Address) SpamToken {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if st, ok := g.tokens[token]; ok {
+		return toSpamToken(token, st)
+	}
+	return SpamToken{Address: strings.ToLower(token.Hex())}
+}
+
+// List 所有被判定或被覆盖的代币（按地址排序）
+func (g *SpamGuard) List() []SpamToken {
+	g.mu.RLock()
+	out := make([]SpamToken, 0, len(g.tokens))
+	for token, st := range g.tokens {
+		out = append(out, toSpamToken(token, st))
+	}
+	g.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	return out
+}
+
+func toSpamToken(token common.Address, st *spamState) SpamToken {
+	return SpamToken{
+		Address:  strings.ToLower(token.Hex()),
+		Reason:   st.reason,
+		Override: st.override,
+		Muted:    st.muted(),
+		MarkedAt: st.markedAt,
+	}
+}
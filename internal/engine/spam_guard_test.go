@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memSpamStore 记录持久化调用的内存 SpamStore
+type memSpamStore struct {
+	mu        sync.Mutex
+	marked    map[string]string
+	overrides map[string]*bool
+	rows      []storage.SpamTokenRow
+}
+
+func newMemSpamStore() *memSpamStore {
+	return &memSpamStore{marked: make(map[string]string), overrides: make(map[string]*bool)}
+}
+
+func (s *memSpamStore) MarkTokenSpam(_ context.Context, address, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marked[address] = reason
+	return nil
+}
+
+func (s *memSpamStore) SetTokenSpamOverride(_ context.Context, address string, spam *bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides[address] = spam
+	return nil
+}
+
+func (s *memSpamStore) LoadSpamTokens(context.Context) ([]storage.SpamTokenRow, error) {
+	return s.rows, nil
+}
+
+func (s *memSpamStore) markedReason(address string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.marked[address]
+}
+
+func spamTransferLog(token common.Address, tx common.Hash, to uint64, amount int64) types.Log {
+	return types.Log{
+		Address:     token,
+		Topics:      []common.Hash{TransferEventHash, common.BytesToHash(filterTokenA.Bytes()), common.BigToHash(new(big.Int).SetUint64(to))},
+		Data:        common.LeftPadBytes(big.NewInt(amount).Bytes(), 32),
+		TxHash:      tx,
+		BlockNumber: 10,
+	}
+}
+
+func TestSpamGuard_Heuristics(t *testing.T) {
+	store := newMemSpamStore()
+	g := NewSpamGuard(SpamConfig{ZeroTransfersPerBlock: 3, AirdropRecipients: 4, FlagUnverifiable: true}, store)
+
+	var logs []types.Log
+	// 零金额泛滥：filterSpam 在同一块内 3 条零金额转账（分属不同交易）
+	for i := uint64(0); i < 3; i++ {
+		logs = append(logs, spamTransferLog(filterSpam, common.BigToHash(new(big.Int).SetUint64(i)), i, 0))
+	}
+	// 空投：filterTokenB 在一笔交易内向 4 个地址发出相同金额
+	for i := uint64(0); i < 4; i++ {
+		logs = append(logs, spamTransferLog(filterTokenB, common.HexToHash("0xb0"), 100+i, 7))
+	}
+	// 正常：filterTokenA 同一交易内金额各不相同
+	for i := uint64(0); i < 4; i++ {
+		logs = append(logs, spamTransferLog(filterTokenA, common.HexToHash("0xa0"), 200+i, int64(i+1)))
+	}
+
+	g.ScanBlock(logs)
+	assert.True(t, g.IsMuted(filterSpam))
+	assert.True(t, g.IsMuted(filterTokenB))
+	assert.False(t, g.IsMuted(filterTokenA))
+	assert.Equal(t, spamReasonZeroValueFlood, g.Status(filterSpam).Reason)
+	assert.Equal(t, spamReasonAirdrop, g.Status(filterTokenB).Reason)
+
+	// 元数据不可读的代币在其下一次 Transfer 时判定
+	g.ReportUnverifiable(filterTokenA)
+	assert.False(t, g.IsMuted(filterTokenA))
+	g.ScanBlock([]types.Log{spamTransferLog(filterTokenA, common.HexToHash("0xa1"), 1, 1)})
+	assert.True(t, g.IsMuted(filterTokenA))
+	assert.Equal(t, spamReasonUnverifiable, g.Status(filterTokenA).Reason)
+
+	addr := g.Status(filterSpam).Address
+	assert.Eventually(t, func() bool { return store.markedReason(addr) == spamReasonZeroValueFlood }, time.Second, 10*time.Millisecond)
+	assert.Len(t, g.List(), 3)
+}
+
+func TestSpamGuard_OverrideWinsOverHeuristics(t *testing.T) {
+	store := newMemSpamStore()
+	notSpam := false
+	store.rows = []storage.SpamTokenRow{
+		{Address: filterTokenA.Hex(), IsSpam: true, Reason: spamReasonAirdrop, Override: &notSpam},
+	}
+	g := NewSpamGuard(SpamConfig{ZeroTransfersPerBlock: 1}, store)
+	require.NoError(t, g.Load(context.Background()))
+	assert.False(t, g.IsMuted(filterTokenA), "override=false keeps a flagged token indexed")
+
+	// 被覆盖为非垃圾的代币不会被重新判定
+	g.ScanBlock([]types.Log{spamTransferLog(filterTokenA, common.HexToHash("0x1"), 1, 0)})
+	assert.False(t, g.IsMuted(filterTokenA))
+
+	spam := true
+	status, err := g.SetOverride(context.Background(), filterTokenB, &spam)
+	require.NoError(t, err)
+	assert.True(t, status.Muted)
+	assert.True(t, g.IsMuted(filterTokenB))
+	assert.Equal(t, &spam, store.overrides[status.Address])
+
+	// 清除覆盖后恢复启发式：filterTokenA 仍带有历史判定，重新静音
+	_, err = g.SetOverride(context.Background(), filterTokenA, nil)
+	require.NoError(t, err)
+	assert.True(t, g.IsMuted(filterTokenA))
+
+	// 清除未被判定代币的覆盖后不再出现在列表中
+	_, err = g.SetOverride(context.Background(), filterTokenB, nil)
+	require.NoError(t, err)
+	assert.False(t, g.IsMuted(filterTokenB))
+	assert.Len(t, g.List(), 1)
+}
+
+func TestExtractActivities_DropsSpamFromSameBlock(t *testing.T) {
+	p := &Processor{metrics: GetMetrics(), chainID: 1}
+	p.SetSpamGuard(NewSpamGuard(SpamConfig{ZeroTransfersPerBlock: 2}, nil))
+
+	logs := []types.Log{
+		spamTransferLog(filterSpam, common.HexToHash("0x1"), 1, 0),
+		spamTransferLog(filterSpam, common.HexToHash("0x2"), 2, 0),
+		spamTransferLog(filterTokenA, common.HexToHash("0x3"), 3, 5),
+	}
+	activities := p.extractActivities(context.Background(), big.NewInt(10), logs, nil)
+	require.Len(t, activities, 1)
+	assert.Equal(t, "0x00000000000000000000000000000000000000aa", activities[0].TokenAddress)
+}
@@ -0,0 +1,282 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// stablecoinFlowWatermark aggregate_watermarks 中的稳定币资金流水位名
+	stablecoinFlowWatermark = "stablecoin_flows"
+	// maxHoursPerRefresh 单轮最多重算的小时数，避免首次启动时长事务
+	maxHoursPerRefresh = 48
+)
+
+// StablecoinHourlyFlow 单小时单稳定币与单个交易所之间的资金流（数量为原始单位，*_usd 按精度换算）
+type StablecoinHourlyFlow struct {
+	Hour         time.Time `db:"hour" json:"hour"`
+	TokenAddress string    `db:"token_address" json:"token_address"`
+	Symbol       string    `db:"-" json:"symbol"`
+	Exchange     string    `db:"exchange" json:"exchange"`
+	Inflow       string    `db:"inflow" json:"inflow"`
+	Outflow      string    `db:"outflow" json:"outflow"`
+	Transfers    int64     `db:"transfers" json:"transfers"`
+	InflowUSD    float64   `db:"-" json:"inflow_usd"`
+	OutflowUSD   float64   `db:"-" json:"outflow_usd"`
+	NetUSD       float64   `db:"-" json:"net_usd"`
+}
+
+// ExchangeNetFlow 窗口内单个交易所的稳定币净流入汇总（正值 = 流入交易所）
+type ExchangeNetFlow struct {
+	Exchange   string  `json:"exchange"`
+	InflowUSD  float64 `json:"inflow_usd"`
+	OutflowUSD float64 `json:"outflow_usd"`
+	NetUSD     float64 `json:"net_usd"`
+	Transfers  int64   `json:"transfers"`
+}
+
+// StablecoinInfo API 响应中的稳定币标记
+type StablecoinInfo struct {
+	Symbol   string `json:"symbol"`
+	Address  string `json:"address"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// StablecoinFlowReport /api/stats/stablecoins 响应
+type StablecoinFlowReport struct {
+	ChainID     int64                  `json:"chain_id"`
+	Hours       int                    `json:"hours"`
+	Stablecoins []StablecoinInfo       `json:"stablecoins"`
+	Exchanges   []ExchangeNetFlow      `json:"exchanges"`
+	Flows       []StablecoinHourlyFlow `json:"flows"`
+}
+
+// StablecoinFlowAggregator 按水位增量维护 stablecoin_hourly_flows：
+// 只重算水位之后新区块所覆盖的小时（UTC），按小时整体重算，reorg 时由 RevertAggregates 回退水位
+type StablecoinFlowAggregator struct {
+	db       *sqlx.DB
+	profile  ChainProfile
+	interval time.Duration
+}
+
+// NewStablecoinFlowAggregator 创建稳定币资金流聚合器（稳定币集合取自链配置）
+func NewStablecoinFlowAggregator(db *sqlx.DB, chainID int64, interval time.Duration) *StablecoinFlowAggregator {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &StablecoinFlowAggregator{db: db, profile: GetChainProfile(chainID), interval: interval}
+}
+
+// Start 启动后台聚合循环（启动时立即执行一轮）；链上未标记稳定币时不启动
+func (a *StablecoinFlowAggregator) Start(ctx context.Context) {
+	if len(a.profile.Stablecoins) == 0 {
+		Logger.Info("💵 [StablecoinFlows] No tagged stablecoins for chain, aggregator disabled",
+			slog.Int64("chain_id", a.profile.ChainID))
+		return
+	}
+	Logger.Info("💵 [StablecoinFlows] Started",
+		slog.Int64("chain_id", a.profile.ChainID),
+		slog.Int("stablecoins", len(a.profile.Stablecoins)),
+		slog.Int("exchange_addresses", len(ExchangeLabels)),
+		slog.Duration("interval", a.interval))
+	go func() {
+		ticker := time.NewTicker(a.interval)
+		defer ticker.Stop()
+		for {
+			if err := a.Refresh(ctx); err != nil && ctx.Err() == nil {
+				Logger.Warn("💵 [StablecoinFlows] Refresh failed", slog.String("error", err.Error()))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Refresh 重算水位之后受影响的小时并推进水位
+func (a *StablecoinFlowAggregator) Refresh(ctx context.Context) error {
+	var pending struct {
+		MinTs    sql.NullInt64  `db:"min_ts"`
+		MaxTs    sql.NullInt64  `db:"max_ts"`
+		MaxBlock sql.NullString `db:"max_block"`
+	}
+	err := a.db.GetContext(ctx, &pending, `
+		SELECT MIN(timestamp) AS min_ts, MAX(timestamp) AS max_ts, MAX(number)::TEXT AS max_block
+		FROM blocks
+		WHERE number > COALESCE((SELECT last_block FROM aggregate_watermarks WHERE name = $1), -1)`,
+		stablecoinFlowWatermark)
+	if err != nil {
+		return fmt.Errorf("read pending blocks: %w", err)
+	}
+	if !pending.MinTs.Valid {
+		return nil
+	}
+
+	hours := affectedHours(pending.MinTs.Int64, pending.MaxTs.Int64, maxHoursPerRefresh)
+	lastHourEnd := hours[len(hours)-1].Add(time.Hour)
+	tokens, exchanges, labels := a.filterArgs()
+
+	tx, err := a.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, hour := range hours {
+		if err := recomputeStablecoinHour(ctx, tx, hour, tokens, exchanges, labels); err != nil {
+			return fmt.Errorf("recompute %s: %w", hour.Format(time.RFC3339), err)
+		}
+	}
+
+	watermark := pending.MaxBlock.String
+	if pending.MaxTs.Int64 >= lastHourEnd.Unix() {
+		if err := tx.GetContext(ctx, &watermark,
+			"SELECT COALESCE(MAX(number), -1)::TEXT FROM blocks WHERE timestamp < $1", lastHourEnd.Unix()); err != nil {
+			return fmt.Errorf("read watermark: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO aggregate_watermarks (name, last_block, updated_at) VALUES ($1, $2::NUMERIC, NOW())
+		ON CONFLICT (name) DO UPDATE SET last_block = EXCLUDED.last_block, updated_at = NOW()`,
+		stablecoinFlowWatermark, watermark); err != nil {
+		return fmt.Errorf("update watermark: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	Logger.Debug("💵 [StablecoinFlows] Refreshed",
+		slog.Int("hours", len(hours)),
+		slog.String("from", hours[0].Format(time.RFC3339)),
+		slog.String("watermark", watermark))
+	return nil
+}
+
+// filterArgs 稳定币地址与交易所地址 / 标签（均为小写，作为 SQL 数组参数）
+func (a *StablecoinFlowAggregator) filterArgs() (tokens, exchanges, labels []string) {
+	for _, coin := range a.profile.Stablecoins {
+		tokens = append(tokens, strings.ToLower(coin.Address.Hex()))
+	}
+	for addr, label := range ExchangeLabels {
+		exchanges = append(exchanges, strings.ToLower(addr))
+		labels = append(labels, label)
+	}
+	return tokens, exchanges, labels
+}
+
+// recomputeStablecoinHour 整体重算某一小时：先经 blocks 时间索引换算区块区间，再按 (稳定币, 交易所) 汇总流入 / 流出
+func recomputeStablecoinHour(ctx context.Context, tx *sqlx.Tx, hour time.Time, tokens, exchanges, labels []string) error {
+	start, end := hour.Unix(), hour.Add(time.Hour).Unix()
+	if _, err := tx.ExecContext(ctx, "DELETE FROM stablecoin_hourly_flows WHERE hour = to_timestamp($1)", start); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `
+		WITH span AS (
+			SELECT MIN(number) AS lo, MAX(number) AS hi FROM blocks WHERE timestamp >= $1 AND timestamp < $2
+		), ex AS (
+			SELECT * FROM UNNEST($4::text[], $5::text[]) AS e(address, label)
+		), t AS (
+			SELECT LOWER(token_address) AS token, LOWER(from_address) AS src, LOWER(to_address) AS dst, amount
+			FROM transfers, span
+			WHERE block_number >= span.lo AND block_number <= span.hi AND LOWER(token_address) = ANY($3::text[])
+		)
+		INSERT INTO stablecoin_hourly_flows (hour, token_address, exchange, inflow, outflow, transfers)
+		SELECT to_timestamp($1), t.token, ex.label,
+			COALESCE(SUM(t.amount) FILTER (WHERE t.dst = ex.address), 0),
+			COALESCE(SUM(t.amount) FILTER (WHERE t.src = ex.address), 0),
+			COUNT(*)
+		FROM t JOIN ex ON t.dst = ex.address OR t.src = ex.address
+		GROUP BY t.token, ex.label`,
+		start, end, tokens, exchanges, labels)
+	return err
+}
+
+// affectedHours 返回 [minTs, maxTs] 覆盖的 UTC 整点小时（最多 limit 个）
+func affectedHours(minTs, maxTs int64, limit int) []time.Time {
+	first := time.Unix(minTs, 0).UTC().Truncate(time.Hour)
+	last := time.Unix(maxTs, 0).UTC().Truncate(time.Hour)
+	var hours []time.Time
+	for h := first; !h.After(last) && len(hours) < limit; h = h.Add(time.Hour) {
+		hours = append(hours, h)
+	}
+	return hours
+}
+
+// QueryStablecoinFlows 读取最近 hours 小时的稳定币交易所资金流（新小时在前），并按交易所汇总净流入
+func QueryStablecoinFlows(ctx context.Context, db *sqlx.DB, chainID int64, hours int) (*StablecoinFlowReport, error) {
+	since := time.Now().UTC().Truncate(time.Hour).Add(-time.Duration(hours-1) * time.Hour)
+
+	flows := []StablecoinHourlyFlow{}
+	if err := TimedSelect(ctx, db, "stablecoin_hourly_flows", &flows, `
+		SELECT hour, token_address, exchange, inflow::TEXT AS inflow, outflow::TEXT AS outflow, transfers
+		FROM stablecoin_hourly_flows WHERE hour >= $1 ORDER BY hour DESC, exchange, token_address`, since); err != nil {
+		return nil, err
+	}
+
+	profile := GetChainProfile(chainID)
+	report := &StablecoinFlowReport{ChainID: chainID, Hours: hours, Stablecoins: []StablecoinInfo{}, Flows: flows}
+	for _, coin := range profile.Stablecoins {
+		report.Stablecoins = append(report.Stablecoins, StablecoinInfo{Symbol: coin.Symbol, Address: coin.Address.Hex(), Decimals: coin.Decimals})
+	}
+	report.Exchanges = summarizeStablecoinFlows(profile, report.Flows)
+	return report, nil
+}
+
+// summarizeStablecoinFlows 按稳定币精度把各行换算为美元（就地填充 *_usd），并按交易所汇总，净流入大者在前
+func summarizeStablecoinFlows(profile ChainProfile, flows []StablecoinHourlyFlow) []ExchangeNetFlow {
+	byExchange := make(map[string]*ExchangeNetFlow)
+	for i := range flows {
+		f := &flows[i]
+		coin, ok := profile.StablecoinByAddress(f.TokenAddress)
+		if !ok {
+			continue // 链配置已不再标记该代币
+		}
+		f.Symbol = coin.Symbol
+		f.InflowUSD = scaleUnits(f.Inflow, coin.Decimals)
+		f.OutflowUSD = scaleUnits(f.Outflow, coin.Decimals)
+		f.NetUSD = f.InflowUSD - f.OutflowUSD
+
+		agg, ok := byExchange[f.Exchange]
+		if !ok {
+			agg = &ExchangeNetFlow{Exchange: f.Exchange}
+			byExchange[f.Exchange] = agg
+		}
+		agg.InflowUSD += f.InflowUSD
+		agg.OutflowUSD += f.OutflowUSD
+		agg.NetUSD += f.NetUSD
+		agg.Transfers += f.Transfers
+	}
+
+	out := make([]ExchangeNetFlow, 0, len(byExchange))
+	for _, agg := range byExchange {
+		out = append(out, *agg)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].NetUSD != out[j].NetUSD {
+			return out[i].NetUSD > out[j].NetUSD
+		}
+		return out[i].Exchange < out[j].Exchange
+	})
+	return out
+}
+
+// scaleUnits 把原始整数数量按精度换算为浮点数（稳定币 1 单位即 1 美元）；无法解析时返回 0
+func scaleUnits(raw string, decimals uint8) float64 {
+	n, ok := new(big.Float).SetString(raw)
+	if !ok {
+		return 0
+	}
+	scale := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+	v, _ := n.Quo(n, scale).Float64()
+	return v
+}
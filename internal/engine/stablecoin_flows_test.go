@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAffectedHours(t *testing.T) {
+	hour := time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC)
+
+	hours := affectedHours(hour.Add(59*time.Minute).Unix(), hour.Add(2*time.Hour+time.Second).Unix(), 48)
+	require.Len(t, hours, 3)
+	assert.Equal(t, hour, hours[0])
+	assert.Equal(t, hour.Add(2*time.Hour), hours[2])
+
+	capped := affectedHours(hour.Unix(), hour.AddDate(0, 0, 5).Unix(), 48)
+	assert.Len(t, capped, 48)
+}
+
+func TestSummarizeStablecoinFlows(t *testing.T) {
+	mainnet := GetChainProfile(1)
+	usdc, ok := mainnet.StablecoinByAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	require.True(t, ok, "lookup is case-insensitive")
+	assert.Equal(t, "USDC", usdc.Symbol)
+	_, ok = GetChainProfile(31337).StablecoinByAddress(usdc.Address.Hex())
+	assert.False(t, ok, "stablecoin sets are per chain")
+
+	flows := []StablecoinHourlyFlow{
+		{TokenAddress: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", Exchange: "Binance", Inflow: "2500000000", Outflow: "500000000", Transfers: 3},
+		{TokenAddress: "0x6b175474e89094c44da98b954eedeac495271d0f", Exchange: "Binance", Inflow: "0", Outflow: "1500000000000000000000", Transfers: 1},
+		{TokenAddress: "0xdac17f958d2ee523a2206206994597c13d831ec7", Exchange: "Kraken", Inflow: "100000000", Outflow: "0", Transfers: 1},
+		{TokenAddress: "0x00000000000000000000000000000000000000aa", Exchange: "Kraken", Inflow: "1", Outflow: "0", Transfers: 1}, // 未标记代币
+	}
+	exchanges := summarizeStablecoinFlows(mainnet, flows)
+
+	assert.Equal(t, "USDC", flows[0].Symbol)
+	assert.InDelta(t, 2000, flows[0].NetUSD, 1e-9)
+	assert.InDelta(t, -1500, flows[1].NetUSD, 1e-9, "DAI uses 18 decimals")
+	assert.Equal(t, []ExchangeNetFlow{
+		{Exchange: "Binance", InflowUSD: 2500, OutflowUSD: 2000, NetUSD: 500, Transfers: 4},
+		{Exchange: "Kraken", InflowUSD: 100, NetUSD: 100, Transfers: 1},
+	}, exchanges)
+
+	assert.Zero(t, scaleUnits("not-a-number", 6))
+}
@@ -0,0 +1,220 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	statusService     *StatusService
+	statusServiceOnce sync.Once
+)
+
+// StatusService /api/status 的唯一数据源。
+// API 服务、HealthServer 与遥测脉冲都从同一份协调器快照投影出 UIStatusDTO，
+// 避免各入口自行读取 HeightOracle / RPC / 数据库导致同一时刻报告不同的数字。
+type StatusService struct {
+	orchestrator *Orchestrator
+
+	mu        sync.RWMutex
+	db        *sqlx.DB
+	rpcPool   RPCClient
+	sequencer *Sequencer
+	version   string
+	chainID   int64
+	emulator  func() interface{} // 内置仿真器状态（未启用为 nil）
+
+	minHealthyNodes int // 健康 RPC 节点数低于该值时 health=false（<1 按 1 计）
+}
+
+// GetStatusService 返回状态服务单例（依赖由启动流程通过 Configure 注入）
+func GetStatusService() *StatusService {
+	statusServiceOnce.Do(func() {
+		statusService = &StatusService{orchestrator: GetOrchestrator()}
+	})
+	return statusService
+}
+
+// Configure 注入数据库、RPC 池、链 ID 与版本号
+func (s *StatusService) Configure(db *sqlx.DB, rpcPool RPCClient, chainID int64, version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.db = db
+	s.rpcPool = rpcPool
+	s.chainID = chainID
+	s.version = version
+}
+
+// SetSequencer 注入 Sequencer（缓冲深度与停滞状态）
+func (s *StatusService) SetSequencer(seq *Sequencer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sequencer = seq
+}
+
+// SetMinHealthyNodes 设置 health 要求的最少健康 RPC 节点数（与 HealthServer 阈值一致）
+func (s *StatusService) SetMinHealthyNodes(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.minHealthyNodes = n
+}
+
+// SetEmulatorStatus 注入仿真器状态读取函数
+func (s *StatusService) SetEmulatorStatus(fn func() interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emulator = fn
+}
+
+// Ready 数据库与 RPC 是否已注入（未就绪时 API 返回初始化占位状态）
+func (s *StatusService) Ready() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.db != nil && s.rpcPool != nil
+}
+
+// Status 从协调器快照投影出对外状态
+func (s *StatusService) Status(ctx context.Context) UIStatusDTO {
+	s.mu.RLock()
+	db, rpcPool, sequencer := s.db, s.rpcPool, s.sequencer
+	version, chainID, emulator := s.version, s.chainID, s.emulator
+	minHealthyNodes := max(s.minHealthyNodes, 1)
+	s.mu.RUnlock()
+
+	o := s.orchestrator
+	snap := o.GetSnapshot()
+	globalSnap := GetGlobalState().Snapshot()
+	maxJobs, maxResults, _ := GetGlobalState().GetCapacity()
+
+	// 🚀 视觉自愈
+	latest := snap.LatestHeight
+	if latest == 0 {
+		if snap.FetchedHeight > 0 {
+			latest = snap.FetchedHeight
+		} else {
+			latest = snap.SyncedCursor
+		}
+	}
+
+	// 1. 实时数据库统计
+	var totalTransfers int64
+	if db != nil {
+		if err := db.GetContext(ctx, &totalTransfers, "SELECT COUNT(*) FROM transfers"); err != nil {
+			slog.Debug("📊 [Status] Failed to count transfers", "err", err)
+		}
+	}
+
+	// 🚀 🔥 影子修正：如果 DB 统计为 0 或失败，回退到 Orchestrator 内存统计
+	if totalTransfers == 0 && snap.Transfers > 0 {
+		totalTransfers = int64(snap.Transfers) // #nosec G115 - 转账计数远小于 int64 上限
+	}
+
+	// 2. 逻辑自洽：lag 与 drift 同源于快照，二者至多一个非零
+	syncLag := SafeInt64Diff(latest, snap.SyncedCursor)
+	drift := int64(0)
+	if syncLag < 0 {
+		drift = -syncLag
+		syncLag = 0
+	}
+
+	// 3. 动态状态评估
+	stateStr := snap.SystemState.String()
+	if globalSnap.ResultsDepth > globalSnap.PipelineDepth*80/100 {
+		stateStr = "pressure_limit"
+	} else if syncLag > 1000 && GetMetrics().GetWindowBPS() < 1 {
+		stateStr = "stalled"
+	}
+
+	stall := GetGlobalState().SequencerStall()
+	bufferSize := 0
+	if sequencer != nil {
+		stall = sequencer.StallState()
+		bufferSize = sequencer.GetBufferSize()
+	}
+	var stallInfo *SequencerStallState
+	if stall.Stalled {
+		stateStr = "stalled"
+		stallInfo = &stall
+	}
+
+	// 🧊 链头停止前进：问题在上游而非索引器，单独标记，不计入索引器健康
+	var upstreamInfo *UpstreamStallState
+	if upstream := GetUpstreamWatch().State(); upstream.Stalled {
+		stateStr = StateUpstreamStalled
+		upstreamInfo = &upstream
+	}
+
+	var nodes RPCNodeStatus
+	health := stateStr != "stalled"
+	if rpcPool != nil {
+		nodes = RPCNodeStatus{Healthy: rpcPool.GetHealthyNodeCount(), Total: rpcPool.GetTotalNodeCount()}
+		health = health && nodes.Healthy >= minHealthyNodes
+	}
+
+	// 4. 进度计算
+	fetchProgress := 0.0
+	syncProgress := 0.0
+	if latest > 0 {
+		fetchProgress = float64(snap.FetchedHeight) / float64(latest) * 100
+		syncProgress = float64(snap.SyncedCursor) / float64(latest) * 100
+	}
+
+	status := UIStatusDTO{
+		Version:             version,
+		State:               stateStr,
+		LatestBlock:         fmt.Sprintf("%d", latest),
+		TargetHeight:        fmt.Sprintf("%d", snap.TargetHeight),
+		LatestScheduled:     fmt.Sprintf("%d", snap.ScheduledHeight),
+		LatestIndexed:       fmt.Sprintf("%d", snap.SyncedCursor),
+		TotalBlocks:         int64(snap.SyncedCursor), // #nosec G115 - 🚀 🔥 UI 总进度基于逻辑游标
+		TotalTransfers:      totalTransfers,
+		MemorySync:          fmt.Sprintf("%d", snap.FetchedHeight),
+		SyncLag:             syncLag,
+		FetchLag:            SafeInt64Diff(latest, snap.FetchedHeight),
+		DriftBlocks:         drift,
+		TimeTravel:          drift > GetHeightOracle().DriftTolerance,
+		SyncProgressPercent: syncProgress,
+		FetchProgress:       fetchProgress,
+		TPS:                 GetMetrics().GetWindowTPS(),
+		BPS:                 GetMetrics().GetWindowBPS(),
+		Health:              health,
+		IsEcoMode:           snap.IsEcoMode,
+		RPCNodes:            nodes,
+		JobsDepth:           int(globalSnap.JobsQueueDepth),
+		JobsCapacity:        int(maxJobs),
+		ResultsDepth:        int(globalSnap.ResultsDepth),
+		ResultsCapacity:     int(maxResults),
+		BufferSize:          bufferSize,
+		SafetyBuffer:        snap.SafetyBuffer,
+		LastLog:             snap.LogEntry,
+		UpdatedAt:           snap.UpdatedAt.Format(time.RFC3339),
+		LastPulse:           time.Now().UnixMilli(),
+		Fingerprint:         "Yokohama-Lab-Primary",
+		SequencerStall:      stallInfo,
+		UpstreamStall:       upstreamInfo,
+	}
+
+	if writer := o.GetAsyncWriter(); writer != nil {
+		status.Writer = writer.GetMetrics()
+	}
+	if chainID != 0 {
+		info := GetChainProfile(chainID).Info()
+		status.Chain = &info
+	}
+	if emulator != nil {
+		status.Emulator = emulator()
+	}
+	if lock := ActiveWriteLock(); lock != nil {
+		holder, err := lock.Holder(ctx)
+		if err != nil {
+			slog.Debug("📊 [Status] Failed to read write lock holder", "err", err)
+		}
+		status.WriteLock = holder
+	}
+	return status
+}
@@ -0,0 +1,177 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusContract /api/status 对外 schema：字段名 → JSON 类型。
+// 仪表盘与外部监控依赖这些字段，删除或改类型属于破坏性变更。
+var statusContract = map[string]string{
+	"version":               "string",
+	"state":                 "string",
+	"latest_block":          "string",
+	"target_height":         "string",
+	"latest_scheduled":      "string",
+	"latest_indexed":        "string",
+	"total_blocks":          "number",
+	"total_transfers":       "number",
+	"memory_sync":           "string",
+	"sync_lag":              "number",
+	"fetch_lag":             "number",
+	"drift_blocks":          "number",
+	"time_travel":           "bool",
+	"sync_progress_percent": "number",
+	"fetch_progress":        "number",
+	"tps":                   "number",
+	"bps":                   "number",
+	"health":                "bool",
+	"is_eco_mode":           "bool",
+	"rpc_nodes":             "object",
+	"jobs_depth":            "number",
+	"jobs_capacity":         "number",
+	"results_depth":         "number",
+	"results_capacity":      "number",
+	"buffer_size":           "number",
+	"safety_buffer":         "number",
+	"updated_at":            "string",
+	"last_pulse":            "number",
+	"fingerprint":           "string",
+}
+
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	case map[string]interface{}:
+		return "object"
+	case nil:
+		return "null"
+	default:
+		return "array"
+	}
+}
+
+func assertStatusContract(t *testing.T, raw []byte) map[string]interface{} {
+	t.Helper()
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	for field, kind := range statusContract {
+		v, ok := doc[field]
+		if assert.Truef(t, ok, "missing field %q", field) {
+			assert.Equalf(t, kind, jsonKind(v), "field %q", field)
+		}
+	}
+	return doc
+}
+
+func testStatusOrchestrator(state CoordinatorState) *Orchestrator {
+	o := &Orchestrator{}
+	o.state = state
+	o.snapshot = state
+	return o
+}
+
+func TestStatusService_Contract(t *testing.T) {
+	o := testStatusOrchestrator(CoordinatorState{
+		LatestHeight:    1000,
+		TargetHeight:    999,
+		FetchedHeight:   950,
+		ScheduledHeight: 980,
+		SyncedCursor:    900,
+		SafetyBuffer:    1,
+	})
+	svc := &StatusService{orchestrator: o, rpcPool: &lossyLogClient{}, version: "test", chainID: 31337}
+
+	raw, err := json.Marshal(svc.Status(context.Background()))
+	require.NoError(t, err)
+	doc := assertStatusContract(t, raw)
+
+	assert.Equal(t, "1000", doc["latest_block"])
+	assert.Equal(t, "999", doc["target_height"])
+	assert.Equal(t, "980", doc["latest_scheduled"])
+	assert.Equal(t, "900", doc["latest_indexed"])
+	assert.Equal(t, "950", doc["memory_sync"])
+	assert.EqualValues(t, 100, doc["sync_lag"])
+	assert.EqualValues(t, 50, doc["fetch_lag"])
+	assert.EqualValues(t, 0, doc["drift_blocks"])
+	assert.Equal(t, map[string]interface{}{"healthy": 1.0, "total": 1.0}, doc["rpc_nodes"])
+	assert.Contains(t, doc, "chain")
+}
+
+func TestStatusService_MinHealthyNodes(t *testing.T) {
+	o := testStatusOrchestrator(CoordinatorState{LatestHeight: 100, FetchedHeight: 100, SyncedCursor: 100})
+	svc := &StatusService{orchestrator: o, rpcPool: &lossyLogClient{}} // 1 个健康节点
+
+	raw, err := json.Marshal(svc.Status(context.Background()))
+	require.NoError(t, err)
+	doc := assertStatusContract(t, raw)
+	assert.Equal(t, true, doc["health"], "default threshold is one healthy node")
+
+	svc.SetMinHealthyNodes(2)
+	raw, err = json.Marshal(svc.Status(context.Background()))
+	require.NoError(t, err)
+	doc = assertStatusContract(t, raw)
+	assert.Equal(t, false, doc["health"], "HEALTH_MIN_HEALTHY_NODES=2 with a single healthy node")
+	assert.Equal(t, map[string]interface{}{"healthy": 1.0, "total": 1.0}, doc["rpc_nodes"])
+}
+
+func TestStatusService_LagAndDriftShareSnapshot(t *testing.T) {
+	// 已索引高度超过链头：lag 归零，超出部分计入 drift
+	o := testStatusOrchestrator(CoordinatorState{LatestHeight: 100, FetchedHeight: 120, SyncedCursor: 120})
+	status := (&StatusService{orchestrator: o}).Status(context.Background())
+
+	assert.Zero(t, status.SyncLag)
+	assert.EqualValues(t, 20, status.DriftBlocks)
+	assert.Equal(t, status.DriftBlocks > GetHeightOracle().DriftTolerance, status.TimeTravel)
+}
+
+func TestStatusService_GetUIStatusMatchesService(t *testing.T) {
+	o := testStatusOrchestrator(CoordinatorState{LatestHeight: 500, FetchedHeight: 480, SyncedCursor: 470, Transfers: 42})
+
+	viaOrchestrator := o.GetUIStatus(context.Background(), nil, "v1")
+	viaService := (&StatusService{orchestrator: o, version: "v1"}).Status(context.Background())
+
+	// 时间相关字段除外，其余数字必须完全一致
+	viaOrchestrator.LastPulse, viaService.LastPulse = 0, 0
+	viaOrchestrator.TPS, viaService.TPS = 0, 0
+	viaOrchestrator.BPS, viaService.BPS = 0, 0
+	assert.Equal(t, viaService, viaOrchestrator)
+	assert.EqualValues(t, 42, viaService.TotalTransfers)
+}
+
+func TestHealthServerStatus_SameSchemaAsAPI(t *testing.T) {
+	h := NewHealthServer(nil, &lossyLogClient{}, nil, nil)
+	rec := httptest.NewRecorder()
+	h.Status(rec, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	healthDoc := assertStatusContract(t, rec.Body.Bytes())
+
+	apiRaw, err := json.Marshal(GetOrchestrator().GetUIStatus(context.Background(), nil, ""))
+	require.NoError(t, err)
+	var apiDoc map[string]interface{}
+	require.NoError(t, json.Unmarshal(apiRaw, &apiDoc))
+
+	keys := func(m map[string]interface{}) []string {
+		out := make([]string, 0, len(m))
+		for k := range m {
+			if k != "writer" && k != "chain" && k != "sequencer_stall" && k != "emulator" {
+				out = append(out, k)
+			}
+		}
+		sort.Strings(out)
+		return out
+	}
+	assert.Equal(t, keys(apiDoc), keys(healthDoc))
+}
@@ -0,0 +1,25 @@
+package engine
+
+import (
+	"context"
+
+	"web3-indexer-go/internal/storage"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NewStore 创建引擎使用的 Postgres 存储：读查询走 TimedSelect/TimedGet（超时 + 慢查询统计），
+// 回滚在删除区块前同一事务内撤销日统计（RevertAggregates）与地址注册表（RevertAddressRegistry）
+func NewStore(db *sqlx.DB) *storage.Postgres {
+	return storage.NewPostgres(db, storage.Options{
+		Select: TimedSelect,
+		Get:    TimedGet,
+		BeforeRollback: func(ctx context.Context, tx *sqlx.Tx, fromBlock string) error {
+			if _, err := RevertAggregates(ctx, tx, fromBlock); err != nil {
+				return err
+			}
+			_, err := RevertAddressRegistry(ctx, tx, fromBlock)
+			return err
+		},
+	})
+}
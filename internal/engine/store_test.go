@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"web3-indexer-go/internal/storage"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordedQuery 假 QueryFunc 捕获的一次调用
+type recordedQuery struct {
+	op    string
+	query string
+	args  []interface{}
+}
+
+func TestStore_ReadsGoThroughInjectedQueryFuncs(t *testing.T) {
+	var calls []recordedQuery
+	record := func(_ context.Context, _ sqlx.QueryerContext, op string, dest interface{}, query string, args ...interface{}) error {
+		calls = append(calls, recordedQuery{op: op, query: query, args: args})
+		if s, ok := dest.(*string); ok {
+			*s = "12345"
+		}
+		return nil
+	}
+	store := storage.NewPostgres(nil, storage.Options{Select: record, Get: record})
+	ctx := context.Background()
+
+	cursor, err := store.GetSyncCursor(ctx, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 12345, cursor)
+
+	_, err = store.GetLatestBlocks(ctx, 7)
+	require.NoError(t, err)
+	_, err = store.GetTransfersInRange(ctx, "100", "200", 50)
+	require.NoError(t, err)
+
+	require.Len(t, calls, 3)
+	assert.Equal(t, "sync_cursor", calls[0].op)
+	assert.Equal(t, []interface{}{int64(1)}, calls[0].args)
+	assert.Equal(t, "latest_blocks", calls[1].op)
+	assert.Equal(t, []interface{}{7}, calls[1].args)
+	assert.Equal(t, "transfers_range", calls[2].op)
+	assert.Equal(t, []interface{}{"100", "200", 50}, calls[2].args)
+	assert.Contains(t, calls[2].query, storage.TransferFrom)
+}
+
+func TestProcessor_RepoAdapterIsSharedStore(t *testing.T) {
+	p := NewProcessor(nil, &lossyLogClient{}, 1, 31337, false, "")
+	var repo DBUpdater = p.GetRepoAdapter()
+	_, ok := repo.(storage.Store)
+	assert.True(t, ok, "guard/watchdog/enricher must use the storage layer")
+}
@@ -33,7 +33,9 @@ func (s *AnvilStrategy) GetBatchSize() int              { return 200 }
 func (s *AnvilStrategy) GetInitialSafetyBuffer() uint64 { return 1 }
 
 // TestnetStrategy: 针对测试网优化（稳健、持久、断点续传）
-type TestnetStrategy struct{}
+type TestnetStrategy struct {
+	confirmations uint64 // 来自 ChainProfile 的最终性深度
+}
 
 func (s *TestnetStrategy) Name() string { return "PERSISTENT_TESTNET" }
 
@@ -42,8 +44,13 @@ func (s *TestnetStrategy) OnStartup(ctx context.Context, o *Orchestrator, db *sq
 	return o.LoadInitialState(db, chainID)
 }
 
-func (s *TestnetStrategy) ShouldPersist() bool            { return true }
-func (s *TestnetStrategy) GetConfirmations() uint64       { return 6 } // 等待 6 个块确认
+func (s *TestnetStrategy) ShouldPersist() bool { return true }
+func (s *TestnetStrategy) GetConfirmations() uint64 {
+	if s.confirmations == 0 {
+		return 6 // 默认等待 6 个块确认
+	}
+	return s.confirmations
+}
 func (s *TestnetStrategy) GetBatchSize() int              { return 50 }
 func (s *TestnetStrategy) GetInitialSafetyBuffer() uint64 { return 1 }
 
@@ -52,5 +59,5 @@ func GetStrategy(chainID int64) Strategy {
 	if chainID == 31337 {
 		return &AnvilStrategy{}
 	}
-	return &TestnetStrategy{}
+	return &TestnetStrategy{confirmations: GetChainProfile(chainID).FinalityDepth}
 }
@@ -12,10 +12,10 @@ import (
 func (o *Orchestrator) LogPulse(ctx context.Context) {
 	status := o.GetUIStatus(ctx, nil, "v2.2.0")
 
-	// 🚀 获取物理真实的 RPC 高度进行对比
+	// 🚀 获取物理真实的 RPC 高度进行对比（休眠期间不探测，避免空闲时每秒一次 RPC）
 	var rpcActual uint64
-	if o.fetcher != nil && o.fetcher.pool != nil {
-		if tip, err := o.fetcher.pool.GetLatestBlockNumber(ctx); err == nil {
+	if o.fetcher != nil && o.fetcher.pool != nil && !o.state.IsEcoMode {
+		if tip, err := LatestChainHead(ctx, o.fetcher.pool); err == nil {
 			rpcActual = tip.Uint64()
 		}
 	}
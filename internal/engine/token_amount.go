@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// defaultTokenDecimals token_metadata 尚未补全时采用的精度（ERC20 惯例）
+const defaultTokenDecimals = 18
+
+// PriceOracle 代币美元价格来源；未注入时 API 与 WS 事件不输出 amount_usd
+type PriceOracle interface {
+	// PriceUSD 返回单个代币（已按精度归一化）的美元价格；未知代币返回 false
+	PriceUSD(tokenAddress string) (float64, bool)
+}
+
+var (
+	priceOracleMu sync.RWMutex
+	priceOracle   PriceOracle
+)
+
+// SetPriceOracle 注入价格源（nil 表示关闭 USD 估值）
+func SetPriceOracle(o PriceOracle) {
+	priceOracleMu.Lock()
+	priceOracle = o
+	priceOracleMu.Unlock()
+}
+
+// FormatTokenAmount 把原始 uint256 金额按 decimals 转为十进制字符串（去掉末尾多余的 0）。
+// 纯整数运算，不经过 float，避免大额转账丢精度；无法解析的输入原样返回。
+func FormatTokenAmount(raw string, decimals uint8) string {
+	v, ok := new(big.Int).SetString(raw, 10)
+	if !ok {
+		return raw
+	}
+	if decimals == 0 {
+		return v.String()
+	}
+
+	neg := v.Sign() < 0
+	digits := new(big.Int).Abs(v).String()
+	if pad := int(decimals) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	split := len(digits) - int(decimals)
+	intPart, frac := digits[:split], strings.TrimRight(digits[split:], "0")
+
+	out := intPart
+	if frac != "" {
+		out += "." + frac
+	}
+	if neg {
+		out = "-" + out
+	}
+	return out
+}
+
+// TokenAmountUSD 用价格源估算归一化金额的美元价值；无价格源或未知代币时返回 nil
+func TokenAmountUSD(tokenAddress, normalized string) *float64 {
+	priceOracleMu.RLock()
+	o := priceOracle
+	priceOracleMu.RUnlock()
+	if o == nil {
+		return nil
+	}
+	price, ok := o.PriceUSD(strings.ToLower(tokenAddress))
+	if !ok {
+		return nil
+	}
+	amount, ok := new(big.Float).SetString(normalized)
+	if !ok {
+		return nil
+	}
+	usd, _ := new(big.Float).Mul(amount, big.NewFloat(price)).Float64()
+	return &usd
+}
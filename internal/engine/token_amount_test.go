@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatTokenAmount(t *testing.T) {
+	cases := []struct {
+		raw      string
+		decimals uint8
+		want     string
+	}{
+		{"1000000000000000000", 18, "1"},
+		{"1500000", 6, "1.5"},
+		{"1", 18, "0.000000000000000001"},
+		{"0", 18, "0"},
+		{"123456789", 0, "123456789"},
+		{"115792089237316195423570985008687907853269984665640564039457584007913129639935", 18,
+			"115792089237316195423570985008687907853269984665640564039457.584007913129639935"},
+		{"-2500", 3, "-2.5"},
+		{"not-a-number", 18, "not-a-number"},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, FormatTokenAmount(tc.raw, tc.decimals), "raw=%s decimals=%d", tc.raw, tc.decimals)
+	}
+}
+
+type fixedPriceOracle map[string]float64
+
+func (f fixedPriceOracle) PriceUSD(token string) (float64, bool) {
+	p, ok := f[token]
+	return p, ok
+}
+
+func TestTokenAmountUSD(t *testing.T) {
+	assert.Nil(t, TokenAmountUSD("0xabc", "1"))
+
+	SetPriceOracle(fixedPriceOracle{"0xabc": 2.5})
+	defer SetPriceOracle(nil)
+
+	usd := TokenAmountUSD("0xABC", "4.2")
+	require.NotNil(t, usd)
+	assert.InDelta(t, 10.5, *usd, 1e-9)
+	assert.Nil(t, TokenAmountUSD("0xdef", "1"))
+}
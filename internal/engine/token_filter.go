@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// 代币过滤拒绝原因（indexer_token_filtered_events_total 的 reason 标签）
+const (
+	tokenFilterDenied     = "denied"      // 命中拒绝名单（已知垃圾代币）
+	tokenFilterNotAllowed = "not_allowed" // 允许名单非空且未包含该代币
+	tokenFilterSpam       = "spam"        // 被垃圾代币启发式或管理员静音
+)
+
+// TokenFilter 代币级索引过滤：允许名单非空时只索引名单内代币，拒绝名单优先于允许名单。
+// 创建后只读，可在多个 goroutine 间共享。
+type TokenFilter struct {
+	allow map[common.Address]struct{}
+	deny  map[common.Address]struct{}
+}
+
+// NewTokenFilter 从地址列表构建过滤器；两个列表都为空（或全是非法地址）时返回 nil，表示不过滤
+func NewTokenFilter(allow, deny []string) *TokenFilter {
+	f := &TokenFilter{allow: toAddressSet(allow), deny: toAddressSet(deny)}
+	if len(f.allow) == 0 && len(f.deny) == 0 {
+		return nil
+	}
+	return f
+}
+
+func toAddressSet(addrs []string) map[common.Address]struct{} {
+	set := make(map[common.Address]struct{}, len(addrs))
+	for _, a := range addrs {
+		a = strings.TrimSpace(a)
+		if !common.IsHexAddress(a) {
+			if a != "" {
+				Logger.Warn("⚠️ [TokenFilter] Ignoring invalid token address", "address", a)
+			}
+			continue
+		}
+		set[common.HexToAddress(a)] = struct{}{}
+	}
+	return set
+}
+
+// Allows 判断是否索引该代币的事件；拒绝时返回原因
+func (f *TokenFilter) Allows(token common.Address) (bool, string) {
+	if f == nil {
+		return true, ""
+	}
+	if _, ok := f.deny[token]; ok {
+		return false, tokenFilterDenied
+	}
+	if len(f.allow) > 0 {
+		if _, ok := f.allow[token]; !ok {
+			return false, tokenFilterNotAllowed
+		}
+	}
+	return true, ""
+}
+
+// Sizes 允许 / 拒绝名单长度（用于启动日志）
+func (f *TokenFilter) Sizes() (allow, deny int) {
+	if f == nil {
+		return 0, 0
+	}
+	return len(f.allow), len(f.deny)
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	filterTokenA = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	filterTokenB = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	filterSpam   = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+)
+
+func TestTokenFilter_AllowAndDeny(t *testing.T) {
+	assert.Nil(t, NewTokenFilter(nil, []string{"", "not-an-address"}))
+
+	var none *TokenFilter
+	ok, _ := none.Allows(filterSpam)
+	assert.True(t, ok, "nil filter allows everything")
+
+	deny := NewTokenFilter(nil, []string{filterSpam.Hex()})
+	ok, reason := deny.Allows(filterSpam)
+	assert.False(t, ok)
+	assert.Equal(t, tokenFilterDenied, reason)
+	ok, _ = deny.Allows(filterTokenA)
+	assert.True(t, ok)
+
+	// 地址大小写不敏感；拒绝名单优先于允许名单
+	both := NewTokenFilter([]string{" 0x00000000000000000000000000000000000000AA ", filterSpam.Hex()}, []string{filterSpam.Hex()})
+	ok, _ = both.Allows(filterTokenA)
+	assert.True(t, ok)
+	ok, reason = both.Allows(filterTokenB)
+	assert.False(t, ok)
+	assert.Equal(t, tokenFilterNotAllowed, reason)
+	ok, reason = both.Allows(filterSpam)
+	assert.False(t, ok)
+	assert.Equal(t, tokenFilterDenied, reason)
+}
+
+func TestProcessLog_SkipsFilteredTokens(t *testing.T) {
+	p := &Processor{metrics: GetMetrics(), chainID: 1}
+	p.SetTokenFilter(NewTokenFilter(nil, []string{filterSpam.Hex()}))
+
+	transferLog := func(token common.Address) types.Log {
+		return types.Log{
+			Address:     token,
+			Topics:      []common.Hash{TransferEventHash, common.BytesToHash(filterTokenA.Bytes()), common.BytesToHash(filterTokenB.Bytes())},
+			Data:        common.LeftPadBytes(big.NewInt(5).Bytes(), 32),
+			BlockNumber: 10,
+		}
+	}
+
+	denied := GetMetrics().TokenFilteredEvents.WithLabelValues(tokenFilterDenied)
+	before := testutil.ToFloat64(denied)
+
+	assert.Nil(t, p.ProcessLog(transferLog(filterSpam)))
+	assert.Equal(t, before+1, testutil.ToFloat64(denied))
+
+	activity := p.ProcessLog(transferLog(filterTokenA))
+	if assert.NotNil(t, activity) {
+		assert.Equal(t, "TRANSFER", activity.Type)
+	}
+	assert.Equal(t, before+1, testutil.ToFloat64(denied))
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"strings"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+)
+
+// collectSupplyDeltas 把批次内的 MINT（零地址转出）与 BURN（转入销毁地址）按 (代币, 区块) 汇总为供应量增量，
+// WETH 的 WRAP / UNWRAP 分别计入铸造与销毁。
+// 结果按代币、区块排序，使并发事务以相同顺序加行锁。
+func collectSupplyDeltas(transfers []models.Transfer) []storage.SupplyDeltaRow {
+	type key struct {
+		token string
+		block uint64
+	}
+	rows := make(map[key]*storage.SupplyDeltaRow)
+
+	for _, t := range transfers {
+		typ := NormalizeActivityType(t.Type)
+		minted := typ == models.ActivityMint || typ == models.ActivityWrap
+		burned := typ == models.ActivityBurn || typ == models.ActivityUnwrap
+		if (!minted && !burned) || t.BlockNumber.Int == nil || t.Amount.Int == nil {
+			continue
+		}
+		k := key{strings.ToLower(t.TokenAddress), t.BlockNumber.Uint64()}
+		row, ok := rows[k]
+		if !ok {
+			row = &storage.SupplyDeltaRow{Token: k.token, Block: k.block, Minted: new(big.Int), Burned: new(big.Int)}
+			rows[k] = row
+		}
+		if minted {
+			row.Minted.Add(row.Minted, t.Amount.ToBig())
+			row.Mints++
+		} else {
+			row.Burned.Add(row.Burned, t.Amount.ToBig())
+			row.Burns++
+		}
+	}
+
+	out := make([]storage.SupplyDeltaRow, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, *row)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Token != out[j].Token {
+			return out[i].Token < out[j].Token
+		}
+		return out[i].Block < out[j].Block
+	})
+	return out
+}
+
+// upsertSupplyDeltasTx 在 AsyncWriter 事务内写入批次的供应量增量（随 blocks 级联删除，reorg 无需额外撤销）
+func upsertSupplyDeltasTx(ctx context.Context, tx execer, transfers []models.Transfer) error {
+	return storage.UpsertSupplyDeltasTx(ctx, tx, collectSupplyDeltas(transfers))
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/storage"
+)
+
+func TestProcessLog_ClassifiesMintAndBurn(t *testing.T) {
+	p := &Processor{metrics: GetMetrics(), chainID: 1}
+	holder := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	transfer := func(from, to common.Address) types.Log {
+		return types.Log{
+			Address:     filterTokenA,
+			Topics:      []common.Hash{TransferEventHash, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+			Data:        common.LeftPadBytes(big.NewInt(7).Bytes(), 32),
+			BlockNumber: 10,
+		}
+	}
+
+	mint := p.ProcessLog(transfer(common.Address{}, holder))
+	require.NotNil(t, mint)
+	assert.Equal(t, models.ActivityMint, mint.Type)
+
+	burn := p.ProcessLog(transfer(holder, common.Address{}))
+	require.NotNil(t, burn)
+	assert.Equal(t, models.ActivityBurn, burn.Type)
+
+	plain := p.ProcessLog(transfer(holder, filterTokenB))
+	require.NotNil(t, plain)
+	assert.Equal(t, "TRANSFER", plain.Type)
+}
+
+func TestCollectSupplyDeltas(t *testing.T) {
+	tokenA := "0x00000000000000000000000000000000000000AA"
+	tokenB := "0x00000000000000000000000000000000000000bb"
+
+	rows := collectSupplyDeltas([]models.Transfer{
+		{BlockNumber: models.NewBigInt(12), TokenAddress: tokenB, Type: models.ActivityMint, Amount: models.NewUint256(5)},
+		{BlockNumber: models.NewBigInt(11), TokenAddress: tokenA, Type: models.ActivityMint, Amount: models.NewUint256(100)},
+		{BlockNumber: models.NewBigInt(11), TokenAddress: tokenA, Type: models.ActivityMint, Amount: models.NewUint256(50)},
+		{BlockNumber: models.NewBigInt(11), TokenAddress: tokenA, Type: models.ActivityBurn, Amount: models.NewUint256(30)},
+		{BlockNumber: models.NewBigInt(11), TokenAddress: tokenA, Type: "TRANSFER", Amount: models.NewUint256(999)}, // 普通转账不影响供应量
+		{TokenAddress: tokenA, Type: models.ActivityMint, Amount: models.NewUint256(1)},                             // 缺少区块号
+		{BlockNumber: models.NewBigInt(13), TokenAddress: tokenB, Type: models.ActivityWrap, Amount: models.NewUint256(8)},
+		{BlockNumber: models.NewBigInt(13), TokenAddress: tokenB, Type: models.ActivityUnwrap, Amount: models.NewUint256(3)},
+	})
+
+	require.Len(t, rows, 3)
+	assert.Equal(t, storage.SupplyDeltaRow{Token: "0x00000000000000000000000000000000000000aa", Block: 11, Minted: big.NewInt(150), Burned: big.NewInt(30), Mints: 2, Burns: 1}, rows[0])
+	assert.Equal(t, storage.SupplyDeltaRow{Token: tokenB, Block: 12, Minted: big.NewInt(5), Burned: big.NewInt(0), Mints: 1}, rows[1])
+	assert.Equal(t, storage.SupplyDeltaRow{Token: tokenB, Block: 13, Minted: big.NewInt(8), Burned: big.NewInt(3), Mints: 1, Burns: 1}, rows[2])
+}
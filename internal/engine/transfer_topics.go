@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// TransferTopics 额外的 Transfer 等价事件：topic0 → origin 标签（写入 transfers.origin）。
+// 桥、包装资产常用自定义事件表达转账，参数需按 (from, to, amount) 顺序声明
+type TransferTopics map[common.Hash]string
+
+// originPattern origin 标签限定为短标识符（入库列宽 64）
+var originPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// ParseTransferTopics 解析 EXTRA_TRANSFER_TOPICS 条目，格式为 origin=topic0，
+// topic0 可写 32 字节十六进制哈希或事件签名（如 Bridged(address,address,uint256)）
+func ParseTransferTopics(specs []string) (TransferTopics, error) {
+	topics := make(TransferTopics, len(specs))
+	for _, spec := range specs {
+		origin, sig, ok := strings.Cut(spec, "=")
+		origin, sig = strings.TrimSpace(origin), strings.TrimSpace(sig)
+		if !ok || !originPattern.MatchString(origin) || sig == "" {
+			return nil, fmt.Errorf("invalid transfer topic %q: want origin=topic0", spec)
+		}
+
+		var topic common.Hash
+		switch {
+		case strings.HasPrefix(sig, "0x") && len(sig) == 2+2*common.HashLength:
+			b, err := hexutil.Decode(sig)
+			if err != nil {
+				return nil, fmt.Errorf("invalid transfer topic %q: %w", spec, err)
+			}
+			topic = common.BytesToHash(b)
+		case strings.Contains(sig, "("):
+			topic = crypto.Keccak256Hash([]byte(strings.ReplaceAll(sig, " ", "")))
+		default:
+			return nil, fmt.Errorf("invalid transfer topic %q: topic0 must be a 32-byte hash or an event signature", spec)
+		}
+
+		if topic == TransferEventHash {
+			return nil, fmt.Errorf("invalid transfer topic %q: standard Transfer is always indexed", spec)
+		}
+		if prev, dup := topics[topic]; dup && prev != origin {
+			return nil, fmt.Errorf("transfer topic %s mapped to both %q and %q", topic.Hex(), prev, origin)
+		}
+		topics[topic] = origin
+	}
+	return topics, nil
+}
+
+// Hashes 返回全部额外 topic0（排序，保证过滤条件稳定）
+func (t TransferTopics) Hashes() []common.Hash {
+	hashes := make([]common.Hash, 0, len(t))
+	for h := range t {
+		hashes = append(hashes, h)
+	}
+	slices.SortFunc(hashes, func(a, b common.Hash) int { return a.Cmp(b) })
+	return hashes
+}
+
+// logTopicFilter eth_getLogs 的 topic0 过滤：标准 Transfer、授权事件（写入 approvals）、
+// WETH 包装 / 解包事件加上额外的等价事件与通用解码的事件
+func logTopicFilter(extra []common.Hash) [][]common.Hash {
+	return [][]common.Hash{append([]common.Hash{
+		TransferEventHash, ApprovalEventHash, ApprovalForAllEventHash, DepositEventHash, WithdrawalEventHash,
+	}, extra...)}
+}
+
+// decodeWrapEvent 解码 WETH9 的 Deposit(address indexed, uint256) / Withdrawal(address indexed, uint256)；
+// 布局不符（同名事件的其他合约）时返回 false
+func decodeWrapEvent(vLog types.Log) (account common.Address, amount *big.Int, ok bool) {
+	if len(vLog.Topics) != 2 || len(vLog.Data) != 32 {
+		return common.Address{}, nil, false
+	}
+	return common.BytesToAddress(vLog.Topics[1].Bytes()), new(big.Int).SetBytes(vLog.Data), true
+}
+
+// decodeTransferLike 按 (from, to, amount) 声明顺序解码 Transfer 等价事件：
+// indexed 参数依次取自 topics[1:]，其余参数依次取自 data 的 32 字节字；参数不足时返回 false
+func decodeTransferLike(vLog types.Log) (from, to common.Address, amount *big.Int, ok bool) {
+	words := make([][]byte, 0, 3)
+	for _, t := range vLog.Topics[1:] {
+		words = append(words, t.Bytes())
+	}
+	for i := 0; len(words) < 3 && i+32 <= len(vLog.Data); i += 32 {
+		words = append(words, vLog.Data[i:i+32])
+	}
+	if len(words) < 3 {
+		return common.Address{}, common.Address{}, nil, false
+	}
+	return common.BytesToAddress(words[0]), common.BytesToAddress(words[1]), new(big.Int).SetBytes(words[2]), true
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"web3-indexer-go/internal/models"
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var bridgedEventHash = crypto.Keccak256Hash([]byte("Bridged(address,address,uint256)"))
+
+func TestParseTransferTopics(t *testing.T) {
+	topics, err := ParseTransferTopics([]string{
+		"bridge=Bridged(address, address, uint256)",
+		"wrapped=" + ApprovalEventHash.Hex(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, TransferTopics{bridgedEventHash: "bridge", ApprovalEventHash: "wrapped"}, topics)
+	assert.Len(t, topics.Hashes(), 2)
+
+	for _, spec := range []string{
+		"bridge",
+		"=Bridged(address,address,uint256)",
+		"bad tag=Bridged(address,address,uint256)",
+		"bridge=0x1234",
+		"bridge=" + TransferEventHash.Hex(),
+	} {
+		_, err := ParseTransferTopics([]string{spec})
+		assert.Error(t, err, spec)
+	}
+	_, err = ParseTransferTopics([]string{"a=Bridged(address,address,uint256)", "b=Bridged(address,address,uint256)"})
+	assert.Error(t, err, "one topic cannot carry two origins")
+}
+
+func TestProcessLog_ExtraTransferTopics(t *testing.T) {
+	p := &Processor{metrics: GetMetrics(), chainID: 1}
+	p.SetExtraTransferTopics(TransferTopics{bridgedEventHash: "bridge"})
+	from, to := testkit.Address("from", 1), testkit.Address("to", 1)
+	word := func(b []byte) []byte { return common.LeftPadBytes(b, 32) }
+
+	// from / to indexed, amount in data
+	indexed := p.ProcessLog(types.Log{
+		Address: testkit.USDC,
+		Topics:  []common.Hash{bridgedEventHash, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    word(big.NewInt(42).Bytes()),
+	})
+	require.NotNil(t, indexed)
+	assert.Equal(t, models.ActivityTransfer, indexed.Type)
+	assert.Equal(t, "bridge", indexed.Origin)
+	assert.Equal(t, strings.ToLower(from.Hex()), indexed.From)
+	assert.Equal(t, strings.ToLower(to.Hex()), indexed.To)
+	assert.Equal(t, "42", indexed.Amount.String())
+
+	// only from indexed; to and amount follow in data
+	mixed := p.ProcessLog(types.Log{
+		Address: testkit.USDC,
+		Topics:  []common.Hash{bridgedEventHash, {}},
+		Data:    append(word(to.Bytes()), word(big.NewInt(7).Bytes())...),
+	})
+	require.NotNil(t, mixed)
+	assert.Equal(t, models.ActivityMint, mixed.Type, "zero sender is classified like a standard Transfer")
+	assert.Equal(t, strings.ToLower(to.Hex()), mixed.To)
+	assert.Equal(t, "7", mixed.Amount.String())
+
+	short := p.ProcessLog(types.Log{Address: testkit.USDC, Topics: []common.Hash{bridgedEventHash, {}}})
+	require.NotNil(t, short)
+	assert.Equal(t, "CONTRACT_EVENT", short.Type, "undecodable events fall back to a generic contract event")
+	assert.Empty(t, short.Origin)
+
+	standard := p.ProcessLog(types.Log{
+		Address: testkit.USDC,
+		Topics:  []common.Hash{TransferEventHash, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    word(big.NewInt(1).Bytes()),
+	})
+	require.NotNil(t, standard)
+	assert.Empty(t, standard.Origin)
+}
+
+func TestLogTopicFilter(t *testing.T) {
+	base := []common.Hash{TransferEventHash, ApprovalEventHash, ApprovalForAllEventHash, DepositEventHash, WithdrawalEventHash}
+	assert.Equal(t, [][]common.Hash{base}, logTopicFilter(nil))
+	assert.Equal(t, [][]common.Hash{append(base, bridgedEventHash)}, logTopicFilter([]common.Hash{bridgedEventHash}))
+}
+
+func TestProcessLog_WETHWrapUnwrap(t *testing.T) {
+	p := &Processor{metrics: GetMetrics(), chainID: 1}
+	holder := testkit.Address("holder", 1)
+	zero := strings.ToLower(common.Address{}.Hex())
+
+	wrap := p.ProcessLog(testkit.WETHDeposit(testkit.WETH, holder, big.NewInt(5)))
+	require.NotNil(t, wrap)
+	assert.Equal(t, models.ActivityWrap, wrap.Type)
+	assert.Equal(t, zero, wrap.From)
+	assert.Equal(t, strings.ToLower(holder.Hex()), wrap.To)
+	assert.Equal(t, "5", wrap.Amount.String())
+
+	unwrap := p.ProcessLog(testkit.WETHWithdrawal(testkit.WETH, holder, big.NewInt(3)))
+	require.NotNil(t, unwrap)
+	assert.Equal(t, models.ActivityUnwrap, unwrap.Type)
+	assert.Equal(t, strings.ToLower(holder.Hex()), unwrap.From)
+	assert.Equal(t, zero, unwrap.To)
+
+	// 其他合约的同名事件、布局不符的事件都不入库
+	assert.Nil(t, p.ProcessLog(testkit.WETHDeposit(testkit.USDC, holder, big.NewInt(5))))
+	malformed := testkit.WETHDeposit(testkit.WETH, holder, big.NewInt(5))
+	malformed.Data = append(malformed.Data, malformed.Data...)
+	assert.Nil(t, p.ProcessLog(malformed))
+
+	// 本地链的 WETH 部署地址不固定，接受任意合约
+	local := &Processor{metrics: GetMetrics(), chainID: 31337}
+	anyWETH := local.ProcessLog(testkit.WETHDeposit(testkit.USDC, holder, big.NewInt(5)))
+	require.NotNil(t, anyWETH)
+	assert.Equal(t, models.ActivityWrap, anyWETH.Type)
+}
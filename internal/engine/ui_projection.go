@@ -2,120 +2,59 @@ package engine
 
 import (
 	"context"
-	"fmt"
-	"log/slog"
-	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
-// UIStatusDTO 是专门给网页看的数据契约 (Shadow Snapshot)
+// UIStatusDTO 是 /api/status 的唯一数据契约 (Shadow Snapshot)，由 StatusService 从协调器快照投影。
+// 字段名即对外 JSON schema，改动需同步 status_service_test.go 中的契约测试。
 type UIStatusDTO struct {
 	Version             string                 `json:"version"`
 	State               string                 `json:"state"` // LIVE, PRESSURE_LIMIT, STALLED, DEGRADED
 	LatestBlock         string                 `json:"latest_block"`
+	TargetHeight        string                 `json:"target_height"`    // 🎯 扣除安全垫后的目标高度
+	LatestScheduled     string                 `json:"latest_scheduled"` // 📅 已提交给 Fetcher 的最高块
 	LatestIndexed       string                 `json:"latest_indexed"`
 	TotalBlocks         int64                  `json:"total_blocks"`
 	TotalTransfers      int64                  `json:"total_transfers"`
 	MemorySync          string                 `json:"memory_sync"` // 🚀 影子游标 (Fetcher 进度)
 	SyncLag             int64                  `json:"sync_lag"`    // 物理滞后
 	FetchLag            int64                  `json:"fetch_lag"`   // 扫描滞后
+	DriftBlocks         int64                  `json:"drift_blocks"`
+	TimeTravel          bool                   `json:"time_travel"`
 	SyncProgressPercent float64                `json:"sync_progress_percent"`
 	FetchProgress       float64                `json:"fetch_progress"`
 	TPS                 float64                `json:"tps"`
 	BPS                 float64                `json:"bps"`
 	Health              bool                   `json:"health"`
+	IsEcoMode           bool                   `json:"is_eco_mode"`
+	RPCNodes            RPCNodeStatus          `json:"rpc_nodes"`
 	JobsDepth           int                    `json:"jobs_depth"`
 	JobsCapacity        int                    `json:"jobs_capacity"`
 	ResultsDepth        int                    `json:"results_depth"`
 	ResultsCapacity     int                    `json:"results_capacity"`
+	BufferSize          int                    `json:"buffer_size"` // Sequencer 乱序缓冲
 	SafetyBuffer        uint64                 `json:"safety_buffer"`
+	Writer              map[string]interface{} `json:"writer,omitempty"` // AsyncWriter 指标
 	LastLog             map[string]interface{} `json:"last_log"`
 	UpdatedAt           string                 `json:"updated_at"`
 	LastPulse           int64                  `json:"last_pulse"`
 	Fingerprint         string                 `json:"fingerprint"`
+	Chain               *ChainInfo             `json:"chain,omitempty"`
+	SequencerStall      *SequencerStallState   `json:"sequencer_stall,omitempty"`
+	UpstreamStall       *UpstreamStallState    `json:"upstream_stall,omitempty"`
+	Emulator            interface{}            `json:"emulator,omitempty"`   // 内置仿真器状态（仅启用时）
+	WriteLock           *WriteLockHolder       `json:"write_lock,omitempty"` // 单写者 advisory 锁当前持有者（仅启用时）
 }
 
-// GetUIStatus 将复杂的内部状态投影为简洁的 UI 对象
-func (o *Orchestrator) GetUIStatus(ctx context.Context, db *sqlx.DB, version string) UIStatusDTO {
-	snap := o.GetSnapshot()
-	globalSnap := GetGlobalState().Snapshot()
-	maxJobs, maxResults, _ := GetGlobalState().GetCapacity()
-
-	// 🚀 视觉自愈
-	latest := snap.LatestHeight
-	if latest == 0 {
-		if snap.FetchedHeight > 0 {
-			latest = snap.FetchedHeight
-		} else {
-			latest = snap.SyncedCursor
-		}
-	}
-
-	// 1. 实时数据库统计 (带 5s 缓存以防高频请求压垮 DB)
-	// 此处为简化逻辑直接查询，实际生产建议使用原子变量缓存
-	var totalBlocks, totalTransfers int64
-	if db != nil {
-		if err := db.GetContext(ctx, &totalBlocks, "SELECT COUNT(*) FROM blocks"); err != nil {
-			slog.Debug("📊 [UI] Failed to count blocks", "err", err)
-		}
-		if err := db.GetContext(ctx, &totalTransfers, "SELECT COUNT(*) FROM transfers"); err != nil {
-			slog.Debug("📊 [UI] Failed to count transfers", "err", err)
-		}
-	}
-
-	// 🚀 🔥 影子修正：如果 DB 统计为 0 或失败，回退到 Orchestrator 内存统计
-	if totalTransfers == 0 && snap.Transfers > 0 {
-		totalTransfers = int64(snap.Transfers)
-	}
-
-	// 2. 逻辑自洽
-	syncLag := SafeInt64Diff(latest, snap.SyncedCursor)
-	if syncLag < 0 {
-		syncLag = 0
-	}
-
-	// 3. 动态状态评估
-	stateStr := snap.SystemState.String()
-	if globalSnap.ResultsDepth > globalSnap.PipelineDepth*80/100 {
-		stateStr = "pressure_limit"
-	} else if syncLag > 1000 && GetMetrics().GetWindowBPS() < 1 {
-		stateStr = "stalled"
-	}
-
-	// 4. 进度计算
-	fetchProgress := 0.0
-	if latest > 0 {
-		fetchProgress = float64(snap.FetchedHeight) / float64(latest) * 100
-	}
-	syncProgress := 0.0
-	if latest > 0 {
-		syncProgress = float64(snap.SyncedCursor) / float64(latest) * 100
-	}
+// RPCNodeStatus RPC 节点健康计数
+type RPCNodeStatus struct {
+	Healthy int `json:"healthy"`
+	Total   int `json:"total"`
+}
 
-	return UIStatusDTO{
-		Version:             version,
-		State:               stateStr,
-		LatestBlock:         fmt.Sprintf("%d", latest),
-		LatestIndexed:       fmt.Sprintf("%d", snap.SyncedCursor),
-		TotalBlocks:         int64(snap.SyncedCursor), // 🚀 🔥 修正：UI 总进度应基于逻辑游标
-		TotalTransfers:      totalTransfers,
-		MemorySync:          fmt.Sprintf("%d", snap.FetchedHeight),
-		SyncLag:             syncLag,
-		FetchLag:            SafeInt64Diff(latest, snap.FetchedHeight),
-		SyncProgressPercent: syncProgress,
-		FetchProgress:       fetchProgress,
-		TPS:                 GetMetrics().GetWindowTPS(),
-		BPS:                 GetMetrics().GetWindowBPS(),
-		Health:              stateStr != "stalled",
-		JobsDepth:           int(globalSnap.JobsQueueDepth),
-		JobsCapacity:        int(maxJobs),
-		ResultsDepth:        int(globalSnap.ResultsDepth),
-		ResultsCapacity:     int(maxResults),
-		SafetyBuffer:        snap.SafetyBuffer,
-		LastLog:             snap.LogEntry,
-		UpdatedAt:           snap.UpdatedAt.Format(time.RFC3339),
-		LastPulse:           time.Now().UnixMilli(),
-		Fingerprint:         "Yokohama-Lab-Primary",
-	}
+// GetUIStatus 将复杂的内部状态投影为简洁的 UI 对象（不依赖全局 StatusService 的轻量入口）
+func (o *Orchestrator) GetUIStatus(ctx context.Context, db *sqlx.DB, version string) UIStatusDTO {
+	svc := &StatusService{orchestrator: o, db: db, version: version}
+	return svc.Status(ctx)
 }
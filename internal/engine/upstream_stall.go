@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// StateUpstreamStalled /api/status 的 state：链头停止前进，问题在上游节点 / 链本身而非索引器
+	StateUpstreamStalled = "UPSTREAM_STALLED"
+
+	// upstreamStallBlocks 链头连续多少个出块间隔未前进判定为上游停滞
+	upstreamStallBlocks = 30
+	// minUpstreamStallAfter 停滞判定下限，避免出块快的链因短暂抖动误报
+	minUpstreamStallAfter = time.Minute
+)
+
+// UpstreamStallState 上游链停滞状态（/api/status、/healthz 与 WS 告警对外暴露）
+type UpstreamStallState struct {
+	Stalled          bool      `json:"stalled"`
+	ChainHead        string    `json:"chain_head"`
+	UnchangedSeconds float64   `json:"unchanged_seconds"`
+	ThresholdSeconds float64   `json:"threshold_seconds"`
+	LastAdvanceAt    time.Time `json:"last_advance_at"`
+	DetectedAt       time.Time `json:"detected_at,omitempty"`
+}
+
+// UpstreamStallThreshold 按链配置推导停滞阈值：override > 0 时直接使用；
+// 否则取 upstreamStallBlocks 个出块间隔（不低于 minUpstreamStallAfter）。
+// 按需出块的链（无交易即不出块）未显式配置时返回 0，表示不检测
+func UpstreamStallThreshold(profile ChainProfile, override time.Duration) time.Duration {
+	if override > 0 {
+		return override
+	}
+	if profile.OnDemandMining || profile.BlockTime <= 0 {
+		return 0
+	}
+	return max(upstreamStallBlocks*profile.BlockTime, minUpstreamStallAfter)
+}
+
+// UpstreamWatch 跟踪链头最后一次前进的时刻。
+// 只在观测到链头时判定（轮询 / 推送兜底保证观测间隔远小于阈值），
+// 因此本地 RPC 故障导致的“观测不到”不会被误判为上游停滞
+type UpstreamWatch struct {
+	mu         sync.Mutex
+	threshold  time.Duration
+	head       uint64
+	advancedAt time.Time
+	stalled    bool
+	detectedAt time.Time
+	onChange   func(UpstreamStallState)
+	now        func() time.Time
+}
+
+var (
+	upstreamWatch     *UpstreamWatch
+	upstreamWatchOnce sync.Once
+)
+
+// GetUpstreamWatch 返回上游停滞检测单例（启动流程通过 Configure 设置阈值，未配置时不检测）
+func GetUpstreamWatch() *UpstreamWatch {
+	upstreamWatchOnce.Do(func() {
+		upstreamWatch = NewUpstreamWatch(0)
+	})
+	return upstreamWatch
+}
+
+// NewUpstreamWatch 创建独立的检测器；threshold <= 0 表示不检测
+func NewUpstreamWatch(threshold time.Duration) *UpstreamWatch {
+	return &UpstreamWatch{threshold: threshold, now: time.Now}
+}
+
+// Configure 设置停滞阈值与状态切换回调（进入 / 解除停滞时各调用一次）
+func (w *UpstreamWatch) Configure(threshold time.Duration, onChange func(UpstreamStallState)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.threshold = threshold
+	w.onChange = onChange
+}
+
+// Observe 记录一次观测到的链头（由 HeadFollower 在每次轮询 / 推送后调用）
+func (w *UpstreamWatch) Observe(head uint64) {
+	w.mu.Lock()
+	if w.threshold <= 0 {
+		w.mu.Unlock()
+		return
+	}
+	now := w.now()
+	wasStalled := w.stalled
+	if head > w.head || w.advancedAt.IsZero() {
+		w.head = head
+		w.advancedAt = now
+		w.stalled = false
+	} else if !w.stalled && now.Sub(w.advancedAt) > w.threshold {
+		w.stalled = true
+		w.detectedAt = now
+	}
+	changed := w.stalled != wasStalled
+	state := w.stateLocked(now)
+	onChange, threshold := w.onChange, w.threshold
+	w.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	GetMetrics().SetUpstreamStalled(state.Stalled)
+	if state.Stalled {
+		Logger.Error("🧊 UPSTREAM_STALLED: chain head has not advanced",
+			"chain_head", state.ChainHead,
+			"unchanged_for", time.Duration(state.UnchangedSeconds*float64(time.Second)).Round(time.Second),
+			"threshold", threshold,
+			"action", "check the RPC node / chain; the indexer is waiting for new blocks")
+	} else {
+		Logger.Info("✅ UPSTREAM_RESUMED: chain head advancing again", "chain_head", state.ChainHead)
+	}
+	if onChange != nil {
+		onChange(state)
+	}
+}
+
+// Reset 丢弃已观测的链头（链重置后链头从 0 重新开始，旧链头不再可比）
+func (w *UpstreamWatch) Reset() {
+	w.mu.Lock()
+	wasStalled := w.stalled
+	w.head = 0
+	w.advancedAt = time.Time{}
+	w.stalled = false
+	w.mu.Unlock()
+	if wasStalled {
+		GetMetrics().SetUpstreamStalled(false)
+	}
+}
+
+// State 返回当前停滞状态（UnchangedSeconds 按读取时刻计算）
+func (w *UpstreamWatch) State() UpstreamStallState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stateLocked(w.now())
+}
+
+// Stalled 链头是否已判定为上游停滞
+func (w *UpstreamWatch) Stalled() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stalled
+}
+
+// WaitingOnUpstream 链头已停滞且 next 超过链头：索引器已追平，等待的块尚未产生
+func (w *UpstreamWatch) WaitingOnUpstream(next uint64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stalled && next > w.head
+}
+
+func (w *UpstreamWatch) stateLocked(now time.Time) UpstreamStallState {
+	st := UpstreamStallState{
+		Stalled:          w.stalled,
+		ThresholdSeconds: w.threshold.Seconds(),
+		LastAdvanceAt:    w.advancedAt,
+	}
+	if w.advancedAt.IsZero() {
+		return st
+	}
+	st.ChainHead = strconv.FormatUint(w.head, 10)
+	st.UnchangedSeconds = now.Sub(w.advancedAt).Seconds()
+	if w.stalled {
+		st.DetectedAt = w.detectedAt
+	}
+	return st
+}
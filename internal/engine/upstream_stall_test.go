@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpstreamStallThreshold(t *testing.T) {
+	assert.Equal(t, 6*time.Minute, UpstreamStallThreshold(GetChainProfile(1), 0), "30 mainnet blocks")
+	assert.Equal(t, time.Minute, UpstreamStallThreshold(GetChainProfile(10), 0), "fast chains are floored")
+	assert.Zero(t, UpstreamStallThreshold(GetChainProfile(31337), 0), "on-demand mining is not checked by default")
+	assert.Equal(t, 5*time.Minute, UpstreamStallThreshold(GetChainProfile(31337), 5*time.Minute))
+}
+
+func TestUpstreamWatch_FlipsOnlyOnObservation(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	w := NewUpstreamWatch(time.Minute)
+	w.now = func() time.Time { return now }
+
+	var events []UpstreamStallState
+	w.Configure(time.Minute, func(st UpstreamStallState) { events = append(events, st) })
+
+	w.Observe(100)
+	now = now.Add(50 * time.Second)
+	w.Observe(100)
+	assert.False(t, w.Stalled())
+
+	// 超过阈值但没有新的观测（本地 RPC 故障）不判定停滞
+	now = now.Add(time.Hour)
+	assert.False(t, w.Stalled())
+
+	w.Observe(100)
+	require.True(t, w.Stalled())
+	state := w.State()
+	assert.Equal(t, "100", state.ChainHead)
+	assert.Equal(t, 60.0, state.ThresholdSeconds)
+	assert.InDelta(t, 3650, state.UnchangedSeconds, 0.001)
+	assert.Equal(t, now, state.DetectedAt)
+
+	assert.True(t, w.WaitingOnUpstream(101), "caught up and the next block does not exist yet")
+	assert.False(t, w.WaitingOnUpstream(90), "behind the head is an indexer problem")
+
+	w.Observe(100)
+	require.Len(t, events, 1, "the alert fires once per stall")
+	assert.True(t, events[0].Stalled)
+
+	now = now.Add(time.Second)
+	w.Observe(101)
+	assert.False(t, w.Stalled())
+	require.Len(t, events, 2)
+	assert.False(t, events[1].Stalled)
+	assert.True(t, w.State().DetectedAt.IsZero())
+}
+
+func TestUpstreamWatch_DisabledIgnoresObservations(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	w := NewUpstreamWatch(0)
+	w.now = func() time.Time { return now }
+
+	w.Observe(5)
+	now = now.Add(24 * time.Hour)
+	w.Observe(5)
+	assert.False(t, w.Stalled())
+	assert.Empty(t, w.State().ChainHead)
+}
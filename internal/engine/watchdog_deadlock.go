@@ -42,9 +42,9 @@ type DeadlockWatchdog struct {
 
 // RepositoryAdapter 定义看门狗需要的数据库接口
 type RepositoryAdapter interface {
-	UpdateSyncCursor(ctx context.Context, height int64) error
+	UpdateSyncCursor(ctx context.Context, chainID, height int64) error
 	GetMaxStoredBlock(ctx context.Context) (int64, error)
-	GetSyncCursor(ctx context.Context) (int64, error)
+	GetSyncCursor(ctx context.Context, chainID int64) (int64, error)
 }
 
 // NewDeadlockWatchdog 创建新的死锁看门狗实例
@@ -156,14 +156,14 @@ func (dw *DeadlockWatchdog) checkAndHeal(ctx context.Context) error {
 		slog.Duration("threshold", dw.stallThreshold))
 
 	// Step 2: 获取真实状态（不受 Sequencer 影响）
-	rpcHeight, err := dw.rpcPool.GetLatestBlockNumber(ctx)
+	rpcHeight, err := LatestChainHead(ctx, dw.rpcPool)
 	if err != nil {
 		Logger.Warn("DeadlockWatchdog: Failed to get RPC height",
 			slog.String("error", err.Error()))
 		return err
 	}
 
-	dbHeight, err := dw.repo.GetSyncCursor(ctx)
+	dbHeight, err := dw.repo.GetSyncCursor(ctx, dw.chainID)
 	if err != nil {
 		Logger.Warn("DeadlockWatchdog: Failed to get DB cursor",
 			slog.String("error", err.Error()))
@@ -215,7 +215,7 @@ func (dw *DeadlockWatchdog) checkAndHeal(ctx context.Context) error {
 	Logger.Info("🔧 DeadlockWatchdog: Step 1/3: Physical cursor force-insert",
 		slog.Int64("new_cursor", newCursorHeight))
 
-	if err := dw.repo.UpdateSyncCursor(ctx, newCursorHeight); err != nil {
+	if err := dw.repo.UpdateSyncCursor(ctx, dw.chainID, newCursorHeight); err != nil {
 		Logger.Error("❌ DeadlockWatchdog: Step 1 FAILED",
 			slog.String("error", err.Error()))
 		event.Error = "Step 1 failed: " + err.Error()
@@ -256,7 +256,7 @@ func (dw *DeadlockWatchdog) checkAndHeal(ctx context.Context) error {
 			slog.Int64("to", fetchTo.Int64()),
 			slog.Int64("blocks", fetchTo.Int64()-fetchFrom.Int64()+1))
 		go func() {
-			if err := dw.fetcher.Schedule(ctx, fetchFrom, fetchTo); err != nil {
+			if err := dw.fetcher.Reschedule(WithScheduleSource(ctx, ScheduleSourceWatchdog), fetchFrom, fetchTo); err != nil {
 				Logger.Error("❌ DeadlockWatchdog: Gap reschedule failed",
 					slog.String("error", err.Error()))
 			}
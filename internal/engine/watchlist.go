@@ -0,0 +1,200 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"web3-indexer-go/internal/storage"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const defaultWatchlistReload = 30 * time.Second
+
+// 监控地址来源（watched_addresses.source）
+const (
+	WatchSourceEnv = "env" // WATCHED_TOKEN_ADDRESSES 初始值
+	WatchSourceAPI = "api" // 运行时通过管理接口加入
+)
+
+// WatchlistStore 监控地址持久化（*storage.Postgres 实现）
+type WatchlistStore interface {
+	SeedWatchedAddresses(ctx context.Context, addresses []string, source string) (int64, error)
+	ListWatchedAddresses(ctx context.Context) ([]storage.WatchedAddressRow, error)
+	AddWatchedAddress(ctx context.Context, row storage.WatchedAddressRow, actor string) (bool, error)
+	RemoveWatchedAddress(ctx context.Context, address, actor string) (bool, error)
+	ListWatchlistAudit(ctx context.Context, limit int) ([]storage.WatchlistAuditRow, error)
+}
+
+// ErrAddressChecksum 混合大小写的地址与 EIP-55 校验和不符（多半是抄错了某一位）
+var ErrAddressChecksum = errors.New("address checksum mismatch")
+
+// ParseWatchAddress 校验监控地址：须为 20 字节十六进制；混合大小写时须符合 EIP-55 校验和，
+// 全小写 / 全大写视为未带校验和
+func ParseWatchAddress(s string) (common.Address, error) {
+	if !common.IsHexAddress(s) {
+		return common.Address{}, errors.New("not a 20-byte hex address")
+	}
+	addr := common.HexToAddress(s)
+	hex := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if hex != strings.ToLower(hex) && hex != strings.ToUpper(hex) && "0x"+hex != addr.Hex() {
+		return common.Address{}, ErrAddressChecksum
+	}
+	return addr, nil
+}
+
+// WatchTarget 接收监控地址全集的组件（Fetcher 按地址过滤 eth_getLogs，Processor 维护监控集合）
+type WatchTarget interface {
+	SetWatchedAddresses(addresses []string)
+}
+
+// Watchlist 以 watched_addresses 表为准的监控地址列表。
+// 启动时加载并下发给 Fetcher / Processor；Add / Remove 写库后立即下发，
+// Run 定期重读以接收其他途径（如直接改表）的修改，均无需重启
+type Watchlist struct {
+	store    WatchlistStore
+	targets  []WatchTarget
+	interval time.Duration
+	seed     []string
+
+	mu      sync.Mutex // 串行化读库与下发，避免并发重读时旧列表覆盖新列表
+	entries []storage.WatchedAddressRow
+	applied bool
+}
+
+// NewWatchlist 创建监控列表；interval <= 0 取默认值
+func NewWatchlist(store WatchlistStore, interval time.Duration, targets ...WatchTarget) *Watchlist {
+	if interval <= 0 {
+		interval = defaultWatchlistReload
+	}
+	return &Watchlist{store: store, targets: targets, interval: interval}
+}
+
+// Load 启动时调用：表为空时以 seed（环境变量）初始化，之后加载并下发。
+// 表中已有记录时忽略 seed，已通过接口删除的地址不会在重启后复活；
+// 失败时 seed 先直接下发，Run 会重试加载
+func (w *Watchlist) Load(ctx context.Context, seed []string) error {
+	w.seed = seed
+	if err := w.load(ctx); err != nil {
+		for _, target := range w.targets {
+			target.SetWatchedAddresses(seed)
+		}
+		return err
+	}
+	return nil
+}
+
+func (w *Watchlist) load(ctx context.Context) error {
+	seeded, err := w.store.SeedWatchedAddresses(ctx, w.seed, WatchSourceEnv)
+	if err != nil {
+		return err
+	}
+	if seeded > 0 {
+		Logger.Info("👀 watchlist_seeded_from_env", slog.Int64("addresses", seeded))
+	}
+	return w.Reload(ctx)
+}
+
+// Reload 从库中重读监控地址；集合有变化时下发给各组件
+func (w *Watchlist) Reload(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	rows, err := w.store.ListWatchedAddresses(ctx)
+	if err != nil {
+		return err
+	}
+	prev := watchedAddressList(w.entries)
+	next := watchedAddressList(rows)
+	w.entries = rows
+	if w.applied && slices.Equal(prev, next) {
+		return nil
+	}
+	w.applied = true
+	for _, target := range w.targets {
+		target.SetWatchedAddresses(next)
+	}
+	GetMetrics().SetWatchedAddresses(len(next))
+	Logger.Info("👀 watchlist_applied", slog.Int("addresses", len(next)), slog.Int("previous", len(prev)))
+	return nil
+}
+
+// Add 加入监控地址并立即生效，actor 记入审计；地址已存在时返回 false
+func (w *Watchlist) Add(ctx context.Context, addr common.Address, label, actor string) (bool, error) {
+	address := strings.ToLower(addr.Hex())
+	added, err := w.store.AddWatchedAddress(ctx, storage.WatchedAddressRow{
+		Address: address,
+		Label:   label,
+		Source:  WatchSourceAPI,
+	}, actor)
+	if err != nil || !added {
+		return added, err
+	}
+	Logger.Info("👀 watchlist_address_added", slog.String("address", address), slog.String("label", label), slog.String("actor", actor))
+	return true, w.Reload(ctx)
+}
+
+// Remove 移除监控地址并立即生效，actor 记入审计；地址不存在时返回 false。
+// 移除最后一个地址后 Fetcher 回到不按地址过滤的全量抓取
+func (w *Watchlist) Remove(ctx context.Context, addr common.Address, actor string) (bool, error) {
+	address := strings.ToLower(addr.Hex())
+	removed, err := w.store.RemoveWatchedAddress(ctx, address, actor)
+	if err != nil || !removed {
+		return removed, err
+	}
+	Logger.Info("👀 watchlist_address_removed", slog.String("address", address), slog.String("actor", actor))
+	return true, w.Reload(ctx)
+}
+
+// Audit 最近 limit 条变更记录（新的在前）
+func (w *Watchlist) Audit(ctx context.Context, limit int) ([]storage.WatchlistAuditRow, error) {
+	return w.store.ListWatchlistAudit(ctx, limit)
+}
+
+// List 返回当前生效的监控地址
+func (w *Watchlist) List() []storage.WatchedAddressRow {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return slices.Clone(w.entries)
+}
+
+// Run 按间隔重读监控地址直到 ctx 结束
+func (w *Watchlist) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.refresh(ctx); err != nil && ctx.Err() == nil {
+				Logger.Warn("watchlist_reload_failed", "err", err)
+			}
+		}
+	}
+}
+
+// refresh 启动加载未成功时重试完整加载（含初始化），否则重读
+func (w *Watchlist) refresh(ctx context.Context) error {
+	w.mu.Lock()
+	applied := w.applied
+	w.mu.Unlock()
+	if !applied {
+		return w.load(ctx)
+	}
+	return w.Reload(ctx)
+}
+
+// watchedAddressList 排序后的小写地址列表（用于比较与下发）
+func watchedAddressList(rows []storage.WatchedAddressRow) []string {
+	addrs := make([]string, len(rows))
+	for i, row := range rows {
+		addrs[i] = strings.ToLower(row.Address)
+	}
+	slices.Sort(addrs)
+	return addrs
+}
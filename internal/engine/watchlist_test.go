@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"web3-indexer-go/internal/storage"
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWatchlistStore struct {
+	rows  []storage.WatchedAddressRow
+	audit []storage.WatchlistAuditRow
+	down  bool
+}
+
+func (s *fakeWatchlistStore) SeedWatchedAddresses(_ context.Context, addresses []string, source string) (int64, error) {
+	if s.down {
+		return 0, errors.New("connection refused")
+	}
+	if len(s.rows) > 0 {
+		return 0, nil
+	}
+	for _, addr := range addresses {
+		s.rows = append(s.rows, storage.WatchedAddressRow{Address: strings.ToLower(addr), Source: source})
+	}
+	return int64(len(addresses)), nil
+}
+
+func (s *fakeWatchlistStore) ListWatchedAddresses(context.Context) ([]storage.WatchedAddressRow, error) {
+	if s.down {
+		return nil, errors.New("connection refused")
+	}
+	return append([]storage.WatchedAddressRow(nil), s.rows...), nil
+}
+
+func (s *fakeWatchlistStore) AddWatchedAddress(_ context.Context, row storage.WatchedAddressRow, actor string) (bool, error) {
+	for _, r := range s.rows {
+		if r.Address == row.Address {
+			return false, nil
+		}
+	}
+	s.rows = append(s.rows, row)
+	s.audit = append(s.audit, storage.WatchlistAuditRow{Action: "add", Address: row.Address, Label: row.Label, Actor: actor})
+	return true, nil
+}
+
+func (s *fakeWatchlistStore) RemoveWatchedAddress(_ context.Context, address, actor string) (bool, error) {
+	for i, r := range s.rows {
+		if r.Address == address {
+			s.rows = append(s.rows[:i], s.rows[i+1:]...)
+			s.audit = append(s.audit, storage.WatchlistAuditRow{Action: "remove", Address: address, Label: r.Label, Actor: actor})
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *fakeWatchlistStore) ListWatchlistAudit(context.Context, int) ([]storage.WatchlistAuditRow, error) {
+	return s.audit, nil
+}
+
+func TestWatchlist_PropagatesChanges(t *testing.T) {
+	ctx := context.Background()
+	tokenA, tokenB := testkit.Address("token", 1), testkit.Address("token", 2)
+	store := &fakeWatchlistStore{}
+	fetcher, processor := &Fetcher{}, &Processor{}
+	w := NewWatchlist(store, 0, fetcher, processor)
+
+	require.NoError(t, w.Load(ctx, []string{tokenA.Hex()}))
+	assert.Equal(t, []common.Address{tokenA}, fetcher.watched())
+	assert.True(t, processor.IsWatched(tokenA))
+	assert.Equal(t, WatchSourceEnv, w.List()[0].Source)
+
+	added, err := w.Add(ctx, tokenB, "usdc", "10.0.0.1:5000")
+	require.NoError(t, err)
+	assert.True(t, added)
+	assert.ElementsMatch(t, []common.Address{tokenA, tokenB}, fetcher.watched())
+	added, err = w.Add(ctx, tokenB, "", "10.0.0.1:5000")
+	require.NoError(t, err)
+	assert.False(t, added, "already watched")
+
+	removed, err := w.Remove(ctx, tokenA, "10.0.0.2:6000")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	assert.Equal(t, []common.Address{tokenB}, fetcher.watched())
+	assert.False(t, processor.IsWatched(tokenA))
+	removed, err = w.Remove(ctx, tokenA, "10.0.0.2:6000")
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	audit, err := w.Audit(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, audit, 2, "one entry per effective change")
+	assert.Equal(t, []string{"add", "remove"}, []string{audit[0].Action, audit[1].Action})
+	assert.Equal(t, "usdc", audit[0].Label)
+	assert.Equal(t, "10.0.0.2:6000", audit[1].Actor)
+
+	// 直接改表：下一次重读生效
+	store.rows = nil
+	require.NoError(t, w.refresh(ctx))
+	assert.Empty(t, fetcher.watched(), "empty watchlist disables the address filter")
+
+	// 重启：表中已有记录时忽略环境变量
+	store.rows = []storage.WatchedAddressRow{{Address: strings.ToLower(tokenB.Hex()), Source: WatchSourceAPI}}
+	restarted := NewWatchlist(store, 0, fetcher)
+	require.NoError(t, restarted.Load(ctx, []string{tokenA.Hex()}))
+	assert.Equal(t, []common.Address{tokenB}, fetcher.watched())
+}
+
+func TestWatchlist_LoadFailureFallsBackToSeed(t *testing.T) {
+	ctx := context.Background()
+	token := testkit.Address("token", 1)
+	store := &fakeWatchlistStore{down: true}
+	fetcher := &Fetcher{}
+	w := NewWatchlist(store, 0, fetcher)
+
+	require.Error(t, w.Load(ctx, []string{token.Hex()}))
+	assert.Equal(t, []common.Address{token}, fetcher.watched(), "env list applies while the table is unreachable")
+
+	store.down = false
+	require.NoError(t, w.refresh(ctx))
+	assert.Len(t, w.List(), 1, "retry seeds the table")
+	assert.Equal(t, []common.Address{token}, fetcher.watched())
+}
+
+func TestParseWatchAddress(t *testing.T) {
+	const checksummed = "0x5FC8d32690cc91D4c39d9d3abcBD16989F875707"
+	for _, s := range []string{checksummed, strings.ToLower(checksummed), "0x" + strings.ToUpper(checksummed[2:])} {
+		addr, err := ParseWatchAddress(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, checksummed, addr.Hex())
+	}
+
+	_, err := ParseWatchAddress("0x5fC8d32690cc91D4c39d9d3abcBD16989F875707")
+	assert.ErrorIs(t, err, ErrAddressChecksum)
+	for _, s := range []string{"", "0x1234", "0xZZC8d32690cc91D4c39d9d3abcBD16989F875707"} {
+		_, err := ParseWatchAddress(s)
+		assert.Error(t, err, s)
+	}
+}
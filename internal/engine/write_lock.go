@@ -0,0 +1,222 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// writeLockNamespace advisory 锁两段式 key 的第一段（"W3IX"），第二段为 chain_id
+const writeLockNamespace int64 = 0x57334958
+
+// ErrWriteLockHeld 写锁由其他实例持有，本实例不得写入
+var ErrWriteLockHeld = errors.New("write lock held by another indexer instance")
+
+var (
+	writeIsolation  atomic.Int64 // sql.IsolationLevel
+	activeWriteLock atomic.Pointer[WriteLock]
+)
+
+func init() {
+	writeIsolation.Store(int64(sql.LevelReadCommitted))
+}
+
+// ParseIsolationLevel 解析 DB_WRITE_ISOLATION（read_committed / repeatable_read / serializable）
+func ParseIsolationLevel(s string) (sql.IsolationLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(strings.ReplaceAll(s, " ", "_"))) {
+	case "", "read_committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable_read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	default:
+		return sql.LevelDefault, fmt.Errorf("unsupported isolation level %q", s)
+	}
+}
+
+// SetWriteIsolation 设置 AsyncWriter 批次与重组回滚事务的隔离级别
+func SetWriteIsolation(level sql.IsolationLevel) {
+	writeIsolation.Store(int64(level))
+}
+
+// writeTxOptions 写事务选项（隔离级别来自 SetWriteIsolation）
+func writeTxOptions() *sql.TxOptions {
+	return &sql.TxOptions{Isolation: sql.IsolationLevel(writeIsolation.Load())}
+}
+
+// SetWriteLock 安装进程级写锁（nil 关闭单写者保护）
+func SetWriteLock(l *WriteLock) {
+	activeWriteLock.Store(l)
+}
+
+// ActiveWriteLock 返回当前写锁（未启用为 nil）
+func ActiveWriteLock() *WriteLock {
+	return activeWriteLock.Load()
+}
+
+// WriteLockHolder 写锁持有者（pg_locks ⨝ pg_stat_activity）
+type WriteLockHolder struct {
+	PID         int       `json:"pid" db:"pid"`
+	Application string    `json:"application" db:"application_name"`
+	ClientAddr  string    `json:"client_addr,omitempty" db:"client_addr"`
+	Since       time.Time `json:"since" db:"backend_start"`
+	Self        bool      `json:"self" db:"-"` // 是否为本实例
+}
+
+// WriteLock 基于 Postgres 会话级 advisory 锁的单写者租约。
+// 专用连接持有锁，进程退出或连接断开时由服务端自动释放；
+// 每个写事务提交前在事务内校验锁仍由该连接持有，误启动的第二个实例无法交错写入破坏哈希链
+type WriteLock struct {
+	db       *sqlx.DB
+	key      int64
+	identity string
+
+	mu     sync.Mutex
+	conn   *sql.Conn
+	pid    int
+	warned bool
+}
+
+// NewWriteLock 创建按 chain_id 划分的写锁；identity 写入持锁连接的 application_name 供状态页展示
+func NewWriteLock(db *sqlx.DB, chainID int64) *WriteLock {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return &WriteLock{
+		db:       db,
+		key:      writeLockKey(chainID),
+		identity: fmt.Sprintf("web3-indexer@%s:%d", host, os.Getpid()),
+	}
+}
+
+// writeLockKey 把 chain_id 折叠进 advisory 锁第二段 key 的 int4 范围
+func writeLockKey(chainID int64) int64 {
+	return chainID & math.MaxInt32
+}
+
+// Acquire 非阻塞获取写锁；已持有时确认持锁连接仍存活，断开则重新竞争
+func (l *WriteLock) Acquire(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		if err := l.conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+		slog.Warn("🔒 [WriteLock] Lock connection lost, re-acquiring", "pid", l.pid)
+		_ = l.conn.Close()
+		l.conn, l.pid = nil, 0
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1::int, $2::int)", writeLockNamespace, l.key).Scan(&ok); err != nil {
+		_ = conn.Close()
+		return false, err
+	}
+	if !ok {
+		_ = conn.Close()
+		if !l.warned {
+			l.warned = true
+			slog.Error("🔒 [WriteLock] Another instance holds the write lock; writes are suspended", "key", l.key)
+		}
+		return false, nil
+	}
+	var pid int
+	if err := conn.QueryRowContext(ctx, "SELECT pg_backend_pid()").Scan(&pid); err != nil {
+		_ = conn.Close()
+		return false, err
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT set_config('application_name', $1, false)", l.identity); err != nil {
+		slog.Debug("🔒 [WriteLock] Failed to tag lock connection", "err", err)
+	}
+	l.conn, l.pid, l.warned = conn, pid, false
+	slog.Info("🔒 [WriteLock] Acquired", "key", l.key, "pid", pid, "identity", l.identity)
+	return true, nil
+}
+
+// Verify 在写事务内确认锁仍由本实例的持锁连接持有（持锁连接被服务端终止后他人可能已接管）
+func (l *WriteLock) Verify(ctx context.Context, tx sqlx.QueryerContext) error {
+	l.mu.Lock()
+	pid := l.pid
+	l.mu.Unlock()
+	if pid == 0 {
+		return ErrWriteLockHeld
+	}
+	var held bool
+	err := sqlx.GetContext(ctx, tx, &held, `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_locks
+			WHERE locktype = 'advisory' AND classid = $1::int8::oid AND objid = $2::int8::oid
+				AND objsubid = 2 AND pid = $3 AND granted
+		)`, writeLockNamespace, l.key, pid)
+	if err != nil {
+		return err
+	}
+	if !held {
+		return ErrWriteLockHeld
+	}
+	return nil
+}
+
+// Guard 写事务入口：获取（或确认）写锁并在事务内校验，失败时调用方应回滚
+func (l *WriteLock) Guard(ctx context.Context, tx sqlx.QueryerContext) error {
+	ok, err := l.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire write lock: %w", err)
+	}
+	if !ok {
+		return ErrWriteLockHeld
+	}
+	return l.Verify(ctx, tx)
+}
+
+// Holder 查询当前持锁会话（无人持有返回 nil）
+func (l *WriteLock) Holder(ctx context.Context) (*WriteLockHolder, error) {
+	var holders []WriteLockHolder
+	err := sqlx.SelectContext(ctx, l.db, &holders, `
+		SELECT a.pid, COALESCE(a.application_name, '') AS application_name,
+			COALESCE(host(a.client_addr), '') AS client_addr, a.backend_start
+		FROM pg_locks lk JOIN pg_stat_activity a ON a.pid = lk.pid
+		WHERE lk.locktype = 'advisory' AND lk.classid = $1::int8::oid AND lk.objid = $2::int8::oid
+			AND lk.objsubid = 2 AND lk.granted
+		LIMIT 1`, writeLockNamespace, l.key)
+	if err != nil || len(holders) == 0 {
+		return nil, err
+	}
+	l.mu.Lock()
+	holders[0].Self = holders[0].PID == l.pid
+	l.mu.Unlock()
+	return &holders[0], nil
+}
+
+// Release 释放写锁并归还持锁连接
+func (l *WriteLock) Release(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return nil
+	}
+	_, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1::int, $2::int)", writeLockNamespace, l.key)
+	if _, resetErr := l.conn.ExecContext(ctx, "RESET application_name"); resetErr != nil && err == nil {
+		err = resetErr
+	}
+	_ = l.conn.Close()
+	l.conn, l.pid = nil, 0
+	return err
+}
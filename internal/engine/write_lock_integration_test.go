@@ -0,0 +1,47 @@
+//go:build integration
+
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWriteLock_SecondInstanceRejected 第二个实例无法获取同一 chain_id 的写锁，持锁方释放后可接管
+func TestWriteLock_SecondInstanceRejected(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	primary := NewWriteLock(db, 424242)
+	standby := NewWriteLock(db, 424242)
+
+	ok, err := primary.Acquire(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = standby.Acquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	tx, err := db.BeginTxx(ctx, writeTxOptions())
+	require.NoError(t, err)
+	assert.NoError(t, primary.Guard(ctx, tx))
+	assert.ErrorIs(t, standby.Guard(ctx, tx), ErrWriteLockHeld)
+	require.NoError(t, tx.Rollback())
+
+	holder, err := standby.Holder(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, holder)
+	assert.False(t, holder.Self)
+	assert.Contains(t, holder.Application, "web3-indexer@")
+
+	require.NoError(t, primary.Release(ctx))
+	ok, err = standby.Acquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.NoError(t, standby.Release(ctx))
+}
@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIsolationLevel(t *testing.T) {
+	cases := map[string]sql.IsolationLevel{
+		"":                sql.LevelReadCommitted,
+		"read_committed":  sql.LevelReadCommitted,
+		"Repeatable Read": sql.LevelRepeatableRead,
+		"SERIALIZABLE":    sql.LevelSerializable,
+	}
+	for in, want := range cases {
+		got, err := ParseIsolationLevel(in)
+		require.NoErrorf(t, err, "input %q", in)
+		assert.Equalf(t, want, got, "input %q", in)
+	}
+
+	_, err := ParseIsolationLevel("read_uncommitted")
+	assert.Error(t, err)
+}
+
+func TestWriteTxOptions_FollowsIsolation(t *testing.T) {
+	defer SetWriteIsolation(sql.LevelReadCommitted)
+
+	SetWriteIsolation(sql.LevelSerializable)
+	assert.Equal(t, sql.LevelSerializable, writeTxOptions().Isolation)
+}
+
+func TestWriteLockKey_FitsInt4(t *testing.T) {
+	assert.EqualValues(t, 11155111, writeLockKey(11155111))
+	assert.LessOrEqual(t, writeLockKey(math.MaxInt64), int64(math.MaxInt32))
+	assert.GreaterOrEqual(t, writeLockKey(-1), int64(0))
+}
@@ -3,26 +3,41 @@ package engine
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"math"
 	"math/big"
 	mrand "math/rand"
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
-// WSSListener 通过 WebSocket 实时监听新块
+// headSubscriber eth_subscribe("newHeads") 的最小接口（*ethclient.Client 实现）
+type headSubscriber interface {
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+	Close()
+}
+
+func dialHeadSubscriber(ctx context.Context, url string) (headSubscriber, error) {
+	return ethclient.DialContext(ctx, url)
+}
+
+// WSSListener 通过 WebSocket 订阅 newHeads 实时监听新块。
+// 连接在 Start 后的监听循环里建立，断线后按指数退避重新拨号；每次断线向 Dropped 发一个信号，
+// 跟踪方据此立即回落到 HTTP 轮询，不必等下一个兜底周期
 type WSSListener struct {
 	newBlocks chan *big.Int
+	dropped   chan struct{}
 	stopCh    chan struct{}
 	stopOnce  sync.Once
 	mu        sync.RWMutex
 	wssURL    string
-	client    *ethclient.Client
+	client    headSubscriber
 	connected bool
+	dial      func(ctx context.Context, url string) (headSubscriber, error)
 
 	// 重连状态管理
 	reconnectCount int           // 当前重连次数
@@ -31,23 +46,18 @@ type WSSListener struct {
 	maxBackoff     time.Duration // 最大退避时间（60s）
 }
 
-// NewWSSListener 创建 WSS 监听器
+// NewWSSListener 创建 WSS 监听器（不立即拨号，首次连接失败同样走退避重连）
 func NewWSSListener(wssURL string) (*WSSListener, error) {
 	if wssURL == "" {
 		return nil, fmt.Errorf("WSS URL is required")
 	}
 
-	client, err := ethclient.Dial(wssURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to WSS: %w", err)
-	}
-
 	return &WSSListener{
 		wssURL:        wssURL,
-		client:        client,
 		newBlocks:     make(chan *big.Int, 10),
+		dropped:       make(chan struct{}, 1),
 		stopCh:        make(chan struct{}),
-		connected:     true,
+		dial:          dialHeadSubscriber,
 		maxReconnects: 0, // 默认无限重试
 		baseBackoff:   1 * time.Second,
 		maxBackoff:    60 * time.Second,
@@ -77,78 +87,130 @@ func (w *WSSListener) calculateBackoff() time.Duration {
 
 // listenNewHeads 监听新块头（带指数退避重连）
 func (w *WSSListener) listenNewHeads(ctx context.Context) {
+	defer w.closeClient()
 	for {
 		// 检查是否超过最大重连次数
 		if w.maxReconnects > 0 && w.reconnectCount >= w.maxReconnects {
-			log.Printf("❌ WSS max reconnections (%d) exceeded, giving up", w.maxReconnects)
+			Logger.Error("❌ WSS max reconnections exceeded, giving up", slog.Int("max", w.maxReconnects))
 			w.setConnected(false)
 			return
 		}
 
-		headers := make(chan *types.Header)
-		sub, err := w.client.SubscribeNewHead(ctx, headers)
+		sub, headers, err := w.subscribe(ctx)
 		if err != nil {
-			log.Printf("❌ WSS subscription failed: %v", err)
-			w.handleReconnect(ctx)
+			Logger.Warn("❌ WSS subscription failed", slog.String("url", w.wssURL), slog.String("error", err.Error()))
+			w.closeClient()
+			if !w.backoff(ctx) {
+				return
+			}
 			continue
 		}
 
-		log.Printf("✅ WSS listener connected to %s (attempt %d)", w.wssURL, w.reconnectCount+1)
+		Logger.Info("✅ WSS listener connected", slog.String("url", w.wssURL), slog.Int("attempt", w.reconnectCount+1))
 		w.setConnected(true)
 		w.reconnectCount = 0 // 成功连接后重置计数器
 
-		for {
-			select {
-			case <-ctx.Done():
-				sub.Unsubscribe()
-				return
-			case <-w.stopCh:
-				sub.Unsubscribe()
-				return
-			case header := <-headers:
-				if header != nil {
-					select {
-					case w.newBlocks <- header.Number:
-						log.Printf("📦 New block detected via WSS: %s", header.Number.String())
-					case <-w.stopCh:
-						sub.Unsubscribe()
-						return
-					}
-				}
-			case err := <-sub.Err():
-				log.Printf("⚠️ WSS subscription error: %v", err)
-				w.setConnected(false)
-				// 退出内层循环，触发重连
-				sub.Unsubscribe()
-				goto reconnect
-			}
+		if !w.consume(ctx, sub, headers) {
+			return
+		}
+		w.closeClient()
+		if !w.backoff(ctx) {
+			return
 		}
+	}
+}
 
-	reconnect:
-		// 计算退避时间
-		backoff := w.calculateBackoff()
-		log.Printf("🔄 Reconnecting to WSS in %v (attempt %d)", backoff, w.reconnectCount+1)
+// subscribe 必要时拨号，然后订阅 newHeads
+func (w *WSSListener) subscribe(ctx context.Context) (ethereum.Subscription, chan *types.Header, error) {
+	w.mu.Lock()
+	client := w.client
+	w.mu.Unlock()
+	if client == nil {
+		c, err := w.dial(ctx, w.wssURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		w.mu.Lock()
+		w.client = c
+		w.mu.Unlock()
+		client = c
+	}
+	headers := make(chan *types.Header, 16)
+	sub, err := client.SubscribeNewHead(ctx, headers)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sub, headers, nil
+}
 
+// consume 转发块头直到订阅出错（返回 true，需重连）或监听结束（返回 false）
+func (w *WSSListener) consume(ctx context.Context, sub ethereum.Subscription, headers <-chan *types.Header) bool {
+	defer sub.Unsubscribe()
+	for {
 		select {
 		case <-ctx.Done():
+			return false
+		case <-w.stopCh:
+			return false
+		case header := <-headers:
+			if header != nil && header.Number != nil {
+				w.publish(header.Number)
+			}
+		case err := <-sub.Err():
+			msg := "closed"
+			if err != nil {
+				msg = err.Error()
+			}
+			Logger.Warn("⚠️ WSS subscription dropped, falling back to polling", slog.String("error", msg))
+			w.setConnected(false)
+			select {
+			case w.dropped <- struct{}{}:
+			default:
+			}
+			return true
+		}
+	}
+}
+
+// publish 非阻塞投递新块号；消费方落后时丢弃最旧的一条（只有最新链头有意义），不阻塞订阅读取
+func (w *WSSListener) publish(n *big.Int) {
+	for {
+		select {
+		case w.newBlocks <- new(big.Int).Set(n):
 			return
-		case <-time.After(backoff):
-			w.reconnectCount++
-			// 继续外层循环进行重连
+		default:
+		}
+		select {
+		case <-w.newBlocks:
+		default:
 		}
 	}
 }
 
-// handleReconnect 处理重连前的准备
-func (w *WSSListener) handleReconnect(ctx context.Context) {
+// backoff 等待退避时间；ctx 结束或 Stop 时返回 false
+func (w *WSSListener) backoff(ctx context.Context) bool {
 	backoff := w.calculateBackoff()
-	log.Printf("🔄 Reconnecting to WSS in %v (attempt %d)", backoff, w.reconnectCount+1)
+	Logger.Info("🔄 Reconnecting to WSS", slog.Duration("in", backoff), slog.Int("attempt", w.reconnectCount+1))
 
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
 	select {
 	case <-ctx.Done():
-		return
-	case <-time.After(backoff):
+		return false
+	case <-w.stopCh:
+		return false
+	case <-timer.C:
 		w.reconnectCount++
+		return true
+	}
+}
+
+func (w *WSSListener) closeClient() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.client != nil {
+		w.client.Close()
+		w.client = nil
 	}
 }
 
@@ -157,6 +219,11 @@ func (w *WSSListener) GetNewBlocks() <-chan *big.Int {
 	return w.newBlocks
 }
 
+// Dropped 订阅断开信号（缓冲 1，连续断线合并为一个）
+func (w *WSSListener) Dropped() <-chan struct{} {
+	return w.dropped
+}
+
 // IsConnected 检查是否连接
 func (w *WSSListener) IsConnected() bool {
 	w.mu.RLock()
@@ -167,16 +234,15 @@ func (w *WSSListener) IsConnected() bool {
 // setConnected 设置连接状态
 func (w *WSSListener) setConnected(connected bool) {
 	w.mu.Lock()
-	defer w.mu.Unlock()
 	w.connected = connected
+	w.mu.Unlock()
+	GetMetrics().SetHeadSubscriptionUp(connected)
 }
 
 // Stop 停止监听
 func (w *WSSListener) Stop() {
 	w.stopOnce.Do(func() {
 		close(w.stopCh)
-		if w.client != nil {
-			w.client.Close()
-		}
+		w.closeClient()
 	})
 }
@@ -0,0 +1,48 @@
+package models
+
+// EventSchemaVersion 推送事件（WS / Sink）载荷的结构版本。
+// 只新增可选字段时保持不变；删除、改名或改变字段类型时递增，消费者据此判断是否需要升级解析逻辑。
+// v2：区块高度统一为数字（block_number），Sink 中的区块 / 转账改用 snake_case 字段并以字符串输出金额（见 json.go）
+const EventSchemaVersion = 2
+
+// BlockEvent "block" 事件载荷（Processor → EventBus → WS Hub）
+type BlockEvent struct {
+	Number         uint64  `json:"number"`
+	Hash           string  `json:"hash"`
+	ParentHash     string  `json:"parent_hash"`
+	Timestamp      uint64  `json:"timestamp"`
+	TxCount        int     `json:"tx_count"`
+	LatencyMs      int64   `json:"latency_ms"`
+	LatencyDisplay string  `json:"latency_display"`
+	LatestChain    int64   `json:"latest_chain"`
+	SyncLag        int64   `json:"sync_lag"`
+	TPS            float64 `json:"tps"`
+}
+
+// NFTTransferEvent "nft_transfer" 事件载荷（ERC-721）；token_id 以十进制字符串传递
+type NFTTransferEvent struct {
+	TxHash      string `json:"tx_hash"`
+	BlockNumber Height `json:"block_number"`
+	LogIndex    uint   `json:"log_index"`
+	Collection  string `json:"collection"`
+	Symbol      string `json:"symbol"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	TokenID     string `json:"token_id"`
+}
+
+// TransferEvent "transfer" 事件载荷；金额以字符串传递避免 JS 精度丢失
+type TransferEvent struct {
+	TxHash           string   `json:"tx_hash"`
+	From             string   `json:"from"`
+	To               string   `json:"to"`
+	Value            string   `json:"value"` // 原始整数金额
+	BlockNumber      Height   `json:"block_number"`
+	TokenAddress     string   `json:"token_address"`
+	Symbol           string   `json:"symbol"`
+	Type             string   `json:"type"`
+	LogIndex         uint     `json:"log_index"`
+	Decimals         uint8    `json:"decimals"`
+	AmountNormalized string   `json:"amount_normalized"`
+	AmountUSD        *float64 `json:"amount_usd,omitempty"` // 无报价的代币不输出
+}
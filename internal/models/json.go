@@ -0,0 +1,184 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/holiman/uint256"
+)
+
+// JSON 编码约定（REST / WS / Sink 共用）：
+//   - 金额、wei 等任意精度整数（Uint256、BigInt）编码为十进制字符串，避免 JS Number（53 位）丢失精度；
+//   - 区块高度（Height）编码为数字：uint64 高度远小于 2^53，前端可直接比较与排序。
+// 解码时两种类型都同时接受字符串与数字，兼容旧版载荷
+
+// MarshalJSON 十进制字符串；零值（nil）编码为 "0"
+func (u Uint256) MarshalJSON() ([]byte, error) {
+	return strconv.AppendQuote(nil, u.String()), nil
+}
+
+// UnmarshalJSON 接受十进制 / 0x 十六进制字符串或十进制数字；null 保持原值
+func (u *Uint256) UnmarshalJSON(data []byte) error {
+	s, ok, err := jsonIntegerText(data)
+	if err != nil || !ok {
+		return wrapJSONErr("uint256", err)
+	}
+	v := new(uint256.Int)
+	if len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		err = v.SetFromHex(s)
+	} else {
+		err = v.SetFromDecimal(s)
+	}
+	if err != nil {
+		return fmt.Errorf("uint256: invalid value %q: %w", s, err)
+	}
+	u.Int = v
+	return nil
+}
+
+// MarshalJSON 十进制字符串；零值（nil）编码为 "0"
+func (b BigInt) MarshalJSON() ([]byte, error) {
+	if b.Int == nil {
+		return []byte(`"0"`), nil
+	}
+	return strconv.AppendQuote(nil, b.Int.String()), nil
+}
+
+// UnmarshalJSON 接受十进制 / 0x 十六进制字符串或十进制数字；null 保持原值
+func (b *BigInt) UnmarshalJSON(data []byte) error {
+	s, ok, err := jsonIntegerText(data)
+	if err != nil || !ok {
+		return wrapJSONErr("bigint", err)
+	}
+	return b.scanString(s)
+}
+
+// Height 区块高度：JSON 编码为数字，数据库读写为 NUMERIC
+type Height uint64
+
+// NewHeight 从 *big.Int 构造高度（nil 或负数为 0）
+func NewHeight(n *big.Int) Height {
+	if n == nil || n.Sign() < 0 {
+		return 0
+	}
+	return Height(n.Uint64())
+}
+
+// String 十进制字符串
+func (h Height) String() string {
+	return strconv.FormatUint(uint64(h), 10)
+}
+
+// UnmarshalJSON 接受数字或十进制字符串（旧版载荷的高度为字符串）；null 保持原值
+func (h *Height) UnmarshalJSON(data []byte) error {
+	s, ok, err := jsonIntegerText(data)
+	if err != nil || !ok {
+		return wrapJSONErr("height", err)
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("height: invalid value %q: %w", s, err)
+	}
+	*h = Height(v)
+	return nil
+}
+
+// Value 实现 driver.Valuer（以十进制字符串写入 NUMERIC）
+func (h Height) Value() (driver.Value, error) {
+	return h.String(), nil
+}
+
+// Scan 实现 sql.Scanner：NUMERIC 可能以字符串（含 "123.000"）、整数或浮点返回
+func (h *Height) Scan(value interface{}) error {
+	var b BigInt
+	if err := b.Scan(value); err != nil {
+		return err
+	}
+	if b.Sign() < 0 || !b.IsUint64() {
+		return fmt.Errorf("height %s out of range", b.String())
+	}
+	*h = Height(b.Uint64())
+	return nil
+}
+
+// MarshalJSON 规范载荷：高度为数字，base fee 为十进制字符串
+func (b Block) MarshalJSON() ([]byte, error) {
+	type plain Block
+	return json.Marshal(struct {
+		plain
+		Number Height `json:"number"`
+	}{plain(b), NewHeight(b.Number.Int)})
+}
+
+// UnmarshalJSON 解析 MarshalJSON 的输出（高度也接受字符串）
+func (b *Block) UnmarshalJSON(data []byte) error {
+	type plain Block
+	var w struct {
+		plain
+		Number Height `json:"number"`
+	}
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	*b = Block(w.plain)
+	b.Number = BigInt{new(big.Int).SetUint64(uint64(w.Number))}
+	return nil
+}
+
+// MarshalJSON 规范载荷：高度为数字，金额为十进制字符串
+func (t Transfer) MarshalJSON() ([]byte, error) {
+	type plain Transfer
+	return json.Marshal(struct {
+		plain
+		BlockNumber Height `json:"block_number"`
+	}{plain(t), NewHeight(t.BlockNumber.Int)})
+}
+
+// UnmarshalJSON 解析 MarshalJSON 的输出（高度也接受字符串）
+func (t *Transfer) UnmarshalJSON(data []byte) error {
+	type plain Transfer
+	var w struct {
+		plain
+		BlockNumber Height `json:"block_number"`
+	}
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	*t = Transfer(w.plain)
+	t.BlockNumber = BigInt{new(big.Int).SetUint64(uint64(w.BlockNumber))}
+	return nil
+}
+
+// jsonIntegerText 取出 JSON 整数的文本（去掉字符串引号）；null 返回 ok=false
+func jsonIntegerText(data []byte) (string, bool, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return "", false, nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return "", false, err
+		}
+		if s == "" {
+			return "", false, fmt.Errorf("empty string")
+		}
+		return s, true, nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return "", false, err
+	}
+	return n.String(), true, nil
+}
+
+func wrapJSONErr(kind string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", kind, err)
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUint256JSON_RoundTripMax(t *testing.T) {
+	maxU := Uint256{new(uint256.Int).SetAllOne()}
+	raw, err := json.Marshal(maxU)
+	require.NoError(t, err)
+	assert.Equal(t, `"115792089237316195423570985008687907853269984665640564039457584007913129639935"`, string(raw))
+
+	var back Uint256
+	require.NoError(t, json.Unmarshal(raw, &back))
+	assert.True(t, maxU.Eq(back.Int))
+
+	raw, err = json.Marshal(Uint256{})
+	require.NoError(t, err)
+	assert.Equal(t, `"0"`, string(raw), "nil encodes as zero")
+}
+
+func TestUint256JSON_AcceptsLegacyForms(t *testing.T) {
+	for in, want := range map[string]uint64{`"42"`: 42, `42`: 42, `"0x2a"`: 42} {
+		var u Uint256
+		require.NoError(t, json.Unmarshal([]byte(in), &u), in)
+		assert.Equal(t, want, u.Uint64(), in)
+	}
+	var u Uint256
+	assert.Error(t, json.Unmarshal([]byte(`"-1"`), &u))
+	assert.Error(t, json.Unmarshal([]byte(`"1e3"`), &u))
+}
+
+func TestBigIntJSON_String(t *testing.T) {
+	n, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	raw, err := json.Marshal(BigInt{n})
+	require.NoError(t, err)
+	assert.Equal(t, `"123456789012345678901234567890"`, string(raw))
+
+	var back BigInt
+	require.NoError(t, json.Unmarshal(raw, &back))
+	assert.Equal(t, 0, n.Cmp(back.Int))
+
+	require.NoError(t, json.Unmarshal([]byte(`9007199254740993`), &back))
+	assert.Equal(t, "9007199254740993", back.String(), "numbers beyond 2^53 keep full precision")
+}
+
+func TestHeightJSON(t *testing.T) {
+	raw, err := json.Marshal(Height(19_000_000))
+	require.NoError(t, err)
+	assert.Equal(t, `19000000`, string(raw))
+
+	var h Height
+	require.NoError(t, json.Unmarshal([]byte(`"123"`), &h))
+	assert.Equal(t, Height(123), h)
+	assert.Error(t, json.Unmarshal([]byte(`"-1"`), &h))
+
+	require.NoError(t, h.Scan("456.000"))
+	assert.Equal(t, Height(456), h)
+}
+
+func TestBlockJSON_RoundTrip(t *testing.T) {
+	fee := BigInt{big.NewInt(7_000_000_000)}
+	b := Block{
+		ProcessedAt:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Number:           BigInt{big.NewInt(100)},
+		Hash:             "0xh",
+		ParentHash:       "0xp",
+		Timestamp:        1700000000,
+		GasLimit:         30_000_000,
+		GasUsed:          21_000,
+		BaseFeePerGas:    &fee,
+		TransactionCount: 3,
+	}
+	raw, err := json.Marshal(b)
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	assert.Equal(t, float64(100), doc["number"])
+	assert.Equal(t, "7000000000", doc["base_fee_per_gas"])
+
+	var back Block
+	require.NoError(t, json.Unmarshal(raw, &back))
+	assert.Equal(t, b.Hash, back.Hash)
+	assert.Equal(t, 0, b.Number.Cmp(back.Number.Int))
+	assert.Equal(t, 0, fee.Cmp(back.BaseFeePerGas.Int))
+	assert.True(t, b.ProcessedAt.Equal(back.ProcessedAt))
+}
+
+func TestTransferJSON_RoundTrip(t *testing.T) {
+	amount, ok := NewUint256FromString("340282366920938463463374607431768211456")
+	require.True(t, ok)
+	tr := Transfer{
+		BlockNumber:  BigInt{big.NewInt(42)},
+		TxHash:       "0xt",
+		LogIndex:     5,
+		From:         "0xf",
+		To:           "0xo",
+		TokenAddress: "0xa",
+		Symbol:       "USDC",
+		Type:         "TRANSFER",
+		Amount:       amount,
+	}
+	raw, err := json.Marshal(tr)
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	assert.Equal(t, float64(42), doc["block_number"])
+	assert.Equal(t, amount.String(), doc["amount"])
+	assert.NotContains(t, doc, "origin")
+
+	var back Transfer
+	require.NoError(t, json.Unmarshal(raw, &back))
+	assert.Equal(t, tr.IdempotencyKey(1), back.IdempotencyKey(1))
+	assert.True(t, amount.Eq(back.Amount.Int))
+}
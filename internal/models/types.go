@@ -127,110 +127,121 @@ func (b BigInt) Value() (driver.Value, error) {
 }
 
 // Scan 实现 sql.Scanner (读取数据库).
+// 驱动对 NUMERIC 可能返回 []byte/string（含 "123.000" 或科学计数法）、int64 或 float64，统一按整数解析。
 func (b *BigInt) Scan(value interface{}) error {
-	if value == nil {
-		b.Int = new(big.Int)
-		return nil
-	}
 	switch v := value.(type) {
+	case nil:
+		b.Int = new(big.Int)
 	case []byte:
-		s := string(v)
-		// 支持 hex 字符串 (0x...)
-		if len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
-			i, ok := new(big.Int).SetString(s[2:], 16)
-			if !ok {
-				return fmt.Errorf("failed to convert hex %s to BigInt", s)
-			}
-			b.Int = i
-			return nil
-		}
-		// 处理科学计数法（PostgreSQL NUMERIC 可能返回）
-		if strings.ContainsAny(s, "eE") {
-			f, _, err := big.ParseFloat(s, 10, 0, big.ToNearestEven)
-			if err != nil {
-				return fmt.Errorf("failed to parse numeric %q: %w", s, err)
-			}
-			bi, acc := f.Int(nil)
-			if acc != big.Exact {
-				return fmt.Errorf("numeric %q is not an integer", s)
-			}
-			b.Int = bi
-			return nil
-		}
-		i, ok := new(big.Int).SetString(s, 10)
-		if !ok {
-			return fmt.Errorf("failed to convert %s to BigInt", s)
-		}
-		b.Int = i
+		return b.scanString(string(v))
 	case string:
-		// 支持 hex 字符串 (0x...)
-		if len(v) >= 2 && v[0] == '0' && (v[1] == 'x' || v[1] == 'X') {
-			i, ok := new(big.Int).SetString(v[2:], 16)
-			if !ok {
-				return fmt.Errorf("failed to convert hex %s to BigInt", v)
-			}
-			b.Int = i
-			return nil
-		}
-		// 处理科学计数法（PostgreSQL NUMERIC 可能返回）
-		if strings.ContainsAny(v, "eE") {
-			f, _, err := big.ParseFloat(v, 10, 0, big.ToNearestEven)
-			if err != nil {
-				return fmt.Errorf("failed to parse numeric %q: %w", v, err)
-			}
-			bi, acc := f.Int(nil)
-			if acc != big.Exact {
-				return fmt.Errorf("numeric %q is not an integer", v)
-			}
-			b.Int = bi
-			return nil
-		}
-		i, ok := new(big.Int).SetString(v, 10)
-		if !ok {
-			return fmt.Errorf("failed to convert %s to BigInt", v)
-		}
-		b.Int = i
+		return b.scanString(v)
 	case int64:
 		b.Int = big.NewInt(v)
 	case int:
 		b.Int = big.NewInt(int64(v))
+	case uint64:
+		b.Int = new(big.Int).SetUint64(v)
+	case float64:
+		f := big.NewFloat(v)
+		if !f.IsInt() {
+			return fmt.Errorf("numeric %v is not an integer", v)
+		}
+		b.Int, _ = f.Int(nil)
 	default:
 		return fmt.Errorf("unsupported type for BigInt: %T", v)
 	}
 	return nil
 }
 
+// scanString 解析十进制、0x 十六进制、带零小数位（NUMERIC 带 scale）或科学计数法的整数
+func (b *BigInt) scanString(s string) error {
+	// 支持 hex 字符串 (0x...)
+	if len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		i, ok := new(big.Int).SetString(s[2:], 16)
+		if !ok {
+			return fmt.Errorf("failed to convert hex %s to BigInt", s)
+		}
+		b.Int = i
+		return nil
+	}
+	// 处理科学计数法与小数位（PostgreSQL NUMERIC 可能返回）
+	if strings.ContainsAny(s, "eE.") {
+		f, _, err := big.ParseFloat(s, 10, 512, big.ToNearestEven)
+		if err != nil {
+			return fmt.Errorf("failed to parse numeric %q: %w", s, err)
+		}
+		bi, acc := f.Int(nil)
+		if acc != big.Exact {
+			return fmt.Errorf("numeric %q is not an integer", s)
+		}
+		b.Int = bi
+		return nil
+	}
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return fmt.Errorf("failed to convert %s to BigInt", s)
+	}
+	b.Int = i
+	return nil
+}
+
 // 对应数据库的结构体
 type Block struct {
-	ProcessedAt      time.Time `db:"processed_at"`
-	Number           BigInt    `db:"number"`
-	Hash             string    `db:"hash"`
-	ParentHash       string    `db:"parent_hash"`
-	Timestamp        uint64    `db:"timestamp"`
-	GasLimit         uint64    `db:"gas_limit"`
-	GasUsed          uint64    `db:"gas_used"`
-	BaseFeePerGas    *BigInt   `db:"base_fee_per_gas"`
-	TransactionCount int       `db:"transaction_count"`
+	ProcessedAt      time.Time `db:"processed_at" json:"processed_at"`
+	Number           BigInt    `db:"number" json:"number"`
+	Hash             string    `db:"hash" json:"hash"`
+	ParentHash       string    `db:"parent_hash" json:"parent_hash"`
+	Timestamp        uint64    `db:"timestamp" json:"timestamp"`
+	GasLimit         uint64    `db:"gas_limit" json:"gas_limit"`
+	GasUsed          uint64    `db:"gas_used" json:"gas_used"`
+	BaseFeePerGas    *BigInt   `db:"base_fee_per_gas" json:"base_fee_per_gas,omitempty"`
+	TransactionCount int       `db:"transaction_count" json:"transaction_count"`
+	// EIP-4844（Dencun 之后的区块头字段，之前的区块与不支持 blob 的链为 nil）
+	BlobGasUsed   *uint64 `db:"blob_gas_used" json:"blob_gas_used,omitempty"`
+	ExcessBlobGas *uint64 `db:"excess_blob_gas" json:"excess_blob_gas,omitempty"`
 }
 
 type Transfer struct {
-	BlockNumber  BigInt  `db:"block_number"`
-	TxHash       string  `db:"tx_hash"`
-	LogIndex     uint    `db:"log_index"`
-	From         string  `db:"from_address"`
-	To           string  `db:"to_address"`
-	TokenAddress string  `db:"token_address"`
-	Symbol       string  `db:"symbol"`        // ✅ 代币符号（如 USDC, USDT）
-	Type         string  `db:"activity_type"` // ✅ 活动类型（如 TRANSFER, SWAP, MINT）
-	Amount       Uint256 `db:"amount"`        // 使用 Uint256 保证金融级精度
+	BlockNumber  BigInt  `db:"block_number" json:"block_number"`
+	TxHash       string  `db:"tx_hash" json:"tx_hash"`
+	LogIndex     uint    `db:"log_index" json:"log_index"`
+	From         string  `db:"from_address" json:"from_address"`
+	To           string  `db:"to_address" json:"to_address"`
+	TokenAddress string  `db:"token_address" json:"token_address"`
+	Symbol       string  `db:"symbol" json:"symbol"`           // ✅ 代币符号（如 USDC, USDT）
+	Type         string  `db:"activity_type" json:"type"`      // ✅ 活动类型（如 TRANSFER, SWAP, MINT）
+	Amount       Uint256 `db:"amount" json:"amount"`           // 使用 Uint256 保证金融级精度
+	Synthesized  bool    `db:"synthesized" json:"synthesized"` // ✅ 模拟器生成的合成数据（非链上真实事件）
+	Origin       string  `db:"origin" json:"origin,omitempty"` // 来源标签：配置的 Transfer 等价事件（EXTRA_TRANSFER_TOPICS）产生的行，标准事件为空
+}
+
+// IdempotencyKey 返回下游幂等键 (chain_id:block:tx_hash:log_index)
+// 同一条链上的同一条日志无论重放多少次（重试、Reorg 回放）都得到相同的键
+func (t Transfer) IdempotencyKey(chainID int64) string {
+	block := "0"
+	if t.BlockNumber.Int != nil {
+		block = t.BlockNumber.String()
+	}
+	return fmt.Sprintf("%d:%s:%s:%d", chainID, block, strings.ToLower(t.TxHash), t.LogIndex)
+}
+
+// IdempotencyKey 返回区块的下游幂等键 (chain_id:block:hash)
+func (b Block) IdempotencyKey(chainID int64) string {
+	number := "0"
+	if b.Number.Int != nil {
+		number = b.Number.String()
+	}
+	return fmt.Sprintf("%d:%s:%s", chainID, number, strings.ToLower(b.Hash))
 }
 
 // GasSpender 记录 Gas 消耗大户
 type GasSpender struct {
-	Address  string `json:"address"`
-	Label    string `json:"label"`
-	TotalGas uint64 `json:"total_gas"`
-	TotalFee string `json:"total_fee"` // 格式化后的 ETH 字符串
+	Address   string `json:"address"`
+	Label     string `json:"label"`
+	TotalGas  uint64 `json:"total_gas"`
+	TotalFee  string `json:"total_fee"` // 格式化后的 ETH 字符串
+	Estimated bool   `json:"estimated"` // 至少一笔交易缺少回执，按 Gas Limit 估算（偏高）
 }
 
 // TokenMetadata 代币元数据结构
@@ -246,7 +257,11 @@ const (
 	ActivitySwap     = "SWAP"
 	ActivityApprove  = "APPROVE"
 	ActivityMint     = "MINT"
+	ActivityBurn     = "BURN"
 	ActivityDeploy   = "DEPLOY"
 	ActivityETH      = "ETH_TRANSFER"
 	ActivityFaucet   = "FAUCET_CLAIM"
+	ActivityInternal = "INTERNAL" // 合约内部调用产生的 ETH 转账（debug_traceBlockByNumber）
+	ActivityWrap     = "WRAP"     // 原生币包装为 WETH（Deposit 事件，记为零地址 → 账户）
+	ActivityUnwrap   = "UNWRAP"   // WETH 解包为原生币（Withdrawal 事件，记为账户 → 零地址）
 )
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBigIntScan_NumericRepresentations 驱动对 NUMERIC 区块号的各种返回形式都应解析为同一整数
+func TestBigIntScan_NumericRepresentations(t *testing.T) {
+	cases := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"bytes", []byte("12345678901"), "12345678901"},
+		{"string", "12345678901", "12345678901"},
+		{"numeric with scale", "12345678901.000", "12345678901"},
+		{"scientific", "1.2345678901e10", "12345678901"},
+		{"large scientific", "1e30", "1000000000000000000000000000000"},
+		{"hex", "0x2dfdc1c35", "12345678901"},
+		{"int64", int64(12345678901), "12345678901"},
+		{"uint64", uint64(18446744073709551615), "18446744073709551615"},
+		{"float64", float64(12345678901), "12345678901"},
+		{"null", nil, "0"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var b BigInt
+			require.NoError(t, b.Scan(tc.value))
+			assert.Equal(t, tc.want, b.String())
+		})
+	}
+
+	var b BigInt
+	assert.Error(t, b.Scan("12.5"))
+	assert.Error(t, b.Scan(float64(1.5)))
+	assert.Error(t, b.Scan(true))
+}
+
+func TestBigIntValue_DecimalString(t *testing.T) {
+	n, ok := NewBigIntFromString("12345678901")
+	require.True(t, ok)
+	v, err := n.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "12345678901", v)
+
+	v, err = BigInt{}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "0", v)
+}
@@ -2,6 +2,7 @@ package monitor
 
 import (
 	"log/slog"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -23,17 +24,33 @@ type QuotaMonitor struct {
 	statusGauge prometheus.Gauge
 }
 
-// NewQuotaMonitor 创建新的额度监控器
-func NewQuotaMonitor() *QuotaMonitor {
-	qm := &QuotaMonitor{
-		usageGauge: promauto.NewGauge(prometheus.GaugeOpts{
+var (
+	quotaGaugesOnce  sync.Once
+	quotaUsageGauge  prometheus.Gauge
+	quotaStatusGauge prometheus.Gauge
+)
+
+// quotaGauges 额度指标进程内只注册一次（多个节点池共享），重复注册会 panic
+func quotaGauges() (usage, status prometheus.Gauge) {
+	quotaGaugesOnce.Do(func() {
+		quotaUsageGauge = promauto.NewGauge(prometheus.GaugeOpts{
 			Name: "rpc_quota_usage_percent",
 			Help: "Percentage of daily RPC quota used (0-100)",
-		}),
-		statusGauge: promauto.NewGauge(prometheus.GaugeOpts{
+		})
+		quotaStatusGauge = promauto.NewGauge(prometheus.GaugeOpts{
 			Name: "rpc_quota_status",
 			Help: "RPC quota status: 0=Safe, 1=Warning, 2=Critical",
-		}),
+		})
+	})
+	return quotaUsageGauge, quotaStatusGauge
+}
+
+// NewQuotaMonitor 创建新的额度监控器
+func NewQuotaMonitor() *QuotaMonitor {
+	usage, status := quotaGauges()
+	qm := &QuotaMonitor{
+		usageGauge:  usage,
+		statusGauge: status,
 	}
 	qm.resetTime = qm.calculateNextReset()
 	go qm.startResetTimer()
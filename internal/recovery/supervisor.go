@@ -0,0 +1,147 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// RestartPolicy 组件的重启策略
+type RestartPolicy struct {
+	MaxRestarts    int           // Window 内允许的最大重启次数，<=0 表示不限
+	Window         time.Duration // 重启计数窗口
+	InitialBackoff time.Duration // 首次重启前等待时间
+	MaxBackoff     time.Duration // 指数退避上限
+}
+
+// DefaultRestartPolicy 默认策略：5 分钟内最多重启 10 次，3s 起步指数退避至 60s
+func DefaultRestartPolicy() RestartPolicy {
+	return RestartPolicy{
+		MaxRestarts:    10,
+		Window:         5 * time.Minute,
+		InitialBackoff: 3 * time.Second,
+		MaxBackoff:     60 * time.Second,
+	}
+}
+
+// backoff 计算窗口内第 n 次重启（n 从 1 开始）的等待时间
+func (p RestartPolicy) backoff(n int) time.Duration {
+	d := p.InitialBackoff
+	if d <= 0 {
+		d = time.Second
+	}
+	for i := 1; i < n; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+var (
+	policiesMu sync.RWMutex
+	policies   = make(map[string]RestartPolicy)
+
+	// OnRestart is an optional callback triggered before a supervised component restarts
+	OnRestart func(name string, restarts int, backoff time.Duration)
+	// OnGiveUp is an optional callback triggered when a supervised component exhausts its policy
+	OnGiveUp func(name string, err error)
+)
+
+// SetRestartPolicy 为指定组件配置重启策略
+func SetRestartPolicy(name string, p RestartPolicy) {
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	policies[name] = p
+}
+
+// PolicyFor 返回组件的重启策略，未配置时返回默认策略
+func PolicyFor(name string) RestartPolicy {
+	policiesMu.RLock()
+	defer policiesMu.RUnlock()
+	if p, ok := policies[name]; ok {
+		return p
+	}
+	return DefaultRestartPolicy()
+}
+
+// GiveUpError 组件在窗口内重启次数超限，监督者放弃重启
+type GiveUpError struct {
+	Name     string
+	Restarts int
+	Window   time.Duration
+}
+
+func (e *GiveUpError) Error() string {
+	return fmt.Sprintf("supervisor gave up on %s after %d restarts within %v", e.Name, e.Restarts, e.Window)
+}
+
+// Supervise 在 WithRecoveryNamed 保护下运行 fn，fn 返回或 panic 后按策略重启。
+// ctx 取消时退出；重启次数超限时将 GiveUpError 非阻塞地发送到 fatalErrCh 并退出。
+func Supervise(ctx context.Context, name string, fatalErrCh chan<- error, fn func()) {
+	policy := PolicyFor(name)
+	var restarts []time.Time
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		WithRecoveryNamed(name, fn)
+		if ctx.Err() != nil {
+			return
+		}
+
+		now := time.Now()
+		restarts = append(restarts, now)
+		if policy.Window > 0 {
+			cutoff := now.Add(-policy.Window)
+			kept := restarts[:0]
+			for _, t := range restarts {
+				if t.After(cutoff) {
+					kept = append(kept, t)
+				}
+			}
+			restarts = kept
+		}
+
+		if policy.MaxRestarts > 0 && len(restarts) > policy.MaxRestarts {
+			err := &GiveUpError{Name: name, Restarts: len(restarts) - 1, Window: policy.Window}
+			Logger.Error("supervisor_gave_up",
+				slog.String("worker_name", name),
+				slog.Int("restarts", len(restarts)-1),
+				slog.Duration("window", policy.Window))
+			if OnGiveUp != nil {
+				OnGiveUp(name, err)
+			}
+			if fatalErrCh != nil {
+				select {
+				case fatalErrCh <- err:
+				default:
+					Logger.Error("supervisor_fatal_channel_full", slog.String("worker_name", name))
+				}
+			}
+			return
+		}
+
+		backoff := policy.backoff(len(restarts))
+		Logger.Warn("supervisor_restarting",
+			slog.String("worker_name", name),
+			slog.Int("restarts_in_window", len(restarts)),
+			slog.Duration("backoff", backoff))
+		if OnRestart != nil {
+			OnRestart(name, len(restarts), backoff)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestartPolicyBackoff(t *testing.T) {
+	p := RestartPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	assert.Equal(t, time.Second, p.backoff(1))
+	assert.Equal(t, 2*time.Second, p.backoff(2))
+	assert.Equal(t, 4*time.Second, p.backoff(3))
+	assert.Equal(t, 5*time.Second, p.backoff(4))
+	assert.Equal(t, 5*time.Second, p.backoff(20))
+}
+
+func TestSuperviseGivesUpAfterMaxRestarts(t *testing.T) {
+	SetRestartPolicy("test_flaky", RestartPolicy{
+		MaxRestarts:    2,
+		Window:         time.Minute,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+	})
+
+	runs := 0
+	restarts := 0
+	OnRestart = func(name string, _ int, _ time.Duration) {
+		if name == "test_flaky" {
+			restarts++
+		}
+	}
+	defer func() { OnRestart = nil }()
+
+	fatalErrCh := make(chan error, 1)
+	Supervise(context.Background(), "test_flaky", fatalErrCh, func() {
+		runs++
+		panic("boom")
+	})
+
+	assert.Equal(t, 3, runs, "initial run plus two restarts")
+	assert.Equal(t, 2, restarts)
+
+	select {
+	case err := <-fatalErrCh:
+		var giveUp *GiveUpError
+		assert.True(t, errors.As(err, &giveUp))
+		assert.Equal(t, "test_flaky", giveUp.Name)
+	default:
+		t.Fatal("expected give-up error on fatalErrCh")
+	}
+}
+
+func TestSuperviseStopsOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := 0
+	Supervise(ctx, "test_cancel", nil, func() {
+		runs++
+		cancel()
+	})
+	assert.Equal(t, 1, runs)
+}
@@ -0,0 +1,456 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strconv"
+
+	"web3-indexer-go/internal/models"
+)
+
+// InsertBlocksTx 使用 UNNEST 在给定连接或事务内批量插入区块（已存在的区块号跳过）
+func InsertBlocksTx(ctx context.Context, exec Execer, blocks []models.Block) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+	numbers := make([]string, len(blocks))
+	hashes := make([]string, len(blocks))
+	parentHashes := make([]string, len(blocks))
+	timestamps := make([]int64, len(blocks))
+	gasLimits := make([]int64, len(blocks))
+	gasUseds := make([]int64, len(blocks))
+	baseFees := make([]*string, len(blocks))
+	txCounts := make([]int, len(blocks))
+	blobGasUsed := make([]*int64, len(blocks))
+	excessBlobGas := make([]*int64, len(blocks))
+
+	for i, b := range blocks {
+		numbers[i] = b.Number.String()
+		hashes[i] = b.Hash
+		parentHashes[i] = b.ParentHash
+		// #nosec G115 - Ethereum timestamps and gas limits fit in int64
+		timestamps[i] = int64(b.Timestamp)
+		// #nosec G115
+		gasLimits[i] = int64(b.GasLimit)
+		// #nosec G115
+		gasUseds[i] = int64(b.GasUsed)
+		if b.BaseFeePerGas != nil {
+			s := b.BaseFeePerGas.String()
+			baseFees[i] = &s
+		}
+		txCounts[i] = b.TransactionCount
+		blobGasUsed[i] = optionalInt64(b.BlobGasUsed)
+		excessBlobGas[i] = optionalInt64(b.ExcessBlobGas)
+	}
+
+	query := `
+		INSERT INTO blocks (number, hash, parent_hash, timestamp, gas_limit, gas_used, base_fee_per_gas, transaction_count, blob_gas_used, excess_blob_gas)
+		SELECT * FROM UNNEST($1::numeric[], $2::text[], $3::text[], $4::bigint[], $5::bigint[], $6::bigint[], $7::numeric[], $8::int[], $9::bigint[], $10::bigint[])
+		ON CONFLICT (number) DO NOTHING
+	`
+	_, err := exec.ExecContext(ctx, query, numbers, hashes, parentHashes, timestamps, gasLimits, gasUseds, baseFees, txCounts, blobGasUsed, excessBlobGas)
+	return err
+}
+
+// InsertTransfersTx 使用 UNNEST 在给定连接或事务内批量插入转账（(block_number, log_index) 冲突时跳过）
+func InsertTransfersTx(ctx context.Context, exec Execer, transfers []models.Transfer) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+	blockNumbers := make([]string, len(transfers))
+	txHashes := make([]string, len(transfers))
+	logIndices := make([]uint64, len(transfers))
+	froms := make([]string, len(transfers))
+	tos := make([]string, len(transfers))
+	amounts := make([]string, len(transfers))
+	tokenAddresses := make([]string, len(transfers))
+	symbols := make([]string, len(transfers))
+	activityTypes := make([]string, len(transfers))
+	synthesized := make([]bool, len(transfers))
+	origins := make([]string, len(transfers))
+
+	for i, t := range transfers {
+		blockNumbers[i] = t.BlockNumber.String()
+		txHashes[i] = t.TxHash
+		logIndices[i] = uint64(t.LogIndex)
+		froms[i] = t.From
+		tos[i] = t.To
+		amounts[i] = t.Amount.String()
+		tokenAddresses[i] = t.TokenAddress
+		symbols[i] = t.Symbol
+		activityTypes[i] = t.Type
+		if activityTypes[i] == "" {
+			activityTypes[i] = models.ActivityTransfer
+		}
+		synthesized[i] = t.Synthesized
+		origins[i] = t.Origin
+	}
+
+	query := `
+		INSERT INTO transfers (block_number, tx_hash, log_index, from_address, to_address, amount, token_address, symbol, activity_type, synthesized, origin)
+		SELECT * FROM UNNEST($1::numeric[], $2::text[], $3::int[], $4::text[], $5::text[], $6::numeric[], $7::text[], $8::text[], $9::text[], $10::bool[], $11::text[])
+		ON CONFLICT (block_number, log_index) DO NOTHING
+	`
+	_, err := exec.ExecContext(ctx, query, blockNumbers, txHashes, logIndices, froms, tos, amounts, tokenAddresses, symbols, activityTypes, synthesized, origins)
+	return err
+}
+
+// UpsertAddressesTx 把批次内的地址出现情况合并进 addresses 注册表：
+// first/last_seen 取最小/最大值，tx_count 累加，is_contract 仅在本批已知时覆盖
+func UpsertAddressesTx(ctx context.Context, exec Execer, rows []AddressSeenRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	addresses := make([]string, len(rows))
+	firsts := make([]string, len(rows))
+	lasts := make([]string, len(rows))
+	txCounts := make([]int64, len(rows))
+	contracts := make([]*bool, len(rows))
+	for i, row := range rows {
+		addresses[i] = row.Address
+		firsts[i] = strconv.FormatUint(row.FirstSeen, 10)
+		lasts[i] = strconv.FormatUint(row.LastSeen, 10)
+		txCounts[i] = row.TxCount
+		contracts[i] = row.IsContract
+	}
+
+	query := `
+		INSERT INTO addresses (address, first_seen_block, last_seen_block, tx_count, is_contract)
+		SELECT * FROM UNNEST($1::varchar[], $2::numeric[], $3::numeric[], $4::bigint[], $5::bool[])
+		ON CONFLICT (address) DO UPDATE SET
+			first_seen_block = LEAST(addresses.first_seen_block, EXCLUDED.first_seen_block),
+			last_seen_block = GREATEST(addresses.last_seen_block, EXCLUDED.last_seen_block),
+			tx_count = addresses.tx_count + EXCLUDED.tx_count,
+			is_contract = COALESCE(EXCLUDED.is_contract, addresses.is_contract),
+			updated_at = NOW()
+	`
+	_, err := exec.ExecContext(ctx, query, addresses, firsts, lasts, txCounts, contracts)
+	return err
+}
+
+// UpsertSupplyDeltasTx 写入逐块供应量增量；同一 (代币, 区块) 重复写入时覆盖（区块的转账总在同一批次内）
+func UpsertSupplyDeltasTx(ctx context.Context, exec Execer, rows []SupplyDeltaRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	tokens := make([]string, len(rows))
+	blocks := make([]string, len(rows))
+	minted := make([]string, len(rows))
+	burned := make([]string, len(rows))
+	mints := make([]int32, len(rows))
+	burns := make([]int32, len(rows))
+	for i, row := range rows {
+		tokens[i] = row.Token
+		blocks[i] = strconv.FormatUint(row.Block, 10)
+		minted[i] = row.Minted.String()
+		burned[i] = row.Burned.String()
+		mints[i] = int32(row.Mints) // #nosec G115 - 单区块内的事件数远小于 int32 上限
+		burns[i] = int32(row.Burns) // #nosec G115
+	}
+
+	query := `
+		INSERT INTO token_supply_deltas (token_address, block_number, minted, burned, mints, burns)
+		SELECT * FROM UNNEST($1::varchar[], $2::numeric[], $3::numeric[], $4::numeric[], $5::int[], $6::int[])
+		ON CONFLICT (token_address, block_number) DO UPDATE SET
+			minted = EXCLUDED.minted,
+			burned = EXCLUDED.burned,
+			mints = EXCLUDED.mints,
+			burns = EXCLUDED.burns
+	`
+	_, err := exec.ExecContext(ctx, query, tokens, blocks, minted, burned, mints, burns)
+	return err
+}
+
+// InsertArbitrageEventsTx 写入套利候选；同一 (区块, sender) 重复写入时覆盖
+func InsertArbitrageEventsTx(ctx context.Context, exec Execer, rows []ArbitrageEventRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	blocks := make([]string, len(rows))
+	senders := make([]string, len(rows))
+	txHashes := make([]string, len(rows))
+	pools := make([]string, len(rows))
+	swaps := make([]int32, len(rows))
+	tokens := make([]string, len(rows))
+	profits := make([]string, len(rows))
+	for i, row := range rows {
+		txJSON, err := json.Marshal(row.TxHashes)
+		if err != nil {
+			return err
+		}
+		poolJSON, err := json.Marshal(row.Pools)
+		if err != nil {
+			return err
+		}
+		blocks[i] = strconv.FormatUint(row.Block, 10)
+		senders[i] = row.Sender
+		txHashes[i] = string(txJSON)
+		pools[i] = string(poolJSON)
+		swaps[i] = int32(row.Swaps) // #nosec G115 - 单区块内的 Swap 数远小于 int32 上限
+		tokens[i] = row.ProfitToken
+		profits[i] = row.ProfitAmount.String()
+	}
+
+	query := `
+		INSERT INTO arbitrage_events (block_number, sender, tx_hashes, pools, swaps, profit_token, profit_amount)
+		SELECT b, s, t::jsonb, p::jsonb, n, pt, pa
+		FROM UNNEST($1::numeric[], $2::varchar[], $3::text[], $4::text[], $5::int[], $6::varchar[], $7::numeric[])
+			AS u(b, s, t, p, n, pt, pa)
+		ON CONFLICT (block_number, sender) DO UPDATE SET
+			tx_hashes = EXCLUDED.tx_hashes,
+			pools = EXCLUDED.pools,
+			swaps = EXCLUDED.swaps,
+			profit_token = EXCLUDED.profit_token,
+			profit_amount = EXCLUDED.profit_amount,
+			detected_at = NOW()
+	`
+	_, err := exec.ExecContext(ctx, query, blocks, senders, txHashes, pools, swaps, tokens, profits)
+	return err
+}
+
+// InsertReceiptsTx 写入交易回执摘要；同一交易重复写入时覆盖（reorg 后区块级联删除，重放时重新写入）
+func InsertReceiptsTx(ctx context.Context, exec Execer, rows []ReceiptRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	hashes := make([]string, len(rows))
+	blocks := make([]string, len(rows))
+	indexes := make([]int32, len(rows))
+	statuses := make([]int16, len(rows))
+	gasUsed := make([]string, len(rows))
+	prices := make([]string, len(rows))
+	fees := make([]string, len(rows))
+	contracts := make([]string, len(rows))
+	for i, row := range rows {
+		hashes[i] = row.TxHash
+		blocks[i] = strconv.FormatUint(row.Block, 10)
+		indexes[i] = int32(row.TxIndex) // #nosec G115 - 区块内交易序号远小于 int32 上限
+		statuses[i] = int16(row.Status) // #nosec G115 - 回执状态只有 0 / 1
+		gasUsed[i] = strconv.FormatUint(row.GasUsed, 10)
+		prices[i] = bigOrZero(row.EffectiveGasPrice)
+		fees[i] = bigOrZero(row.Fee)
+		contracts[i] = row.ContractAddress
+	}
+
+	query := `
+		INSERT INTO receipts (tx_hash, block_number, tx_index, status, gas_used, effective_gas_price, fee, contract_address)
+		SELECT h, b, i, s, g, p, f, NULLIF(c, '')
+		FROM UNNEST($1::varchar[], $2::numeric[], $3::int[], $4::smallint[], $5::numeric[], $6::numeric[], $7::numeric[], $8::varchar[])
+			AS u(h, b, i, s, g, p, f, c)
+		ON CONFLICT (tx_hash) DO UPDATE SET
+			block_number = EXCLUDED.block_number,
+			tx_index = EXCLUDED.tx_index,
+			status = EXCLUDED.status,
+			gas_used = EXCLUDED.gas_used,
+			effective_gas_price = EXCLUDED.effective_gas_price,
+			fee = EXCLUDED.fee,
+			contract_address = EXCLUDED.contract_address
+	`
+	_, err := exec.ExecContext(ctx, query, hashes, blocks, indexes, statuses, gasUsed, prices, fees, contracts)
+	return err
+}
+
+// optionalInt64 可空的区块头字段（如 blob gas）转为可空 BIGINT
+func optionalInt64(v *uint64) *int64 {
+	if v == nil {
+		return nil
+	}
+	n := int64(*v) // #nosec G115 - blob gas 远小于 int64 上限
+	return &n
+}
+
+func bigOrZero(v *big.Int) string {
+	if v == nil {
+		return "0"
+	}
+	return v.String()
+}
+
+// InsertNFTTransfersTx 写入 ERC-721 转账；(block_number, log_index) 已存在时跳过（重放幂等）
+func InsertNFTTransfersTx(ctx context.Context, exec Execer, rows []NFTTransferRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	blocks := make([]string, len(rows))
+	logIndices := make([]uint64, len(rows))
+	txHashes := make([]string, len(rows))
+	collections := make([]string, len(rows))
+	froms := make([]string, len(rows))
+	tos := make([]string, len(rows))
+	tokenIDs := make([]string, len(rows))
+	for i, row := range rows {
+		blocks[i] = row.BlockNumber.String()
+		logIndices[i] = uint64(row.LogIndex)
+		txHashes[i] = row.TxHash
+		collections[i] = row.Collection
+		froms[i] = row.FromAddress
+		tos[i] = row.ToAddress
+		tokenIDs[i] = row.TokenID
+	}
+
+	query := `
+		INSERT INTO nft_transfers (block_number, log_index, tx_hash, collection, from_address, to_address, token_id)
+		SELECT * FROM UNNEST($1::numeric[], $2::int[], $3::varchar[], $4::varchar[], $5::varchar[], $6::varchar[], $7::numeric[])
+		ON CONFLICT (block_number, log_index) DO NOTHING
+	`
+	_, err := exec.ExecContext(ctx, query, blocks, logIndices, txHashes, collections, froms, tos, tokenIDs)
+	return err
+}
+
+// InsertApprovalsTx 写入授权事件；(block_number, log_index) 已存在时跳过（重放幂等）
+func InsertApprovalsTx(ctx context.Context, exec Execer, rows []ApprovalRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	blocks := make([]string, len(rows))
+	logIndices := make([]uint64, len(rows))
+	txHashes := make([]string, len(rows))
+	tokens := make([]string, len(rows))
+	kinds := make([]string, len(rows))
+	owners := make([]string, len(rows))
+	spenders := make([]string, len(rows))
+	amounts := make([]string, len(rows))
+	tokenIDs := make([]string, len(rows))
+	approved := make([]bool, len(rows))
+	for i, row := range rows {
+		blocks[i] = row.BlockNumber.String()
+		logIndices[i] = uint64(row.LogIndex)
+		txHashes[i] = row.TxHash
+		tokens[i] = row.TokenAddress
+		kinds[i] = row.Kind
+		owners[i] = row.Owner
+		spenders[i] = row.Spender
+		amounts[i] = row.Amount
+		tokenIDs[i] = row.TokenID
+		approved[i] = row.Approved
+	}
+
+	// 空串表示不适用，落库为 NULL
+	query := `
+		INSERT INTO approvals (block_number, log_index, tx_hash, token_address, kind, owner, spender, amount, token_id, approved)
+		SELECT b, l, h, tok, k, o, sp, NULLIF(a, '')::numeric, NULLIF(id, '')::numeric, ap
+		FROM UNNEST($1::numeric[], $2::int[], $3::varchar[], $4::varchar[], $5::varchar[], $6::varchar[], $7::varchar[], $8::text[], $9::text[], $10::bool[])
+			AS u(b, l, h, tok, k, o, sp, a, id, ap)
+		ON CONFLICT (block_number, log_index) DO NOTHING
+	`
+	_, err := exec.ExecContext(ctx, query, blocks, logIndices, txHashes, tokens, kinds, owners, spenders, amounts, tokenIDs, approved)
+	return err
+}
+
+// InsertContractEventsTx 写入通用解码的合约事件；(block_number, log_index) 已存在时跳过（重放幂等）
+func InsertContractEventsTx(ctx context.Context, exec Execer, rows []ContractEventRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	blocks := make([]string, len(rows))
+	logIndices := make([]uint64, len(rows))
+	txHashes := make([]string, len(rows))
+	contracts := make([]string, len(rows))
+	names := make([]string, len(rows))
+	topics := make([]string, len(rows))
+	args := make([]string, len(rows))
+	for i, row := range rows {
+		blocks[i] = row.BlockNumber.String()
+		logIndices[i] = uint64(row.LogIndex)
+		txHashes[i] = row.TxHash
+		contracts[i] = row.ContractAddress
+		names[i] = row.EventName
+		topics[i] = row.Topic0
+		args[i] = string(row.Args)
+	}
+
+	query := `
+		INSERT INTO contract_events (block_number, log_index, tx_hash, contract_address, event_name, topic0, args)
+		SELECT b, l, h, c, n, t, a::jsonb
+		FROM UNNEST($1::numeric[], $2::int[], $3::varchar[], $4::varchar[], $5::varchar[], $6::varchar[], $7::text[])
+			AS u(b, l, h, c, n, t, a)
+		ON CONFLICT (block_number, log_index) DO NOTHING
+	`
+	_, err := exec.ExecContext(ctx, query, blocks, logIndices, txHashes, contracts, names, topics, args)
+	return err
+}
+
+// InsertBlobTxsTx 写入 blob 交易；同一交易重复写入时覆盖（reorg 后区块级联删除，重放时重新写入）
+func InsertBlobTxsTx(ctx context.Context, exec Execer, rows []BlobTxRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	hashes := make([]string, len(rows))
+	blocks := make([]string, len(rows))
+	indexes := make([]int32, len(rows))
+	blobHashes := make([]string, len(rows))
+	counts := make([]int16, len(rows))
+	blobGas := make([]int64, len(rows))
+	feeCaps := make([]string, len(rows))
+	for i, row := range rows {
+		hashJSON, err := json.Marshal(row.BlobHashes)
+		if err != nil {
+			return err
+		}
+		hashes[i] = row.TxHash
+		blocks[i] = strconv.FormatUint(row.Block, 10)
+		indexes[i] = int32(row.TxIndex) // #nosec G115 - 区块内交易序号远小于 int32 上限
+		blobHashes[i] = string(hashJSON)
+		counts[i] = int16(len(row.BlobHashes)) // #nosec G115 - 单笔交易的 blob 数受协议上限约束
+		blobGas[i] = int64(row.BlobGas)        // #nosec G115 - blob gas 远小于 int64 上限
+		feeCaps[i] = bigOrZero(row.BlobGasFeeCap)
+	}
+
+	query := `
+		INSERT INTO blob_transactions (tx_hash, block_number, tx_index, blob_hashes, blob_count, blob_gas, blob_gas_fee_cap)
+		SELECT h, b, i, bh::jsonb, c, g, f
+		FROM UNNEST($1::varchar[], $2::numeric[], $3::int[], $4::text[], $5::smallint[], $6::bigint[], $7::numeric[])
+			AS u(h, b, i, bh, c, g, f)
+		ON CONFLICT (tx_hash) DO UPDATE SET
+			block_number = EXCLUDED.block_number,
+			tx_index = EXCLUDED.tx_index,
+			blob_hashes = EXCLUDED.blob_hashes,
+			blob_count = EXCLUDED.blob_count,
+			blob_gas = EXCLUDED.blob_gas,
+			blob_gas_fee_cap = EXCLUDED.blob_gas_fee_cap
+	`
+	_, err := exec.ExecContext(ctx, query, hashes, blocks, indexes, blobHashes, counts, blobGas, feeCaps)
+	return err
+}
+
+// InsertBlockFeesTx 写入区块费用统计；同一区块重复写入时覆盖
+func InsertBlockFeesTx(ctx context.Context, exec Execer, rows []BlockFeeRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	blocks := make([]string, len(rows))
+	burnt := make([]string, len(rows))
+	tips := make([]string, len(rows))
+	rewards := make([]string, len(rows))
+	estimated := make([]bool, len(rows))
+	for i, row := range rows {
+		values := make([]string, len(row.Rewards))
+		for j, r := range row.Rewards {
+			values[j] = bigOrZero(r)
+		}
+		rewardJSON, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+		blocks[i] = strconv.FormatUint(row.Block, 10)
+		burnt[i] = bigOrZero(row.BurntFees)
+		tips[i] = bigOrZero(row.PriorityFees)
+		rewards[i] = string(rewardJSON)
+		estimated[i] = row.Estimated
+	}
+
+	query := `
+		INSERT INTO block_fees (block_number, burnt_fees, priority_fees, reward_percentiles, estimated)
+		SELECT b, bf, pf, r::jsonb, e
+		FROM UNNEST($1::numeric[], $2::numeric[], $3::numeric[], $4::text[], $5::boolean[])
+			AS u(b, bf, pf, r, e)
+		ON CONFLICT (block_number) DO UPDATE SET
+			burnt_fees = EXCLUDED.burnt_fees,
+			priority_fees = EXCLUDED.priority_fees,
+			reward_percentiles = EXCLUDED.reward_percentiles,
+			estimated = EXCLUDED.estimated
+	`
+	_, err := exec.ExecContext(ctx, query, blocks, burnt, tips, rewards, estimated)
+	return err
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// UpsertCheckpointTx 写入链的同步检查点（sync_checkpoints.last_synced_block）
+func UpsertCheckpointTx(ctx context.Context, exec Execer, chainID, height int64) error {
+	_, err := exec.ExecContext(ctx, `
+		INSERT INTO sync_checkpoints (chain_id, last_synced_block)
+		VALUES ($1, $2)
+		ON CONFLICT (chain_id) DO UPDATE SET
+			last_synced_block = EXCLUDED.last_synced_block,
+			updated_at = NOW()`,
+		chainID, strconv.FormatInt(height, 10))
+	if err != nil {
+		return fmt.Errorf("upsert checkpoint: %w", err)
+	}
+	return nil
+}
+
+// DeleteCheckpointTx 删除链的同步检查点（下次启动从配置的起始块开始）
+func DeleteCheckpointTx(ctx context.Context, exec Execer, chainID int64) error {
+	if _, err := exec.ExecContext(ctx, "DELETE FROM sync_checkpoints WHERE chain_id = $1", chainID); err != nil {
+		return fmt.Errorf("delete checkpoint: %w", err)
+	}
+	return nil
+}
+
+// UpsertSyncStatusTx 写入链的同步状态（Grafana 展示用）：已同步高度、链上高度与二者之差
+func UpsertSyncStatusTx(ctx context.Context, exec Execer, chainID, synced, latest int64) error {
+	_, err := exec.ExecContext(ctx, `
+		INSERT INTO sync_status (chain_id, last_synced_block, latest_block, sync_lag, status, last_processed_block, last_processed_timestamp)
+		VALUES ($1, $2, $3, $4, 'syncing', $2, NOW())
+		ON CONFLICT (chain_id) DO UPDATE SET
+			last_synced_block = EXCLUDED.last_synced_block,
+			latest_block = EXCLUDED.latest_block,
+			sync_lag = EXCLUDED.sync_lag,
+			last_processed_block = EXCLUDED.last_processed_block,
+			last_processed_timestamp = EXCLUDED.last_processed_timestamp`,
+		chainID, synced, latest, max(latest-synced, 0))
+	if err != nil {
+		return fmt.Errorf("upsert sync status: %w", err)
+	}
+	return nil
+}
+
+// updateCursorTx 强制移动链的同步游标：检查点不存在时创建，sync_status 只更新该链已有的行
+func updateCursorTx(ctx context.Context, exec Execer, chainID, height int64) error {
+	if err := UpsertCheckpointTx(ctx, exec, chainID, height); err != nil {
+		return err
+	}
+	if _, err := exec.ExecContext(ctx,
+		"UPDATE sync_status SET last_processed_block = $1, last_processed_timestamp = NOW() WHERE chain_id = $2",
+		strconv.FormatInt(height, 10), chainID); err != nil {
+		return fmt.Errorf("update sync status: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,499 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"web3-indexer-go/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TransferColumns 转账查询列（配合 TransferFrom 使用，别名 t / m）
+const TransferColumns = `t.id, t.block_number, t.tx_hash, t.log_index, t.from_address, t.to_address, t.amount, t.token_address, t.symbol, t.activity_type,
+	t.origin, COALESCE(m.decimals, 18) AS decimals`
+
+// TransferFrom 关联 token_metadata 的 FROM 子句（两表地址均以小写存储）
+const TransferFrom = "FROM transfers t LEFT JOIN token_metadata m ON m.address = t.token_address"
+
+// Options Postgres 可选注入项
+type Options struct {
+	Select         QueryFunc    // 多行读查询（默认 sqlx.SelectContext）
+	Get            QueryFunc    // 单行读查询（默认 sqlx.GetContext）
+	BeforeRollback RollbackHook // 回滚删除 blocks 之前的派生表撤销
+}
+
+// Postgres 基于 sqlx 的 Store 实现
+type Postgres struct {
+	db   *sqlx.DB
+	opts Options
+}
+
+var _ Store = (*Postgres)(nil)
+
+// NewPostgres 包装现有连接池
+func NewPostgres(db *sqlx.DB, opts Options) *Postgres {
+	if opts.Select == nil {
+		opts.Select = func(ctx context.Context, db sqlx.QueryerContext, _ string, dest interface{}, query string, args ...interface{}) error {
+			return sqlx.SelectContext(ctx, db, dest, query, args...)
+		}
+	}
+	if opts.Get == nil {
+		opts.Get = func(ctx context.Context, db sqlx.QueryerContext, _ string, dest interface{}, query string, args ...interface{}) error {
+			return sqlx.GetContext(ctx, db, dest, query, args...)
+		}
+	}
+	return &Postgres{db: db, opts: opts}
+}
+
+// DB 返回底层连接池（供尚未迁移到 Store 的分析查询使用）
+func (p *Postgres) DB() *sqlx.DB {
+	return p.db
+}
+
+// InsertBlocks 批量写入区块（已存在的区块号跳过）
+func (p *Postgres) InsertBlocks(ctx context.Context, blocks []models.Block) error {
+	return InsertBlocksTx(ctx, p.db, blocks)
+}
+
+// InsertTransfers 批量写入转账（(block_number, log_index) 冲突时跳过）
+func (p *Postgres) InsertTransfers(ctx context.Context, transfers []models.Transfer) error {
+	return InsertTransfersTx(ctx, p.db, transfers)
+}
+
+// RollbackAbove 删除 number > height 的区块与转账，并把该链的同步游标回退到 height（单事务）
+func (p *Postgres) RollbackAbove(ctx context.Context, chainID, height int64) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rbErr := tx.Rollback(); rbErr != nil && rbErr != sql.ErrTxDone {
+			slog.Error("📝 RollbackAbove: Rollback failed", "err", rbErr)
+		}
+	}()
+
+	if err := p.RollbackTx(ctx, tx, strconv.FormatInt(height+1, 10)); err != nil {
+		return err
+	}
+	if err := updateCursorTx(ctx, tx, chainID, height); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RollbackTx 在调用方事务内删除 number >= fromBlock 的区块与转账（先执行 BeforeRollback），不动同步游标
+func (p *Postgres) RollbackTx(ctx context.Context, tx *sqlx.Tx, fromBlock string) error {
+	if p.opts.BeforeRollback != nil {
+		if err := p.opts.BeforeRollback(ctx, tx, fromBlock); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM transfers WHERE block_number >= $1::NUMERIC", fromBlock); err != nil {
+		return fmt.Errorf("delete transfers: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM blocks WHERE number >= $1::NUMERIC", fromBlock); err != nil {
+		return fmt.Errorf("delete blocks: %w", err)
+	}
+	return nil
+}
+
+// Reset 清空所有索引数据、派生统计、字节码缓存与进度（开发链重启后同一地址的字节码可能不同）
+func (p *Postgres) Reset(ctx context.Context) error {
+	_, err := p.db.ExecContext(ctx, "TRUNCATE TABLE blocks, transfers, daily_stats, daily_token_stats, aggregate_block_deltas, addresses, token_supply_deltas, stablecoin_hourly_flows, arbitrage_events, receipts, nft_transfers, approvals, contract_events, blob_transactions, block_fees, skipped_ranges, address_code CASCADE; DELETE FROM sync_checkpoints; DELETE FROM aggregate_watermarks;")
+	return err
+}
+
+// GetMaxStoredBlock 已落盘的最高区块号（空表为 0）
+func (p *Postgres) GetMaxStoredBlock(ctx context.Context) (int64, error) {
+	var dbMax int64
+	err := p.opts.Get(ctx, p.db, "max_stored_block", &dbMax, "SELECT COALESCE(MAX(number), 0) FROM blocks")
+	return dbMax, err
+}
+
+// GetSyncCursor 该链的持久化同步游标（无检查点时为 0）
+func (p *Postgres) GetSyncCursor(ctx context.Context, chainID int64) (int64, error) {
+	var cursor string
+	err := p.opts.Get(ctx, p.db, "sync_cursor", &cursor,
+		"SELECT COALESCE((SELECT last_synced_block::TEXT FROM sync_checkpoints WHERE chain_id = $1), '0')", chainID)
+	if err != nil {
+		return 0, err
+	}
+	num, _ := new(big.Int).SetString(cursor, 10)
+	if num == nil {
+		return 0, nil
+	}
+	return num.Int64(), nil
+}
+
+// UpdateSyncCursor 强制移动该链的同步游标（sync_checkpoints 与 sync_status 同步更新）
+func (p *Postgres) UpdateSyncCursor(ctx context.Context, chainID, height int64) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint:errcheck // Rollback is standard practice, error is expected if commit succeeded
+
+	if err := updateCursorTx(ctx, tx, chainID, height); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetLatestBlocks 最近 limit 个区块（按高度倒序）
+func (p *Postgres) GetLatestBlocks(ctx context.Context, limit int) ([]BlockRow, error) {
+	var rows []BlockRow
+	err := p.opts.Select(ctx, p.db, "latest_blocks", &rows,
+		"SELECT number, hash, parent_hash, timestamp, processed_at FROM blocks ORDER BY number DESC LIMIT $1", limit)
+	return rows, err
+}
+
+// GetLatestTransfers 最近 limit 条转账（指定 activityTypes 时只返回这些活动类型）
+func (p *Postgres) GetLatestTransfers(ctx context.Context, limit int, activityTypes ...string) ([]TransferRow, error) {
+	var rows []TransferRow
+	if len(activityTypes) > 0 {
+		err := p.opts.Select(ctx, p.db, "latest_transfers_by_type", &rows,
+			"SELECT "+TransferColumns+" "+TransferFrom+" WHERE t.activity_type = ANY($1) ORDER BY t.block_number DESC, t.log_index DESC LIMIT $2",
+			activityTypes, limit)
+		return rows, err
+	}
+	err := p.opts.Select(ctx, p.db, "latest_transfers", &rows,
+		"SELECT "+TransferColumns+" "+TransferFrom+" ORDER BY t.block_number DESC, t.log_index DESC LIMIT $1", limit)
+	return rows, err
+}
+
+// GetTransfersInRange [fromBlock, toBlock] 区间内最近 limit 条转账（指定 activityTypes 时只返回这些活动类型）
+func (p *Postgres) GetTransfersInRange(ctx context.Context, fromBlock, toBlock string, limit int, activityTypes ...string) ([]TransferRow, error) {
+	rows := []TransferRow{}
+	if len(activityTypes) > 0 {
+		err := p.opts.Select(ctx, p.db, "transfers_range_by_type", &rows, `
+			SELECT `+TransferColumns+`
+			`+TransferFrom+`
+			WHERE t.block_number >= $1::NUMERIC AND t.block_number <= $2::NUMERIC AND t.activity_type = ANY($3)
+			ORDER BY t.block_number DESC, t.log_index DESC LIMIT $4`,
+			fromBlock, toBlock, activityTypes, limit)
+		return rows, err
+	}
+	err := p.opts.Select(ctx, p.db, "transfers_range", &rows, `
+		SELECT `+TransferColumns+`
+		`+TransferFrom+`
+		WHERE t.block_number >= $1::NUMERIC AND t.block_number <= $2::NUMERIC
+		ORDER BY t.block_number DESC, t.log_index DESC LIMIT $3`,
+		fromBlock, toBlock, limit)
+	return rows, err
+}
+
+// GetTransfersByTx 单笔交易内的全部转账（按 log_index 升序）
+func (p *Postgres) GetTransfersByTx(ctx context.Context, txHash string) ([]TransferRow, error) {
+	rows := []TransferRow{}
+	err := p.opts.Select(ctx, p.db, "transfers_by_tx", &rows,
+		"SELECT "+TransferColumns+" "+TransferFrom+" WHERE t.tx_hash = $1 ORDER BY t.log_index", txHash)
+	return rows, err
+}
+
+// GetBlockHash 已落盘区块的哈希（不存在时返回 sql.ErrNoRows）
+func (p *Postgres) GetBlockHash(ctx context.Context, number string) (string, error) {
+	var hash string
+	err := p.opts.Get(ctx, p.db, "block_hash", &hash, "SELECT hash FROM blocks WHERE number = $1::NUMERIC", number)
+	return hash, err
+}
+
+// GetBlockProcessedAt 区块落盘时间
+func (p *Postgres) GetBlockProcessedAt(ctx context.Context, number string) (time.Time, error) {
+	var processedAt time.Time
+	err := p.opts.Get(ctx, p.db, "block_processed_at", &processedAt, "SELECT processed_at FROM blocks WHERE number = $1::NUMERIC", number)
+	return processedAt, err
+}
+
+// UpdateTokenSymbol 回填尚无符号的转账记录
+func (p *Postgres) UpdateTokenSymbol(tokenAddress, symbol string) error {
+	_, err := p.db.Exec(`UPDATE transfers SET symbol = $1 WHERE token_address = $2 AND (symbol IS NULL OR symbol = '')`, symbol, tokenAddress)
+	return err
+}
+
+// UpdateTokenDecimals 预留方法，transfers 表没有 decimals 字段（精度存于 token_metadata）
+func (p *Postgres) UpdateTokenDecimals(_ string, _ uint8) error {
+	return nil
+}
+
+// SaveTokenMetadata 持久化代币元数据（L2 缓存）
+func (p *Postgres) SaveTokenMetadata(meta models.TokenMetadata, address string) error {
+	_, err := p.db.Exec(`
+		INSERT INTO token_metadata (address, symbol, decimals, name, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (address) DO UPDATE SET
+			symbol = EXCLUDED.symbol,
+			decimals = EXCLUDED.decimals,
+			name = EXCLUDED.name,
+			updated_at = NOW()`,
+		strings.ToLower(address), meta.Symbol, meta.Decimals, meta.Name)
+	return err
+}
+
+// LoadAllMetadata 加载全部已缓存的代币元数据（地址小写）
+func (p *Postgres) LoadAllMetadata() (map[string]models.TokenMetadata, error) {
+	var rows []struct {
+		Address  string `db:"address"`
+		Symbol   string `db:"symbol"`
+		Decimals uint8  `db:"decimals"`
+		Name     string `db:"name"`
+	}
+	// symbol 为空的行是垃圾代币标记先于元数据写入的占位行，不进入元数据缓存
+	if err := p.db.Select(&rows, "SELECT address, symbol, decimals, COALESCE(name, '') AS name FROM token_metadata WHERE symbol <> ''"); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]models.TokenMetadata, len(rows))
+	for _, row := range rows {
+		result[strings.ToLower(row.Address)] = models.TokenMetadata{
+			Symbol:   row.Symbol,
+			Decimals: row.Decimals,
+			Name:     row.Name,
+		}
+	}
+	return result, nil
+}
+
+// ListStaleTokens 列出元数据需要刷新的代币（地址小写）：已有交易但没有元数据记录的代币排在最前，
+// 其次是 symbol 未知、name 为空或 updated_at 早于 updatedBefore 的记录（按更新时间从旧到新）
+func (p *Postgres) ListStaleTokens(ctx context.Context, updatedBefore time.Time, limit int) ([]string, error) {
+	var addrs []string
+	err := p.db.SelectContext(ctx, &addrs, `
+		SELECT address FROM (
+			SELECT DISTINCT t.token_address AS address, NULL::TIMESTAMPTZ AS updated_at
+			FROM transfers t
+			WHERE t.token_address <> '0x0000000000000000000000000000000000000000'
+			  AND NOT EXISTS (SELECT 1 FROM token_metadata m WHERE m.address = t.token_address)
+			UNION ALL
+			SELECT address, updated_at FROM token_metadata
+			WHERE symbol IN ('', 'UNKNOWN') OR COALESCE(name, '') = ''
+			   OR updated_at IS NULL OR updated_at < $1
+		) stale
+		ORDER BY updated_at NULLS FIRST, address
+		LIMIT $2`, updatedBefore, limit)
+	return addrs, err
+}
+
+// MarkTokenSpam 记录启发式判定结果（元数据尚未抓取时插入占位行）；不改动管理员覆盖
+func (p *Postgres) MarkTokenSpam(ctx context.Context, address, reason string) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO token_metadata (address, symbol, name, is_spam, spam_reason, spam_marked_at)
+		VALUES ($1, '', '', TRUE, $2, NOW())
+		ON CONFLICT (address) DO UPDATE SET
+			is_spam = TRUE,
+			spam_reason = EXCLUDED.spam_reason,
+			spam_marked_at = NOW()`,
+		strings.ToLower(address), reason)
+	return err
+}
+
+// SetTokenSpamOverride 设置管理员覆盖（nil 清除覆盖，恢复跟随启发式）
+func (p *Postgres) SetTokenSpamOverride(ctx context.Context, address string, spam *bool) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO token_metadata (address, symbol, name, spam_override)
+		VALUES ($1, '', '', $2)
+		ON CONFLICT (address) DO UPDATE SET
+			spam_override = EXCLUDED.spam_override,
+			updated_at = NOW()`,
+		strings.ToLower(address), spam)
+	return err
+}
+
+// LoadSpamTokens 加载所有被判定为垃圾或带管理员覆盖的代币
+func (p *Postgres) LoadSpamTokens(ctx context.Context) ([]SpamTokenRow, error) {
+	rows := []SpamTokenRow{}
+	err := p.opts.Select(ctx, p.db, "spam_tokens", &rows, `
+		SELECT address, is_spam, COALESCE(spam_reason, '') AS spam_reason, spam_override,
+			COALESCE(spam_marked_at, updated_at, NOW()) AS marked_at
+		FROM token_metadata
+		WHERE is_spam OR spam_override IS NOT NULL`)
+	return rows, err
+}
+
+// LoadAddressCodes 批量读取已缓存的地址字节码长度
+func (p *Postgres) LoadAddressCodes(ctx context.Context, addresses []string) ([]AddressCodeRow, error) {
+	rows := []AddressCodeRow{}
+	if len(addresses) == 0 {
+		return rows, nil
+	}
+	err := p.opts.Select(ctx, p.db, "address_codes", &rows,
+		"SELECT address, code_size, checked_at FROM address_code WHERE address = ANY($1)", addresses)
+	return rows, err
+}
+
+// SaveAddressCodes 批量写入地址字节码长度（已存在则刷新），并同步地址注册表的 is_contract
+func (p *Postgres) SaveAddressCodes(ctx context.Context, rows []AddressCodeRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	addresses := make([]string, len(rows))
+	sizes := make([]int32, len(rows))
+	checked := make([]time.Time, len(rows))
+	for i, row := range rows {
+		addresses[i] = strings.ToLower(row.Address)
+		sizes[i] = int32(row.CodeSize) // #nosec G115 - EVM 字节码长度远小于 int32 上限
+		checked[i] = row.CheckedAt
+	}
+	_, err := p.db.ExecContext(ctx, `
+		WITH saved AS (
+			INSERT INTO address_code (address, code_size, checked_at)
+			SELECT * FROM UNNEST($1::VARCHAR[], $2::INTEGER[], $3::TIMESTAMPTZ[])
+			ON CONFLICT (address) DO UPDATE SET
+				code_size = EXCLUDED.code_size,
+				checked_at = EXCLUDED.checked_at
+			RETURNING address, code_size
+		)
+		UPDATE addresses a SET is_contract = saved.code_size > 0, updated_at = NOW()
+		FROM saved
+		WHERE a.address = saved.address AND a.is_contract IS DISTINCT FROM (saved.code_size > 0)`,
+		addresses, sizes, checked)
+	return err
+}
+
+// InsertSkippedRange 记录一段被跳过的区块区间，返回自增 ID
+func (p *Postgres) InsertSkippedRange(ctx context.Context, row SkippedRangeRow) (int64, error) {
+	var id int64
+	err := p.db.GetContext(ctx, &id, `
+		INSERT INTO skipped_ranges (from_block, to_block, reason, skipped_at)
+		VALUES ($1, $2, $3, $4) RETURNING id`,
+		row.FromBlock.String(), row.ToBlock.String(), row.Reason, row.SkippedAt)
+	return id, err
+}
+
+// ListSkippedRanges 最近 limit 条跳过区间（按记录时间倒序）
+func (p *Postgres) ListSkippedRanges(ctx context.Context, limit int) ([]SkippedRangeRow, error) {
+	rows := []SkippedRangeRow{}
+	err := p.opts.Select(ctx, p.db, "skipped_ranges", &rows, `
+		SELECT id, from_block, to_block, reason, skipped_at, backfilled_to, backfilled_at
+		FROM skipped_ranges ORDER BY id DESC LIMIT $1`, limit)
+	return rows, err
+}
+
+// NextPendingSkippedRange 最早记录且尚未补齐的跳过区间；没有时返回 nil, nil
+func (p *Postgres) NextPendingSkippedRange(ctx context.Context) (*SkippedRangeRow, error) {
+	var row SkippedRangeRow
+	err := p.db.GetContext(ctx, &row, `
+		SELECT id, from_block, to_block, reason, skipped_at, backfilled_to, backfilled_at
+		FROM skipped_ranges WHERE backfilled_at IS NULL ORDER BY id LIMIT 1`)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// unnormalizedTransfers 已知精度（token_metadata 中元数据已读取）但尚未写入 normalized_amount 的转账
+const unnormalizedTransfers = `
+	FROM transfers t JOIN token_metadata m ON m.address = t.token_address
+	WHERE t.normalized_amount IS NULL AND m.symbol <> ''`
+
+// CountUnnormalizedTransfers 待回填 normalized_amount 的转账数
+func (p *Postgres) CountUnnormalizedTransfers(ctx context.Context) (int64, error) {
+	var n int64
+	err := p.opts.Get(ctx, p.db, "count_unnormalized_transfers", &n, "SELECT COUNT(*)"+unnormalizedTransfers)
+	return n, err
+}
+
+// NormalizeTransferAmounts 按 token_metadata.decimals 为至多 limit 条转账写入 normalized_amount（amount / 10^decimals），
+// 按 id 升序推进，返回本批更新的行数（0 表示已全部补齐）
+func (p *Postgres) NormalizeTransferAmounts(ctx context.Context, limit int) (int64, error) {
+	res, err := p.db.ExecContext(ctx, `
+		WITH batch AS (
+			SELECT t.id, m.decimals`+unnormalizedTransfers+`
+			ORDER BY t.id
+			LIMIT $1
+		)
+		UPDATE transfers t SET normalized_amount = t.amount * power(10::NUMERIC, -b.decimals)
+		FROM batch b WHERE t.id = b.id`, limit)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// SeedWatchedAddresses 表为空时写入初始监控地址（已有记录则不做任何事，避免重启复活已删除的地址），返回写入行数
+func (p *Postgres) SeedWatchedAddresses(ctx context.Context, addresses []string, source string) (int64, error) {
+	if len(addresses) == 0 {
+		return 0, nil
+	}
+	res, err := p.db.ExecContext(ctx, `
+		INSERT INTO watched_addresses (address, source)
+		SELECT DISTINCT lower(a), $2 FROM unnest($1::text[]) AS a
+		WHERE NOT EXISTS (SELECT 1 FROM watched_addresses)
+		ON CONFLICT (address) DO NOTHING`,
+		addresses, source)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ListWatchedAddresses 全部监控地址（按加入时间排序）
+func (p *Postgres) ListWatchedAddresses(ctx context.Context) ([]WatchedAddressRow, error) {
+	rows := []WatchedAddressRow{}
+	err := p.opts.Select(ctx, p.db, "watched_addresses", &rows,
+		"SELECT address, label, source, added_at FROM watched_addresses ORDER BY added_at, address")
+	return rows, err
+}
+
+// AddWatchedAddress 加入监控地址并在同一语句中写入审计记录；已存在时返回 false（不覆盖原有标签，不记审计）
+func (p *Postgres) AddWatchedAddress(ctx context.Context, row WatchedAddressRow, actor string) (bool, error) {
+	res, err := p.db.ExecContext(ctx, `
+		WITH added AS (
+			INSERT INTO watched_addresses (address, label, source) VALUES (lower($1), $2, $3)
+			ON CONFLICT (address) DO NOTHING
+			RETURNING address, label
+		)
+		INSERT INTO watchlist_audit (action, address, label, actor)
+		SELECT 'add', address, label, $4 FROM added`,
+		row.Address, row.Label, row.Source, actor)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RemoveWatchedAddress 移除监控地址并在同一语句中写入审计记录；不存在时返回 false
+func (p *Postgres) RemoveWatchedAddress(ctx context.Context, address, actor string) (bool, error) {
+	res, err := p.db.ExecContext(ctx, `
+		WITH removed AS (
+			DELETE FROM watched_addresses WHERE address = lower($1)
+			RETURNING address, label
+		)
+		INSERT INTO watchlist_audit (action, address, label, actor)
+		SELECT 'remove', address, label, $2 FROM removed`,
+		address, actor)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListWatchlistAudit 最近的监控地址变更记录（新的在前）
+func (p *Postgres) ListWatchlistAudit(ctx context.Context, limit int) ([]WatchlistAuditRow, error) {
+	rows := []WatchlistAuditRow{}
+	err := p.opts.Select(ctx, p.db, "watchlist_audit", &rows,
+		"SELECT id, action, address, label, actor, changed_at FROM watchlist_audit ORDER BY id DESC LIMIT $1", limit)
+	return rows, err
+}
+
+// MarkSkippedRangeBackfilled 记录回填进度；done 时整段标记为已补齐
+func (p *Postgres) MarkSkippedRangeBackfilled(ctx context.Context, id int64, to models.Height, done bool) error {
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE skipped_ranges SET backfilled_to = $2,
+			backfilled_at = CASE WHEN $3 THEN NOW() ELSE NULL END
+		WHERE id = $1`,
+		id, to.String(), done)
+	return err
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// StmtCache 按 SQL 文本缓存预编译语句（热路径 INSERT 的 SQL 文本固定，一次 prepare 多次执行）。
+// database/sql 的 Stmt 在每个连接首次使用时 prepare 并在该连接上复用；
+// 事务内经 Tx.StmtContext 绑定到事务连接，已在该连接 prepare 过的语句不会重复 prepare
+type StmtCache struct {
+	db *sqlx.DB
+
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+
+	hits     atomic.Uint64
+	prepares atomic.Uint64
+}
+
+// StmtCacheStats 语句缓存命中统计（压测报告与 /metrics 使用）
+type StmtCacheStats struct {
+	Statements int    `json:"statements"`
+	Hits       uint64 `json:"hits"`
+	Prepares   uint64 `json:"prepares"`
+}
+
+// NewStmtCache 创建绑定到连接池的语句缓存
+func NewStmtCache(db *sqlx.DB) *StmtCache {
+	return &StmtCache{db: db, stmts: make(map[string]*sql.Stmt)}
+}
+
+// stmt 返回 query 对应的预编译语句，首次使用时在连接池上 prepare
+func (c *StmtCache) stmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.stmts[query]; ok {
+		c.hits.Add(1)
+		return s, nil
+	}
+	s, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	c.prepares.Add(1)
+	c.stmts[query] = s
+	return s, nil
+}
+
+// Tx 返回在事务 tx 内执行缓存语句的 Execer，可直接传给 InsertBlocksTx 等批量写入函数
+func (c *StmtCache) Tx(tx *sqlx.Tx) Execer {
+	return &stmtTxExecer{cache: c, tx: tx}
+}
+
+// Stats 返回当前缓存的语句数与命中 / prepare 次数
+func (c *StmtCache) Stats() StmtCacheStats {
+	c.mu.Lock()
+	n := len(c.stmts)
+	c.mu.Unlock()
+	return StmtCacheStats{Statements: n, Hits: c.hits.Load(), Prepares: c.prepares.Load()}
+}
+
+// Close 释放全部预编译语句（连接池关闭前调用）
+func (c *StmtCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var firstErr error
+	for query, s := range c.stmts {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(c.stmts, query)
+	}
+	return firstErr
+}
+
+type stmtTxExecer struct {
+	cache *StmtCache
+	tx    *sqlx.Tx
+}
+
+// ExecContext 事务绑定的语句随事务提交 / 回滚自动关闭，无需显式 Close
+func (e *stmtTxExecer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	s, err := e.cache.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return e.tx.StmtContext(ctx, s).ExecContext(ctx, args...)
+}
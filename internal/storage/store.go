@@ -0,0 +1,272 @@
+// Package storage 集中管理索引数据的读写 SQL。
+// 上层（engine / API / 工具）只依赖 Store 接口，不再各自拼写 blocks / transfers / sync_checkpoints 语句，
+// 以便替换后端（Postgres 之外的 ClickHouse / SQLite）或在测试中注入假实现。
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"web3-indexer-go/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Execer 可执行写语句的对象（*sqlx.DB 或 *sqlx.Tx）
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// QueryFunc 读查询执行器；op 为指标/日志中的操作名，默认实现直接调用 sqlx
+type QueryFunc func(ctx context.Context, db sqlx.QueryerContext, op string, dest interface{}, query string, args ...interface{}) error
+
+// RollbackHook 在回滚事务内、删除 blocks 之前调用，用于撤销派生表（如日统计）中 number >= fromBlock 的贡献
+type RollbackHook func(ctx context.Context, tx *sqlx.Tx, fromBlock string) error
+
+// BlockRow 区块列表行（API 展示用，timestamp 以字符串保留 NUMERIC 精度）
+type BlockRow struct {
+	Number      models.Height `db:"number"`
+	Hash        string        `db:"hash"`
+	ParentHash  string        `db:"parent_hash"`
+	Timestamp   string        `db:"timestamp"`
+	ProcessedAt time.Time     `db:"processed_at"`
+}
+
+// TransferRow 转账列表行；decimals 取自 token_metadata，元数据未补全时按 18 位
+type TransferRow struct {
+	ID           int            `db:"id" json:"id"`
+	BlockNumber  models.Height  `db:"block_number" json:"block_number"`
+	TxHash       string         `db:"tx_hash" json:"tx_hash"`
+	LogIndex     int            `db:"log_index" json:"log_index"`
+	FromAddress  string         `db:"from_address" json:"from_address"`
+	ToAddress    string         `db:"to_address" json:"to_address"`
+	Amount       models.Uint256 `db:"amount" json:"amount"`
+	TokenAddress string         `db:"token_address" json:"token_address"`
+	Symbol       string         `db:"symbol" json:"symbol"`
+	Type         string         `db:"activity_type" json:"type"`
+	Origin       string         `db:"origin" json:"origin,omitempty"` // Transfer 等价事件的来源标签
+	Decimals     uint8          `db:"decimals" json:"decimals"`
+}
+
+// SpamTokenRow 垃圾代币状态行（仅包含被启发式判定或被管理员覆盖的代币）
+type SpamTokenRow struct {
+	Address  string    `db:"address"`
+	IsSpam   bool      `db:"is_spam"`
+	Reason   string    `db:"spam_reason"`
+	Override *bool     `db:"spam_override"`
+	MarkedAt time.Time `db:"marked_at"`
+}
+
+// WatchedAddressRow watched_addresses 表的一行（地址为小写）
+type WatchedAddressRow struct {
+	Address string    `db:"address" json:"address"`
+	Label   string    `db:"label" json:"label,omitempty"`
+	Source  string    `db:"source" json:"source"`
+	AddedAt time.Time `db:"added_at" json:"added_at"`
+}
+
+// WatchlistAuditRow watchlist_audit 表的一行
+type WatchlistAuditRow struct {
+	ID        int64     `db:"id" json:"id"`
+	Action    string    `db:"action" json:"action"`
+	Address   string    `db:"address" json:"address"`
+	Label     string    `db:"label" json:"label,omitempty"`
+	Actor     string    `db:"actor" json:"actor"`
+	ChangedAt time.Time `db:"changed_at" json:"changed_at"`
+}
+
+// AddressCodeRow address_code 表的一行（CodeSize 为 0 表示外部账户）
+type AddressCodeRow struct {
+	Address   string    `db:"address"`
+	CodeSize  int       `db:"code_size"`
+	CheckedAt time.Time `db:"checked_at"`
+}
+
+// AddressSeenRow 一个批次内某地址的出现情况（IsContract 为 nil 表示尚未查询字节码）
+type AddressSeenRow struct {
+	Address    string
+	FirstSeen  uint64
+	LastSeen   uint64
+	TxCount    int64
+	IsContract *bool
+}
+
+// SkippedRangeRow skipped_ranges 表的一行：[FromBlock, ToBlock] 未经索引即被跳过
+type SkippedRangeRow struct {
+	ID        int64         `db:"id" json:"id"`
+	FromBlock models.Height `db:"from_block" json:"from_block"`
+	ToBlock   models.Height `db:"to_block" json:"to_block"`
+	Reason    string        `db:"reason" json:"reason"`
+	SkippedAt time.Time     `db:"skipped_at" json:"skipped_at"`
+
+	BackfilledTo *models.Height `db:"backfilled_to" json:"backfilled_to,omitempty"` // 回填进度，nil 表示尚未开始
+	BackfilledAt *time.Time     `db:"backfilled_at" json:"backfilled_at,omitempty"` // 整段补齐的时间
+}
+
+// Pending 区间尚未整段补齐
+func (r SkippedRangeRow) Pending() bool {
+	return r.BackfilledAt == nil
+}
+
+// NextBackfill 下一个待回填的区块
+func (r SkippedRangeRow) NextBackfill() uint64 {
+	if r.BackfilledTo == nil {
+		return uint64(r.FromBlock)
+	}
+	return uint64(*r.BackfilledTo) + 1
+}
+
+// SupplyDeltaRow 某代币在某区块内的铸造 / 销毁汇总
+type SupplyDeltaRow struct {
+	Token  string
+	Block  uint64
+	Minted *big.Int
+	Burned *big.Int
+	Mints  int
+	Burns  int
+}
+
+// ArbitrageEventRow 同块环形套利候选（利润为未扣除 Gas 的估算值，单位为利润代币原始单位）
+type ArbitrageEventRow struct {
+	Block        uint64
+	Sender       string
+	TxHashes     []string
+	Pools        []string
+	Swaps        int
+	ProfitToken  string
+	ProfitAmount *big.Int
+}
+
+// NFTTransferRow ERC-721 转账（地址均为小写，token_id 为十进制字符串）
+type NFTTransferRow struct {
+	BlockNumber models.Height `db:"block_number" json:"block_number"`
+	LogIndex    uint          `db:"log_index" json:"log_index"`
+	TxHash      string        `db:"tx_hash" json:"tx_hash"`
+	Collection  string        `db:"collection" json:"collection"`
+	FromAddress string        `db:"from_address" json:"from_address"`
+	ToAddress   string        `db:"to_address" json:"to_address"`
+	TokenID     string        `db:"token_id" json:"token_id"`
+}
+
+// 授权事件类型（approvals.kind）
+const (
+	ApprovalKindERC20  = "erc20"   // Approval(owner, spender, value)
+	ApprovalKindERC721 = "erc721"  // Approval(owner, approved, tokenId)：单个 token 的授权
+	ApprovalKindForAll = "for_all" // ApprovalForAll(owner, operator, approved)：ERC721 / ERC1155 整个合集的授权
+)
+
+// ApprovalRow 授权事件（地址均为小写；Amount / TokenID 为十进制字符串，不适用时为空）
+type ApprovalRow struct {
+	BlockNumber  models.Height `db:"block_number" json:"block_number"`
+	LogIndex     uint          `db:"log_index" json:"log_index"`
+	TxHash       string        `db:"tx_hash" json:"tx_hash"`
+	TokenAddress string        `db:"token_address" json:"token_address"`
+	Kind         string        `db:"kind" json:"kind"`
+	Owner        string        `db:"owner" json:"owner"`
+	Spender      string        `db:"spender" json:"spender"`
+	Amount       string        `db:"amount" json:"amount,omitempty"`     // erc20 授权额度
+	TokenID      string        `db:"token_id" json:"token_id,omitempty"` // erc721 被授权的 token
+	// Approved 授权是否生效：额度非零 / 被授权方非零地址 / ApprovalForAll 为 true；false 表示撤销
+	Approved bool `db:"approved" json:"approved"`
+}
+
+// ContractEventRow 按配置的事件签名（EVENT_SIGNATURES）通用解码的合约事件；
+// Args 为参数名 → 值的 JSON（整数为十进制字符串，地址 / 字节为小写十六进制，动态类型的 indexed 参数为其 keccak 哈希）
+type ContractEventRow struct {
+	BlockNumber     models.Height   `db:"block_number" json:"block_number"`
+	LogIndex        uint            `db:"log_index" json:"log_index"`
+	TxHash          string          `db:"tx_hash" json:"tx_hash"`
+	ContractAddress string          `db:"contract_address" json:"contract_address"`
+	EventName       string          `db:"event_name" json:"event_name"`
+	Topic0          string          `db:"topic0" json:"topic0"`
+	Args            json.RawMessage `db:"args" json:"args"`
+}
+
+// BlobTxRow EIP-4844 blob 交易（type 3）：BlobHashes 为 versioned hash（小写十六进制），随区块级联删除
+type BlobTxRow struct {
+	TxHash        string
+	Block         uint64
+	TxIndex       uint
+	BlobHashes    []string
+	BlobGas       uint64
+	BlobGasFeeCap *big.Int
+}
+
+// BlockFeeRow 区块 EIP-1559 费用统计：BurntFees = baseFee × gasUsed，PriorityFees 为小费总额，
+// Rewards 为 0, 5, …, 100 分位的 gas 加权小费（wei），随区块级联删除
+type BlockFeeRow struct {
+	Block        uint64
+	BurntFees    *big.Int
+	PriorityFees *big.Int
+	Rewards      []*big.Int
+	Estimated    bool
+}
+
+// ReceiptRow 交易回执摘要（回执抓取模式下写入，随区块级联删除）
+type ReceiptRow struct {
+	TxHash            string
+	Block             uint64
+	TxIndex           uint
+	Status            uint64 // 1 成功，0 失败（revert）
+	GasUsed           uint64
+	EffectiveGasPrice *big.Int
+	Fee               *big.Int // GasUsed × EffectiveGasPrice（wei）
+	ContractAddress   string   // 合约创建交易的新合约地址，否则为空
+}
+
+// Store 索引数据存储接口
+type Store interface {
+	// 写入
+	InsertBlocks(ctx context.Context, blocks []models.Block) error
+	InsertTransfers(ctx context.Context, transfers []models.Transfer) error
+	RollbackAbove(ctx context.Context, chainID, height int64) error
+	Reset(ctx context.Context) error
+
+	// 同步游标
+	GetMaxStoredBlock(ctx context.Context) (int64, error)
+	GetSyncCursor(ctx context.Context, chainID int64) (int64, error)
+	UpdateSyncCursor(ctx context.Context, chainID, height int64) error
+
+	// 读取
+	GetLatestBlocks(ctx context.Context, limit int) ([]BlockRow, error)
+	GetLatestTransfers(ctx context.Context, limit int, activityTypes ...string) ([]TransferRow, error)
+	GetTransfersInRange(ctx context.Context, fromBlock, toBlock string, limit int, activityTypes ...string) ([]TransferRow, error)
+	GetTransfersByTx(ctx context.Context, txHash string) ([]TransferRow, error)
+	GetBlockHash(ctx context.Context, number string) (string, error)
+	GetBlockProcessedAt(ctx context.Context, number string) (time.Time, error)
+
+	// 代币元数据
+	UpdateTokenSymbol(tokenAddress, symbol string) error
+	UpdateTokenDecimals(tokenAddress string, decimals uint8) error
+	SaveTokenMetadata(meta models.TokenMetadata, address string) error
+	LoadAllMetadata() (map[string]models.TokenMetadata, error)
+
+	// 垃圾代币
+	MarkTokenSpam(ctx context.Context, address, reason string) error
+	SetTokenSpamOverride(ctx context.Context, address string, spam *bool) error
+	LoadSpamTokens(ctx context.Context) ([]SpamTokenRow, error)
+
+	// 地址字节码缓存
+	LoadAddressCodes(ctx context.Context, addresses []string) ([]AddressCodeRow, error)
+	SaveAddressCodes(ctx context.Context, rows []AddressCodeRow) error
+
+	// 跳过区间
+	InsertSkippedRange(ctx context.Context, row SkippedRangeRow) (int64, error)
+	ListSkippedRanges(ctx context.Context, limit int) ([]SkippedRangeRow, error)
+	NextPendingSkippedRange(ctx context.Context) (*SkippedRangeRow, error)
+	MarkSkippedRangeBackfilled(ctx context.Context, id int64, to models.Height, done bool) error
+
+	// 金额精度回填
+	CountUnnormalizedTransfers(ctx context.Context) (int64, error)
+	NormalizeTransferAmounts(ctx context.Context, limit int) (int64, error)
+
+	// 监控地址
+	SeedWatchedAddresses(ctx context.Context, addresses []string, source string) (int64, error)
+	ListWatchedAddresses(ctx context.Context) ([]WatchedAddressRow, error)
+	AddWatchedAddress(ctx context.Context, row WatchedAddressRow, actor string) (bool, error)
+	RemoveWatchedAddress(ctx context.Context, address, actor string) (bool, error)
+	ListWatchlistAudit(ctx context.Context, limit int) ([]WatchlistAuditRow, error)
+}
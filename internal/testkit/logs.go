@@ -0,0 +1,133 @@
+package testkit
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// TransferTopic Transfer(address,address,uint256)，ERC20 与 ERC721 共用同一签名
+	TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	// ApprovalTopic Approval(address,address,uint256)
+	ApprovalTopic = crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))
+	// ApprovalForAllTopic ApprovalForAll(address,address,bool)，ERC721 与 ERC1155 共用同一签名
+	ApprovalForAllTopic = crypto.Keccak256Hash([]byte("ApprovalForAll(address,address,bool)"))
+
+	// DepositTopic WETH9 Deposit(address,uint256)
+	DepositTopic = crypto.Keccak256Hash([]byte("Deposit(address,uint256)"))
+	// WithdrawalTopic WETH9 Withdrawal(address,uint256)
+	WithdrawalTopic = crypto.Keccak256Hash([]byte("Withdrawal(address,uint256)"))
+
+	// WETH 主网 WETH9 合约地址
+	WETH = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+
+	// USDC 主网 USDC 合约地址（ERC20 日志的默认 token）
+	USDC = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+)
+
+// Address 由 label 与序号派生的确定性地址
+func Address(label string, i uint64) common.Address {
+	var seed [8]byte
+	binary.BigEndian.PutUint64(seed[:], i)
+	return common.BytesToAddress(crypto.Keccak256([]byte(label), seed[:]))
+}
+
+// TxHash 由区块高度与序号派生的确定性交易哈希
+func TxHash(block uint64, i uint64) common.Hash {
+	var seed [16]byte
+	binary.BigEndian.PutUint64(seed[:8], block)
+	binary.BigEndian.PutUint64(seed[8:], i)
+	return crypto.Keccak256Hash(seed[:])
+}
+
+// ERC20Transfer 标准 ERC20 Transfer 日志：from/to 在 topics，金额在 data（32 字节）
+func ERC20Transfer(token, from, to common.Address, amount *big.Int) types.Log {
+	return types.Log{
+		Address: token,
+		Topics:  []common.Hash{TransferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    common.LeftPadBytes(amount.Bytes(), 32),
+	}
+}
+
+// ERC721Transfer ERC721 Transfer 日志：tokenId 作为第三个 indexed topic，data 为空
+func ERC721Transfer(collection, from, to common.Address, tokenID *big.Int) types.Log {
+	return types.Log{
+		Address: collection,
+		Topics: []common.Hash{TransferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes()),
+			common.BigToHash(tokenID)},
+	}
+}
+
+// ERC20Approval 标准 ERC20 Approval 日志
+func ERC20Approval(token, owner, spender common.Address, amount *big.Int) types.Log {
+	return types.Log{
+		Address: token,
+		Topics:  []common.Hash{ApprovalTopic, common.BytesToHash(owner.Bytes()), common.BytesToHash(spender.Bytes())},
+		Data:    common.LeftPadBytes(amount.Bytes(), 32),
+	}
+}
+
+// ERC721Approval ERC721 单个 token 的 Approval 日志：tokenId 作为第三个 indexed topic，data 为空
+func ERC721Approval(collection, owner, approved common.Address, tokenID *big.Int) types.Log {
+	return types.Log{
+		Address: collection,
+		Topics: []common.Hash{ApprovalTopic, common.BytesToHash(owner.Bytes()), common.BytesToHash(approved.Bytes()),
+			common.BigToHash(tokenID)},
+	}
+}
+
+// ApprovalForAll ApprovalForAll 日志：approved 编码为 data 中的 32 字节布尔值
+func ApprovalForAll(collection, owner, operator common.Address, approved bool) types.Log {
+	flag := []byte{0}
+	if approved {
+		flag[0] = 1
+	}
+	return types.Log{
+		Address: collection,
+		Topics:  []common.Hash{ApprovalForAllTopic, common.BytesToHash(owner.Bytes()), common.BytesToHash(operator.Bytes())},
+		Data:    common.LeftPadBytes(flag, 32),
+	}
+}
+
+// WETHDeposit WETH9 Deposit 日志：dst 在 topics，数量在 data
+func WETHDeposit(weth, dst common.Address, wad *big.Int) types.Log {
+	return types.Log{
+		Address: weth,
+		Topics:  []common.Hash{DepositTopic, common.BytesToHash(dst.Bytes())},
+		Data:    common.LeftPadBytes(wad.Bytes(), 32),
+	}
+}
+
+// WETHWithdrawal WETH9 Withdrawal 日志：src 在 topics，数量在 data
+func WETHWithdrawal(weth, src common.Address, wad *big.Int) types.Log {
+	return types.Log{
+		Address: weth,
+		Topics:  []common.Hash{WithdrawalTopic, common.BytesToHash(src.Bytes())},
+		Data:    common.LeftPadBytes(wad.Bytes(), 32),
+	}
+}
+
+// InBlock 把日志定位到区块内：填充区块号、区块哈希、交易哈希与日志索引
+func InBlock(l types.Log, block *types.Block, index uint) types.Log {
+	l.BlockNumber = block.NumberU64()
+	l.BlockHash = block.Hash()
+	l.TxHash = TxHash(block.NumberU64(), uint64(index))
+	l.Index = index
+	return l
+}
+
+// TransferLogs 为区块生成 n 条 token 的 ERC20 转账（from/to 按高度与序号派生，金额 1000+i）
+func TransferLogs(block *types.Block, token common.Address, n int) []types.Log {
+	logs := make([]types.Log, n)
+	for i := range logs {
+		idx := uint64(i) // #nosec G115 - i is non-negative
+		seed := block.NumberU64()<<20 | idx
+		l := ERC20Transfer(token, Address("from", seed), Address("to", seed), big.NewInt(int64(1000+i)))
+		logs[i] = InBlock(l, block, uint(i))
+	}
+	return logs
+}
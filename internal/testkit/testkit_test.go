@@ -0,0 +1,74 @@
+package testkit
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain_LinksParentHashes(t *testing.T) {
+	blocks := Chain(100, 5)
+	require.Len(t, blocks, 5)
+	assert.Equal(t, Block(99, common.Hash{}).Hash(), blocks[0].ParentHash())
+	for i := 1; i < len(blocks); i++ {
+		assert.Equal(t, blocks[i-1].Hash(), blocks[i].ParentHash())
+		assert.Equal(t, blocks[i-1].NumberU64()+1, blocks[i].NumberU64())
+	}
+	assert.Equal(t, blocks[2].Hash(), Chain(100, 5)[2].Hash(), "fixtures must be deterministic")
+}
+
+func TestHeader_HashSurvivesJSONRoundTrip(t *testing.T) {
+	h := Header(42, common.HexToHash("0x1"))
+	raw, err := json.Marshal(h)
+	require.NoError(t, err)
+	var decoded types.Header
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, h.Hash(), decoded.Hash())
+}
+
+func TestReorgPairAndFork_DivergeAtSameHeight(t *testing.T) {
+	parent := Block(9, common.Hash{})
+	canonical, orphan := ReorgPair(10, parent.Hash())
+	assert.Equal(t, canonical.NumberU64(), orphan.NumberU64())
+	assert.Equal(t, canonical.ParentHash(), orphan.ParentHash())
+	assert.NotEqual(t, canonical.Hash(), orphan.Hash())
+
+	base := Chain(0, 3)
+	fork := Fork(base[1], 2)
+	assert.Equal(t, base[1].Hash(), fork[0].ParentHash())
+	assert.Equal(t, base[2].NumberU64(), fork[0].NumberU64())
+	assert.NotEqual(t, base[2].Hash(), fork[0].Hash())
+	assert.Equal(t, fork[0].Hash(), fork[1].ParentHash())
+}
+
+func TestBlockWithTxs_TxRootMatchesBody(t *testing.T) {
+	tx := types.NewTransaction(0, Address("to", 1), big.NewInt(1), 21000, big.NewInt(1), nil)
+	b := BlockWithTxs(5, common.Hash{}, []*types.Transaction{tx})
+	assert.Len(t, b.Transactions(), 1)
+	assert.NotEqual(t, types.EmptyTxsHash, b.TxHash())
+}
+
+func TestTransferLogs_ShapeAndPlacement(t *testing.T) {
+	b := Block(7, common.Hash{})
+	logs := TransferLogs(b, USDC, 3)
+	require.Len(t, logs, 3)
+	for i, l := range logs {
+		assert.Equal(t, TransferTopic, l.Topics[0])
+		assert.Len(t, l.Topics, 3)
+		assert.Len(t, l.Data, 32)
+		assert.Equal(t, uint64(7), l.BlockNumber)
+		assert.Equal(t, b.Hash(), l.BlockHash)
+		assert.Equal(t, uint(i), l.Index)
+	}
+	assert.NotEqual(t, logs[0].TxHash, logs[1].TxHash)
+
+	nft := ERC721Transfer(Address("nft", 0), Address("a", 0), Address("b", 0), big.NewInt(5))
+	assert.Len(t, nft.Topics, 4)
+	assert.Empty(t, nft.Data)
+	assert.Equal(t, big.NewInt(5), nft.Topics[3].Big())
+}
@@ -186,6 +186,10 @@ let isWSConnected = false;
 let reconnectInterval = 1000; // 初始重连 1s
 const MAX_RECONNECT_INTERVAL = 30000; // 最大重连 30s
 
+// 🔁 断线续传：服务端事件流标识与最后处理的事件序号，重连时作为 ?resume=<stream>:<seq> 上送
+let wsStream = null;
+let wsLastSeq = 0;
+
 const stateEl = document.getElementById('state');
 const healthEl = document.getElementById('health');
 
@@ -194,14 +198,56 @@ function updateSystemState(connectionLabel, colorClass, isPulse = false) {
     stateEl.innerHTML = `<span class="${colorClass} ${pulseClass}">${connectionLabel}</span>`;
 }
 
-function connectWS() {
+// 🔐 服务端启用 WS_AUTH_TOKEN 时，令牌通过页面地址 ?token= 传入并转发给 /ws
+// 🔁 withResume 为真且已建立过会话时附带续传令牌，由服务端回放断线期间的事件
+function buildWSUrl(withResume = false) {
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-    const wsUrl = protocol + '//' + window.location.host + '/ws';
+    const params = new URLSearchParams();
+    const token = new URLSearchParams(window.location.search).get('token');
+    if (token) params.set('token', token);
+    if (withResume && wsStream) params.set('resume', `${wsStream}:${wsLastSeq}`);
+    const query = params.toString();
+    return protocol + '//' + window.location.host + '/ws' + (query ? '?' + query : '');
+}
+
+// 🔁 处理会话与序号：返回 true 表示消息已消费或为重复事件，无需继续渲染
+function handleSequencing(raw) {
+    if (raw.type === 'session') {
+        wsStream = raw.data.stream;
+        if (raw.data.resumed) {
+            if (raw.data.replayed > 0) addLog(`🔁 Resumed stream, replaying ${raw.data.replayed} missed events`, 'info');
+        } else {
+            wsLastSeq = raw.data.seq;
+        }
+        return true;
+    }
+    if (raw.type === 'snapshot_required') {
+        // 断线期间的事件已无法回放：以 HTTP 快照为准，从服务端当前序号继续
+        wsStream = raw.data.stream;
+        wsLastSeq = raw.data.seq;
+        addLog(`🔁 Missed events unavailable (${raw.data.reason}), refreshing snapshot`, 'warn');
+        fetchData();
+        return true;
+    }
+    if (!raw.seq) return false;
+    if (raw.seq <= wsLastSeq) return true; // 回放与实时事件重叠
+    if (wsLastSeq > 0 && raw.seq > wsLastSeq + 1) {
+        // 服务端背压丢弃造成的缺口：刷新快照兜底
+        addLog(`⚠️ ${raw.seq - wsLastSeq - 1} live events missed, refreshing snapshot`, 'warn');
+        fetchData();
+    }
+    wsLastSeq = raw.seq;
+    return false;
+}
+
+function connectWS() {
+    const wsUrl = buildWSUrl(true);
     
     if (!isWSConnected) {
         updateSystemState('CONNECTING...', 'status-connecting');
     }
     
+    const resuming = wsStream !== null;
     ws = new WebSocket(wsUrl);
 
     ws.onopen = () => {
@@ -219,8 +265,8 @@ function connectWS() {
 
         resetIdleTimer(); // Initial activity
 
-        // 💡 架构升级：重连后自动补齐数据
-        fetchData();
+        // 💡 架构升级：首次连接拉取快照；重连由续传回放补齐，无法回放时服务端下发 snapshot_required
+        if (!resuming) fetchData();
         addLog('System connected. Streaming live data...', 'info');
     };
 
@@ -228,6 +274,7 @@ function connectWS() {
         try {
             const raw = JSON.parse(event.data);
             let msg = raw;
+            if (handleSequencing(raw)) return;
 
             // 🚀 Handle sleeping state from backend
             // 🛡️ 演示模式保护：忽略后端的休眠信号
@@ -377,7 +424,7 @@ function attemptWSRecovery() {
     addLog('🔄 尝试恢复 WebSocket 连接...', 'info');
     wsFailCount = 0; // 重置失败计数
 
-    const testWS = new WebSocket((window.location.protocol === 'https:' ? 'wss:' : 'ws:') + '//' + window.location.host + '/ws');
+    const testWS = new WebSocket(buildWSUrl());
 
     const testTimeout = setTimeout(() => {
         testWS.close();
@@ -658,6 +705,8 @@ function renderActivityIcon(type) {
         'TRANSFER':       '💸 <span style="color: #3b82f6;">Transfer</span>',
         'CONTRACT_EVENT': '📜 <span style="color: #94a3b8;">Contract Log</span>',
         'ETH_TRANSFER':   '⛽ <span style="color: #6366f1;">ETH Transfer</span>',
+        'WRAP':           '🎁 <span style="color: #0ea5e9;">Wrap</span>',
+        'UNWRAP':         '📤 <span style="color: #0ea5e9;">Unwrap</span>',
         'DEPLOY':         '🏗️ <span style="color: #f43f5e;">Deployment</span>',
         'FAUCET_CLAIM':   '🚰 <span style="color: #06b6d4; font-weight: bold;">Faucet Claim</span>'
     };
@@ -696,7 +745,7 @@ function updateTransfersTable(tx) {
     const symbol = tx.symbol || '';
     const type = tx.type || 'TRANSFER';
     const token = tx.token_address || '0xunknown';
-    const displayAmount = formatAmount(tx.amount || tx.value, 18); // 默认 18 位精度
+    const displayAmount = formatAmount(tx.amount || tx.value, tx.decimals ?? 18); // 服务端下发 token 精度，缺省 18 位
     
     // 🎨 Activity & Token Badge 渲染
     const activityDisplay = renderActivityIcon(type);
@@ -731,15 +780,18 @@ function updateGasLeaderboard(data) {
     list.innerHTML = data.map((item, index) => {
         const label = item.label || (item.address.substring(0, 10) + '...');
         const gasM = (item.total_gas / 1e6).toFixed(2);
+        // 估算值（按 Gas Limit）加 ~ 前缀，实际值来自交易回执
+        const approx = item.estimated ? '~' : '';
+        const basis = item.estimated ? 'Estimated from gas limit' : 'Actual gas used (receipts)';
         return `
             <div class="gas-item">
                 <div style="display: flex; align-items: center; overflow: hidden;">
                     <span class="gas-rank">#${index + 1}</span>
                     <span class="gas-label" title="${item.address}">${label}</span>
                 </div>
-                <div class="gas-stats">
-                    <div class="gas-amount">${gasM}M Gas</div>
-                    <div class="gas-fee">${item.total_fee} ETH</div>
+                <div class="gas-stats" title="${basis}">
+                    <div class="gas-amount">${approx}${gasM}M Gas</div>
+                    <div class="gas-fee">${approx}${item.total_fee} ETH</div>
                 </div>
             </div>
         `;
@@ -790,7 +842,7 @@ async function fetchData() {
                 const symbol = t.symbol || '';
                 const type = t.type || t.activity_type || 'TRANSFER';
                 const token = t.token_address || '0x...';
-                const displayAmount = formatAmount(t.amount || '0', 18); // 默认 18 位精度
+                const displayAmount = formatAmount(t.amount || '0', t.decimals ?? 18); // 服务端下发 token 精度，缺省 18 位
                 
                 // 🎨 Activity & Token Badge 渲染逻辑
                 const activityDisplay = renderActivityIcon(type);
@@ -2,21 +2,29 @@ package web
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/models"
 
 	"github.com/gorilla/websocket"
 )
 
 // WSEvent 定义发送到前端的消息结构
+// SchemaVersion 为 0 时由 Broadcast 填充为 models.EventSchemaVersion；
+// Seq 由 Hub 在广播时按事件流单调分配，客户端据此检测缺口并断线续传（控制事件不带序号）
 type WSEvent struct {
-	Data interface{} `json:"data"`
-	Type string      `json:"type"` // "block" or "transfer"
+	Data          interface{} `json:"data"`
+	Type          string      `json:"type"` // "block" or "transfer"
+	SchemaVersion int         `json:"schema_version"`
+	Seq           uint64      `json:"seq,omitempty"`
 }
 
 const (
@@ -24,6 +32,11 @@ const (
 	pongWait       = 60 * time.Second // 🔥 增加 Pong 等待时间（30s → 60s）
 	pingPeriod     = (pongWait * 9) / 10
 	maxMessageSize = 512
+
+	// defaultClientSendBuffer 每个客户端的发送缓冲（条）
+	defaultClientSendBuffer = 256
+	// defaultMaxConsecutiveDrops 连续丢弃多少条后判定为慢客户端并断开
+	defaultMaxConsecutiveDrops = 50
 )
 
 var upgrader = websocket.Upgrader{
@@ -31,26 +44,25 @@ var upgrader = websocket.Upgrader{
 	WriteBufferSize: 1024,
 	// 🔥 横滨实验室优化：增加写入缓冲区（防止高频数据阻塞）
 	HandshakeTimeout: 10 * time.Second, // 握手超时 10 秒
-	// 🚀 工业级安全保护：限制跨域请求，防止 CSRF/WebSocket Hijacking
-	CheckOrigin: func(r *http.Request) bool {
-		origin := r.Header.Get("Origin")
-		if origin == "" {
-			return true // 允许非浏览器请求
-		}
-
-		// 允许本地开发环境 (localhost/127.0.0.1 和 mp1 域名)
-		if strings.Contains(origin, "localhost") || strings.Contains(origin, "127.0.0.1") || strings.Contains(origin, "mp1") {
-			return true
-		}
-
-		// 允许指定的演示域名 (横滨实验室官方域名)
-		if strings.Contains(origin, "st6160.click") {
-			return true
-		}
+	// 🚀 工业级安全保护：限制跨域请求，防止 CSRF/WebSocket Hijacking（Hub.checkOrigin 在握手前已校验）
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
 
-		slog.Warn("🚫 [Security] Blocked unauthorized WebSocket origin", "origin", origin)
+// defaultOriginAllowed 未配置 WS_ALLOWED_ORIGINS 时的内置来源白名单
+// 按解析后的主机名精确匹配，避免 st6160.click.evil.com / localhost.attacker.io 之类的子串绕过
+func defaultOriginAllowed(origin string) bool {
+	u, err := url.Parse(strings.TrimSpace(origin))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
 		return false
-	},
+	}
+	host := strings.ToLower(u.Hostname())
+	switch host {
+	case "localhost", "127.0.0.1", "mp1": // 本地开发环境
+		return true
+	}
+
+	// 允许指定的演示域名 (横滨实验室官方域名及其子域名)
+	return host == "st6160.click" || strings.HasSuffix(host, ".st6160.click")
 }
 
 // Client 代表一个连接的前端用户
@@ -58,6 +70,12 @@ type Client struct {
 	hub  *Hub
 	conn *websocket.Conn
 	send chan []byte
+
+	resume string // 握手时携带的续传令牌 <stream>:<seq>，空表示新会话
+
+	// 背压统计（仅由 Hub.Run 协程读写）
+	consecutiveDrops int    // 连续丢弃数，成功投递一次即清零
+	dropped          uint64 // 累计丢弃数
 }
 
 // Hub 负责维护活跃连接和广播消息
@@ -69,6 +87,22 @@ type Hub struct {
 	logger     *slog.Logger
 	OnActivity func()            // 🚀 Activity callback for On-Demand logic
 	OnNeedMeta func(addr string) // 🎨 Metadata request callback
+
+	// 📡 背压：每客户端缓冲 + 连续丢弃上限（慢客户端不再拖垮广播，也不会因一次抖动被踢）
+	clientBuffer int
+	maxDrops     int
+	metrics      *engine.Metrics
+
+	// 🔐 握手准入：可选令牌、来源白名单与连接数上限
+	authToken      string
+	allowedOrigins map[string]bool // 规范化后的 scheme://host[:port]；为空时使用内置白名单
+	maxClients     int             // 0 表示不限
+	slots          atomic.Int64    // 已占用的连接名额（准入时预留，升级失败或客户端移除时释放）
+
+	// 🔁 断线续传：事件流标识、最新序号与回放缓冲（seq / replay 仅由 Run 协程读写）
+	stream string
+	seq    uint64
+	replay *replayRing
 }
 
 func NewHub() *Hub {
@@ -78,9 +112,146 @@ func NewHub() *Hub {
 		unregister: make(chan *Client),
 		clients:    make(map[*Client]bool),
 		logger:     engine.Logger,
+
+		clientBuffer: defaultClientSendBuffer,
+		maxDrops:     defaultMaxConsecutiveDrops,
+		metrics:      engine.GetMetrics(),
+
+		stream: newStreamID(),
+		replay: newReplayRing(defaultReplayBuffer),
+	}
+}
+
+// SetBackpressure 设置每客户端发送缓冲与慢客户端判定阈值（需在 Run 之前调用；<=0 保持默认）
+func (h *Hub) SetBackpressure(clientBuffer, maxConsecutiveDrops int) {
+	if clientBuffer > 0 {
+		h.clientBuffer = clientBuffer
+	}
+	if maxConsecutiveDrops > 0 {
+		h.maxDrops = maxConsecutiveDrops
+	}
+}
+
+// SetAccessControl 配置 /ws 握手准入（需在对外服务前调用）：
+// token 非空时要求 ?token= 或 Authorization: Bearer；origins 非空时替换内置来源白名单（"*" 表示任意来源）；
+// maxClients > 0 时超出上限的握手返回 503。
+func (h *Hub) SetAccessControl(token string, origins []string, maxClients int) {
+	h.authToken = token
+	h.allowedOrigins = nil
+	for _, o := range origins {
+		if o = normalizeOrigin(o); o != "" {
+			if h.allowedOrigins == nil {
+				h.allowedOrigins = make(map[string]bool)
+			}
+			h.allowedOrigins[o] = true
+		}
+	}
+	if maxClients > 0 {
+		h.maxClients = maxClients
 	}
 }
 
+// normalizeOrigin 统一为小写、去掉末尾斜杠，便于精确匹配
+func normalizeOrigin(origin string) string {
+	return strings.TrimRight(strings.ToLower(strings.TrimSpace(origin)), "/")
+}
+
+// checkOrigin 校验浏览器来源；非浏览器客户端不带 Origin，交由令牌校验把关
+func (h *Hub) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if h.allowedOrigins == nil {
+		return defaultOriginAllowed(origin)
+	}
+	return h.allowedOrigins["*"] || h.allowedOrigins[normalizeOrigin(origin)]
+}
+
+// checkToken 常量时间比较访问令牌；未配置令牌时放行
+func (h *Hub) checkToken(r *http.Request) bool {
+	if h.authToken == "" {
+		return true
+	}
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.authToken)) == 1
+}
+
+// admit 握手前准入检查；拒绝时写回 HTTP 错误并返回 false
+func (h *Hub) admit(w http.ResponseWriter, r *http.Request) bool {
+	reason, status := "", 0
+	switch {
+	case !h.checkOrigin(r):
+		reason, status = "origin", http.StatusForbidden
+	case !h.checkToken(r):
+		reason, status = "token", http.StatusUnauthorized
+	case !h.reserveSlot():
+		reason, status = "capacity", http.StatusServiceUnavailable
+	default:
+		return true
+	}
+	h.metrics.RecordWSRejected(reason)
+	h.logger.Warn("🚫 [Security] WebSocket handshake rejected",
+		slog.String("reason", reason),
+		slog.String("origin", r.Header.Get("Origin")),
+		slog.String("remote", r.RemoteAddr))
+	http.Error(w, http.StatusText(status), status)
+	return false
+}
+
+// reserveSlot 以 CAS 原子地预留一个连接名额，并发握手不会越过 maxClients
+func (h *Hub) reserveSlot() bool {
+	for {
+		n := h.slots.Load()
+		if h.maxClients > 0 && n >= int64(h.maxClients) {
+			return false
+		}
+		if h.slots.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// releaseSlot 归还 admit 预留的名额
+func (h *Hub) releaseSlot() {
+	h.slots.Add(-1)
+}
+
+// removeClient 将客户端移出集合、关闭发送通道并归还名额（仅在 Run 协程调用）
+func (h *Hub) removeClient(client *Client) {
+	delete(h.clients, client)
+	close(client.send)
+	h.releaseSlot()
+	h.metrics.SetWSConnectedClients(len(h.clients))
+}
+
+// deliver 非阻塞投递到客户端缓冲；缓冲满时丢弃并计数，连续丢弃达到阈值后断开该客户端
+func (h *Hub) deliver(client *Client, message []byte) {
+	select {
+	case client.send <- message:
+		client.consecutiveDrops = 0
+		return
+	default:
+	}
+
+	client.consecutiveDrops++
+	client.dropped++
+	evict := client.consecutiveDrops >= h.maxDrops
+	h.metrics.RecordWSDrop(evict)
+	if !evict {
+		return
+	}
+
+	h.removeClient(client)
+	h.logger.Warn("ws_slow_client_evicted",
+		slog.Int("consecutive_drops", client.consecutiveDrops),
+		slog.Uint64("total_dropped", client.dropped),
+		slog.Int("total_clients", len(h.clients)))
+}
+
 func (h *Hub) Run(ctx context.Context) {
 	h.logger.Info("websocket_hub_started")
 	for {
@@ -89,31 +260,42 @@ func (h *Hub) Run(ctx context.Context) {
 			h.logger.Info("websocket_hub_stopping")
 			// 优雅关闭：关闭所有客户端连接
 			for client := range h.clients {
-				close(client.send)
-				delete(h.clients, client)
+				h.removeClient(client)
 			}
 			return
 		case client := <-h.register:
 			h.clients[client] = true
+			h.metrics.SetWSConnectedClients(len(h.clients))
 			if h.OnActivity != nil {
 				h.OnActivity() // WebSocket connection is an activity
 			}
 			h.logger.Info("ws_client_connected", slog.Int("total_clients", len(h.clients)))
+			h.greet(client)
 
 		case client := <-h.unregister:
 			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send)
-				h.logger.Info("ws_client_disconnected", slog.Int("total_clients", len(h.clients)))
+				h.removeClient(client)
+				h.logger.Info("ws_client_disconnected",
+					slog.Int("total_clients", len(h.clients)),
+					slog.Uint64("dropped", client.dropped))
 			}
 
 		case event := <-h.broadcast:
-			// 序列化消息
+			// 分配序号并序列化；序列化失败不占用序号，保证回放缓冲内序号连续
+			ev, sequenced := event.(WSEvent)
+			if sequenced {
+				ev.Seq = h.seq + 1
+				event = ev
+			}
 			message, err := json.Marshal(event)
 			if err != nil {
 				h.logger.Error("ws_json_marshal_error", slog.String("error", err.Error()))
 				continue
 			}
+			if sequenced {
+				h.seq = ev.Seq
+				h.replay.push(ev.Seq, message)
+			}
 
 			// 广播给所有客户端
 			if len(h.clients) == 0 {
@@ -121,36 +303,44 @@ func (h *Hub) Run(ctx context.Context) {
 			}
 
 			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					h.logger.Warn("ws_client_blocked_dropping_client")
-					close(client.send)
-					delete(h.clients, client)
-				}
+				h.deliver(client, message)
 			}
 		}
 	}
 }
 
+// withSchemaVersion 为未声明版本的 WSEvent 填充当前载荷版本
+func withSchemaVersion(event interface{}) interface{} {
+	if ev, ok := event.(WSEvent); ok && ev.SchemaVersion == 0 {
+		ev.SchemaVersion = models.EventSchemaVersion
+		return ev
+	}
+	return event
+}
+
 // Broadcast 对外暴露的广播方法，非阻塞
 func (h *Hub) Broadcast(event interface{}) {
 	select {
-	case h.broadcast <- event:
+	case h.broadcast <- withSchemaVersion(event):
 	default:
 		// 如果 Hub 处理不过来，丢弃消息，保证 Indexer 核心不卡死
+		h.metrics.BroadcastDropped.Inc()
 		h.logger.Warn("ws_hub_blocked_dropping_message")
 	}
 }
 
 // HandleWS 处理 WebSocket 请求
 func (h *Hub) HandleWS(w http.ResponseWriter, r *http.Request) {
+	if !h.admit(w, r) {
+		return
+	}
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
+		h.releaseSlot()
 		h.logger.Error("ws_upgrade_failed", slog.String("error", err.Error()))
 		return
 	}
-	client := &Client{hub: h, conn: conn, send: make(chan []byte, 256)}
+	client := &Client{hub: h, conn: conn, send: make(chan []byte, h.clientBuffer), resume: r.URL.Query().Get("resume")}
 	client.hub.register <- client
 
 	// 启动写泵（发送消息给前端）
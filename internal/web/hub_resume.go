@@ -0,0 +1,169 @@
+package web
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultReplayBuffer Hub 保留用于断线续传的最近事件条数
+const defaultReplayBuffer = 4096
+
+// 续传失败原因（snapshot_required 事件的 reason）
+const (
+	resumeInvalidToken  = "invalid_token"  // 令牌格式错误
+	resumeStreamChanged = "stream_changed" // 服务端已重启，序号空间不同
+	resumeOutOfWindow   = "out_of_window"  // 断线期间的事件已滚出回放缓冲
+	resumeTooFarBehind  = "too_far_behind" // 缺失事件超过客户端发送缓冲，回放会立即触发背压
+)
+
+// WSSession 连接建立时下发的会话信息（type=session，不带序号）：
+// Stream 标识本进程的事件流，Seq 为当前最新序号；Resumed 表示已从续传点回放，客户端保留原有进度
+type WSSession struct {
+	Stream   string `json:"stream"`
+	Seq      uint64 `json:"seq"`
+	Resumed  bool   `json:"resumed"`
+	Replayed int    `json:"replayed"`
+}
+
+// WSSnapshotRequired 续传失败时下发（type=snapshot_required）：客户端应通过 HTTP 重新拉取快照，并从 Seq 继续计数
+type WSSnapshotRequired struct {
+	Reason string `json:"reason"`
+	Stream string `json:"stream"`
+	Seq    uint64 `json:"seq"`
+}
+
+// replayEntry 一条已序列化的广播事件
+type replayEntry struct {
+	seq     uint64
+	message []byte
+}
+
+// replayRing 最近广播事件的环形缓冲；序号连续，仅由 Hub.Run 协程读写
+type replayRing struct {
+	entries []replayEntry
+	start   int
+	size    int
+}
+
+func newReplayRing(capacity int) *replayRing {
+	return &replayRing{entries: make([]replayEntry, capacity)}
+}
+
+func (r *replayRing) push(seq uint64, message []byte) {
+	if len(r.entries) == 0 {
+		return
+	}
+	if r.size < len(r.entries) {
+		r.entries[(r.start+r.size)%len(r.entries)] = replayEntry{seq: seq, message: message}
+		r.size++
+		return
+	}
+	r.entries[r.start] = replayEntry{seq: seq, message: message}
+	r.start = (r.start + 1) % len(r.entries)
+}
+
+// since 返回序号大于 seq 的事件；head 为当前最新序号。缺失部分已滚出缓冲时返回 false
+func (r *replayRing) since(seq, head uint64) ([]replayEntry, bool) {
+	if seq > head {
+		return nil, false
+	}
+	if seq == head {
+		return nil, true
+	}
+	if r.size == 0 || r.entries[r.start].seq > seq+1 {
+		return nil, false
+	}
+	out := make([]replayEntry, 0, head-seq)
+	for i := 0; i < r.size; i++ {
+		e := r.entries[(r.start+i)%len(r.entries)]
+		if e.seq > seq {
+			out = append(out, e)
+		}
+	}
+	return out, true
+}
+
+// newStreamID 进程级事件流标识；重启后变化，旧令牌随之失效
+func newStreamID() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36)
+}
+
+// parseResumeToken 解析 ?resume=<stream>:<seq>
+func parseResumeToken(token string) (stream string, seq uint64, ok bool) {
+	stream, rawSeq, found := strings.Cut(token, ":")
+	if !found || stream == "" {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseUint(rawSeq, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return stream, seq, true
+}
+
+// SetReplayBuffer 设置断线续传回放缓冲条数（需在 Run 之前调用；<=0 保持默认）
+func (h *Hub) SetReplayBuffer(n int) {
+	if n > 0 {
+		h.replay = newReplayRing(n)
+	}
+}
+
+// resolveResume 计算续传需回放的事件；不能续传时返回原因
+func (h *Hub) resolveResume(c *Client) ([]replayEntry, string) {
+	stream, seq, ok := parseResumeToken(c.resume)
+	switch {
+	case !ok:
+		return nil, resumeInvalidToken
+	case stream != h.stream:
+		return nil, resumeStreamChanged
+	}
+	entries, ok := h.replay.since(seq, h.seq)
+	if !ok {
+		return nil, resumeOutOfWindow
+	}
+	// 会话事件占一个缓冲位
+	if len(entries)+1 > cap(c.send) {
+		return nil, resumeTooFarBehind
+	}
+	return entries, ""
+}
+
+// greet 新连接注册后下发会话信息；携带续传令牌时回放断线期间的事件，无法回放则要求客户端刷新快照。
+// 在 Run 协程中与广播串行执行，回放与后续实时事件之间不会遗漏或重复
+func (h *Hub) greet(c *Client) {
+	session := WSSession{Stream: h.stream, Seq: h.seq}
+	var entries []replayEntry
+	reason := ""
+	if c.resume != "" {
+		entries, reason = h.resolveResume(c)
+		session.Resumed = reason == ""
+		session.Replayed = len(entries)
+	}
+	h.sendControl(c, WSEvent{Type: "session", Data: session})
+	if c.resume == "" {
+		return
+	}
+	if reason != "" {
+		h.metrics.RecordWSResume("snapshot")
+		h.logger.Info("ws_resume_snapshot_required", "reason", reason, "token", c.resume, "head_seq", h.seq)
+		h.sendControl(c, WSEvent{Type: "snapshot_required", Data: WSSnapshotRequired{Reason: reason, Stream: h.stream, Seq: h.seq}})
+		return
+	}
+	h.metrics.RecordWSResume("replayed")
+	h.logger.Info("ws_resume_replayed", "replayed", len(entries), "head_seq", h.seq)
+	for _, e := range entries {
+		h.deliver(c, e.message)
+	}
+}
+
+// sendControl 投递不带序号、不进入回放缓冲的控制事件
+func (h *Hub) sendControl(c *Client, event WSEvent) {
+	message, err := json.Marshal(withSchemaVersion(event))
+	if err != nil {
+		h.logger.Error("ws_json_marshal_error", "error", err.Error())
+		return
+	}
+	h.deliver(c, message)
+}
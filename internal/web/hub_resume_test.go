@@ -0,0 +1,162 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayRing_Since(t *testing.T) {
+	// 容量 4，写入 1..6 后环已回绕，缓冲内为 3..6
+	wrapped := newReplayRing(4)
+	for seq := uint64(1); seq <= 6; seq++ {
+		wrapped.push(seq, []byte(fmt.Sprint(seq)))
+	}
+	partial := newReplayRing(4)
+	partial.push(1, []byte("1"))
+	partial.push(2, []byte("2"))
+
+	tests := []struct {
+		name string
+		ring *replayRing
+		seq  uint64
+		head uint64
+		want []uint64
+		ok   bool
+	}{
+		{name: "up_to_date", ring: wrapped, seq: 6, head: 6, want: nil, ok: true},
+		{name: "ahead_of_head", ring: wrapped, seq: 7, head: 6, ok: false},
+		{name: "wrapped_oldest_missing", ring: wrapped, seq: 2, head: 6, want: []uint64{3, 4, 5, 6}, ok: true},
+		{name: "wrapped_tail", ring: wrapped, seq: 4, head: 6, want: []uint64{5, 6}, ok: true},
+		{name: "out_of_window", ring: wrapped, seq: 1, head: 6, ok: false},
+		{name: "not_full", ring: partial, seq: 0, head: 2, want: []uint64{1, 2}, ok: true},
+		{name: "empty_up_to_date", ring: newReplayRing(4), seq: 0, head: 0, ok: true},
+		{name: "empty_behind", ring: newReplayRing(4), seq: 0, head: 3, ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, ok := tt.ring.since(tt.seq, tt.head)
+			assert.Equal(t, tt.ok, ok)
+			var got []uint64
+			for _, e := range entries {
+				got = append(got, e.seq)
+				assert.Equal(t, fmt.Sprint(e.seq), string(e.message))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// 容量为 0 时不保留任何事件
+	disabled := newReplayRing(0)
+	disabled.push(1, []byte("1"))
+	_, ok := disabled.since(0, 1)
+	assert.False(t, ok)
+}
+
+func TestParseResumeToken(t *testing.T) {
+	tests := []struct {
+		token  string
+		stream string
+		seq    uint64
+		ok     bool
+	}{
+		{token: "m1x2y3:42", stream: "m1x2y3", seq: 42, ok: true},
+		{token: "m1x2y3:0", stream: "m1x2y3", seq: 0, ok: true},
+		{token: "m1x2y3:18446744073709551615", stream: "m1x2y3", seq: 18446744073709551615, ok: true},
+		{token: ""},
+		{token: "m1x2y3"},
+		{token: ":42"},
+		{token: "m1x2y3:"},
+		{token: "m1x2y3:-1"},
+		{token: "m1x2y3:1:2"},
+		{token: "m1x2y3:18446744073709551616"},
+	}
+	for _, tt := range tests {
+		stream, seq, ok := parseResumeToken(tt.token)
+		assert.Equal(t, tt.ok, ok, tt.token)
+		assert.Equal(t, tt.stream, stream, tt.token)
+		assert.Equal(t, tt.seq, seq, tt.token)
+	}
+}
+
+// decodeData 把事件载荷解码为具体类型
+func decodeData(t *testing.T, ev WSEvent, out interface{}) {
+	t.Helper()
+	raw, err := json.Marshal(ev.Data)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(raw, out))
+}
+
+// readSession 读取连接建立时的 session 事件
+func readSession(t *testing.T, conn *websocket.Conn) WSSession {
+	t.Helper()
+	ev := readEvent(t, conn)
+	require.Equal(t, "session", ev.Type)
+	var session WSSession
+	decodeData(t, ev, &session)
+	return session
+}
+
+func TestHub_Resume(t *testing.T) {
+	h := NewHub()
+	h.SetReplayBuffer(4)
+	h.SetBackpressure(4, 0)
+	srv := startHub(t, h)
+
+	// 观察者连接逐条读到广播，确认 Hub 已处理完毕后再重连
+	observer, _, err := dialWS(t, srv, "", nil)
+	require.NoError(t, err)
+	stream := readSession(t, observer).Stream
+	broadcast := func(from, to uint64) {
+		for n := from; n <= to; n++ {
+			h.Broadcast(WSEvent{Type: "block", Data: map[string]uint64{"number": n}})
+		}
+		for n := from; n <= to; n++ {
+			assert.Equal(t, n, readEvent(t, observer).Seq)
+		}
+	}
+	broadcast(1, 3)
+
+	// 回放断线期间的事件
+	conn, _, err := dialWS(t, srv, fmt.Sprintf("resume=%s:1", stream), nil)
+	require.NoError(t, err)
+	session := readSession(t, conn)
+	assert.Equal(t, WSSession{Stream: stream, Seq: 3, Resumed: true, Replayed: 2}, session)
+	assert.Equal(t, uint64(2), readEvent(t, conn).Seq)
+	assert.Equal(t, uint64(3), readEvent(t, conn).Seq)
+	// 回放之后紧接实时事件，不重复不遗漏
+	broadcast(4, 4)
+	assert.Equal(t, uint64(4), readEvent(t, conn).Seq)
+
+	// 已是最新：续传成功但无需回放
+	conn, _, err = dialWS(t, srv, fmt.Sprintf("resume=%s:4", stream), nil)
+	require.NoError(t, err)
+	assert.Equal(t, WSSession{Stream: stream, Seq: 4, Resumed: true}, readSession(t, conn))
+
+	broadcast(5, 7) // 缓冲内为 4..7
+
+	for _, tt := range []struct {
+		token  string
+		reason string
+	}{
+		{token: "garbage", reason: resumeInvalidToken},
+		{token: "previous-process:3", reason: resumeStreamChanged},
+		{token: stream + ":2", reason: resumeOutOfWindow},
+		{token: stream + ":3", reason: resumeTooFarBehind}, // 4 条回放 + session 超过 4 条发送缓冲
+	} {
+		conn, _, err := dialWS(t, srv, "resume="+tt.token, nil)
+		require.NoError(t, err, tt.token)
+		session := readSession(t, conn)
+		assert.False(t, session.Resumed, tt.token)
+		ev := readEvent(t, conn)
+		require.Equal(t, "snapshot_required", ev.Type, tt.token)
+		assert.Zero(t, ev.Seq, "control events are not sequenced")
+		var snapshot WSSnapshotRequired
+		decodeData(t, ev, &snapshot)
+		assert.Equal(t, WSSnapshotRequired{Reason: tt.reason, Stream: stream, Seq: 7}, snapshot, tt.token)
+	}
+}
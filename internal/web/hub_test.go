@@ -0,0 +1,179 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startHub 启动 Hub 与承载 /ws 的测试服务器，测试结束时一并关闭
+func startHub(t *testing.T, h *Hub) *httptest.Server {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	go h.Run(ctx)
+	srv := httptest.NewServer(http.HandlerFunc(h.HandleWS))
+	t.Cleanup(func() {
+		srv.Close()
+		cancel()
+	})
+	return srv
+}
+
+// dialWS 以指定查询串与请求头握手；失败时 resp 携带拒绝状态码
+func dialWS(t *testing.T, srv *httptest.Server, query string, header http.Header) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	if query != "" {
+		url += "?" + query
+	}
+	conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+	if resp != nil {
+		_ = resp.Body.Close()
+	}
+	if conn != nil {
+		t.Cleanup(func() { _ = conn.Close() })
+	}
+	return conn, resp, err
+}
+
+// readEvent 读取一条服务端事件
+func readEvent(t *testing.T, conn *websocket.Conn) WSEvent {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	_, message, err := conn.ReadMessage()
+	require.NoError(t, err)
+	var ev WSEvent
+	require.NoError(t, json.Unmarshal(message, &ev))
+	return ev
+}
+
+// requireRejected 断言握手被拒绝且返回指定状态码
+func requireRejected(t *testing.T, status int, resp *http.Response, err error) {
+	t.Helper()
+	require.ErrorIs(t, err, websocket.ErrBadHandshake)
+	require.NotNil(t, resp)
+	assert.Equal(t, status, resp.StatusCode)
+}
+
+func TestHub_TokenAuth(t *testing.T) {
+	h := NewHub()
+	h.SetAccessControl("s3cret", nil, 0)
+	srv := startHub(t, h)
+
+	_, resp, err := dialWS(t, srv, "", nil)
+	requireRejected(t, http.StatusUnauthorized, resp, err)
+	_, resp, err = dialWS(t, srv, "token=wrong", nil)
+	requireRejected(t, http.StatusUnauthorized, resp, err)
+	_, resp, err = dialWS(t, srv, "", http.Header{"Authorization": {"Bearer wrong"}})
+	requireRejected(t, http.StatusUnauthorized, resp, err)
+
+	conn, _, err := dialWS(t, srv, "token=s3cret", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "session", readEvent(t, conn).Type)
+
+	conn, _, err = dialWS(t, srv, "", http.Header{"Authorization": {"Bearer s3cret"}})
+	require.NoError(t, err)
+	assert.Equal(t, "session", readEvent(t, conn).Type)
+}
+
+func TestHub_OriginAllowList(t *testing.T) {
+	h := NewHub()
+	h.SetAccessControl("", []string{" https://App.Example.com/ "}, 0)
+	srv := startHub(t, h)
+
+	for _, origin := range []string{"https://app.example.com", "HTTPS://APP.EXAMPLE.COM/", ""} {
+		header := http.Header{}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		_, _, err := dialWS(t, srv, "", header)
+		assert.NoError(t, err, "origin %q", origin)
+	}
+	for _, origin := range []string{"https://evil.example", "http://app.example.com", "http://localhost:3000"} {
+		_, resp, err := dialWS(t, srv, "", http.Header{"Origin": {origin}})
+		requireRejected(t, http.StatusForbidden, resp, err)
+	}
+
+	// 未配置白名单时使用内置列表；"*" 放行任意来源
+	assert.True(t, NewHub().checkOrigin(&http.Request{Header: http.Header{"Origin": {"http://localhost:3000"}}}))
+	assert.False(t, NewHub().checkOrigin(&http.Request{Header: http.Header{"Origin": {"https://evil.example"}}}))
+	for origin, allowed := range map[string]bool{
+		"https://st6160.click":           true,
+		"https://demo.st6160.click:8443": true,
+		"http://127.0.0.1:8080":          true,
+		"https://st6160.click.evil.com":  false,
+		"https://evilst6160.click":       false,
+		"http://localhost.attacker.io":   false,
+		"http://127.0.0.1.nip.io":        false,
+		"null":                           false,
+	} {
+		assert.Equal(t, allowed, defaultOriginAllowed(origin), "origin %q", origin)
+	}
+	h = NewHub()
+	h.SetAccessControl("", []string{"*"}, 0)
+	assert.True(t, h.checkOrigin(&http.Request{Header: http.Header{"Origin": {"https://evil.example"}}}))
+}
+
+func TestHub_MaxClientsAndConnectedGauge(t *testing.T) {
+	h := NewHub()
+	h.SetAccessControl("", nil, 2)
+	srv := startHub(t, h)
+	gauge := func() float64 { return testutil.ToFloat64(h.metrics.WSConnectedClients) }
+
+	first, _, err := dialWS(t, srv, "", nil)
+	require.NoError(t, err)
+	second, _, err := dialWS(t, srv, "", nil)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return h.slots.Load() == 2 }, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, float64(2), gauge())
+
+	_, resp, err := dialWS(t, srv, "", nil)
+	requireRejected(t, http.StatusServiceUnavailable, resp, err)
+
+	require.NoError(t, first.Close())
+	require.Eventually(t, func() bool { return h.slots.Load() == 1 }, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, float64(1), gauge())
+
+	// 断开后释放名额
+	_, _, err = dialWS(t, srv, "", nil)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return h.slots.Load() == 2 }, 2*time.Second, 5*time.Millisecond)
+
+	require.NoError(t, second.Close())
+	require.Eventually(t, func() bool { return gauge() == 1 }, 2*time.Second, 5*time.Millisecond)
+}
+
+// TestHub_MaxClientsConcurrentHandshakes 并发握手时名额原子预留，成功数不超过上限
+func TestHub_MaxClientsConcurrentHandshakes(t *testing.T) {
+	const limit, dialers = 3, 20
+	h := NewHub()
+	h.SetAccessControl("", nil, limit)
+	srv := startHub(t, h)
+
+	var wg sync.WaitGroup
+	var admitted atomic.Int64
+	for range dialers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := dialWS(t, srv, "", nil); err == nil {
+				admitted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(limit), admitted.Load())
+	assert.Equal(t, int64(limit), h.slots.Load())
+}
@@ -2,8 +2,6 @@ package web
 
 import (
 	"context"
-	"encoding/json"
-	"log/slog"
 	"sync"
 	"time"
 )
@@ -115,22 +113,13 @@ func (h *ThrottledHub) flushAggregatedEvents() {
 		"aggregated_to", len(aggregated),
 		"total_batches", h.aggregatedBatches)
 
-	// 批量广播
+	// 批量广播：交给基础 Hub 的 Run 协程投递（clients 只在该协程内访问，背压与慢客户端剔除同样生效）
 	for _, event := range aggregated {
-		message, err := json.Marshal(event)
-		if err != nil {
-			h.logger.Error("ws_json_marshal_error", slog.String("error", err.Error()))
-			continue
-		}
-
-		// 使用基础 Hub 的广播逻辑（避免重复代码）
-		for client := range h.Hub.clients {
-			select {
-			case client.send <- message:
-			default:
-				// 客户端阻塞，丢弃
-				h.droppedEvents++
-			}
+		select {
+		case h.Hub.broadcast <- withSchemaVersion(event):
+		default:
+			h.droppedEvents++
+			h.metrics.BroadcastDropped.Inc()
 		}
 	}
 
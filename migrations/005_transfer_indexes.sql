@@ -0,0 +1,12 @@
+-- migrations/005_transfer_indexes.sql
+-- Secondary indexes for the /api/transfers, /api/search and /api/stats filters.
+-- Address / token / type lookups are always ordered by block_number DESC, so the
+-- composite indexes serve both the filter and the ORDER BY ... LIMIT without a sort.
+
+CREATE INDEX IF NOT EXISTS idx_transfers_from_block ON transfers(from_address, block_number DESC);
+CREATE INDEX IF NOT EXISTS idx_transfers_to_block ON transfers(to_address, block_number DESC);
+CREATE INDEX IF NOT EXISTS idx_transfers_token_block ON transfers(token_address, block_number DESC);
+CREATE INDEX IF NOT EXISTS idx_transfers_activity_block ON transfers(activity_type, block_number DESC);
+
+-- from_ts / to_ts windows are resolved to a block range through blocks.timestamp
+CREATE INDEX IF NOT EXISTS idx_blocks_timestamp ON blocks(timestamp);
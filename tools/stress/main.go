@@ -2,18 +2,52 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"math/big"
+	"os"
 	"sync/atomic"
 	"time"
 
 	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/testkit"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
 func main() {
+	profile := flag.String("profile", "cpu", "stress profile: cpu (parsing only) | persist (Sequencer → Processor → AsyncWriter → Postgres)")
+	duration := flag.Duration("duration", 30*time.Second, "load injection duration (persist profile)")
+	rate := flag.Int("rate", 0, "target blocks per second, 0 = unthrottled (persist profile)")
+	logsPerBlock := flag.Int("logs", 50, "ERC20 Transfer logs per block (persist profile)")
+	reset := flag.Bool("reset", false, "TRUNCATE blocks/transfers before the run (persist profile)")
+	stmtCache := flag.Bool("stmt-cache", true, "reuse prepared INSERT statements in the AsyncWriter; compare runs with -stmt-cache=false (persist profile)")
+	flag.Parse()
+
+	switch *profile {
+	case "cpu":
+		runCPUProfile()
+	case "persist":
+		if err := runPersistProfile(persistOptions{
+			DSN:          os.Getenv("DATABASE_URL"),
+			Duration:     *duration,
+			Rate:         *rate,
+			LogsPerBlock: *logsPerBlock,
+			Reset:        *reset,
+			StmtCache:    *stmtCache,
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Persist stress failed: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown profile %q\n", *profile)
+		os.Exit(2)
+	}
+}
+
+// runCPUProfile 纯内存解析压测（不含持久化）
+func runCPUProfile() {
 	fmt.Println("🚀 Initializing Ultra-High Speed Stress Tester (Target: 1000+ TPS)")
 
 	// Use a mock logger to avoid I/O bottlenecks during stress test
@@ -42,23 +76,24 @@ func main() {
 		}
 	}()
 
+	// 200 txs per block（交易体复用，交易根每块重新计算）
+	mockTxs := make([]*types.Transaction, 200)
+	for i := range mockTxs {
+		mockTxs[i] = types.NewTransaction(uint64(i), testkit.Address("to", uint64(i)), big.NewInt(0), 21000, big.NewInt(1), nil) // #nosec G115 - i is non-negative
+	}
+	var parent common.Hash
+
 	// 🚀 Main Stress Loop
 	for i := 0; i < 1000000; i++ {
 		blockNum := big.NewInt(int64(i))
-		_ = generateMockTransactions(200) // 200 txs per block
-
-		block := types.NewBlockWithHeader(&types.Header{
-			Number: blockNum,
-			// #nosec G115 - Stress test time conversion
-			Time:       uint64(time.Now().Unix()),
-			ParentHash: common.HexToHash("0x123"),
-		})
+		block := testkit.BlockWithTxs(uint64(i), parent, mockTxs) // #nosec G115 - i is non-negative
+		parent = block.Hash()
 
 		// Simulate data arriving from fetcher
 		_ = engine.BlockData{
 			Block:  block,
 			Number: blockNum,
-			Logs:   generateMockLogs(50), // 50 logs per block
+			Logs:   testkit.TransferLogs(block, testkit.USDC, 50), // 50 logs per block
 		}
 
 		// We increment count to simulate "work done"
@@ -77,23 +112,3 @@ func main() {
 	fmt.Printf("🏁 Stress Test Completed!\nTotal Blocks: %d\nTotal Time: %v\nAverage TPS: %.2f\n",
 		count.Load(), totalTime, float64(count.Load())/totalTime.Seconds())
 }
-
-func generateMockTransactions(n int) types.Transactions {
-	txs := make(types.Transactions, n)
-	for i := 0; i < n; i++ {
-		txs[i] = types.NewTransaction(uint64(i), common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
-	}
-	return txs
-}
-
-func generateMockLogs(n int) []types.Log {
-	logs := make([]types.Log, n)
-	for i := 0; i < n; i++ {
-		logs[i] = types.Log{
-			Address: common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
-			Topics:  []common.Hash{common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")}, // Transfer
-			Data:    common.Hex2Bytes("0000000000000000000000000000000000000000000000000000000000000001"),
-		}
-	}
-	return logs
-}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"web3-indexer-go/internal/database"
+	"web3-indexer-go/internal/engine"
+	"web3-indexer-go/internal/testkit"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/jmoiron/sqlx"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // Required for sqlx to recognize "pgx" driver
+)
+
+// stressChainID 压测专用链 ID（非 Anvil，保证 reorg 父哈希校验路径同样被计入开销）
+const stressChainID = 1337
+
+// drainTimeout 注入结束后等待 AsyncWriter 追平的最长时间
+const drainTimeout = 60 * time.Second
+
+type persistOptions struct {
+	DSN          string
+	Duration     time.Duration
+	Rate         int // 目标区块/秒，0 表示不限速
+	LogsPerBlock int
+	Reset        bool
+	StmtCache    bool // AsyncWriter 是否复用预编译 INSERT 语句（关闭即为对照组）
+}
+
+// commitTracker 记录每个区块的注入时刻，按 Orchestrator 落盘游标计算 checkpoint 延迟
+type commitTracker struct {
+	mu         sync.Mutex
+	injectedAt map[uint64]time.Time
+	latencies  []time.Duration
+	committed  uint64
+}
+
+func (c *commitTracker) inject(height uint64, at time.Time) {
+	c.mu.Lock()
+	c.injectedAt[height] = at
+	c.mu.Unlock()
+}
+
+func (c *commitTracker) advance(cursor uint64, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for h := c.committed + 1; h <= cursor; h++ {
+		if at, ok := c.injectedAt[h]; ok {
+			c.latencies = append(c.latencies, now.Sub(at))
+			delete(c.injectedAt, h)
+		}
+	}
+	if cursor > c.committed {
+		c.committed = cursor
+	}
+}
+
+func (c *commitTracker) percentile(p float64) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), c.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(float64(len(sorted)-1) * p)
+	return sorted[idx]
+}
+
+// runPersistProfile 端到端持久化压测：合成区块绕过 Fetcher 直接送入 Sequencer，
+// 经 Processor → Orchestrator → AsyncWriter 写入真实 Postgres，
+// 统计持续写入 TPS、WAL 增长与 checkpoint 延迟
+func runPersistProfile(opts persistOptions) error {
+	if opts.DSN == "" {
+		return errors.New("DATABASE_URL is required for the persist profile")
+	}
+	if opts.LogsPerBlock <= 0 {
+		opts.LogsPerBlock = 1
+	}
+
+	engine.InitLogger("error")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := sqlx.Connect("pgx", opts.DSN)
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer db.Close()
+
+	if err := database.InitSchema(ctx, db); err != nil {
+		return fmt.Errorf("init schema: %w", err)
+	}
+	if opts.Reset {
+		if _, err := db.ExecContext(ctx, "TRUNCATE blocks, transfers RESTART IDENTITY CASCADE"); err != nil {
+			return fmt.Errorf("reset: %w", err)
+		}
+		if _, err := db.ExecContext(ctx, "DELETE FROM sync_checkpoints WHERE chain_id = $1", stressChainID); err != nil {
+			return fmt.Errorf("reset checkpoint: %w", err)
+		}
+	}
+
+	var startHeight uint64
+	if err := db.GetContext(ctx, &startHeight, "SELECT COALESCE(MAX(number), 0)::BIGINT + 1 FROM blocks"); err != nil {
+		return fmt.Errorf("read start height: %w", err)
+	}
+
+	var walStart string
+	if err := db.GetContext(ctx, &walStart, "SELECT pg_current_wal_lsn()::text"); err != nil {
+		return fmt.Errorf("read wal lsn: %w", err)
+	}
+
+	// 🧱 组装真实持久化链路（无 Fetcher）
+	orchestrator := engine.GetOrchestrator()
+	orchestrator.ForceSetCursors(startHeight - 1)
+	engine.SetStatementCache(opts.StmtCache)
+	writer := engine.NewAsyncWriter(db, orchestrator, false, stressChainID)
+	orchestrator.SetAsyncWriter(writer)
+	writer.Start()
+
+	processor := engine.NewProcessor(db, nil, 100, stressChainID, false, "stress")
+	resultCh := make(chan engine.BlockData, 5000)
+	fatalErrCh := make(chan error, 100)
+	sequencer := engine.NewSequencer(processor, new(big.Int).SetUint64(startHeight), stressChainID, resultCh, fatalErrCh, engine.GetMetrics())
+	seqDone := make(chan struct{})
+	go func() {
+		defer close(seqDone)
+		sequencer.Run(ctx)
+	}()
+
+	tracker := &commitTracker{injectedAt: make(map[uint64]time.Time), committed: startHeight - 1}
+	monitorCtx, stopMonitor := context.WithCancel(ctx)
+	defer stopMonitor()
+	go monitorCommits(monitorCtx, orchestrator, tracker, startHeight)
+
+	fmt.Printf("⚡ Persist stress: start=%d duration=%v rate=%d blk/s logs/block=%d stmt-cache=%v\n",
+		startHeight, opts.Duration, opts.Rate, opts.LogsPerBlock, opts.StmtCache)
+
+	// 💉 注入合成区块
+	started := time.Now()
+	deadline := started.Add(opts.Duration)
+	var pace <-chan time.Time
+	if opts.Rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(opts.Rate))
+		defer ticker.Stop()
+		pace = ticker.C
+	}
+
+	// 续接已有数据时父哈希必须与库中前一块一致，否则首块即触发 reorg 检测
+	parent := common.HexToHash("0x0")
+	var prevHash string
+	if err := db.GetContext(ctx, &prevHash, "SELECT hash FROM blocks WHERE number = $1", startHeight-1); err == nil {
+		parent = common.HexToHash(prevHash)
+	}
+	height := startHeight
+	for time.Now().Before(deadline) {
+		if pace != nil {
+			<-pace
+		}
+		block := buildStressBlock(height, parent, started)
+		data := engine.BlockData{
+			Number: new(big.Int).SetUint64(height),
+			Block:  block,
+			Logs:   testkit.TransferLogs(block, testkit.USDC, opts.LogsPerBlock),
+		}
+		tracker.inject(height, time.Now())
+		select {
+		case resultCh <- data:
+		case err := <-fatalErrCh:
+			return fmt.Errorf("sequencer failed at block %d: %w", height, err)
+		}
+		parent = block.Hash()
+		height++
+	}
+	lastInjected := height - 1
+	injectElapsed := time.Since(started)
+
+	// ⏳ 等待落盘追平
+	drainDeadline := time.Now().Add(drainTimeout)
+	for orchestrator.GetSnapshot().SyncedCursor < lastInjected && time.Now().Before(drainDeadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	tracker.advance(orchestrator.GetSnapshot().SyncedCursor, time.Now())
+	totalElapsed := time.Since(started)
+
+	cancel()
+	<-seqDone
+	stmtStats := writer.StatementCacheStats()
+	orchestrator.Shutdown()
+
+	// 📊 汇总
+	reportCtx := context.Background()
+	var persistedBlocks, persistedTransfers int64
+	if err := db.GetContext(reportCtx, &persistedBlocks, "SELECT COUNT(*) FROM blocks WHERE number >= $1", startHeight); err != nil {
+		return fmt.Errorf("count blocks: %w", err)
+	}
+	if err := db.GetContext(reportCtx, &persistedTransfers, "SELECT COUNT(*) FROM transfers WHERE block_number >= $1", startHeight); err != nil {
+		return fmt.Errorf("count transfers: %w", err)
+	}
+	var walBytes int64
+	if err := db.GetContext(reportCtx, &walBytes, "SELECT pg_wal_lsn_diff(pg_current_wal_lsn(), $1::pg_lsn)::BIGINT", walStart); err != nil {
+		return fmt.Errorf("wal diff: %w", err)
+	}
+
+	injected := lastInjected - startHeight + 1
+	fmt.Printf("🏁 Persist Stress Completed!\n")
+	fmt.Printf("   Blocks injected:      %d (%.1f blk/s over %v)\n", injected, float64(injected)/injectElapsed.Seconds(), injectElapsed.Round(time.Millisecond))
+	fmt.Printf("   Blocks persisted:     %d\n", persistedBlocks)
+	fmt.Printf("   Transfers persisted:  %d\n", persistedTransfers)
+	fmt.Printf("   Sustained insert TPS: %.2f (incl. drain, %v)\n", float64(persistedTransfers)/totalElapsed.Seconds(), totalElapsed.Round(time.Millisecond))
+	fmt.Printf("   WAL growth:           %.2f MiB", float64(walBytes)/(1<<20))
+	if persistedTransfers > 0 {
+		fmt.Printf(" (%.0f bytes/transfer)", float64(walBytes)/float64(persistedTransfers))
+	}
+	fmt.Println()
+	fmt.Printf("   Checkpoint latency:   p50=%v p95=%v p99=%v max=%v\n",
+		tracker.percentile(0.50).Round(time.Millisecond),
+		tracker.percentile(0.95).Round(time.Millisecond),
+		tracker.percentile(0.99).Round(time.Millisecond),
+		tracker.percentile(1.0).Round(time.Millisecond))
+	if opts.StmtCache {
+		fmt.Printf("   Prepared statements:  %d cached, %d prepares, %d reuses\n", stmtStats.Statements, stmtStats.Prepares, stmtStats.Hits)
+	} else {
+		fmt.Printf("   Prepared statements:  disabled (baseline)\n")
+	}
+
+	if uint64(persistedBlocks) < injected {
+		return fmt.Errorf("only %d/%d blocks persisted within drain timeout", persistedBlocks, injected)
+	}
+	return nil
+}
+
+// monitorCommits 轮询落盘游标并打印实时进度
+func monitorCommits(ctx context.Context, o *engine.Orchestrator, tracker *commitTracker, startHeight uint64) {
+	poll := time.NewTicker(100 * time.Millisecond)
+	defer poll.Stop()
+	report := time.NewTicker(time.Second)
+	defer report.Stop()
+	started := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-poll.C:
+			tracker.advance(o.GetSnapshot().SyncedCursor, now)
+		case <-report.C:
+			cursor := o.GetSnapshot().SyncedCursor
+			committed := uint64(0)
+			if cursor >= startHeight {
+				committed = cursor - startHeight + 1
+			}
+			fmt.Printf("📊 committed=%d blocks (%.1f blk/s) checkpoint p95=%v\n",
+				committed, float64(committed)/time.Since(started).Seconds(),
+				tracker.percentile(0.95).Round(time.Millisecond))
+		}
+	}
+}
+
+// buildStressBlock 以 testkit 区块头为基础，时间戳锚定在压测开始时刻（E2E 延迟指标保持真实量级）
+func buildStressBlock(height uint64, parent common.Hash, base time.Time) *types.Block {
+	h := testkit.Header(height, parent)
+	// #nosec G115 - Stress test time conversion
+	h.Time = uint64(base.Unix()) + height
+	return types.NewBlockWithHeader(h).WithBody(types.Body{})
+}